@@ -2,7 +2,9 @@ package analysis
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -11,10 +13,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/argoproj/argo-rollouts/metricproviders"
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	templateutil "github.com/argoproj/argo-rollouts/utils/template"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
 )
 
 const (
@@ -27,6 +32,15 @@ const (
 	// DefaultErrorRetryInterval is the default interval to retry a measurement upon error, in the
 	// event an interval was not specified
 	DefaultErrorRetryInterval time.Duration = 10 * time.Second
+	// DefaultRetryMultiplier is the default factor the delay between retries grows by, when a metric
+	// has a retry policy
+	DefaultRetryMultiplier int32 = 2
+	// DefaultRetryMaxAttempts is the default number of times resuming an in-flight measurement is
+	// attempted, when a metric has a retry policy
+	DefaultRetryMaxAttempts int32 = 3
+	// DefaultMaxConcurrentMeasurements is the default number of measurements of a single AnalysisRun
+	// which are taken at the same time
+	DefaultMaxConcurrentMeasurements = 10
 )
 
 // Event reasons for analysis events
@@ -44,6 +58,7 @@ type metricTask struct {
 
 func (c *AnalysisController) reconcileAnalysisRun(origRun *v1alpha1.AnalysisRun) *v1alpha1.AnalysisRun {
 	if origRun.Status.Phase.Completed() {
+		scheduler.forgetRun(runKey(origRun))
 		return origRun
 	}
 	log := logutil.WithAnalysisRun(origRun)
@@ -143,8 +158,7 @@ func generateMetricTasks(run *v1alpha1.AnalysisRun) []metricTask {
 		logCtx := log.WithField("metric", metric.Name)
 		lastMeasurement := analysisutil.LastMeasurement(run, metric.Name)
 		if lastMeasurement != nil && lastMeasurement.FinishedAt == nil {
-			now := metav1.Now()
-			if lastMeasurement.ResumeAt != nil && lastMeasurement.ResumeAt.After(now.Time) {
+			if lastMeasurement.ResumeAt != nil && !scheduler.isDue(scheduler.resumeTime(run, metric.Name, lastMeasurement)) {
 				continue
 			}
 			// last measurement is still in-progress. need to complete it
@@ -169,7 +183,7 @@ func generateMetricTasks(run *v1alpha1.AnalysisRun) []metricTask {
 					logCtx.Warnf("failed to parse duration: %v", err)
 					continue
 				}
-				if run.Status.StartedAt.Add(duration).After(time.Now()) {
+				if !scheduler.isDue(scheduler.dueTime(run, metric.Name, run.Status.StartedAt.Time, duration)) {
 					logCtx.Infof("waiting until start delay duration passes")
 					continue
 				}
@@ -189,7 +203,14 @@ func generateMetricTasks(run *v1alpha1.AnalysisRun) []metricTask {
 		// to decide if it should be taken now. metric.Interval can be null because we may be
 		// retrying a metric due to error.
 		interval := DefaultErrorRetryInterval
-		if metric.Interval != "" {
+		if lastMeasurement.Phase == v1alpha1.AnalysisPhaseError && metric.RetryPolicy != nil {
+			retryInterval, err := retryDelay(metric, metricResult.ConsecutiveError)
+			if err != nil {
+				logCtx.Warnf("failed to parse retry interval: %v", err)
+				continue
+			}
+			interval = retryInterval
+		} else if metric.Interval != "" {
 			metricInterval, err := metric.Interval.Duration()
 			if err != nil {
 				logCtx.Warnf("failed to parse interval: %v", err)
@@ -197,7 +218,7 @@ func generateMetricTasks(run *v1alpha1.AnalysisRun) []metricTask {
 			}
 			interval = metricInterval
 		}
-		if time.Now().After(lastMeasurement.FinishedAt.Add(interval)) {
+		if scheduler.isDue(scheduler.dueTime(run, metric.Name, lastMeasurement.FinishedAt.Time, interval)) {
 			tasks = append(tasks, metricTask{metric: metric})
 			logCtx.Infof("running overdue measurement")
 			continue
@@ -277,11 +298,16 @@ func (c *AnalysisController) runMeasurements(run *v1alpha1.AnalysisRun, tasks []
 		return err
 	}
 
+	// metrics are measured in parallel, bounded so a run with many metrics does not open an
+	// unbounded number of connections to the metric providers
+	workers := make(chan struct{}, maxConcurrentMeasurements())
 	for _, task := range tasks {
 		wg.Add(1)
 
 		go func(t metricTask) {
 			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
 			//redact secret values from logs
 			log := logutil.WithRedactor(*logutil.WithAnalysisRun(run).WithField("metric", t.metric.Name), secrets)
 
@@ -295,9 +321,10 @@ func (c *AnalysisController) runMeasurements(run *v1alpha1.AnalysisRun, tasks []
 					Phase: v1alpha1.AnalysisPhaseRunning,
 				}
 			}
+			metricResult.DryRun = t.metric.DryRun
 
 			var newMeasurement v1alpha1.Measurement
-			provider, err := c.newProvider(*log, t.metric)
+			provider, err := c.newProvider(*log, run.Namespace, t.metric)
 			if err != nil {
 				if t.incompleteMeasurement != nil {
 					newMeasurement = *t.incompleteMeasurement
@@ -308,24 +335,51 @@ func (c *AnalysisController) runMeasurements(run *v1alpha1.AnalysisRun, tasks []
 				newMeasurement.Phase = v1alpha1.AnalysisPhaseError
 				newMeasurement.Message = err.Error()
 			} else {
+				span := tracing.StartSpan(logutil.AnalysisRunKey, run.Namespace, run.Name, "metric provider "+provider.Type())
+				span.SetAttribute("metric", t.metric.Name)
+				operation := "run"
+				startTime := time.Now()
 				if t.incompleteMeasurement == nil {
 					newMeasurement = provider.Run(run, t.metric)
 				} else {
 					// metric is incomplete. either terminate or resume it
 					if terminating {
 						log.Infof("terminating in-progress measurement")
+						operation = "terminate"
 						newMeasurement = provider.Terminate(run, t.metric, *t.incompleteMeasurement)
 						if newMeasurement.Phase == v1alpha1.AnalysisPhaseSuccessful {
 							newMeasurement.Message = "metric terminated"
 						}
 					} else {
+						operation = "resume"
 						newMeasurement = provider.Resume(run, t.metric, *t.incompleteMeasurement)
 					}
 				}
+				var measurementErr error
+				if newMeasurement.Phase == v1alpha1.AnalysisPhaseError {
+					measurementErr = errors.New(newMeasurement.Message)
+				}
+				c.metricsServer.ObserveMetricProvider(run.Namespace, owningRollout(run), provider.Type(), operation, time.Since(startTime), measurementErr != nil)
+				span.End(measurementErr)
+			}
+
+			if t.incompleteMeasurement != nil && !terminating && newMeasurement.Phase == v1alpha1.AnalysisPhaseError {
+				// the measurement is kept in-flight and resumed again after the backoff of the
+				// retry policy, rather than the provider retrying before it returns
+				if delay, retry := resumeRetryDelay(t.metric, t.incompleteMeasurement.RetryCount); retry {
+					log.Warnf("resuming measurement had error, retrying in %v: %s", delay, newMeasurement.Message)
+					message := newMeasurement.Message
+					newMeasurement = *t.incompleteMeasurement.DeepCopy()
+					newMeasurement.Message = message
+					newMeasurement.RetryCount++
+					resumeAt := metav1.NewTime(time.Now().Add(delay))
+					newMeasurement.ResumeAt = &resumeAt
+				}
 			}
 
 			if newMeasurement.Phase.Completed() {
 				log.Infof("measurement completed %s", newMeasurement.Phase)
+				c.metricsServer.IncMeasurement(run.Namespace, owningRollout(run), metricproviders.Type(t.metric), newMeasurement.Phase)
 				if newMeasurement.FinishedAt == nil {
 					finishedAt := metav1.Now()
 					newMeasurement.FinishedAt = &finishedAt
@@ -374,13 +428,69 @@ func (c *AnalysisController) runMeasurements(run *v1alpha1.AnalysisRun, tasks []
 	return nil
 }
 
+// retryDelay returns how long to wait before the next attempt of a measurement of the metric, after
+// the given number of attempts errored in a row, following the retry policy of the metric
+func retryDelay(metric v1alpha1.Metric, failures int32) (time.Duration, error) {
+	policy := metric.RetryPolicy
+	delay := DefaultErrorRetryInterval
+	if policy.InitialInterval != "" {
+		initialInterval, err := policy.InitialInterval.Duration()
+		if err != nil {
+			return 0, err
+		}
+		delay = initialInterval
+	}
+	multiplier := DefaultRetryMultiplier
+	if policy.Multiplier > 0 {
+		multiplier = policy.Multiplier
+	}
+	for i := int32(1); i < failures && multiplier > 1; i++ {
+		if delay > math.MaxInt64/time.Duration(multiplier) {
+			return math.MaxInt64, nil
+		}
+		delay *= time.Duration(multiplier)
+	}
+	return delay, nil
+}
+
+// resumeRetryDelay returns how long to wait before resuming an in-flight measurement again, after
+// resuming it errored, and false if the metric has no retry policy or the attempts are exhausted
+func resumeRetryDelay(metric v1alpha1.Metric, retryCount int32) (time.Duration, bool) {
+	if metric.RetryPolicy == nil {
+		return 0, false
+	}
+	maxAttempts := DefaultRetryMaxAttempts
+	if metric.RetryPolicy.MaxAttempts > 0 {
+		maxAttempts = metric.RetryPolicy.MaxAttempts
+	}
+	if retryCount+1 >= maxAttempts {
+		return 0, false
+	}
+	delay, err := retryDelay(metric, retryCount+1)
+	if err != nil {
+		return 0, false
+	}
+	return delay, true
+}
+
+// maxConcurrentMeasurements returns the number of measurements of a run taken at the same time
+func maxConcurrentMeasurements() int {
+	limit := configutil.Get().GetInt(configutil.MaxConcurrentMeasurementsKey, DefaultMaxConcurrentMeasurements)
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
+
 // asssessRunStatus assesses the overall status of this AnalysisRun
 // If any metric is not yet completed, the AnalysisRun is still considered Running
-// Once all metrics are complete, the worst status is used as the overall AnalysisRun status
+// Once all metrics are complete, the worst status is used as the overall AnalysisRun status. The
+// status of dry-run metrics is ignored
 func (c *AnalysisController) asssessRunStatus(run *v1alpha1.AnalysisRun) v1alpha1.AnalysisPhase {
 	var worstStatus v1alpha1.AnalysisPhase
 	terminating := analysisutil.IsTerminating(run)
 	everythingCompleted := true
+	dryRunCompleted := false
 
 	if run.Status.StartedAt == nil {
 		now := metav1.Now()
@@ -411,6 +521,12 @@ func (c *AnalysisController) asssessRunStatus(run *v1alpha1.AnalysisRun) v1alpha
 			if !metricStatus.Completed() {
 				// if any metric is in-progress, then entire analysis run will be considered running
 				everythingCompleted = false
+			} else if metric.DryRun {
+				// the result of a dry-run metric is recorded, but does not affect the run
+				if metricStatus != v1alpha1.AnalysisPhaseSuccessful {
+					log.Infof("dry-run metric completed %s, ignoring its result", metricStatus)
+				}
+				dryRunCompleted = true
 			} else {
 				// otherwise, remember the worst status of all completed metric results
 				if worstStatus == "" {
@@ -423,6 +539,10 @@ func (c *AnalysisController) asssessRunStatus(run *v1alpha1.AnalysisRun) v1alpha
 			}
 		}
 	}
+	if everythingCompleted && worstStatus == "" && dryRunCompleted {
+		// every metric of the run is a dry-run metric
+		return v1alpha1.AnalysisPhaseSuccessful
+	}
 	if !everythingCompleted || worstStatus == "" {
 		return v1alpha1.AnalysisPhaseRunning
 	}
@@ -505,7 +625,7 @@ func calculateNextReconcileTime(run *v1alpha1.AnalysisRun) *time.Time {
 					logCtx.Warnf("failed to parse interval: %v", err)
 					continue
 				}
-				endInitialDelay := startTime.Add(duration)
+				endInitialDelay := scheduler.dueTime(run, metric.Name, startTime.Time, duration)
 				if reconcileTime == nil || reconcileTime.After(endInitialDelay) {
 					reconcileTime = &endInitialDelay
 				}
@@ -518,8 +638,9 @@ func calculateNextReconcileTime(run *v1alpha1.AnalysisRun) *time.Time {
 		if lastMeasurement.FinishedAt == nil {
 			// unfinished in-flight measurement.
 			if lastMeasurement.ResumeAt != nil {
-				if reconcileTime == nil || reconcileTime.After(lastMeasurement.ResumeAt.Time) {
-					reconcileTime = &lastMeasurement.ResumeAt.Time
+				resumeTime := scheduler.resumeTime(run, metric.Name, lastMeasurement)
+				if reconcileTime == nil || reconcileTime.After(resumeTime) {
+					reconcileTime = &resumeTime
 				}
 			}
 			continue
@@ -531,7 +652,14 @@ func calculateNextReconcileTime(run *v1alpha1.AnalysisRun) *time.Time {
 			continue
 		}
 		var interval time.Duration
-		if metric.Interval != "" {
+		if lastMeasurement.Phase == v1alpha1.AnalysisPhaseError && metric.RetryPolicy != nil {
+			retryInterval, err := retryDelay(metric, metricResult.ConsecutiveError)
+			if err != nil {
+				logCtx.Warnf("failed to parse retry interval: %v", err)
+				continue
+			}
+			interval = retryInterval
+		} else if metric.Interval != "" {
 			metricInterval, err := metric.Interval.Duration()
 			if err != nil {
 				logCtx.Warnf("failed to parse interval: %v", err)
@@ -549,7 +677,7 @@ func calculateNextReconcileTime(run *v1alpha1.AnalysisRun) *time.Time {
 			continue
 		}
 		// Take the earliest time of all metrics
-		metricReconcileTime := lastMeasurement.FinishedAt.Add(interval)
+		metricReconcileTime := scheduler.dueTime(run, metric.Name, lastMeasurement.FinishedAt.Time, interval)
 		if reconcileTime == nil || reconcileTime.After(metricReconcileTime) {
 			reconcileTime = &metricReconcileTime
 		}
@@ -574,7 +702,7 @@ func (c *AnalysisController) garbageCollectMeasurements(run *v1alpha1.AnalysisRu
 				continue
 			}
 			log := logutil.WithAnalysisRun(run).WithField("metric", metric.Name)
-			provider, err := c.newProvider(*log, metric)
+			provider, err := c.newProvider(*log, run.Namespace, metric)
 			if err != nil {
 				errors = append(errors, err)
 				continue
@@ -592,3 +720,13 @@ func (c *AnalysisController) garbageCollectMeasurements(run *v1alpha1.AnalysisRu
 	}
 	return nil
 }
+
+// owningRollout returns the name of the rollout controlling the AnalysisRun, or an empty string if
+// the run was created by an experiment or by hand
+func owningRollout(run *v1alpha1.AnalysisRun) string {
+	ownerRef := metav1.GetControllerOf(run)
+	if ownerRef == nil || ownerRef.Kind != "Rollout" {
+		return ""
+	}
+	return ownerRef.Name
+}
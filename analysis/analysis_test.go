@@ -3,7 +3,9 @@ package analysis
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,8 +14,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 )
 
 func timePtr(t metav1.Time) *metav1.Time {
@@ -318,6 +322,45 @@ func TestGenerateMetricTasksHonorInitialDelay(t *testing.T) {
 	}
 }
 
+func TestGenerateMetricTasksHonorRetryPolicy(t *testing.T) {
+	nowMinus30 := metav1.NewTime(metav1.Now().Add(-30 * time.Second))
+	run := &v1alpha1.AnalysisRun{
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: []v1alpha1.Metric{{
+				Name:        "success-rate",
+				Interval:    "20s",
+				RetryPolicy: &v1alpha1.MetricRetryPolicy{InitialInterval: "10s"},
+			}},
+		},
+		Status: v1alpha1.AnalysisRunStatus{
+			Phase: v1alpha1.AnalysisPhaseRunning,
+			MetricResults: []v1alpha1.MetricResult{{
+				Name:             "success-rate",
+				Phase:            v1alpha1.AnalysisPhaseRunning,
+				ConsecutiveError: 2,
+				Measurements: []v1alpha1.Measurement{{
+					Phase:      v1alpha1.AnalysisPhaseError,
+					StartedAt:  &nowMinus30,
+					FinishedAt: &nowMinus30,
+				}},
+			}},
+		},
+	}
+	{
+		// the second retry waits for twice the initial interval, sooner than the metric interval
+		assert.Equal(t, 1, len(generateMetricTasks(run)))
+		reconcileTime := calculateNextReconcileTime(run)
+		assert.NotNil(t, reconcileTime)
+		assert.Equal(t, nowMinus30.Add(20*time.Second), *reconcileTime)
+	}
+	{
+		// the third retry waits for four times the initial interval
+		run.Status.MetricResults[0].ConsecutiveError = 3
+		assert.Equal(t, 0, len(generateMetricTasks(run)))
+		assert.Equal(t, nowMinus30.Add(40*time.Second), *calculateNextReconcileTime(run))
+	}
+}
+
 func TestGenerateMetricTasksHonorResumeAt(t *testing.T) {
 	now := metav1.Now()
 	nowMinus50 := metav1.NewTime(now.Add(-50 * time.Second))
@@ -445,6 +488,59 @@ func TestAssessRunStatus(t *testing.T) {
 	}
 }
 
+func TestAssessRunStatusDryRun(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	run := &v1alpha1.AnalysisRun{
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name: "latency",
+				},
+				{
+					Name:   "success-rate",
+					DryRun: true,
+				},
+			},
+		},
+	}
+	{
+		// ensure the failure of a dry-run metric is ignored
+		run.Status = v1alpha1.AnalysisRunStatus{
+			Phase: v1alpha1.AnalysisPhaseRunning,
+			MetricResults: []v1alpha1.MetricResult{
+				{
+					Name:  "latency",
+					Phase: v1alpha1.AnalysisPhaseSuccessful,
+				},
+				{
+					Name:   "success-rate",
+					Phase:  v1alpha1.AnalysisPhaseFailed,
+					DryRun: true,
+				},
+			},
+		}
+		assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, c.asssessRunStatus(run))
+	}
+	{
+		// ensure the run waits for dry-run metrics to complete
+		run.Status.MetricResults[1].Phase = v1alpha1.AnalysisPhaseRunning
+		assert.Equal(t, v1alpha1.AnalysisPhaseRunning, c.asssessRunStatus(run))
+	}
+	{
+		// ensure a run of dry-run metrics only is successful
+		run.Spec.Metrics = run.Spec.Metrics[1:]
+		run.Status.MetricResults = []v1alpha1.MetricResult{{
+			Name:   "success-rate",
+			Phase:  v1alpha1.AnalysisPhaseError,
+			DryRun: true,
+		}}
+		assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, c.asssessRunStatus(run))
+		assert.Equal(t, v1alpha1.AnalysisPhaseError, run.Status.MetricResults[0].Phase)
+	}
+}
+
 // TestAssessRunStatusUpdateResult ensures we update the metricresult status properly
 // based on latest measurements
 func TestAssessRunStatusUpdateResult(t *testing.T) {
@@ -597,6 +693,12 @@ func TestAssessMetricStatusConsecutiveErrors(t *testing.T) {
 	result.ConsecutiveError = 4
 	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, assessMetricStatus(metric, result, true))
 	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, assessMetricStatus(metric, result, false))
+	metric.ConsecutiveErrorLimit = pointer.Int32Ptr(10)
+	result.ConsecutiveError = 10
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, assessMetricStatus(metric, result, false))
+	metric.ConsecutiveErrorLimit = pointer.Int32Ptr(0)
+	result.ConsecutiveError = 1
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, assessMetricStatus(metric, result, false))
 }
 
 func TestAssessMetricStatusCountReached(t *testing.T) {
@@ -955,6 +1057,91 @@ func TestReconcileAnalysisRunResumeInProgress(t *testing.T) {
 	assert.NotNil(t, newRun.Status.MetricResults[0].Measurements[0].FinishedAt)
 }
 
+func TestReconcileAnalysisRunRetryResume(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	run := v1alpha1.AnalysisRun{
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: []v1alpha1.Metric{{
+				Name:        "test",
+				RetryPolicy: &v1alpha1.MetricRetryPolicy{InitialInterval: "30s", MaxAttempts: 2},
+				Provider: v1alpha1.MetricProvider{
+					Job: &v1alpha1.JobMetric{},
+				},
+			}},
+		},
+		Status: v1alpha1.AnalysisRunStatus{
+			Phase: v1alpha1.AnalysisPhaseRunning,
+			MetricResults: []v1alpha1.MetricResult{{
+				Name:  "test",
+				Phase: v1alpha1.AnalysisPhaseRunning,
+				Measurements: []v1alpha1.Measurement{{
+					Phase:     v1alpha1.AnalysisPhaseRunning,
+					StartedAt: timePtr(metav1.NewTime(time.Now().Add(-60 * time.Second))),
+				}},
+			}},
+		},
+	}
+
+	errored := newMeasurement(v1alpha1.AnalysisPhaseError)
+	errored.Message = "connection refused"
+	f.provider.On("Resume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errored, nil)
+
+	// the measurement is resumed again after the initial interval
+	newRun := c.reconcileAnalysisRun(&run)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, newRun.Status.Phase)
+	measurement := newRun.Status.MetricResults[0].Measurements[0]
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Equal(t, "connection refused", measurement.Message)
+	assert.Equal(t, int32(1), measurement.RetryCount)
+	assert.Nil(t, measurement.FinishedAt)
+	assert.NotNil(t, measurement.ResumeAt)
+	assert.InDelta(t, 30, time.Until(measurement.ResumeAt.Time).Seconds(), 5)
+	assert.Equal(t, int32(0), newRun.Status.MetricResults[0].ConsecutiveError)
+
+	// the measurement errors once the attempts are exhausted
+	newRun.Status.MetricResults[0].Measurements[0].ResumeAt = timePtr(metav1.NewTime(time.Now().Add(-1 * time.Second)))
+	newRun = c.reconcileAnalysisRun(newRun)
+	measurement = newRun.Status.MetricResults[0].Measurements[0]
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, int32(1), newRun.Status.MetricResults[0].ConsecutiveError)
+}
+
+func TestRetryDelay(t *testing.T) {
+	metric := v1alpha1.Metric{RetryPolicy: &v1alpha1.MetricRetryPolicy{}}
+	for failures, expected := range []time.Duration{10 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		delay, err := retryDelay(metric, int32(failures))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, delay)
+	}
+
+	metric.RetryPolicy = &v1alpha1.MetricRetryPolicy{InitialInterval: "1m", Multiplier: 1}
+	delay, err := retryDelay(metric, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, delay)
+
+	metric.RetryPolicy = &v1alpha1.MetricRetryPolicy{InitialInterval: "1h", Multiplier: 10}
+	delay, err = retryDelay(metric, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(math.MaxInt64), delay)
+
+	metric.RetryPolicy = &v1alpha1.MetricRetryPolicy{InitialInterval: "invalid"}
+	_, err = retryDelay(metric, 1)
+	assert.Error(t, err)
+
+	_, retry := resumeRetryDelay(v1alpha1.Metric{}, 0)
+	assert.False(t, retry)
+	metric.RetryPolicy = &v1alpha1.MetricRetryPolicy{}
+	delay, retry = resumeRetryDelay(metric, 1)
+	assert.True(t, retry)
+	assert.Equal(t, 20*time.Second, delay)
+	_, retry = resumeRetryDelay(metric, 2)
+	assert.False(t, retry)
+}
+
 // TestRunMeasurementsResetConsecutiveErrorCounter verifies we reset the metric consecutiveError counter
 // when metric measures success, failed, or inconclusive.
 func TestRunMeasurementsResetConsecutiveErrorCounter(t *testing.T) {
@@ -1380,3 +1567,44 @@ func TestKeyNotInSecret(t *testing.T) {
 	_, _, err := c.resolveArgs(tasks, args, metav1.NamespaceDefault)
 	assert.Equal(t, "key 'key-name' does not exist in secret 'secret-name'", err.Error())
 }
+
+func TestRunMeasurementsConcurrencyLimit(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	configutil.SetDefaults(map[string]string{configutil.MaxConcurrentMeasurementsKey: "2"})
+	defer configutil.SetDefaults(nil)
+
+	var runMetrics []v1alpha1.Metric
+	for i := 0; i < 5; i++ {
+		runMetrics = append(runMetrics, v1alpha1.Metric{
+			Name: fmt.Sprintf("metric-%d", i),
+			Provider: v1alpha1.MetricProvider{
+				Prometheus: &v1alpha1.PrometheusMetric{},
+			},
+		})
+	}
+	run := &v1alpha1.AnalysisRun{
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: runMetrics,
+		},
+	}
+	var lock sync.Mutex
+	active, maxActive := 0, 0
+	f.provider.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(newMeasurement(v1alpha1.AnalysisPhaseSuccessful), nil).Run(func(args mock.Arguments) {
+		lock.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		lock.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		lock.Lock()
+		active--
+		lock.Unlock()
+	})
+	newRun := c.reconcileAnalysisRun(run)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, newRun.Status.Phase)
+	assert.Len(t, newRun.Status.MetricResults, 5)
+	assert.True(t, maxActive <= 2, "at most 2 measurements run at the same time")
+}
@@ -6,7 +6,6 @@ import (
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/util/wait"
 	batchinformers "k8s.io/client-go/informers/batch/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -24,6 +23,7 @@ import (
 	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
 	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 // AnalysisController is the controller implementation for Analysis resources
@@ -35,6 +35,8 @@ type AnalysisController struct {
 
 	secretLister corelisters.SecretLister
 
+	secretGetter secretutil.Getter
+
 	analysisRunLister listers.AnalysisRunLister
 
 	analysisRunSynced cache.InformerSynced
@@ -43,7 +45,7 @@ type AnalysisController struct {
 
 	metricsServer *metrics.MetricsServer
 
-	newProvider func(logCtx log.Entry, metric v1alpha1.Metric) (metricproviders.Provider, error)
+	newProvider func(logCtx log.Entry, namespace string, metric v1alpha1.Metric) (metricproviders.Provider, error)
 
 	// used for unit testing
 	enqueueAnalysis      func(obj interface{})
@@ -59,6 +61,10 @@ type AnalysisController struct {
 	// Kubernetes API.
 	recorder     record.EventRecorder
 	resyncPeriod time.Duration
+	// resumeTimers tracks runs waiting in the workqueue for their next measurement to be due
+	resumeTimers *resumeTimers
+	// pendingReports tracks the running runs whose report is published once they complete
+	pendingReports *pendingReports
 }
 
 // NewAnalysisController returns a new analysis controller
@@ -84,18 +90,25 @@ func NewAnalysisController(
 		analysisRunSynced:    analysisRunInformer.Informer().HasSynced,
 		recorder:             recorder,
 		resyncPeriod:         resyncPeriod,
+		resumeTimers:         newResumeTimers(),
+		pendingReports:       newPendingReports(),
 	}
 
 	controller.enqueueAnalysis = func(obj interface{}) {
 		controllerutil.Enqueue(obj, analysisRunWorkQueue)
 	}
 	controller.enqueueAnalysisAfter = func(obj interface{}, duration time.Duration) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			controller.resumeTimers.schedule(key, duration)
+		}
 		controllerutil.EnqueueAfter(obj, duration, analysisRunWorkQueue)
 	}
 
+	controller.secretGetter = secretutil.NewGetter(secretInformer.Lister(), controller.kubeclientset)
 	providerFactory := metricproviders.ProviderFactory{
-		KubeClient: controller.kubeclientset,
-		JobLister:  jobInformer.Lister(),
+		KubeClient:   controller.kubeclientset,
+		JobLister:    jobInformer.Lister(),
+		SecretGetter: controller.secretGetter,
 	}
 	controller.newProvider = providerFactory.NewProvider
 
@@ -116,6 +129,9 @@ func NewAnalysisController(
 	analysisRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueAnalysis,
 		UpdateFunc: func(old, new interface{}) {
+			if controller.isResyncOfScheduledRun(old, new) {
+				return
+			}
 			controller.enqueueAnalysis(new)
 		},
 		DeleteFunc: controller.enqueueAnalysis,
@@ -124,15 +140,9 @@ func NewAnalysisController(
 }
 
 func (c *AnalysisController) Run(threadiness int, stopCh <-chan struct{}) error {
-	log.Info("Starting analysis workers")
-	for i := 0; i < threadiness; i++ {
-		go wait.Until(func() {
-			controllerutil.RunWorker(c.analysisRunWorkQueue, logutil.AnalysisRunKey, c.syncHandler, c.metricsServer)
-		}, time.Second, stopCh)
-	}
-	log.Infof("Started %d analysis workers", threadiness)
-	<-stopCh
-	log.Info("Shutting down analysis workers")
+	log.Infof("Starting %d analysis workers", threadiness)
+	controllerutil.RunWorkers(threadiness, c.analysisRunWorkQueue, logutil.AnalysisRunKey, c.syncHandler, c.metricsServer, stopCh)
+	log.Info("Shut down analysis workers")
 
 	return nil
 }
@@ -143,10 +153,13 @@ func (c *AnalysisController) syncHandler(key string) error {
 	if err != nil {
 		return err
 	}
-	log.WithField(logutil.AnalysisRunKey, name).WithField(logutil.NamespaceKey, namespace).Infof("Started syncing Analysis at (%v)", startTime)
+	c.resumeTimers.clear(key)
+	logutil.WithObject(logutil.AnalysisRunKey, namespace, name).Infof("Started syncing Analysis at (%v)", startTime)
 	run, err := c.analysisRunLister.AnalysisRuns(namespace).Get(name)
 	if k8serrors.IsNotFound(err) {
-		log.WithField(logutil.AnalysisRunKey, name).WithField(logutil.NamespaceKey, namespace).Info("Analysis has been deleted")
+		logutil.WithObject(logutil.AnalysisRunKey, namespace, name).Info("Analysis has been deleted")
+		c.pendingReports.remove(key)
+		scheduler.forgetRun(key)
 		return nil
 	}
 	if err != nil {
@@ -163,11 +176,39 @@ func (c *AnalysisController) syncHandler(key string) error {
 
 	if run.DeletionTimestamp != nil {
 		logutil.WithAnalysisRun(run).Info("No reconciliation as analysis marked for deletion")
+		scheduler.forgetRun(key)
 		return nil
 	}
 
+	if !run.Status.Phase.Completed() {
+		c.pendingReports.add(key)
+	}
 	newRun := c.reconcileAnalysisRun(run)
-	return c.persistAnalysisRunStatus(run, newRun.Status)
+	if err := c.persistAnalysisRunStatus(run, newRun.Status); err != nil {
+		return err
+	}
+	if newRun.Status.Phase.Completed() {
+		return c.publishReport(newRun)
+	}
+	return nil
+}
+
+// isResyncOfScheduledRun returns true if the update is a periodic resync of a run which is already
+// scheduled to be reconciled when its next measurement is due
+func (c *AnalysisController) isResyncOfScheduledRun(old, new interface{}) bool {
+	oldRun, ok := old.(*v1alpha1.AnalysisRun)
+	if !ok {
+		return false
+	}
+	newRun, ok := new.(*v1alpha1.AnalysisRun)
+	if !ok || oldRun.ResourceVersion != newRun.ResourceVersion {
+		return false
+	}
+	key, err := cache.MetaNamespaceKeyFunc(newRun)
+	if err != nil {
+		return false
+	}
+	return c.resumeTimers.pending(key)
 }
 
 func (c *AnalysisController) enqueueIfCompleted(obj interface{}) {
@@ -99,7 +99,7 @@ func (f *fixture) newController(resync resyncFunc) (*AnalysisController, informe
 		k8sI.Batch().V1().Jobs(),
 		resync(),
 		analysisRunWorkqueue,
-		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), &metrics.K8sRequestsCountProvider{}),
+		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), nil, &metrics.K8sRequestsCountProvider{}),
 		&record.FakeRecorder{})
 
 	c.enqueueAnalysis = func(obj interface{}) {
@@ -120,7 +120,7 @@ func (f *fixture) newController(resync resyncFunc) (*AnalysisController, informe
 		c.enqueueAnalysis(obj)
 	}
 	f.provider = &mocks.Provider{}
-	c.newProvider = func(logCtx log.Entry, metric v1alpha1.Metric) (metricproviders.Provider, error) {
+	c.newProvider = func(logCtx log.Entry, namespace string, metric v1alpha1.Metric) (metricproviders.Provider, error) {
 		return f.provider, nil
 	}
 
@@ -289,7 +289,14 @@ func TestNoReconcileForNotFoundAnalysisRun(t *testing.T) {
 			Namespace: metav1.NamespaceDefault,
 		},
 	}
+	// the measurement schedule of the deleted run is forgotten
+	reference := time.Now().Add(time.Hour)
+	scheduler.dueTime(ar, "success-rate", reference, time.Minute)
+	key := observedKey(ar, "success-rate", reference)
 	f.run(getKey(ar, t))
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	assert.NotContains(t, scheduler.observed, key)
 }
 
 func TestNoReconcileForAnalysisRunWithDeletionTimestamp(t *testing.T) {
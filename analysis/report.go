@@ -0,0 +1,370 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/metricproviders"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// ReportStoreConfigMap stores the reports in a ConfigMap next to the AnalysisRun
+	ReportStoreConfigMap = "configMap"
+	// ReportStoreHTTP uploads the reports to an object storage bucket which accepts PUT requests
+	ReportStoreHTTP = "http"
+
+	// ReportJSONKey is the key of the JSON report in the ConfigMap of a report
+	ReportJSONKey = "report.json"
+	// ReportMarkdownKey is the key of the markdown report in the ConfigMap of a report
+	ReportMarkdownKey = "report.md"
+
+	// ReportPublishedAnnotation records the time the report of a run was published, so it is
+	// published once and retried until it succeeds
+	ReportPublishedAnnotation = annotations.RolloutLabel + "/report-published"
+
+	// reportTokenKey is the key of the bearer token in the secret used to upload reports
+	reportTokenKey = "token"
+	// reportUploadTimeout bounds the time taken to upload a report
+	reportUploadTimeout = 30 * time.Second
+)
+
+var controllerKind = v1alpha1.SchemeGroupVersion.WithKind("AnalysisRun")
+
+// Report summarizes a completed AnalysisRun for release reviews and compliance records
+type Report struct {
+	Name        string         `json:"name"`
+	Namespace   string         `json:"namespace"`
+	Rollout     string         `json:"rollout,omitempty"`
+	Phase       string         `json:"phase"`
+	Message     string         `json:"message,omitempty"`
+	StartedAt   *metav1.Time   `json:"startedAt,omitempty"`
+	GeneratedAt metav1.Time    `json:"generatedAt"`
+	Args        []ReportArg    `json:"args,omitempty"`
+	Metrics     []MetricReport `json:"metrics"`
+}
+
+// ReportArg is an argument of the run. Arguments read from secrets are not included.
+type ReportArg struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MetricReport summarizes the measurements of a metric and the conditions they were judged by
+type MetricReport struct {
+	Name              string                 `json:"name"`
+	Provider          string                 `json:"provider"`
+	SuccessCondition  string                 `json:"successCondition,omitempty"`
+	FailureCondition  string                 `json:"failureCondition,omitempty"`
+	FailureLimit      int32                  `json:"failureLimit"`
+	InconclusiveLimit int32                  `json:"inconclusiveLimit"`
+	Phase             string                 `json:"phase"`
+	Message           string                 `json:"message,omitempty"`
+	Count             int32                  `json:"count"`
+	Successful        int32                  `json:"successful"`
+	Failed            int32                  `json:"failed"`
+	Inconclusive      int32                  `json:"inconclusive"`
+	Error             int32                  `json:"error"`
+	Measurements      []v1alpha1.Measurement `json:"measurements,omitempty"`
+}
+
+// NewReport returns the report of the run
+func NewReport(run *v1alpha1.AnalysisRun) Report {
+	report := Report{
+		Name:        run.Name,
+		Namespace:   run.Namespace,
+		Rollout:     owningRollout(run),
+		Phase:       string(run.Status.Phase),
+		Message:     run.Status.Message,
+		StartedAt:   run.Status.StartedAt,
+		GeneratedAt: metav1.NewTime(time.Now()),
+		Metrics:     []MetricReport{},
+	}
+	for _, arg := range run.Spec.Args {
+		if arg.Value != nil {
+			report.Args = append(report.Args, ReportArg{Name: arg.Name, Value: *arg.Value})
+		}
+	}
+	for _, metric := range run.Spec.Metrics {
+		metricReport := MetricReport{
+			Name:              metric.Name,
+			Provider:          metricproviders.Type(metric),
+			SuccessCondition:  metric.SuccessCondition,
+			FailureCondition:  metric.FailureCondition,
+			FailureLimit:      metric.FailureLimit,
+			InconclusiveLimit: metric.InconclusiveLimit,
+			Phase:             string(v1alpha1.AnalysisPhasePending),
+		}
+		for _, result := range run.Status.MetricResults {
+			if result.Name != metric.Name {
+				continue
+			}
+			metricReport.Phase = string(result.Phase)
+			metricReport.Message = result.Message
+			metricReport.Count = result.Count
+			metricReport.Successful = result.Successful
+			metricReport.Failed = result.Failed
+			metricReport.Inconclusive = result.Inconclusive
+			metricReport.Error = result.Error
+			metricReport.Measurements = result.Measurements
+		}
+		report.Metrics = append(report.Metrics, metricReport)
+	}
+	return report
+}
+
+// JSON returns the indented JSON encoding of the report
+func (r Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown renders the report as a markdown document
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Analysis Report: %s\n\n", mdEscape(r.Name))
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Namespace | %s |\n", mdEscape(r.Namespace))
+	if r.Rollout != "" {
+		fmt.Fprintf(&b, "| Rollout | %s |\n", mdEscape(r.Rollout))
+	}
+	fmt.Fprintf(&b, "| Phase | %s |\n", mdEscape(r.Phase))
+	if r.Message != "" {
+		fmt.Fprintf(&b, "| Message | %s |\n", mdEscape(r.Message))
+	}
+	if r.StartedAt != nil {
+		fmt.Fprintf(&b, "| Started | %s |\n", r.StartedAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "| Generated | %s |\n", r.GeneratedAt.UTC().Format(time.RFC3339))
+
+	if len(r.Args) > 0 {
+		b.WriteString("\n## Arguments\n\n| Name | Value |\n|---|---|\n")
+		for _, arg := range r.Args {
+			fmt.Fprintf(&b, "| %s | %s |\n", mdEscape(arg.Name), mdEscape(arg.Value))
+		}
+	}
+
+	b.WriteString("\n## Metrics\n\n| Metric | Provider | Phase | Successful | Failed | Inconclusive | Error | Failure Limit | Inconclusive Limit |\n|---|---|---|---|---|---|---|---|---|\n")
+	for _, metric := range r.Metrics {
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %d | %d | %d | %d |\n", mdEscape(metric.Name), metric.Provider, metric.Phase,
+			metric.Successful, metric.Failed, metric.Inconclusive, metric.Error, metric.FailureLimit, metric.InconclusiveLimit)
+	}
+	for _, metric := range r.Metrics {
+		fmt.Fprintf(&b, "\n### %s\n\n", mdEscape(metric.Name))
+		if metric.SuccessCondition != "" {
+			fmt.Fprintf(&b, "Success condition: `%s`\n\n", metric.SuccessCondition)
+		}
+		if metric.FailureCondition != "" {
+			fmt.Fprintf(&b, "Failure condition: `%s`\n\n", metric.FailureCondition)
+		}
+		if metric.Message != "" {
+			fmt.Fprintf(&b, "Message: %s\n\n", mdEscape(metric.Message))
+		}
+		if len(metric.Measurements) == 0 {
+			b.WriteString("No measurements\n")
+			continue
+		}
+		b.WriteString("| Finished | Phase | Value | Message |\n|---|---|---|---|\n")
+		for _, measurement := range metric.Measurements {
+			finishedAt := ""
+			if measurement.FinishedAt != nil {
+				finishedAt = measurement.FinishedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", finishedAt, measurement.Phase, mdEscape(measurement.Value), mdEscape(measurement.Message))
+		}
+	}
+	return b.String()
+}
+
+// mdEscape escapes text so it can be placed in a markdown table cell
+func mdEscape(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+// pendingReports tracks the runs this controller reconciled before they completed, whose report is
+// published once they complete. Runs which completed before the controller started, or while the
+// reports were disabled, are not published.
+type pendingReports struct {
+	lock sync.Mutex
+	keys map[string]bool
+}
+
+func newPendingReports() *pendingReports {
+	return &pendingReports{
+		keys: make(map[string]bool),
+	}
+}
+
+// add records that the report of the run is published once it completes
+func (p *pendingReports) add(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.keys[key] = true
+}
+
+func (p *pendingReports) has(key string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.keys[key]
+}
+
+// remove forgets the run once its report is published, or will not be
+func (p *pendingReports) remove(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.keys, key)
+}
+
+// publishReport stores the report of a completed run in the store configured in the controller
+// configuration and marks the run with the ReportPublishedAnnotation. Only the runs the controller
+// observed before they completed, while the reports were enabled, are published. Failures are
+// recorded as events and returned so the run is requeued, but do not affect its outcome.
+func (c *AnalysisController) publishReport(run *v1alpha1.AnalysisRun) error {
+	key, err := cache.MetaNamespaceKeyFunc(run)
+	if err != nil {
+		return err
+	}
+	if !c.pendingReports.has(key) {
+		return nil
+	}
+	if !configutil.Get().GetBool(configutil.AnalysisReportsKey, false) {
+		c.pendingReports.remove(key)
+		return nil
+	}
+	if _, ok := run.Annotations[ReportPublishedAnnotation]; ok {
+		c.pendingReports.remove(key)
+		return nil
+	}
+	logCtx := logutil.WithAnalysisRun(run)
+	report := NewReport(run)
+	data, err := report.JSON()
+	if err == nil {
+		store := configutil.Get().GetString(configutil.AnalysisReportsStoreKey, ReportStoreConfigMap)
+		switch store {
+		case ReportStoreConfigMap:
+			err = c.storeReportConfigMap(run, data, report.Markdown())
+		case ReportStoreHTTP:
+			err = c.uploadReport(run, data, report.Markdown())
+		default:
+			err = fmt.Errorf("unsupported report store '%s'", store)
+		}
+	}
+	if err != nil {
+		logCtx.Warnf("Failed to publish report: %v", err)
+		c.recorder.Eventf(run, corev1.EventTypeWarning, "ReportFailed", "Failed to publish report: %v", err)
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				ReportPublishedAnnotation: report.GeneratedAt.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.argoProjClientset.ArgoprojV1alpha1().AnalysisRuns(run.Namespace).Patch(run.Name, patchtypes.MergePatchType, patch)
+	if err != nil {
+		logCtx.Warnf("Failed to mark report as published: %v", err)
+		return err
+	}
+	c.pendingReports.remove(key)
+	logCtx.Info("Published report")
+	c.recorder.Eventf(run, corev1.EventTypeNormal, "ReportPublished", "Published report of AnalysisRun '%s'", run.Name)
+	return nil
+}
+
+// reportConfigMapName returns the name of the ConfigMap holding the report of the run
+func reportConfigMapName(run *v1alpha1.AnalysisRun) string {
+	return run.Name + "-report"
+}
+
+// storeReportConfigMap stores the report in a ConfigMap owned by the run, so it is deleted with it
+func (c *AnalysisController) storeReportConfigMap(run *v1alpha1.AnalysisRun, data []byte, markdown string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            reportConfigMapName(run),
+			Namespace:       run.Namespace,
+			Labels:          run.Labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(run, controllerKind)},
+		},
+		Data: map[string]string{
+			ReportJSONKey:     string(data),
+			ReportMarkdownKey: markdown,
+		},
+	}
+	configMapIf := c.kubeclientset.CoreV1().ConfigMaps(run.Namespace)
+	_, err := configMapIf.Create(cm)
+	if !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := configMapIf.Get(cm.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing = existing.DeepCopy()
+	existing.Data = cm.Data
+	_, err = configMapIf.Update(existing)
+	return err
+}
+
+// uploadReport uploads the report to <url>/<namespace>/<name>.json and .md
+func (c *AnalysisController) uploadReport(run *v1alpha1.AnalysisRun, data []byte, markdown string) error {
+	url := strings.TrimSuffix(configutil.Get().GetString(configutil.AnalysisReportsHTTPURLKey, ""), "/")
+	if url == "" {
+		return fmt.Errorf("%s is not set", configutil.AnalysisReportsHTTPURLKey)
+	}
+	token := ""
+	if secretName := configutil.Get().GetString(configutil.AnalysisReportsHTTPTokenSecretKey, ""); secretName != "" {
+		secret, err := c.secretGetter.Get(defaults.Namespace(), secretName)
+		if err != nil {
+			return err
+		}
+		token = string(secret.Data[reportTokenKey])
+	}
+	client := &http.Client{Timeout: reportUploadTimeout}
+	prefix := fmt.Sprintf("%s/%s/%s", url, run.Namespace, run.Name)
+	uploads := []struct {
+		url         string
+		contentType string
+		body        []byte
+	}{
+		{prefix + ".json", "application/json", data},
+		{prefix + ".md", "text/markdown", []byte(markdown)},
+	}
+	for _, upload := range uploads {
+		req, err := http.NewRequest(http.MethodPut, upload.url, bytes.NewReader(upload.body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", upload.contentType)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("upload of %s returned %s", upload.url, resp.Status)
+		}
+	}
+	return nil
+}
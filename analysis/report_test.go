@@ -0,0 +1,237 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+func newCompletedRun() *v1alpha1.AnalysisRun {
+	run := newRun()
+	run.Name = "foo-abc123-1"
+	run.Namespace = metav1.NamespaceDefault
+	run.OwnerReferences = []metav1.OwnerReference{{Kind: "Rollout", Name: "foo", Controller: pointer.BoolPtr(true)}}
+	run.Spec.Args = []v1alpha1.Argument{
+		{Name: "service-name", Value: pointer.StringPtr("foo|svc")},
+		{Name: "token", ValueFrom: &v1alpha1.ValueFrom{SecretKeyRef: &v1alpha1.SecretKeyRef{Name: "token", Key: "token"}}},
+	}
+	run.Spec.Metrics[0].SuccessCondition = "result == 1"
+	run.Spec.Metrics[0].FailureLimit = 2
+	run.Status.Phase = v1alpha1.AnalysisPhaseFailed
+	run.Status.Message = "metric2 failed"
+	run.Status.MetricResults[0].Phase = v1alpha1.AnalysisPhaseSuccessful
+	run.Status.MetricResults[0].Count = 1
+	run.Status.MetricResults[0].Successful = 1
+	run.Status.MetricResults = run.Status.MetricResults[:1]
+	return run
+}
+
+func TestNewReport(t *testing.T) {
+	report := NewReport(newCompletedRun())
+	assert.Equal(t, "foo", report.Rollout)
+	assert.Equal(t, "Failed", report.Phase)
+	// arguments read from secrets are not included
+	assert.Equal(t, []ReportArg{{Name: "service-name", Value: "foo|svc"}}, report.Args)
+	assert.Len(t, report.Metrics, 2)
+	assert.Equal(t, "job", report.Metrics[0].Provider)
+	assert.Equal(t, "Successful", report.Metrics[0].Phase)
+	assert.Equal(t, int32(1), report.Metrics[0].Successful)
+	assert.Len(t, report.Metrics[0].Measurements, 1)
+	// metrics which were not measured are pending
+	assert.Equal(t, "Pending", report.Metrics[1].Phase)
+
+	data, err := report.JSON()
+	assert.NoError(t, err)
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, report.Metrics[0].SuccessCondition, decoded.Metrics[0].SuccessCondition)
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "# Analysis Report: foo-abc123-1")
+	assert.Contains(t, markdown, "| Rollout | foo |")
+	assert.Contains(t, markdown, `| service-name | foo\|svc |`)
+	assert.Contains(t, markdown, "| metric1 | job | Successful | 1 | 0 | 0 | 0 | 2 | 0 |")
+	assert.Contains(t, markdown, "Success condition: `result == 1`")
+	assert.Contains(t, markdown, "### metric2\n\nNo measurements")
+}
+
+func TestPublishReportConfigMap(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	run := newCompletedRun()
+	f.objects = append(f.objects, run)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	key := getKey(run, t)
+
+	// reports are not published unless enabled, even once enabled for a run completed before
+	c.pendingReports.add(key)
+	assert.NoError(t, c.publishReport(run))
+	assert.Empty(t, f.kubeclient.Actions())
+	assert.Empty(t, f.client.Actions())
+	configutil.SetDefaults(map[string]string{configutil.AnalysisReportsKey: "true"})
+	defer configutil.SetDefaults(nil)
+	assert.NoError(t, c.publishReport(run))
+	assert.Empty(t, f.kubeclient.Actions())
+
+	c.pendingReports.add(key)
+	assert.NoError(t, c.publishReport(run))
+	cm, err := f.kubeclient.CoreV1().ConfigMaps(run.Namespace).Get("foo-abc123-1-report", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AnalysisRun", cm.OwnerReferences[0].Kind)
+	assert.Contains(t, cm.Data[ReportJSONKey], `"phase": "Failed"`)
+	assert.Contains(t, cm.Data[ReportMarkdownKey], "| Phase | Failed |")
+
+	// the run is marked as published
+	published, err := f.client.ArgoprojV1alpha1().AnalysisRuns(run.Namespace).Get(run.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, published.Annotations[ReportPublishedAnnotation])
+
+	// the report of a run which was already published is not published again
+	actions := len(f.kubeclient.Actions())
+	c.pendingReports.add(key)
+	assert.NoError(t, c.publishReport(published))
+	assert.Len(t, f.kubeclient.Actions(), actions)
+
+	// the report of a run completed again replaces the previous report
+	run.Status.Message = "metric2 failed again"
+	c.pendingReports.add(key)
+	assert.NoError(t, c.publishReport(run))
+	cm, err = f.kubeclient.CoreV1().ConfigMaps(run.Namespace).Get("foo-abc123-1-report", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, cm.Data[ReportJSONKey], `"message": "metric2 failed again"`)
+}
+
+func TestPublishReportRetriesUntilPublished(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	f := newFixture(t)
+	defer f.Close()
+	run := newCompletedRun()
+	f.analysisRunLister = append(f.analysisRunLister, run)
+	f.objects = append(f.objects, run)
+	configutil.SetDefaults(map[string]string{
+		configutil.AnalysisReportsKey:        "true",
+		configutil.AnalysisReportsStoreKey:   ReportStoreHTTP,
+		configutil.AnalysisReportsHTTPURLKey: server.URL,
+	})
+	defer configutil.SetDefaults(nil)
+
+	// a failed upload is returned so the run is requeued
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	c.pendingReports.add(getKey(run, t))
+	assert.Error(t, c.syncHandler(getKey(run, t)))
+	unpublished, err := f.client.ArgoprojV1alpha1().AnalysisRuns(run.Namespace).Get(run.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, unpublished.Annotations[ReportPublishedAnnotation])
+
+	// the retry publishes the report and marks the run as published
+	assert.NoError(t, c.syncHandler(getKey(run, t)))
+	published, err := f.client.ArgoprojV1alpha1().AnalysisRuns(run.Namespace).Get(run.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, published.Annotations[ReportPublishedAnnotation])
+}
+
+func TestPublishReportOfRunCompletedBeforeControllerStarted(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	run := newCompletedRun()
+	f.analysisRunLister = append(f.analysisRunLister, run)
+	f.objects = append(f.objects, run)
+	configutil.SetDefaults(map[string]string{configutil.AnalysisReportsKey: "true"})
+	defer configutil.SetDefaults(nil)
+
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	assert.NoError(t, c.syncHandler(getKey(run, t)))
+	_, err := f.kubeclient.CoreV1().ConfigMaps(run.Namespace).Get("foo-abc123-1-report", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	unpublished, err := f.client.ArgoprojV1alpha1().AnalysisRuns(run.Namespace).Get(run.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, unpublished.Annotations[ReportPublishedAnnotation])
+}
+
+func TestPublishReportOfRunCompletedByController(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	run := &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-abc123-1", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: []v1alpha1.Metric{{
+				Name:     "success-rate",
+				Count:    1,
+				Provider: v1alpha1.MetricProvider{Prometheus: &v1alpha1.PrometheusMetric{}},
+			}},
+		},
+	}
+	f.analysisRunLister = append(f.analysisRunLister, run)
+	f.objects = append(f.objects, run)
+	configutil.SetDefaults(map[string]string{configutil.AnalysisReportsKey: "true"})
+	defer configutil.SetDefaults(nil)
+
+	// the run completes with its first measurement, and its report is published
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	f.provider.On("Type").Return("mock")
+	f.provider.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(newMeasurement(v1alpha1.AnalysisPhaseSuccessful), nil)
+	assert.NoError(t, c.syncHandler(getKey(run, t)))
+	cm, err := f.kubeclient.CoreV1().ConfigMaps(run.Namespace).Get("foo-abc123-1-report", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, cm.Data[ReportJSONKey], `"phase": "Successful"`)
+	assert.False(t, c.pendingReports.has(getKey(run, t)))
+}
+
+func TestPublishReportHTTP(t *testing.T) {
+	var lock sync.Mutex
+	uploads := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "Bearer upload-token", r.Header.Get("Authorization"))
+		uploads[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	f := newFixture(t)
+	defer f.Close()
+	f.secretRunLister = append(f.secretRunLister, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "reports-token", Namespace: defaults.Namespace()},
+		Data:       map[string][]byte{"token": []byte("upload-token")},
+	})
+	configutil.SetDefaults(map[string]string{
+		configutil.AnalysisReportsKey:                "true",
+		configutil.AnalysisReportsStoreKey:           ReportStoreHTTP,
+		configutil.AnalysisReportsHTTPURLKey:         server.URL + "/reports/",
+		configutil.AnalysisReportsHTTPTokenSecretKey: "reports-token",
+	})
+	defer configutil.SetDefaults(nil)
+
+	run := newCompletedRun()
+	f.objects = append(f.objects, run)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	c.pendingReports.add(getKey(run, t))
+	assert.NoError(t, c.publishReport(run))
+	assert.Contains(t, uploads["/reports/default/foo-abc123-1.json"], `"rollout": "foo"`)
+	assert.Contains(t, uploads["/reports/default/foo-abc123-1.md"], "# Analysis Report: foo-abc123-1")
+	assert.Empty(t, f.kubeclient.Actions())
+}
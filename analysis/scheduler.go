@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// DefaultClockSkewTolerance is how early a measurement may be taken relative to its scheduled time.
+// It absorbs workqueue timer jitter and the second precision of timestamps persisted in the status,
+// which would otherwise cause a measurement to wait for the next resync.
+const DefaultClockSkewTolerance = time.Second
+
+// measurementScheduler decides when measurements are due. Schedules are computed from the
+// timestamps recorded in the AnalysisRun status so they survive controller restarts. A timestamp
+// which is in the future of the local clock was written by a controller whose clock is skewed; the
+// delay is then measured from when this controller first observed the timestamp, using the
+// monotonic clock, instead of waiting for the local clock to catch up.
+type measurementScheduler struct {
+	nowFn     func() time.Time
+	tolerance time.Duration
+
+	lock     sync.Mutex
+	observed map[string]time.Time
+}
+
+// scheduler is shared by all AnalysisRuns reconciled by the controller
+var scheduler = newMeasurementScheduler(DefaultClockSkewTolerance)
+
+func newMeasurementScheduler(tolerance time.Duration) *measurementScheduler {
+	return &measurementScheduler{
+		nowFn:     time.Now,
+		tolerance: tolerance,
+		observed:  make(map[string]time.Time),
+	}
+}
+
+// runKey returns the workqueue key of the run
+func runKey(run *v1alpha1.AnalysisRun) string {
+	key, _ := cache.MetaNamespaceKeyFunc(run)
+	return key
+}
+
+// observedKey is prefixed with the workqueue key of the run, so the observations of a deleted run
+// can be forgotten once only its key is known
+func observedKey(run *v1alpha1.AnalysisRun, metricName string, reference time.Time) string {
+	return fmt.Sprintf("%s/%s/%d", runKey(run), metricName, reference.UnixNano())
+}
+
+// dueTime returns when an action scheduled delay after the reference timestamp is due
+func (s *measurementScheduler) dueTime(run *v1alpha1.AnalysisRun, metricName string, reference time.Time, delay time.Duration) time.Time {
+	now := s.nowFn()
+	if !reference.After(now.Add(s.tolerance)) {
+		return reference.Add(delay)
+	}
+	key := observedKey(run, metricName, reference)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	observed, ok := s.observed[key]
+	if !ok {
+		observed = now
+		s.observed[key] = observed
+	}
+	return observed.Add(delay)
+}
+
+// resumeTime returns when an in-flight measurement should be resumed. The delay requested by the
+// provider is taken relative to when the measurement started so a skewed ResumeAt is tolerated.
+func (s *measurementScheduler) resumeTime(run *v1alpha1.AnalysisRun, metricName string, measurement *v1alpha1.Measurement) time.Time {
+	if measurement.StartedAt == nil || !measurement.StartedAt.After(s.nowFn().Add(s.tolerance)) {
+		return measurement.ResumeAt.Time
+	}
+	delay := measurement.ResumeAt.Sub(measurement.StartedAt.Time)
+	return s.dueTime(run, metricName, measurement.StartedAt.Time, delay)
+}
+
+// isDue returns true if the scheduled time has been reached, within the skew tolerance
+func (s *measurementScheduler) isDue(t time.Time) bool {
+	return !s.nowFn().Add(s.tolerance).Before(t)
+}
+
+// forgetRun drops the observation times recorded for the run with the workqueue key once it no
+// longer needs scheduling
+func (s *measurementScheduler) forgetRun(key string) {
+	prefix := key + "/"
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key := range s.observed {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.observed, key)
+		}
+	}
+}
+
+// resumeTimers tracks when each AnalysisRun is next scheduled to be reconciled by the workqueue
+// timer. Runs with a pending timer do not need to be reconciled on informer resyncs, so
+// measurements are taken when they are due rather than whenever the resync period elapses.
+type resumeTimers struct {
+	nowFn func() time.Time
+
+	lock   sync.Mutex
+	timers map[string]time.Time
+}
+
+func newResumeTimers() *resumeTimers {
+	return &resumeTimers{
+		nowFn:  time.Now,
+		timers: make(map[string]time.Time),
+	}
+}
+
+// schedule records that the run will be reconciled after the duration. An earlier pending timer
+// is kept since the workqueue fires the earliest of the two.
+func (t *resumeTimers) schedule(key string, duration time.Duration) {
+	fireAt := t.nowFn().Add(duration)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if existing, ok := t.timers[key]; ok && existing.Before(fireAt) && existing.After(t.nowFn()) {
+		return
+	}
+	t.timers[key] = fireAt
+}
+
+// pending returns true if a timer for the run has not fired yet
+func (t *resumeTimers) pending(key string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	fireAt, ok := t.timers[key]
+	return ok && fireAt.After(t.nowFn())
+}
+
+// clear forgets the timer of the run. Every reconciliation schedules the next timer if one is needed
+func (t *resumeTimers) clear(key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.timers, key)
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newTestScheduler(now time.Time) *measurementScheduler {
+	s := newMeasurementScheduler(DefaultClockSkewTolerance)
+	s.nowFn = func() time.Time {
+		return now
+	}
+	return s
+}
+
+func TestSchedulerIsDueWithinTolerance(t *testing.T) {
+	now := time.Now()
+	s := newTestScheduler(now)
+	assert.True(t, s.isDue(now.Add(-time.Second)))
+	assert.True(t, s.isDue(now.Add(500*time.Millisecond)))
+	assert.False(t, s.isDue(now.Add(2*time.Second)))
+}
+
+func TestSchedulerDueTimeSkewedReference(t *testing.T) {
+	now := time.Now()
+	s := newTestScheduler(now)
+	run := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "abc"}}
+
+	// a reference in the past is honored as is
+	past := now.Add(-30 * time.Second)
+	assert.Equal(t, past.Add(time.Minute), s.dueTime(run, "success-rate", past, time.Minute))
+
+	// a reference written by a controller with a clock an hour ahead is measured from when it was
+	// first observed rather than delaying the measurement by an hour
+	future := now.Add(time.Hour)
+	assert.Equal(t, now.Add(time.Minute), s.dueTime(run, "success-rate", future, time.Minute))
+	s.nowFn = func() time.Time {
+		return now.Add(30 * time.Second)
+	}
+	assert.Equal(t, now.Add(time.Minute), s.dueTime(run, "success-rate", future, time.Minute))
+
+	// the observations of a run with a similar name are kept
+	other := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{Name: "foo-2", Namespace: "default", UID: "def"}}
+	s.dueTime(other, "success-rate", future, time.Minute)
+	s.forgetRun("default/foo")
+	assert.Len(t, s.observed, 1)
+	s.forgetRun("default/foo-2")
+	assert.Empty(t, s.observed)
+}
+
+func TestSchedulerResumeTimeSkewedStart(t *testing.T) {
+	now := time.Now()
+	s := newTestScheduler(now)
+	run := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{UID: "abc"}}
+	startedAt := metav1.NewTime(now.Add(time.Hour))
+	resumeAt := metav1.NewTime(now.Add(time.Hour + 10*time.Second))
+	measurement := &v1alpha1.Measurement{
+		StartedAt: &startedAt,
+		ResumeAt:  &resumeAt,
+	}
+	assert.Equal(t, now.Add(10*time.Second), s.resumeTime(run, "success-rate", measurement))
+
+	startedAt = metav1.NewTime(now.Add(-time.Minute))
+	assert.Equal(t, resumeAt.Time, s.resumeTime(run, "success-rate", measurement))
+}
+
+func TestResumeTimers(t *testing.T) {
+	now := time.Now()
+	timers := newResumeTimers()
+	timers.nowFn = func() time.Time {
+		return now
+	}
+	assert.False(t, timers.pending("default/run"))
+
+	timers.schedule("default/run", 30*time.Second)
+	timers.schedule("default/run", time.Minute)
+	assert.True(t, timers.pending("default/run"))
+	assert.Equal(t, now.Add(30*time.Second), timers.timers["default/run"])
+
+	timers.nowFn = func() time.Time {
+		return now.Add(31 * time.Second)
+	}
+	assert.False(t, timers.pending("default/run"))
+
+	timers.schedule("default/run", time.Minute)
+	timers.clear("default/run")
+	assert.False(t, timers.pending("default/run"))
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
@@ -19,13 +21,23 @@ import (
 	"k8s.io/klog"
 
 	"github.com/argoproj/argo-rollouts/controller"
+	"github.com/argoproj/argo-rollouts/controller/diagnostics"
 	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/controller/webhook"
 	jobprovider "github.com/argoproj/argo-rollouts/metricproviders/job"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
 	"github.com/argoproj/argo-rollouts/pkg/signals"
+	"github.com/argoproj/argo-rollouts/server"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
+	crdutil "github.com/argoproj/argo-rollouts/utils/crd"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	kubeclientmetrics "github.com/argoproj/argo-rollouts/utils/kubeclientmetrics"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
+	"github.com/argoproj/argo-rollouts/utils/version"
 )
 
 const (
@@ -40,29 +52,73 @@ func newCommand() *cobra.Command {
 		clientConfig        clientcmd.ClientConfig
 		rolloutResyncPeriod int64
 		logLevel            string
+		logFormat           string
 		glogLevel           int
 		metricsPort         int
 		instanceID          string
+		namespaced          bool
 		rolloutThreads      int
 		experimentThreads   int
 		analysisThreads     int
 		serviceThreads      int
+		daemonSetThreads    int
 		istioVersion        string
+		otlpAddress         string
+		serverSideApply     bool
+		enablePprof         bool
+		pprofPort           int
+		shutdownTimeout     time.Duration
+		installCRDs         bool
+		crdDir              string
+		stripCaches         bool
+		disabledProviders   []string
+		disabledRouters     []string
+		namespaceReconciles int
+		apiServerPort       int
+		apiServerCertFile   string
+		apiServerKeyFile    string
+		webhookPort         int
+		webhookCertFile     string
+		webhookKeyFile      string
+		leaderElection      controller.LeaderElectionOptions
 	)
 	var command = cobra.Command{
 		Use:   cliName,
 		Short: "argo-rollouts is a controller to operate on rollout CRD",
 		RunE: func(c *cobra.Command, args []string) error {
 			setLogLevel(logLevel)
-			formatter := &log.TextFormatter{
-				FullTimestamp: true,
-			}
-			log.SetFormatter(formatter)
+			setLogFormat(logFormat)
 			setGLogLevel(glogLevel)
+			if apiServerPort > 0 && (apiServerCertFile == "" || apiServerKeyFile == "") {
+				// the API server receives bearer tokens, which must not be sent in plain text
+				log.Fatal("--api-server-cert-file and --api-server-key-file are required to serve the API server")
+			}
+			configutil.SetDefaults(map[string]string{
+				configutil.DisabledMetricProvidersKey:             strings.Join(disabledProviders, ","),
+				configutil.DisabledTrafficRoutersKey:              strings.Join(disabledRouters, ","),
+				configutil.MaxConcurrentReconcilesPerNamespaceKey: strconv.Itoa(namespaceReconciles),
+			})
 
 			// set up signals so we handle the first shutdown signal gracefully
 			stopCh := signals.SetupSignalHandler()
 
+			if enablePprof {
+				pprofServer := diagnostics.NewServer(fmt.Sprintf("127.0.0.1:%d", pprofPort))
+				go func() {
+					log.Infof("Starting pprof server at %s", pprofServer.Addr)
+					if err := pprofServer.ListenAndServe(); err != nil {
+						log.Errorf("pprof server stopped: %v", err)
+					}
+				}()
+			}
+
+			if otlpAddress != "" {
+				exporter := tracing.NewOTLPExporter(otlpAddress)
+				tracing.SetExporter(exporter)
+				go exporter.Run(stopCh)
+				log.Infof("Exporting traces to %s", otlpAddress)
+			}
+
 			// cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 			config, err := clientConfig.ClientConfig()
 			checkError(err)
@@ -71,13 +127,27 @@ func newCommand() *cobra.Command {
 			checkError(err)
 			if modified {
 				namespace = configNS
+			} else if namespaced {
+				namespace = defaults.Namespace()
+			}
+			if namespace != metav1.NamespaceAll {
 				log.Infof("Using namespace %s", namespace)
 			}
+			if leaderElection.Namespace == "" {
+				leaderElection.Namespace = defaults.Namespace()
+			}
 			k8sRequestProvider := &metrics.K8sRequestsCountProvider{}
 			kubeclientmetrics.AddMetricsTransportWrapper(config, k8sRequestProvider.IncKubernetesRequest)
 
 			kubeClient, err := kubernetes.NewForConfig(config)
 			checkError(err)
+			if installCRDs {
+				crds, err := crdutil.LoadCRDs(crdDir)
+				checkError(err)
+				extensionsClient, err := apiextensionsclient.NewForConfig(config)
+				checkError(err)
+				checkError(crdutil.Install(extensionsClient.ApiextensionsV1beta1().CustomResourceDefinitions(), crds, version.GetVersion().Version))
+			}
 			rolloutClient, err := clientset.NewForConfig(config)
 			checkError(err)
 			dynamicClient, err := dynamic.NewForConfig(config)
@@ -95,6 +165,14 @@ func newCommand() *cobra.Command {
 				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
 					options.LabelSelector = instanceIDSelector.String()
 				}))
+			// ClusterAnalysisTemplates are cluster-scoped, so they are not listed from the namespace of
+			// the controller
+			clusterAnalysisTemplateInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+				rolloutClient,
+				resyncDuration,
+				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.LabelSelector = instanceIDSelector.String()
+				}))
 			jobInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
 				kubeClient,
 				resyncDuration,
@@ -102,32 +180,74 @@ func newCommand() *cobra.Command {
 				kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
 					options.LabelSelector = jobprovider.AnalysisRunUIDLabelKey
 				}))
+			// the secrets registering the clusters the ReplicaSets of rollouts can be created in
+			clusterSecretInformerFactory := clusterutil.NewSecretInformerFactory(kubeClient, defaults.Namespace(), resyncDuration)
+			if stripCaches {
+				controllerutil.AddCacheTransforms(kubeInformerFactory, namespace)
+				controllerutil.AddJobCacheTransforms(jobInformerFactory, namespace, jobprovider.AnalysisRunUIDLabelKey)
+			}
 			cm := controller.NewManager(
 				namespace,
 				kubeClient,
 				rolloutClient,
 				dynamicClient,
 				kubeInformerFactory.Apps().V1().ReplicaSets(),
+				kubeInformerFactory.Apps().V1().Deployments(),
+				kubeInformerFactory.Apps().V1().DaemonSets(),
 				kubeInformerFactory.Core().V1().Services(),
 				kubeInformerFactory.Core().V1().Secrets(),
+				clusterSecretInformerFactory.Core().V1().Secrets(),
 				jobInformerFactory.Batch().V1().Jobs(),
 				argoRolloutsInformerFactory.Argoproj().V1alpha1().Rollouts(),
 				argoRolloutsInformerFactory.Argoproj().V1alpha1().Experiments(),
 				argoRolloutsInformerFactory.Argoproj().V1alpha1().AnalysisRuns(),
 				argoRolloutsInformerFactory.Argoproj().V1alpha1().AnalysisTemplates(),
+				clusterAnalysisTemplateInformerFactory.Argoproj().V1alpha1().ClusterAnalysisTemplates(),
 				resyncDuration,
 				instanceID,
 				metricsPort,
 				k8sRequestProvider,
-				defaultIstioVersion)
+				defaultIstioVersion,
+				serverSideApply,
+				shutdownTimeout,
+				leaderElection)
 
 			// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 			// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 			kubeInformerFactory.Start(stopCh)
+			configutil.Watch(kubeClient, defaults.Namespace(), resyncDuration, stopCh)
 			argoRolloutsInformerFactory.Start(stopCh)
+			if namespace == metav1.NamespaceAll {
+				clusterAnalysisTemplateInformerFactory.Start(stopCh)
+			}
 			jobInformerFactory.Start(stopCh)
+			clusterSecretInformerFactory.Start(stopCh)
+
+			if apiServerPort > 0 {
+				apiServer := server.NewServer(kubeClient, rolloutClient, config).NewHTTPServer(fmt.Sprintf("0.0.0.0:%d", apiServerPort))
+				go func() {
+					log.Infof("Starting API server at %s", apiServer.Addr)
+					if err := apiServer.ListenAndServeTLS(apiServerCertFile, apiServerKeyFile); err != nil {
+						log.Errorf("API server stopped: %v", err)
+					}
+				}()
+			}
+
+			if webhookPort > 0 {
+				validator := webhook.NewValidator(
+					kubeInformerFactory.Apps().V1().Deployments().Lister(),
+					argoRolloutsInformerFactory.Argoproj().V1alpha1().AnalysisTemplates().Lister(),
+					clusterAnalysisTemplateInformerFactory.Argoproj().V1alpha1().ClusterAnalysisTemplates().Lister())
+				webhookServer := webhook.NewServer(fmt.Sprintf("0.0.0.0:%d", webhookPort), validator)
+				go func() {
+					log.Infof("Starting validating webhook at %s", webhookServer.Addr)
+					if err := webhookServer.ListenAndServeTLS(webhookCertFile, webhookKeyFile); err != nil {
+						log.Errorf("Validating webhook stopped: %v", err)
+					}
+				}()
+			}
 
-			if err = cm.Run(rolloutThreads, serviceThreads, experimentThreads, analysisThreads, stopCh); err != nil {
+			if err = cm.Run(rolloutThreads, serviceThreads, experimentThreads, analysisThreads, daemonSetThreads, stopCh); err != nil {
 				log.Fatalf("Error running controller: %s", err.Error())
 			}
 			return nil
@@ -136,14 +256,39 @@ func newCommand() *cobra.Command {
 	clientConfig = addKubectlFlagsToCmd(&command)
 	command.Flags().Int64Var(&rolloutResyncPeriod, "rollout-resync", controller.DefaultRolloutResyncPeriod, "Time period in seconds for rollouts resync.")
 	command.Flags().StringVar(&logLevel, "loglevel", "info", "Set the logging level. One of: debug|info|warn|error")
+	command.Flags().StringVar(&logFormat, "log-format", logutil.TextFormat, "Set the logging format. One of: text|json")
 	command.Flags().IntVar(&glogLevel, "gloglevel", 0, "Set the glog logging level")
 	command.Flags().IntVar(&metricsPort, "metricsport", controller.DefaultMetricsPort, "Set the port the metrics endpoint should be exposed over")
 	command.Flags().StringVar(&instanceID, "instance-id", "", "Indicates which argo rollout objects the controller should operate on")
+	command.Flags().BoolVar(&namespaced, "namespaced", false, "Only operate on the objects of the namespace of the controller, unless --namespace is set. Requires no cluster-wide permissions")
 	command.Flags().IntVar(&rolloutThreads, "rollout-threads", controller.DefaultRolloutThreads, "Set the number of worker threads for the Rollout controller")
 	command.Flags().IntVar(&experimentThreads, "experiment-threads", controller.DefaultExperimentThreads, "Set the number of worker threads for the Experiment controller")
 	command.Flags().IntVar(&analysisThreads, "analysis-threads", controller.DefaultAnalysisThreads, "Set the number of worker threads for the Experiment controller")
 	command.Flags().IntVar(&serviceThreads, "service-threads", controller.DefaultServiceThreads, "Set the number of worker threads for the Service controller")
+	command.Flags().IntVar(&daemonSetThreads, "daemonset-threads", controller.DefaultDaemonSetThreads, "Set the number of worker threads for the DaemonSet controller")
 	command.Flags().StringVar(&istioVersion, "istio-api-version", defaultIstioVersion, "Set the default Istio apiVersion that controller should look when manipulating VirtualServices.")
+	command.Flags().StringVar(&otlpAddress, "otlp-address", "", "Address of an OpenTelemetry collector (e.g. http://otel-collector:4318) to send reconcile traces to. Tracing is disabled if unset")
+	command.Flags().BoolVar(&serverSideApply, "server-side-apply", false, "Use server-side apply with the 'argo-rollouts' field manager to update service selectors and rollout status. Requires Kubernetes v1.16+")
+	command.Flags().BoolVar(&enablePprof, "enable-pprof", false, "Expose pprof and expvar endpoints on localhost for profiling the controller")
+	command.Flags().IntVar(&pprofPort, "pprof-port", diagnostics.DefaultPort, "Set the localhost port the pprof endpoint should be exposed over")
+	command.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", controller.DefaultShutdownTimeout, "Time to wait for in-flight reconciliations to finish on shutdown")
+	command.Flags().BoolVar(&installCRDs, "install-crds", false, "Create or update the Argo Rollouts CRDs at startup. CRDs installed by a newer controller are never downgraded")
+	command.Flags().StringVar(&crdDir, "crd-dir", crdutil.DefaultCRDDir, "Directory containing the CRD manifests used by --install-crds")
+	command.Flags().IntVar(&namespaceReconciles, "max-concurrent-reconciles-per-namespace", 0, "Maximum number of rollouts of a single namespace reconciled at the same time. 0 means unlimited")
+	command.Flags().StringSliceVar(&disabledProviders, "disabled-metric-providers", nil, "Metric provider types AnalysisRuns are not allowed to use (e.g. wavefront,kayenta)")
+	command.Flags().StringSliceVar(&disabledRouters, "disabled-traffic-routers", nil, "Traffic routers rollouts are not allowed to use (e.g. istio)")
+	command.Flags().IntVar(&apiServerPort, "api-server-port", 0, fmt.Sprintf("Serve the rollouts API over TLS on this port (e.g. %d). The API is disabled if unset", server.DefaultPort))
+	command.Flags().StringVar(&apiServerCertFile, "api-server-cert-file", "", "Path of the TLS certificate of the API server. Required with --api-server-port")
+	command.Flags().StringVar(&apiServerKeyFile, "api-server-key-file", "", "Path of the TLS private key of the API server. Required with --api-server-port")
+	command.Flags().IntVar(&webhookPort, "webhook-port", 0, fmt.Sprintf("Serve the validating admission webhook over TLS on this port (e.g. %d). The webhook is disabled if unset", webhook.DefaultPort))
+	command.Flags().StringVar(&webhookCertFile, "webhook-cert-file", "/tmp/k8s-webhook-server/serving-certs/tls.crt", "Path of the TLS certificate of the validating admission webhook")
+	command.Flags().StringVar(&webhookKeyFile, "webhook-key-file", "/tmp/k8s-webhook-server/serving-certs/tls.key", "Path of the TLS private key of the validating admission webhook")
+	command.Flags().BoolVar(&leaderElection.Enabled, "leader-elect", false, "Elect a leader among the controller replicas with a Lease, so only the leader reconciles the resources")
+	command.Flags().StringVar(&leaderElection.Namespace, "leader-election-namespace", "", "Namespace of the leader election Lease. Defaults to the namespace of the controller")
+	command.Flags().DurationVar(&leaderElection.LeaseDuration, "leader-election-lease-duration", controller.DefaultLeaseDuration, "Duration the other replicas wait before acquiring a lease the leader did not renew")
+	command.Flags().DurationVar(&leaderElection.RenewDeadline, "leader-election-renew-deadline", controller.DefaultRenewDeadline, "Duration the leader retries renewing its lease before giving up leadership")
+	command.Flags().DurationVar(&leaderElection.RetryPeriod, "leader-election-retry-period", controller.DefaultRetryPeriod, "Duration between the attempts to acquire or renew the lease")
+	command.Flags().BoolVar(&stripCaches, "strip-informer-caches", true, "Drop managed fields, last-applied annotations and job pod templates from cached objects to reduce memory usage")
 	return &command
 }
 
@@ -173,6 +318,15 @@ func setLogLevel(logLevel string) {
 	log.SetLevel(level)
 }
 
+// setLogFormat sets the logrus formatter matching the log format
+func setLogFormat(logFormat string) {
+	formatter, err := logutil.NewFormatter(logFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.SetFormatter(formatter)
+}
+
 // setGLogLevel set the glog level for the k8s go-client
 func setGLogLevel(glogLevel int) {
 	klog.InitFlags(nil)
@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -23,12 +27,17 @@ import (
 
 	"github.com/argoproj/argo-rollouts/analysis"
 	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/daemonset"
 	"github.com/argoproj/argo-rollouts/experiments"
+	"github.com/argoproj/argo-rollouts/notifications"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	rolloutscheme "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/scheme"
 	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/rollout"
 	"github.com/argoproj/argo-rollouts/service"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	recordutil "github.com/argoproj/argo-rollouts/utils/record"
 )
 
 const controllerAgentName = "rollouts-controller"
@@ -51,6 +60,12 @@ const (
 
 	// DefaultServiceThreads Default number of service worker threads to start with the controller
 	DefaultServiceThreads = 10
+
+	// DefaultDaemonSetThreads Default number of DaemonSet worker threads to start with the controller
+	DefaultDaemonSetThreads = 5
+
+	// DefaultShutdownTimeout Default time to wait for in-flight reconciliations to finish when the controller is stopped
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 // Manager is the controller implementation for Argo-Rollout resources
@@ -60,22 +75,35 @@ type Manager struct {
 	experimentController *experiments.ExperimentController
 	analysisController   *analysis.AnalysisController
 	serviceController    *service.ServiceController
+	daemonSetController  *daemonset.DaemonSetController
+	notificationEngine   *notifications.Engine
+	notificationDelivery *notifications.Deliverer
 
-	rolloutSynced          cache.InformerSynced
-	experimentSynced       cache.InformerSynced
-	analysisRunSynced      cache.InformerSynced
-	analysisTemplateSynced cache.InformerSynced
-	secretSynced           cache.InformerSynced
-	serviceSynced          cache.InformerSynced
-	jobSynced              cache.InformerSynced
-	replicasSetSynced      cache.InformerSynced
+	rolloutSynced                 cache.InformerSynced
+	experimentSynced              cache.InformerSynced
+	analysisRunSynced             cache.InformerSynced
+	analysisTemplateSynced        cache.InformerSynced
+	clusterAnalysisTemplateSynced cache.InformerSynced
+	secretSynced                  cache.InformerSynced
+	clusterSecretSynced           cache.InformerSynced
+	serviceSynced                 cache.InformerSynced
+	jobSynced                     cache.InformerSynced
+	replicasSetSynced             cache.InformerSynced
+	deploymentSynced              cache.InformerSynced
+	daemonSetSynced               cache.InformerSynced
 
 	rolloutWorkqueue     workqueue.RateLimitingInterface
 	serviceWorkqueue     workqueue.RateLimitingInterface
 	experimentWorkqueue  workqueue.RateLimitingInterface
 	analysisRunWorkqueue workqueue.RateLimitingInterface
+	daemonSetWorkqueue   workqueue.RateLimitingInterface
 
+	kubeclientset       kubernetes.Interface
+	instanceID          string
+	leaderElection      LeaderElectionOptions
 	defaultIstioVersion string
+	shutdownTimeout     time.Duration
+	resyncPeriod        time.Duration
 }
 
 // NewManager returns a new manager to manage all the controllers
@@ -85,18 +113,25 @@ func NewManager(
 	argoprojclientset clientset.Interface,
 	dynamicclientset dynamic.Interface,
 	replicaSetInformer appsinformers.ReplicaSetInformer,
+	deploymentInformer appsinformers.DeploymentInformer,
+	daemonSetInformer appsinformers.DaemonSetInformer,
 	servicesInformer coreinformers.ServiceInformer,
 	secretInformer coreinformers.SecretInformer,
+	clusterSecretInformer coreinformers.SecretInformer,
 	jobInformer batchinformers.JobInformer,
 	rolloutsInformer informers.RolloutInformer,
 	experimentsInformer informers.ExperimentInformer,
 	analysisRunInformer informers.AnalysisRunInformer,
 	analysisTemplateInformer informers.AnalysisTemplateInformer,
+	clusterAnalysisTemplateInformer informers.ClusterAnalysisTemplateInformer,
 	resyncPeriod time.Duration,
 	instanceID string,
 	metricsPort int,
 	k8sRequestProvider *metrics.K8sRequestsCountProvider,
 	defaultIstioVersion string,
+	serverSideApply bool,
+	shutdownTimeout time.Duration,
+	leaderElection LeaderElectionOptions,
 ) *Manager {
 
 	utilruntime.Must(rolloutscheme.AddToScheme(scheme.Scheme))
@@ -105,21 +140,33 @@ func NewManager(
 	// Create event broadcaster
 	// Add argo-rollouts custom resources to the default Kubernetes Scheme so Events can be
 	// logged for argo-rollouts types.
-	eventBroadcaster := record.NewBroadcaster()
+	// Identical events are deduplicated in the controller and similar events are aggregated by the
+	// broadcaster so long running rollouts do not flood the namespace event stream. Only the
+	// Kubernetes events are deduplicated: every event is kept in the rollout's decision history
+	// annotation and fires the notification triggers.
+	eventBroadcaster := record.NewBroadcasterWithCorrelatorOptions(recordutil.CorrelatorOptions())
 	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
-	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+	eventRecorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 	metricsAddr := fmt.Sprintf("0.0.0.0:%d", metricsPort)
 	metricsServer := metrics.NewMetricsServer(
 		metricsAddr,
 		rolloutsInformer.Lister(),
+		replicaSetInformer.Lister(),
 		k8sRequestProvider,
 	)
+	// Rollout events also fire the notification triggers subscribed to in the rollout annotations
+	notificationDelivery := notifications.NewDeliverer(kubeclientset, defaults.Namespace(), metricsServer)
+	notificationEngine := notifications.NewEngine(kubeclientset, analysisRunInformer.Lister(), defaults.Namespace(), notificationDelivery)
+	dedupRecorder := recordutil.NewDedupRecorder(eventRecorder, recordutil.DefaultDedupWindow)
+	auditRecorder := recordutil.NewAuditRecorder(dedupRecorder, recordutil.DefaultDecisionHistoryLimit)
+	recorder := notifications.NewRecorder(auditRecorder, notificationEngine)
 
 	rolloutWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
 	experimentWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Experiments")
 	analysisRunWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AnalysisRuns")
 	serviceWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Services")
+	daemonSetWorkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "DaemonSets")
 
 	rolloutController := rollout.NewRolloutController(
 		namespace,
@@ -129,23 +176,30 @@ func NewManager(
 		experimentsInformer,
 		analysisRunInformer,
 		analysisTemplateInformer,
+		clusterAnalysisTemplateInformer,
 		replicaSetInformer,
+		deploymentInformer,
 		servicesInformer,
 		rolloutsInformer,
+		clusterutil.NewSecretRegistry(clusterSecretInformer, defaults.Namespace(), namespace, resyncPeriod),
 		resyncPeriod,
 		rolloutWorkqueue,
 		serviceWorkqueue,
 		metricsServer,
 		recorder,
-		defaultIstioVersion)
+		auditRecorder,
+		defaultIstioVersion,
+		serverSideApply)
 
 	experimentController := experiments.NewExperimentController(
 		kubeclientset,
 		argoprojclientset,
 		replicaSetInformer,
+		servicesInformer,
 		experimentsInformer,
 		analysisRunInformer,
 		analysisTemplateInformer,
+		clusterAnalysisTemplateInformer,
 		resyncPeriod,
 		rolloutWorkqueue,
 		experimentWorkqueue,
@@ -172,25 +226,55 @@ func NewManager(
 		serviceWorkqueue,
 		metricsServer)
 
+	daemonSetController := daemonset.NewDaemonSetController(
+		kubeclientset,
+		argoprojclientset,
+		daemonSetInformer,
+		analysisRunInformer,
+		analysisTemplateInformer,
+		resyncPeriod,
+		instanceID,
+		daemonSetWorkqueue,
+		metricsServer,
+		recorder)
+
 	cm := &Manager{
 		metricsServer:          metricsServer,
 		rolloutSynced:          rolloutsInformer.Informer().HasSynced,
 		serviceSynced:          servicesInformer.Informer().HasSynced,
 		secretSynced:           secretInformer.Informer().HasSynced,
+		clusterSecretSynced:    clusterSecretInformer.Informer().HasSynced,
 		jobSynced:              jobInformer.Informer().HasSynced,
 		experimentSynced:       experimentsInformer.Informer().HasSynced,
 		analysisRunSynced:      analysisRunInformer.Informer().HasSynced,
 		analysisTemplateSynced: analysisTemplateInformer.Informer().HasSynced,
 		replicasSetSynced:      replicaSetInformer.Informer().HasSynced,
+		deploymentSynced:       deploymentInformer.Informer().HasSynced,
+		daemonSetSynced:        daemonSetInformer.Informer().HasSynced,
 		rolloutWorkqueue:       rolloutWorkqueue,
 		experimentWorkqueue:    experimentWorkqueue,
 		analysisRunWorkqueue:   analysisRunWorkqueue,
 		serviceWorkqueue:       serviceWorkqueue,
+		daemonSetWorkqueue:     daemonSetWorkqueue,
 		rolloutController:      rolloutController,
 		serviceController:      serviceController,
 		experimentController:   experimentController,
 		analysisController:     analysisController,
+		daemonSetController:    daemonSetController,
+		notificationEngine:     notificationEngine,
+		notificationDelivery:   notificationDelivery,
+		kubeclientset:          kubeclientset,
+		instanceID:             instanceID,
+		leaderElection:         leaderElection,
 		defaultIstioVersion:    defaultIstioVersion,
+		shutdownTimeout:        shutdownTimeout,
+		resyncPeriod:           resyncPeriod,
+	}
+	// Controllers of a single namespace are not allowed to list cluster-scoped resources, so their
+	// ClusterAnalysisTemplate informer is never started
+	cm.clusterAnalysisTemplateSynced = func() bool { return true }
+	if namespace == metav1.NamespaceAll {
+		cm.clusterAnalysisTemplateSynced = clusterAnalysisTemplateInformer.Informer().HasSynced
 	}
 
 	return cm
@@ -199,38 +283,87 @@ func NewManager(
 // Run will set up the event handlers for types we are interested in, as well
 // as syncing informer caches and starting workers. It will block until stopCh
 // is closed, at which point it will shutdown the workqueue and wait for
-// workers to finish processing their current work items.
-func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, experimentThreadiness, analysisThreadiness int, stopCh <-chan struct{}) error {
+// workers to finish processing their current work items, up to the shutdown
+// timeout. With leader election, the workers are only started once the
+// replica is elected, while the metrics server runs on every replica.
+func (c *Manager) Run(rolloutThreadiness, serviceThreadiness, experimentThreadiness, analysisThreadiness, daemonSetThreadiness int, stopCh <-chan struct{}) error {
 
 	defer runtime.HandleCrash()
 	defer c.serviceWorkqueue.ShutDown()
 	defer c.rolloutWorkqueue.ShutDown()
 	defer c.experimentWorkqueue.ShutDown()
 	defer c.analysisRunWorkqueue.ShutDown()
-	// Wait for the caches to be synced before starting workers
-	log.Info("Waiting for controller's informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.serviceSynced, c.jobSynced, c.secretSynced, c.rolloutSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced, c.replicasSetSynced); !ok {
-		return fmt.Errorf("failed to wait for caches to sync")
-	}
-
-	// Start the informer factories to begin populating the informer caches
-	log.Info("Starting Controllers")
-	go wait.Until(func() { c.rolloutController.Run(rolloutThreadiness, stopCh) }, time.Second, stopCh)
-	go wait.Until(func() { c.serviceController.Run(serviceThreadiness, stopCh) }, time.Second, stopCh)
-	go wait.Until(func() { c.experimentController.Run(experimentThreadiness, stopCh) }, time.Second, stopCh)
-	go wait.Until(func() { c.analysisController.Run(analysisThreadiness, stopCh) }, time.Second, stopCh)
-	log.Info("Started controller")
+	defer c.daemonSetWorkqueue.ShutDown()
 
 	go func() {
 		log.Infof("Starting Metric Server at %s", c.metricsServer.Addr)
 		err := c.metricsServer.ListenAndServe()
-		if err != nil {
+		if err != nil && err != http.ErrServerClosed {
 			err = errors.Wrap(err, "Starting Metric Server")
 			log.Fatal(err)
 		}
 	}()
-	<-stopCh
-	log.Info("Shutting down workers")
 
+	// Wait for the caches to be synced before starting workers
+	log.Info("Waiting for controller's informer caches to sync")
+	if ok := cache.WaitForCacheSync(stopCh, c.serviceSynced, c.jobSynced, c.secretSynced, c.clusterSecretSynced, c.rolloutSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced, c.clusterAnalysisTemplateSynced, c.replicasSetSynced, c.deploymentSynced, c.daemonSetSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	runControllers := func(stopCh <-chan struct{}) {
+		c.runControllers(rolloutThreadiness, serviceThreadiness, experimentThreadiness, analysisThreadiness, daemonSetThreadiness, stopCh)
+	}
+	if c.leaderElection.Enabled {
+		log.Infof("Waiting to acquire the lease %s/%s", c.leaderElection.Namespace, leaseName(c.instanceID))
+		c.runWithLeaderElection(runControllers, stopCh)
+	} else {
+		c.metricsServer.SetLeader(true)
+		runControllers(stopCh)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.metricsServer.Shutdown(ctx); err != nil {
+		log.Warnf("Error shutting down metrics server: %v", err)
+	}
 	return nil
 }
+
+// runControllers starts the workers of the controllers and blocks until stopCh is closed and the
+// workers finished, up to the shutdown timeout
+func (c *Manager) runControllers(rolloutThreadiness, serviceThreadiness, experimentThreadiness, analysisThreadiness, daemonSetThreadiness int, stopCh <-chan struct{}) {
+	log.Info("Starting Controllers")
+	var wg sync.WaitGroup
+	runController := func(run func(int, <-chan struct{}) error, threadiness int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() { _ = run(threadiness, stopCh) }, time.Second, stopCh)
+		}()
+	}
+	runController(c.rolloutController.Run, rolloutThreadiness)
+	runController(c.serviceController.Run, serviceThreadiness)
+	runController(c.experimentController.Run, experimentThreadiness)
+	runController(c.analysisController.Run, analysisThreadiness)
+	runController(c.daemonSetController.Run, daemonSetThreadiness)
+	c.notificationEngine.Watch(c.resyncPeriod, stopCh)
+	go c.notificationDelivery.Run(notifications.DefaultDeliveryWorkers, stopCh)
+	log.Info("Started controller")
+
+	<-stopCh
+	log.Infof("Shutting down workers, waiting up to %v for in-flight reconciliations", c.shutdownTimeout)
+	// The controllers stop handing out new work items once stopCh is closed. Waiting for the items
+	// in progress ensures their status (including in-progress measurements which will be resumed)
+	// is persisted before the process exits.
+	workersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+		log.Info("All workers finished")
+	case <-time.After(c.shutdownTimeout):
+		log.Warnf("Timed out after %v waiting for workers to finish", c.shutdownTimeout)
+	}
+}
@@ -0,0 +1,38 @@
+package diagnostics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+const (
+	// DefaultPort is the default port the diagnostics endpoint listens on
+	DefaultPort = 6060
+	// PprofPath is the endpoint prefix of the pprof handlers
+	PprofPath = "/debug/pprof/"
+	// ExpvarPath is the endpoint exposing expvar variables, including memstats
+	ExpvarPath = "/debug/vars"
+)
+
+// NewHandler returns a handler serving the pprof profiles and expvar variables. The handlers are
+// registered on a dedicated mux so they are never exposed over the metrics endpoint.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PprofPath, pprof.Index)
+	mux.HandleFunc(PprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(PprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(PprofPath+"trace", pprof.Trace)
+	mux.Handle(ExpvarPath, expvar.Handler())
+	return mux
+}
+
+// NewServer returns a diagnostics server listening on addr. The address should be bound to
+// localhost, since profiles can leak sensitive information from the controller's memory.
+func NewServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:    addr,
+		Handler: NewHandler(),
+	}
+}
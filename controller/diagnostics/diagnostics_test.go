@@ -0,0 +1,25 @@
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler()
+	for _, path := range []string{PprofPath, PprofPath + "goroutine", PprofPath + "heap", ExpvarPath} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	server := NewServer("127.0.0.1:6060")
+	assert.Equal(t, "127.0.0.1:6060", server.Addr)
+	assert.NotNil(t, server.Handler)
+}
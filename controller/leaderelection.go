@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// DefaultLeaderElectionLeaseName is the name of the Lease the controller replicas elect their
+	// leader with. The instance id of the controller is appended to it, so the replicas of each
+	// controller instance elect their own leader.
+	DefaultLeaderElectionLeaseName = "argo-rollouts-controller-lock"
+
+	// DefaultLeaseDuration Default duration non-leader replicas wait before acquiring a lease which was not renewed
+	DefaultLeaseDuration = 15 * time.Second
+
+	// DefaultRenewDeadline Default duration the leader retries renewing its lease before giving up leadership
+	DefaultRenewDeadline = 10 * time.Second
+
+	// DefaultRetryPeriod Default duration between the attempts to acquire or renew the lease
+	DefaultRetryPeriod = 2 * time.Second
+)
+
+// LeaderElectionOptions configures the election of the controller replica which reconciles the
+// resources. The other replicas only serve their metrics and health endpoints.
+type LeaderElectionOptions struct {
+	Enabled       bool
+	Namespace     string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// leaseName returns the name of the Lease of the replicas of the controller instance
+func leaseName(instanceID string) string {
+	if instanceID == "" {
+		return DefaultLeaderElectionLeaseName
+	}
+	return DefaultLeaderElectionLeaseName + "-" + instanceID
+}
+
+// runWithLeaderElection calls run once the replica acquires the lease, and releases the lease once
+// run returned after stopCh was closed. The process exits if the replica loses the lease while it is
+// not stopping, so no two replicas reconcile the same resources.
+func (c *Manager) runWithLeaderElection(run func(stopCh <-chan struct{}), stopCh <-chan struct{}) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Failed to get the hostname of the controller: %v", err)
+	}
+	identity := hostname + "_" + string(uuid.NewUUID())
+	ctx, cancel := context.WithCancel(context.Background())
+	elected := make(chan struct{})
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock: &resourcelock.LeaseLock{
+				LeaseMeta: metav1.ObjectMeta{
+					Name:      leaseName(c.instanceID),
+					Namespace: c.leaderElection.Namespace,
+				},
+				Client: c.kubeclientset.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{
+					Identity: identity,
+				},
+			},
+			LeaseDuration:   c.leaderElection.LeaseDuration,
+			RenewDeadline:   c.leaderElection.RenewDeadline,
+			RetryPeriod:     c.leaderElection.RetryPeriod,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					log.Infof("Acquired the lease as %s", identity)
+					c.metricsServer.SetLeader(true)
+					close(elected)
+				},
+				OnStoppedLeading: func() {
+					c.metricsServer.SetLeader(false)
+					if ctx.Err() == nil {
+						log.Fatalf("Lost the lease as %s", identity)
+					}
+				},
+				OnNewLeader: func(leader string) {
+					if leader != identity {
+						log.Infof("Waiting for the lease held by %s", leader)
+					}
+				},
+			},
+		})
+	}()
+
+	select {
+	case <-elected:
+		run(stopCh)
+	case <-stopCh:
+	}
+	// the lease is only released once the workers finished, so the next leader does not reconcile
+	// the same resources in the meantime
+	cancel()
+	<-released
+}
@@ -19,7 +19,7 @@ func TestIncKubernetesRequest(t *testing.T) {
 	cancel, rolloutLister := newFakeLister(noRollouts)
 	defer cancel()
 	provider := &K8sRequestsCountProvider{}
-	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, provider)
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, provider)
 	provider.IncKubernetesRequest(kubeclientmetrics.ResourceInfo{
 		Kind:       "replicasets",
 		Namespace:  "default",
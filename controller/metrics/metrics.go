@@ -7,27 +7,41 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 
 	// make sure to register workqueue prometheus metrics
 	_ "k8s.io/component-base/metrics/prometheus/workqueue"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	rolloutlister "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
 
 type MetricsServer struct {
 	*http.Server
 	reconcileHistogram *prometheus.HistogramVec
 	errorCounter       *prometheus.CounterVec
+	providerHistogram  *prometheus.HistogramVec
+	providerErrors     *prometheus.CounterVec
+	measurementPhases  *prometheus.CounterVec
+	serviceSwitches    *prometheus.CounterVec
+	notificationsSent  *prometheus.CounterVec
+	notificationRetry  *prometheus.CounterVec
+	leader             prometheus.Gauge
 	k8sRequestsCounter *K8sRequestsCountProvider
 }
 
 const (
 	// MetricsPath is the endpoint to collect rollout metrics
 	MetricsPath = "/metrics"
+	// HealthzPath is the liveness endpoint of the controller, served by every replica
+	HealthzPath = "/healthz"
 )
 
 // Follow Prometheus naming practices
@@ -39,6 +53,10 @@ var (
 
 	descRolloutReconcilePhaseLabels = append(descRolloutWithStrategyLabels, "phase")
 
+	descRolloutRevisionLabels = append(descRolloutWithStrategyLabels, "revision", "pod_template_hash", "role")
+
+	descMetricProviderLabels = []string{"namespace", "rollout", "provider"}
+
 	descRolloutInfo = prometheus.NewDesc(
 		"rollout_info",
 		"Information about rollout.",
@@ -59,6 +77,57 @@ var (
 		descRolloutReconcilePhaseLabels,
 		nil,
 	)
+
+	descRolloutCurrentStep = prometheus.NewDesc(
+		"rollout_current_step_index",
+		"Index of the current canary step of the rollout. Equals the number of steps once all steps completed.",
+		descRolloutWithStrategyLabels,
+		nil,
+	)
+
+	descRolloutDesiredWeight = prometheus.NewDesc(
+		"rollout_canary_desired_weight",
+		"Percentage of the traffic the canary should receive at the current step.",
+		descRolloutWithStrategyLabels,
+		nil,
+	)
+
+	descRolloutActualWeight = prometheus.NewDesc(
+		"rollout_canary_actual_weight",
+		"Percentage of the available pods of the rollout which run the new revision.",
+		descRolloutWithStrategyLabels,
+		nil,
+	)
+
+	descRolloutReplicas = prometheus.NewDesc(
+		"rollout_replicas",
+		"Desired, current, updated and available replicas of the rollout.",
+		append(descRolloutWithStrategyLabels, "type"),
+		nil,
+	)
+
+	descRolloutRevisionReplicas = prometheus.NewDesc(
+		"rollout_revision_replicas",
+		"Desired replicas of a revision of the rollout.",
+		descRolloutRevisionLabels,
+		nil,
+	)
+
+	descRolloutRevisionAvailableReplicas = prometheus.NewDesc(
+		"rollout_revision_available_replicas",
+		"Available replicas of a revision of the rollout.",
+		descRolloutRevisionLabels,
+		nil,
+	)
+)
+
+const (
+	// RevisionRoleNew is the role of the revision of the current pod template
+	RevisionRoleNew = "new"
+	// RevisionRoleStable is the role of the stable revision of a canary or the active revision of a blue green rollout
+	RevisionRoleStable = "stable"
+	// RevisionRoleOld is the role of the revisions which are neither new nor stable
+	RevisionRoleOld = "old"
 )
 
 // RolloutPhase the phases of a reconcile can have
@@ -80,16 +149,20 @@ const (
 	Error RolloutPhase = "Error"
 )
 
-// NewMetricsServer returns a new prometheus server which collects rollout metrics
-func NewMetricsServer(addr string, rolloutLister rolloutlister.RolloutLister, k8sRequestProvider *K8sRequestsCountProvider) *MetricsServer {
+// NewMetricsServer returns a new prometheus server which collects rollout metrics. The replica set
+// lister is optional and the replica counts per revision are not collected without it.
+func NewMetricsServer(addr string, rolloutLister rolloutlister.RolloutLister, replicaSetLister appslisters.ReplicaSetLister, k8sRequestProvider *K8sRequestsCountProvider) *MetricsServer {
 	mux := http.NewServeMux()
-	rolloutRegistry := NewRolloutRegistry(rolloutLister)
+	rolloutRegistry := NewRolloutRegistry(rolloutLister, replicaSetLister)
 	mux.Handle(MetricsPath, promhttp.HandlerFor(prometheus.Gatherers{
 		// contains app controller specific metrics
 		rolloutRegistry,
 		// contains process, golang and controller workqueues metrics
 		prometheus.DefaultGatherer,
 	}, promhttp.HandlerOpts{}))
+	mux.HandleFunc(HealthzPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
 
 	reconcileHistogram := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -112,6 +185,69 @@ func NewMetricsServer(addr string, rolloutLister rolloutlister.RolloutLister, k8
 
 	rolloutRegistry.MustRegister(errorCounter)
 
+	providerHistogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "analysis_run_metric_provider_duration_seconds",
+			Help:    "Duration of metric provider calls made while taking measurements.",
+			Buckets: []float64{0.05, 0.1, .25, .5, 1, 2.5, 5, 10},
+		},
+		append(descMetricProviderLabels, "operation"),
+	)
+	rolloutRegistry.MustRegister(providerHistogram)
+
+	providerErrors := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "analysis_run_metric_provider_error_total",
+			Help: "Measurements which ended in an Error phase, by metric provider.",
+		},
+		descMetricProviderLabels,
+	)
+	rolloutRegistry.MustRegister(providerErrors)
+
+	measurementPhases := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "analysis_run_metric_measurement_total",
+			Help: "Completed measurements, by metric provider and phase.",
+		},
+		append(descMetricProviderLabels, "phase"),
+	)
+	rolloutRegistry.MustRegister(measurementPhases)
+
+	serviceSwitches := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rollout_service_switch_total",
+			Help: "Switches of the selector of a service of the rollout to a new revision.",
+		},
+		append(descRolloutDefaultLabels, "service"),
+	)
+	rolloutRegistry.MustRegister(serviceSwitches)
+
+	notificationsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_delivery_total",
+			Help: "Notifications delivered or given up on, by notification service.",
+		},
+		[]string{"service", "result"},
+	)
+	rolloutRegistry.MustRegister(notificationsSent)
+
+	notificationRetry := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_delivery_retry_total",
+			Help: "Notification deliveries which failed and were queued for a retry.",
+		},
+		[]string{"service"},
+	)
+	rolloutRegistry.MustRegister(notificationRetry)
+
+	leader := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "controller_leader",
+			Help: "Whether the controller replica is the leader which reconciles the resources (1) or is on standby (0).",
+		},
+	)
+	rolloutRegistry.MustRegister(leader)
+
 	return &MetricsServer{
 		Server: &http.Server{
 			Addr:    addr,
@@ -119,6 +255,13 @@ func NewMetricsServer(addr string, rolloutLister rolloutlister.RolloutLister, k8
 		},
 		reconcileHistogram: reconcileHistogram,
 		errorCounter:       errorCounter,
+		providerHistogram:  providerHistogram,
+		providerErrors:     providerErrors,
+		measurementPhases:  measurementPhases,
+		serviceSwitches:    serviceSwitches,
+		notificationsSent:  notificationsSent,
+		notificationRetry:  notificationRetry,
+		leader:             leader,
 		k8sRequestsCounter: k8sRequestProvider,
 	}
 }
@@ -133,6 +276,51 @@ func (m *MetricsServer) IncError(namespace, name string) {
 	m.errorCounter.WithLabelValues(namespace, name).Inc()
 }
 
+// ObserveMetricProvider records the duration of a metric provider call made for an AnalysisRun
+// and counts it as an error if the measurement ended in an Error phase. The rollout label is empty
+// for AnalysisRuns not owned by a rollout.
+func (m *MetricsServer) ObserveMetricProvider(namespace, rollout, provider, operation string, duration time.Duration, failed bool) {
+	m.providerHistogram.WithLabelValues(namespace, rollout, provider, operation).Observe(duration.Seconds())
+	if failed {
+		m.providerErrors.WithLabelValues(namespace, rollout, provider).Inc()
+	}
+}
+
+// IncMeasurement counts a completed measurement of an AnalysisRun by the phase it ended in. The
+// rollout label is empty for AnalysisRuns not owned by a rollout.
+func (m *MetricsServer) IncMeasurement(namespace, rollout, provider string, phase v1alpha1.AnalysisPhase) {
+	m.measurementPhases.WithLabelValues(namespace, rollout, provider, string(phase)).Inc()
+}
+
+// IncServiceSwitch counts a switch of the selector of a service of the rollout
+func (m *MetricsServer) IncServiceSwitch(rollout *v1alpha1.Rollout, service string) {
+	m.serviceSwitches.WithLabelValues(rollout.Namespace, rollout.Name, service).Inc()
+}
+
+// IncNotificationDelivery counts a notification which was delivered (succeeded is true) or dropped
+// after its last delivery attempt failed
+func (m *MetricsServer) IncNotificationDelivery(service string, succeeded bool) {
+	result := "success"
+	if !succeeded {
+		result = "failure"
+	}
+	m.notificationsSent.WithLabelValues(service, result).Inc()
+}
+
+// IncNotificationRetry counts a failed notification delivery which will be retried
+func (m *MetricsServer) IncNotificationRetry(service string) {
+	m.notificationRetry.WithLabelValues(service).Inc()
+}
+
+// SetLeader records whether the controller replica is the leader
+func (m *MetricsServer) SetLeader(leader bool) {
+	if leader {
+		m.leader.Set(1)
+	} else {
+		m.leader.Set(0)
+	}
+}
+
 // calculatePhase calculates where a Rollout is in a Completed, Paused, Error, Timeout, or InvalidSpec phase
 func calculatePhase(rollout *v1alpha1.Rollout) RolloutPhase {
 	phase := Progressing
@@ -159,20 +347,22 @@ func calculatePhase(rollout *v1alpha1.Rollout) RolloutPhase {
 }
 
 type rolloutCollector struct {
-	store rolloutlister.RolloutLister
+	store            rolloutlister.RolloutLister
+	replicaSetLister appslisters.ReplicaSetLister
 }
 
 // NewRolloutCollector returns a prometheus collector for rollout metrics
-func NewRolloutCollector(rolloutLister rolloutlister.RolloutLister) prometheus.Collector {
+func NewRolloutCollector(rolloutLister rolloutlister.RolloutLister, replicaSetLister appslisters.ReplicaSetLister) prometheus.Collector {
 	return &rolloutCollector{
-		store: rolloutLister,
+		store:            rolloutLister,
+		replicaSetLister: replicaSetLister,
 	}
 }
 
 // NewRolloutRegistry creates a new prometheus registry that collects rollouts
-func NewRolloutRegistry(rolloutLister rolloutlister.RolloutLister) *prometheus.Registry {
+func NewRolloutRegistry(rolloutLister rolloutlister.RolloutLister, replicaSetLister appslisters.ReplicaSetLister) *prometheus.Registry {
 	registry := prometheus.NewRegistry()
-	registry.MustRegister(NewRolloutCollector(rolloutLister))
+	registry.MustRegister(NewRolloutCollector(rolloutLister, replicaSetLister))
 	return registry
 }
 
@@ -180,6 +370,13 @@ func NewRolloutRegistry(rolloutLister rolloutlister.RolloutLister) *prometheus.R
 func (c *rolloutCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- descRolloutInfo
 	ch <- descRolloutCreated
+	ch <- descRolloutPhaseLabels
+	ch <- descRolloutCurrentStep
+	ch <- descRolloutDesiredWeight
+	ch <- descRolloutActualWeight
+	ch <- descRolloutReplicas
+	ch <- descRolloutRevisionReplicas
+	ch <- descRolloutRevisionAvailableReplicas
 }
 
 // Collect implements the prometheus.Collector interface
@@ -190,10 +387,30 @@ func (c *rolloutCollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 	for _, rollout := range rollouts {
-		collectRollouts(ch, rollout)
+		collectRollouts(ch, rollout, c.getReplicaSets(rollout))
 	}
 }
 
+// getReplicaSets returns the replica sets controlled by the rollout, or nil if the collector has no
+// replica set lister
+func (c *rolloutCollector) getReplicaSets(rollout *v1alpha1.Rollout) []*appsv1.ReplicaSet {
+	if c.replicaSetLister == nil {
+		return nil
+	}
+	rsList, err := c.replicaSetLister.ReplicaSets(rollout.Namespace).List(labels.Everything())
+	if err != nil {
+		log.Warnf("Failed to collect replica sets of rollout '%s/%s': %v", rollout.Namespace, rollout.Name, err)
+		return nil
+	}
+	var owned []*appsv1.ReplicaSet
+	for _, rs := range rsList {
+		if ref := metav1.GetControllerOf(rs); ref != nil && ref.UID == rollout.UID {
+			owned = append(owned, rs)
+		}
+	}
+	return owned
+}
+
 func boolFloat64(b bool) float64 {
 	if b {
 		return 1
@@ -201,7 +418,21 @@ func boolFloat64(b bool) float64 {
 	return 0
 }
 
-func collectRollouts(ch chan<- prometheus.Metric, rollout *v1alpha1.Rollout) {
+// revisionRole returns whether the replica set runs the new, stable or an old revision of the rollout
+func revisionRole(rollout *v1alpha1.Rollout, newRS, rs *appsv1.ReplicaSet) string {
+	hash := replicasetutil.GetPodTemplateHash(rs)
+	switch {
+	case newRS != nil && rs.Name == newRS.Name:
+		return RevisionRoleNew
+	case rollout.Spec.Strategy.Canary != nil && hash == rollout.Status.Canary.StableRS:
+		return RevisionRoleStable
+	case rollout.Spec.Strategy.BlueGreen != nil && hash == rollout.Status.BlueGreen.ActiveSelector:
+		return RevisionRoleStable
+	}
+	return RevisionRoleOld
+}
+
+func collectRollouts(ch chan<- prometheus.Metric, rollout *v1alpha1.Rollout, rsList []*appsv1.ReplicaSet) {
 
 	addConstMetric := func(desc *prometheus.Desc, t prometheus.ValueType, v float64, lv ...string) {
 		lv = append([]string{rollout.Namespace, rollout.Name, defaults.GetStrategyType(rollout)}, lv...)
@@ -222,4 +453,31 @@ func collectRollouts(ch chan<- prometheus.Metric, rollout *v1alpha1.Rollout) {
 	addGauge(descRolloutPhaseLabels, boolFloat64(calculatedPhase == Timeout), string(Timeout))
 	addGauge(descRolloutPhaseLabels, boolFloat64(calculatedPhase == Error), string(Error))
 	addGauge(descRolloutPhaseLabels, boolFloat64(calculatedPhase == InvalidSpec), string(InvalidSpec))
+
+	addGauge(descRolloutReplicas, float64(defaults.GetReplicasOrDefault(rollout.Spec.Replicas)), "desired")
+	addGauge(descRolloutReplicas, float64(rollout.Status.Replicas), "current")
+	addGauge(descRolloutReplicas, float64(rollout.Status.UpdatedReplicas), "updated")
+	addGauge(descRolloutReplicas, float64(rollout.Status.AvailableReplicas), "available")
+
+	newRS := replicasetutil.FindNewReplicaSet(rollout, rsList)
+	for _, rs := range rsList {
+		lv := []string{rs.Annotations[annotations.RevisionAnnotation], replicasetutil.GetPodTemplateHash(rs), revisionRole(rollout, newRS, rs)}
+		addGauge(descRolloutRevisionReplicas, float64(defaults.GetReplicasOrDefault(rs.Spec.Replicas)), lv...)
+		addGauge(descRolloutRevisionAvailableReplicas, float64(rs.Status.AvailableReplicas), lv...)
+	}
+
+	if rollout.Spec.Strategy.Canary == nil {
+		return
+	}
+	if _, currentStepIndex := replicasetutil.GetCurrentCanaryStep(rollout); currentStepIndex != nil {
+		addGauge(descRolloutCurrentStep, float64(*currentStepIndex))
+	}
+	addGauge(descRolloutDesiredWeight, float64(replicasetutil.GetCurrentSetWeight(rollout)))
+	if rsList != nil {
+		actualWeight := float64(0)
+		if available := replicasetutil.GetAvailableReplicaCountForReplicaSets(rsList); available > 0 && newRS != nil {
+			actualWeight = float64(newRS.Status.AvailableReplicas) * 100 / float64(available)
+		}
+		addGauge(descRolloutActualWeight, actualWeight)
+	}
 }
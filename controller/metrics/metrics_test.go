@@ -7,16 +7,22 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	informer "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
 	lister "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
 )
 
 // assertMetricsPrinted asserts every line in the expected lines appears in the body
@@ -58,7 +64,7 @@ func newFakeLister(fakeRollout ...string) (context.CancelFunc, lister.RolloutLis
 func testRolloutDescribe(t *testing.T, fakeRollout string, expectedResponse string) {
 	cancel, rolloutLister := newFakeLister(fakeRollout)
 	defer cancel()
-	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, &K8sRequestsCountProvider{})
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
 	req, err := http.NewRequest("GET", "/metrics", nil)
 	assert.NoError(t, err)
 	rr := httptest.NewRecorder()
@@ -123,3 +129,175 @@ func TestMetrics(t *testing.T) {
 		testRolloutDescribe(t, combination.rollout, combination.expectedResponse)
 	}
 }
+
+func TestObserveMetricProvider(t *testing.T) {
+	cancel, rolloutLister := newFakeLister()
+	defer cancel()
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
+	metricsServ.ObserveMetricProvider("default", "guestbook", "prometheus", "run", 200*time.Millisecond, false)
+	metricsServ.ObserveMetricProvider("default", "guestbook", "prometheus", "run", 2*time.Second, true)
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assertMetricsPrinted(t, `analysis_run_metric_provider_duration_seconds_count{namespace="default",operation="run",provider="prometheus",rollout="guestbook"} 2
+analysis_run_metric_provider_error_total{namespace="default",provider="prometheus",rollout="guestbook"} 1`, rr.Body.String())
+}
+
+func TestIncMeasurement(t *testing.T) {
+	cancel, rolloutLister := newFakeLister()
+	defer cancel()
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
+	metricsServ.IncMeasurement("default", "guestbook", "prometheus", v1alpha1.AnalysisPhaseSuccessful)
+	metricsServ.IncMeasurement("default", "guestbook", "prometheus", v1alpha1.AnalysisPhaseSuccessful)
+	metricsServ.IncMeasurement("default", "guestbook", "prometheus", v1alpha1.AnalysisPhaseFailed)
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assertMetricsPrinted(t, `analysis_run_metric_measurement_total{namespace="default",phase="Successful",provider="prometheus",rollout="guestbook"} 2
+analysis_run_metric_measurement_total{namespace="default",phase="Failed",provider="prometheus",rollout="guestbook"} 1`, rr.Body.String())
+}
+
+func TestIncServiceSwitch(t *testing.T) {
+	cancel, rolloutLister := newFakeLister()
+	defer cancel()
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
+	rollout := newFakeRollout(fakeRollout)
+	metricsServ.IncServiceSwitch(rollout, "active")
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assertMetricsPrinted(t, `rollout_service_switch_total{name="guestbook-bluegreen",namespace="default",service="active"} 1`, rr.Body.String())
+}
+
+func TestIncNotificationDelivery(t *testing.T) {
+	cancel, rolloutLister := newFakeLister()
+	defer cancel()
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
+	metricsServ.IncNotificationDelivery("slack", true)
+	metricsServ.IncNotificationDelivery("slack", false)
+	metricsServ.IncNotificationRetry("slack")
+	metricsServ.IncNotificationRetry("slack")
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assertMetricsPrinted(t, `notification_delivery_total{result="success",service="slack"} 1
+notification_delivery_total{result="failure",service="slack"} 1
+notification_delivery_retry_total{service="slack"} 2`, rr.Body.String())
+}
+
+func TestSetLeader(t *testing.T) {
+	cancel, rolloutLister := newFakeLister()
+	defer cancel()
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, nil, &K8sRequestsCountProvider{})
+	metricsServ.SetLeader(true)
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assertMetricsPrinted(t, `controller_leader 1`, rr.Body.String())
+
+	req, err = http.NewRequest("GET", HealthzPath, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, "ok", rr.Body.String())
+}
+
+const fakeCanaryRollout = `
+apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: guestbook-canary
+  namespace: default
+  uid: 2d6fbb4f-5e70-4f47-a4f9-5f4ae3b2a0c1
+spec:
+  replicas: 4
+  selector:
+    matchLabels:
+      app: guestbook
+  template:
+    metadata:
+      labels:
+        app: guestbook
+    spec:
+      containers:
+      - name: guestbook
+        image: gcr.io/heptio-images/ks-guestbook-demo:0.2
+  strategy:
+    canary:
+      steps:
+      - setWeight: 50
+      - pause: {}
+status:
+  currentStepIndex: 1
+  replicas: 4
+  updatedReplicas: 2
+  availableReplicas: 3
+  canary:
+    stableRS: 6cb88c6bcf
+`
+
+func newFakeReplicaSet(rollout *v1alpha1.Rollout, hash, revision string, image string, replicas, available int32) *appsv1.ReplicaSet {
+	template := rollout.Spec.Template.DeepCopy()
+	template.Spec.Containers[0].Image = image
+	template.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] = hash
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            rollout.Name + "-" + hash,
+			Namespace:       rollout.Namespace,
+			Labels:          map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: hash},
+			Annotations:     map[string]string{annotations.RevisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rollout, v1alpha1.SchemeGroupVersion.WithKind("Rollout"))},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: pointer.Int32Ptr(replicas),
+			Template: *template,
+		},
+		Status: appsv1.ReplicaSetStatus{AvailableReplicas: available},
+	}
+}
+
+func TestCanaryRolloutMetrics(t *testing.T) {
+	cancel, rolloutLister := newFakeLister(fakeCanaryRollout)
+	defer cancel()
+	rollout := newFakeRollout(fakeCanaryRollout)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, indexer.Add(newFakeReplicaSet(rollout, "5f8b8f8d4c", "2", "gcr.io/heptio-images/ks-guestbook-demo:0.2", 2, 1)))
+	assert.NoError(t, indexer.Add(newFakeReplicaSet(rollout, "6cb88c6bcf", "1", "gcr.io/heptio-images/ks-guestbook-demo:0.1", 2, 2)))
+	// replica sets of other rollouts are not collected
+	other := newFakeReplicaSet(rollout, "7d9c8b7f6d", "1", "gcr.io/heptio-images/ks-guestbook-demo:0.1", 1, 1)
+	other.OwnerReferences = nil
+	assert.NoError(t, indexer.Add(other))
+
+	metricsServ := NewMetricsServer("localhost:8080", rolloutLister, appslisters.NewReplicaSetLister(indexer), &K8sRequestsCountProvider{})
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	metricsServ.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	body := rr.Body.String()
+	assertMetricsPrinted(t, `rollout_info{name="guestbook-canary",namespace="default",strategy="canary"} 1
+rollout_current_step_index{name="guestbook-canary",namespace="default",strategy="canary"} 1
+rollout_canary_desired_weight{name="guestbook-canary",namespace="default",strategy="canary"} 50
+rollout_canary_actual_weight{name="guestbook-canary",namespace="default",strategy="canary"} 33.333333333333336
+rollout_replicas{name="guestbook-canary",namespace="default",strategy="canary",type="desired"} 4
+rollout_replicas{name="guestbook-canary",namespace="default",strategy="canary",type="available"} 3
+rollout_revision_replicas{name="guestbook-canary",namespace="default",pod_template_hash="5f8b8f8d4c",revision="2",role="new",strategy="canary"} 2
+rollout_revision_available_replicas{name="guestbook-canary",namespace="default",pod_template_hash="6cb88c6bcf",revision="1",role="stable",strategy="canary"} 2`, body)
+	assert.NotContains(t, body, "7d9c8b7f6d")
+}
@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+const (
+	// DefaultPort is the default port the webhook server listens on
+	DefaultPort = 8443
+	// ValidatePath is the endpoint of the validating admission webhook
+	ValidatePath = "/validate"
+)
+
+// Validator rejects invalid rollouts, experiments and analysis templates at admission time, with
+// the same checks the controllers report as InvalidSpec conditions once the objects are accepted
+type Validator struct {
+	deploymentLister      appslisters.DeploymentLister
+	templateLister        listers.AnalysisTemplateLister
+	clusterTemplateLister listers.ClusterAnalysisTemplateLister
+}
+
+// NewValidator returns a validator resolving the Deployments referenced by workloadRefs and the
+// analysis templates referenced by rollouts and experiments with the listers
+func NewValidator(deploymentLister appslisters.DeploymentLister, templateLister listers.AnalysisTemplateLister, clusterTemplateLister listers.ClusterAnalysisTemplateLister) *Validator {
+	return &Validator{
+		deploymentLister:      deploymentLister,
+		templateLister:        templateLister,
+		clusterTemplateLister: clusterTemplateLister,
+	}
+}
+
+// NewServer returns a webhook server listening on addr. The API server only calls webhooks over
+// TLS, so the server is expected to be started with ListenAndServeTLS.
+func NewServer(addr string, validator *Validator) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(ValidatePath, validator)
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface by answering the AdmissionReview of the request
+func (v *Validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+	response := &admissionv1.AdmissionResponse{
+		UID:     review.Request.UID,
+		Allowed: true,
+	}
+	if err := v.validate(review.Request); err != nil {
+		log.Infof("Rejected %s '%s/%s': %v", review.Request.Kind.Kind, review.Request.Namespace, review.Request.Name, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		}
+	}
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Errorf("Failed to write admission review: %v", err)
+	}
+}
+
+// validate returns the reason the object of the request is invalid, or nil if it is valid. Updates
+// which leave the spec unchanged are always allowed, so the controllers can still update the
+// metadata and status of objects accepted before the webhook was installed.
+func (v *Validator) validate(req *admissionv1.AdmissionRequest) error {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil
+	}
+	switch req.Kind.Kind {
+	case "Rollout":
+		var ro, old v1alpha1.Rollout
+		if err := decode(req, &ro, &old); err != nil {
+			return err
+		}
+		if req.Operation == admissionv1.Update && reflect.DeepEqual(ro.Spec, old.Spec) {
+			return nil
+		}
+		return v.validateRollout(&ro, req.Namespace)
+	case "Experiment":
+		var ex, old v1alpha1.Experiment
+		if err := decode(req, &ex, &old); err != nil {
+			return err
+		}
+		if req.Operation == admissionv1.Update && reflect.DeepEqual(ex.Spec, old.Spec) {
+			return nil
+		}
+		return v.validateExperiment(&ex, req.Namespace)
+	case "AnalysisTemplate":
+		var template, old v1alpha1.AnalysisTemplate
+		if err := decode(req, &template, &old); err != nil {
+			return err
+		}
+		if req.Operation == admissionv1.Update && reflect.DeepEqual(template.Spec, old.Spec) {
+			return nil
+		}
+		return analysisutil.ValidateMetrics(template.Spec.Metrics)
+	case "ClusterAnalysisTemplate":
+		var template, old v1alpha1.ClusterAnalysisTemplate
+		if err := decode(req, &template, &old); err != nil {
+			return err
+		}
+		if req.Operation == admissionv1.Update && reflect.DeepEqual(template.Spec, old.Spec) {
+			return nil
+		}
+		return analysisutil.ValidateMetrics(template.Spec.Metrics)
+	}
+	return nil
+}
+
+// decode unmarshals the object of the request, and the previous object of updates
+func decode(req *admissionv1.AdmissionRequest, obj, old interface{}) error {
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", req.Kind.Kind, err)
+	}
+	if req.Operation == admissionv1.Update {
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", req.Kind.Kind, err)
+		}
+	}
+	return nil
+}
+
+// validateRollout verifies the spec of the rollout and the arguments of its analyses
+func (v *Validator) validateRollout(ro *v1alpha1.Rollout, namespace string) error {
+	if ref := ro.Spec.WorkloadRef; ref != nil && !conditions.InvalidWorkloadRef(ref) {
+		// the pod template and selector are read from the Deployment, which may be created after
+		// the rollout
+		deployment, err := v.deploymentLister.Deployments(namespace).Get(ref.Name)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		ro.Spec.Template = *deployment.Spec.Template.DeepCopy()
+		ro.Spec.Selector = deployment.Spec.Selector.DeepCopy()
+	}
+	if cond := conditions.VerifyRolloutSpec(ro, nil); cond != nil {
+		return fmt.Errorf("%s", cond.Message)
+	}
+	var paths []string
+	analyses := map[string]*v1alpha1.RolloutAnalysis{}
+	addAnalysis := func(path string, analysis *v1alpha1.RolloutAnalysis) {
+		if analysis != nil {
+			paths = append(paths, path)
+			analyses[path] = analysis
+		}
+	}
+	if blueGreen := ro.Spec.Strategy.BlueGreen; blueGreen != nil {
+		addAnalysis("blueGreen.prePromotionAnalysis", blueGreen.PrePromotionAnalysis)
+		addAnalysis("blueGreen.postPromotionAnalysis", blueGreen.PostPromotionAnalysis)
+	}
+	if canary := ro.Spec.Strategy.Canary; canary != nil {
+		if canary.Analysis != nil {
+			addAnalysis("canary.analysis", &canary.Analysis.RolloutAnalysis)
+		}
+		for i, step := range canary.Steps {
+			addAnalysis(fmt.Sprintf("canary.steps[%d].analysis", i), step.Analysis)
+			if step.Experiment == nil {
+				continue
+			}
+			for _, analysis := range step.Experiment.Analyses {
+				templates := []v1alpha1.RolloutAnalysisTemplates{{TemplateName: analysis.TemplateName, ClusterScope: analysis.ClusterScope}}
+				path := fmt.Sprintf("canary.steps[%d].experiment.analyses[%s]", i, analysis.Name)
+				if err := v.validateArgs(namespace, path, templates, rolloutArgs(analysis.Args)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, path := range paths {
+		analysis := analyses[path]
+		templates := analysis.Templates
+		if analysis.TemplateName != "" {
+			templates = append(templates, v1alpha1.RolloutAnalysisTemplates{TemplateName: analysis.TemplateName})
+		}
+		if err := v.validateArgs(namespace, path, templates, rolloutArgs(analysis.Args)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateExperiment verifies the spec of the experiment and the arguments of its analyses
+func (v *Validator) validateExperiment(ex *v1alpha1.Experiment, namespace string) error {
+	if cond := conditions.VerifyExperimentSpec(ex, nil); cond != nil {
+		return fmt.Errorf("%s", cond.Message)
+	}
+	for _, analysis := range ex.Spec.Analyses {
+		templates := []v1alpha1.RolloutAnalysisTemplates{{TemplateName: analysis.TemplateName, ClusterScope: analysis.ClusterScope}}
+		if err := v.validateArgs(namespace, fmt.Sprintf("analyses[%s]", analysis.Name), templates, analysis.Args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rolloutArgs returns the arguments of a rollout analysis as the arguments of an AnalysisRun. The
+// values are only resolved when the run is created, so they are left empty.
+func rolloutArgs(args []v1alpha1.AnalysisRunArgument) []v1alpha1.Argument {
+	arguments := make([]v1alpha1.Argument, len(args))
+	for i := range args {
+		value := args[i].Value
+		arguments[i] = v1alpha1.Argument{Name: args[i].Name, Value: &value}
+	}
+	return arguments
+}
+
+// validateArgs returns an error if the arguments do not resolve every argument of the templates.
+// Templates which do not exist yet are not verified.
+func (v *Validator) validateArgs(namespace, path string, refs []v1alpha1.RolloutAnalysisTemplates, args []v1alpha1.Argument) error {
+	var templates []*v1alpha1.AnalysisTemplate
+	for _, ref := range refs {
+		template, err := analysisutil.GetTemplate(v.templateLister, v.clusterTemplateLister, namespace, ref.TemplateName, ref.ClusterScope)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		templates = append(templates, template)
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	flattened, err := analysisutil.FlattenTemplates(templates)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if _, err := analysisutil.MergeArgs(args, flattened.Spec.Args); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
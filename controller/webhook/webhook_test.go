@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+)
+
+func newValidator(objs ...interface{}) *Validator {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range objs {
+		_ = indexer.Add(obj)
+	}
+	return NewValidator(appslisters.NewDeploymentLister(indexer), listers.NewAnalysisTemplateLister(indexer), listers.NewClusterAnalysisTemplateLister(indexer))
+}
+
+func newRollout() *v1alpha1.Rollout {
+	labels := map[string]string{"app": "guestbook"}
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.RolloutSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "guestbook", Image: "guestbook:v1"}},
+				},
+			},
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					Steps: []v1alpha1.CanaryStep{{
+						SetWeight: pointer.Int32Ptr(20),
+					}, {
+						Analysis: &v1alpha1.RolloutAnalysis{
+							Templates: []v1alpha1.RolloutAnalysisTemplates{{TemplateName: "success-rate"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func newTemplate() *v1alpha1.AnalysisTemplate {
+	return &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "success-rate", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Args: []v1alpha1.Argument{{Name: "service-name"}},
+			Metrics: []v1alpha1.Metric{{
+				Name: "success-rate",
+				Provider: v1alpha1.MetricProvider{
+					Prometheus: &v1alpha1.PrometheusMetric{Query: "up{service='{{args.service-name}}'}"},
+				},
+			}},
+		},
+	}
+}
+
+func newRequest(t *testing.T, operation admissionv1.Operation, kind string, obj, old runtime.Object) *admissionv1.AdmissionRequest {
+	raw, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	req := &admissionv1.AdmissionRequest{
+		UID:       types.UID("abc123"),
+		Kind:      metav1.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: kind},
+		Namespace: metav1.NamespaceDefault,
+		Operation: operation,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+	if old != nil {
+		raw, err := json.Marshal(old)
+		assert.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func TestValidateRollout(t *testing.T) {
+	v := newValidator()
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", newRollout(), nil)))
+
+	ro := newRollout()
+	ro.Spec.Strategy.Canary.Steps[0].SetWeight = pointer.Int32Ptr(120)
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)), "SetWeight needs to be between 0 and 100")
+
+	ro = newRollout()
+	ro.Spec.Strategy.BlueGreen = &v1alpha1.BlueGreenStrategy{ActiveService: "active"}
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)), "Multiple Strategies can not be listed")
+
+	ro = newRollout()
+	ro.Spec.Strategy = v1alpha1.RolloutStrategy{BlueGreen: &v1alpha1.BlueGreenStrategy{PreviewService: "preview"}}
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)), "Rollout has missing field '.Spec.Strategy.BlueGreen.ActiveService'")
+
+	// updates which leave the spec unchanged are allowed
+	updated := ro.DeepCopy()
+	updated.Annotations = map[string]string{"rollout.argoproj.io/revision": "2"}
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Update, "Rollout", updated, ro)))
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Delete, "Rollout", ro, nil)))
+}
+
+func TestValidateRolloutAnalysisArgs(t *testing.T) {
+	// args of templates which do not exist yet are not verified
+	assert.NoError(t, newValidator().validate(newRequest(t, admissionv1.Create, "Rollout", newRollout(), nil)))
+
+	v := newValidator(newTemplate())
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", newRollout(), nil)), "canary.steps[1].analysis: args.service-name was not resolved")
+
+	ro := newRollout()
+	ro.Spec.Strategy.Canary.Steps[1].Analysis.Args = []v1alpha1.AnalysisRunArgument{{Name: "service-name", Value: "guestbook"}}
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)))
+}
+
+func TestValidateWorkloadRefRollout(t *testing.T) {
+	ro := newRollout()
+	ro.Spec.Selector = nil
+	ro.Spec.Template = corev1.PodTemplateSpec{}
+	ro.Spec.WorkloadRef = &v1alpha1.ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "guestbook"}
+
+	// the Deployment may be created after the rollout
+	assert.NoError(t, newValidator().validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)))
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: metav1.NamespaceDefault},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{},
+			Template: newRollout().Spec.Template,
+		},
+	}
+	assert.EqualError(t, newValidator(deployment).validate(newRequest(t, admissionv1.Create, "Rollout", ro, nil)), "This rollout is selecting all pods. A non-empty selector is required.")
+}
+
+func TestValidateExperiment(t *testing.T) {
+	ex := &v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.ExperimentSpec{
+			Templates: []v1alpha1.TemplateSpec{{
+				Name:     "baseline",
+				Selector: newRollout().Spec.Selector,
+				Template: newRollout().Spec.Template,
+			}},
+			Analyses: []v1alpha1.ExperimentAnalysisTemplateRef{{Name: "success-rate", TemplateName: "success-rate"}},
+		},
+	}
+	v := newValidator(newTemplate())
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Experiment", ex, nil)), "analyses[success-rate]: args.service-name was not resolved")
+
+	ex.Spec.Analyses[0].Args = []v1alpha1.Argument{{Name: "service-name", Value: pointer.StringPtr("guestbook")}}
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Create, "Experiment", ex, nil)))
+
+	ex.Spec.Templates[0].Selector = nil
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "Experiment", ex, nil)), "Rollout has missing field '.Spec.Templates[0].Selector'")
+}
+
+func TestValidateAnalysisTemplate(t *testing.T) {
+	v := newValidator()
+	template := newTemplate()
+	assert.NoError(t, v.validate(newRequest(t, admissionv1.Create, "AnalysisTemplate", template, nil)))
+
+	template.Spec.Metrics[0].Count = 2
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "AnalysisTemplate", template, nil)), "metrics[0]: interval must be specified when count > 1")
+
+	clusterTemplate := &v1alpha1.ClusterAnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "success-rate"},
+		Spec:       template.Spec,
+	}
+	assert.EqualError(t, v.validate(newRequest(t, admissionv1.Create, "ClusterAnalysisTemplate", clusterTemplate, nil)), "metrics[0]: interval must be specified when count > 1")
+}
+
+func TestServeHTTP(t *testing.T) {
+	ro := newRollout()
+	ro.Spec.Strategy.Canary.Steps[0].SetWeight = pointer.Int32Ptr(120)
+	body, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  newRequest(t, admissionv1.Create, "Rollout", ro, nil),
+	})
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	NewServer("0.0.0.0:8443", newValidator()).Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var review admissionv1.AdmissionReview
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &review))
+	assert.Equal(t, "AdmissionReview", review.Kind)
+	assert.Nil(t, review.Request)
+	assert.Equal(t, types.UID("abc123"), review.Response.UID)
+	assert.False(t, review.Response.Allowed)
+	assert.Equal(t, "SetWeight needs to be between 0 and 100", review.Response.Result.Message)
+
+	rr = httptest.NewRecorder()
+	NewServer("0.0.0.0:8443", newValidator()).Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,171 @@
+package daemonset
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
+	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+// daemonSetKind is the kind of the owner reference of the AnalysisRuns created for DaemonSets
+var daemonSetKind = appsv1.SchemeGroupVersion.WithKind("DaemonSet")
+
+// DaemonSetController progressively updates the pods of DaemonSets annotated with a strategy.
+// The DaemonSets use the OnDelete update strategy, and the controller deletes their pods node by
+// node so the DaemonSet controller recreates them from the new revision.
+type DaemonSetController struct {
+	// kubeclientset is a standard kubernetes clientset
+	kubeclientset kubernetes.Interface
+	// argoProjClientset is a clientset for our own API group
+	argoProjClientset clientset.Interface
+
+	daemonSetLister        appslisters.DaemonSetLister
+	analysisRunLister      listers.AnalysisRunLister
+	analysisTemplateLister listers.AnalysisTemplateLister
+
+	daemonSetSynced cache.InformerSynced
+
+	metricsServer *metrics.MetricsServer
+
+	// used for unit testing
+	enqueueDaemonSet      func(obj interface{})
+	enqueueDaemonSetAfter func(obj interface{}, duration time.Duration)
+
+	daemonSetWorkqueue workqueue.RateLimitingInterface
+	// recorder is an event recorder for recording Event resources to the
+	// Kubernetes API.
+	recorder     record.EventRecorder
+	resyncPeriod time.Duration
+	// instanceID is the controller instance id of the DaemonSets updated by the controller
+	instanceID string
+}
+
+// NewDaemonSetController returns a new DaemonSet controller
+func NewDaemonSetController(
+	kubeclientset kubernetes.Interface,
+	argoProjClientset clientset.Interface,
+	daemonSetInformer appsinformers.DaemonSetInformer,
+	analysisRunInformer informers.AnalysisRunInformer,
+	analysisTemplateInformer informers.AnalysisTemplateInformer,
+	resyncPeriod time.Duration,
+	instanceID string,
+	daemonSetWorkQueue workqueue.RateLimitingInterface,
+	metricsServer *metrics.MetricsServer,
+	recorder record.EventRecorder) *DaemonSetController {
+
+	controller := &DaemonSetController{
+		kubeclientset:          kubeclientset,
+		argoProjClientset:      argoProjClientset,
+		daemonSetLister:        daemonSetInformer.Lister(),
+		analysisRunLister:      analysisRunInformer.Lister(),
+		analysisTemplateLister: analysisTemplateInformer.Lister(),
+		daemonSetSynced:        daemonSetInformer.Informer().HasSynced,
+		metricsServer:          metricsServer,
+		daemonSetWorkqueue:     daemonSetWorkQueue,
+		recorder:               recorder,
+		resyncPeriod:           resyncPeriod,
+		instanceID:             instanceID,
+	}
+
+	controller.enqueueDaemonSet = func(obj interface{}) {
+		controllerutil.Enqueue(obj, daemonSetWorkQueue)
+	}
+	controller.enqueueDaemonSetAfter = func(obj interface{}, duration time.Duration) {
+		controllerutil.EnqueueAfter(obj, duration, daemonSetWorkQueue)
+	}
+
+	log.Info("Setting up DaemonSet event handlers")
+	// The status of a DaemonSet changes whenever one of its pods is recreated or becomes available,
+	// so the pods do not need to be watched
+	daemonSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueIfProgressive,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueIfProgressive(new)
+		},
+	})
+
+	analysisRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			controller.enqueueIfCompleted(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueIfCompleted(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.enqueueIfCompleted(obj)
+		},
+	})
+	return controller
+}
+
+// Run starts the DaemonSet workers
+func (c *DaemonSetController) Run(threadiness int, stopCh <-chan struct{}) error {
+	log.Info("Starting DaemonSet workers")
+	controllerutil.RunWorkers(threadiness, c.daemonSetWorkqueue, logutil.DaemonSetKey, c.syncHandler, c.metricsServer, stopCh)
+	log.Info("Shut down DaemonSet workers")
+
+	return nil
+}
+
+func (c *DaemonSetController) syncHandler(key string) error {
+	startTime := time.Now()
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	logCtx := logutil.WithObject(logutil.DaemonSetKey, namespace, name)
+	ds, err := c.daemonSetLister.DaemonSets(namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		logCtx.Info("DaemonSet has been deleted")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ds.DeletionTimestamp != nil || ds.Annotations[StrategyAnnotation] == "" || analysisutil.GetInstanceID(ds) != c.instanceID {
+		return nil
+	}
+	logCtx.Infof("Started syncing DaemonSet at (%v)", startTime)
+	defer func() {
+		duration := time.Since(startTime)
+		logCtx.WithField("time_ms", duration.Seconds()*1e3).Info("Reconciliation completed")
+	}()
+	return c.syncDaemonSet(ds)
+}
+
+// enqueueIfProgressive enqueues DaemonSets annotated with a strategy which belong to the
+// controller instance
+func (c *DaemonSetController) enqueueIfProgressive(obj interface{}) {
+	ds, ok := obj.(*appsv1.DaemonSet)
+	if !ok || ds.Annotations[StrategyAnnotation] == "" || analysisutil.GetInstanceID(ds) != c.instanceID {
+		return
+	}
+	c.enqueueDaemonSet(ds)
+}
+
+// enqueueIfCompleted conditionally enqueues the AnalysisRun's DaemonSet if the run is complete
+func (c *DaemonSetController) enqueueIfCompleted(obj interface{}) {
+	run, ok := obj.(*v1alpha1.AnalysisRun)
+	if !ok {
+		return
+	}
+	if run.Status.Phase.Completed() {
+		controllerutil.EnqueueParentObject(run, daemonSetKind.Kind, c.enqueueDaemonSet)
+	}
+}
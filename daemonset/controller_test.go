@@ -0,0 +1,319 @@
+package daemonset
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
+)
+
+const testStrategy = `
+steps:
+- setWeight: 50
+- analysis:
+    templates:
+    - templateName: node-health
+`
+
+type fixture struct {
+	t          *testing.T
+	client     *fake.Clientset
+	kubeclient *k8sfake.Clientset
+	recorder   *record.FakeRecorder
+	controller *DaemonSetController
+}
+
+func newFixture(t *testing.T, ds *appsv1.DaemonSet, objects []runtime.Object, kubeobjects ...runtime.Object) *fixture {
+	f := &fixture{t: t, recorder: record.NewFakeRecorder(20)}
+	f.client = fake.NewSimpleClientset(objects...)
+	f.kubeclient = k8sfake.NewSimpleClientset(append(kubeobjects, ds)...)
+	i := informers.NewSharedInformerFactory(f.client, 0)
+	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, 0)
+	f.controller = NewDaemonSetController(f.kubeclient, f.client,
+		k8sI.Apps().V1().DaemonSets(),
+		i.Argoproj().V1alpha1().AnalysisRuns(),
+		i.Argoproj().V1alpha1().AnalysisTemplates(),
+		0,
+		"",
+		workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "DaemonSets"),
+		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), nil, &metrics.K8sRequestsCountProvider{}),
+		f.recorder)
+	f.controller.enqueueDaemonSetAfter = func(obj interface{}, duration time.Duration) {}
+	_ = k8sI.Apps().V1().DaemonSets().Informer().GetIndexer().Add(ds)
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *v1alpha1.AnalysisRun:
+			_ = i.Argoproj().V1alpha1().AnalysisRuns().Informer().GetIndexer().Add(o)
+		case *v1alpha1.AnalysisTemplate:
+			_ = i.Argoproj().V1alpha1().AnalysisTemplates().Informer().GetIndexer().Add(o)
+		}
+	}
+	return f
+}
+
+func (f *fixture) run(ds *appsv1.DaemonSet) {
+	assert.NoError(f.t, f.controller.syncHandler(ds.Namespace+"/"+ds.Name))
+}
+
+// status returns the status the controller patched into the DaemonSet
+func (f *fixture) status() *Status {
+	for _, action := range f.kubeclient.Actions() {
+		patch, ok := action.(core.PatchAction)
+		if !ok || !action.Matches("patch", "daemonsets") {
+			continue
+		}
+		var obj appsv1.DaemonSet
+		assert.NoError(f.t, json.Unmarshal(patch.GetPatch(), &obj))
+		status := GetStatus(&obj)
+		return &status
+	}
+	return nil
+}
+
+func (f *fixture) deletedPods() []string {
+	var names []string
+	for _, action := range f.kubeclient.Actions() {
+		if deleteAction, ok := action.(core.DeleteAction); ok && action.Matches("delete", "pods") {
+			names = append(names, deleteAction.GetName())
+		}
+	}
+	return names
+}
+
+func newDaemonSet(status *Status, desired int32) *appsv1.DaemonSet {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node-agent",
+			Namespace:   metav1.NamespaceDefault,
+			UID:         uuid.NewUUID(),
+			Annotations: map[string]string{StrategyAnnotation: testStrategy},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "node-agent"}},
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: desired},
+	}
+	if status != nil {
+		value, _ := json.Marshal(status)
+		ds.Annotations[StatusAnnotation] = string(value)
+	}
+	return ds
+}
+
+func newRevision(ds *appsv1.DaemonSet, hash string, revision int64) *appsv1.ControllerRevision {
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-%s", ds.Name, hash),
+			Namespace:       ds.Namespace,
+			Labels:          map[string]string{"app": "node-agent", appsv1.DefaultDaemonSetUniqueLabelKey: hash},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ds, daemonSetKind)},
+		},
+		Revision: revision,
+	}
+}
+
+func newPod(ds *appsv1.DaemonSet, node, hash string, ready bool) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-%s", ds.Name, node),
+			Namespace:       ds.Namespace,
+			Labels:          map[string]string{"app": "node-agent", appsv1.DefaultDaemonSetUniqueLabelKey: hash},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ds, daemonSetKind)},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             readyStatus,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+			}},
+		},
+	}
+}
+
+func newTemplate() *v1alpha1.AnalysisTemplate {
+	return &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-health", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Args:    []v1alpha1.Argument{{Name: NodesArg}},
+			Metrics: []v1alpha1.Metric{{Name: "health", Provider: v1alpha1.MetricProvider{Job: &v1alpha1.JobMetric{}}}},
+		},
+	}
+}
+
+func TestGetStrategy(t *testing.T) {
+	ds := newDaemonSet(nil, 1)
+	strategy, err := GetStrategy(ds)
+	assert.NoError(t, err)
+	assert.Len(t, strategy.Steps, 2)
+
+	ds.Annotations[StrategyAnnotation] = "steps:\n- pause: {}"
+	_, err = GetStrategy(ds)
+	assert.EqualError(t, err, "steps[0].pause needs a duration greater than 0")
+
+	ds.Annotations[StrategyAnnotation] = "steps:\n- setWeight: 10\n  pause:\n    duration: 10"
+	_, err = GetStrategy(ds)
+	assert.EqualError(t, err, "steps[0] must have exactly one of setWeight, pause or analysis set")
+
+	delete(ds.Annotations, StrategyAnnotation)
+	strategy, err = GetStrategy(ds)
+	assert.NoError(t, err)
+	assert.Nil(t, strategy)
+}
+
+func TestSyncRequiresOnDeleteStrategy(t *testing.T) {
+	ds := newDaemonSet(nil, 1)
+	ds.Spec.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+	f := newFixture(t, ds, nil, newRevision(ds, "abc", 1))
+	f.run(ds)
+	assert.Nil(t, f.status())
+	assert.Contains(t, <-f.recorder.Events, "InvalidStrategy")
+}
+
+func TestSyncAdoptsUpdatedDaemonSet(t *testing.T) {
+	ds := newDaemonSet(nil, 2)
+	f := newFixture(t, ds, nil, newRevision(ds, "abc", 1), newPod(ds, "node-1", "abc", true), newPod(ds, "node-2", "abc", true))
+	f.run(ds)
+	assert.Equal(t, &Status{Revision: "abc", StepIndex: 2, Phase: PhaseHealthy}, f.status())
+	assert.Empty(t, f.deletedPods())
+	assert.Empty(t, f.recorder.Events)
+}
+
+func TestSyncUpdatesPodsOfStep(t *testing.T) {
+	ds := newDaemonSet(&Status{Revision: "abc", StepIndex: 2, Phase: PhaseHealthy}, 4)
+	f := newFixture(t, ds, nil,
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-4", "abc", true),
+		newPod(ds, "node-2", "abc", true),
+		newPod(ds, "node-3", "abc", false),
+		newPod(ds, "node-1", "abc", true),
+	)
+	f.run(ds)
+	// the unavailable pod is replaced first, and no other pod may become unavailable
+	assert.Equal(t, []string{"node-agent-node-3"}, f.deletedPods())
+	assert.Equal(t, &Status{Revision: "def", Phase: PhaseProgressing}, f.status())
+	assert.Contains(t, <-f.recorder.Events, "DaemonSetUpdated")
+
+	ds = newDaemonSet(&Status{Revision: "def", Phase: PhaseProgressing}, 4)
+	f = newFixture(t, ds, nil,
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-4", "abc", true),
+		newPod(ds, "node-2", "abc", true),
+		newPod(ds, "node-3", "def", true),
+		newPod(ds, "node-1", "abc", true),
+	)
+	f.run(ds)
+	// pods are replaced in the order of their nodes
+	assert.Equal(t, []string{"node-agent-node-1"}, f.deletedPods())
+	assert.Nil(t, f.status())
+}
+
+func TestSyncRunsAnalysisOnUpdatedNodes(t *testing.T) {
+	ds := newDaemonSet(&Status{Revision: "def", Phase: PhaseProgressing}, 4)
+	f := newFixture(t, ds, []runtime.Object{newTemplate()},
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-1", "def", true),
+		newPod(ds, "node-2", "def", true),
+		newPod(ds, "node-3", "abc", true),
+		newPod(ds, "node-4", "abc", true),
+	)
+	f.run(ds)
+	assert.Empty(t, f.deletedPods())
+	assert.Equal(t, int32(1), f.status().StepIndex)
+	assert.Equal(t, "node-agent-def-1", f.status().AnalysisRun)
+
+	run, err := f.client.ArgoprojV1alpha1().AnalysisRuns(ds.Namespace).Get("node-agent-def-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "node-1|node-2", *run.Spec.Args[0].Value)
+	assert.Equal(t, "DaemonSet", metav1.GetControllerOf(run).Kind)
+}
+
+func TestSyncAbortsOnFailedAnalysis(t *testing.T) {
+	ds := newDaemonSet(&Status{Revision: "def", StepIndex: 1, Phase: PhaseProgressing, AnalysisRun: "node-agent-def-1"}, 2)
+	run := &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-agent-def-1", Namespace: ds.Namespace},
+		Status:     v1alpha1.AnalysisRunStatus{Phase: v1alpha1.AnalysisPhaseFailed, Message: "health failed"},
+	}
+	f := newFixture(t, ds, []runtime.Object{run},
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-1", "def", true),
+		newPod(ds, "node-2", "abc", true),
+	)
+	f.run(ds)
+	assert.Empty(t, f.deletedPods())
+	assert.Equal(t, PhaseDegraded, f.status().Phase)
+	assert.Equal(t, "AnalysisRun 'node-agent-def-1' completed as Failed: health failed", f.status().Message)
+	assert.Contains(t, <-f.recorder.Events, "DaemonSetAborted")
+}
+
+func TestSyncCompletesAfterSteps(t *testing.T) {
+	ds := newDaemonSet(&Status{Revision: "def", StepIndex: 1, Phase: PhaseProgressing, AnalysisRun: "node-agent-def-1"}, 2)
+	run := &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-agent-def-1", Namespace: ds.Namespace},
+		Status:     v1alpha1.AnalysisRunStatus{Phase: v1alpha1.AnalysisPhaseSuccessful},
+	}
+	f := newFixture(t, ds, []runtime.Object{run},
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-1", "def", true),
+		newPod(ds, "node-2", "abc", true),
+	)
+	f.run(ds)
+	// the remaining pods are updated once all steps completed
+	assert.Equal(t, []string{"node-agent-node-2"}, f.deletedPods())
+	assert.Equal(t, &Status{Revision: "def", StepIndex: 2, Phase: PhaseProgressing}, f.status())
+	assert.Contains(t, <-f.recorder.Events, "DaemonSetStepCompleted")
+
+	ds = newDaemonSet(&Status{Revision: "def", StepIndex: 2, Phase: PhaseProgressing}, 2)
+	f = newFixture(t, ds, nil,
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-1", "def", true),
+		newPod(ds, "node-2", "def", true),
+	)
+	f.run(ds)
+	assert.Equal(t, PhaseHealthy, f.status().Phase)
+	assert.Contains(t, <-f.recorder.Events, "DaemonSetCompleted")
+}
+
+func TestSyncCountsUnavailablePodsOfDaemonSet(t *testing.T) {
+	ds := newDaemonSet(&Status{Revision: "def", Phase: PhaseProgressing}, 4)
+	// a pod is unavailable in the status of the DaemonSet before its readiness is listed
+	ds.Status.NumberUnavailable = 1
+	f := newFixture(t, ds, nil,
+		newRevision(ds, "abc", 1),
+		newRevision(ds, "def", 2),
+		newPod(ds, "node-1", "abc", true),
+		newPod(ds, "node-2", "abc", true),
+		newPod(ds, "node-3", "abc", true),
+		newPod(ds, "node-4", "abc", true),
+	)
+	f.run(ds)
+	assert.Empty(t, f.deletedPods())
+}
@@ -0,0 +1,125 @@
+package daemonset
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	// StrategyAnnotation holds the progressive update strategy of a DaemonSet. DaemonSets without
+	// the annotation are left to the DaemonSet controller.
+	StrategyAnnotation = "rollout.argoproj.io/daemonset-strategy"
+	// StatusAnnotation holds the progress of the update of a DaemonSet
+	StatusAnnotation = "rollout.argoproj.io/daemonset-status"
+)
+
+// Strategy defines how the pods of a DaemonSet are updated to a new revision
+type Strategy struct {
+	// Steps define the order of phases to execute the update. The pods which are not updated once
+	// all steps completed are updated afterwards.
+	Steps []Step `json:"steps,omitempty"`
+	// MaxUnavailable is the maximum number of pods that can be unavailable during the update.
+	// Value can be an absolute number (ex: 5) or a percentage of the desired pods (ex: 10%).
+	// Defaults to 1.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Step is a phase of the update of a DaemonSet. Only one of the fields is set.
+type Step struct {
+	// SetWeight is the percentage of the nodes running a pod of the new revision
+	SetWeight *int32 `json:"setWeight,omitempty"`
+	// Pause waits for the duration before the next step
+	Pause *v1alpha1.RolloutPause `json:"pause,omitempty"`
+	// Analysis runs an AnalysisRun and waits for it to succeed before the next step
+	Analysis *v1alpha1.RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// Phase is the phase of the update of a DaemonSet
+type Phase string
+
+const (
+	// PhaseProgressing means the pods are being updated to the new revision
+	PhaseProgressing Phase = "Progressing"
+	// PhaseDegraded means the update was aborted because an analysis did not succeed
+	PhaseDegraded Phase = "Degraded"
+	// PhaseHealthy means every pod runs the current revision
+	PhaseHealthy Phase = "Healthy"
+)
+
+// Status is the progress of the update of a DaemonSet
+type Status struct {
+	// Revision is the controller-revision-hash the pods are updated to
+	Revision string `json:"revision"`
+	// StepIndex is the index of the current step
+	StepIndex int32 `json:"stepIndex"`
+	// Phase is the phase of the update
+	Phase Phase `json:"phase"`
+	// Message explains the phase
+	Message string `json:"message,omitempty"`
+	// PauseStartTime is when the current pause step started
+	PauseStartTime *metav1.Time `json:"pauseStartTime,omitempty"`
+	// AnalysisRun is the name of the AnalysisRun of the current analysis step
+	AnalysisRun string `json:"analysisRun,omitempty"`
+}
+
+// GetStrategy returns the strategy of the DaemonSet, or nil if the DaemonSet is not updated
+// progressively
+func GetStrategy(ds *appsv1.DaemonSet) (*Strategy, error) {
+	value, ok := ds.Annotations[StrategyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var strategy Strategy
+	if err := yaml.Unmarshal([]byte(value), &strategy); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation '%s': %v", StrategyAnnotation, err)
+	}
+	if err := validateStrategy(strategy); err != nil {
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+func validateStrategy(strategy Strategy) error {
+	for i, step := range strategy.Steps {
+		fields := 0
+		if step.SetWeight != nil {
+			fields++
+			if *step.SetWeight < 0 || *step.SetWeight > 100 {
+				return fmt.Errorf("steps[%d].setWeight needs to be between 0 and 100", i)
+			}
+		}
+		if step.Pause != nil {
+			fields++
+			if step.Pause.Duration == nil || step.Pause.DurationSeconds() < 0 {
+				return fmt.Errorf("steps[%d].pause needs a duration greater than 0", i)
+			}
+		}
+		if step.Analysis != nil {
+			fields++
+			if len(step.Analysis.Templates) == 0 {
+				return fmt.Errorf("steps[%d].analysis needs at least one template", i)
+			}
+		}
+		if fields != 1 {
+			return fmt.Errorf("steps[%d] must have exactly one of setWeight, pause or analysis set", i)
+		}
+	}
+	return nil
+}
+
+// GetStatus returns the status of the update of the DaemonSet. An unknown status is returned as
+// empty, which restarts the update.
+func GetStatus(ds *appsv1.DaemonSet) Status {
+	var status Status
+	if value, ok := ds.Annotations[StatusAnnotation]; ok {
+		_ = json.Unmarshal([]byte(value), &status)
+	}
+	return status
+}
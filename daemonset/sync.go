@@ -0,0 +1,389 @@
+package daemonset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// NodesArg is the argument of the AnalysisRuns of a DaemonSet holding the names of the nodes
+	// running the new revision, separated by '|' so it can be used in regular expressions
+	NodesArg = "nodes"
+	// RevisionArg is the argument of the AnalysisRuns of a DaemonSet holding the new revision
+	RevisionArg = "revision"
+)
+
+// nowFn is the current time, overridden in tests
+var nowFn = time.Now
+
+// syncDaemonSet runs the steps of the strategy of the DaemonSet for its current revision
+func (c *DaemonSetController) syncDaemonSet(orig *appsv1.DaemonSet) error {
+	ds := orig.DeepCopy()
+	logCtx := logutil.WithDaemonSet(ds)
+	strategy, err := GetStrategy(ds)
+	if err != nil {
+		c.recorder.Event(ds, corev1.EventTypeWarning, "InvalidStrategy", err.Error())
+		return nil
+	}
+	if ds.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType {
+		c.recorder.Eventf(ds, corev1.EventTypeWarning, "InvalidStrategy", "The update strategy of the DaemonSet needs to be '%s'", appsv1.OnDeleteDaemonSetStrategyType)
+		return nil
+	}
+	revision, err := c.getCurrentRevision(ds)
+	if err != nil || revision == "" {
+		return err
+	}
+	pods, err := c.getPods(ds)
+	if err != nil {
+		return err
+	}
+
+	prevStatus := GetStatus(ds)
+	status := prevStatus
+	if status.Revision != revision {
+		if err := c.terminateAnalysisRun(ds, status.AnalysisRun); err != nil {
+			return err
+		}
+		status = Status{Revision: revision, Phase: PhaseProgressing}
+		if prevStatus.Revision == "" && countUpdated(pods, revision) == len(pods) {
+			// the DaemonSet is annotated after its pods reached the current revision
+			status.StepIndex = int32(len(strategy.Steps))
+		} else {
+			logCtx.Infof("Updating the pods to revision %s", revision)
+			c.recorder.Eventf(ds, corev1.EventTypeNormal, "DaemonSetUpdated", "Updating the pods to revision %s", revision)
+		}
+	}
+	// a degraded update is not retried: no further pods are replaced until the pod template changes,
+	// which starts the update of the new revision, or the status annotation is removed
+	if status.Phase == PhaseDegraded {
+		return nil
+	}
+
+	desired := ds.Status.DesiredNumberScheduled
+	for int(status.StepIndex) < len(strategy.Steps) {
+		step := strategy.Steps[status.StepIndex]
+		completed := false
+		switch {
+		case step.SetWeight != nil:
+			updatedCount := int32(math.Ceil(float64(desired) * float64(*step.SetWeight) / 100))
+			completed, err = c.updatePods(ds, strategy, pods, revision, updatedCount)
+		case step.Pause != nil:
+			completed = c.reconcilePause(ds, *step.Pause, &status)
+		case step.Analysis != nil:
+			completed, err = c.reconcileAnalysis(ds, *step.Analysis, pods, &status)
+		}
+		if err != nil {
+			return err
+		}
+		if !completed {
+			return c.persistStatus(ds, status)
+		}
+		status.StepIndex++
+		status.Message = ""
+		c.recorder.Eventf(ds, corev1.EventTypeNormal, "DaemonSetStepCompleted", "Step %d of %d completed", status.StepIndex, len(strategy.Steps))
+	}
+
+	completed, err := c.updatePods(ds, strategy, pods, revision, desired)
+	if err != nil {
+		return err
+	}
+	if completed && status.Phase != PhaseHealthy {
+		status.Phase = PhaseHealthy
+		if prevStatus.Revision != "" {
+			c.recorder.Eventf(ds, corev1.EventTypeNormal, "DaemonSetCompleted", "Updated all pods to revision %s", revision)
+		}
+	}
+	return c.persistStatus(ds, status)
+}
+
+// getCurrentRevision returns the hash of the newest ControllerRevision of the DaemonSet, which
+// the DaemonSet controller creates new pods from
+func (c *DaemonSetController) getCurrentRevision(ds *appsv1.DaemonSet) (string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	revisions, err := c.kubeclientset.AppsV1().ControllerRevisions(ds.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", err
+	}
+	var current *appsv1.ControllerRevision
+	for i := range revisions.Items {
+		revision := &revisions.Items[i]
+		controllerutil.StripControllerRevision(revision)
+		if !metav1.IsControlledBy(revision, ds) {
+			continue
+		}
+		if current == nil || revision.Revision > current.Revision {
+			current = revision
+		}
+	}
+	if current == nil {
+		return "", nil
+	}
+	return current.Labels[appsv1.DefaultDaemonSetUniqueLabelKey], nil
+}
+
+// getPods returns the pods of the DaemonSet which are not being deleted, sorted by the names of
+// their nodes. The pods are stripped to the fields read by the controller while the update makes
+// progress, since a DaemonSet can run on thousands of nodes.
+func (c *DaemonSetController) getPods(ds *appsv1.DaemonSet) ([]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList, err := c.kubeclientset.CoreV1().Pods(ds.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		controllerutil.StripPod(pod)
+		if pod.DeletionTimestamp == nil && metav1.IsControlledBy(pod, ds) {
+			pods = append(pods, pod)
+		}
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].Spec.NodeName < pods[j].Spec.NodeName
+	})
+	return pods, nil
+}
+
+func isUpdated(pod *corev1.Pod, revision string) bool {
+	return pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey] == revision
+}
+
+func countUpdated(pods []*corev1.Pod, revision string) int {
+	count := 0
+	for _, pod := range pods {
+		if isUpdated(pod, revision) {
+			count++
+		}
+	}
+	return count
+}
+
+// updatePods deletes pods of previous revisions until the number of pods of the revision reaches
+// the updated count, without exceeding the pods the strategy allows to be unavailable. It returns
+// true once the pods of the revision are available.
+func (c *DaemonSetController) updatePods(ds *appsv1.DaemonSet, strategy *Strategy, pods []*corev1.Pod, revision string, updatedCount int32) (bool, error) {
+	logCtx := logutil.WithDaemonSet(ds)
+	now := metav1.NewTime(nowFn())
+	updated := int32(0)
+	updatedAvailable := true
+	// pods which are missing are being recreated
+	unavailable := ds.Status.DesiredNumberScheduled - int32(len(pods))
+	var oldPods []*corev1.Pod
+	for _, pod := range pods {
+		available := podutil.IsPodAvailable(pod, ds.Spec.MinReadySeconds, now)
+		if !available {
+			unavailable++
+		}
+		if isUpdated(pod, revision) {
+			updated++
+			updatedAvailable = updatedAvailable && available
+			continue
+		}
+		oldPods = append(oldPods, pod)
+	}
+	if updated >= updatedCount {
+		return updatedAvailable, nil
+	}
+
+	// unavailable pods of previous revisions are replaced first since deleting them does not
+	// reduce the availability of the DaemonSet
+	sort.SliceStable(oldPods, func(i, j int) bool {
+		return !podutil.IsPodAvailable(oldPods[i], ds.Spec.MinReadySeconds, now) && podutil.IsPodAvailable(oldPods[j], ds.Spec.MinReadySeconds, now)
+	})
+	// the status of the DaemonSet may count pods as unavailable before the listed pods show it, such
+	// as pods which just failed their readiness probe
+	if unavailable < ds.Status.NumberUnavailable {
+		unavailable = ds.Status.NumberUnavailable
+	}
+	budget := maxUnavailable(strategy, ds.Status.DesiredNumberScheduled) - unavailable
+	for _, pod := range oldPods {
+		if updated >= updatedCount {
+			break
+		}
+		if podutil.IsPodAvailable(pod, ds.Spec.MinReadySeconds, now) {
+			if budget <= 0 {
+				break
+			}
+			budget--
+		}
+		logCtx.Infof("Deleting pod '%s' on node '%s' to update it to revision %s", pod.Name, pod.Spec.NodeName, revision)
+		err := c.kubeclientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, nil)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		updated++
+	}
+	return false, nil
+}
+
+// maxUnavailable resolves the pods of the DaemonSet the strategy allows to be unavailable. At
+// least one pod may be unavailable so the update can make progress.
+func maxUnavailable(strategy *Strategy, desired int32) int32 {
+	value := intstr.FromInt(1)
+	if strategy.MaxUnavailable != nil {
+		value = *strategy.MaxUnavailable
+	}
+	unavailable, err := intstr.GetValueFromIntOrPercent(&value, int(desired), false)
+	if err != nil || unavailable < 1 {
+		return 1
+	}
+	return int32(unavailable)
+}
+
+// reconcilePause returns true once the pause step waited for its duration
+func (c *DaemonSetController) reconcilePause(ds *appsv1.DaemonSet, pause v1alpha1.RolloutPause, status *Status) bool {
+	now := nowFn()
+	if status.PauseStartTime == nil {
+		startTime := metav1.NewTime(now)
+		status.PauseStartTime = &startTime
+	}
+	remaining := status.PauseStartTime.Add(time.Duration(pause.DurationSeconds()) * time.Second).Sub(now)
+	if remaining > 0 {
+		status.Message = fmt.Sprintf("Paused for %d seconds", pause.DurationSeconds())
+		c.enqueueDaemonSetAfter(ds, remaining)
+		return false
+	}
+	status.PauseStartTime = nil
+	return true
+}
+
+// reconcileAnalysis creates the AnalysisRun of the analysis step and returns true once it
+// succeeded. The update is aborted when the run does not succeed.
+func (c *DaemonSetController) reconcileAnalysis(ds *appsv1.DaemonSet, analysis v1alpha1.RolloutAnalysis, pods []*corev1.Pod, status *Status) (bool, error) {
+	if status.AnalysisRun == "" {
+		run, err := c.newAnalysisRun(ds, analysis, pods, status)
+		if err != nil {
+			return false, err
+		}
+		run, err = analysisutil.CreateWithCollisionCounter(logutil.WithDaemonSet(ds), c.argoProjClientset.ArgoprojV1alpha1().AnalysisRuns(ds.Namespace), *run)
+		if err != nil {
+			return false, err
+		}
+		c.recorder.Eventf(ds, corev1.EventTypeNormal, "AnalysisRunCreated", "Created AnalysisRun '%s'", run.Name)
+		status.AnalysisRun = run.Name
+		status.Message = fmt.Sprintf("Waiting for AnalysisRun '%s'", run.Name)
+		return false, nil
+	}
+	run, err := c.analysisRunLister.AnalysisRuns(ds.Namespace).Get(status.AnalysisRun)
+	if k8serrors.IsNotFound(err) {
+		// the run is recreated
+		status.AnalysisRun = ""
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch run.Status.Phase {
+	case v1alpha1.AnalysisPhaseSuccessful:
+		status.AnalysisRun = ""
+		return true, nil
+	case v1alpha1.AnalysisPhaseFailed, v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseInconclusive:
+		status.Phase = PhaseDegraded
+		status.Message = fmt.Sprintf("AnalysisRun '%s' completed as %s: %s", run.Name, run.Status.Phase, run.Status.Message)
+		c.recorder.Event(ds, corev1.EventTypeWarning, "DaemonSetAborted", status.Message)
+	}
+	return false, nil
+}
+
+// newAnalysisRun returns the AnalysisRun of the analysis step. The nodes running the new
+// revision and the revision are passed as arguments, so the metrics can be scoped to those nodes.
+func (c *DaemonSetController) newAnalysisRun(ds *appsv1.DaemonSet, analysis v1alpha1.RolloutAnalysis, pods []*corev1.Pod, status *Status) (*v1alpha1.AnalysisRun, error) {
+	var nodes []string
+	for _, pod := range pods {
+		if isUpdated(pod, status.Revision) {
+			nodes = append(nodes, pod.Spec.NodeName)
+		}
+	}
+	nodesValue := strings.Join(nodes, "|")
+	revision := status.Revision
+	args := []v1alpha1.Argument{
+		{Name: NodesArg, Value: &nodesValue},
+		{Name: RevisionArg, Value: &revision},
+	}
+	for i := range analysis.Args {
+		value := analysis.Args[i].Value
+		args = append(args, v1alpha1.Argument{Name: analysis.Args[i].Name, Value: &value})
+	}
+	templates := make([]*v1alpha1.AnalysisTemplate, 0)
+	for _, templateRef := range analysis.Templates {
+		template, err := c.analysisTemplateLister.AnalysisTemplates(ds.Namespace).Get(templateRef.TemplateName)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	name := fmt.Sprintf("%s-%s-%d", ds.Name, status.Revision, status.StepIndex)
+	run, err := analysisutil.NewAnalysisRunFromTemplates(templates, args, name, "", ds.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	run.Labels = analysisutil.StepLabels(status.StepIndex, status.Revision, analysisutil.GetInstanceID(ds))
+	run.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(ds, daemonSetKind)}
+	return run, nil
+}
+
+// terminateAnalysisRun terminates the run of a previous revision
+func (c *DaemonSetController) terminateAnalysisRun(ds *appsv1.DaemonSet, name string) error {
+	if name == "" {
+		return nil
+	}
+	run, err := c.analysisRunLister.AnalysisRuns(ds.Namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if run.Status.Phase.Completed() || run.Spec.Terminate {
+		return nil
+	}
+	return analysisutil.TerminateRun(c.argoProjClientset.ArgoprojV1alpha1().AnalysisRuns(ds.Namespace), name)
+}
+
+// persistStatus patches the status annotation of the DaemonSet if the status changed
+func (c *DaemonSetController) persistStatus(ds *appsv1.DaemonSet, status Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if ds.Annotations[StatusAnnotation] == string(value) {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{StatusAnnotation: string(value)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kubeclientset.AppsV1().DaemonSets(ds.Namespace).Patch(ds.Name, patchtypes.MergePatchType, patch)
+	if err != nil {
+		return err
+	}
+	logutil.WithDaemonSet(ds).Infof("Patched status: %s", value)
+	return nil
+}
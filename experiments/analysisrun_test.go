@@ -98,6 +98,41 @@ func TestCreateAnalysisRunWhenAvailable(t *testing.T) {
 	assert.Equal(t, v1alpha1.AnalysisPhasePending, patchedEx.Status.AnalysisRuns[0].Phase)
 }
 
+// TestCreateAnalysisRunFromClusterTemplate ensures we create the AnalysisRun from a ClusterAnalysisTemplate
+func TestCreateAnalysisRunFromClusterTemplate(t *testing.T) {
+	templates := generateTemplates("bar")
+	aTemplates := generateAnalysisTemplates("success-rate")
+	clusterTemplate := v1alpha1.ClusterAnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: aTemplates[0].Name},
+		Spec:       aTemplates[0].Spec,
+	}
+	e := newExperiment("foo", templates, "")
+	e.Spec.Analyses = []v1alpha1.ExperimentAnalysisTemplateRef{
+		{
+			Name:         "success-rate",
+			TemplateName: clusterTemplate.Name,
+			ClusterScope: true,
+		},
+	}
+	e.Status.Phase = v1alpha1.AnalysisPhaseRunning
+	e.Status.AvailableAt = now()
+	rs := templateToRS(e, templates[0], 1)
+	ar := analysisTemplateToRun("success-rate", e, &clusterTemplate.Spec)
+
+	f := newFixture(t, e, rs, &clusterTemplate)
+	defer f.Close()
+
+	createIdx := f.expectCreateAnalysisRunAction(ar)
+	patchIdx := f.expectPatchExperimentAction(e)
+	f.run(getKey(e, t))
+
+	createdAr := f.getCreatedAnalysisRun(createIdx)
+	assert.Equal(t, e.Namespace, createdAr.Namespace)
+	assert.Equal(t, clusterTemplate.Spec.Metrics, createdAr.Spec.Metrics)
+	patchedEx := f.getPatchedExperimentAsObj(patchIdx)
+	assert.Equal(t, v1alpha1.AnalysisPhasePending, patchedEx.Status.AnalysisRuns[0].Phase)
+}
+
 // TestCreateAnalysisRunWithInstanceID ensures we add an instance ID to the AnalysisRun
 func TestCreateAnalysisRunWithInstanceID(t *testing.T) {
 	templates := generateTemplates("bar")
@@ -522,6 +557,43 @@ func TestCompleteExperimentOnSuccessfulRequiredAnalysisRun(t *testing.T) {
 	assert.Equal(t, patchedEx.Status.Phase, v1alpha1.AnalysisPhaseSuccessful)
 }
 
+// TestCompleteExperimentWithoutDurationOnSuccessfulAnalysisRun verifies the controller completes a
+// experiment without a duration when its analyses complete successfully
+func TestCompleteExperimentWithoutDurationOnSuccessfulAnalysisRun(t *testing.T) {
+	templates := generateTemplates("bar")
+	e := newExperiment("foo", templates, "")
+	e.Spec.Analyses = []v1alpha1.ExperimentAnalysisTemplateRef{
+		{
+			Name:         "success-rate",
+			TemplateName: "success-rate",
+		},
+	}
+	e.Status.Phase = v1alpha1.AnalysisPhaseRunning
+	e.Status.AvailableAt = secondsAgo(60)
+	rs := templateToRS(e, templates[0], 0)
+	rs.Spec.Replicas = new(int32)
+	ar := analysisTemplateToRun("success-rate", e, &v1alpha1.AnalysisTemplateSpec{})
+	ar.Status = v1alpha1.AnalysisRunStatus{
+		Phase: v1alpha1.AnalysisPhaseSuccessful,
+	}
+	e.Status.AnalysisRuns = []v1alpha1.ExperimentAnalysisRunStatus{
+		{
+			Name:        e.Spec.Analyses[0].Name,
+			Phase:       v1alpha1.AnalysisPhaseRunning,
+			AnalysisRun: ar.Name,
+		},
+	}
+
+	f := newFixture(t, e, rs, ar)
+	defer f.Close()
+	f.expectUpdateReplicaSetAction(rs)
+	patchIndex := f.expectPatchExperimentAction(e)
+	f.run(getKey(e, t))
+	patchedEx := f.getPatchedExperimentAsObj(patchIndex)
+	assert.Equal(t, patchedEx.Status.Message, requiredAnalysisCompletedMessage)
+	assert.Equal(t, patchedEx.Status.Phase, v1alpha1.AnalysisPhaseSuccessful)
+}
+
 func TestDoNotCompleteExperimentWithRemainingRequiredAnalysisRun(t *testing.T) {
 	templates := generateTemplates("bar")
 	e := newExperiment("foo", templates, "")
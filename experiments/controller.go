@@ -7,10 +7,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	patchtypes "k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -26,6 +27,7 @@ import (
 	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	"github.com/argoproj/argo-rollouts/utils/diff"
+	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 )
 
@@ -39,12 +41,15 @@ type ExperimentController struct {
 	// rsControl is used for adopting/releasing replica sets.
 	replicaSetControl controller.RSControlInterface
 
-	replicaSetLister       appslisters.ReplicaSetLister
-	experimentsLister      listers.ExperimentLister
-	analysisTemplateLister listers.AnalysisTemplateLister
-	analysisRunLister      listers.AnalysisRunLister
+	replicaSetLister              appslisters.ReplicaSetLister
+	serviceLister                 corelisters.ServiceLister
+	experimentsLister             listers.ExperimentLister
+	analysisTemplateLister        listers.AnalysisTemplateLister
+	clusterAnalysisTemplateLister listers.ClusterAnalysisTemplateLister
+	analysisRunLister             listers.AnalysisRunLister
 
 	replicaSetSynced       cache.InformerSynced
+	serviceSynced          cache.InformerSynced
 	experimentSynced       cache.InformerSynced
 	analysisTemplateSynced cache.InformerSynced
 	analysisRunSynced      cache.InformerSynced
@@ -73,9 +78,11 @@ func NewExperimentController(
 	kubeclientset kubernetes.Interface,
 	argoProjClientset clientset.Interface,
 	replicaSetInformer appsinformers.ReplicaSetInformer,
+	serviceInformer coreinformers.ServiceInformer,
 	experimentsInformer informers.ExperimentInformer,
 	analysisRunInformer informers.AnalysisRunInformer,
 	analysisTemplateInformer informers.AnalysisTemplateInformer,
+	clusterAnalysisTemplateInformer informers.ClusterAnalysisTemplateInformer,
 	resyncPeriod time.Duration,
 	rolloutWorkQueue workqueue.RateLimitingInterface,
 	experimentWorkQueue workqueue.RateLimitingInterface,
@@ -88,18 +95,21 @@ func NewExperimentController(
 	}
 
 	controller := &ExperimentController{
-		kubeclientset:          kubeclientset,
-		argoProjClientset:      argoProjClientset,
-		replicaSetControl:      replicaSetControl,
-		replicaSetLister:       replicaSetInformer.Lister(),
-		experimentsLister:      experimentsInformer.Lister(),
-		analysisTemplateLister: analysisTemplateInformer.Lister(),
-		analysisRunLister:      analysisRunInformer.Lister(),
-		metricsServer:          metricsServer,
-		rolloutWorkqueue:       rolloutWorkQueue,
-		experimentWorkqueue:    experimentWorkQueue,
+		kubeclientset:                 kubeclientset,
+		argoProjClientset:             argoProjClientset,
+		replicaSetControl:             replicaSetControl,
+		replicaSetLister:              replicaSetInformer.Lister(),
+		serviceLister:                 serviceInformer.Lister(),
+		experimentsLister:             experimentsInformer.Lister(),
+		analysisTemplateLister:        analysisTemplateInformer.Lister(),
+		clusterAnalysisTemplateLister: clusterAnalysisTemplateInformer.Lister(),
+		analysisRunLister:             analysisRunInformer.Lister(),
+		metricsServer:                 metricsServer,
+		rolloutWorkqueue:              rolloutWorkQueue,
+		experimentWorkqueue:           experimentWorkQueue,
 
 		replicaSetSynced:       replicaSetInformer.Informer().HasSynced,
+		serviceSynced:          serviceInformer.Informer().HasSynced,
 		experimentSynced:       experimentsInformer.Informer().HasSynced,
 		analysisRunSynced:      analysisRunInformer.Informer().HasSynced,
 		analysisTemplateSynced: analysisTemplateInformer.Informer().HasSynced,
@@ -194,14 +204,8 @@ func NewExperimentController(
 
 func (ec *ExperimentController) Run(threadiness int, stopCh <-chan struct{}) error {
 	log.Info("Starting Experiment workers")
-	for i := 0; i < threadiness; i++ {
-		go wait.Until(func() {
-			controllerutil.RunWorker(ec.experimentWorkqueue, logutil.ExperimentKey, ec.syncHandler, ec.metricsServer)
-		}, time.Second, stopCh)
-	}
-	log.Info("Started Experiment workers")
-	<-stopCh
-	log.Info("Shutting down experiment workers")
+	controllerutil.RunWorkers(threadiness, ec.experimentWorkqueue, logutil.ExperimentKey, ec.syncHandler, ec.metricsServer, stopCh)
+	log.Info("Shut down experiment workers")
 
 	return nil
 }
@@ -212,7 +216,7 @@ func (ec *ExperimentController) syncHandler(key string) error {
 	if err != nil {
 		return err
 	}
-	logCtx := log.WithField(logutil.ExperimentKey, name).WithField(logutil.NamespaceKey, namespace)
+	logCtx := logutil.WithObject(logutil.ExperimentKey, namespace, name)
 	logCtx.Infof("Started syncing Experiment at (%v)", startTime)
 	experiment, err := ec.experimentsLister.Experiments(namespace).Get(name)
 	if k8serrors.IsNotFound(err) {
@@ -235,6 +239,12 @@ func (ec *ExperimentController) syncHandler(key string) error {
 		return nil
 	}
 
+	if passedTTL, timeRemaining := experimentutil.PassedTTL(experiment); passedTTL {
+		return ec.deleteExpiredExperiment(experiment)
+	} else if timeRemaining > 0 {
+		ec.enqueueExperimentAfter(experiment, timeRemaining)
+	}
+
 	prevCond := conditions.GetExperimentCondition(experiment.Status, v1alpha1.InvalidExperimentSpec)
 	invalidSpecCond := conditions.VerifyExperimentSpec(experiment, prevCond)
 	if invalidSpecCond != nil {
@@ -262,7 +272,9 @@ func (ec *ExperimentController) syncHandler(key string) error {
 		ec.kubeclientset,
 		ec.argoProjClientset,
 		ec.replicaSetLister,
+		ec.serviceLister,
 		ec.analysisTemplateLister,
+		ec.clusterAnalysisTemplateLister,
 		ec.analysisRunLister,
 		ec.recorder,
 		ec.enqueueExperimentAfter,
@@ -299,6 +311,18 @@ func (ec *ExperimentController) persistExperimentStatus(orig *v1alpha1.Experimen
 	return nil
 }
 
+// deleteExpiredExperiment deletes an experiment whose TTL expired. The ReplicaSets, Services and
+// AnalysisRuns of the experiment are garbage collected through their owner references.
+func (ec *ExperimentController) deleteExpiredExperiment(experiment *v1alpha1.Experiment) error {
+	logCtx := logutil.WithExperiment(experiment)
+	logCtx.Infof("Deleting experiment after its TTL of %d seconds expired", *experiment.Spec.TTLSecondsAfterFinished)
+	err := ec.argoProjClientset.ArgoprojV1alpha1().Experiments(experiment.Namespace).Delete(experiment.Name, nil)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 // enqueueIfCompleted conditionally enqueues the AnalysisRun's Experiment if the run is complete
 func (c *ExperimentController) enqueueIfCompleted(obj interface{}) {
 	run, ok := obj.(*v1alpha1.AnalysisRun)
@@ -62,10 +62,12 @@ type fixture struct {
 	client     *fake.Clientset
 	kubeclient *k8sfake.Clientset
 	// Objects to put in the store.
-	experimentLister       []*v1alpha1.Experiment
-	replicaSetLister       []*appsv1.ReplicaSet
-	analysisRunLister      []*v1alpha1.AnalysisRun
-	analysisTemplateLister []*v1alpha1.AnalysisTemplate
+	experimentLister              []*v1alpha1.Experiment
+	replicaSetLister              []*appsv1.ReplicaSet
+	serviceLister                 []*corev1.Service
+	analysisRunLister             []*v1alpha1.AnalysisRun
+	analysisTemplateLister        []*v1alpha1.AnalysisTemplate
+	clusterAnalysisTemplateLister []*v1alpha1.ClusterAnalysisTemplate
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -86,6 +88,9 @@ func newFixture(t *testing.T, objects ...runtime.Object) *fixture {
 		case *v1alpha1.AnalysisTemplate:
 			f.objects = append(f.objects, obj)
 			f.analysisTemplateLister = append(f.analysisTemplateLister, obj.(*v1alpha1.AnalysisTemplate))
+		case *v1alpha1.ClusterAnalysisTemplate:
+			f.objects = append(f.objects, obj)
+			f.clusterAnalysisTemplateLister = append(f.clusterAnalysisTemplateLister, obj.(*v1alpha1.ClusterAnalysisTemplate))
 		case *v1alpha1.AnalysisRun:
 			f.objects = append(f.objects, obj)
 			f.analysisRunLister = append(f.analysisRunLister, obj.(*v1alpha1.AnalysisRun))
@@ -95,6 +100,9 @@ func newFixture(t *testing.T, objects ...runtime.Object) *fixture {
 		case *appsv1.ReplicaSet:
 			f.kubeobjects = append(f.kubeobjects, obj)
 			f.replicaSetLister = append(f.replicaSetLister, obj.(*appsv1.ReplicaSet))
+		case *corev1.Service:
+			f.kubeobjects = append(f.kubeobjects, obj)
+			f.serviceLister = append(f.serviceLister, obj.(*corev1.Service))
 		}
 	}
 	f.client = fake.NewSimpleClientset(f.objects...)
@@ -314,13 +322,15 @@ func (f *fixture) newController(resync resyncFunc) (*ExperimentController, infor
 
 	c := NewExperimentController(f.kubeclient, f.client,
 		k8sI.Apps().V1().ReplicaSets(),
+		k8sI.Core().V1().Services(),
 		i.Argoproj().V1alpha1().Experiments(),
 		i.Argoproj().V1alpha1().AnalysisRuns(),
 		i.Argoproj().V1alpha1().AnalysisTemplates(),
+		i.Argoproj().V1alpha1().ClusterAnalysisTemplates(),
 		resync(),
 		rolloutWorkqueue,
 		experimentWorkqueue,
-		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), &metrics.K8sRequestsCountProvider{}),
+		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), nil, &metrics.K8sRequestsCountProvider{}),
 		&record.FakeRecorder{})
 
 	var enqueuedObjectsLock sync.Mutex
@@ -352,6 +362,10 @@ func (f *fixture) newController(resync resyncFunc) (*ExperimentController, infor
 		k8sI.Apps().V1().ReplicaSets().Informer().GetIndexer().Add(r)
 	}
 
+	for _, s := range f.serviceLister {
+		k8sI.Core().V1().Services().Informer().GetIndexer().Add(s)
+	}
+
 	for _, r := range f.analysisRunLister {
 		i.Argoproj().V1alpha1().AnalysisRuns().Informer().GetIndexer().Add(r)
 	}
@@ -359,6 +373,10 @@ func (f *fixture) newController(resync resyncFunc) (*ExperimentController, infor
 	for _, r := range f.analysisTemplateLister {
 		i.Argoproj().V1alpha1().AnalysisTemplates().Informer().GetIndexer().Add(r)
 	}
+
+	for _, r := range f.clusterAnalysisTemplateLister {
+		i.Argoproj().V1alpha1().ClusterAnalysisTemplates().Informer().GetIndexer().Add(r)
+	}
 	return c, i, k8sI
 }
 
@@ -379,7 +397,7 @@ func (f *fixture) runController(experimentName string, startInformers bool, expe
 		i.Start(stopCh)
 		k8sI.Start(stopCh)
 
-		assert.True(f.t, cache.WaitForCacheSync(stopCh, c.replicaSetSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced))
+		assert.True(f.t, cache.WaitForCacheSync(stopCh, c.replicaSetSynced, c.serviceSynced, c.experimentSynced, c.analysisRunSynced, c.analysisTemplateSynced))
 	}
 
 	err := c.syncHandler(experimentName)
@@ -454,6 +472,8 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			action.Matches("watch", "rollouts") ||
 			action.Matches("list", "replicaSets") ||
 			action.Matches("watch", "replicaSets") ||
+			action.Matches("list", "services") ||
+			action.Matches("watch", "services") ||
 			action.Matches("list", "experiments") ||
 			action.Matches("watch", "experiments") ||
 			action.Matches("list", "analysistemplates") ||
@@ -480,12 +500,30 @@ func (f *fixture) expectUpdateReplicaSetAction(r *appsv1.ReplicaSet) int {
 	return len
 }
 
+func (f *fixture) expectCreateServiceAction(s *corev1.Service) int {
+	len := len(f.kubeactions)
+	f.kubeactions = append(f.kubeactions, core.NewCreateAction(schema.GroupVersionResource{Resource: "services"}, s.Namespace, s))
+	return len
+}
+
+func (f *fixture) expectDeleteServiceAction(s *corev1.Service) int {
+	len := len(f.kubeactions)
+	f.kubeactions = append(f.kubeactions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "services"}, s.Namespace, s.Name))
+	return len
+}
+
 func (f *fixture) expectGetExperimentAction(experiment *v1alpha1.Experiment) int {
 	len := len(f.actions)
 	f.actions = append(f.actions, core.NewGetAction(schema.GroupVersionResource{Resource: "experiments"}, experiment.Namespace, experiment.Name))
 	return len
 }
 
+func (f *fixture) expectDeleteExperimentAction(experiment *v1alpha1.Experiment) int {
+	len := len(f.actions)
+	f.actions = append(f.actions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "experiments"}, experiment.Namespace, experiment.Name))
+	return len
+}
+
 func (f *fixture) expectUpdateExperimentAction(experiment *v1alpha1.Experiment) int {
 	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "experiments"}, experiment.Namespace, experiment)
 	len := len(f.actions)
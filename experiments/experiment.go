@@ -11,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -30,15 +31,17 @@ const (
 
 type experimentContext struct {
 	// parameters supplied to the context
-	ex                     *v1alpha1.Experiment
-	templateRSs            map[string]*appsv1.ReplicaSet
-	kubeclientset          kubernetes.Interface
-	argoProjClientset      clientset.Interface
-	analysisTemplateLister rolloutslisters.AnalysisTemplateLister
-	analysisRunLister      rolloutslisters.AnalysisRunLister
-	replicaSetLister       appslisters.ReplicaSetLister
-	recorder               record.EventRecorder
-	enqueueExperimentAfter func(obj interface{}, duration time.Duration)
+	ex                            *v1alpha1.Experiment
+	templateRSs                   map[string]*appsv1.ReplicaSet
+	kubeclientset                 kubernetes.Interface
+	argoProjClientset             clientset.Interface
+	analysisTemplateLister        rolloutslisters.AnalysisTemplateLister
+	clusterAnalysisTemplateLister rolloutslisters.ClusterAnalysisTemplateLister
+	analysisRunLister             rolloutslisters.AnalysisRunLister
+	replicaSetLister              appslisters.ReplicaSetLister
+	serviceLister                 corelisters.ServiceLister
+	recorder                      record.EventRecorder
+	enqueueExperimentAfter        func(obj interface{}, duration time.Duration)
 
 	// calculated values during reconciliation
 	log       *log.Entry
@@ -54,22 +57,26 @@ func newExperimentContext(
 	kubeclientset kubernetes.Interface,
 	argoProjClientset clientset.Interface,
 	replicaSetLister appslisters.ReplicaSetLister,
+	serviceLister corelisters.ServiceLister,
 	analysisTemplateLister rolloutslisters.AnalysisTemplateLister,
+	clusterAnalysisTemplateLister rolloutslisters.ClusterAnalysisTemplateLister,
 	analysisRunLister rolloutslisters.AnalysisRunLister,
 	recorder record.EventRecorder,
 	enqueueExperimentAfter func(obj interface{}, duration time.Duration),
 ) *experimentContext {
 
 	exCtx := experimentContext{
-		ex:                     experiment,
-		templateRSs:            templateRSs,
-		kubeclientset:          kubeclientset,
-		argoProjClientset:      argoProjClientset,
-		replicaSetLister:       replicaSetLister,
-		analysisTemplateLister: analysisTemplateLister,
-		analysisRunLister:      analysisRunLister,
-		recorder:               recorder,
-		enqueueExperimentAfter: enqueueExperimentAfter,
+		ex:                            experiment,
+		templateRSs:                   templateRSs,
+		kubeclientset:                 kubeclientset,
+		argoProjClientset:             argoProjClientset,
+		replicaSetLister:              replicaSetLister,
+		serviceLister:                 serviceLister,
+		analysisTemplateLister:        analysisTemplateLister,
+		clusterAnalysisTemplateLister: clusterAnalysisTemplateLister,
+		analysisRunLister:             analysisRunLister,
+		recorder:                      recorder,
+		enqueueExperimentAfter:        enqueueExperimentAfter,
 
 		log:           log.WithField(logutil.ExperimentKey, experiment.Name).WithField(logutil.NamespaceKey, experiment.Namespace),
 		newStatus:     experiment.Status.DeepCopy(),
@@ -148,6 +155,12 @@ func (ec *experimentContext) reconcileTemplate(template v1alpha1.TemplateSpec) {
 		templateStatus.AvailableReplicas = replicasetutil.GetAvailableReplicaCountForReplicaSets([]*appsv1.ReplicaSet{rs})
 	}
 
+	if err := ec.reconcileService(template, rs, desiredReplicaCount, templateStatus); err != nil {
+		logCtx.Warnf("Failed to reconcile Service: %v", err)
+		templateStatus.Status = v1alpha1.TemplateStatusError
+		templateStatus.Message = fmt.Sprintf("Failed to reconcile Service for template '%s': %v", template.Name, err)
+	}
+
 	if prevStatus.Replicas != templateStatus.Replicas ||
 		prevStatus.UpdatedReplicas != templateStatus.UpdatedReplicas ||
 		prevStatus.ReadyReplicas != templateStatus.ReadyReplicas ||
@@ -419,6 +432,10 @@ func (ec *experimentContext) calculateStatus() *v1alpha1.ExperimentStatus {
 			}
 		}
 	}
+	if !prevStatus.Phase.Completed() && ec.newStatus.Phase.Completed() {
+		now := metav1.Now()
+		ec.newStatus.FinishedAt = &now
+	}
 	ec.newStatus = calculateExperimentConditions(ec.ex, *ec.newStatus)
 	if prevStatus.Phase != ec.newStatus.Phase {
 		msg := fmt.Sprintf("Experiment transitioned from %s -> %s", prevStatus.Phase, ec.newStatus.Phase)
@@ -490,7 +507,7 @@ func (ec *experimentContext) assessAnalysisRuns() (v1alpha1.AnalysisPhase, strin
 
 // newAnalysisRun generates an AnalysisRun from the experiment and template
 func (ec *experimentContext) newAnalysisRun(analysis v1alpha1.ExperimentAnalysisTemplateRef, args []v1alpha1.Argument) (*v1alpha1.AnalysisRun, error) {
-	template, err := ec.analysisTemplateLister.AnalysisTemplates(ec.ex.Namespace).Get(analysis.TemplateName)
+	template, err := analysisutil.GetTemplate(ec.analysisTemplateLister, ec.clusterAnalysisTemplateLister, ec.ex.Namespace, analysis.TemplateName, analysis.ClusterScope)
 	if err != nil {
 		return nil, err
 	}
@@ -508,8 +525,9 @@ func (ec *experimentContext) newAnalysisRun(analysis v1alpha1.ExperimentAnalysis
 	return run, nil
 }
 
-// verifyAnalysisTemplate verifies an AnalysisTemplate. For now, it simply means that it exists
+// verifyAnalysisTemplate verifies an AnalysisTemplate or ClusterAnalysisTemplate. For now, it simply
+// means that it exists
 func (ec *experimentContext) verifyAnalysisTemplate(analysis v1alpha1.ExperimentAnalysisTemplateRef) error {
-	_, err := ec.analysisTemplateLister.AnalysisTemplates(ec.ex.Namespace).Get(analysis.TemplateName)
+	_, err := analysisutil.GetTemplate(ec.analysisTemplateLister, ec.clusterAnalysisTemplateLister, ec.ex.Namespace, analysis.TemplateName, analysis.ClusterScope)
 	return err
 }
@@ -16,6 +16,7 @@ import (
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
@@ -31,7 +32,7 @@ func newTestContext(ex *v1alpha1.Experiment, objects ...runtime.Object) *experim
 		switch obj.(type) {
 		case *v1alpha1.Experiment:
 			exobjects = append(exobjects, obj)
-		case *appsv1.ReplicaSet:
+		case *appsv1.ReplicaSet, *corev1.Service:
 			kubeobjects = append(kubeobjects, obj)
 		}
 	}
@@ -40,9 +41,16 @@ func newTestContext(ex *v1alpha1.Experiment, objects ...runtime.Object) *experim
 
 	k8sI := kubeinformers.NewSharedInformerFactory(kubeclient, noResyncPeriodFunc())
 	rsLister := k8sI.Apps().V1().ReplicaSets().Lister()
+	serviceLister := k8sI.Core().V1().Services().Lister()
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			k8sI.Core().V1().Services().Informer().GetIndexer().Add(svc)
+		}
+	}
 	rolloutsI := informers.NewSharedInformerFactory(rolloutclient, noResyncPeriodFunc())
 	analysisRunLister := rolloutsI.Argoproj().V1alpha1().AnalysisRuns().Lister()
 	analysisTemplateLister := rolloutsI.Argoproj().V1alpha1().AnalysisTemplates().Lister()
+	clusterAnalysisTemplateLister := rolloutsI.Argoproj().V1alpha1().ClusterAnalysisTemplates().Lister()
 
 	return newExperimentContext(
 		ex,
@@ -50,7 +58,9 @@ func newTestContext(ex *v1alpha1.Experiment, objects ...runtime.Object) *experim
 		kubeclient,
 		rolloutclient,
 		rsLister,
+		serviceLister,
 		analysisTemplateLister,
+		clusterAnalysisTemplateLister,
 		analysisRunLister,
 		&record.FakeRecorder{},
 		func(obj interface{}, duration time.Duration) {},
@@ -169,6 +179,23 @@ func TestNoPatch(t *testing.T) {
 	f.run(getKey(e, t))
 }
 
+func TestDeleteExperimentAfterTTL(t *testing.T) {
+	templates := generateTemplates("bar")
+	e := newExperiment("foo", templates, "")
+	e.Spec.TTLSecondsAfterFinished = pointer.Int32Ptr(60)
+	e.Status.Phase = v1alpha1.AnalysisPhaseSuccessful
+	e.Status.FinishedAt = secondsAgo(61)
+	e.Status.TemplateStatuses = []v1alpha1.TemplateStatus{
+		generateTemplatesStatus("bar", 0, 0, v1alpha1.TemplateStatusSuccessful, now()),
+	}
+	rs := templateToRS(e, templates[0], 0)
+	f := newFixture(t, e, rs)
+	defer f.Close()
+
+	f.expectDeleteExperimentAction(e)
+	f.run(getKey(e, t))
+}
+
 func TestSuccessAfterDurationPasses(t *testing.T) {
 	templates := generateTemplates("bar", "baz")
 	e := newExperiment("foo", templates, "5s")
@@ -195,11 +222,12 @@ func TestSuccessAfterDurationPasses(t *testing.T) {
 		generateTemplatesStatus("baz", 1, 1, v1alpha1.TemplateStatusSuccessful, now()),
 	}
 	cond := newCondition(conditions.ExperimentCompleteReason, e)
-	expectedPatch := calculatePatch(e, `{
+	expectedPatch := calculatePatch(e, fmt.Sprintf(`{
 		"status":{
-			"phase": "Successful"
+			"phase": "Successful",
+			"finishedAt": "%s"
 		}
-	}`, templateStatuses, cond)
+	}`, now().UTC().Format(time.RFC3339)), templateStatuses, cond)
 	assert.Equal(t, expectedPatch, patch)
 }
 
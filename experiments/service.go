@@ -0,0 +1,109 @@
+package experiments
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
+)
+
+// reconcileService creates the Service selecting the pods of a template with a service or a weight,
+// which the traffic router of the rollout sends the share of the template to. The Service is deleted
+// once the pods of the template are gone.
+func (ec *experimentContext) reconcileService(template v1alpha1.TemplateSpec, rs *appsv1.ReplicaSet, desiredReplicaCount int32, templateStatus *v1alpha1.TemplateStatus) error {
+	serviceName := experimentutil.ServiceNameForTemplate(template, rs)
+	if serviceName == "" || (desiredReplicaCount == 0 && templateStatus.Replicas == 0) {
+		if templateStatus.ServiceName == "" {
+			return nil
+		}
+		if err := ec.deleteService(templateStatus.ServiceName); err != nil {
+			return err
+		}
+		templateStatus.ServiceName = ""
+		return nil
+	}
+	if desiredReplicaCount == 0 {
+		// keep the Service until the pods it selects are terminated
+		return nil
+	}
+
+	svc, err := ec.serviceLister.Services(ec.ex.Namespace).Get(serviceName)
+	if k8serrors.IsNotFound(err) {
+		newSvc, err := newServiceForTemplate(ec.ex, template, rs)
+		if err != nil {
+			return err
+		}
+		svc, err = ec.kubeclientset.CoreV1().Services(ec.ex.Namespace).Create(newSvc)
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+		ec.log.Infof("Created Service '%s' for template '%s'", newSvc.Name, template.Name)
+		ec.recorder.Eventf(ec.ex, corev1.EventTypeNormal, "CreatedService", "Created Service '%s' for template '%s'", newSvc.Name, template.Name)
+		templateStatus.ServiceName = newSvc.Name
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ref := metav1.GetControllerOf(svc); ref == nil || ref.UID != ec.ex.UID {
+		return fmt.Errorf("Service '%s' already exists and is not owned by the experiment", svc.Name)
+	}
+	templateStatus.ServiceName = svc.Name
+	return nil
+}
+
+func (ec *experimentContext) deleteService(name string) error {
+	svc, err := ec.serviceLister.Services(ec.ex.Namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ref := metav1.GetControllerOf(svc); ref == nil || ref.UID != ec.ex.UID {
+		return nil
+	}
+	err = ec.kubeclientset.CoreV1().Services(ec.ex.Namespace).Delete(name, nil)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	ec.log.Infof("Deleted Service '%s'", name)
+	return nil
+}
+
+// newServiceForTemplate returns a Service exposing the container ports of the template, selecting
+// the pods of the ReplicaSet of the template
+func newServiceForTemplate(experiment *v1alpha1.Experiment, template v1alpha1.TemplateSpec, rs *appsv1.ReplicaSet) (*corev1.Service, error) {
+	var ports []corev1.ServicePort
+	for _, container := range rs.Spec.Template.Spec.Containers {
+		for _, port := range container.Ports {
+			ports = append(ports, corev1.ServicePort{
+				Name:       port.Name,
+				Protocol:   port.Protocol,
+				Port:       port.ContainerPort,
+				TargetPort: intstr.FromInt(int(port.ContainerPort)),
+			})
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("the containers of template '%s' do not expose any ports", template.Name)
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            experimentutil.ServiceNameForTemplate(template, rs),
+			Namespace:       experiment.Namespace,
+			Annotations:     newReplicaSetAnnotations(experiment.Name, template.Name),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(experiment, controllerKind)},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: rs.Spec.Selector.MatchLabels,
+			Ports:    ports,
+		},
+	}, nil
+}
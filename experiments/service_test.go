@@ -0,0 +1,74 @@
+package experiments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newWeightedExperiment() (*v1alpha1.Experiment, v1alpha1.TemplateSpec) {
+	templates := generateTemplates("bar")
+	templates[0].Weight = pointer.Int32Ptr(10)
+	templates[0].Template.Spec.Containers[0].Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}}
+	return newExperiment("foo", templates, ""), templates[0]
+}
+
+func TestReconcileServiceCreatesService(t *testing.T) {
+	e, template := newWeightedExperiment()
+	rs := templateToRS(e, template, 1)
+	exCtx := newTestContext(e)
+
+	templateStatus := v1alpha1.TemplateStatus{Name: template.Name}
+	assert.NoError(t, exCtx.reconcileService(template, rs, 1, &templateStatus))
+	assert.Equal(t, rs.Name, templateStatus.ServiceName)
+
+	svc, err := exCtx.kubeclientset.CoreV1().Services(e.Namespace).Get(rs.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, rs.Spec.Selector.MatchLabels, svc.Spec.Selector)
+	assert.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+	assert.Equal(t, e.UID, metav1.GetControllerOf(svc).UID)
+
+	// templates without ports can not be exposed
+	template.Template.Spec.Containers[0].Ports = nil
+	rs = templateToRS(e, template, 1)
+	exCtx = newTestContext(e)
+	err = exCtx.reconcileService(template, rs, 1, &v1alpha1.TemplateStatus{})
+	assert.EqualError(t, err, "the containers of template 'bar' do not expose any ports")
+}
+
+func TestReconcileServiceDeletesService(t *testing.T) {
+	e, template := newWeightedExperiment()
+	rs := templateToRS(e, template, 1)
+	svc, _ := newServiceForTemplate(e, template, rs)
+	exCtx := newTestContext(e, svc)
+	templateStatus := v1alpha1.TemplateStatus{Name: template.Name, ServiceName: svc.Name, Replicas: 1}
+
+	// the Service is kept until the pods of the template are terminated
+	assert.NoError(t, exCtx.reconcileService(template, rs, 0, &templateStatus))
+	assert.Equal(t, svc.Name, templateStatus.ServiceName)
+	assert.Empty(t, exCtx.kubeclientset.(*k8sfake.Clientset).Actions())
+
+	templateStatus.Replicas = 0
+	assert.NoError(t, exCtx.reconcileService(template, rs, 0, &templateStatus))
+	assert.Equal(t, "", templateStatus.ServiceName)
+	actions := exCtx.kubeclientset.(*k8sfake.Clientset).Actions()
+	assert.Len(t, actions, 1)
+	assert.True(t, actions[0].Matches("delete", "services"))
+}
+
+func TestReconcileServiceNotOwned(t *testing.T) {
+	e, template := newWeightedExperiment()
+	rs := templateToRS(e, template, 1)
+	svc, _ := newServiceForTemplate(e, template, rs)
+	svc.OwnerReferences = nil
+	exCtx := newTestContext(e, svc)
+
+	err := exCtx.reconcileService(template, rs, 1, &v1alpha1.TemplateStatus{})
+	assert.EqualError(t, err, "Service 'foo-bar' already exists and is not owned by the experiment")
+}
@@ -0,0 +1,243 @@
+package cloudevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is a CloudEvent quality gate
+	ProviderType = "CloudEvent"
+	// DefaultSource is the source of the events when the metric does not specify one
+	DefaultSource = "argo-rollouts"
+	// DefaultTimeoutSeconds is the time to wait for the result when the metric does not specify one
+	DefaultTimeoutSeconds = 600
+
+	// EventIDKey is the measurement metadata key of the id of the event
+	EventIDKey = "eventId"
+	// ContextKey is the measurement metadata key of the context of the evaluation
+	ContextKey = "context"
+
+	contentType          = "application/cloudevents+json"
+	resumeDelay          = 15 * time.Second
+	httpConnectionTimout = 15 * time.Second
+)
+
+// event is a CloudEvent in the structured content mode
+type event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Provider sends a CloudEvent requesting an evaluation and polls for its result
+// Implements the Provider Interface
+type Provider struct {
+	logCtx log.Entry
+	client *http.Client
+}
+
+// Type indicates provider is a CloudEvent provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run sends the event requesting the evaluation
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	measurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+	ce := metric.Provider.CloudEvent
+
+	e := event{
+		SpecVersion: "1.0",
+		ID:          string(uuid.NewUUID()),
+		Source:      ce.Source,
+		Type:        ce.Type,
+		Subject:     ce.Subject,
+		Time:        startTime.UTC().Format(time.RFC3339),
+	}
+	if e.Source == "" {
+		e.Source = DefaultSource
+	}
+	if ce.Data != "" {
+		if !json.Valid([]byte(ce.Data)) {
+			return metricutil.MarkMeasurementError(measurement, errors.New("data of the event is not valid JSON"))
+		}
+		e.DataContentType = "application/json"
+		e.Data = json.RawMessage(ce.Data)
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+
+	data, status, err := p.do(http.MethodPost, ce.URL, ce.Headers, body)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	if status < 200 || status >= 300 {
+		return metricutil.MarkMeasurementError(measurement, fmt.Errorf("sending the event failed with status code %d", status))
+	}
+
+	context := e.ID
+	if ce.ContextJSONPath != "" {
+		context, err = find(ce.ContextJSONPath, data)
+		if err != nil {
+			return metricutil.MarkMeasurementError(measurement, fmt.Errorf("could not read the context of the evaluation: %v", err))
+		}
+		if context == "" {
+			return metricutil.MarkMeasurementError(measurement, errors.New("the response to the event has no context"))
+		}
+	}
+	measurement.Metadata = map[string]string{
+		EventIDKey: e.ID,
+		ContextKey: context,
+	}
+	measurement.Phase = v1alpha1.AnalysisPhaseRunning
+	resumeTime := metav1.NewTime(time.Now().Add(resumeDelay))
+	measurement.ResumeAt = &resumeTime
+	return measurement
+}
+
+// Resume polls for the result of the evaluation
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	ce := metric.Provider.CloudEvent
+	if measurement.Metadata == nil || measurement.Metadata[ContextKey] == "" {
+		return metricutil.MarkMeasurementError(measurement, errors.New("evaluation metadata reference missing"))
+	}
+	resultURL := strings.NewReplacer(
+		"$(context)", measurement.Metadata[ContextKey],
+		"$(id)", measurement.Metadata[EventIDKey],
+	).Replace(ce.ResultURL)
+
+	data, status, err := p.do(http.MethodGet, resultURL, ce.Headers, nil)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	var result string
+	switch {
+	case status == http.StatusNotFound:
+	case status < 200 || status >= 300:
+		return metricutil.MarkMeasurementError(measurement, fmt.Errorf("reading the result failed with status code %d", status))
+	default:
+		// the result is missing until the evaluation completes
+		result, _ = find(ce.ResultJSONPath, data)
+	}
+
+	if result == "" {
+		timeout := time.Duration(ce.TimeoutSeconds) * time.Second
+		if ce.TimeoutSeconds <= 0 {
+			timeout = DefaultTimeoutSeconds * time.Second
+		}
+		if measurement.StartedAt != nil && time.Since(measurement.StartedAt.Time) > timeout {
+			return metricutil.MarkMeasurementError(measurement, fmt.Errorf("timed out after %v waiting for the result of the evaluation", timeout))
+		}
+		resumeTime := metav1.NewTime(time.Now().Add(resumeDelay))
+		measurement.ResumeAt = &resumeTime
+		measurement.Phase = v1alpha1.AnalysisPhaseRunning
+		return measurement
+	}
+
+	measurement.Value = result
+	measurement.Phase = evaluate.EvaluateResult(result, metric, p.logCtx)
+	finishTime := metav1.Now()
+	measurement.FinishedAt = &finishTime
+	return measurement
+}
+
+// Terminate stops waiting for the result of the evaluation
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	now := metav1.Now()
+	measurement.FinishedAt = &now
+	measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+	p.logCtx.Infof("stopped waiting for the evaluation %s", measurement.Metadata[ContextKey])
+	return measurement
+}
+
+// GarbageCollect is a no-op for the CloudEvent provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+func (p *Provider) do(method, url string, headers []v1alpha1.WebMetricHeader, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, header := range headers {
+		req.Header.Set(header.Key, header.Value)
+	}
+	response, err := p.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, response.StatusCode, nil
+}
+
+// find returns the value selected by the JSONPath in the JSON data
+func find(path string, data []byte) (string, error) {
+	parser := jsonpath.New("cloudevent")
+	if err := parser.Parse(path); err != nil {
+		return "", err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", fmt.Errorf("could not parse JSON body: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := parser.Execute(buf, obj); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ValidateJSONPaths returns an error if the JSONPaths of the metric cannot be parsed
+func ValidateJSONPaths(metric v1alpha1.Metric) error {
+	for _, path := range []string{metric.Provider.CloudEvent.ContextJSONPath, metric.Provider.CloudEvent.ResultJSONPath} {
+		if path == "" {
+			continue
+		}
+		if err := jsonpath.New("cloudevent").Parse(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCloudEventProvider creates a new CloudEvent provider
+func NewCloudEventProvider(logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		client: &http.Client{Timeout: httpConnectionTimout},
+	}
+}
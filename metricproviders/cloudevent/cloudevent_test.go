@@ -0,0 +1,188 @@
+package cloudevent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// fakeKeptn accepts evaluation events and serves the evaluation finished events
+type fakeKeptn struct {
+	*httptest.Server
+	events []map[string]interface{}
+	result string
+}
+
+func newFakeKeptn(t *testing.T) *fakeKeptn {
+	k := &fakeKeptn{}
+	k.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "secret", req.Header.Get("x-token"))
+		switch req.URL.Path {
+		case "/api/v1/event":
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, "application/cloudevents+json", req.Header.Get("Content-Type"))
+			body, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			var e map[string]interface{}
+			assert.NoError(t, json.Unmarshal(body, &e))
+			k.events = append(k.events, e)
+			_, _ = w.Write([]byte(`{"keptnContext": "ctx-1"}`))
+		case "/api/mongodb-datastore/event/type/sh.keptn.event.evaluation.finished":
+			assert.Equal(t, "shkeptncontext:ctx-1", req.URL.Query().Get("filter"))
+			if k.result == "" {
+				_, _ = w.Write([]byte(`{"events": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"events": [{"data": {"result": "` + k.result + `"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return k
+}
+
+func newMetric(url string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "quality-gate",
+		SuccessCondition: `result == "pass"`,
+		FailureCondition: `result == "fail"`,
+		Provider: v1alpha1.MetricProvider{
+			CloudEvent: &v1alpha1.CloudEventMetric{
+				URL:             url + "/api/v1/event",
+				Type:            "sh.keptn.event.hello.evaluation.triggered",
+				Subject:         "guestbook",
+				Data:            `{"project": "sockshop", "stage": "hardening", "service": "guestbook"}`,
+				Headers:         []v1alpha1.WebMetricHeader{{Key: "x-token", Value: "secret"}},
+				ContextJSONPath: "{$.keptnContext}",
+				ResultURL:       url + "/api/mongodb-datastore/event/type/sh.keptn.event.evaluation.finished?filter=shkeptncontext:$(context)",
+				ResultJSONPath:  "{$.events[0].data.result}",
+			},
+		},
+	}
+}
+
+func TestRunAndResume(t *testing.T) {
+	keptn := newFakeKeptn(t)
+	defer keptn.Close()
+	p := NewCloudEventProvider(*log.WithField("test", t.Name()))
+	metric := newMetric(keptn.URL)
+	assert.Equal(t, ProviderType, p.Type())
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.NotNil(t, measurement.ResumeAt)
+	assert.Equal(t, "ctx-1", measurement.Metadata[ContextKey])
+	assert.Len(t, keptn.events, 1)
+	e := keptn.events[0]
+	assert.Equal(t, "1.0", e["specversion"])
+	assert.Equal(t, measurement.Metadata[EventIDKey], e["id"])
+	assert.Equal(t, DefaultSource, e["source"])
+	assert.Equal(t, "sh.keptn.event.hello.evaluation.triggered", e["type"])
+	assert.Equal(t, "guestbook", e["subject"])
+	assert.Equal(t, map[string]interface{}{"project": "sockshop", "stage": "hardening", "service": "guestbook"}, e["data"])
+
+	// the evaluation has not finished
+	measurement = p.Resume(&v1alpha1.AnalysisRun{}, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Nil(t, measurement.FinishedAt)
+
+	keptn.result = "fail"
+	measurement = p.Resume(&v1alpha1.AnalysisRun{}, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "fail", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestRunDefaultsContextToEventID(t *testing.T) {
+	var id string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			var e map[string]interface{}
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&e))
+			id = e["id"].(string)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		assert.Equal(t, "/results/"+id, req.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "pass"}`))
+	}))
+	defer server.Close()
+	p := NewCloudEventProvider(*log.WithField("test", t.Name()))
+	metric := v1alpha1.Metric{
+		SuccessCondition: `result == "pass"`,
+		Provider: v1alpha1.MetricProvider{
+			CloudEvent: &v1alpha1.CloudEventMetric{
+				URL:            server.URL,
+				Type:           "dev.cdevents.testsuite.finished",
+				ResultURL:      server.URL + "/results/$(id)",
+				ResultJSONPath: "{$.result}",
+			},
+		},
+	}
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Equal(t, id, measurement.Metadata[ContextKey])
+	measurement = p.Resume(&v1alpha1.AnalysisRun{}, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+}
+
+func TestRunErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	p := NewCloudEventProvider(*log.WithField("test", t.Name()))
+
+	metric := newMetric(server.URL)
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "sending the event failed with status code 400", measurement.Message)
+
+	metric.Provider.CloudEvent.Data = "{"
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "data of the event is not valid JSON", measurement.Message)
+}
+
+func TestResumeTimeout(t *testing.T) {
+	keptn := newFakeKeptn(t)
+	defer keptn.Close()
+	p := NewCloudEventProvider(*log.WithField("test", t.Name()))
+	metric := newMetric(keptn.URL)
+	metric.Provider.CloudEvent.TimeoutSeconds = 60
+	startedAt := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	measurement := v1alpha1.Measurement{
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+		StartedAt: &startedAt,
+		Metadata:  map[string]string{ContextKey: "ctx-1"},
+	}
+
+	measurement = p.Resume(&v1alpha1.AnalysisRun{}, metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "timed out after 1m0s waiting for the result of the evaluation", measurement.Message)
+}
+
+func TestTerminate(t *testing.T) {
+	p := NewCloudEventProvider(*log.WithField("test", t.Name()))
+	measurement := p.Terminate(&v1alpha1.AnalysisRun{}, newMetric(""), v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning})
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.NoError(t, p.GarbageCollect(&v1alpha1.AnalysisRun{}, newMetric(""), 0))
+}
+
+func TestValidateJSONPaths(t *testing.T) {
+	metric := newMetric("")
+	assert.NoError(t, ValidateJSONPaths(metric))
+	metric.Provider.CloudEvent.ResultJSONPath = "{$.events["
+	assert.Error(t, ValidateJSONPaths(metric))
+}
@@ -0,0 +1,641 @@
+package datadog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
+)
+
+const (
+	//ProviderType indicates the provider is datadog
+	ProviderType = "Datadog"
+	//k8s secret that has the datadog api and application keys
+	DatadogTokensSecretName = "datadog-api-keys"
+	// DefaultAddress is the datadog API used when the secret does not have an address
+	DefaultAddress = "https://api.datadoghq.com"
+	// DefaultInterval is the time window of the query when the metric does not specify one
+	DefaultInterval = v1alpha1.DurationString("5m")
+	// APIVersionV1 queries the v1 query API. It is the default API version.
+	APIVersionV1 = "v1"
+	// APIVersionV2 queries the v2 timeseries API, which supports formulas
+	APIVersionV2 = "v2"
+	// v2QueryName is the name of the query of a v2 metric which only has a query
+	v2QueryName = "query"
+
+	// AggregatorAvg, AggregatorMin, AggregatorMax, AggregatorLast, AggregatorSum and AggregatorP95
+	// reduce the points of a series to the result of a measurement
+	AggregatorAvg  = "avg"
+	AggregatorMin  = "min"
+	AggregatorMax  = "max"
+	AggregatorLast = "last"
+	AggregatorSum  = "sum"
+	AggregatorP95  = "p95"
+
+	// MultipleSeriesFirst, MultipleSeriesEach and MultipleSeriesMerge define how the series of a
+	// query returning several series are evaluated
+	MultipleSeriesFirst = "first"
+	MultipleSeriesEach  = "each"
+	MultipleSeriesMerge = "merge"
+
+	// MonitorStateOK, MonitorStateWarn and MonitorStateAlert are the states of a datadog monitor
+	// which are evaluated without success and failure conditions. Other states, such as No Data, are
+	// inconclusive.
+	MonitorStateOK    = "OK"
+	MonitorStateWarn  = "Warn"
+	MonitorStateAlert = "Alert"
+
+	// AddressEnv, APIKeyEnv and AppKeyEnv are the environment variables of the controller read when
+	// the metric has no secretRef and the datadog-api-keys secret does not exist
+	AddressEnv = "DD_ADDRESS"
+	APIKeyEnv  = "DD_API_KEY"
+	AppKeyEnv  = "DD_APP_KEY"
+
+	addressKey = "address"
+	apiKeyKey  = "api-key"
+	appKeyKey  = "app-key"
+
+	requestTimeout = 10 * time.Second
+)
+
+// DatadogAPI sends queries to a datadog account
+type DatadogAPI struct {
+	client  *http.Client
+	address string
+	apiKey  string
+	appKey  string
+}
+
+// series are the values of a timeseries returned by a query. Values are nil when there is no data.
+type series []*float64
+
+type datadogResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Series []struct {
+		// Pointlist is a list of [<timestamp>, <value>] pairs
+		Pointlist [][]*float64 `json:"pointlist"`
+	} `json:"series"`
+}
+
+type datadogV2Query struct {
+	DataSource string `json:"data_source"`
+	Query      string `json:"query"`
+	Name       string `json:"name"`
+}
+
+type datadogV2Formula struct {
+	Formula string `json:"formula"`
+}
+
+type datadogV2Request struct {
+	Data struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			From     int64              `json:"from"`
+			To       int64              `json:"to"`
+			Queries  []datadogV2Query   `json:"queries"`
+			Formulas []datadogV2Formula `json:"formulas"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type datadogV2Response struct {
+	Data struct {
+		Attributes struct {
+			Values [][]*float64 `json:"values"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Query queries the timeseries of the query between from and to with the v1 API
+func (api *DatadogAPI) Query(query string, from, to time.Time) ([]series, error) {
+	endpoint, err := url.Parse(api.address + "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("from", strconv.FormatInt(from.Unix(), 10))
+	params.Set("to", strconv.FormatInt(to.Unix(), 10))
+	endpoint.RawQuery = params.Encode()
+
+	request, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var res datadogResponse
+	if err := api.do(request, &res); err != nil {
+		return nil, err
+	}
+	if res.Status == "error" {
+		return nil, fmt.Errorf("query failed: %s", res.Error)
+	}
+	result := make([]series, 0, len(res.Series))
+	for _, s := range res.Series {
+		values := make(series, 0, len(s.Pointlist))
+		for _, point := range s.Pointlist {
+			if len(point) < 2 {
+				values = append(values, nil)
+				continue
+			}
+			values = append(values, point[1])
+		}
+		result = append(result, values)
+	}
+	return result, nil
+}
+
+// QueryTimeseries queries the timeseries of the formula combining the named queries between from
+// and to with the v2 API
+func (api *DatadogAPI) QueryTimeseries(queries map[string]string, formula string, from, to time.Time) ([]series, error) {
+	var body datadogV2Request
+	body.Data.Type = "timeseries_request"
+	body.Data.Attributes.From = from.Unix() * 1000
+	body.Data.Attributes.To = to.Unix() * 1000
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		body.Data.Attributes.Queries = append(body.Data.Attributes.Queries, datadogV2Query{
+			DataSource: "metrics",
+			Query:      queries[name],
+			Name:       name,
+		})
+	}
+	body.Data.Attributes.Formulas = []datadogV2Formula{{Formula: formula}}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", api.address+"/api/v2/query/timeseries", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var res datadogV2Response
+	if err := api.do(request, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		return nil, fmt.Errorf("query failed: %s", strings.Join(res.Errors, ", "))
+	}
+	result := make([]series, 0, len(res.Data.Attributes.Values))
+	for _, values := range res.Data.Attributes.Values {
+		result = append(result, values)
+	}
+	return result, nil
+}
+
+type datadogMonitor struct {
+	OverallState string `json:"overall_state"`
+}
+
+// MonitorState returns the overall state of the monitor
+func (api *DatadogAPI) MonitorState(monitorID string) (string, error) {
+	id, err := strconv.ParseInt(monitorID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid datadog monitorId '%s'", monitorID)
+	}
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/monitor/%d", api.address, id), nil)
+	if err != nil {
+		return "", err
+	}
+	var res datadogMonitor
+	if err := api.do(request, &res); err != nil {
+		return "", err
+	}
+	if res.OverallState == "" {
+		return "", fmt.Errorf("monitor %d has no state", id)
+	}
+	return res.OverallState, nil
+}
+
+type datadogSLOHistory struct {
+	Data struct {
+		Overall struct {
+			SLIValue             *float64           `json:"sli_value"`
+			ErrorBudgetRemaining map[string]float64 `json:"error_budget_remaining"`
+		} `json:"overall"`
+		Thresholds map[string]struct {
+			Target float64 `json:"target"`
+		} `json:"thresholds"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// SLOStatus is the status of an SLO over a time window
+type SLOStatus struct {
+	// SLI is the percentage of good events or time during the window
+	SLI float64
+	// Target is the target percentage of the SLO
+	Target float64
+	// ErrorBudgetRemaining is the percentage of the error budget of the timeframe of the target left
+	ErrorBudgetRemaining float64
+	// BurnRate is the rate the error budget was consumed at during the window. A burn rate of 1
+	// consumes the error budget exactly in the timeframe of the target
+	BurnRate float64
+}
+
+// SLOStatus returns the status of the SLO between from and to. The timeframe selects the target of
+// an SLO with several targets.
+func (api *DatadogAPI) SLOStatus(sloID, timeframe string, from, to time.Time) (*SLOStatus, error) {
+	endpoint, err := url.Parse(fmt.Sprintf("%s/api/v1/slo/%s/history", api.address, url.PathEscape(sloID)))
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("from_ts", strconv.FormatInt(from.Unix(), 10))
+	params.Set("to_ts", strconv.FormatInt(to.Unix(), 10))
+	endpoint.RawQuery = params.Encode()
+
+	request, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var res datadogSLOHistory
+	if err := api.do(request, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		return nil, fmt.Errorf("query failed: %s", strings.Join(res.Errors, ", "))
+	}
+	if res.Data.Overall.SLIValue == nil {
+		return nil, fmt.Errorf("slo %s has no data", sloID)
+	}
+	if timeframe == "" {
+		if len(res.Data.Thresholds) != 1 {
+			return nil, fmt.Errorf("slo %s has %d targets, the sloTimeframe needs to select one", sloID, len(res.Data.Thresholds))
+		}
+		for tf := range res.Data.Thresholds {
+			timeframe = tf
+		}
+	}
+	threshold, ok := res.Data.Thresholds[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("slo %s has no target for the timeframe %s", sloID, timeframe)
+	}
+	if threshold.Target >= 100 {
+		return nil, fmt.Errorf("slo %s has no error budget with a target of %v", sloID, threshold.Target)
+	}
+	sli := *res.Data.Overall.SLIValue
+	return &SLOStatus{
+		SLI:                  sli,
+		Target:               threshold.Target,
+		ErrorBudgetRemaining: res.Data.Overall.ErrorBudgetRemaining[timeframe],
+		BurnRate:             (100 - sli) / (100 - threshold.Target),
+	}, nil
+}
+
+// do sends the request with the datadog keys and parses the JSON response into res
+func (api *DatadogAPI) do(request *http.Request, res interface{}) error {
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("DD-API-KEY", api.apiKey)
+	request.Header.Set("DD-APPLICATION-KEY", api.appKey)
+
+	response, err := api.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("received non 2xx response code: %v", response.StatusCode)
+	}
+	if err := json.Unmarshal(body, res); err != nil {
+		return fmt.Errorf("could not parse JSON body: %v", err)
+	}
+	return nil
+}
+
+// Provider contains all the required components to run a Datadog query
+type Provider struct {
+	secrets secretutil.Getter
+	logCtx  log.Entry
+}
+
+// Type indicates provider is a Datadog provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run queries datadog for the metric over the interval of the metric ending now, or reads the state of
+// the monitor or the status of the SLO of the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	api, err := NewDatadogAPI(run.Namespace, metric, p.secrets)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	if metric.Provider.Datadog.MonitorID != "" {
+		state, err := api.MonitorState(metric.Provider.Datadog.MonitorID)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		newMeasurement.Value = state
+		newMeasurement.Phase = p.evaluateMonitorState(metric, state)
+		finishedTime := metav1.Now()
+		newMeasurement.FinishedAt = &finishedTime
+		return newMeasurement
+	}
+	interval, err := Interval(metric)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	if metric.Provider.Datadog.SLOID != "" {
+		status, err := api.SLOStatus(metric.Provider.Datadog.SLOID, metric.Provider.Datadog.SLOTimeframe, startTime.Add(-interval), startTime.Time)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		newValue, newStatus, err := p.evaluateSLOStatus(metric, status)
+		if err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		newMeasurement.Value = newValue
+		newMeasurement.Phase = newStatus
+		finishedTime := metav1.Now()
+		newMeasurement.FinishedAt = &finishedTime
+		return newMeasurement
+	}
+	var response []series
+	if metric.Provider.Datadog.APIVersion == APIVersionV2 {
+		queries, formula := V2Queries(metric)
+		response, err = api.QueryTimeseries(queries, formula, startTime.Add(-interval), startTime.Time)
+	} else {
+		response, err = api.Query(metric.Provider.Datadog.Query, startTime.Add(-interval), startTime.Time)
+	}
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// evaluateMonitorState evaluates the state of a monitor with the conditions of the metric. Without
+// conditions, OK is successful, Alert failed and any other state inconclusive.
+func (p *Provider) evaluateMonitorState(metric v1alpha1.Metric, state string) v1alpha1.AnalysisPhase {
+	if metric.SuccessCondition != "" || metric.FailureCondition != "" {
+		return evaluate.EvaluateResult(state, metric, p.logCtx)
+	}
+	switch state {
+	case MonitorStateOK:
+		return v1alpha1.AnalysisPhaseSuccessful
+	case MonitorStateAlert:
+		return v1alpha1.AnalysisPhaseFailed
+	}
+	return v1alpha1.AnalysisPhaseInconclusive
+}
+
+// evaluateSLOStatus evaluates the status of an SLO with the conditions of the metric. The result has
+// the sli, target, errorBudgetRemaining and burnRate fields.
+func (p *Provider) evaluateSLOStatus(metric v1alpha1.Metric, status *SLOStatus) (string, v1alpha1.AnalysisPhase, error) {
+	result := map[string]interface{}{
+		"sli":                  status.SLI,
+		"target":               status.Target,
+		"errorBudgetRemaining": status.ErrorBudgetRemaining,
+		"burnRate":             status.BurnRate,
+	}
+	value, err := json.Marshal(result)
+	if err != nil {
+		return "", v1alpha1.AnalysisPhaseError, err
+	}
+	return string(value), evaluate.EvaluateResult(result, metric, p.logCtx), nil
+}
+
+// processResponse evaluates the series returned by the query, reduced by the aggregator of the metric
+func (p *Provider) processResponse(metric v1alpha1.Metric, response []series) (string, v1alpha1.AnalysisPhase, error) {
+	datadog := metric.Provider.Datadog
+	switch datadog.MultipleSeries {
+	case MultipleSeriesEach:
+		return p.processEachSeries(metric, response)
+	case MultipleSeriesMerge:
+		var merged series
+		for _, s := range response {
+			merged = append(merged, s...)
+		}
+		response = []series{merged}
+	default:
+		if len(response) > 1 {
+			p.logCtx.Warnf("Datadog query returned %d series, only the first series is evaluated", len(response))
+		}
+	}
+	if len(response) == 0 || len(response[0]) == 0 {
+		return "", v1alpha1.AnalysisPhaseError, errors.New("no data found in the response from datadog")
+	}
+	result, ok := Aggregate(datadog.Aggregator, response[0])
+	if !ok {
+		return "", v1alpha1.AnalysisPhaseError, errors.New("no value found in the points of the response from datadog")
+	}
+	newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+	return strconv.FormatFloat(result, 'f', -1, 64), newStatus, nil
+}
+
+// processEachSeries evaluates every series returned by the query which has data. The value is the
+// list of the results of the series, and the phase the worst phase of the series.
+func (p *Provider) processEachSeries(metric v1alpha1.Metric, response []series) (string, v1alpha1.AnalysisPhase, error) {
+	var values []string
+	phase := v1alpha1.AnalysisPhaseSuccessful
+	for _, s := range response {
+		result, ok := Aggregate(metric.Provider.Datadog.Aggregator, s)
+		if !ok {
+			continue
+		}
+		values = append(values, strconv.FormatFloat(result, 'f', -1, 64))
+		phase = analysisutil.Worst(phase, evaluate.EvaluateResult(result, metric, p.logCtx))
+	}
+	if len(values) == 0 {
+		return "", v1alpha1.AnalysisPhaseError, errors.New("no data found in the response from datadog")
+	}
+	return "[" + strings.Join(values, ",") + "]", phase, nil
+}
+
+// Aggregate reduces the points of the series with the aggregator, ignoring points without data. It
+// returns false if no point has data.
+func Aggregate(aggregator string, points series) (float64, bool) {
+	var values []float64
+	for _, point := range points {
+		if point != nil {
+			values = append(values, *point)
+		}
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	switch aggregator {
+	case AggregatorAvg, AggregatorSum:
+		sum := float64(0)
+		for _, value := range values {
+			sum += value
+		}
+		if aggregator == AggregatorAvg {
+			return sum / float64(len(values)), true
+		}
+		return sum, true
+	case AggregatorMin:
+		sort.Float64s(values)
+		return values[0], true
+	case AggregatorMax:
+		sort.Float64s(values)
+		return values[len(values)-1], true
+	case AggregatorP95:
+		// nearest rank percentile
+		sort.Float64s(values)
+		rank := int(math.Ceil(0.95 * float64(len(values))))
+		return values[rank-1], true
+	}
+	return values[len(values)-1], true
+}
+
+// Resume should not be used the Datadog provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Datadog provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the Datadog provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Datadog provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the Datadog provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// Interval returns the time window of the query of the metric
+func Interval(metric v1alpha1.Metric) (time.Duration, error) {
+	interval := metric.Provider.Datadog.Interval
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	duration, err := interval.Duration()
+	if err != nil {
+		return 0, fmt.Errorf("invalid datadog interval: %v", err)
+	}
+	if duration <= 0 {
+		return 0, errors.New("datadog interval must be greater than 0")
+	}
+	return duration, nil
+}
+
+// V2Queries returns the named queries of the v2 metric and the formula combining them. The query of
+// a metric without queries is named query.
+func V2Queries(metric v1alpha1.Metric) (map[string]string, string) {
+	datadog := metric.Provider.Datadog
+	queries := datadog.Queries
+	if len(queries) == 0 {
+		queries = map[string]string{v2QueryName: datadog.Query}
+	}
+	formula := datadog.Formula
+	if formula == "" && len(queries) == 1 {
+		for name := range queries {
+			formula = name
+		}
+	}
+	return queries, formula
+}
+
+// NewDatadogProvider creates a new Datadog provider reading the datadog keys with the secret getter
+func NewDatadogProvider(secrets secretutil.Getter, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx:  logCtx,
+		secrets: secrets,
+	}
+}
+
+// NewDatadogAPI generates a Datadog API client for the metric of an AnalysisRun in the namespace.
+// The address and keys are read from the secret referenced by the metric, in the namespace of the
+// AnalysisRun. Without a secretRef they are read from the datadog-api-keys secret in the namespace
+// of the controller, or from the environment of the controller if the secret does not exist.
+func NewDatadogAPI(namespace string, metric v1alpha1.Metric, secrets secretutil.Getter) (*DatadogAPI, error) {
+	ref := metric.Provider.Datadog.SecretRef
+	var address, apiKey, appKey string
+	if ref != nil {
+		secret, err := secrets.Get(namespace, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		address = string(secret.Data[keyOrDefault(ref.AddressKey, addressKey)])
+		apiKey = string(secret.Data[keyOrDefault(ref.APIKeyKey, apiKeyKey)])
+		appKey = string(secret.Data[keyOrDefault(ref.AppKeyKey, appKeyKey)])
+		if apiKey == "" || appKey == "" {
+			return nil, fmt.Errorf("secret '%s' needs the '%s' and '%s' keys", ref.Name, keyOrDefault(ref.APIKeyKey, apiKeyKey), keyOrDefault(ref.AppKeyKey, appKeyKey))
+		}
+	} else {
+		secret, err := secrets.Get(Namespace(), DatadogTokensSecretName)
+		switch {
+		case err == nil:
+			address = string(secret.Data[addressKey])
+			apiKey = string(secret.Data[apiKeyKey])
+			appKey = string(secret.Data[appKeyKey])
+			if apiKey == "" || appKey == "" {
+				return nil, fmt.Errorf("secret '%s' needs the '%s' and '%s' keys", DatadogTokensSecretName, apiKeyKey, appKeyKey)
+			}
+		case k8serrors.IsNotFound(err) && os.Getenv(APIKeyEnv) != "" && os.Getenv(AppKeyEnv) != "":
+			address = os.Getenv(AddressEnv)
+			apiKey = os.Getenv(APIKeyEnv)
+			appKey = os.Getenv(AppKeyEnv)
+		default:
+			return nil, err
+		}
+	}
+	if address == "" {
+		address = DefaultAddress
+	}
+	return &DatadogAPI{
+		client:  &http.Client{Timeout: requestTimeout},
+		address: address,
+		apiKey:  apiKey,
+		appKey:  appKey,
+	}, nil
+}
+
+func keyOrDefault(key, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
+}
+
+// Namespace returns the namespace the datadog api keys secret is read from
+func Namespace() string {
+	return defaults.Namespace()
+}
@@ -0,0 +1,447 @@
+package datadog
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
+)
+
+func newMetric(interval v1alpha1.DurationString) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "error-rate",
+		SuccessCondition: "result < 0.05",
+		FailureCondition: "result >= 0.05",
+		Provider: v1alpha1.MetricProvider{
+			Datadog: &v1alpha1.DatadogMetric{
+				Query:    "avg:kubernetes.cpu.user.total{*}",
+				Interval: interval,
+			},
+		},
+	}
+}
+
+func newSecret(namespace, name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+// newTestProvider returns a provider sending its queries to a server responding with the body and
+// the window of the last query
+func newTestProvider(t *testing.T, status int, body string) (*Provider, *time.Duration, func()) {
+	window := new(time.Duration)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v1/query", req.URL.Path)
+		assert.Equal(t, "api", req.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "app", req.Header.Get("DD-APPLICATION-KEY"))
+		from, _ := strconv.ParseInt(req.URL.Query().Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(req.URL.Query().Get("to"), 10, 64)
+		*window = time.Duration(to-from) * time.Second
+		rw.WriteHeader(status)
+		io.WriteString(rw, body)
+	}))
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"address": server.URL, "api-key": "api", "app-key": "app"})
+	secrets := secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret))
+	return NewDatadogProvider(secrets, *log.NewEntry(log.New())), window, server.Close
+}
+
+func TestType(t *testing.T) {
+	p := NewDatadogProvider(nil, log.Entry{})
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	p, window, closeServer := newTestProvider(t, 200, `{"status": "ok", "series": [{"pointlist": [[1598867910000, 0.2], [1598867925000, 0.0020008318672513122]]}]}`)
+	defer closeServer()
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric(""))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "0.0020008318672513122", measurement.Value)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.FinishedAt)
+	assert.Equal(t, 5*time.Minute, *window)
+}
+
+func TestRunWithInterval(t *testing.T) {
+	p, window, closeServer := newTestProvider(t, 200, `{"status": "ok", "series": [{"pointlist": [[1598867925000, 0.1]]}]}`)
+	defer closeServer()
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric("15m"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, 15*time.Minute, *window)
+}
+
+func TestRunV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/api/v2/query/timeseries", req.URL.Path)
+		assert.Equal(t, "api", req.Header.Get("DD-API-KEY"))
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		var request datadogV2Request
+		assert.NoError(t, json.Unmarshal(body, &request))
+		attributes := request.Data.Attributes
+		assert.Equal(t, "timeseries_request", request.Data.Type)
+		assert.Equal(t, int64(10*time.Minute/time.Millisecond), attributes.To-attributes.From)
+		assert.Equal(t, []datadogV2Query{
+			{DataSource: "metrics", Query: "sum:requests.error{*}.as_count()", Name: "errors"},
+			{DataSource: "metrics", Query: "sum:requests.hit{*}.as_count()", Name: "hits"},
+		}, attributes.Queries)
+		assert.Equal(t, []datadogV2Formula{{Formula: "errors / hits * 100"}}, attributes.Formulas)
+		io.WriteString(rw, `{"data": {"type": "timeseries_response", "attributes": {"times": [1598867910000, 1598867925000], "values": [[12.5, 1.5]]}}}`)
+	}))
+	defer server.Close()
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"address": server.URL, "api-key": "api", "app-key": "app"})
+	p := NewDatadogProvider(secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)), *log.NewEntry(log.New()))
+
+	metric := v1alpha1.Metric{
+		Name:             "error-rate",
+		SuccessCondition: "result < 5",
+		Provider: v1alpha1.MetricProvider{
+			Datadog: &v1alpha1.DatadogMetric{
+				APIVersion: APIVersionV2,
+				Interval:   "10m",
+				Queries: map[string]string{
+					"hits":   "sum:requests.hit{*}.as_count()",
+					"errors": "sum:requests.error{*}.as_count()",
+				},
+				Formula: "errors / hits * 100",
+			},
+		},
+	}
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "1.5", measurement.Value)
+}
+
+func TestRunV2WithErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		io.WriteString(rw, `{"errors": ["Invalid formula: unknown query 'c'"]}`)
+	}))
+	defer server.Close()
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"address": server.URL, "api-key": "api", "app-key": "app"})
+	p := NewDatadogProvider(secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)), *log.NewEntry(log.New()))
+
+	metric := newMetric("")
+	metric.Provider.Datadog.APIVersion = APIVersionV2
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "query failed: Invalid formula: unknown query 'c'", measurement.Message)
+}
+
+func TestV2Queries(t *testing.T) {
+	queries, formula := V2Queries(newMetric(""))
+	assert.Equal(t, map[string]string{"query": "avg:kubernetes.cpu.user.total{*}"}, queries)
+	assert.Equal(t, "query", formula)
+
+	metric := newMetric("")
+	metric.Provider.Datadog.Query = ""
+	metric.Provider.Datadog.Queries = map[string]string{"cpu": "avg:kubernetes.cpu.user.total{*}.rollup(max, 60)"}
+	queries, formula = V2Queries(metric)
+	assert.Equal(t, metric.Provider.Datadog.Queries, queries)
+	assert.Equal(t, "cpu", formula)
+}
+
+func TestRunWithErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		message string
+	}{
+		{500, `{}`, "received non 2xx response code: 500"},
+		{200, `{"status": "error", "error": "Rate limit of 300 requests in 3600 seconds reached"}`, "query failed: Rate limit of 300 requests in 3600 seconds reached"},
+		{200, `not json`, "could not parse JSON body: invalid character 'o' in literal null (expecting 'u')"},
+		{200, `{"status": "ok", "series": []}`, "no data found in the response from datadog"},
+		{200, `{"status": "ok", "series": [{"pointlist": [[1598867925000, null]]}]}`, "no value found in the points of the response from datadog"},
+	}
+	for _, test := range tests {
+		p, _, closeServer := newTestProvider(t, test.status, test.body)
+		measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric(""))
+		closeServer()
+		assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+		assert.Equal(t, test.message, measurement.Message)
+	}
+}
+
+func TestRunWithAggregator(t *testing.T) {
+	p, _, closeServer := newTestProvider(t, 200, `{"status": "ok", "series": [{"pointlist": [[1598867910000, 0.01], [1598867925000, 0.2], [1598867940000, null], [1598867955000, 0.03]]}]}`)
+	defer closeServer()
+
+	metric := newMetric("")
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "0.03", measurement.Value)
+
+	// the spike fails the measurement only if it is the max
+	metric.Provider.Datadog.Aggregator = AggregatorMax
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "0.2", measurement.Value)
+}
+
+func TestRunWithMultipleSeries(t *testing.T) {
+	// the query is grouped by pod, and only the second pod has a high error rate
+	p, _, closeServer := newTestProvider(t, 200, `{"status": "ok", "series": [
+		{"pointlist": [[1598867910000, 0.01], [1598867925000, 0.02]]},
+		{"pointlist": [[1598867910000, 0.2], [1598867925000, 0.1]]},
+		{"pointlist": [[1598867910000, null]]}
+	]}`)
+	defer closeServer()
+	metric := newMetric("")
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "0.02", measurement.Value)
+
+	metric.Provider.Datadog.MultipleSeries = MultipleSeriesEach
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "[0.02,0.1]", measurement.Value)
+
+	metric.Provider.Datadog.MultipleSeries = MultipleSeriesMerge
+	metric.Provider.Datadog.Aggregator = AggregatorMax
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "0.2", measurement.Value)
+}
+
+func TestRunEachSeriesWithoutData(t *testing.T) {
+	p, _, closeServer := newTestProvider(t, 200, `{"status": "ok", "series": [{"pointlist": [[1598867910000, null]]}]}`)
+	defer closeServer()
+	metric := newMetric("")
+	metric.Provider.Datadog.MultipleSeries = MultipleSeriesEach
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "no data found in the response from datadog", measurement.Message)
+}
+
+func TestRunWithMonitor(t *testing.T) {
+	state := "OK"
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "/api/v1/monitor/1234", req.URL.Path)
+		assert.Equal(t, "app", req.Header.Get("DD-APPLICATION-KEY"))
+		io.WriteString(rw, `{"id": 1234, "name": "checkout error rate", "overall_state": "`+state+`"}`)
+	}))
+	defer server.Close()
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"address": server.URL, "api-key": "api", "app-key": "app"})
+	p := NewDatadogProvider(secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)), *log.NewEntry(log.New()))
+	metric := v1alpha1.Metric{
+		Name: "monitor",
+		Provider: v1alpha1.MetricProvider{
+			Datadog: &v1alpha1.DatadogMetric{MonitorID: "1234"},
+		},
+	}
+
+	tests := map[string]v1alpha1.AnalysisPhase{
+		"OK":      v1alpha1.AnalysisPhaseSuccessful,
+		"Alert":   v1alpha1.AnalysisPhaseFailed,
+		"Warn":    v1alpha1.AnalysisPhaseInconclusive,
+		"No Data": v1alpha1.AnalysisPhaseInconclusive,
+	}
+	for state = range tests {
+		measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+		assert.Equal(t, tests[state], measurement.Phase, state)
+		assert.Equal(t, state, measurement.Value)
+	}
+
+	// conditions replace the default evaluation of the states
+	state = "Warn"
+	metric.SuccessCondition = `result in ["OK", "Warn"]`
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+
+	metric.Provider.Datadog.MonitorID = "checkout"
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "invalid datadog monitorId 'checkout'", measurement.Message)
+}
+
+func TestRunWithSLO(t *testing.T) {
+	body := `{"data": {"overall": {"sli_value": 99, "error_budget_remaining": {"7d": 85.5}}, "thresholds": {"7d": {"target": 99.5, "timeframe": "7d"}}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v1/slo/abc123/history", req.URL.Path)
+		from, _ := strconv.ParseInt(req.URL.Query().Get("from_ts"), 10, 64)
+		to, _ := strconv.ParseInt(req.URL.Query().Get("to_ts"), 10, 64)
+		assert.Equal(t, int64(3600), to-from)
+		io.WriteString(rw, body)
+	}))
+	defer server.Close()
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"address": server.URL, "api-key": "api", "app-key": "app"})
+	p := NewDatadogProvider(secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)), *log.NewEntry(log.New()))
+	metric := v1alpha1.Metric{
+		Name:             "burn-rate",
+		SuccessCondition: "result.burnRate < 1 && result.errorBudgetRemaining > 50",
+		Provider: v1alpha1.MetricProvider{
+			Datadog: &v1alpha1.DatadogMetric{SLOID: "abc123", Interval: "1h"},
+		},
+	}
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, `{"burnRate":2,"errorBudgetRemaining":85.5,"sli":99,"target":99.5}`, measurement.Value)
+
+	metric.SuccessCondition = "result.burnRate < 3"
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+
+	metric.Provider.Datadog.SLOTimeframe = "30d"
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "slo abc123 has no target for the timeframe 30d", measurement.Message)
+
+	body = `{"data": {"overall": {"sli_value": 99}, "thresholds": {"7d": {"target": 99.5}, "30d": {"target": 99.9}}}}`
+	metric.Provider.Datadog.SLOTimeframe = ""
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "slo abc123 has 2 targets, the sloTimeframe needs to select one", measurement.Message)
+
+	body = `{"data": {"overall": {}}}`
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "slo abc123 has no data", measurement.Message)
+}
+
+func TestAggregate(t *testing.T) {
+	point := func(v float64) *float64 {
+		return &v
+	}
+	points := series{point(4), nil, point(1), point(3), point(2)}
+	tests := map[string]float64{
+		"":             2,
+		AggregatorLast: 2,
+		AggregatorAvg:  2.5,
+		AggregatorMin:  1,
+		AggregatorMax:  4,
+		AggregatorSum:  10,
+		AggregatorP95:  4,
+	}
+	for aggregator, expected := range tests {
+		result, ok := Aggregate(aggregator, points)
+		assert.True(t, ok)
+		assert.Equal(t, expected, result, aggregator)
+	}
+
+	var many series
+	for i := 1; i <= 100; i++ {
+		many = append(many, point(float64(i)))
+	}
+	result, _ := Aggregate(AggregatorP95, many)
+	assert.Equal(t, float64(95), result)
+
+	_, ok := Aggregate(AggregatorAvg, series{nil})
+	assert.False(t, ok)
+}
+
+func TestInterval(t *testing.T) {
+	interval, err := Interval(newMetric(""))
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, interval)
+
+	interval, err = Interval(newMetric("1h"))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, interval)
+
+	_, err = Interval(newMetric("-1m"))
+	assert.EqualError(t, err, "datadog interval must be greater than 0")
+}
+
+func TestNewDatadogAPI(t *testing.T) {
+	secret := newSecret(Namespace(), DatadogTokensSecretName, map[string]string{"api-key": "api"})
+	fakeClient := k8sfake.NewSimpleClientset(secret)
+	metric := newMetric("")
+
+	_, err := NewDatadogAPI("default", metric, secretutil.NewGetter(nil, fakeClient))
+	assert.EqualError(t, err, "secret 'datadog-api-keys' needs the 'api-key' and 'app-key' keys")
+
+	secret.Data["app-key"] = []byte("app")
+	api, err := NewDatadogAPI("default", metric, secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAddress, api.address)
+	assert.Equal(t, "api", api.apiKey)
+	assert.Equal(t, "app", api.appKey)
+}
+
+func TestNewDatadogAPIWithSecretRef(t *testing.T) {
+	fakeClient := k8sfake.NewSimpleClientset(
+		newSecret("default", "team-a", map[string]string{"url": "https://api.datadoghq.eu", "key": "api-a", "app": "app-a"}),
+		newSecret(Namespace(), "shared", map[string]string{"api-key": "api-shared", "app-key": "app-shared"}),
+		newSecret("other", "team-b", map[string]string{"api-key": "api-b", "app-key": "app-b"}),
+	)
+	secrets := secretutil.NewGetter(nil, fakeClient)
+	metric := newMetric("")
+
+	metric.Provider.Datadog.SecretRef = &v1alpha1.DatadogSecretRef{Name: "team-a", AddressKey: "url", APIKeyKey: "key", AppKeyKey: "app"}
+	api, err := NewDatadogAPI("default", metric, secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.datadoghq.eu", api.address)
+	assert.Equal(t, "api-a", api.apiKey)
+	assert.Equal(t, "app-a", api.appKey)
+
+	// secrets of other namespaces, including the namespace of the controller, can not be read
+	metric.Provider.Datadog.SecretRef = &v1alpha1.DatadogSecretRef{Name: "shared"}
+	_, err = NewDatadogAPI("default", metric, secrets)
+	assert.True(t, k8serrors.IsNotFound(err))
+	metric.Provider.Datadog.SecretRef = &v1alpha1.DatadogSecretRef{Name: "team-b"}
+	_, err = NewDatadogAPI("default", metric, secrets)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// the shared secret is read without a secretRef
+	metric.Provider.Datadog.SecretRef = nil
+	api, err = NewDatadogAPI("default", metric, secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAddress, api.address)
+	assert.Equal(t, "api-shared", api.apiKey)
+
+	metric.Provider.Datadog.SecretRef = &v1alpha1.DatadogSecretRef{Name: "team-a", APIKeyKey: "missing"}
+	_, err = NewDatadogAPI("default", metric, secrets)
+	assert.EqualError(t, err, "secret 'team-a' needs the 'missing' and 'app-key' keys")
+}
+
+func TestNewDatadogAPIFromEnvironment(t *testing.T) {
+	secrets := secretutil.NewGetter(nil, k8sfake.NewSimpleClientset())
+	_, err := NewDatadogAPI("default", newMetric(""), secrets)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	os.Setenv(APIKeyEnv, "api")
+	os.Setenv(AppKeyEnv, "app")
+	os.Setenv(AddressEnv, "https://us3.datadoghq.com")
+	defer func() {
+		os.Unsetenv(APIKeyEnv)
+		os.Unsetenv(AppKeyEnv)
+		os.Unsetenv(AddressEnv)
+	}()
+	api, err := NewDatadogAPI("default", newMetric(""), secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://us3.datadoghq.com", api.address)
+	assert.Equal(t, "api", api.apiKey)
+	assert.Equal(t, "app", api.appKey)
+
+	// a referenced secret is not replaced by the environment
+	metric := newMetric("")
+	metric.Provider.Datadog.SecretRef = &v1alpha1.DatadogSecretRef{Name: "missing"}
+	_, err = NewDatadogAPI("default", metric, secrets)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
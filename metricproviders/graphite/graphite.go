@@ -0,0 +1,182 @@
+package graphite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+)
+
+const (
+	//ProviderType indicates the provider is graphite
+	ProviderType = "Graphite"
+	// DefaultInterval is the time range of the query when the metric does not specify one
+	DefaultInterval = v1alpha1.DurationString("5m")
+
+	requestTimeout = 10 * time.Second
+)
+
+// GraphiteAPI renders targets of a graphite server
+type GraphiteAPI struct {
+	client  *http.Client
+	address string
+}
+
+type graphiteSeries struct {
+	Target string `json:"target"`
+	// Datapoints is a list of [<value>, <timestamp>] pairs. Values are null when there is no data.
+	Datapoints [][]*float64 `json:"datapoints"`
+}
+
+// Render renders the target over the interval ending now
+func (api *GraphiteAPI) Render(target string, interval time.Duration) ([]graphiteSeries, error) {
+	endpoint, err := url.Parse(api.address + "/render")
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("target", target)
+	params.Set("from", fmt.Sprintf("-%ds", int64(interval/time.Second)))
+	params.Set("format", "json")
+	endpoint.RawQuery = params.Encode()
+
+	response, err := api.client.Get(endpoint.String())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("received non 2xx response code: %v", response.StatusCode)
+	}
+	var res []graphiteSeries
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("could not parse JSON body: %v", err)
+	}
+	return res, nil
+}
+
+// Provider contains all the required components to run a Graphite query
+type Provider struct {
+	api    *GraphiteAPI
+	logCtx log.Entry
+}
+
+// Type indicates provider is a Graphite provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run renders the target of the metric over the interval of the metric ending now
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	interval, err := Interval(metric)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	response, err := p.api.Render(metric.Provider.Graphite.Query, interval)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newValue, newStatus, err := p.processResponse(metric, response)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// processResponse evaluates the last datapoint with a value of the first series. Graphite returns
+// null for the datapoints of the intervals which have not been aggregated yet, so they are skipped.
+func (p *Provider) processResponse(metric v1alpha1.Metric, response []graphiteSeries) (string, v1alpha1.AnalysisPhase, error) {
+	if len(response) == 0 {
+		return "", v1alpha1.AnalysisPhaseError, errors.New("no series found in the response from graphite")
+	}
+	datapoints := response[0].Datapoints
+	for i := len(datapoints) - 1; i >= 0; i-- {
+		if len(datapoints[i]) == 0 || datapoints[i][0] == nil {
+			continue
+		}
+		result := *datapoints[i][0]
+		newStatus := evaluate.EvaluateResult(result, metric, p.logCtx)
+		return strconv.FormatFloat(result, 'f', -1, 64), newStatus, nil
+	}
+	return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("no datapoint with a value found for target '%s'", response[0].Target)
+}
+
+// Resume should not be used the Graphite provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Graphite provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the Graphite provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Graphite provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the Graphite provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// Interval returns the time range of the query of the metric
+func Interval(metric v1alpha1.Metric) (time.Duration, error) {
+	interval := metric.Provider.Graphite.Interval
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	duration, err := interval.Duration()
+	if err != nil {
+		return 0, fmt.Errorf("invalid graphite interval: %v", err)
+	}
+	if duration < time.Second {
+		return 0, errors.New("graphite interval must be at least 1s")
+	}
+	return duration, nil
+}
+
+// NewGraphiteProvider creates a new Graphite provider
+func NewGraphiteProvider(api *GraphiteAPI, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewGraphiteAPI generates a Graphite API client from the metric configuration
+func NewGraphiteAPI(metric v1alpha1.Metric) (*GraphiteAPI, error) {
+	address, err := url.Parse(metric.Provider.Graphite.Address)
+	if err != nil {
+		return nil, err
+	}
+	if address.Scheme == "" || address.Host == "" {
+		return nil, fmt.Errorf("graphite address '%s' needs a scheme and a host", metric.Provider.Graphite.Address)
+	}
+	return &GraphiteAPI{
+		client:  &http.Client{Timeout: requestTimeout},
+		address: address.String(),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package graphite
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newMetric(address string, interval v1alpha1.DurationString) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "error-rate",
+		SuccessCondition: "result < 5",
+		Provider: v1alpha1.MetricProvider{
+			Graphite: &v1alpha1.GraphiteMetric{
+				Address:  address,
+				Query:    "sumSeries(app.{{args.service-name}}.errors)",
+				Interval: interval,
+			},
+		},
+	}
+}
+
+// newTestServer returns a server responding with the status and body to render requests and recording
+// the from parameter of the last request
+func newTestServer(t *testing.T, status int, body string, from *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/render", req.URL.Path)
+		assert.Equal(t, "json", req.URL.Query().Get("format"))
+		assert.Equal(t, "sumSeries(app.{{args.service-name}}.errors)", req.URL.Query().Get("target"))
+		*from = req.URL.Query().Get("from")
+		rw.WriteHeader(status)
+		io.WriteString(rw, body)
+	}))
+}
+
+func TestType(t *testing.T) {
+	p := NewGraphiteProvider(nil, log.Entry{})
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSuccessfully(t *testing.T) {
+	var from string
+	server := newTestServer(t, 200, `[{"target": "sumSeries(app.errors)", "datapoints": [[10, 1598867910], [2.5, 1598867970], [null, 1598868030]]}]`, &from)
+	defer server.Close()
+	metric := newMetric(server.URL, "")
+	api, err := NewGraphiteAPI(metric)
+	assert.NoError(t, err)
+	p := NewGraphiteProvider(api, *log.NewEntry(log.New()))
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "2.5", measurement.Value)
+	assert.Equal(t, "-300s", from)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	metric.Provider.Graphite.Interval = "1h"
+	p.Run(&v1alpha1.AnalysisRun{}, metric)
+	assert.Equal(t, "-3600s", from)
+}
+
+func TestRunWithErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		message string
+	}{
+		{500, ``, "received non 2xx response code: 500"},
+		{200, `{`, "could not parse JSON body: unexpected end of JSON input"},
+		{200, `[]`, "no series found in the response from graphite"},
+		{200, `[{"target": "app.errors", "datapoints": [[null, 1598867910], [null, 1598867970]]}]`, "no datapoint with a value found for target 'app.errors'"},
+	}
+	for _, test := range tests {
+		var from string
+		server := newTestServer(t, test.status, test.body, &from)
+		metric := newMetric(server.URL, "")
+		api, err := NewGraphiteAPI(metric)
+		assert.NoError(t, err)
+		measurement := NewGraphiteProvider(api, *log.NewEntry(log.New())).Run(&v1alpha1.AnalysisRun{}, metric)
+		server.Close()
+		assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+		assert.Equal(t, test.message, measurement.Message)
+	}
+}
+
+func TestNewGraphiteAPI(t *testing.T) {
+	_, err := NewGraphiteAPI(newMetric("graphite:8080", ""))
+	assert.EqualError(t, err, "graphite address 'graphite:8080' needs a scheme and a host")
+
+	api, err := NewGraphiteAPI(newMetric("http://graphite.monitoring:8080", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://graphite.monitoring:8080", api.address)
+}
+
+func TestInterval(t *testing.T) {
+	interval, err := Interval(newMetric("", "2m"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2m0s", interval.String())
+
+	_, err = Interval(newMetric("", "100ms"))
+	assert.EqualError(t, err, "graphite interval must be at least 1s")
+}
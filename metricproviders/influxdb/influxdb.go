@@ -0,0 +1,225 @@
+package influxdb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
+)
+
+const (
+	//ProviderType indicates the provider is influxdb
+	ProviderType = "Influxdb"
+	// DefaultProfile is the secret holding the address, org and token of the influxdb server when
+	// the metric does not reference a profile
+	DefaultProfile = "influxdb"
+
+	addressKey   = "address"
+	orgKey       = "org"
+	authTokenKey = "authToken"
+
+	// valueColumn is the column of the flux tables holding the values
+	valueColumn = "_value"
+
+	requestTimeout = 30 * time.Second
+)
+
+// InfluxdbAPI runs flux queries against an influxdb 2.x server
+type InfluxdbAPI struct {
+	client    *http.Client
+	address   string
+	org       string
+	authToken string
+}
+
+// Query runs the flux query and returns the values of the _value column of the result tables
+func (api *InfluxdbAPI) Query(query string) ([]string, error) {
+	endpoint, err := url.Parse(api.address + "/api/v2/query")
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("org", api.org)
+	endpoint.RawQuery = params.Encode()
+
+	request, err := http.NewRequest("POST", endpoint.String(), strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Token "+api.authToken)
+	request.Header.Set("Content-Type", "application/vnd.flux")
+	request.Header.Set("Accept", "application/csv")
+
+	response, err := api.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var res struct {
+			Message string `json:"message"`
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		if err := json.Unmarshal(body, &res); err == nil && res.Message != "" {
+			return nil, fmt.Errorf("query failed with response code %v: %s", response.StatusCode, res.Message)
+		}
+		return nil, fmt.Errorf("received non 2xx response code: %v", response.StatusCode)
+	}
+	return parseValues(response.Body)
+}
+
+// parseValues returns the values of the _value column of the tables of a flux CSV response. Every
+// table starts with a header row, and annotation rows start with #.
+func parseValues(body io.Reader) ([]string, error) {
+	reader := csv.NewReader(body)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	valueIndex := -1
+	var values []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse CSV body: %v", err)
+		}
+		if len(record) > 1 && record[1] == "result" {
+			valueIndex = -1
+			for i, column := range record {
+				if column == valueColumn {
+					valueIndex = i
+				}
+			}
+			continue
+		}
+		if valueIndex < 0 || valueIndex >= len(record) {
+			continue
+		}
+		values = append(values, record[valueIndex])
+	}
+	return values, nil
+}
+
+// Provider contains all the required components to run a flux query
+type Provider struct {
+	api    *InfluxdbAPI
+	logCtx log.Entry
+}
+
+// Type indicates provider is an influxdb provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run runs the flux query of the metric
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+
+	values, err := p.api.Query(metric.Provider.Influxdb.Query)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newValue, newStatus, err := p.processResponse(metric, values)
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	newMeasurement.Value = newValue
+	newMeasurement.Phase = newStatus
+	finishedTime := metav1.Now()
+	newMeasurement.FinishedAt = &finishedTime
+	return newMeasurement
+}
+
+// processResponse flattens the values of the result tables into the result of the measurement. A
+// single value is evaluated as is, and several values as a list. Numeric values are evaluated as
+// numbers.
+func (p *Provider) processResponse(metric v1alpha1.Metric, values []string) (string, v1alpha1.AnalysisPhase, error) {
+	if len(values) == 0 {
+		return "", v1alpha1.AnalysisPhaseError, errors.New("no values found in the response from influxdb")
+	}
+	results := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			results = append(results, number)
+		} else {
+			results = append(results, value)
+		}
+	}
+	if len(results) == 1 {
+		return values[0], evaluate.EvaluateResult(results[0], metric, p.logCtx), nil
+	}
+	return "[" + strings.Join(values, ",") + "]", evaluate.EvaluateResult(results, metric, p.logCtx), nil
+}
+
+// Resume should not be used the influxdb provider since all the work should occur in the Run method
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Influxdb provider should not execute the Resume method")
+	return measurement
+}
+
+// Terminate should not be used the influxdb provider since all the work should occur in the Run method
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	p.logCtx.Warn("Influxdb provider should not execute the Terminate method")
+	return measurement
+}
+
+// GarbageCollect is a no-op for the influxdb provider
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	return nil
+}
+
+// NewInfluxdbProvider creates a new influxdb provider
+func NewInfluxdbProvider(api *InfluxdbAPI, logCtx log.Entry) *Provider {
+	return &Provider{
+		logCtx: logCtx,
+		api:    api,
+	}
+}
+
+// NewInfluxdbAPI generates an influxdb API client from the profile secret of the metric
+func NewInfluxdbAPI(metric v1alpha1.Metric, secrets secretutil.Getter) (*InfluxdbAPI, error) {
+	profile := metric.Provider.Influxdb.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	secret, err := secrets.Get(Namespace(), profile)
+	if err != nil {
+		return nil, err
+	}
+	address := string(secret.Data[addressKey])
+	authToken := string(secret.Data[authTokenKey])
+	if address == "" || authToken == "" {
+		return nil, fmt.Errorf("secret '%s' needs the '%s' and '%s' keys", profile, addressKey, authTokenKey)
+	}
+	return &InfluxdbAPI{
+		client:    &http.Client{Timeout: requestTimeout},
+		address:   strings.TrimSuffix(address, "/"),
+		org:       string(secret.Data[orgKey]),
+		authToken: authToken,
+	}, nil
+}
+
+// Namespace returns the namespace the influxdb profile secrets are read from
+func Namespace() string {
+	return defaults.Namespace()
+}
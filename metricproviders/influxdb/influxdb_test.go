@@ -0,0 +1,151 @@
+package influxdb
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
+)
+
+const query = `from(bucket: "app")
+  |> range(start: -5m)
+  |> filter(fn: (r) => r._measurement == "errors")
+  |> mean()`
+
+func newMetric(successCondition string) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "error-rate",
+		SuccessCondition: successCondition,
+		Provider: v1alpha1.MetricProvider{
+			Influxdb: &v1alpha1.InfluxdbMetric{Query: query},
+		},
+	}
+}
+
+func newProfile(name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace()},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+// newTestProvider returns a provider running its queries against a server responding with the status
+// and body
+func newTestProvider(t *testing.T, status int, body string) (*Provider, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/api/v2/query", req.URL.Path)
+		assert.Equal(t, "my-org", req.URL.Query().Get("org"))
+		assert.Equal(t, "Token my-token", req.Header.Get("Authorization"))
+		assert.Equal(t, "application/vnd.flux", req.Header.Get("Content-Type"))
+		received, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, query, string(received))
+		rw.WriteHeader(status)
+		io.WriteString(rw, body)
+	}))
+	secret := newProfile(DefaultProfile, map[string]string{"address": server.URL + "/", "org": "my-org", "authToken": "my-token"})
+	api, err := NewInfluxdbAPI(newMetric(""), secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret)))
+	assert.NoError(t, err)
+	return NewInfluxdbProvider(api, *log.NewEntry(log.New())), server.Close
+}
+
+func TestType(t *testing.T) {
+	p := NewInfluxdbProvider(nil, log.Entry{})
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestRunSingleValue(t *testing.T) {
+	p, closeServer := newTestProvider(t, 200, ",result,table,_start,_stop,_value\r\n,_result,0,2020-08-31T09:00:00Z,2020-08-31T09:05:00Z,0.25\r\n\r\n")
+	defer closeServer()
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric("result < 1"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "0.25", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestRunMultipleTables(t *testing.T) {
+	body := strings.Join([]string{
+		"#datatype,string,long,string,double",
+		",result,table,pod,_value",
+		",_result,0,pod-a,0.5",
+		"",
+		",result,table,pod,_value",
+		",_result,1,pod-b,2",
+		"",
+	}, "\r\n")
+	p, closeServer := newTestProvider(t, 200, body)
+	defer closeServer()
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric("all(result, {# < 1})"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+	assert.Equal(t, "[0.5,2]", measurement.Value)
+
+	measurement = p.Run(&v1alpha1.AnalysisRun{}, newMetric("result[0] < 1"))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+}
+
+func TestRunStringValue(t *testing.T) {
+	p, closeServer := newTestProvider(t, 200, ",result,table,_value\r\n,_result,0,ok\r\n")
+	defer closeServer()
+
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric(`result == "ok"`))
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "ok", measurement.Value)
+}
+
+func TestRunWithErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		message string
+	}{
+		{400, `{"code": "invalid", "message": "compilation failed: error at @1:1-1:5: undefined identifier fron"}`, "query failed with response code 400: compilation failed: error at @1:1-1:5: undefined identifier fron"},
+		{502, `<html>bad gateway</html>`, "received non 2xx response code: 502"},
+		{200, "\r\n", "no values found in the response from influxdb"},
+	}
+	for _, test := range tests {
+		p, closeServer := newTestProvider(t, test.status, test.body)
+		measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric("result < 1"))
+		closeServer()
+		assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+		assert.Equal(t, test.message, measurement.Message)
+	}
+}
+
+func TestNewInfluxdbAPI(t *testing.T) {
+	secrets := secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(
+		newProfile("team-a", map[string]string{"address": "http://influxdb:8086", "authToken": "token-a"}),
+		newProfile("incomplete", map[string]string{"address": "http://influxdb:8086"}),
+	))
+	metric := newMetric("")
+	metric.Provider.Influxdb.Profile = "team-a"
+	api, err := NewInfluxdbAPI(metric, secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://influxdb:8086", api.address)
+	assert.Equal(t, "token-a", api.authToken)
+
+	metric.Provider.Influxdb.Profile = "incomplete"
+	_, err = NewInfluxdbAPI(metric, secrets)
+	assert.EqualError(t, err, "secret 'incomplete' needs the 'address' and 'authToken' keys")
+
+	metric.Provider.Influxdb.Profile = ""
+	_, err = NewInfluxdbAPI(metric, secrets)
+	assert.EqualError(t, err, `secrets "influxdb" not found`)
+}
@@ -200,6 +200,14 @@ func (p *JobProvider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.
 	if err != nil {
 		return err
 	}
+	// the jobs of the other metrics of the run are counted against their own limits
+	metricJobs := []*batchv1.Job{}
+	for _, job := range jobs {
+		if job.Annotations[AnalysisRunMetricAnnotationKey] == metric.Name {
+			metricJobs = append(metricJobs, job)
+		}
+	}
+	jobs = metricJobs
 	sort.Slice(jobs[:], func(i, j int) bool {
 		return jobs[i].CreationTimestamp.Before(&jobs[j].CreationTimestamp)
 	})
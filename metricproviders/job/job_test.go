@@ -299,3 +299,32 @@ func TestGarbageCollect(t *testing.T) {
 		}
 	}
 }
+
+func TestGarbageCollectOnlyJobsOfMetric(t *testing.T) {
+	run := newRunWithJobMetric()
+	now := time.Now()
+	var objs []runtime.Object
+	for i := 0; i < 4; i++ {
+		job := newJob(run, batchv1.JobComplete)
+		job.Name = fmt.Sprintf("%s-%d", job.Name, i)
+		job.CreationTimestamp = metav1.NewTime(now.Add(time.Second * time.Duration(i)))
+		if i < 2 {
+			job.Annotations[AnalysisRunMetricAnnotationKey] = "othermetric"
+		}
+		objs = append(objs, job)
+	}
+	p := newTestJobProvider(objs...)
+	err := p.GarbageCollect(run, run.Spec.Metrics[0], 1)
+	assert.NoError(t, err)
+	basename := newJob(run, "").Name
+
+	for i := 0; i < 4; i++ {
+		_, err := p.kubeclientset.BatchV1().Jobs(run.Namespace).Get(fmt.Sprintf("%s-%d", basename, i), metav1.GetOptions{})
+		if i == 2 {
+			// ensure we only deleted the oldest job of the metric
+			assert.True(t, k8serrors.IsNotFound(err))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
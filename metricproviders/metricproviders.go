@@ -2,6 +2,7 @@ package metricproviders
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/argoproj/argo-rollouts/metricproviders/wavefront"
 
@@ -12,10 +13,17 @@ import (
 	"k8s.io/client-go/kubernetes"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 
+	"github.com/argoproj/argo-rollouts/metricproviders/cloudevent"
+	"github.com/argoproj/argo-rollouts/metricproviders/datadog"
+	"github.com/argoproj/argo-rollouts/metricproviders/graphite"
+	"github.com/argoproj/argo-rollouts/metricproviders/influxdb"
 	"github.com/argoproj/argo-rollouts/metricproviders/job"
+	"github.com/argoproj/argo-rollouts/metricproviders/plugin"
 	"github.com/argoproj/argo-rollouts/metricproviders/prometheus"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 // Provider methods to query a external systems and generate a measurement
@@ -36,14 +44,58 @@ type Provider interface {
 type ProviderFactory struct {
 	KubeClient kubernetes.Interface
 	JobLister  batchlisters.JobLister
+	// SecretGetter is used by providers to read their credentials
+	SecretGetter secretutil.Getter
 }
 
-type ProviderFactoryFunc func(logCtx log.Entry, metric v1alpha1.Metric) (Provider, error)
+type ProviderFactoryFunc func(logCtx log.Entry, namespace string, metric v1alpha1.Metric) (Provider, error)
 
-// NewProvider creates the correct provider based on the provider type of the Metric
-func (f *ProviderFactory) NewProvider(logCtx log.Entry, metric v1alpha1.Metric) (Provider, error) {
+// Type returns the type of the provider configured in the metric or an empty string
+func Type(metric v1alpha1.Metric) string {
 	if metric.Provider.Prometheus != nil {
-		api, err := prometheus.NewPrometheusAPI(metric)
+		return prometheus.ProviderType
+	} else if metric.Provider.Job != nil {
+		return job.ProviderType
+	} else if metric.Provider.Kayenta != nil {
+		return kayenta.ProviderType
+	} else if metric.Provider.Web != nil {
+		return webmetric.ProviderType
+	} else if metric.Provider.Wavefront != nil {
+		return wavefront.ProviderType
+	} else if metric.Provider.CloudEvent != nil {
+		return cloudevent.ProviderType
+	} else if metric.Provider.Datadog != nil {
+		return datadog.ProviderType
+	} else if metric.Provider.Graphite != nil {
+		return graphite.ProviderType
+	} else if metric.Provider.Influxdb != nil {
+		return influxdb.ProviderType
+	} else if metric.Provider.Plugin != nil {
+		return plugin.ProviderType
+	}
+	return ""
+}
+
+// IsDisabled returns true if the operator disabled the provider type. Provider types are
+// matched case insensitively.
+func IsDisabled(providerType string) bool {
+	for _, disabled := range configutil.Get().GetStringSlice(configutil.DisabledMetricProvidersKey, nil) {
+		if strings.EqualFold(disabled, providerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewProvider creates the correct provider based on the provider type of the Metric. The namespace
+// is the namespace of the AnalysisRun, which the secrets referenced by the metric are read from.
+func (f *ProviderFactory) NewProvider(logCtx log.Entry, namespace string, metric v1alpha1.Metric) (Provider, error) {
+	// checked before the provider is created so a disabled provider never reads its secrets
+	if providerType := Type(metric); providerType != "" && IsDisabled(providerType) {
+		return nil, fmt.Errorf("metric provider '%s' used by metric '%s' is disabled", providerType, metric.Name)
+	}
+	if metric.Provider.Prometheus != nil {
+		api, err := prometheus.NewPrometheusAPI(metric, namespace, f.SecretGetter)
 		if err != nil {
 			return nil, err
 		}
@@ -59,13 +111,38 @@ func (f *ProviderFactory) NewProvider(logCtx log.Entry, metric v1alpha1.Metric)
 		if err != nil {
 			return nil, err
 		}
-		return webmetric.NewWebMetricProvider(logCtx, c, p), nil
+		return webmetric.NewWebMetricProvider(logCtx, c, p, f.SecretGetter), nil
 	} else if metric.Provider.Wavefront != nil {
-		client, err := wavefront.NewWavefrontAPI(metric, f.KubeClient)
+		client, err := wavefront.NewWavefrontAPI(metric, f.SecretGetter)
 		if err != nil {
 			return nil, err
 		}
 		return wavefront.NewWavefrontProvider(client, logCtx), nil
+	} else if metric.Provider.CloudEvent != nil {
+		if err := cloudevent.ValidateJSONPaths(metric); err != nil {
+			return nil, err
+		}
+		return cloudevent.NewCloudEventProvider(logCtx), nil
+	} else if metric.Provider.Datadog != nil {
+		return datadog.NewDatadogProvider(f.SecretGetter, logCtx), nil
+	} else if metric.Provider.Graphite != nil {
+		api, err := graphite.NewGraphiteAPI(metric)
+		if err != nil {
+			return nil, err
+		}
+		return graphite.NewGraphiteProvider(api, logCtx), nil
+	} else if metric.Provider.Influxdb != nil {
+		api, err := influxdb.NewInfluxdbAPI(metric, f.SecretGetter)
+		if err != nil {
+			return nil, err
+		}
+		return influxdb.NewInfluxdbProvider(api, logCtx), nil
+	} else if metric.Provider.Plugin != nil {
+		p, err := plugin.NewPluginProvider(metric, logCtx)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	}
 	return nil, fmt.Errorf("no valid provider in metric '%s'", metric.Name)
 }
@@ -0,0 +1,163 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+const (
+	//ProviderType indicates the provider is a metric provider plugin
+	ProviderType = "Plugin"
+
+	// MethodRun, MethodResume, MethodTerminate and MethodGarbageCollect are the methods of the
+	// provider called on a plugin, at <address>/v1/<method>
+	MethodRun            = "run"
+	MethodResume         = "resume"
+	MethodTerminate      = "terminate"
+	MethodGarbageCollect = "garbageCollect"
+)
+
+// Request is the body of a call to a plugin
+type Request struct {
+	AnalysisRun *v1alpha1.AnalysisRun `json:"analysisRun"`
+	Metric      v1alpha1.Metric       `json:"metric"`
+	// Measurement is the measurement to resume or terminate
+	Measurement *v1alpha1.Measurement `json:"measurement,omitempty"`
+	// Limit is the number of measurements to keep when garbage collecting
+	Limit int `json:"limit,omitempty"`
+}
+
+// Response is the body of the response of a plugin
+type Response struct {
+	// Measurement is the measurement taken, resumed or terminated by the plugin
+	Measurement *v1alpha1.Measurement `json:"measurement,omitempty"`
+	// Error fails the call. Failed measurements are marked as errors
+	Error string `json:"error,omitempty"`
+}
+
+// Plugins returns the addresses of the plugins declared in the controller configuration by name
+func Plugins() (map[string]string, error) {
+	return pluginutil.Addresses(configutil.MetricProviderPluginsKey)
+}
+
+// Provider dispatches the calls of the analysis controller to a metric provider plugin. Plugins run
+// out of process, typically as a sidecar of the controller, and are called over HTTP or a unix socket.
+type Provider struct {
+	name    string
+	address string
+	client  *http.Client
+	logCtx  log.Entry
+}
+
+// Type indicates provider is a plugin provider
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Run asks the plugin to start a measurement
+func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alpha1.Measurement {
+	startTime := metav1.Now()
+	newMeasurement := v1alpha1.Measurement{
+		StartedAt: &startTime,
+	}
+	measurement, err := p.call(MethodRun, Request{AnalysisRun: run, Metric: metric})
+	if err != nil {
+		return metricutil.MarkMeasurementError(newMeasurement, err)
+	}
+	if measurement.StartedAt == nil {
+		measurement.StartedAt = &startTime
+	}
+	return *measurement
+}
+
+// Resume asks the plugin whether an in-flight measurement completed
+func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	resumed, err := p.call(MethodResume, Request{AnalysisRun: run, Metric: metric, Measurement: &measurement})
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	return *resumed
+}
+
+// Terminate asks the plugin to stop an in-flight measurement
+func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
+	terminated, err := p.call(MethodTerminate, Request{AnalysisRun: run, Metric: metric, Measurement: &measurement})
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	return *terminated
+}
+
+// GarbageCollect asks the plugin to clean up the resources of the measurements beyond the limit
+func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, limit int) error {
+	_, err := p.call(MethodGarbageCollect, Request{AnalysisRun: run, Metric: metric, Limit: limit})
+	return err
+}
+
+// call posts the request to the method of the plugin and returns the measurement of the response
+func (p *Provider) call(method string, request Request) (*v1alpha1.Measurement, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.client.Post(p.address+"/v1/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' could not be reached: %v", p.name, err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var res Response
+	if err := json.Unmarshal(data, &res); err != nil {
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return nil, fmt.Errorf("plugin '%s' %s failed with status code %d", p.name, method, response.StatusCode)
+		}
+		return nil, fmt.Errorf("could not parse the response of plugin '%s': %v", p.name, err)
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("plugin '%s' %s failed: %s", p.name, method, res.Error)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("plugin '%s' %s failed with status code %d", p.name, method, response.StatusCode)
+	}
+	if method != MethodGarbageCollect && res.Measurement == nil {
+		return nil, fmt.Errorf("plugin '%s' returned no measurement", p.name)
+	}
+	return res.Measurement, nil
+}
+
+// NewPluginProvider creates a provider calling the plugin of the metric
+func NewPluginProvider(metric v1alpha1.Metric, logCtx log.Entry) (*Provider, error) {
+	name := metric.Provider.Plugin.Name
+	plugins, err := Plugins()
+	if err != nil {
+		return nil, err
+	}
+	address, ok := plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("metric provider plugin '%s' is not declared in %s", name, configutil.MetricProviderPluginsKey)
+	}
+	client, address, err := pluginutil.NewClient(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{
+		name:    name,
+		address: address,
+		client:  client,
+		logCtx:  *logCtx.WithField("plugin", name),
+	}, nil
+}
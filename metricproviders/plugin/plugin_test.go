@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+func newMetric() v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name: "score",
+		Provider: v1alpha1.MetricProvider{
+			Plugin: &v1alpha1.PluginMetric{Name: "acme/score", Config: `{"service": "checkout"}`},
+		},
+	}
+}
+
+// newPluginHandler returns a handler of the plugin protocol answering every method with the function
+func newPluginHandler(t *testing.T, handle func(method string, request Request) Response) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		var request Request
+		assert.NoError(t, json.Unmarshal(body, &request))
+		response := handle(filepath.Base(req.URL.Path), request)
+		if response.Error != "" {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		assert.NoError(t, json.NewEncoder(rw).Encode(response))
+	})
+}
+
+func newProvider(t *testing.T, address string) *Provider {
+	configutil.SetDefaults(map[string]string{configutil.MetricProviderPluginsKey: "acme/score=" + address})
+	defer configutil.SetDefaults(nil)
+	p, err := NewPluginProvider(newMetric(), *log.NewEntry(log.New()))
+	assert.NoError(t, err)
+	return p
+}
+
+func TestType(t *testing.T) {
+	p := newProvider(t, "http://localhost:8090")
+	assert.Equal(t, ProviderType, p.Type())
+}
+
+func TestDispatch(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(newPluginHandler(t, func(method string, request Request) Response {
+		methods = append(methods, method)
+		assert.Equal(t, "my-run", request.AnalysisRun.Name)
+		assert.Equal(t, `{"service": "checkout"}`, request.Metric.Provider.Plugin.Config)
+		switch method {
+		case MethodRun:
+			resumeAt := metav1.Now()
+			return Response{Measurement: &v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning, ResumeAt: &resumeAt}}
+		case MethodResume:
+			measurement := request.Measurement
+			measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+			measurement.Value = "0.98"
+			return Response{Measurement: measurement}
+		case MethodTerminate:
+			measurement := request.Measurement
+			measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+			return Response{Measurement: measurement}
+		case MethodGarbageCollect:
+			assert.Equal(t, 10, request.Limit)
+			return Response{}
+		}
+		return Response{Error: "unknown method " + method}
+	}))
+	defer server.Close()
+	p := newProvider(t, server.URL+"/")
+	run := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{Name: "my-run"}}
+
+	measurement := p.Run(run, newMetric())
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.NotNil(t, measurement.StartedAt)
+	assert.NotNil(t, measurement.ResumeAt)
+
+	resumed := p.Resume(run, newMetric(), measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, resumed.Phase)
+	assert.Equal(t, "0.98", resumed.Value)
+
+	terminated := p.Terminate(run, newMetric(), measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, terminated.Phase)
+
+	assert.NoError(t, p.GarbageCollect(run, newMetric(), 10))
+	assert.Equal(t, []string{MethodRun, MethodResume, MethodTerminate, MethodGarbageCollect}, methods)
+}
+
+func TestPluginErrors(t *testing.T) {
+	server := httptest.NewServer(newPluginHandler(t, func(method string, request Request) Response {
+		if method == MethodRun {
+			return Response{}
+		}
+		return Response{Error: "backend unavailable"}
+	}))
+	defer server.Close()
+	p := newProvider(t, server.URL)
+	run := &v1alpha1.AnalysisRun{}
+
+	measurement := p.Run(run, newMetric())
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "plugin 'acme/score' returned no measurement", measurement.Message)
+	assert.NotNil(t, measurement.StartedAt)
+
+	measurement = p.Resume(run, newMetric(), v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseRunning})
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "plugin 'acme/score' resume failed: backend unavailable", measurement.Message)
+
+	err := p.GarbageCollect(run, newMetric(), 10)
+	assert.EqualError(t, err, "plugin 'acme/score' garbageCollect failed: backend unavailable")
+
+	server.Close()
+	measurement = p.Run(run, newMetric())
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Contains(t, measurement.Message, "plugin 'acme/score' could not be reached")
+}
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	server := httptest.NewUnstartedServer(newPluginHandler(t, func(method string, request Request) Response {
+		return Response{Measurement: &v1alpha1.Measurement{Phase: v1alpha1.AnalysisPhaseSuccessful}}
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	p := newProvider(t, "unix://"+socket)
+	measurement := p.Run(&v1alpha1.AnalysisRun{}, newMetric())
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+}
+
+func TestNewPluginProvider(t *testing.T) {
+	logCtx := *log.NewEntry(log.New())
+	_, err := NewPluginProvider(newMetric(), logCtx)
+	assert.EqualError(t, err, "metric provider plugin 'acme/score' is not declared in metricProviders.plugins")
+
+	tests := []struct {
+		plugins string
+		err     string
+	}{
+		{"acme/score", "invalid plugin 'acme/score' in metricProviders.plugins: expected <name>=<address>"},
+		{"acme/score=localhost:8090", "plugin address 'localhost:8090' must start with http://, https:// or unix://"},
+		{"acme/score=unix://", "plugin unix socket path is empty"},
+	}
+	for _, test := range tests {
+		configutil.SetDefaults(map[string]string{configutil.MetricProviderPluginsKey: test.plugins})
+		_, err := NewPluginProvider(newMetric(), logCtx)
+		assert.EqualError(t, err, test.err)
+	}
+	configutil.SetDefaults(nil)
+
+	configutil.SetDefaults(map[string]string{configutil.MetricProviderPluginsKey: "acme/other=http://localhost:8091, acme/score = https://score.example.com/"})
+	defer configutil.SetDefaults(nil)
+	p, err := NewPluginProvider(newMetric(), logCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://score.example.com", p.address)
+}
@@ -22,6 +22,14 @@ func (m mockAPI) Query(ctx context.Context, query string, ts time.Time) (model.V
 	return m.value, m.warnings, nil
 }
 
+// QueryRange performs a query for the given range.
+func (m mockAPI) QueryRange(ctx context.Context, query string, r v1.Range) (model.Value, v1.Warnings, error) {
+	if m.err != nil {
+		return nil, m.warnings, m.err
+	}
+	return m.value, m.warnings, nil
+}
+
 // Below methods are not used but required for the interface implementation
 
 func (m mockAPI) Metadata(ctx context.Context, metric string, limit string) (map[string][]v1.Metadata, error) {
@@ -44,10 +52,6 @@ func (m mockAPI) LabelValues(ctx context.Context, label string) (model.LabelValu
 	panic("Not used")
 }
 
-func (m mockAPI) QueryRange(ctx context.Context, query string, r v1.Range) (model.Value, v1.Warnings, error) {
-	panic("Not used")
-}
-
 func (m mockAPI) Series(ctx context.Context, matches []string, startTime time.Time, endTime time.Time) ([]model.LabelSet, v1.Warnings, error) {
 	panic("Not used")
 }
@@ -2,8 +2,12 @@ package prometheus
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -15,11 +19,16 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/utils/evaluate"
 	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 const (
 	//ProviderType indicates the provider is prometheus
 	ProviderType = "Prometheus"
+	// DefaultRangeStep is the resolution of range queries which do not specify one
+	DefaultRangeStep = v1alpha1.DurationString("1m")
+	// DefaultRangeAggregator reduces the samples of the series of range queries which do not specify an aggregator
+	DefaultRangeAggregator = "avg"
 )
 
 // Provider contains all the required components to run a prometheus query
@@ -44,7 +53,18 @@ func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alph
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	response, warnings, err := p.api.Query(ctx, metric.Provider.Prometheus.Query, time.Now())
+	var response model.Value
+	var warnings v1.Warnings
+	var err error
+	if metric.Provider.Prometheus.Range != nil {
+		var queryRange v1.Range
+		if queryRange, err = Range(metric, time.Now()); err != nil {
+			return metricutil.MarkMeasurementError(newMeasurement, err)
+		}
+		response, warnings, err = p.api.QueryRange(ctx, metric.Provider.Prometheus.Query, queryRange)
+	} else {
+		response, warnings, err = p.api.Query(ctx, metric.Provider.Prometheus.Query, time.Now())
+	}
 	if err != nil {
 		return metricutil.MarkMeasurementError(newMeasurement, err)
 	}
@@ -121,6 +141,35 @@ func (p *Provider) processResponse(metric v1alpha1.Metric, response model.Value)
 		}
 		newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
 		return valueStr, newStatus, nil
+	case model.Matrix:
+		aggregator := DefaultRangeAggregator
+		if metric.Provider.Prometheus.Range != nil && metric.Provider.Prometheus.Range.Aggregator != "" {
+			aggregator = metric.Provider.Prometheus.Range.Aggregator
+		}
+		results := make([]float64, 0, len(value))
+		valueStr := "["
+		for _, s := range value {
+			if s == nil || len(s.Values) == 0 {
+				continue
+			}
+			result, err := Aggregate(aggregator, s.Values)
+			if err != nil {
+				return "", v1alpha1.AnalysisPhaseError, err
+			}
+			valueStr = valueStr + model.SampleValue(result).String() + ","
+			results = append(results, result)
+		}
+		if len(valueStr) > 1 {
+			valueStr = valueStr[:len(valueStr)-1]
+		}
+		valueStr = valueStr + "]"
+		for _, result := range results {
+			if math.IsNaN(result) {
+				return valueStr, v1alpha1.AnalysisPhaseInconclusive, nil
+			}
+		}
+		newStatus := evaluate.EvaluateResult(results, metric, p.logCtx)
+		return valueStr, newStatus, nil
 	//TODO(dthomson) add other response types
 	default:
 		return "", v1alpha1.AnalysisPhaseError, fmt.Errorf("Prometheus metric type not supported")
@@ -135,10 +184,70 @@ func NewPrometheusProvider(api v1.API, logCtx log.Entry) *Provider {
 	}
 }
 
-// NewPrometheusAPI generates a prometheus API from the metric configuration
-func NewPrometheusAPI(metric v1alpha1.Metric) (v1.API, error) {
+// Range returns the time range of the range query of the metric ending at the time of the measurement
+func Range(metric v1alpha1.Metric, now time.Time) (v1.Range, error) {
+	queryRange := metric.Provider.Prometheus.Range
+	duration, err := queryRange.Duration.Duration()
+	if err != nil {
+		return v1.Range{}, fmt.Errorf("invalid prometheus range duration: %v", err)
+	}
+	stepString := queryRange.Step
+	if stepString == "" {
+		stepString = DefaultRangeStep
+	}
+	step, err := stepString.Duration()
+	if err != nil {
+		return v1.Range{}, fmt.Errorf("invalid prometheus range step: %v", err)
+	}
+	if duration <= 0 || step <= 0 {
+		return v1.Range{}, errors.New("prometheus range duration and step must be greater than 0")
+	}
+	return v1.Range{Start: now.Add(-duration), End: now, Step: step}, nil
+}
+
+// Aggregate reduces the samples of a series with the aggregator
+func Aggregate(aggregator string, samples []model.SamplePair) (float64, error) {
+	if len(samples) == 0 {
+		return math.NaN(), nil
+	}
+	result := float64(samples[0].Value)
+	switch aggregator {
+	case "avg", "sum":
+		sum := 0.0
+		for _, sample := range samples {
+			sum += float64(sample.Value)
+		}
+		if aggregator == "sum" {
+			return sum, nil
+		}
+		return sum / float64(len(samples)), nil
+	case "min":
+		for _, sample := range samples {
+			result = math.Min(result, float64(sample.Value))
+		}
+	case "max":
+		for _, sample := range samples {
+			result = math.Max(result, float64(sample.Value))
+		}
+	case "last":
+		result = float64(samples[len(samples)-1].Value)
+	default:
+		return 0, fmt.Errorf("unknown prometheus range aggregator '%s'", aggregator)
+	}
+	return result, nil
+}
+
+// NewPrometheusAPI generates a prometheus API from the metric configuration. The credentials and CA
+// certificates of the metric are read from secrets in the namespace of the AnalysisRun, since the
+// address of the server is chosen by the author of the template.
+func NewPrometheusAPI(metric v1alpha1.Metric, namespace string, secrets secretutil.Getter) (v1.API, error) {
+	roundTripper, err := newRoundTripper(metric.Provider.Prometheus, namespace, secrets)
+	if err != nil {
+		return nil, err
+	}
 	client, err := api.NewClient(api.Config{
-		Address: metric.Provider.Prometheus.Address,
+		Address:      metric.Provider.Prometheus.Address,
+		RoundTripper: roundTripper,
 	})
 	if err != nil {
 		return nil, err
@@ -146,3 +255,89 @@ func NewPrometheusAPI(metric v1alpha1.Metric) (v1.API, error) {
 
 	return v1.NewAPI(client), nil
 }
+
+// authRoundTripper sets the Authorization header of the requests to the prometheus server
+type authRoundTripper struct {
+	authorization func(*http.Request)
+	next          http.RoundTripper
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	rt.authorization(req)
+	return rt.next.RoundTrip(req)
+}
+
+// newRoundTripper returns the transport of the requests to the prometheus server, or nil to use the
+// default transport of the client when the metric configures neither authentication nor TLS
+func newRoundTripper(metric *v1alpha1.PrometheusMetric, namespace string, secrets secretutil.Getter) (http.RoundTripper, error) {
+	if metric.Authentication == nil && metric.TLS == nil {
+		return nil, nil
+	}
+	var roundTripper http.RoundTripper = api.DefaultRoundTripper
+	if metric.TLS != nil {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: metric.TLS.InsecureSkipVerify,
+			ServerName:         metric.TLS.ServerName,
+		}
+		if metric.TLS.CASecretKeyRef != nil {
+			ca, err := secretValue(secrets, namespace, metric.TLS.CASecretKeyRef)
+			if err != nil {
+				return nil, fmt.Errorf("could not read the CA certificates: %v", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(ca)) {
+				return nil, fmt.Errorf("no PEM encoded certificate found in key '%s' of secret '%s'", metric.TLS.CASecretKeyRef.Key, metric.TLS.CASecretKeyRef.Name)
+			}
+		}
+		roundTripper = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+	if metric.Authentication == nil {
+		return roundTripper, nil
+	}
+	if bearer := metric.Authentication.BearerTokenSecretKeyRef; bearer != nil {
+		token, err := secretValue(secrets, namespace, bearer)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the bearer token: %v", err)
+		}
+		return &authRoundTripper{
+			authorization: func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+			next:          roundTripper,
+		}, nil
+	}
+	if basicAuth := metric.Authentication.BasicAuth; basicAuth != nil {
+		password, err := secretValue(secrets, namespace, basicAuth.PasswordSecretKeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the basic auth password: %v", err)
+		}
+		return &authRoundTripper{
+			authorization: func(req *http.Request) { req.SetBasicAuth(basicAuth.Username, password) },
+			next:          roundTripper,
+		}, nil
+	}
+	return roundTripper, nil
+}
+
+// secretValue returns the value of the key of a secret in the namespace
+func secretValue(secrets secretutil.Getter, namespace string, ref *v1alpha1.SecretKeyRef) (string, error) {
+	if ref == nil {
+		return "", errors.New("secretKeyRef must be specified")
+	}
+	if secrets == nil {
+		return "", errors.New("secrets are not available to the prometheus provider")
+	}
+	secret, err := secrets.Get(namespace, ref.Name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' does not exist in secret '%s'", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
@@ -1,18 +1,28 @@
 package prometheus
 
 import (
+	"context"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 
 	"github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 func newScalar(f float64) model.Value {
@@ -317,10 +327,161 @@ func TestNewPrometheusAPI(t *testing.T) {
 			},
 		},
 	}
-	_, err := NewPrometheusAPI(metric)
+	_, err := NewPrometheusAPI(metric, metav1.NamespaceDefault, nil)
 	assert.NotNil(t, err)
 
 	metric.Provider.Prometheus.Address = "https://www.example.com"
-	_, err = NewPrometheusAPI(metric)
+	_, err = NewPrometheusAPI(metric, metav1.NamespaceDefault, nil)
 	assert.Nil(t, err)
 }
+
+func TestRunRangeQuery(t *testing.T) {
+	e := log.Entry{}
+	mock := mockAPI{
+		value: model.Matrix{
+			{Values: []model.SamplePair{{Value: 1}, {Value: 2}, {Value: 6}}},
+			{Values: []model.SamplePair{{Value: 4}, {Value: 5}}},
+			{Values: []model.SamplePair{}},
+		},
+	}
+	p := NewPrometheusProvider(mock, e)
+	metric := v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: "all(result, {# < 5})",
+		Provider: v1alpha1.MetricProvider{
+			Prometheus: &v1alpha1.PrometheusMetric{
+				Query: "test",
+				Range: &v1alpha1.PrometheusRange{Duration: "10m"},
+			},
+		},
+	}
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, "[3,4.5]", measurement.Value)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+
+	metric.Provider.Prometheus.Range.Aggregator = "max"
+	measurement = p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, "[6,5]", measurement.Value)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, measurement.Phase)
+
+	metric.Provider.Prometheus.Range.Aggregator = "median"
+	measurement = p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "unknown prometheus range aggregator 'median'", measurement.Message)
+
+	metric.Provider.Prometheus.Range = &v1alpha1.PrometheusRange{Duration: "10m", Step: "0s"}
+	measurement = p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, "prometheus range duration and step must be greater than 0", measurement.Message)
+}
+
+func TestRange(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	metric := v1alpha1.Metric{
+		Provider: v1alpha1.MetricProvider{
+			Prometheus: &v1alpha1.PrometheusMetric{
+				Range: &v1alpha1.PrometheusRange{Duration: "10m"},
+			},
+		},
+	}
+	queryRange, err := Range(metric, now)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.Range{Start: now.Add(-10 * time.Minute), End: now, Step: time.Minute}, queryRange)
+
+	metric.Provider.Prometheus.Range.Step = "30s"
+	queryRange, err = Range(metric, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, queryRange.Step)
+}
+
+func TestAggregate(t *testing.T) {
+	samples := []model.SamplePair{{Value: 3}, {Value: 1}, {Value: 2}}
+	for aggregator, expected := range map[string]float64{"avg": 2, "sum": 6, "min": 1, "max": 3, "last": 2} {
+		result, err := Aggregate(aggregator, samples)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result, aggregator)
+	}
+	result, err := Aggregate("avg", nil)
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(result))
+}
+
+func TestNewPrometheusAPIWithAuthenticationAndTLS(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authorization = req.Header.Get("Authorization")
+		rw.Header().Set("Content-Type", "application/json")
+		io.WriteString(rw, `{"status": "success", "data": {"resultType": "scalar", "result": [1600000000, "10"]}}`)
+	}))
+	defer server.Close()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "thanos", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{
+			"token":    []byte("my-token"),
+			"password": []byte("my-password"),
+			"ca.crt":   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		},
+	}
+	// a secret of the controller namespace cannot be sent to the server
+	controllerSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "datadog-api-keys", Namespace: defaults.Namespace()},
+		Data:       map[string][]byte{"api-key": []byte("controller-key")},
+	}
+	secrets := secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret, controllerSecret))
+	query := func(prometheus v1alpha1.PrometheusMetric) error {
+		prometheus.Address = server.URL
+		api, err := NewPrometheusAPI(v1alpha1.Metric{Provider: v1alpha1.MetricProvider{Prometheus: &prometheus}}, metav1.NamespaceDefault, secrets)
+		if err != nil {
+			return err
+		}
+		_, _, err = api.Query(context.Background(), "up", time.Now())
+		return err
+	}
+	caTLS := &v1alpha1.PrometheusTLSConfig{CASecretKeyRef: &v1alpha1.SecretKeyRef{Name: "thanos", Key: "ca.crt"}}
+
+	assert.Error(t, query(v1alpha1.PrometheusMetric{}))
+
+	assert.NoError(t, query(v1alpha1.PrometheusMetric{
+		TLS: &v1alpha1.PrometheusTLSConfig{InsecureSkipVerify: true},
+	}))
+	assert.Equal(t, "", authorization)
+
+	assert.NoError(t, query(v1alpha1.PrometheusMetric{
+		TLS: caTLS,
+		Authentication: &v1alpha1.PrometheusAuthentication{
+			BearerTokenSecretKeyRef: &v1alpha1.SecretKeyRef{Name: "thanos", Key: "token"},
+		},
+	}))
+	assert.Equal(t, "Bearer my-token", authorization)
+
+	assert.NoError(t, query(v1alpha1.PrometheusMetric{
+		TLS: caTLS,
+		Authentication: &v1alpha1.PrometheusAuthentication{
+			BasicAuth: &v1alpha1.PrometheusBasicAuth{
+				Username:             "argo-rollouts",
+				PasswordSecretKeyRef: &v1alpha1.SecretKeyRef{Name: "thanos", Key: "password"},
+			},
+		},
+	}))
+	assert.Equal(t, "Basic YXJnby1yb2xsb3V0czpteS1wYXNzd29yZA==", authorization)
+
+	err := query(v1alpha1.PrometheusMetric{
+		TLS: &v1alpha1.PrometheusTLSConfig{CASecretKeyRef: &v1alpha1.SecretKeyRef{Name: "thanos", Key: "token"}},
+	})
+	assert.EqualError(t, err, "no PEM encoded certificate found in key 'token' of secret 'thanos'")
+
+	err = query(v1alpha1.PrometheusMetric{
+		Authentication: &v1alpha1.PrometheusAuthentication{
+			BearerTokenSecretKeyRef: &v1alpha1.SecretKeyRef{Name: "missing", Key: "token"},
+		},
+	})
+	assert.EqualError(t, err, `could not read the bearer token: secrets "missing" not found`)
+
+	// the secrets are read from the namespace of the AnalysisRun, not the controller namespace
+	err = query(v1alpha1.PrometheusMetric{
+		Authentication: &v1alpha1.PrometheusAuthentication{
+			BearerTokenSecretKeyRef: &v1alpha1.SecretKeyRef{Name: "datadog-api-keys", Key: "api-key"},
+		},
+	})
+	assert.EqualError(t, err, `could not read the bearer token: secrets "datadog-api-keys" not found`)
+}
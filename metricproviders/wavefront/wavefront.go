@@ -3,21 +3,19 @@ package wavefront
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"math"
-	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	wavefront_api "github.com/spaceapegames/go-wavefront"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	"github.com/argoproj/argo-rollouts/utils/evaluate"
 	metricutil "github.com/argoproj/argo-rollouts/utils/metric"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 const (
@@ -25,6 +23,10 @@ const (
 	ProviderType = "Wavefront"
 	//k8s secret that has wavefront api tokens
 	WavefrontTokensSecretName = "wavefront-api-tokens"
+	// NoDataRetriesKey is the key of the measurement metadata counting the retries of a query returning no data
+	NoDataRetriesKey = "noDataRetries"
+	// DefaultNoDataRetryDelay is the time to wait before retrying a query returning no data
+	DefaultNoDataRetryDelay = v1alpha1.DurationString("30s")
 )
 
 type Provider struct {
@@ -66,40 +68,75 @@ func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alph
 	newMeasurement := v1alpha1.Measurement{
 		StartedAt: &startTime,
 	}
+	return p.measure(metric, newMeasurement, 0)
+}
 
-	queryParams := &wavefront_api.QueryParams{
-		QueryString:             metric.Provider.Wavefront.Query,
-		StartTime:               strconv.FormatInt(time.Now().Unix()*1000, 10),
-		MaxPoints:               "1",
-		Granularity:             "s",
-		SeriesOutsideTimeWindow: false,
+// measure runs the query of the metric. A query returning no data is retried after the retry delay
+// of the metric until its retries are exhausted, then the measurement is inconclusive.
+func (p *Provider) measure(metric v1alpha1.Metric, measurement v1alpha1.Measurement, retries int) v1alpha1.Measurement {
+	queryParams, err := newQueryParams(metric, time.Now())
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
 	}
-
 	response, err := p.api.NewQuery(queryParams).Execute()
 	if err != nil {
-		return metricutil.MarkMeasurementError(newMeasurement, err)
+		return metricutil.MarkMeasurementError(measurement, err)
+	}
+	if !hasData(response) && retries < int(metric.Provider.Wavefront.NoDataRetries) {
+		delay, err := noDataRetryDelay(metric)
+		if err != nil {
+			return metricutil.MarkMeasurementError(measurement, err)
+		}
+		p.logCtx.Infof("wavefront query returned no data, retrying in %v", delay)
+		if measurement.Metadata == nil {
+			measurement.Metadata = map[string]string{}
+		}
+		measurement.Metadata[NoDataRetriesKey] = strconv.Itoa(retries + 1)
+		measurement.Phase = v1alpha1.AnalysisPhaseRunning
+		resumeTime := metav1.NewTime(time.Now().Add(delay))
+		measurement.ResumeAt = &resumeTime
+		return measurement
 	}
 	newValue, newStatus, err := p.processResponse(metric, response)
 	if err != nil {
-		return metricutil.MarkMeasurementError(newMeasurement, err)
+		return metricutil.MarkMeasurementError(measurement, err)
 
 	}
-	newMeasurement.Value = newValue
-	newMeasurement.Phase = newStatus
+	if !hasData(response) {
+		measurement.Message = "wavefront query returned no data"
+	}
+	measurement.Value = newValue
+	measurement.Phase = newStatus
+	measurement.ResumeAt = nil
 	finishedTime := metav1.Now()
-	newMeasurement.FinishedAt = &finishedTime
-	return newMeasurement
+	measurement.FinishedAt = &finishedTime
+	return measurement
 }
 
-// Resume should not be used the Wavefront provider since all the work should occur in the Run method
+// Resume retries a query which returned no data
 func (p *Provider) Resume(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
-	p.logCtx.Warn("Wavefront provider should not execute the Resume method")
-	return measurement
+	retries, ok := measurement.Metadata[NoDataRetriesKey]
+	if !ok {
+		p.logCtx.Warn("Wavefront provider should only resume measurements retrying a query returning no data")
+		return measurement
+	}
+	count, err := strconv.Atoi(retries)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, fmt.Errorf("invalid %s metadata: %v", NoDataRetriesKey, err))
+	}
+	return p.measure(metric, measurement, count)
 }
 
-// Terminate should not be used the Wavefront provider since all the work should occur in the Run method
+// Terminate stops retrying a query which returned no data
 func (p *Provider) Terminate(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric, measurement v1alpha1.Measurement) v1alpha1.Measurement {
-	p.logCtx.Warn("Wavefront provider should not execute the Terminate method")
+	if _, ok := measurement.Metadata[NoDataRetriesKey]; !ok {
+		p.logCtx.Warn("Wavefront provider should only terminate measurements retrying a query returning no data")
+		return measurement
+	}
+	now := metav1.Now()
+	measurement.FinishedAt = &now
+	measurement.Phase = v1alpha1.AnalysisPhaseSuccessful
+	p.logCtx.Info("stopped retrying the wavefront query")
 	return measurement
 }
 
@@ -108,11 +145,65 @@ func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Met
 	return nil
 }
 
+// newQueryParams returns the parameters of the query of the metric at the time of the measurement. The
+// query is summarized over the window of the metric into a single point.
+func newQueryParams(metric v1alpha1.Metric, now time.Time) (*wavefront_api.QueryParams, error) {
+	queryParams := &wavefront_api.QueryParams{
+		QueryString:             metric.Provider.Wavefront.Query,
+		StartTime:               strconv.FormatInt(now.Unix()*1000, 10),
+		MaxPoints:               "1",
+		Granularity:             "s",
+		SeriesOutsideTimeWindow: false,
+	}
+	if metric.Provider.Wavefront.Window != "" {
+		window, err := metric.Provider.Wavefront.Window.Duration()
+		if err != nil {
+			return nil, fmt.Errorf("invalid wavefront window: %v", err)
+		}
+		queryParams.StartTime = strconv.FormatInt(now.Add(-window).Unix()*1000, 10)
+		queryParams.EndTime = strconv.FormatInt(now.Unix()*1000, 10)
+	}
+	return queryParams, nil
+}
+
+func noDataRetryDelay(metric v1alpha1.Metric) (time.Duration, error) {
+	delay := metric.Provider.Wavefront.NoDataRetryDelay
+	if delay == "" {
+		delay = DefaultNoDataRetryDelay
+	}
+	duration, err := delay.Duration()
+	if err != nil {
+		return 0, fmt.Errorf("invalid wavefront noDataRetryDelay: %v", err)
+	}
+	return duration, nil
+}
+
+// hasData returns false when the response has no series, or a series has no value. Wavefront returns
+// NaN for the points without data.
+func hasData(response *wavefront_api.QueryResponse) bool {
+	if len(response.TimeSeries) == 0 {
+		return false
+	}
+	for _, series := range response.TimeSeries {
+		if len(series.DataPoints) == 0 || math.IsNaN(series.DataPoints[0][1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// firstValue returns the value of the first point of the series, or NaN if the series has no point
+func firstValue(series wavefront_api.TimeSeries) float64 {
+	if len(series.DataPoints) == 0 {
+		return math.NaN()
+	}
+	return series.DataPoints[0][1] // Wavefront DataPoint struct is of type []float{<timestamp>, <value>}
+}
+
 func (p *Provider) processResponse(metric v1alpha1.Metric, response *wavefront_api.QueryResponse) (string, v1alpha1.AnalysisPhase, error) {
 
 	if len(response.TimeSeries) == 1 {
-		series := response.TimeSeries[0]
-		result := series.DataPoints[0][1] // Wavefront DataPoint struct is of type []float{<timestamp>, <value>}
+		result := firstValue(response.TimeSeries[0])
 		if math.IsNaN(result) {
 			return fmt.Sprintf("%.2f", result), v1alpha1.AnalysisPhaseInconclusive, nil
 		}
@@ -123,7 +214,7 @@ func (p *Provider) processResponse(metric v1alpha1.Metric, response *wavefront_a
 		results := make([]float64, 0, len(response.TimeSeries))
 		valueStr := "["
 		for _, series := range response.TimeSeries {
-			value := series.DataPoints[0][1]
+			value := firstValue(series)
 			valueStr = valueStr + fmt.Sprintf("%.2f", value) + ","
 			results = append(results, value)
 		}
@@ -140,7 +231,8 @@ func (p *Provider) processResponse(metric v1alpha1.Metric, response *wavefront_a
 		return valueStr, newStatus, nil
 
 	} else {
-		return "", v1alpha1.AnalysisPhaseFailed, fmt.Errorf("No TimeSeries found in response from Wavefront")
+		// No Data: the query matched no series
+		return "", v1alpha1.AnalysisPhaseInconclusive, nil
 	}
 }
 
@@ -153,9 +245,9 @@ func NewWavefrontProvider(api WavefrontClientAPI, logCtx log.Entry) *Provider {
 }
 
 // NewWavefrontAPI generates a Wavefront API client from the metric configuration
-func NewWavefrontAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface) (WavefrontClientAPI, error) {
+func NewWavefrontAPI(metric v1alpha1.Metric, secrets secretutil.Getter) (WavefrontClientAPI, error) {
 	ns := Namespace()
-	secret, err := kubeclientset.CoreV1().Secrets(ns).Get(WavefrontTokensSecretName, metav1.GetOptions{})
+	secret, err := secrets.Get(ns, WavefrontTokensSecretName)
 	if err != nil {
 		return nil, err
 	}
@@ -175,17 +267,7 @@ func NewWavefrontAPI(metric v1alpha1.Metric, kubeclientset kubernetes.Interface)
 	}
 }
 
+// Namespace returns the namespace the wavefront api tokens secret is read from
 func Namespace() string {
-	// This way assumes you've set the POD_NAMESPACE environment variable using the downward API.
-	// This check has to be done first for backwards compatibility with the way InClusterConfig was originally set up
-	if ns, ok := os.LookupEnv("POD_NAMESPACE"); ok {
-		return ns
-	}
-	// Fall back to the namespace associated with the service account token, if available
-	if data, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
-		if ns := strings.TrimSpace(string(data)); len(ns) > 0 {
-			return ns
-		}
-	}
-	return "argo-rollouts"
+	return defaults.Namespace()
 }
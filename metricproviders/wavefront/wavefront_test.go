@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 	log "github.com/sirupsen/logrus"
 	wavefront_api "github.com/spaceapegames/go-wavefront"
 	"github.com/stretchr/testify/assert"
@@ -90,7 +92,7 @@ func TestRunWithQueryError(t *testing.T) {
 	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
 }
 
-func TestRunWithEvaluationError(t *testing.T) {
+func TestRunWithNoData(t *testing.T) {
 	e := log.WithField("", "")
 	mock := mockAPI{
 		response: &wavefront_api.QueryResponse{
@@ -108,11 +110,103 @@ func TestRunWithEvaluationError(t *testing.T) {
 		},
 	}
 	measurement := p.Run(newAnalysisRun(), metric)
-	assert.Equal(t, "No TimeSeries found in response from Wavefront", measurement.Message)
+	assert.Equal(t, "wavefront query returned no data", measurement.Message)
 	assert.NotNil(t, measurement.StartedAt)
 	assert.Equal(t, "", measurement.Value)
 	assert.NotNil(t, measurement.FinishedAt)
-	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase)
+}
+
+func TestRunRetriesNoData(t *testing.T) {
+	e := log.WithField("", "")
+	noData := mockAPI{
+		response: &wavefront_api.QueryResponse{
+			TimeSeries: []wavefront_api.TimeSeries{{
+				DataPoints: []wavefront_api.DataPoint{[]float64{12000, math.NaN()}},
+			}},
+		}}
+	metric := v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: "result == 10",
+		Provider: v1alpha1.MetricProvider{
+			Wavefront: &v1alpha1.WavefrontMetric{
+				Query:            "test",
+				NoDataRetries:    2,
+				NoDataRetryDelay: "1m",
+			},
+		},
+	}
+	p := NewWavefrontProvider(noData, *e)
+	measurement := p.Run(newAnalysisRun(), metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Nil(t, measurement.FinishedAt)
+	assert.Equal(t, "1", measurement.Metadata[NoDataRetriesKey])
+	assert.NotNil(t, measurement.ResumeAt)
+	assert.True(t, measurement.ResumeAt.Sub(measurement.StartedAt.Time) >= time.Minute)
+
+	measurement = p.Resume(newAnalysisRun(), metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseRunning, measurement.Phase)
+	assert.Equal(t, "2", measurement.Metadata[NoDataRetriesKey])
+
+	// the retries are exhausted
+	measurement = p.Resume(newAnalysisRun(), metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseInconclusive, measurement.Phase)
+	assert.Equal(t, "NaN", measurement.Value)
+	assert.Nil(t, measurement.ResumeAt)
+	assert.NotNil(t, measurement.FinishedAt)
+
+	// the data arrives before the retries are exhausted
+	measurement = p.Run(newAnalysisRun(), metric)
+	p = NewWavefrontProvider(mockAPI{
+		response: &wavefront_api.QueryResponse{
+			TimeSeries: []wavefront_api.TimeSeries{{
+				DataPoints: []wavefront_api.DataPoint{[]float64{12000, 10}},
+			}},
+		}}, *e)
+	measurement = p.Resume(newAnalysisRun(), metric, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.Equal(t, "10.00", measurement.Value)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestTerminateRetry(t *testing.T) {
+	e := log.NewEntry(log.New())
+	p := NewWavefrontProvider(mockAPI{}, *e)
+	now := metav1.Now()
+	measurement := v1alpha1.Measurement{
+		StartedAt: &now,
+		Phase:     v1alpha1.AnalysisPhaseRunning,
+		Metadata:  map[string]string{NoDataRetriesKey: "1"},
+	}
+	measurement = p.Terminate(newAnalysisRun(), v1alpha1.Metric{}, measurement)
+	assert.Equal(t, v1alpha1.AnalysisPhaseSuccessful, measurement.Phase)
+	assert.NotNil(t, measurement.FinishedAt)
+}
+
+func TestNewQueryParams(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	metric := v1alpha1.Metric{
+		Provider: v1alpha1.MetricProvider{
+			Wavefront: &v1alpha1.WavefrontMetric{
+				Query: "test",
+			},
+		},
+	}
+	params, err := newQueryParams(metric, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "1600000000000", params.StartTime)
+	assert.Equal(t, "", params.EndTime)
+	assert.Equal(t, "1", params.MaxPoints)
+
+	metric.Provider.Wavefront.Window = "10m"
+	params, err = newQueryParams(metric, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "1599999400000", params.StartTime)
+	assert.Equal(t, "1600000000000", params.EndTime)
+
+	metric.Provider.Wavefront.Window = "10x"
+	_, err = newQueryParams(metric, now)
+	assert.EqualError(t, err, "invalid wavefront window: time: unknown unit x in duration 10x")
 }
 
 func TestResume(t *testing.T) {
@@ -238,11 +332,11 @@ func TestNewWavefrontAPI(t *testing.T) {
 		return true, tokenSecret, nil
 	})
 
-	_, err := NewWavefrontAPI(metric, fakeClient)
+	_, err := NewWavefrontAPI(metric, secretutil.NewGetter(nil, fakeClient))
 	assert.NotNil(t, err)
 	assert.Equal(t, err.Error(), "API token not found")
 
 	metric.Provider.Wavefront.Address = "example.wavefront.com"
-	_, err = NewWavefrontAPI(metric, fakeClient)
+	_, err = NewWavefrontAPI(metric, secretutil.NewGetter(nil, fakeClient))
 	assert.Nil(t, err)
 }
@@ -2,7 +2,10 @@ package webmetric
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -15,12 +18,16 @@ import (
 	"k8s.io/client-go/util/jsonpath"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	"github.com/argoproj/argo-rollouts/utils/evaluate"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 )
 
 const (
 	//ProviderType indicates the provider is prometheus
 	ProviderType = "WebMetric"
+	// DefaultTimeoutSeconds is the request timeout used when the metric does not specify one
+	DefaultTimeoutSeconds = 10
 )
 
 // Provider contains all the required components to run a WebMetric query
@@ -29,6 +36,7 @@ type Provider struct {
 	logCtx     log.Entry
 	client     *http.Client
 	jsonParser *jsonpath.JSONPath
+	secrets    secretutil.Getter
 }
 
 // Type incidates provider is a WebMetric provider
@@ -59,14 +67,27 @@ func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alph
 	request.Header = make(http.Header)
 
 	for _, header := range metric.Provider.Web.Headers {
-		request.Header.Set(header.Key, header.Value)
+		value := header.Value
+		if header.ValueFrom != nil {
+			if value, err = p.secretValue(run.Namespace, header.ValueFrom.SecretKeyRef); err != nil {
+				return metricutil.MarkMeasurementError(measurement, fmt.Errorf("could not read the value of header '%s': %v", header.Key, err))
+			}
+		}
+		request.Header.Set(header.Key, value)
+	}
+
+	client, err := p.httpClient(run.Namespace, metric)
+	if err != nil {
+		return metricutil.MarkMeasurementError(measurement, err)
 	}
 
 	// Send Request
-	response, err := p.client.Do(request)
+	response, err := client.Do(request)
 	if err != nil {
 		return metricutil.MarkMeasurementError(measurement, err)
-	} else if response.StatusCode < 200 || response.StatusCode >= 300 {
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
 		return metricutil.MarkMeasurementError(measurement, fmt.Errorf("received non 2xx response code: %v", response.StatusCode))
 	}
 
@@ -83,6 +104,70 @@ func (p *Provider) Run(run *v1alpha1.AnalysisRun, metric v1alpha1.Metric) v1alph
 	return measurement
 }
 
+// secretValue returns the value of the key of a secret in the namespace of the AnalysisRun
+func (p *Provider) secretValue(namespace string, ref *v1alpha1.SecretKeyRef) (string, error) {
+	if ref == nil {
+		return "", errors.New("secretKeyRef must be specified")
+	}
+	if p.secrets == nil {
+		return "", errors.New("secrets are not available to the web provider")
+	}
+	secret, err := p.secrets.Get(namespace, ref.Name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' does not exist in secret '%s'", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
+
+// httpClient returns the client of the provider, with a transport configured by the TLS options of the
+// metric when it has some
+func (p *Provider) httpClient(namespace string, metric v1alpha1.Metric) (*http.Client, error) {
+	tlsOptions := metric.Provider.Web.TLS
+	if tlsOptions == nil {
+		return p.client, nil
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: tlsOptions.InsecureSkipVerify,
+		ServerName:         tlsOptions.ServerName,
+	}
+	if tlsOptions.CASecretKeyRef != nil {
+		ca, err := p.secretValue(namespace, tlsOptions.CASecretKeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA certificates: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(ca)) {
+			return nil, fmt.Errorf("no PEM encoded certificate found in key '%s' of secret '%s'", tlsOptions.CASecretKeyRef.Key, tlsOptions.CASecretKeyRef.Name)
+		}
+	}
+	if tlsOptions.CertSecretKeyRef != nil || tlsOptions.KeySecretKeyRef != nil {
+		cert, err := p.secretValue(namespace, tlsOptions.CertSecretKeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the client certificate: %v", err)
+		}
+		key, err := p.secretValue(namespace, tlsOptions.KeySecretKeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the key of the client certificate: %v", err)
+		}
+		certificate, err := tls.X509KeyPair([]byte(cert), []byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return &http.Client{
+		Timeout: p.client.Timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
 func (p *Provider) parseResponse(metric v1alpha1.Metric, response *http.Response) (string, v1alpha1.AnalysisPhase, error) {
 	var data interface{}
 
@@ -127,9 +212,9 @@ func (p *Provider) GarbageCollect(run *v1alpha1.AnalysisRun, metric v1alpha1.Met
 func NewWebMetricHttpClient(metric v1alpha1.Metric) *http.Client {
 	var timeout time.Duration
 
-	// Using a default timeout of 10 seconds
+	// Using a default timeout of 10 seconds, unless overridden in the controller config
 	if metric.Provider.Web.TimeoutSeconds <= 0 {
-		timeout = time.Duration(10) * time.Second
+		timeout = time.Duration(configutil.Get().GetInt(configutil.WebMetricTimeoutSecondsKey, DefaultTimeoutSeconds)) * time.Second
 	} else {
 		timeout = time.Duration(metric.Provider.Web.TimeoutSeconds) * time.Second
 	}
@@ -148,10 +233,11 @@ func NewWebMetricJsonParser(metric v1alpha1.Metric) (*jsonpath.JSONPath, error)
 	return jsonParser, err
 }
 
-func NewWebMetricProvider(logCtx log.Entry, client *http.Client, jsonParser *jsonpath.JSONPath) *Provider {
+func NewWebMetricProvider(logCtx log.Entry, client *http.Client, jsonParser *jsonpath.JSONPath, secrets secretutil.Getter) *Provider {
 	return &Provider{
 		logCtx:     logCtx,
 		client:     client,
 		jsonParser: jsonParser,
+		secrets:    secrets,
 	}
 }
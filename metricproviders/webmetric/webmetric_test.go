@@ -1,14 +1,19 @@
 package webmetric
 
 import (
+	"encoding/pem"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
 func TestRunSuite(t *testing.T) {
@@ -248,7 +253,7 @@ func TestRunSuite(t *testing.T) {
 
 		jsonparser, err := NewWebMetricJsonParser(test.metric)
 		assert.NoError(t, err)
-		provider := NewWebMetricProvider(*logCtx, server.Client(), jsonparser)
+		provider := NewWebMetricProvider(*logCtx, server.Client(), jsonparser, nil)
 
 		// Get our result
 		measurement := provider.Run(newAnalysisRun(), test.metric)
@@ -277,3 +282,72 @@ func TestRunSuite(t *testing.T) {
 func newAnalysisRun() *v1alpha1.AnalysisRun {
 	return &v1alpha1.AnalysisRun{}
 }
+
+func newTLSMetric(url string, tlsConfig *v1alpha1.WebMetricTLSConfig) v1alpha1.Metric {
+	return v1alpha1.Metric{
+		Name:             "foo",
+		SuccessCondition: "result == 'true'",
+		Provider: v1alpha1.MetricProvider{
+			Web: &v1alpha1.WebMetric{
+				URL:      url,
+				JSONPath: "{$.ok}",
+				Headers: []v1alpha1.WebMetricHeader{{
+					Key:       "Authorization",
+					ValueFrom: &v1alpha1.ValueFrom{SecretKeyRef: &v1alpha1.SecretKeyRef{Name: "scoring", Key: "token"}},
+				}},
+				TLS: tlsConfig,
+			},
+		},
+	}
+}
+
+func TestRunWithSecretsAndTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer my-token" {
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(rw, `{"ok": "true"}`)
+	}))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "scoring", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{
+			"token":  []byte("Bearer my-token"),
+			"ca.crt": ca,
+		},
+	}
+	secrets := secretutil.NewGetter(nil, k8sfake.NewSimpleClientset(secret))
+	run := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault}}
+
+	tests := []struct {
+		tlsConfig     *v1alpha1.WebMetricTLSConfig
+		expectedPhase v1alpha1.AnalysisPhase
+		message       string
+	}{
+		{nil, v1alpha1.AnalysisPhaseError, "x509"},
+		{&v1alpha1.WebMetricTLSConfig{InsecureSkipVerify: true}, v1alpha1.AnalysisPhaseSuccessful, ""},
+		{&v1alpha1.WebMetricTLSConfig{CASecretKeyRef: &v1alpha1.SecretKeyRef{Name: "scoring", Key: "ca.crt"}}, v1alpha1.AnalysisPhaseSuccessful, ""},
+		{&v1alpha1.WebMetricTLSConfig{CASecretKeyRef: &v1alpha1.SecretKeyRef{Name: "scoring", Key: "token"}}, v1alpha1.AnalysisPhaseError, "no PEM encoded certificate found in key 'token' of secret 'scoring'"},
+		{&v1alpha1.WebMetricTLSConfig{CASecretKeyRef: &v1alpha1.SecretKeyRef{Name: "scoring", Key: "missing"}}, v1alpha1.AnalysisPhaseError, "could not read the CA certificates: key 'missing' does not exist in secret 'scoring'"},
+	}
+	for _, test := range tests {
+		metric := newTLSMetric(server.URL, test.tlsConfig)
+		jsonparser, err := NewWebMetricJsonParser(metric)
+		assert.NoError(t, err)
+		provider := NewWebMetricProvider(*log.WithField("test", "test"), NewWebMetricHttpClient(metric), jsonparser, secrets)
+		measurement := provider.Run(run, metric)
+		assert.Equal(t, test.expectedPhase, measurement.Phase)
+		assert.Contains(t, measurement.Message, test.message)
+	}
+
+	metric := newTLSMetric(server.URL, &v1alpha1.WebMetricTLSConfig{InsecureSkipVerify: true})
+	metric.Provider.Web.Headers[0].ValueFrom.SecretKeyRef.Name = "missing"
+	jsonparser, err := NewWebMetricJsonParser(metric)
+	assert.NoError(t, err)
+	provider := NewWebMetricProvider(*log.WithField("test", "test"), NewWebMetricHttpClient(metric), jsonparser, secrets)
+	measurement := provider.Run(run, metric)
+	assert.Equal(t, v1alpha1.AnalysisPhaseError, measurement.Phase)
+	assert.Equal(t, `could not read the value of header 'Authorization': secrets "missing" not found`, measurement.Message)
+}
@@ -0,0 +1,248 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the controller's namespace, holding the
+	// notification services, templates and triggers
+	ConfigMapName = "argo-rollouts-notification-configmap"
+	// SecretName is the name of the Secret, in the controller's namespace, holding the credentials
+	// referenced by the notification services
+	SecretName = "argo-rollouts-notification-secret"
+
+	serviceKeyPrefix  = "service."
+	templateKeyPrefix = "template."
+	triggerKeyPrefix  = "trigger."
+)
+
+// Triggers of rollout notifications
+const (
+	TriggerUpdated        = "on-rollout-updated"
+	TriggerStepCompleted  = "on-rollout-step-completed"
+	TriggerPaused         = "on-rollout-paused"
+	TriggerPromoted       = "on-rollout-promoted"
+	TriggerAnalysisFailed = "on-analysis-run-failed"
+	TriggerAborted        = "on-rollout-aborted"
+	TriggerCompleted      = "on-rollout-completed"
+	TriggerStuck          = "on-rollout-stuck"
+)
+
+// Triggers of the notifications of DaemonSets updated progressively
+const (
+	TriggerDaemonSetUpdated       = "on-daemonset-updated"
+	TriggerDaemonSetStepCompleted = "on-daemonset-step-completed"
+	TriggerDaemonSetAborted       = "on-daemonset-aborted"
+	TriggerDaemonSetCompleted     = "on-daemonset-completed"
+)
+
+// Template renders the message sent by a notification
+type Template struct {
+	// Message is a Go template rendered with the rollout (.Rollout), the trigger (.Trigger) and the
+	// message of the event which fired the trigger (.Message)
+	Message string `json:"message"`
+	// Email holds the settings used when the notification is sent by email
+	Email *EmailTemplate `json:"email,omitempty"`
+
+	message *template.Template
+	subject *template.Template
+}
+
+// EmailTemplate holds the email specific settings of a template
+type EmailTemplate struct {
+	Subject string `json:"subject,omitempty"`
+}
+
+// defaultTemplates are the templates sent by triggers which are not configured in the ConfigMap
+var defaultTemplates = map[string]string{
+	TriggerUpdated:        "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} is updating: {{.Message}}\"",
+	TriggerStepCompleted:  "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} completed a step: {{.Message}}\"",
+	TriggerPaused:         "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} is paused awaiting promotion: {{.Message}}\"",
+	TriggerPromoted:       "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} was promoted: {{.Message}}\"",
+	TriggerAnalysisFailed: "message: \"Analysis of rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} failed: {{.Message}}\"",
+	TriggerAborted:        "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} was aborted: {{.Message}}\"",
+	TriggerCompleted:      "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} is fully promoted\"",
+	TriggerStuck:          "message: \"Rollout {{.Rollout.Namespace}}/{{.Rollout.Name}} is stuck: {{.Message}}\"",
+
+	TriggerDaemonSetUpdated:       "message: \"DaemonSet {{.DaemonSet.Namespace}}/{{.DaemonSet.Name}} is updating: {{.Message}}\"",
+	TriggerDaemonSetStepCompleted: "message: \"DaemonSet {{.DaemonSet.Namespace}}/{{.DaemonSet.Name}} completed a step: {{.Message}}\"",
+	TriggerDaemonSetAborted:       "message: \"Update of DaemonSet {{.DaemonSet.Namespace}}/{{.DaemonSet.Name}} was aborted: {{.Message}}\"",
+	TriggerDaemonSetCompleted:     "message: \"DaemonSet {{.DaemonSet.Namespace}}/{{.DaemonSet.Name}} is fully updated\"",
+}
+
+// Config is the parsed notification configuration
+type Config struct {
+	// Services are the configured notification services keyed by name (e.g. slack, webhook.ops)
+	Services map[string]Service
+	// Templates are the templates keyed by name
+	Templates map[string]*Template
+	// Triggers are the names of the templates sent by each trigger
+	Triggers map[string][]string
+}
+
+// ParseConfig parses the notification ConfigMap. Service settings may reference keys of the
+// Secret with a $ prefix (e.g. token: $slack-token) so credentials are not stored in the ConfigMap.
+// Triggers which are not configured send a default template.
+func ParseConfig(cm *corev1.ConfigMap, secret *corev1.Secret) (*Config, error) {
+	cfg := &Config{
+		Services:  map[string]Service{},
+		Templates: map[string]*Template{},
+		Triggers:  map[string][]string{},
+	}
+	for trigger, text := range defaultTemplates {
+		tmpl, err := parseTemplate(trigger, text)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Templates[trigger] = tmpl
+		cfg.Triggers[trigger] = []string{trigger}
+	}
+	if cm == nil {
+		return cfg, nil
+	}
+	var secretData map[string][]byte
+	if secret != nil {
+		secretData = secret.Data
+	}
+	for _, key := range sortedKeys(cm.Data) {
+		value := cm.Data[key]
+		switch {
+		case strings.HasPrefix(key, serviceKeyPrefix):
+			name := strings.TrimPrefix(key, serviceKeyPrefix)
+			opts, err := resolveSecretRefs([]byte(value), secretData)
+			if err != nil {
+				return nil, fmt.Errorf("invalid service '%s': %v", name, err)
+			}
+			service, err := newService(name, opts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid service '%s': %v", name, err)
+			}
+			cfg.Services[name] = service
+		case strings.HasPrefix(key, templateKeyPrefix):
+			name := strings.TrimPrefix(key, templateKeyPrefix)
+			tmpl, err := parseTemplate(name, value)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Templates[name] = tmpl
+		case strings.HasPrefix(key, triggerKeyPrefix):
+			name := strings.TrimPrefix(key, triggerKeyPrefix)
+			var templates []string
+			if err := yaml.Unmarshal([]byte(value), &templates); err != nil {
+				return nil, fmt.Errorf("invalid trigger '%s': %v", name, err)
+			}
+			cfg.Triggers[name] = templates
+		}
+	}
+	for trigger, templates := range cfg.Triggers {
+		for _, name := range templates {
+			if _, ok := cfg.Templates[name]; !ok {
+				return nil, fmt.Errorf("trigger '%s' references unknown template '%s'", trigger, name)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// templateFuncs are the functions available to templates in addition to the Go template builtins
+var templateFuncs = template.FuncMap{
+	// json encodes the value as JSON, e.g. to quote strings in JSON payloads
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func parseTemplate(name, text string) (*Template, error) {
+	var tmpl Template
+	if err := yaml.Unmarshal([]byte(text), &tmpl); err != nil {
+		return nil, fmt.Errorf("invalid template '%s': %v", name, err)
+	}
+	var err error
+	if tmpl.message, err = template.New(name).Funcs(templateFuncs).Parse(tmpl.Message); err != nil {
+		return nil, fmt.Errorf("invalid template '%s': %v", name, err)
+	}
+	if tmpl.Email != nil && tmpl.Email.Subject != "" {
+		if tmpl.subject, err = template.New(name + ".subject").Funcs(templateFuncs).Parse(tmpl.Email.Subject); err != nil {
+			return nil, fmt.Errorf("invalid template '%s': %v", name, err)
+		}
+	}
+	return &tmpl, nil
+}
+
+// render returns the subject and body of the template rendered with the variables
+func (t *Template) render(vars interface{}) (string, string, error) {
+	var body strings.Builder
+	if err := t.message.Execute(&body, vars); err != nil {
+		return "", "", err
+	}
+	var subject strings.Builder
+	if t.subject != nil {
+		if err := t.subject.Execute(&subject, vars); err != nil {
+			return "", "", err
+		}
+	}
+	return subject.String(), body.String(), nil
+}
+
+// resolveSecretRefs parses the YAML service settings and replaces the values starting with $ by
+// the value of the matching key of the secret. The settings are returned as JSON.
+func resolveSecretRefs(value []byte, secretData map[string][]byte) ([]byte, error) {
+	var opts interface{}
+	if err := yaml.Unmarshal(value, &opts); err != nil {
+		return nil, err
+	}
+	var resolve func(v interface{}) (interface{}, error)
+	resolve = func(v interface{}) (interface{}, error) {
+		switch typed := v.(type) {
+		case string:
+			if !strings.HasPrefix(typed, "$") {
+				return typed, nil
+			}
+			secretValue, ok := secretData[strings.TrimPrefix(typed, "$")]
+			if !ok {
+				return nil, fmt.Errorf("key '%s' not found in secret '%s'", strings.TrimPrefix(typed, "$"), SecretName)
+			}
+			return string(secretValue), nil
+		case map[string]interface{}:
+			for k, item := range typed {
+				resolved, err := resolve(item)
+				if err != nil {
+					return nil, err
+				}
+				typed[k] = resolved
+			}
+		case []interface{}:
+			for i, item := range typed {
+				resolved, err := resolve(item)
+				if err != nil {
+					return nil, err
+				}
+				typed[i] = resolved
+			}
+		}
+		return v, nil
+	}
+	resolved, err := resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resolved)
+}
+
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
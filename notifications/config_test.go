@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "argo-rollouts"},
+		Data:       data,
+	}
+}
+
+func newSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: "argo-rollouts"},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	cfg, err := ParseConfig(nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Services)
+	for _, trigger := range []string{TriggerStepCompleted, TriggerPaused, TriggerPromoted, TriggerAnalysisFailed, TriggerAborted, TriggerCompleted, TriggerStuck, TriggerDaemonSetUpdated, TriggerDaemonSetStepCompleted, TriggerDaemonSetAborted, TriggerDaemonSetCompleted} {
+		assert.Equal(t, []string{trigger}, cfg.Triggers[trigger])
+		assert.NotNil(t, cfg.Templates[trigger])
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cm := newConfigMap(map[string]string{
+		"service.slack": "token: $slack-token",
+		"service.email": `
+host: smtp.example.com
+from: rollouts@example.com
+username: rollouts
+password: $email-password`,
+		"service.webhook.ops": `
+url: https://ops.example.com/hooks
+headers:
+  X-Token: $ops-token`,
+		"template.my-aborted": `
+message: "{{.Rollout.Name}} aborted: {{.Message}}"
+email:
+  subject: "{{.Rollout.Name}} aborted"`,
+		"trigger.on-rollout-aborted": "[my-aborted]",
+	})
+	secret := newSecret(map[string]string{
+		"slack-token":    "xoxb-123",
+		"email-password": "secret",
+		"ops-token":      "456",
+	})
+	cfg, err := ParseConfig(cm, secret)
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Services, 3)
+	assert.Equal(t, "xoxb-123", cfg.Services["slack"].(*slackService).Token)
+	assert.Equal(t, defaultSlackURL, cfg.Services["slack"].(*slackService).URL)
+	assert.Equal(t, "secret", cfg.Services["email"].(*emailService).Password)
+	assert.Equal(t, 587, cfg.Services["email"].(*emailService).Port)
+	assert.Equal(t, "456", cfg.Services["webhook.ops"].(*webhookService).Headers["X-Token"])
+	assert.Equal(t, []string{"my-aborted"}, cfg.Triggers[TriggerAborted])
+
+	ro := &v1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "guestbook"}}
+	subject, body, err := cfg.Templates["my-aborted"].render(map[string]interface{}{"Rollout": ro, "Message": "analysis failed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook aborted", subject)
+	assert.Equal(t, "guestbook aborted: analysis failed", body)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"service.slack": "token: $missing"},
+		{"service.slack": "url: https://slack.example.com"},
+		{"service.pagerduty": "token: abc"},
+		{"template.broken": "message: \"{{.Rollout.Name\""},
+		{"trigger.on-rollout-aborted": "[missing-template]"},
+	} {
+		_, err := ParseConfig(newConfigMap(data), newSecret(nil))
+		assert.Error(t, err, data)
+	}
+}
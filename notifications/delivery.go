@@ -0,0 +1,220 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo-rollouts/controller/metrics"
+)
+
+const (
+	// DefaultMaxDeliveryAttempts is the number of times a notification is sent before it is dropped
+	DefaultMaxDeliveryAttempts = 5
+	// DefaultDeliveryWorkers is the number of notifications sent at the same time
+	DefaultDeliveryWorkers = 2
+
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 5 * time.Minute
+	// queueFlushPeriod is how often the queued and delivered notifications are written to the queue
+	// ConfigMap
+	queueFlushPeriod = 5 * time.Second
+)
+
+// Notification is a message to deliver through a notification service
+type Notification struct {
+	// Service is the name of the service the notification is sent with (e.g. slack)
+	Service string `json:"service"`
+	// Recipient identifies the destination within the service, such as a channel or an address
+	Recipient string `json:"recipient"`
+	// Subject is a short summary of the message. Not every service uses it
+	Subject string `json:"subject,omitempty"`
+	// Body is the rendered message
+	Body string `json:"body,omitempty"`
+	// Payload is the request rendered by services with a templated payload
+	Payload string `json:"payload,omitempty"`
+}
+
+// Service sends notifications to one kind of destination
+type Service interface {
+	Send(n Notification) error
+}
+
+// payloadRenderer is implemented by services rendering their own request payload from the
+// template variables. The payload is rendered when the notification is queued.
+type payloadRenderer interface {
+	renderPayload(vars map[string]interface{}) (string, error)
+}
+
+// permanentError is returned by services for failures which will not succeed when retried, such
+// as a rejected payload
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// NewPermanentError marks the error as one which should not be retried
+func NewPermanentError(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanentError returns true if the error should not be retried
+func IsPermanentError(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Deliverer sends notifications from a queue. Failed deliveries are retried with an exponential
+// backoff so a flaky destination does not silently drop notifications. The pending notifications
+// are persisted in the queue ConfigMap, so the notifications still pending when the controller
+// stops are sent once it starts again.
+type Deliverer struct {
+	metricsServer *metrics.MetricsServer
+	queue         workqueue.RateLimitingInterface
+	store         *queueStore
+	maxAttempts   int
+
+	lock     sync.RWMutex
+	services map[string]Service
+}
+
+// NewDeliverer returns a Deliverer with no services, persisting its queue in the namespace
+func NewDeliverer(kubeclientset kubernetes.Interface, namespace string, metricsServer *metrics.MetricsServer) *Deliverer {
+	return &Deliverer{
+		metricsServer: metricsServer,
+		queue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.NewItemExponentialFailureRateLimiter(retryBaseDelay, retryMaxDelay),
+			"Notifications"),
+		store:       newQueueStore(kubeclientset, namespace),
+		maxAttempts: DefaultMaxDeliveryAttempts,
+		services:    map[string]Service{},
+	}
+}
+
+// SetServices replaces the services notifications can be sent with, keyed by service name
+func (d *Deliverer) SetServices(services map[string]Service) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.services = services
+}
+
+func (d *Deliverer) getService(name string) (Service, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	service, ok := d.services[name]
+	return service, ok
+}
+
+// Deliver queues the notification to be sent. The notification is persisted by the next flush of
+// the queue. A notification which cannot be persisted is still sent, but is lost if the controller
+// stops before it is delivered.
+func (d *Deliverer) Deliver(n Notification) {
+	if err := d.store.add(n); err != nil {
+		log.WithField("service", n.Service).WithField("recipient", n.Recipient).Warnf("Failed to persist notification: %v", err)
+	}
+	d.queue.Add(n)
+}
+
+// queuePending queues the notifications persisted before the controller restarted
+func (d *Deliverer) queuePending() {
+	pending, err := d.store.list()
+	if err != nil {
+		log.Warnf("Failed to read the pending notifications from ConfigMap '%s': %v", QueueConfigMapName, err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Infof("Queueing %d pending notifications", len(pending))
+	}
+	for _, n := range pending {
+		d.queue.Add(n)
+	}
+}
+
+// Run sends the pending and queued notifications until the stop channel is closed, persisting the
+// queue periodically and once the workers stop
+func (d *Deliverer) Run(workers int, stopCh <-chan struct{}) {
+	d.queuePending()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(d.flush, queueFlushPeriod, stopCh)
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() {
+				for d.processNext() {
+				}
+			}, time.Second, stopCh)
+		}()
+	}
+	<-stopCh
+	d.queue.ShutDown()
+	wg.Wait()
+	d.flush()
+}
+
+// flush writes the changes of the queue to the queue ConfigMap
+func (d *Deliverer) flush() {
+	if err := d.store.flush(); err != nil {
+		log.Warnf("Failed to persist the notification queue in ConfigMap '%s': %v", QueueConfigMapName, err)
+	}
+}
+
+func (d *Deliverer) processNext() bool {
+	obj, shutdown := d.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer d.queue.Done(obj)
+	n, ok := obj.(Notification)
+	if !ok {
+		d.queue.Forget(obj)
+		return true
+	}
+	err := d.send(n)
+	logCtx := log.WithField("service", n.Service).WithField("recipient", n.Recipient)
+	switch {
+	case err == nil:
+		d.queue.Forget(n)
+		d.forget(n)
+		d.metricsServer.IncNotificationDelivery(n.Service, true)
+	case !IsPermanentError(err) && d.queue.NumRequeues(n)+1 < d.maxAttempts:
+		logCtx.Warnf("Failed to send notification, retrying: %v", err)
+		d.metricsServer.IncNotificationRetry(n.Service)
+		d.queue.AddRateLimited(n)
+	default:
+		logCtx.Errorf("Failed to send notification after %d attempts: %v", d.queue.NumRequeues(n)+1, err)
+		d.queue.Forget(n)
+		d.forget(n)
+		d.metricsServer.IncNotificationDelivery(n.Service, false)
+	}
+	return true
+}
+
+// forget removes a notification which was delivered or dropped from the queue ConfigMap on the next
+// flush
+func (d *Deliverer) forget(n Notification) {
+	if err := d.store.remove(n); err != nil {
+		log.WithField("service", n.Service).WithField("recipient", n.Recipient).Warnf("Failed to remove notification from ConfigMap '%s': %v", QueueConfigMapName, err)
+	}
+}
+
+func (d *Deliverer) send(n Notification) error {
+	service, ok := d.getService(n.Service)
+	if !ok {
+		return NewPermanentError(fmt.Errorf("notification service '%s' is not configured", n.Service))
+	}
+	return service.Send(n)
+}
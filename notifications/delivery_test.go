@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo-rollouts/controller/metrics"
+	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
+)
+
+type fakeService struct {
+	errs []error
+	sent []Notification
+}
+
+func (s *fakeService) Send(n Notification) error {
+	s.sent = append(s.sent, n)
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func newTestDeliverer(services map[string]Service) *Deliverer {
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	d := NewDeliverer(k8sfake.NewSimpleClientset(), "argo-rollouts", metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), nil, &metrics.K8sRequestsCountProvider{}))
+	// send retries without waiting so the test does not depend on the backoff
+	d.queue = workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(0, 0))
+	d.SetServices(services)
+	return d
+}
+
+// drain processes the queue until it is empty
+func drain(d *Deliverer) {
+	for d.queue.Len() > 0 {
+		d.processNext()
+	}
+}
+
+func TestDeliverSuccess(t *testing.T) {
+	service := &fakeService{}
+	d := newTestDeliverer(map[string]Service{"slack": service})
+	d.Deliver(Notification{Service: "slack", Recipient: "#rollouts", Body: "aborted"})
+	drain(d)
+	assert.Len(t, service.sent, 1)
+	assert.Equal(t, 0, d.queue.NumRequeues(service.sent[0]))
+}
+
+func TestDeliverRetriesTransientErrors(t *testing.T) {
+	service := &fakeService{errs: []error{errors.New("timeout"), errors.New("timeout")}}
+	d := newTestDeliverer(map[string]Service{"slack": service})
+	n := Notification{Service: "slack", Recipient: "#rollouts", Body: "aborted"}
+	d.Deliver(n)
+	drain(d)
+	assert.Len(t, service.sent, 3)
+	assert.Equal(t, 0, d.queue.NumRequeues(n))
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	var errs []error
+	for i := 0; i < DefaultMaxDeliveryAttempts+2; i++ {
+		errs = append(errs, errors.New("timeout"))
+	}
+	service := &fakeService{errs: errs}
+	d := newTestDeliverer(map[string]Service{"slack": service})
+	n := Notification{Service: "slack", Recipient: "#rollouts", Body: "aborted"}
+	d.Deliver(n)
+	drain(d)
+	assert.Len(t, service.sent, DefaultMaxDeliveryAttempts)
+	assert.Equal(t, 0, d.queue.NumRequeues(n))
+}
+
+func TestDeliverDoesNotRetryPermanentErrors(t *testing.T) {
+	service := &fakeService{errs: []error{NewPermanentError(errors.New("invalid_payload"))}}
+	d := newTestDeliverer(map[string]Service{"slack": service})
+	d.Deliver(Notification{Service: "slack", Recipient: "#rollouts", Body: "aborted"})
+	drain(d)
+	assert.Len(t, service.sent, 1)
+}
+
+func TestDeliverUnknownService(t *testing.T) {
+	d := newTestDeliverer(map[string]Service{})
+	d.Deliver(Notification{Service: "pagerduty", Body: "aborted"})
+	drain(d)
+	assert.Equal(t, 0, d.queue.Len())
+}
+
+func TestDeliverPersistsPendingNotifications(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	service := &fakeService{errs: []error{errors.New("timeout")}}
+	d := newTestDeliverer(map[string]Service{"slack": service})
+	d.store = newQueueStore(client, "argo-rollouts")
+	n := Notification{Service: "slack", Recipient: "#rollouts", Body: "aborted"}
+	d.Deliver(n)
+	// queueing does not wait for the ConfigMap to be written
+	assert.Empty(t, client.Actions())
+	d.processNext()
+	assert.NoError(t, d.store.flush())
+	cm, err := client.CoreV1().ConfigMaps("argo-rollouts").Get(QueueConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, cm.Data, 1)
+
+	// the notification pending when the controller stopped is sent once it starts again
+	service.sent = nil
+	restarted := newTestDeliverer(map[string]Service{"slack": service})
+	restarted.store = newQueueStore(client, "argo-rollouts")
+	restarted.queuePending()
+	drain(restarted)
+	assert.Equal(t, []Notification{n}, service.sent)
+	assert.NoError(t, restarted.store.flush())
+	cm, err = client.CoreV1().ConfigMaps("argo-rollouts").Get(QueueConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, cm.Data)
+}
+
+func TestQueueStoreCapsPersistedNotifications(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	store := newQueueStore(client, "argo-rollouts")
+	store.maxEntries = 2
+	for _, body := range []string{"a", "b", "c"} {
+		assert.NoError(t, store.add(Notification{Service: "slack", Body: body}))
+		assert.NoError(t, store.flush())
+	}
+	pending, err := store.list()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pending))
+
+	// a removed notification makes room for the next one
+	assert.NoError(t, store.remove(pending[0]))
+	assert.NoError(t, store.add(Notification{Service: "slack", Body: "c"}))
+	assert.NoError(t, store.flush())
+	pending, err = store.list()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pending))
+	assert.Contains(t, pending, Notification{Service: "slack", Body: "c"})
+}
+
+func TestQueueStoreRetriesConfigMapCreatedByAnotherWriter(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	created := false
+	client.PrependReactor("create", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		if created {
+			return false, nil, nil
+		}
+		created = true
+		return true, nil, k8serrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, QueueConfigMapName)
+	})
+	store := newQueueStore(client, "argo-rollouts")
+	n := Notification{Service: "slack", Body: "aborted"}
+	assert.NoError(t, store.add(n))
+	assert.NoError(t, store.flush())
+	pending, err := store.list()
+	assert.NoError(t, err)
+	assert.Equal(t, []Notification{n}, pending)
+}
+
+func TestQueueStoreKeepsChangesWhichFailed(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	client.PrependReactor("create", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("unavailable")
+	})
+	store := newQueueStore(client, "argo-rollouts")
+	n := Notification{Service: "slack", Body: "aborted"}
+	assert.NoError(t, store.add(n))
+	assert.EqualError(t, store.flush(), "unavailable")
+	assert.Len(t, store.changes, 1)
+}
+
+func TestIsPermanentError(t *testing.T) {
+	assert.False(t, IsPermanentError(errors.New("timeout")))
+	assert.True(t, IsPermanentError(NewPermanentError(errors.New("invalid_payload"))))
+}
@@ -0,0 +1,287 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	// GitHubServiceType reports rollouts as GitHub deployment statuses
+	GitHubServiceType = "github"
+	// GitLabServiceType reports rollouts as GitLab deployments
+	GitLabServiceType = "gitlab"
+
+	// CommitSHAAnnotation is the pod template annotation holding the commit the images of the
+	// rollout were built from. If it is not set, the commit is resolved from the image tags.
+	CommitSHAAnnotation = "notifications.argoproj.io/commit-sha"
+	// EnvironmentAnnotation is the rollout annotation overriding the name of the deployment
+	// environment, which defaults to <namespace>/<name>
+	EnvironmentAnnotation = "notifications.argoproj.io/environment"
+
+	defaultGitHubURL = "https://api.github.com"
+	defaultGitLabURL = "https://gitlab.com"
+
+	// maxDeploymentDescription is the longest description accepted by GitHub deployment statuses
+	maxDeploymentDescription = 140
+)
+
+// Deployment states reported for the triggers of rollouts
+const (
+	deploymentStateInProgress = "in_progress"
+	deploymentStateSuccess    = "success"
+	deploymentStateFailure    = "failure"
+)
+
+var (
+	// commitSHARegexp matches image tags which are abbreviated or full commit SHAs
+	commitSHARegexp = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+	// triggerDeploymentStates maps the triggers of rollouts to the state of their deployment
+	triggerDeploymentStates = map[string]string{
+		TriggerUpdated:        deploymentStateInProgress,
+		TriggerStepCompleted:  deploymentStateInProgress,
+		TriggerPaused:         deploymentStateInProgress,
+		TriggerPromoted:       deploymentStateInProgress,
+		TriggerAnalysisFailed: deploymentStateFailure,
+		TriggerAborted:        deploymentStateFailure,
+		TriggerCompleted:      deploymentStateSuccess,
+		TriggerStuck:          deploymentStateInProgress,
+	}
+)
+
+// deploymentStatus is the payload of deployment services, rendered when the notification is queued
+type deploymentStatus struct {
+	SHA         string `json:"sha"`
+	Environment string `json:"environment"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+// newDeploymentStatus returns the deployment status of the rollout for the template variables
+func newDeploymentStatus(vars map[string]interface{}) (string, error) {
+	ro, ok := vars["Rollout"].(*v1alpha1.Rollout)
+	if !ok {
+		return "", fmt.Errorf("deployment statuses require a rollout")
+	}
+	trigger, _ := vars["Trigger"].(string)
+	state, ok := triggerDeploymentStates[trigger]
+	if !ok {
+		return "", fmt.Errorf("trigger '%s' has no deployment state", trigger)
+	}
+	sha := resolveCommitSHA(ro)
+	if sha == "" {
+		return "", fmt.Errorf("commit of rollout '%s' not found: set the %s annotation of the pod template", ro.Name, CommitSHAAnnotation)
+	}
+	environment := ro.Annotations[EnvironmentAnnotation]
+	if environment == "" {
+		environment = fmt.Sprintf("%s/%s", ro.Namespace, ro.Name)
+	}
+	description, _ := vars["Body"].(string)
+	if analysis, ok := vars["Analysis"].([]AnalysisSummary); ok && len(analysis) > 0 {
+		var runs []string
+		for _, run := range analysis {
+			runs = append(runs, fmt.Sprintf("%s: %s", run.Name, run.Phase))
+		}
+		description = fmt.Sprintf("%s (analysis %s)", description, strings.Join(runs, ", "))
+	}
+	if len(description) > maxDeploymentDescription {
+		description = description[:maxDeploymentDescription-3] + "..."
+	}
+	payload, err := json.Marshal(deploymentStatus{
+		SHA:         sha,
+		Environment: environment,
+		State:       state,
+		Description: description,
+	})
+	return string(payload), err
+}
+
+// resolveCommitSHA returns the commit of the pod template of the rollout from the commit annotation
+// or from the tag of the first image tagged with a commit SHA
+func resolveCommitSHA(ro *v1alpha1.Rollout) string {
+	if sha := ro.Spec.Template.Annotations[CommitSHAAnnotation]; sha != "" {
+		return sha
+	}
+	for _, container := range ro.Spec.Template.Spec.Containers {
+		image := container.Image
+		if i := strings.Index(image, "@"); i >= 0 {
+			image = image[:i]
+		}
+		i := strings.LastIndex(image, ":")
+		if i < 0 || strings.Contains(image[i:], "/") {
+			continue
+		}
+		if tag := image[i+1:]; commitSHARegexp.MatchString(tag) {
+			return tag
+		}
+	}
+	return ""
+}
+
+func parseDeploymentStatus(n Notification) (*deploymentStatus, error) {
+	var status deploymentStatus
+	if err := json.Unmarshal([]byte(n.Payload), &status); err != nil || status.SHA == "" {
+		return nil, NewPermanentError(fmt.Errorf("notification has no deployment status"))
+	}
+	return &status, nil
+}
+
+// doJSON sends the request body as JSON and decodes the response into the result, if set
+func doJSON(client *http.Client, method, url string, headers map[string]string, body, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return NewPermanentError(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return NewPermanentError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// gitHubService reports the state of rollouts as statuses of the GitHub deployment of their commit
+// and environment. The recipient is the repository (owner/repo).
+type gitHubService struct {
+	Token string `json:"token"`
+	// URL overrides the GitHub API endpoint (e.g. for GitHub Enterprise)
+	URL string `json:"url,omitempty"`
+
+	client *http.Client
+}
+
+func (s *gitHubService) renderPayload(vars map[string]interface{}) (string, error) {
+	return newDeploymentStatus(vars)
+}
+
+func (s *gitHubService) Send(n Notification) error {
+	status, err := parseDeploymentStatus(n)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		"Authorization": "token " + s.Token,
+		// the in_progress state is part of the flash preview
+		"Accept": "application/vnd.github.flash-preview+json, application/vnd.github.ant-man-preview+json",
+	}
+	repoURL := fmt.Sprintf("%s/repos/%s", strings.TrimSuffix(s.URL, "/"), n.Recipient)
+
+	// the deployment of a commit and environment is reused so retries and later states of the
+	// rollout update the same deployment
+	var deployments []struct {
+		ID int64 `json:"id"`
+	}
+	query := url.Values{"sha": {status.SHA}, "environment": {status.Environment}}
+	if err := doJSON(s.client, http.MethodGet, repoURL+"/deployments?"+query.Encode(), headers, nil, &deployments); err != nil {
+		return err
+	}
+	var deploymentID int64
+	if len(deployments) > 0 {
+		deploymentID = deployments[0].ID
+	} else {
+		var created struct {
+			ID int64 `json:"id"`
+		}
+		err := doJSON(s.client, http.MethodPost, repoURL+"/deployments", headers, map[string]interface{}{
+			"ref":               status.SHA,
+			"environment":       status.Environment,
+			"description":       status.Description,
+			"auto_merge":        false,
+			"required_contexts": []string{},
+		}, &created)
+		if err != nil {
+			return err
+		}
+		deploymentID = created.ID
+	}
+	return doJSON(s.client, http.MethodPost, fmt.Sprintf("%s/deployments/%d/statuses", repoURL, deploymentID), headers, map[string]string{
+		"state":       status.State,
+		"description": status.Description,
+		"environment": status.Environment,
+	}, nil)
+}
+
+// gitLabDeploymentStates maps deployment states to the statuses of GitLab deployments
+var gitLabDeploymentStates = map[string]string{
+	deploymentStateInProgress: "running",
+	deploymentStateSuccess:    "success",
+	deploymentStateFailure:    "failed",
+}
+
+// gitLabService reports the state of rollouts as the status of the GitLab deployment of their commit
+// and environment. The recipient is the project ID or path (group/project).
+type gitLabService struct {
+	Token string `json:"token"`
+	// URL overrides the GitLab endpoint (e.g. for self-managed GitLab)
+	URL string `json:"url,omitempty"`
+	// Ref is the branch or tag deployments are created for
+	Ref string `json:"ref"`
+
+	client *http.Client
+}
+
+func (s *gitLabService) renderPayload(vars map[string]interface{}) (string, error) {
+	return newDeploymentStatus(vars)
+}
+
+func (s *gitLabService) Send(n Notification) error {
+	status, err := parseDeploymentStatus(n)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"PRIVATE-TOKEN": s.Token}
+	projectURL := fmt.Sprintf("%s/api/v4/projects/%s", strings.TrimSuffix(s.URL, "/"), url.PathEscape(n.Recipient))
+	state := gitLabDeploymentStates[status.State]
+
+	var deployments []struct {
+		ID     int64  `json:"id"`
+		SHA    string `json:"sha"`
+		Status string `json:"status"`
+	}
+	query := url.Values{"environment": {status.Environment}, "order_by": {"id"}, "sort": {"desc"}}
+	if err := doJSON(s.client, http.MethodGet, projectURL+"/deployments?"+query.Encode(), headers, nil, &deployments); err != nil {
+		return err
+	}
+	for _, deployment := range deployments {
+		if deployment.SHA != status.SHA {
+			continue
+		}
+		if deployment.Status == state {
+			return nil
+		}
+		return doJSON(s.client, http.MethodPut, fmt.Sprintf("%s/deployments/%d", projectURL, deployment.ID), headers, map[string]string{
+			"status": state,
+		}, nil)
+	}
+	return doJSON(s.client, http.MethodPost, projectURL+"/deployments", headers, map[string]interface{}{
+		"environment": status.Environment,
+		"sha":         status.SHA,
+		"ref":         s.Ref,
+		"tag":         false,
+		"status":      state,
+	}, nil)
+}
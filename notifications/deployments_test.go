@@ -0,0 +1,167 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newDeployedRollout(image string) *v1alpha1.Rollout {
+	ro := newSubscribedRollout(nil)
+	ro.Spec.Template.Spec.Containers = []corev1.Container{{Name: "guestbook", Image: image}}
+	return ro
+}
+
+func TestResolveCommitSHA(t *testing.T) {
+	assert.Equal(t, "3f9c2a1", resolveCommitSHA(newDeployedRollout("registry:5000/guestbook:3f9c2a1")))
+	assert.Equal(t, "3f9c2a1", resolveCommitSHA(newDeployedRollout("guestbook:3f9c2a1@sha256:abcdef")))
+	assert.Equal(t, "", resolveCommitSHA(newDeployedRollout("guestbook:v1.2.0")))
+	assert.Equal(t, "", resolveCommitSHA(newDeployedRollout("registry:5000/guestbook")))
+
+	ro := newDeployedRollout("guestbook:v1.2.0")
+	ro.Spec.Template.Annotations = map[string]string{CommitSHAAnnotation: "8d1e4b7"}
+	assert.Equal(t, "8d1e4b7", resolveCommitSHA(ro))
+}
+
+func TestNewDeploymentStatus(t *testing.T) {
+	ro := newDeployedRollout("guestbook:3f9c2a1")
+	payload, err := newDeploymentStatus(map[string]interface{}{
+		"Rollout":  ro,
+		"Trigger":  TriggerAborted,
+		"Body":     "Rollout default/guestbook was aborted",
+		"Analysis": []AnalysisSummary{{Name: "guestbook-abc-2", Phase: v1alpha1.AnalysisPhaseFailed}},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"sha": "3f9c2a1",
+		"environment": "default/guestbook",
+		"state": "failure",
+		"description": "Rollout default/guestbook was aborted (analysis guestbook-abc-2: Failed)"
+	}`, payload)
+
+	ro.Annotations = map[string]string{EnvironmentAnnotation: "production"}
+	payload, err = newDeploymentStatus(map[string]interface{}{"Rollout": ro, "Trigger": TriggerCompleted, "Body": strings.Repeat("a", 200)})
+	assert.NoError(t, err)
+	var status deploymentStatus
+	assert.NoError(t, json.Unmarshal([]byte(payload), &status))
+	assert.Equal(t, "production", status.Environment)
+	assert.Equal(t, deploymentStateSuccess, status.State)
+	assert.Len(t, status.Description, maxDeploymentDescription)
+
+	_, err = newDeploymentStatus(map[string]interface{}{"Rollout": newDeployedRollout("guestbook:latest"), "Trigger": TriggerCompleted})
+	assert.Error(t, err)
+}
+
+func TestGitHubService(t *testing.T) {
+	var existing string
+	var requests []string
+	var status map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token ghp-123", r.Header.Get("Authorization"))
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet:
+			assert.Equal(t, "3f9c2a1", r.URL.Query().Get("sha"))
+			assert.Equal(t, "default/guestbook", r.URL.Query().Get("environment"))
+			_, _ = w.Write([]byte(existing))
+		case r.URL.Path == "/repos/argoproj/guestbook/deployments":
+			var deployment map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&deployment))
+			assert.Equal(t, "3f9c2a1", deployment["ref"])
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 7}`))
+		default:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer ts.Close()
+	service, err := newService("github", []byte(`{"token":"ghp-123","url":"`+ts.URL+`"}`))
+	assert.NoError(t, err)
+	payload, err := service.(payloadRenderer).renderPayload(map[string]interface{}{
+		"Rollout": newDeployedRollout("guestbook:3f9c2a1"),
+		"Trigger": TriggerUpdated,
+		"Body":    "updating",
+	})
+	assert.NoError(t, err)
+	n := Notification{Service: "github", Recipient: "argoproj/guestbook", Payload: payload}
+
+	existing = `[]`
+	assert.NoError(t, service.Send(n))
+	assert.Equal(t, []string{
+		"GET /repos/argoproj/guestbook/deployments",
+		"POST /repos/argoproj/guestbook/deployments",
+		"POST /repos/argoproj/guestbook/deployments/7/statuses",
+	}, requests)
+	assert.Equal(t, deploymentStateInProgress, status["state"])
+
+	// the existing deployment of the commit is updated
+	requests = nil
+	existing = `[{"id": 5}]`
+	assert.NoError(t, service.Send(n))
+	assert.Equal(t, []string{
+		"GET /repos/argoproj/guestbook/deployments",
+		"POST /repos/argoproj/guestbook/deployments/5/statuses",
+	}, requests)
+
+	assert.True(t, IsPermanentError(service.Send(Notification{Service: "github", Recipient: "argoproj/guestbook"})))
+}
+
+func TestGitLabService(t *testing.T) {
+	var existing string
+	var requests []string
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "glpat-123", r.Header.Get("PRIVATE-TOKEN"))
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(existing))
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	service, err := newService("gitlab", []byte(`{"token":"glpat-123","url":"`+ts.URL+`","ref":"main"}`))
+	assert.NoError(t, err)
+	payload, err := service.(payloadRenderer).renderPayload(map[string]interface{}{
+		"Rollout": newDeployedRollout("guestbook:3f9c2a1"),
+		"Trigger": TriggerCompleted,
+	})
+	assert.NoError(t, err)
+	n := Notification{Service: "gitlab", Recipient: "argoproj/guestbook", Payload: payload}
+
+	existing = `[{"id": 3, "sha": "0000000", "status": "success"}]`
+	assert.NoError(t, service.Send(n))
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/argoproj%2Fguestbook/deployments",
+		"POST /api/v4/projects/argoproj%2Fguestbook/deployments",
+	}, requests)
+	assert.Equal(t, "success", body["status"])
+	assert.Equal(t, "main", body["ref"])
+
+	requests = nil
+	existing = `[{"id": 4, "sha": "3f9c2a1", "status": "running"}]`
+	assert.NoError(t, service.Send(n))
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/argoproj%2Fguestbook/deployments",
+		"PUT /api/v4/projects/argoproj%2Fguestbook/deployments/4",
+	}, requests)
+
+	// the deployment already has the state
+	requests = nil
+	existing = `[{"id": 4, "sha": "3f9c2a1", "status": "success"}]`
+	assert.NoError(t, service.Send(n))
+	assert.Len(t, requests, 1)
+
+	_, err = newService("gitlab", []byte(`{"token":"glpat-123"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package notifications
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+// SubscribeAnnotationPrefix is the prefix of the rollout annotations subscribing to a trigger.
+// The annotation notifications.argoproj.io/subscribe.<trigger>.<service> holds the semicolon
+// separated recipients notified through the service, e.g.
+// notifications.argoproj.io/subscribe.on-rollout-aborted.slack: my-channel
+const SubscribeAnnotationPrefix = "notifications.argoproj.io/subscribe."
+
+// subscription is a recipient of the notifications of a trigger
+type subscription struct {
+	service   string
+	recipient string
+}
+
+// Engine renders the templates of triggers fired by rollouts and queues them for delivery to the
+// subscribers of the rollout. The configuration is reloaded whenever the notification ConfigMap
+// or Secret changes.
+type Engine struct {
+	kubeclientset     kubernetes.Interface
+	analysisRunLister listers.AnalysisRunLister
+	namespace         string
+	deliverer         *Deliverer
+
+	lock      sync.RWMutex
+	config    *Config
+	configMap *corev1.ConfigMap
+	secret    *corev1.Secret
+}
+
+// NewEngine returns an engine reading its configuration from the namespace and sending
+// notifications with the deliverer. The AnalysisRun lister is used to summarize the analysis of
+// the rollout and may be nil.
+func NewEngine(kubeclientset kubernetes.Interface, analysisRunLister listers.AnalysisRunLister, namespace string, deliverer *Deliverer) *Engine {
+	cfg, _ := ParseConfig(nil, nil)
+	return &Engine{
+		kubeclientset:     kubeclientset,
+		analysisRunLister: analysisRunLister,
+		namespace:         namespace,
+		deliverer:         deliverer,
+		config:            cfg,
+	}
+}
+
+// AnalysisSummary describes an AnalysisRun of the rollout in the template variables
+type AnalysisSummary struct {
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Phase   v1alpha1.AnalysisPhase `json:"phase,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+// Watch starts informers on the notification ConfigMap and Secret and reloads the configuration
+// whenever they change. It does not block.
+func (e *Engine) Watch(resyncPeriod time.Duration, stopCh <-chan struct{}) {
+	restClient := e.kubeclientset.CoreV1().RESTClient()
+	watch := func(resource, name string, objType runtime.Object, update func(obj interface{})) {
+		lw := cache.NewListWatchFromClient(restClient, resource, e.namespace, fields.OneTermEqualSelector("metadata.name", name))
+		_, informer := cache.NewInformer(lw, objType, resyncPeriod, cache.ResourceEventHandlerFuncs{
+			AddFunc:    update,
+			UpdateFunc: func(old, new interface{}) { update(new) },
+			DeleteFunc: func(obj interface{}) { update(nil) },
+		})
+		go informer.Run(stopCh)
+	}
+	watch("configmaps", ConfigMapName, &corev1.ConfigMap{}, func(obj interface{}) {
+		e.lock.Lock()
+		e.configMap, _ = obj.(*corev1.ConfigMap)
+		e.lock.Unlock()
+		e.reload()
+	})
+	watch("secrets", SecretName, &corev1.Secret{}, func(obj interface{}) {
+		e.lock.Lock()
+		e.secret, _ = obj.(*corev1.Secret)
+		e.lock.Unlock()
+		e.reload()
+	})
+}
+
+// reload parses the current ConfigMap and Secret. An invalid configuration is logged and the
+// previous configuration is kept.
+func (e *Engine) reload() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	cfg, err := ParseConfig(e.configMap, e.secret)
+	if err != nil {
+		log.Errorf("Invalid notification configuration in ConfigMap '%s': %v", ConfigMapName, err)
+		return
+	}
+	e.config = cfg
+	e.deliverer.SetServices(cfg.Services)
+	log.Infof("Loaded notification configuration with %d services", len(cfg.Services))
+}
+
+func (e *Engine) getConfig() *Config {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.config
+}
+
+// Notify sends the templates of the trigger to the subscribers of the rollout
+func (e *Engine) Notify(ro *v1alpha1.Rollout, trigger, message string) {
+	subscriptions := getSubscriptions(ro, trigger)
+	if len(subscriptions) == 0 {
+		return
+	}
+	e.send(logutil.WithRollout(ro), subscriptions, trigger, e.templateVars(ro, trigger, message))
+}
+
+// NotifyDaemonSet sends the templates of the trigger to the subscribers of a DaemonSet updated
+// progressively. The templates are rendered with the DaemonSet (.DaemonSet) instead of a rollout.
+func (e *Engine) NotifyDaemonSet(ds *appsv1.DaemonSet, trigger, message string) {
+	subscriptions := getSubscriptions(ds, trigger)
+	if len(subscriptions) == 0 {
+		return
+	}
+	vars := map[string]interface{}{
+		"DaemonSet": ds,
+		"Trigger":   trigger,
+		"Message":   message,
+	}
+	e.send(logutil.WithDaemonSet(ds), subscriptions, trigger, vars)
+}
+
+// send renders the templates of the trigger and queues them for delivery to the subscriptions
+func (e *Engine) send(logCtx *log.Entry, subscriptions []subscription, trigger string, vars map[string]interface{}) {
+	cfg := e.getConfig()
+	for _, name := range cfg.Triggers[trigger] {
+		subject, body, err := cfg.Templates[name].render(vars)
+		if err != nil {
+			logCtx.Warnf("Failed to render notification template '%s': %v", name, err)
+			continue
+		}
+		for _, sub := range subscriptions {
+			n := Notification{
+				Service:   sub.service,
+				Recipient: sub.recipient,
+				Subject:   subject,
+				Body:      body,
+			}
+			if renderer, ok := cfg.Services[sub.service].(payloadRenderer); ok {
+				payloadVars := map[string]interface{}{
+					"Subject":   subject,
+					"Body":      body,
+					"Recipient": sub.recipient,
+				}
+				for k, v := range vars {
+					payloadVars[k] = v
+				}
+				if n.Payload, err = renderer.renderPayload(payloadVars); err != nil {
+					logCtx.Warnf("Failed to render the payload of service '%s': %v", sub.service, err)
+					continue
+				}
+			}
+			logCtx.Infof("Sending notification '%s' to %s", name, sub.service)
+			e.deliverer.Deliver(n)
+		}
+	}
+}
+
+// templateVars returns the variables templates are rendered with
+func (e *Engine) templateVars(ro *v1alpha1.Rollout, trigger, message string) map[string]interface{} {
+	var step interface{}
+	if ro.Status.CurrentStepIndex != nil {
+		step = *ro.Status.CurrentStepIndex
+	}
+	return map[string]interface{}{
+		"Rollout":  ro,
+		"Trigger":  trigger,
+		"Message":  message,
+		"Revision": ro.Annotations[annotations.RevisionAnnotation],
+		"Step":     step,
+		"Analysis": e.analysisSummary(ro),
+	}
+}
+
+// analysisSummary returns the AnalysisRuns referenced by the rollout status
+func (e *Engine) analysisSummary(ro *v1alpha1.Rollout) []AnalysisSummary {
+	runs := []AnalysisSummary{
+		{Name: ro.Status.Canary.CurrentStepAnalysisRun, Type: v1alpha1.RolloutTypeStepLabel},
+		{Name: ro.Status.Canary.CurrentBackgroundAnalysisRun, Type: v1alpha1.RolloutTypeBackgroundRunLabel},
+		{Name: ro.Status.BlueGreen.PrePromotionAnalysisRun, Type: v1alpha1.RolloutTypePrePromotionLabel},
+		{Name: ro.Status.BlueGreen.PostPromotionAnalysisRun, Type: v1alpha1.RolloutTypePostPromotionLabel},
+	}
+	summary := []AnalysisSummary{}
+	for _, run := range runs {
+		if run.Name == "" {
+			continue
+		}
+		if e.analysisRunLister != nil {
+			if ar, err := e.analysisRunLister.AnalysisRuns(ro.Namespace).Get(run.Name); err == nil {
+				run.Phase = ar.Status.Phase
+				run.Message = ar.Status.Message
+			}
+		}
+		summary = append(summary, run)
+	}
+	return summary
+}
+
+// getSubscriptions returns the recipients subscribed to the trigger in the annotations of the
+// rollout or DaemonSet
+func getSubscriptions(obj metav1.Object, trigger string) []subscription {
+	prefix := fmt.Sprintf("%s%s.", SubscribeAnnotationPrefix, trigger)
+	var subscriptions []subscription
+	for key, value := range obj.GetAnnotations() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		service := strings.TrimPrefix(key, prefix)
+		recipients := strings.Split(value, ";")
+		for _, recipient := range recipients {
+			recipient = strings.TrimSpace(recipient)
+			if recipient == "" && len(recipients) > 1 {
+				continue
+			}
+			subscriptions = append(subscriptions, subscription{service: service, recipient: recipient})
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].service != subscriptions[j].service {
+			return subscriptions[i].service < subscriptions[j].service
+		}
+		return subscriptions[i].recipient < subscriptions[j].recipient
+	})
+	return subscriptions
+}
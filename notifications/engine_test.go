@@ -0,0 +1,167 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+func newSubscribedRollout(annotations map[string]string) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "guestbook",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+// queued returns the notifications waiting in the delivery queue
+func queued(d *Deliverer) []Notification {
+	var notifications []Notification
+	for d.queue.Len() > 0 {
+		item, _ := d.queue.Get()
+		notifications = append(notifications, item.(Notification))
+		d.queue.Done(item)
+		d.queue.Forget(item)
+	}
+	return notifications
+}
+
+func TestGetSubscriptions(t *testing.T) {
+	ro := newSubscribedRollout(map[string]string{
+		SubscribeAnnotationPrefix + TriggerAborted + ".slack":       "rollouts; oncall",
+		SubscribeAnnotationPrefix + TriggerAborted + ".webhook.ops": "",
+		SubscribeAnnotationPrefix + TriggerCompleted + ".slack":     "rollouts",
+	})
+	assert.Equal(t, []subscription{
+		{service: "slack", recipient: "oncall"},
+		{service: "slack", recipient: "rollouts"},
+		{service: "webhook.ops", recipient: ""},
+	}, getSubscriptions(ro, TriggerAborted))
+	assert.Empty(t, getSubscriptions(ro, TriggerPaused))
+}
+
+func TestNotify(t *testing.T) {
+	d := newTestDeliverer(nil)
+	e := NewEngine(nil, nil, "argo-rollouts", d)
+	ro := newSubscribedRollout(map[string]string{
+		SubscribeAnnotationPrefix + TriggerAborted + ".slack": "rollouts",
+	})
+	e.Notify(ro, TriggerAborted, "AnalysisRun 'guestbook-1' completed with phase 'Failed'")
+	e.Notify(ro, TriggerCompleted, "")
+	assert.Equal(t, []Notification{{
+		Service:   "slack",
+		Recipient: "rollouts",
+		Body:      "Rollout default/guestbook was aborted: AnalysisRun 'guestbook-1' completed with phase 'Failed'",
+	}}, queued(d))
+}
+
+func TestNotifyWebhookPayload(t *testing.T) {
+	d := newTestDeliverer(nil)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(&v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook-abc-2", Namespace: "default"},
+		Status:     v1alpha1.AnalysisRunStatus{Phase: v1alpha1.AnalysisPhaseFailed, Message: "metric 'error-rate' failed"},
+	}))
+	e := NewEngine(nil, listers.NewAnalysisRunLister(indexer), "argo-rollouts", d)
+	e.configMap = newConfigMap(map[string]string{
+		"service.webhook.changes": `
+url: https://changes.example.com
+body: |
+  {"rollout": {{json .Rollout.Name}}, "revision": {{json .Revision}}, "step": {{json .Step}}, "analysis": {{json .Analysis}}, "message": {{json .Body}}}
+`,
+	})
+	e.reload()
+	ro := newSubscribedRollout(map[string]string{
+		SubscribeAnnotationPrefix + TriggerAborted + ".webhook.changes": "",
+		annotations.RevisionAnnotation:                                  "3",
+	})
+	step := int32(1)
+	ro.Status.CurrentStepIndex = &step
+	ro.Status.Canary.CurrentStepAnalysisRun = "guestbook-abc-2"
+	e.Notify(ro, TriggerAborted, "analysis failed")
+
+	notifications := queued(d)
+	assert.Len(t, notifications, 1)
+	assert.JSONEq(t, `{
+		"rollout": "guestbook",
+		"revision": "3",
+		"step": 1,
+		"analysis": [{"name": "guestbook-abc-2", "type": "Step", "phase": "Failed", "message": "metric 'error-rate' failed"}],
+		"message": "Rollout default/guestbook was aborted: analysis failed"
+	}`, notifications[0].Payload)
+}
+
+func TestReload(t *testing.T) {
+	d := newTestDeliverer(nil)
+	e := NewEngine(nil, nil, "argo-rollouts", d)
+	e.configMap = newConfigMap(map[string]string{
+		"service.slack":              "token: $slack-token",
+		"template.custom":            "message: \"custom {{.Rollout.Name}}\"",
+		"trigger.on-rollout-aborted": "[custom]",
+	})
+	e.secret = newSecret(map[string]string{"slack-token": "xoxb-123"})
+	e.reload()
+	_, ok := d.getService("slack")
+	assert.True(t, ok)
+
+	// an invalid configuration keeps the previous one
+	e.configMap = newConfigMap(map[string]string{"trigger.on-rollout-aborted": "[missing]"})
+	e.reload()
+	assert.Equal(t, []string{"custom"}, e.getConfig().Triggers[TriggerAborted])
+}
+
+func TestRecorder(t *testing.T) {
+	d := newTestDeliverer(nil)
+	e := NewEngine(nil, nil, "argo-rollouts", d)
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewRecorder(fakeRecorder, e)
+	ro := newSubscribedRollout(map[string]string{
+		SubscribeAnnotationPrefix + TriggerStepCompleted + ".slack": "rollouts",
+		SubscribeAnnotationPrefix + TriggerPromoted + ".slack":      "rollouts",
+	})
+	recorder.Eventf(ro, corev1.EventTypeNormal, "SetStepIndex", "Set Step Index to %d", 2)
+	recorder.Event(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set guestbook-abc to 2")
+	recorder.Event(&v1alpha1.Experiment{}, corev1.EventTypeNormal, "SetStepIndex", "Set Step Index to 1")
+	recorder.Eventf(ro, corev1.EventTypeNormal, "RolloutPromoted", "Rollout promoted to step %d", 3)
+	assert.Len(t, fakeRecorder.Events, 4)
+	notifications := queued(d)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, "Rollout default/guestbook completed a step: Set Step Index to 2", notifications[0].Body)
+	assert.Equal(t, "Rollout default/guestbook was promoted: Rollout promoted to step 3", notifications[1].Body)
+}
+
+func TestRecorderDaemonSet(t *testing.T) {
+	d := newTestDeliverer(nil)
+	e := NewEngine(nil, nil, "argo-rollouts", d)
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewRecorder(fakeRecorder, e)
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node-agent",
+			Namespace: "kube-system",
+			Annotations: map[string]string{
+				SubscribeAnnotationPrefix + TriggerDaemonSetAborted + ".slack": "agents",
+				SubscribeAnnotationPrefix + TriggerAborted + ".slack":          "agents",
+			},
+		},
+	}
+	recorder.Event(ds, corev1.EventTypeWarning, "DaemonSetAborted", "AnalysisRun 'node-agent-abc-1' completed as Failed")
+	// the triggers of rollouts are not fired for DaemonSets
+	recorder.Event(ds, corev1.EventTypeNormal, "RolloutAborted", "")
+	assert.Equal(t, []Notification{{
+		Service:   "slack",
+		Recipient: "agents",
+		Body:      "Update of DaemonSet kube-system/node-agent was aborted: AnalysisRun 'node-agent-abc-1' completed as Failed",
+	}}, queued(d))
+}
@@ -0,0 +1,211 @@
+package notifications
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// QueueConfigMapName is the name of the ConfigMap, in the controller's namespace, holding the
+	// notifications which are not delivered yet, so they are sent after the controller restarts
+	QueueConfigMapName = "argo-rollouts-notification-queue"
+	// DefaultMaxPersistedNotifications is the number of pending notifications the queue ConfigMap
+	// holds at most, which keeps it below the size limit of ConfigMaps
+	DefaultMaxPersistedNotifications = 100
+)
+
+// queueStore persists the pending notifications in the queue ConfigMap, keyed by a hash of the
+// notification so a notification queued twice is stored once. Queued and removed notifications
+// are recorded in memory and written to the ConfigMap in batches by flush, so queueing a
+// notification does not wait for the API server.
+type queueStore struct {
+	kubeclientset kubernetes.Interface
+	namespace     string
+	maxEntries    int
+
+	lock sync.Mutex
+	// changes holds the notifications to store, and an empty value for the ones to remove, which
+	// are not written to the ConfigMap yet
+	changes map[string]string
+}
+
+func newQueueStore(kubeclientset kubernetes.Interface, namespace string) *queueStore {
+	return &queueStore{
+		kubeclientset: kubeclientset,
+		namespace:     namespace,
+		maxEntries:    DefaultMaxPersistedNotifications,
+		changes:       map[string]string{},
+	}
+}
+
+// notificationKey returns the key of the notification in the queue ConfigMap
+func notificationKey(n Notification) (string, []byte, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), data, nil
+}
+
+// add stores the notification, on the next flush, until it is removed
+func (s *queueStore) add(n Notification) error {
+	key, data, err := notificationKey(n)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.changes[key] = string(data)
+	return nil
+}
+
+// remove deletes a notification which was delivered or dropped on the next flush
+func (s *queueStore) remove(n Notification) error {
+	key, _, err := notificationKey(n)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.changes[key] = ""
+	return nil
+}
+
+// flush writes the notifications added and removed since the last flush to the queue ConfigMap.
+// Notifications added once the ConfigMap holds maxEntries notifications are not stored. The changes
+// are kept for the next flush if the ConfigMap cannot be written.
+func (s *queueStore) flush() error {
+	s.lock.Lock()
+	changes := s.changes
+	s.changes = map[string]string{}
+	s.lock.Unlock()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var skipped int
+	err := s.update(func(pending map[string]string) bool {
+		modified := false
+		skipped = 0
+		for key, data := range changes {
+			if data == "" {
+				if _, ok := pending[key]; ok {
+					delete(pending, key)
+					modified = true
+				}
+				continue
+			}
+			if _, ok := pending[key]; !ok && len(pending) >= s.maxEntries {
+				skipped++
+				continue
+			}
+			if pending[key] != data {
+				pending[key] = data
+				modified = true
+			}
+		}
+		return modified
+	})
+	if err != nil {
+		s.lock.Lock()
+		// changes recorded during the flush are newer than the ones which failed
+		for key, data := range changes {
+			if _, ok := s.changes[key]; !ok {
+				s.changes[key] = data
+			}
+		}
+		s.lock.Unlock()
+		return err
+	}
+	if skipped > 0 {
+		log.Warnf("ConfigMap '%s' holds %d notifications, %d notifications are not persisted", QueueConfigMapName, s.maxEntries, skipped)
+	}
+	return nil
+}
+
+// list returns the stored notifications, ordered by key so they are queued deterministically.
+// Entries which cannot be parsed are skipped.
+func (s *queueStore) list() ([]Notification, error) {
+	cm, err := s.kubeclientset.CoreV1().ConfigMaps(s.namespace).Get(QueueConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(cm.Data))
+	for key := range cm.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var notifications []Notification
+	for _, key := range keys {
+		var n Notification
+		if err := json.Unmarshal([]byte(cm.Data[key]), &n); err != nil {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+// update applies the change to the data of the queue ConfigMap, creating it if needed. The change
+// returns false if it did not modify the data. The change is applied again when the ConfigMap was
+// modified or created by another writer in the meantime.
+func (s *queueStore) update(change func(pending map[string]string) bool) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(retry.DefaultRetry, func() (bool, error) {
+		lastErr = s.tryUpdate(change)
+		switch {
+		case lastErr == nil:
+			return true, nil
+		case k8serrors.IsConflict(lastErr) || k8serrors.IsAlreadyExists(lastErr):
+			return false, nil
+		default:
+			return false, lastErr
+		}
+	})
+	if err == wait.ErrWaitTimeout {
+		err = lastErr
+	}
+	return err
+}
+
+func (s *queueStore) tryUpdate(change func(pending map[string]string) bool) error {
+	configMaps := s.kubeclientset.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(QueueConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		pending := map[string]string{}
+		if !change(pending) {
+			return nil
+		}
+		_, err = configMaps.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: QueueConfigMapName, Namespace: s.namespace},
+			Data:       pending,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	if !change(cm.Data) {
+		return nil
+	}
+	_, err = configMaps.Update(cm)
+	return err
+}
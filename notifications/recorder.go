@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// eventTriggers maps the reasons of rollout events to the trigger they fire
+var eventTriggers = map[string]string{
+	"RolloutUpdated":    TriggerUpdated,
+	"SetStepIndex":      TriggerStepCompleted,
+	"RolloutPaused":     TriggerPaused,
+	"RolloutPromoted":   TriggerPromoted,
+	"AnalysisRunFailed": TriggerAnalysisFailed,
+	"AnalysisRunError":  TriggerAnalysisFailed,
+	"RolloutAborted":    TriggerAborted,
+	"RolloutCompleted":  TriggerCompleted,
+	"RolloutStuck":      TriggerStuck,
+}
+
+// daemonSetEventTriggers maps the reasons of the events of DaemonSets updated progressively to the
+// trigger they fire
+var daemonSetEventTriggers = map[string]string{
+	"DaemonSetUpdated":       TriggerDaemonSetUpdated,
+	"DaemonSetStepCompleted": TriggerDaemonSetStepCompleted,
+	"DaemonSetAborted":       TriggerDaemonSetAborted,
+	"DaemonSetCompleted":     TriggerDaemonSetCompleted,
+}
+
+// Recorder wraps an EventRecorder and fires the trigger matching the reason of each rollout event
+type Recorder struct {
+	record.EventRecorder
+	engine *Engine
+}
+
+// NewRecorder returns a recorder sending the notifications of rollout events with the engine
+func NewRecorder(recorder record.EventRecorder, engine *Engine) *Recorder {
+	return &Recorder{
+		EventRecorder: recorder,
+		engine:        engine,
+	}
+}
+
+// Event records the event and notifies the subscribers of the trigger it fires
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, reason, message)
+	switch obj := object.(type) {
+	case *v1alpha1.Rollout:
+		if trigger, ok := eventTriggers[reason]; ok {
+			r.engine.Notify(obj, trigger, message)
+		}
+	case *appsv1.DaemonSet:
+		if trigger, ok := daemonSetEventTriggers[reason]; ok {
+			r.engine.NotifyDaemonSet(obj, trigger, message)
+		}
+	}
+}
+
+// Eventf records the formatted event and notifies the subscribers of the trigger it fires
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
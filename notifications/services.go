@@ -0,0 +1,387 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// SlackServiceType sends notifications as Slack messages
+	SlackServiceType = "slack"
+	// EmailServiceType sends notifications by email over SMTP
+	EmailServiceType = "email"
+	// WebhookServiceType sends notifications as HTTP requests
+	WebhookServiceType = "webhook"
+	// TeamsServiceType sends notifications as adaptive cards to Microsoft Teams incoming webhooks
+	TeamsServiceType = "teams"
+	// GoogleChatServiceType sends notifications as Google Chat webhook messages
+	GoogleChatServiceType = "googlechat"
+
+	// DefaultSignatureHeader is the header holding the HMAC signature of signed webhook requests
+	DefaultSignatureHeader = "X-Rollouts-Signature"
+
+	defaultSlackURL = "https://slack.com/api/chat.postMessage"
+	requestTimeout  = 30 * time.Second
+)
+
+// newService returns the service for the settings of the service.<name> key. The type of the
+// service is the part of the name before the first dot, so several webhooks can be configured
+// (e.g. webhook.ops, webhook.audit).
+func newService(name string, opts []byte) (Service, error) {
+	serviceType := strings.SplitN(name, ".", 2)[0]
+	client := &http.Client{Timeout: requestTimeout}
+	switch serviceType {
+	case SlackServiceType:
+		var s slackService
+		if err := json.Unmarshal(opts, &s); err != nil {
+			return nil, err
+		}
+		if s.Token == "" {
+			return nil, fmt.Errorf("token is required")
+		}
+		if s.URL == "" {
+			s.URL = defaultSlackURL
+		}
+		s.client = client
+		return &s, nil
+	case EmailServiceType:
+		var s emailService
+		if err := json.Unmarshal(opts, &s); err != nil {
+			return nil, err
+		}
+		if s.Host == "" || s.From == "" {
+			return nil, fmt.Errorf("host and from are required")
+		}
+		if s.Port == 0 {
+			s.Port = 587
+		}
+		s.sendMail = smtp.SendMail
+		return &s, nil
+	case WebhookServiceType:
+		var s webhookService
+		if err := json.Unmarshal(opts, &s); err != nil {
+			return nil, err
+		}
+		if s.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		if s.Method == "" {
+			s.Method = http.MethodPost
+		}
+		if s.SignatureHeader == "" {
+			s.SignatureHeader = DefaultSignatureHeader
+		}
+		if s.Body != "" {
+			var err error
+			if s.bodyTemplate, err = template.New(name).Funcs(templateFuncs).Parse(s.Body); err != nil {
+				return nil, fmt.Errorf("invalid body: %v", err)
+			}
+		}
+		s.client = client
+		return &s, nil
+	case GitHubServiceType:
+		var s gitHubService
+		if err := json.Unmarshal(opts, &s); err != nil {
+			return nil, err
+		}
+		if s.Token == "" {
+			return nil, fmt.Errorf("token is required")
+		}
+		if s.URL == "" {
+			s.URL = defaultGitHubURL
+		}
+		s.client = client
+		return &s, nil
+	case GitLabServiceType:
+		var s gitLabService
+		if err := json.Unmarshal(opts, &s); err != nil {
+			return nil, err
+		}
+		if s.Token == "" || s.Ref == "" {
+			return nil, fmt.Errorf("token and ref are required")
+		}
+		if s.URL == "" {
+			s.URL = defaultGitLabURL
+		}
+		s.client = client
+		return &s, nil
+	case TeamsServiceType:
+		var s teamsService
+		if err := json.Unmarshal(opts, &s.chatWebhooks); err != nil {
+			return nil, err
+		}
+		if len(s.Webhooks) == 0 {
+			return nil, fmt.Errorf("webhooks are required")
+		}
+		s.client = client
+		return &s, nil
+	case GoogleChatServiceType:
+		var s googleChatService
+		if err := json.Unmarshal(opts, &s.chatWebhooks); err != nil {
+			return nil, err
+		}
+		if len(s.Webhooks) == 0 {
+			return nil, fmt.Errorf("webhooks are required")
+		}
+		s.client = client
+		return &s, nil
+	}
+	return nil, fmt.Errorf("unknown service type '%s'", serviceType)
+}
+
+// checkResponse returns an error for unsuccessful responses. Client errors other than rate
+// limiting are permanent since resending the same request will fail the same way.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	err := fmt.Errorf("received status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return NewPermanentError(err)
+	}
+	return err
+}
+
+// slackService posts notifications to the channel of the recipient with the chat.postMessage API
+type slackService struct {
+	Token string `json:"token"`
+	// URL overrides the Slack API endpoint (e.g. for a proxy)
+	URL string `json:"url,omitempty"`
+
+	client *http.Client
+}
+
+func (s *slackService) Send(n Notification) error {
+	payload, err := json.Marshal(map[string]string{
+		"channel": n.Recipient,
+		"text":    n.Body,
+	})
+	if err != nil {
+		return NewPermanentError(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return NewPermanentError(err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	// the Slack API reports most failures with a successful status code
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		err := fmt.Errorf("slack error: %s", result.Error)
+		if result.Error == "ratelimited" || result.Error == "internal_error" || result.Error == "service_unavailable" {
+			return err
+		}
+		return NewPermanentError(err)
+	}
+	return nil
+}
+
+// emailService sends notifications to the address of the recipient over SMTP
+type emailService struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	From     string `json:"from"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (s *emailService) Send(n Notification) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	subject := n.Subject
+	if subject == "" {
+		subject = "Argo Rollouts notification"
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.From, n.Recipient, subject, n.Body)
+	err := s.sendMail(net.JoinHostPort(s.Host, strconv.Itoa(s.Port)), auth, s.From, []string{n.Recipient}, []byte(msg))
+	if protoErr, ok := err.(*textproto.Error); ok && protoErr.Code >= 500 {
+		// permanent SMTP failures, such as an unknown recipient
+		return NewPermanentError(err)
+	}
+	return err
+}
+
+// webhookService sends notifications as JSON to an HTTP endpoint. The request body is either
+// rendered from the body template or holds the recipient, subject and message of the notification.
+type webhookService struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is a Go template of the JSON request body, rendered with the template variables
+	Body string `json:"body,omitempty"`
+	// SigningSecret signs the request body with HMAC-SHA256 so the receiver can verify the sender
+	SigningSecret string `json:"signingSecret,omitempty"`
+	// SignatureHeader is the header holding the signature, formatted as sha256=<hex digest>
+	SignatureHeader string `json:"signatureHeader,omitempty"`
+
+	bodyTemplate *template.Template
+	client       *http.Client
+}
+
+func (s *webhookService) renderPayload(vars map[string]interface{}) (string, error) {
+	if s.bodyTemplate == nil {
+		return "", nil
+	}
+	var body strings.Builder
+	if err := s.bodyTemplate.Execute(&body, vars); err != nil {
+		return "", err
+	}
+	if !json.Valid([]byte(body.String())) {
+		return "", fmt.Errorf("rendered body is not valid JSON")
+	}
+	return body.String(), nil
+}
+
+func (s *webhookService) Send(n Notification) error {
+	payload := []byte(n.Payload)
+	if n.Payload == "" {
+		var err error
+		payload, err = json.Marshal(map[string]string{
+			"recipient": n.Recipient,
+			"subject":   n.Subject,
+			"message":   n.Body,
+		})
+		if err != nil {
+			return NewPermanentError(err)
+		}
+	}
+	headers := map[string]string{}
+	for k, v := range s.Headers {
+		headers[k] = v
+	}
+	if s.SigningSecret != "" {
+		headers[s.SignatureHeader] = "sha256=" + sign(s.SigningSecret, payload)
+	}
+	return post(s.client, s.Method, s.URL, headers, payload)
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the payload
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON posts the payload as JSON to the URL and checks the response
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return NewPermanentError(err)
+	}
+	return post(client, http.MethodPost, url, nil, data)
+}
+
+// post sends the JSON data to the URL and checks the response
+func post(client *http.Client, method, url string, headers map[string]string, data []byte) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return NewPermanentError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// chatWebhooks holds the incoming webhook URLs of chat services keyed by recipient. Incoming
+// webhook URLs embed their credentials, so they are usually referenced from the secret.
+type chatWebhooks struct {
+	Webhooks map[string]string `json:"webhooks"`
+
+	client *http.Client
+}
+
+// webhookURL returns the incoming webhook of the recipient
+func (c *chatWebhooks) webhookURL(recipient string) (string, error) {
+	url, ok := c.Webhooks[recipient]
+	if !ok {
+		return "", NewPermanentError(fmt.Errorf("no webhook configured for recipient '%s'", recipient))
+	}
+	return url, nil
+}
+
+// teamsService posts notifications as adaptive cards to the Microsoft Teams channel of the recipient
+type teamsService struct {
+	chatWebhooks
+}
+
+func (s *teamsService) Send(n Notification) error {
+	url, err := s.webhookURL(n.Recipient)
+	if err != nil {
+		return err
+	}
+	var body []map[string]interface{}
+	if n.Subject != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": n.Subject, "weight": "bolder", "size": "medium", "wrap": true})
+	}
+	body = append(body, map[string]interface{}{"type": "TextBlock", "text": n.Body, "wrap": true})
+	return postJSON(s.client, url, map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.2",
+				"body":    body,
+			},
+		}},
+	})
+}
+
+// googleChatService posts notifications to the Google Chat space of the recipient
+type googleChatService struct {
+	chatWebhooks
+}
+
+func (s *googleChatService) Send(n Notification) error {
+	url, err := s.webhookURL(n.Recipient)
+	if err != nil {
+		return err
+	}
+	text := n.Body
+	if n.Subject != "" {
+		text = fmt.Sprintf("*%s*\n%s", n.Subject, n.Body)
+	}
+	return postJSON(s.client, url, map[string]string{"text": text})
+}
@@ -0,0 +1,180 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackService(t *testing.T) {
+	var received map[string]string
+	response := `{"ok":true}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-123", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(response))
+	}))
+	defer ts.Close()
+	service, err := newService("slack", []byte(`{"token":"xoxb-123","url":"`+ts.URL+`"}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Send(Notification{Service: "slack", Recipient: "rollouts", Body: "aborted"}))
+	assert.Equal(t, map[string]string{"channel": "rollouts", "text": "aborted"}, received)
+
+	response = `{"ok":false,"error":"channel_not_found"}`
+	err = service.Send(Notification{Service: "slack", Recipient: "missing", Body: "aborted"})
+	assert.True(t, IsPermanentError(err))
+
+	response = `{"ok":false,"error":"ratelimited"}`
+	err = service.Send(Notification{Service: "slack", Recipient: "rollouts", Body: "aborted"})
+	assert.Error(t, err)
+	assert.False(t, IsPermanentError(err))
+}
+
+func TestWebhookService(t *testing.T) {
+	status := http.StatusOK
+	var received map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "456", r.Header.Get("X-Token"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	service, err := newService("webhook.ops", []byte(`{"url":"`+ts.URL+`","headers":{"X-Token":"456"}}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Send(Notification{Service: "webhook.ops", Subject: "aborted", Body: "guestbook aborted"}))
+	assert.Equal(t, "guestbook aborted", received["message"])
+
+	status = http.StatusServiceUnavailable
+	err = service.Send(Notification{Service: "webhook.ops", Body: "guestbook aborted"})
+	assert.Error(t, err)
+	assert.False(t, IsPermanentError(err))
+
+	status = http.StatusBadRequest
+	assert.True(t, IsPermanentError(service.Send(Notification{Service: "webhook.ops", Body: "guestbook aborted"})))
+}
+
+func TestWebhookServiceTemplatedBody(t *testing.T) {
+	var body []byte
+	var signature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		body, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(DefaultSignatureHeader)
+	}))
+	defer ts.Close()
+	service, err := newService("webhook.changes", []byte(`{"url":"`+ts.URL+`","method":"PUT","signingSecret":"hmac-key","body":"{\"rollout\": {{json .Rollout}}}"}`))
+	assert.NoError(t, err)
+	webhook := service.(*webhookService)
+
+	payload, err := webhook.renderPayload(map[string]interface{}{"Rollout": "guestbook"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"rollout": "guestbook"}`, payload)
+	assert.NoError(t, service.Send(Notification{Service: "webhook.changes", Payload: payload}))
+	assert.Equal(t, payload, string(body))
+	mac := hmac.New(sha256.New, []byte("hmac-key"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+
+	service, err = newService("webhook.changes", []byte(`{"url":"`+ts.URL+`","body":"{\"rollout\": {{.Rollout}}}"}`))
+	assert.NoError(t, err)
+	_, err = service.(*webhookService).renderPayload(map[string]interface{}{"Rollout": "guestbook"})
+	assert.EqualError(t, err, "rendered body is not valid JSON")
+
+	_, err = newService("webhook.changes", []byte(`{"url":"`+ts.URL+`","body":"{{.Rollout"}`))
+	assert.Error(t, err)
+}
+
+func TestEmailService(t *testing.T) {
+	service, err := newService("email", []byte(`{"host":"smtp.example.com","from":"rollouts@example.com","username":"rollouts","password":"secret"}`))
+	assert.NoError(t, err)
+	email := service.(*emailService)
+	var sentTo []string
+	var sentMsg string
+	email.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.NotNil(t, a)
+		sentTo = to
+		sentMsg = string(msg)
+		return nil
+	}
+	assert.NoError(t, service.Send(Notification{Service: "email", Recipient: "team@example.com", Subject: "guestbook aborted", Body: "analysis failed"}))
+	assert.Equal(t, []string{"team@example.com"}, sentTo)
+	assert.Contains(t, sentMsg, "Subject: guestbook aborted\r\n")
+	assert.Contains(t, sentMsg, "analysis failed")
+
+	email.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		return &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+	}
+	assert.True(t, IsPermanentError(service.Send(Notification{Service: "email", Recipient: "missing@example.com"})))
+
+	email.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+	err = service.Send(Notification{Service: "email", Recipient: "team@example.com"})
+	assert.Error(t, err)
+	assert.False(t, IsPermanentError(err))
+}
+
+func TestTeamsService(t *testing.T) {
+	var received map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/channel", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer ts.Close()
+	service, err := newService("teams", []byte(`{"webhooks":{"rollouts":"`+ts.URL+`/channel"}}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Send(Notification{Service: "teams", Recipient: "rollouts", Subject: "guestbook aborted", Body: "analysis failed"}))
+	attachments := received["attachments"].([]interface{})
+	assert.Len(t, attachments, 1)
+	attachment := attachments[0].(map[string]interface{})
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", attachment["contentType"])
+	body := attachment["content"].(map[string]interface{})["body"].([]interface{})
+	assert.Len(t, body, 2)
+	assert.Equal(t, "guestbook aborted", body[0].(map[string]interface{})["text"])
+	assert.Equal(t, "analysis failed", body[1].(map[string]interface{})["text"])
+
+	assert.True(t, IsPermanentError(service.Send(Notification{Service: "teams", Recipient: "missing", Body: "aborted"})))
+
+	_, err = newService("teams", []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestGoogleChatService(t *testing.T) {
+	status := http.StatusOK
+	var received map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	service, err := newService("googlechat", []byte(`{"webhooks":{"rollouts":"`+ts.URL+`"}}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Send(Notification{Service: "googlechat", Recipient: "rollouts", Body: "guestbook aborted"}))
+	assert.Equal(t, map[string]string{"text": "guestbook aborted"}, received)
+
+	assert.NoError(t, service.Send(Notification{Service: "googlechat", Recipient: "rollouts", Subject: "Aborted", Body: "guestbook aborted"}))
+	assert.Equal(t, map[string]string{"text": "*Aborted*\nguestbook aborted"}, received)
+
+	status = http.StatusTooManyRequests
+	err = service.Send(Notification{Service: "googlechat", Recipient: "rollouts", Body: "guestbook aborted"})
+	assert.Error(t, err)
+	assert.False(t, IsPermanentError(err))
+
+	assert.True(t, IsPermanentError(service.Send(Notification{Service: "googlechat", Recipient: "missing", Body: "aborted"})))
+}
@@ -26,6 +26,27 @@ type AnalysisTemplateList struct {
 	Items           []AnalysisTemplate `json:"items"`
 }
 
+// ClusterAnalysisTemplate holds the template for performing canary analysis that can be
+// referenced by rollouts and experiments of every namespace
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=clusteranalysistemplates,shortName=cat,scope=Cluster
+type ClusterAnalysisTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AnalysisTemplateSpec `json:"spec"`
+}
+
+// ClusterAnalysisTemplateList is a list of ClusterAnalysisTemplate resources
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterAnalysisTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ClusterAnalysisTemplate `json:"items"`
+}
+
 // AnalysisTemplateSpec is the specification for a AnalysisTemplate resource
 type AnalysisTemplateSpec struct {
 	// Metrics contains the list of metrics to query as part of an analysis run
@@ -80,6 +101,12 @@ type Metric struct {
 	// ConsecutiveErrorLimit is the maximum number of times the measurement is allowed to error in
 	// succession, before the metric is considered error (default: 4)
 	ConsecutiveErrorLimit *int32 `json:"consecutiveErrorLimit,omitempty"`
+	// RetryPolicy configures the backoff between the retries of measurements which errored, and
+	// how many times resuming an in-flight measurement is attempted before it errors
+	RetryPolicy *MetricRetryPolicy `json:"retryPolicy,omitempty"`
+	// DryRun records the measurements of the metric without letting its result fail the AnalysisRun,
+	// so new queries can be trialed before they gate rollouts (default: false)
+	DryRun bool `json:"dryRun,omitempty"`
 	// Provider configuration to the external system to use to verify the analysis
 	Provider MetricProvider `json:"provider"`
 }
@@ -99,6 +126,19 @@ func (m *Metric) EffectiveCount() *int32 {
 	return &m.Count
 }
 
+// MetricRetryPolicy defines an exponential backoff between the attempts of a measurement which
+// errored. The n-th retry waits InitialInterval * Multiplier^(n-1).
+type MetricRetryPolicy struct {
+	// InitialInterval is the delay before the first retry (default: 10s)
+	InitialInterval DurationString `json:"initialInterval,omitempty"`
+	// Multiplier is the factor the delay grows by with every retry. A multiplier of 1 retries at a
+	// constant interval (default: 2)
+	Multiplier int32 `json:"multiplier,omitempty"`
+	// MaxAttempts is the number of times resuming an in-flight measurement is attempted, including
+	// the first attempt, before the measurement is considered Error (default: 3)
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+}
+
 // MetricProvider which external system to use to verify the analysis
 // Only one of the fields in this struct should be non-nil
 type MetricProvider struct {
@@ -110,6 +150,16 @@ type MetricProvider struct {
 	Wavefront *WavefrontMetric `json:"wavefront,omitempty"`
 	// Job specifies the job metric run
 	Job *JobMetric `json:"job,omitempty"`
+	// CloudEvent requests an evaluation from an external quality gate with a CloudEvent
+	CloudEvent *CloudEventMetric `json:"cloudEvent,omitempty"`
+	// Datadog specifies the datadog metric to query
+	Datadog *DatadogMetric `json:"datadog,omitempty"`
+	// Graphite specifies the graphite metric to query
+	Graphite *GraphiteMetric `json:"graphite,omitempty"`
+	// Influxdb specifies the influxdb flux query to perform
+	Influxdb *InfluxdbMetric `json:"influxdb,omitempty"`
+	// Plugin measures the metric with a metric provider plugin declared in the controller configuration
+	Plugin *PluginMetric `json:"plugin,omitempty"`
 }
 
 // AnalysisPhase is the overall phase of an AnalysisRun, MetricResult, or Measurement
@@ -140,6 +190,52 @@ type PrometheusMetric struct {
 	Address string `json:"address,omitempty"`
 	// Query is a raw prometheus query to perform
 	Query string `json:"query,omitempty"`
+	// Range evaluates the query over a time range instead of at the time of the measurement
+	Range *PrometheusRange `json:"range,omitempty"`
+	// Authentication configures the credentials sent to the prometheus server
+	Authentication *PrometheusAuthentication `json:"authentication,omitempty"`
+	// TLS configures the verification of the certificate of the prometheus server
+	TLS *PrometheusTLSConfig `json:"tls,omitempty"`
+}
+
+// PrometheusRange defines a range query, whose samples are reduced to a single value per series
+type PrometheusRange struct {
+	// Duration is the time range ending at the time of the measurement the query is evaluated over (e.g. 10m)
+	Duration DurationString `json:"duration"`
+	// Step is the resolution of the query. Defaults to 1m
+	Step DurationString `json:"step,omitempty"`
+	// Aggregator reduces the samples of each series to a value. One of avg, min, max, last or sum.
+	// Defaults to avg
+	Aggregator string `json:"aggregator,omitempty"`
+}
+
+// PrometheusAuthentication defines the credentials sent to the prometheus server. The referenced
+// secrets are read from the namespace of the controller
+type PrometheusAuthentication struct {
+	// BearerTokenSecretKeyRef selects the token sent in the Authorization header
+	BearerTokenSecretKeyRef *SecretKeyRef `json:"bearerTokenSecretKeyRef,omitempty"`
+	// BasicAuth selects the username and password sent with basic authentication
+	BasicAuth *PrometheusBasicAuth `json:"basicAuth,omitempty"`
+}
+
+// PrometheusBasicAuth defines the username and password of basic authentication
+type PrometheusBasicAuth struct {
+	// Username is the username
+	Username string `json:"username"`
+	// PasswordSecretKeyRef selects the password
+	PasswordSecretKeyRef *SecretKeyRef `json:"passwordSecretKeyRef"`
+}
+
+// PrometheusTLSConfig configures the TLS connections to the prometheus server. The referenced secrets
+// are read from the namespace of the controller
+type PrometheusTLSConfig struct {
+	// InsecureSkipVerify disables the verification of the certificate of the server
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// ServerName overrides the name the certificate of the server is verified against
+	ServerName string `json:"serverName,omitempty"`
+	// CASecretKeyRef selects the PEM encoded CA certificates the certificate of the server is verified
+	// with. Defaults to the CA certificates of the controller
+	CASecretKeyRef *SecretKeyRef `json:"caSecretKeyRef,omitempty"`
 }
 
 // WavefrontMetric defines the wavefront query to perform canary analysis
@@ -148,6 +244,91 @@ type WavefrontMetric struct {
 	Address string `json:"address,omitempty"`
 	// Query is a raw wavefront query to perform
 	Query string `json:"query,omitempty"`
+	// Window is the time range ending at the time of the measurement the query is summarized over
+	// (e.g. 5m). Defaults to the latest point of the query
+	Window DurationString `json:"window,omitempty"`
+	// NoDataRetries is the number of times a query returning no data is retried before the
+	// measurement is inconclusive. Defaults to 0
+	NoDataRetries int32 `json:"noDataRetries,omitempty"`
+	// NoDataRetryDelay is the time to wait before retrying a query returning no data. Defaults to 30s
+	NoDataRetryDelay DurationString `json:"noDataRetryDelay,omitempty"`
+}
+
+// GraphiteMetric defines the graphite query to perform canary analysis
+type GraphiteMetric struct {
+	// Address is the HTTP address and port of the graphite server
+	Address string `json:"address"`
+	// Query is a raw graphite target to render
+	Query string `json:"query"`
+	// Interval is the time range of the query, ending at the time of the measurement (e.g. 5m, 1h).
+	// Defaults to 5m
+	Interval DurationString `json:"interval,omitempty"`
+}
+
+// InfluxdbMetric defines the flux query to perform canary analysis
+type InfluxdbMetric struct {
+	// Profile is the name of the secret holding the address, org and authToken of the influxdb
+	// server, in the namespace of the controller. Defaults to influxdb
+	Profile string `json:"profile,omitempty"`
+	// Query is a raw flux query to perform
+	Query string `json:"query"`
+}
+
+// PluginMetric defines the metric measured by a metric provider plugin
+type PluginMetric struct {
+	// Name is the name of the plugin in the metricProviders.plugins setting of the controller
+	Name string `json:"name"`
+	// Config is the JSON encoded configuration of the metric passed to the plugin
+	Config string `json:"config,omitempty"`
+}
+
+// DatadogMetric defines the datadog query to perform canary analysis
+type DatadogMetric struct {
+	// Interval is the time window of the query, ending at the time of the measurement (e.g. 5m, 1h).
+	// Defaults to 5m
+	Interval DurationString `json:"interval,omitempty"`
+	// Query is a raw datadog query to perform
+	Query string `json:"query,omitempty"`
+	// MonitorID is the ID of a datadog monitor whose state is the result of the measurement (e.g.
+	// OK, Warn, Alert). Can not be combined with a query
+	MonitorID string `json:"monitorId,omitempty"`
+	// SLOID is the ID of a datadog SLO whose SLI, error budget remaining and burn rate over the
+	// interval are the result of the measurement. Can not be combined with a query
+	SLOID string `json:"sloId,omitempty"`
+	// SLOTimeframe selects the target of an SLO with several targets (e.g. 7d, 30d)
+	SLOTimeframe string `json:"sloTimeframe,omitempty"`
+	// APIVersion is the version of the datadog API to query, v1 or v2. The v2 timeseries API
+	// supports formulas combining several queries. Defaults to v1
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Queries are named queries combined by the formula. Only supported by the v2 API
+	Queries map[string]string `json:"queries,omitempty"`
+	// Formula is an expression combining the queries by name (e.g. errors / hits * 100). Only
+	// supported by the v2 API. Defaults to the only query
+	Formula string `json:"formula,omitempty"`
+	// Aggregator reduces the points of the series to the result of the measurement: avg, min, max,
+	// last, sum or p95. Points without data are ignored. Defaults to last
+	Aggregator string `json:"aggregator,omitempty"`
+	// MultipleSeries defines how the series of a query returning several series, such as a query
+	// grouped by pod, are evaluated: first only evaluates the first series, each evaluates every
+	// series and measures the worst result, and merge evaluates the points of all series together.
+	// Defaults to first
+	MultipleSeries string `json:"multipleSeries,omitempty"`
+	// SecretRef references the secret, in the namespace of the AnalysisRun, holding the datadog
+	// keys. Defaults to the datadog-api-keys secret in the namespace of the controller
+	SecretRef *DatadogSecretRef `json:"secretRef,omitempty"`
+}
+
+// DatadogSecretRef references a secret of the namespace of the AnalysisRun holding the address and
+// keys of a datadog account
+type DatadogSecretRef struct {
+	// Name is the name of the secret
+	Name string `json:"name"`
+	// AddressKey is the key of the address of the datadog API. Defaults to address
+	AddressKey string `json:"addressKey,omitempty"`
+	// APIKeyKey is the key of the datadog API key. Defaults to api-key
+	APIKeyKey string `json:"apiKeyKey,omitempty"`
+	// AppKeyKey is the key of the datadog application key. Defaults to app-key
+	AppKeyKey string `json:"appKeyKey,omitempty"`
 }
 
 // JobMetric defines a job to run which acts as a metric
@@ -253,6 +434,9 @@ type MetricResult struct {
 	// ConsecutiveError is the number of times an error was encountered during measurement in succession
 	// Resets to zero when non-errors are encountered
 	ConsecutiveError int32 `json:"consecutiveError,omitempty"`
+	// DryRun indicates the metric is a dry-run metric, whose result does not affect the phase of the
+	// AnalysisRun
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // Measurement is a point in time result value of a single metric, and the time it was measured
@@ -272,6 +456,8 @@ type Measurement struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 	// ResumeAt is the  timestamp when the analysisRun should try to resume the measurement
 	ResumeAt *metav1.Time `json:"resumeAt,omitempty"`
+	// RetryCount is the number of times resuming this measurement errored and was retried
+	RetryCount int32 `json:"retryCount,omitempty"`
 }
 
 type KayentaMetric struct {
@@ -316,9 +502,60 @@ type WebMetric struct {
 	Headers        []WebMetricHeader `json:"headers,omitempty" patchStrategy:"merge" patchMergeKey:"key"`
 	TimeoutSeconds int               `json:"timeoutSeconds,omitempty"`
 	JSONPath       string            `json:"jsonPath"`
+	// TLS configures the verification of the certificate of the server and the client certificate
+	TLS *WebMetricTLSConfig `json:"tls,omitempty"`
+}
+
+// WebMetricTLSConfig configures the TLS connections of a web metric. The referenced secrets are read
+// from the namespace of the AnalysisRun
+type WebMetricTLSConfig struct {
+	// InsecureSkipVerify disables the verification of the certificate of the server
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// ServerName overrides the name the certificate of the server is verified against
+	ServerName string `json:"serverName,omitempty"`
+	// CASecretKeyRef selects the PEM encoded CA certificates the certificate of the server is verified
+	// with. Defaults to the CA certificates of the controller
+	CASecretKeyRef *SecretKeyRef `json:"caSecretKeyRef,omitempty"`
+	// CertSecretKeyRef selects the PEM encoded client certificate
+	CertSecretKeyRef *SecretKeyRef `json:"certSecretKeyRef,omitempty"`
+	// KeySecretKeyRef selects the PEM encoded key of the client certificate
+	KeySecretKeyRef *SecretKeyRef `json:"keySecretKeyRef,omitempty"`
+}
+
+// CloudEventMetric sends a CloudEvent requesting an evaluation to an external quality gate, such as
+// Keptn, and polls for the result of the evaluation
+type CloudEventMetric struct {
+	// URL is the address the event is sent to
+	URL string `json:"url"`
+	// Type is the type of the event (e.g. sh.keptn.event.hello.evaluation.triggered)
+	Type string `json:"type"`
+	// Source is the source of the event. Defaults to argo-rollouts
+	Source string `json:"source,omitempty"`
+	// Subject is the subject of the event
+	Subject string `json:"subject,omitempty"`
+	// Data is the JSON encoded data of the event
+	Data string `json:"data,omitempty"`
+	// Headers are sent with the event and with the requests for the result
+	// +patchMergeKey=key
+	// +patchStrategy=merge
+	Headers []WebMetricHeader `json:"headers,omitempty" patchStrategy:"merge" patchMergeKey:"key"`
+	// ContextJSONPath selects the context of the evaluation (e.g. the keptnContext) in the response
+	// to the event. Defaults to the id of the event
+	ContextJSONPath string `json:"contextJsonPath,omitempty"`
+	// ResultURL is polled for the result of the evaluation. The $(context) and $(id) placeholders
+	// are replaced by the context of the evaluation and the id of the event
+	ResultURL string `json:"resultUrl"`
+	// ResultJSONPath selects the result in the response of the result URL. The evaluation is
+	// pending while the result is empty or missing
+	ResultJSONPath string `json:"resultJsonPath"`
+	// TimeoutSeconds is the time to wait for the result of the evaluation. Defaults to 600
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 type WebMetricHeader struct {
 	Key   string `json:"key"`
-	Value string `json:"value"`
+	Value string `json:"value,omitempty"`
+	// ValueFrom reads the value of the header from a secret in the namespace of the AnalysisRun. Only
+	// supported by the web provider
+	ValueFrom *ValueFrom `json:"valueFrom,omitempty"`
 }
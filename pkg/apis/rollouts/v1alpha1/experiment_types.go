@@ -31,7 +31,8 @@ type ExperimentSpec struct {
 	// +patchStrategy=merge
 	Templates []TemplateSpec `json:"templates" patchStrategy:"merge" patchMergeKey:"name"`
 	// Duration the amount of time for the experiment to run as a duration string (e.g. 30s, 5m, 1h).
-	// If omitted, the experiment will run indefinitely, stopped either via termination, or a failed analysis run.
+	// If omitted, the experiment runs until its analyses complete, or indefinitely if it has no
+	// analyses, stopped either via termination, or a failed analysis run.
 	// +optional
 	Duration DurationString `json:"duration,omitempty"`
 	// ProgressDeadlineSeconds The maximum time in seconds for a experiment to
@@ -47,6 +48,11 @@ type ExperimentSpec struct {
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	Analyses []ExperimentAnalysisTemplateRef `json:"analyses,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// TTLSecondsAfterFinished is the number of seconds after the experiment finished to delete the
+	// experiment, along with the ReplicaSets, Services and AnalysisRuns it created. If omitted, the
+	// experiment is not deleted.
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
 }
 
 type TemplateSpec struct {
@@ -67,6 +73,22 @@ type TemplateSpec struct {
 	Selector *metav1.LabelSelector `json:"selector"`
 	// Template describes the pods that will be created.
 	Template corev1.PodTemplateSpec `json:"template"`
+	// Weight is the percentage of the traffic of the rollout which created the experiment that is
+	// sent to the pods of the template. A Service selecting the pods is created for the template.
+	// Requires the rollout to use traffic routing.
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+	// Service controls the creation of a Service selecting the pods of the template. A Service is
+	// always created for a template with a weight.
+	// +optional
+	Service *TemplateService `json:"service,omitempty"`
+}
+
+// TemplateService describes the Service created for a template
+type TemplateService struct {
+	// Name of the Service. Defaults to the name of the ReplicaSet of the template
+	// +optional
+	Name string `json:"name,omitempty"`
 }
 
 type TemplateStatusCode string
@@ -111,6 +133,9 @@ type TemplateStatus struct {
 	// LastTransitionTime is the last time the replicaset transitioned, which resets the countdown
 	// on the ProgressDeadlineSeconds check.
 	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// ServiceName is the name of the Service selecting the pods of the template
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
 }
 
 // ExperimentStatus is the status for a Experiment resource
@@ -134,6 +159,9 @@ type ExperimentStatus struct {
 	// AnalysisRuns tracks the status of AnalysisRuns associated with this Experiment
 	// +optional
 	AnalysisRuns []ExperimentAnalysisRunStatus `json:"analysisRuns,omitempty"`
+	// FinishedAt is the time when the experiment completed
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
 }
 
 // ExperimentConditionType defines the conditions of Experiment
@@ -191,6 +219,8 @@ type ExperimentAnalysisTemplateRef struct {
 	Name string `json:"name"`
 	// TemplateName reference of the AnalysisTemplate name used by the Experiment to create the run
 	TemplateName string `json:"templateName"`
+	// ClusterScope indicates the template is a ClusterAnalysisTemplate
+	ClusterScope bool `json:"clusterScope,omitempty"`
 	// Args are the arguments that will be added to the AnalysisRuns
 	// +optional
 	// +patchMergeKey=name
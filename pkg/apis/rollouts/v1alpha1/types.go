@@ -18,6 +18,7 @@ import (
 // +kubebuilder:printcolumn:name="Current",type="integer",JSONPath=".status.replicas",description="Total number of non-terminated pods targeted by this rollout"
 // +kubebuilder:printcolumn:name="Up-to-date",type="integer",JSONPath=".status.updatedReplicas",description="Total number of non-terminated pods targeted by this rollout that have the desired template spec"
 // +kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableReplicas",description="Total number of available pods (ready for at least minReadySeconds) targeted by this rollout"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Health of the rollout"
 
 // Rollout is a specification for a Rollout resource
 type Rollout struct {
@@ -37,9 +38,16 @@ type RolloutSpec struct {
 	// Label selector for pods. Existing ReplicaSets whose pods are
 	// selected by this will be the ones affected by this rollout.
 	// It must match the pod template's labels.
+	// +optional
 	Selector *metav1.LabelSelector `json:"selector"`
 	// Template describes the pods that will be created.
+	// +optional
 	Template corev1.PodTemplateSpec `json:"template"`
+	// WorkloadRef references a Deployment whose pod template and selector are used in place of
+	// Template and Selector. The Deployment is scaled down to zero once the rollout completes with
+	// its pod template.
+	// +optional
+	WorkloadRef *ObjectRef `json:"workloadRef,omitempty"`
 	// Minimum number of seconds for which a newly created pod should be ready
 	// without any of its container crashing, for it to be considered available.
 	// Defaults to 0 (pod will be considered available as soon as it is ready)
@@ -52,6 +60,10 @@ type RolloutSpec struct {
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 	// Paused pauses the rollout at its current step.
 	Paused bool `json:"paused,omitempty"`
+	// RestartAt indicates when the pods of the rollout should be restarted. The pods created before this
+	// time are deleted a few at a time, respecting maxUnavailable, and recreated by their ReplicaSets.
+	// +optional
+	RestartAt *metav1.Time `json:"restartAt,omitempty"`
 	// ProgressDeadlineSeconds The maximum time in seconds for a rollout to
 	// make progress before it is considered to be failed. Argo Rollouts will
 	// continue to process failed rollouts and a condition with a
@@ -59,6 +71,118 @@ type RolloutSpec struct {
 	// Note that progress will not be estimated during the time a rollout is paused.
 	// Defaults to 600s.
 	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// ProgressDeadlineAbort aborts the update of the rollout once it exceeds the progress deadline,
+	// instead of only reporting the ProgressDeadlineExceeded reason.
+	// +optional
+	ProgressDeadlineAbort bool `json:"progressDeadlineAbort,omitempty"`
+	// StuckDetection defines when a rollout which makes no progress, for reasons the progress
+	// deadline does not cover, is considered stuck. A stuck rollout has the Stuck condition.
+	// +optional
+	StuckDetection *StuckDetection `json:"stuckDetection,omitempty"`
+	// RollbackWindow defines the previous revisions a rollout is rolled back to without going
+	// through its steps, pauses and analysis
+	// +optional
+	RollbackWindow *RollbackWindow `json:"rollbackWindow,omitempty"`
+	// SLOAnalysis evaluates the error budget burn rate of service level objectives during an update
+	// and for a window after it completes. The update is aborted, or rolled back once it completed,
+	// when a budget burns too fast.
+	// +optional
+	SLOAnalysis *SLOAnalysis `json:"sloAnalysis,omitempty"`
+	// Analysis configures how many completed AnalysisRuns and Experiments of the rollout are retained
+	// +optional
+	Analysis *AnalysisRunStrategy `json:"analysis,omitempty"`
+}
+
+// AnalysisRunStrategy configures the history of the AnalysisRuns and Experiments of a rollout
+type AnalysisRunStrategy struct {
+	// SuccessfulRunHistoryLimit limits the number of old successful AnalysisRuns and Experiments to be retained.
+	// Defaults to 5
+	// +optional
+	SuccessfulRunHistoryLimit *int32 `json:"successfulRunHistoryLimit,omitempty"`
+	// UnsuccessfulRunHistoryLimit limits the number of old unsuccessful (Failed, Error or Inconclusive)
+	// AnalysisRuns and Experiments to be retained. Defaults to 5
+	// +optional
+	UnsuccessfulRunHistoryLimit *int32 `json:"unsuccessfulRunHistoryLimit,omitempty"`
+}
+
+// ObjectRef holds a reference to a workload providing the pod template of a rollout
+type ObjectRef struct {
+	// APIVersion of the referent. Defaults to apps/v1
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the referent. Only Deployment is supported
+	Kind string `json:"kind"`
+	// Name of the referent
+	Name string `json:"name"`
+}
+
+// StuckDetection defines how long a rollout can wait on something other than the availability of
+// its pods before it is considered stuck
+type StuckDetection struct {
+	// PausedSeconds is the number of seconds the rollout can wait for a promotion, at a pause step
+	// without a duration or before promoting a blue-green preview, before it is considered stuck
+	// +optional
+	PausedSeconds *int32 `json:"pausedSeconds,omitempty"`
+	// InconclusiveSeconds is the number of seconds the rollout can be paused on an inconclusive
+	// AnalysisRun or Experiment before it is considered stuck
+	// +optional
+	InconclusiveSeconds *int32 `json:"inconclusiveSeconds,omitempty"`
+	// TrafficWeightSeconds is the number of seconds the traffic router can fail to apply the
+	// weights of the rollout before it is considered stuck
+	// +optional
+	TrafficWeightSeconds *int32 `json:"trafficWeightSeconds,omitempty"`
+}
+
+// RollbackWindow defines how recent a previous revision of a rollout must be for a rollback to it to
+// be fully promoted. When both fields are set, the revision must be within both.
+type RollbackWindow struct {
+	// Revisions is the number of revisions before the stable revision a rollback is fully promoted to
+	// +optional
+	Revisions int32 `json:"revisions,omitempty"`
+	// Duration is how long after a revision was replaced a rollback to it is fully promoted
+	// +optional
+	Duration DurationString `json:"duration,omitempty"`
+}
+
+// SLOAnalysis defines the service level objectives whose burn rates are evaluated by the rollout
+type SLOAnalysis struct {
+	// Address is the HTTP address and port of the prometheus server
+	Address string `json:"address"`
+	// Objectives are the service level objectives of the rollout
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Objectives []ServiceLevelObjective `json:"objectives" patchStrategy:"merge" patchMergeKey:"name"`
+	// Interval between the evaluations of the burn rates. Defaults to 1m
+	// +optional
+	Interval DurationString `json:"interval,omitempty"`
+	// PostPromotionWindow how long the burn rates are evaluated after the update completed. Defaults to 15m
+	// +optional
+	PostPromotionWindow DurationString `json:"postPromotionWindow,omitempty"`
+}
+
+// ServiceLevelObjective defines an objective and the burn rates of its error budget which are too fast
+type ServiceLevelObjective struct {
+	// Name of the objective
+	Name string `json:"name"`
+	// Target is the percentage of good events of the objective, e.g. "99.9"
+	Target string `json:"target"`
+	// ErrorRatioQuery is a prometheus query returning the ratio of bad events to all events over
+	// $(window), which is replaced by the windows of the burn rates
+	ErrorRatioQuery string `json:"errorRatioQuery"`
+	// BurnRates are the burn rates which fail the objective when both of their windows exceed the
+	// threshold. Defaults to 14.4 over 1h and 5m, and 6 over 6h and 30m
+	// +optional
+	BurnRates []BurnRate `json:"burnRates,omitempty"`
+}
+
+// BurnRate is a burn rate threshold over a long and a short window
+type BurnRate struct {
+	// LongWindow the window of the burn rate, e.g. 1h
+	LongWindow DurationString `json:"longWindow"`
+	// ShortWindow the window confirming the burn rate is still high, e.g. 5m
+	ShortWindow DurationString `json:"shortWindow"`
+	// Threshold is how many times faster than the objective allows the budget may burn, e.g. "14.4"
+	Threshold string `json:"threshold"`
 }
 
 const (
@@ -114,10 +238,51 @@ type BlueGreenStrategy struct {
 	// ScaleDownDelayRevisionLimit limits the number of old RS that can run at one time before getting scaled down
 	// +optional
 	ScaleDownDelayRevisionLimit *int32 `json:"scaleDownDelayRevisionLimit,omitempty"`
+	// AbortScaleDownDelaySeconds scales the new ReplicaSet down to zero the specified number of
+	// seconds after the update was aborted. If omitted, the new ReplicaSet is kept at its preview
+	// scale until the update is retried or the pod template changes.
+	// +optional
+	AbortScaleDownDelaySeconds *int32 `json:"abortScaleDownDelaySeconds,omitempty"`
 	// PrePromotionAnalysis configuration to run analysis before a selector switch
 	PrePromotionAnalysis *RolloutAnalysis `json:"prePromotionAnalysis,omitempty"`
+	// PostPromotionAnalysis configuration to run analysis after a selector switch. The active service is
+	// switched back to the previous ReplicaSet if the analysis fails
+	// +optional
+	PostPromotionAnalysis *RolloutAnalysis `json:"postPromotionAnalysis,omitempty"`
+	// AntiAffinity enables anti-affinity rules for Blue Green deployment
+	// +optional
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+	// PreviewMetadata specify labels and annotations which will be attached to the preview pods for
+	// the duration which they act as preview pods, and will be removed after
+	// +optional
+	PreviewMetadata *PodTemplateMetadata `json:"previewMetadata,omitempty"`
+	// ActiveMetadata specify labels and annotations which will be attached to the active pods for
+	// the duration which they act as active pods, and will be removed after
+	// +optional
+	ActiveMetadata *PodTemplateMetadata `json:"activeMetadata,omitempty"`
+}
+
+// AntiAffinity defines which inter-pod scheduling rule to use for anti-affinity injection
+type AntiAffinity struct {
+	// PreferredDuringSchedulingIgnoredDuringExecution prefers to schedule the pods of the new
+	// ReplicaSet on other nodes than the pods of the stable ReplicaSet
+	// +optional
+	PreferredDuringSchedulingIgnoredDuringExecution *PreferredDuringSchedulingIgnoredDuringExecution `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	// RequiredDuringSchedulingIgnoredDuringExecution requires the pods of the new ReplicaSet to be
+	// scheduled on other nodes than the pods of the stable ReplicaSet
+	// +optional
+	RequiredDuringSchedulingIgnoredDuringExecution *RequiredDuringSchedulingIgnoredDuringExecution `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
 }
 
+// PreferredDuringSchedulingIgnoredDuringExecution defines the weight of the anti-affinity injection
+type PreferredDuringSchedulingIgnoredDuringExecution struct {
+	// Weight associated with matching the corresponding podAffinityTerm, in the range 1-100.
+	Weight int32 `json:"weight"`
+}
+
+// RequiredDuringSchedulingIgnoredDuringExecution defines inter-pod scheduling rule to be RequiredDuringSchedulingIgnoredDuringExecution
+type RequiredDuringSchedulingIgnoredDuringExecution struct{}
+
 // CanaryStrategy defines parameters for a Replica Based Canary
 type CanaryStrategy struct {
 	// CanaryService holds the name of a service which selects pods with canary version and don't select any pods with stable version.
@@ -131,6 +296,13 @@ type CanaryStrategy struct {
 	Steps []CanaryStep `json:"steps,omitempty"`
 	// TrafficRouting hosts all the supported service meshes supported to enable more fine-grained traffic routing
 	TrafficRouting *RolloutTrafficRouting `json:"trafficRouting,omitempty"`
+	// Cluster is the name of the registered cluster the ReplicaSets of new revisions are created in,
+	// instead of the cluster of the controller. The ReplicaSets of previous revisions keep running
+	// in their clusters, so changing the cluster migrates the rollout through its steps. Requires
+	// traffic routing with a router reaching the services of every cluster, e.g. a Gateway API
+	// HTTPRoute to multi-cluster services.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
 
 	// MaxUnavailable The maximum number of pods that can be unavailable during the update.
 	// Value can be an absolute number (ex: 5) or a percentage of total pods at the start of update (ex: 10%).
@@ -159,18 +331,129 @@ type CanaryStrategy struct {
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
 	// Analysis runs a separate analysisRun while all the steps execute. This is intended to be a continuous validation of the new ReplicaSet
 	Analysis *RolloutAnalysisBackground `json:"analysis,omitempty"`
+	// Partition replaces the pods of the stable ReplicaSet in a defined order without surging above
+	// the desired number of pods, for workloads which can not run more pods than replicas. The
+	// setWeight steps define how many pods are replaced before the next step runs.
+	// +optional
+	Partition *PartitionStrategy `json:"partition,omitempty"`
+	// AntiAffinity enables anti-affinity rules for Canary deployment
+	// +optional
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+	// CanaryMetadata specify labels and annotations which will be attached to the canary pods for
+	// the duration which they act as a canary, and will be removed after
+	// +optional
+	CanaryMetadata *PodTemplateMetadata `json:"canaryMetadata,omitempty"`
+	// StableMetadata specify labels and annotations which will be attached to the stable pods for
+	// the duration which they act as stable pods, and will be removed after
+	// +optional
+	StableMetadata *PodTemplateMetadata `json:"stableMetadata,omitempty"`
+}
+
+// PartitionOrder is the order in which the pods of the stable ReplicaSet are replaced
+type PartitionOrder string
+
+const (
+	// PartitionOrderOldest replaces the oldest pods first
+	PartitionOrderOldest PartitionOrder = "Oldest"
+	// PartitionOrderNewest replaces the newest pods first
+	PartitionOrderNewest PartitionOrder = "Newest"
+	// PartitionOrderNodeName replaces the pods in the order of the names of their nodes
+	PartitionOrderNodeName PartitionOrder = "NodeName"
+)
+
+// PartitionStrategy defines how the pods of the stable ReplicaSet are replaced by a partitioned canary
+type PartitionStrategy struct {
+	// Order in which the pods of the stable ReplicaSet are replaced. Defaults to Oldest.
+	// +optional
+	Order PartitionOrder `json:"order,omitempty"`
 }
 
 // RolloutTrafficRouting hosts all the different configuration for supported service meshes to enable more fine-grained traffic routing
 type RolloutTrafficRouting struct {
 	// Istio holds Istio specific configuration to route traffic
 	Istio *IstioTrafficRouting `json:"istio,omitempty"`
+	// SMI holds TrafficSplit specific configuration to route traffic
+	SMI *SMITrafficRouting `json:"smi,omitempty"`
+	// ALB holds AWS Application Load Balancer specific configuration to route traffic
+	ALB *ALBTrafficRouting `json:"alb,omitempty"`
+	// Nginx holds Nginx Ingress specific configuration to route traffic
+	Nginx *NginxTrafficRouting `json:"nginx,omitempty"`
+	// GatewayAPI holds Gateway API specific configuration to route traffic
+	GatewayAPI *GatewayAPITrafficRouting `json:"gatewayAPI,omitempty"`
+	// Plugin routes traffic with a traffic router plugin declared in the controller configuration
+	Plugin *PluginTrafficRouting `json:"plugin,omitempty"`
+}
+
+// PluginTrafficRouting configuration for a traffic router plugin to control traffic routing
+type PluginTrafficRouting struct {
+	// Name is the name of the plugin in the trafficRouters.plugins setting of the controller
+	Name string `json:"name"`
+	// Config is the JSON encoded configuration of the traffic routing passed to the plugin
+	Config string `json:"config,omitempty"`
+}
+
+// GatewayAPITrafficRouting configuration for a Gateway API HTTPRoute to control traffic routing
+type GatewayAPITrafficRouting struct {
+	// HTTPRoute refers to the name of the HTTPRoute whose backendRef weights the controller manages
+	HTTPRoute string `json:"httpRoute"`
+}
+
+// NginxTrafficRouting configuration for the Nginx Ingress Controller to control traffic routing
+type NginxTrafficRouting struct {
+	// PrimaryIngress refers to the name of the Ingress routing traffic to the stable service. The
+	// controller creates a canary Ingress from it
+	PrimaryIngress string `json:"primaryIngress"`
+	// AnnotationPrefix has to match the configured annotation prefix on the nginx ingress controller.
+	// Defaults to nginx.ingress.kubernetes.io
+	// +optional
+	AnnotationPrefix string `json:"annotationPrefix,omitempty"`
+}
+
+// ALBTrafficRouting configuration for an AWS Application Load Balancer Ingress to control traffic routing
+type ALBTrafficRouting struct {
+	// Ingress refers to the name of the Ingress whose action annotation the controller manages
+	Ingress string `json:"ingress"`
+	// ServicePort refers to the port the target groups of the stable and canary services forward to
+	ServicePort int32 `json:"servicePort"`
+	// RootService is the name of the action the rules of the Ingress use as their backend.
+	// Defaults to the stable service
+	// +optional
+	RootService string `json:"rootService,omitempty"`
+	// AnnotationPrefix has to match the configured annotation prefix on the alb ingress controller.
+	// Defaults to alb.ingress.kubernetes.io
+	// +optional
+	AnnotationPrefix string `json:"annotationPrefix,omitempty"`
+}
+
+// SMITrafficRouting configuration for TrafficSplit Custom Resource to control traffic routing
+type SMITrafficRouting struct {
+	// RootService holds the name of the service clients use to communicate. Defaults to the stable service
+	// +optional
+	RootService string `json:"rootService,omitempty"`
+	// TrafficSplitName holds the name of the TrafficSplit. Defaults to the name of the rollout
+	// +optional
+	TrafficSplitName string `json:"trafficSplitName,omitempty"`
 }
 
 // IstioTrafficRouting configuration for Istio service mesh to enable fine grain configuration
 type IstioTrafficRouting struct {
 	// VirtualService reference to a Virtual Service that modified to shape traffic
 	VirtualService IstioVirtualService `json:"virtualService"`
+	// DestinationRule references an Istio DestinationRule whose subsets are the destinations of the
+	// routes of the VirtualService instead of the stable and canary services
+	// +optional
+	DestinationRule *IstioDestinationRule `json:"destinationRule,omitempty"`
+}
+
+// IstioDestinationRule is a reference to an Istio DestinationRule whose subsets the controller
+// points at the pods of the stable and canary ReplicaSets
+type IstioDestinationRule struct {
+	// Name holds the name of the DestinationRule
+	Name string `json:"name"`
+	// CanarySubsetName is the subset routing to the pods of the canary ReplicaSet
+	CanarySubsetName string `json:"canarySubsetName"`
+	// StableSubsetName is the subset routing to the pods of the stable ReplicaSet
+	StableSubsetName string `json:"stableSubsetName"`
 }
 
 // IstioVirtualService holds information on the virtual service the rollout needs to modify
@@ -187,7 +470,8 @@ type RolloutExperimentStep struct {
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	Templates []RolloutExperimentTemplate `json:"templates" patchStrategy:"merge" patchMergeKey:"name"`
-	// Duration is a duration string (e.g. 30s, 5m, 1h) that the experiment should run for
+	// Duration is a duration string (e.g. 30s, 5m, 1h) that the experiment should run for. If omitted,
+	// the experiment runs until its analyses complete
 	// +optional
 	Duration DurationString `json:"duration,omitempty"`
 	// Analyses reference which analysis templates to run with the experiment
@@ -201,10 +485,15 @@ type RolloutExperimentStepAnalysisTemplateRef struct {
 	Name string `json:"name"`
 	// TemplateName reference of the AnalysisTemplate name used by the Rollout to create the run
 	TemplateName string `json:"templateName"`
+	// ClusterScope indicates the template is a ClusterAnalysisTemplate
+	ClusterScope bool `json:"clusterScope,omitempty"`
 	// Args the arguments that will be added to the AnalysisRuns
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	Args []AnalysisRunArgument `json:"args,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// RequiredForCompletion indicates that experiment should complete after analysis finishes
+	// +optional
+	RequiredForCompletion bool `json:"requiredForCompletion,omitempty"`
 }
 
 // RolloutExperimentTemplate defines the template used to create experiments for the Rollout's experiment canary step
@@ -223,6 +512,13 @@ type RolloutExperimentTemplate struct {
 	// use the same selector as the Rollout
 	// +optional
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Weight is the percentage of the traffic sent to the pods of the template while the experiment
+	// runs, taken from the stable ReplicaSet. Requires traffic routing.
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+	// Service creates a Service selecting the pods of the template
+	// +optional
+	Service *TemplateService `json:"service,omitempty"`
 }
 
 // PodTemplateMetadata extra labels to add to the template
@@ -257,6 +553,91 @@ type CanaryStep struct {
 	Experiment *RolloutExperimentStep `json:"experiment,omitempty"`
 	// Analysis defines the AnalysisRun that will run for a step
 	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+	// SetFeatureFlag sets the percentage of users a feature flag is served to
+	SetFeatureFlag *SetFeatureFlag `json:"setFeatureFlag,omitempty"`
+	// SetHeaderRoute routes the requests with specific headers or cookies to the canary service,
+	// independently of the canary weight
+	SetHeaderRoute *SetHeaderRoute `json:"setHeaderRoute,omitempty"`
+	// SetMirrorRoute mirrors a percentage of the requests of the traffic router to the canary service
+	SetMirrorRoute *SetMirrorRoute `json:"setMirrorRoute,omitempty"`
+	// SetCanaryScale scales the canary ReplicaSet independently of the canary weight
+	SetCanaryScale *SetCanaryScale `json:"setCanaryScale,omitempty"`
+}
+
+// SetCanaryScale defines the scale of the canary ReplicaSet, independently of the weight of the
+// traffic sent to it. Requires traffic routing. Only one of the fields can be set
+type SetCanaryScale struct {
+	// Weight is the percentage of the replicas of the rollout the canary ReplicaSet has
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+	// Replicas is the number of replicas the canary ReplicaSet has
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// MatchTrafficWeight scales the canary ReplicaSet with the canary weight again, canceling the
+	// scale of an earlier step
+	// +optional
+	MatchTrafficWeight bool `json:"matchTrafficWeight,omitempty"`
+}
+
+// SetMirrorRoute mirrors requests to the canary service without serving the responses of the canary
+type SetMirrorRoute struct {
+	// Percentage of the requests of the routes of the rollout mirrored to the canary. The mirror is
+	// removed if 0
+	Percentage int32 `json:"percentage"`
+}
+
+// SetHeaderRoute defines a route of the traffic router sending the requests matching all of its
+// matches to the canary service
+type SetHeaderRoute struct {
+	// Name of the route. A later step with the same name replaces the route
+	Name string `json:"name"`
+	// Match lists the headers and cookies the requests routed to the canary have. The route is
+	// removed if empty
+	// +optional
+	Match []HeaderRoutingMatch `json:"match,omitempty"`
+}
+
+// HeaderRoutingMatch matches the value of a header or a cookie of a request
+type HeaderRoutingMatch struct {
+	// HeaderName is the name of the header to match. One of headerName or cookieName is required
+	// +optional
+	HeaderName string `json:"headerName,omitempty"`
+	// CookieName is the name of the cookie to match
+	// +optional
+	CookieName string `json:"cookieName,omitempty"`
+	// Value is how the value of the header or cookie is matched
+	Value StringMatch `json:"value"`
+}
+
+// StringMatch matches a string exactly, by prefix, or with a regular expression. Only one of the
+// fields can be set
+type StringMatch struct {
+	// Exact matches the string exactly
+	// +optional
+	Exact string `json:"exact,omitempty"`
+	// Prefix matches the beginning of the string
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Regex matches the string with a RE2 regular expression
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// SetFeatureFlag rolls out a feature flag of a feature flag service together with the canary
+type SetFeatureFlag struct {
+	// Provider is the feature flag service managing the flag. One of: launchDarkly, unleash
+	Provider string `json:"provider"`
+	// Flag is the key of the feature flag
+	Flag string `json:"flag"`
+	// Project is the project of the flag. Defaults to default
+	// +optional
+	Project string `json:"project,omitempty"`
+	// Environment is the environment the flag is rolled out in
+	Environment string `json:"environment"`
+	// Percentage of users the flag is served to. If not set, the percentage follows the canary
+	// weight for the rest of the update
+	// +optional
+	Percentage *int32 `json:"percentage,omitempty"`
 }
 
 // RolloutAnalysisBackground defines a template that is used to create a background analysisRun
@@ -283,6 +664,8 @@ type RolloutAnalysis struct {
 type RolloutAnalysisTemplates struct {
 	//TemplateName name of template to use in AnalysisRun
 	TemplateName string `json:"templateName"`
+	// ClusterScope indicates the template is a ClusterAnalysisTemplate
+	ClusterScope bool `json:"clusterScope,omitempty"`
 }
 
 // AnalysisRunArgument argument to add to analysisRun
@@ -299,6 +682,18 @@ type AnalysisRunArgument struct {
 type ArgumentValueFrom struct {
 	// PodTemplateHashValue gets the value from one of the children ReplicaSet's Pod Template Hash
 	PodTemplateHashValue *ValueFromPodTemplateHash `json:"podTemplateHashValue,omitempty"`
+	// SecretKeyRef gets the value from a key of a secret of the rollout namespace. The reference is
+	// passed to the AnalysisRun, which reads the secret when it runs, so the value is never written
+	// to the run spec
+	SecretKeyRef *SecretKeyRef `json:"secretKeyRef,omitempty"`
+	// FieldRef gets the value from a field of the rollout
+	FieldRef *FieldRef `json:"fieldRef,omitempty"`
+}
+
+// FieldRef selects a field of the rollout
+type FieldRef struct {
+	// FieldPath is the path of the field, e.g. metadata.name or metadata.labels['app']
+	FieldPath string `json:"fieldPath"`
 }
 
 // ValueFromPodTemplateHash indicates which ReplicaSet pod template pod hash to use
@@ -318,8 +713,12 @@ const (
 	RolloutTypeStepLabel = "Step"
 	// RolloutTypeBackgroundRunLabel indicates that the analysisRun was created in Background to an execution
 	RolloutTypeBackgroundRunLabel = "Background"
+	// RolloutTypeSLOLabel indicates that the analysisRun evaluates the burn rates of the service level objectives
+	RolloutTypeSLOLabel = "SLO"
 	// RolloutTypePrePromotionLabel indicates that the analysisRun was created before the active service promotion
 	RolloutTypePrePromotionLabel = "PrePromotion"
+	// RolloutTypePostPromotionLabel indicates that the analysisRun was created after the active service promotion
+	RolloutTypePostPromotionLabel = "PostPromotion"
 	// RolloutCanaryStepIndexLabel indicates which step created this analysisRun
 	RolloutCanaryStepIndexLabel = "step-index"
 )
@@ -390,6 +789,15 @@ type PauseCondition struct {
 type RolloutStatus struct {
 	// Abort cancel the current rollout progression
 	Abort bool `json:"abort,omitempty"`
+	// Promote promotes a paused rollout past its current step: the canary moves to the next step, and the
+	// blue-green rollout switches the active service once the pre-promotion analysis passes. Set by
+	// `kubectl argo rollouts promote` and cleared by the controller once processed.
+	Promote bool `json:"promote,omitempty"`
+	// RestartedAt indicates the restartAt time of the last completed restart of the pods of the rollout
+	RestartedAt *metav1.Time `json:"restartedAt,omitempty"`
+	// PromoteFull promotes the rollout skipping all the remaining steps, pauses and analysis, until the next
+	// update of the rollout
+	PromoteFull bool `json:"promoteFull,omitempty"`
 	// PauseConditions indicates why the rollout is currently paused
 	PauseConditions []PauseCondition `json:"pauseConditions,omitempty"`
 	//ControllerPause indicates the controller has paused the rollout
@@ -440,8 +848,50 @@ type RolloutStatus struct {
 	// Selector that identifies the pods that are receiving active traffic
 	// +optional
 	Selector string `json:"selector,omitempty"`
+	// Phase is the health of the rollout computed by the controller from its status
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+	// Message explains the phase of the rollout
+	// +optional
+	Message string `json:"message,omitempty"`
+	// SLO describes the state of the SLO analysis of the rollout
+	// +optional
+	SLO SLOStatus `json:"slo,omitempty"`
+}
+
+// SLOStatus status fields of the SLO analysis of the rollout
+type SLOStatus struct {
+	// CurrentAnalysisRun is the AnalysisRun evaluating the burn rates of the current revision
+	// +optional
+	CurrentAnalysisRun string `json:"currentAnalysisRun,omitempty"`
+	// PromotedAt is when the update to the current revision completed
+	// +optional
+	PromotedAt *metav1.Time `json:"promotedAt,omitempty"`
+	// RolledBackFrom is the pod template hash of the revision rolled back after its update completed
+	// +optional
+	RolledBackFrom string `json:"rolledBackFrom,omitempty"`
+	// RolledBackTo is the pod template hash of the revision the rollout was rolled back to. Its
+	// burn rates are not evaluated.
+	// +optional
+	RolledBackTo string `json:"rolledBackTo,omitempty"`
 }
 
+// RolloutPhase is the health of a rollout
+type RolloutPhase string
+
+const (
+	// RolloutPhaseHealthy means the rollout is fully promoted and its pods are available
+	RolloutPhaseHealthy RolloutPhase = "Healthy"
+	// RolloutPhaseProgressing means the rollout is updating or waiting for its pods to become available
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePaused means the rollout is paused by a step, the controller or the user
+	RolloutPhasePaused RolloutPhase = "Paused"
+	// RolloutPhaseDegraded means the rollout has an invalid spec or exceeded its progress deadline
+	RolloutPhaseDegraded RolloutPhase = "Degraded"
+	// RolloutPhaseAborted means the update of the rollout was aborted and the stable pods are restored
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
 // BlueGreenStatus status fields that only pertain to the blueGreen rollout
 type BlueGreenStatus struct {
 	// PreviewSelector indicates which replicas set the preview service is serving traffic to
@@ -464,6 +914,11 @@ type BlueGreenStatus struct {
 	ScaleUpPreviewCheckPoint bool `json:"scaleUpPreviewCheckPoint,omitempty"`
 	// PrePromotionAnalysisRun is the current analysis run running before the active service promotion
 	PrePromotionAnalysisRun string `json:"prePromotionAnalysisRun,omitempty"`
+	// PostPromotionAnalysisRun is the current analysis run running after the active service promotion
+	PostPromotionAnalysisRun string `json:"postPromotionAnalysisRun,omitempty"`
+	// AbortScaleDownAt indicates when the new ReplicaSet of an aborted update is scaled down to zero
+	// +optional
+	AbortScaleDownAt *metav1.Time `json:"abortScaleDownAt,omitempty"`
 }
 
 // CanaryStatus status fields that only pertain to the canary rollout
@@ -477,6 +932,16 @@ type CanaryStatus struct {
 	CurrentBackgroundAnalysisRun string `json:"currentBackgroundAnalysisRun,omitempty"`
 	// CurrentExperiment indicates the running experiment
 	CurrentExperiment string `json:"currentExperiment,omitempty"`
+	// FeatureFlags are the percentages the feature flags of the steps were set to
+	FeatureFlags []FeatureFlagStatus `json:"featureFlags,omitempty"`
+}
+
+// FeatureFlagStatus is the percentage of users a feature flag was set to by the rollout
+type FeatureFlagStatus struct {
+	// Key identifies the flag by its provider, project, environment and flag key
+	Key string `json:"key"`
+	// Percentage of users the flag is served to
+	Percentage int32 `json:"percentage"`
 }
 
 // RolloutConditionType defines the conditions of Rollout
@@ -498,6 +963,19 @@ const (
 	// RolloutReplicaFailure ReplicaFailure is added in a deployment when one of its pods
 	// fails to be created or deleted.
 	RolloutReplicaFailure RolloutConditionType = "ReplicaFailure"
+	// RolloutReferencesVerified means the objects referenced by the rollout, such as services and
+	// analysis templates, exist and are compatible with the rollout. Updates do not start until the
+	// references are verified.
+	RolloutReferencesVerified RolloutConditionType = "ReferencesVerified"
+	// RolloutDegraded means the rollout cannot progress until it is fixed, e.g. because an image of
+	// the new revision failed signature verification.
+	RolloutDegraded RolloutConditionType = "Degraded"
+	// RolloutStuck means the rollout makes no progress for longer than allowed by its stuck
+	// detection, e.g. because it waits for a promotion or on an inconclusive analysis.
+	RolloutStuck RolloutConditionType = "Stuck"
+	// RolloutServiceSelectorSwitched means the active or stable service read from the cache selects
+	// the ReplicaSet the controller switched it to. The rollout is not completed until it does.
+	RolloutServiceSelectorSwitched RolloutConditionType = "ServiceSelectorSwitched"
 )
 
 // RolloutCondition describes the state of a rollout at a certain point.
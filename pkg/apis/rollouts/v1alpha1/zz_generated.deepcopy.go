@@ -26,6 +26,22 @@ import (
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ALBTrafficRouting) DeepCopyInto(out *ALBTrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ALBTrafficRouting.
+func (in *ALBTrafficRouting) DeepCopy() *ALBTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(ALBTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AnalysisRun) DeepCopyInto(out *AnalysisRun) {
 	*out = *in
@@ -165,6 +181,32 @@ func (in *AnalysisRunStatus) DeepCopy() *AnalysisRunStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisRunStrategy) DeepCopyInto(out *AnalysisRunStrategy) {
+	*out = *in
+	if in.SuccessfulRunHistoryLimit != nil {
+		in, out := &in.SuccessfulRunHistoryLimit, &out.SuccessfulRunHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UnsuccessfulRunHistoryLimit != nil {
+		in, out := &in.UnsuccessfulRunHistoryLimit, &out.UnsuccessfulRunHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisRunStrategy.
+func (in *AnalysisRunStrategy) DeepCopy() *AnalysisRunStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisRunStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AnalysisTemplate) DeepCopyInto(out *AnalysisTemplate) {
 	*out = *in
@@ -255,6 +297,32 @@ func (in *AnalysisTemplateSpec) DeepCopy() *AnalysisTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AntiAffinity) DeepCopyInto(out *AntiAffinity) {
+	*out = *in
+	if in.PreferredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.PreferredDuringSchedulingIgnoredDuringExecution, &out.PreferredDuringSchedulingIgnoredDuringExecution
+		*out = new(PreferredDuringSchedulingIgnoredDuringExecution)
+		**out = **in
+	}
+	if in.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.RequiredDuringSchedulingIgnoredDuringExecution, &out.RequiredDuringSchedulingIgnoredDuringExecution
+		*out = new(RequiredDuringSchedulingIgnoredDuringExecution)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AntiAffinity.
+func (in *AntiAffinity) DeepCopy() *AntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Argument) DeepCopyInto(out *Argument) {
 	*out = *in
@@ -289,6 +357,16 @@ func (in *ArgumentValueFrom) DeepCopyInto(out *ArgumentValueFrom) {
 		*out = new(ValueFromPodTemplateHash)
 		**out = **in
 	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.FieldRef != nil {
+		in, out := &in.FieldRef, &out.FieldRef
+		*out = new(FieldRef)
+		**out = **in
+	}
 	return
 }
 
@@ -309,6 +387,10 @@ func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
 		in, out := &in.ScaleDownDelayStartTime, &out.ScaleDownDelayStartTime
 		*out = (*in).DeepCopy()
 	}
+	if in.AbortScaleDownAt != nil {
+		in, out := &in.AbortScaleDownAt, &out.AbortScaleDownAt
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -350,11 +432,36 @@ func (in *BlueGreenStrategy) DeepCopyInto(out *BlueGreenStrategy) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.AbortScaleDownDelaySeconds != nil {
+		in, out := &in.AbortScaleDownDelaySeconds, &out.AbortScaleDownDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
 	if in.PrePromotionAnalysis != nil {
 		in, out := &in.PrePromotionAnalysis, &out.PrePromotionAnalysis
 		*out = new(RolloutAnalysis)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PostPromotionAnalysis != nil {
+		in, out := &in.PostPromotionAnalysis, &out.PostPromotionAnalysis
+		*out = new(RolloutAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = new(AntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreviewMetadata != nil {
+		in, out := &in.PreviewMetadata, &out.PreviewMetadata
+		*out = new(PodTemplateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveMetadata != nil {
+		in, out := &in.ActiveMetadata, &out.ActiveMetadata
+		*out = new(PodTemplateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -368,9 +475,30 @@ func (in *BlueGreenStrategy) DeepCopy() *BlueGreenStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BurnRate) DeepCopyInto(out *BurnRate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BurnRate.
+func (in *BurnRate) DeepCopy() *BurnRate {
+	if in == nil {
+		return nil
+	}
+	out := new(BurnRate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 	*out = *in
+	if in.FeatureFlags != nil {
+		in, out := &in.FeatureFlags, &out.FeatureFlags
+		*out = make([]FeatureFlagStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -407,6 +535,26 @@ func (in *CanaryStep) DeepCopyInto(out *CanaryStep) {
 		*out = new(RolloutAnalysis)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SetFeatureFlag != nil {
+		in, out := &in.SetFeatureFlag, &out.SetFeatureFlag
+		*out = new(SetFeatureFlag)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SetHeaderRoute != nil {
+		in, out := &in.SetHeaderRoute, &out.SetHeaderRoute
+		*out = new(SetHeaderRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SetMirrorRoute != nil {
+		in, out := &in.SetMirrorRoute, &out.SetMirrorRoute
+		*out = new(SetMirrorRoute)
+		**out = **in
+	}
+	if in.SetCanaryScale != nil {
+		in, out := &in.SetCanaryScale, &out.SetCanaryScale
+		*out = new(SetCanaryScale)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -450,6 +598,26 @@ func (in *CanaryStrategy) DeepCopyInto(out *CanaryStrategy) {
 		*out = new(RolloutAnalysisBackground)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(PartitionStrategy)
+		**out = **in
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = new(AntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CanaryMetadata != nil {
+		in, out := &in.CanaryMetadata, &out.CanaryMetadata
+		*out = new(PodTemplateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StableMetadata != nil {
+		in, out := &in.StableMetadata, &out.StableMetadata
+		*out = new(PodTemplateMetadata)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -463,6 +631,133 @@ func (in *CanaryStrategy) DeepCopy() *CanaryStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudEventMetric) DeepCopyInto(out *CloudEventMetric) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]WebMetricHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudEventMetric.
+func (in *CloudEventMetric) DeepCopy() *CloudEventMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudEventMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAnalysisTemplate) DeepCopyInto(out *ClusterAnalysisTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAnalysisTemplate.
+func (in *ClusterAnalysisTemplate) DeepCopy() *ClusterAnalysisTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAnalysisTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAnalysisTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAnalysisTemplateList) DeepCopyInto(out *ClusterAnalysisTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAnalysisTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAnalysisTemplateList.
+func (in *ClusterAnalysisTemplateList) DeepCopy() *ClusterAnalysisTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAnalysisTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAnalysisTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatadogMetric) DeepCopyInto(out *DatadogMetric) {
+	*out = *in
+	if in.Queries != nil {
+		in, out := &in.Queries, &out.Queries
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(DatadogSecretRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogMetric.
+func (in *DatadogMetric) DeepCopy() *DatadogMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(DatadogMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatadogSecretRef) DeepCopyInto(out *DatadogSecretRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogSecretRef.
+func (in *DatadogSecretRef) DeepCopy() *DatadogSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(DatadogSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Experiment) DeepCopyInto(out *Experiment) {
 	*out = *in
@@ -603,6 +898,11 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -642,6 +942,10 @@ func (in *ExperimentStatus) DeepCopyInto(out *ExperimentStatus) {
 		*out = make([]ExperimentAnalysisRunStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -656,127 +960,245 @@ func (in *ExperimentStatus) DeepCopy() *ExperimentStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *IstioTrafficRouting) DeepCopyInto(out *IstioTrafficRouting) {
+func (in *FeatureFlagStatus) DeepCopyInto(out *FeatureFlagStatus) {
 	*out = *in
-	in.VirtualService.DeepCopyInto(&out.VirtualService)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioTrafficRouting.
-func (in *IstioTrafficRouting) DeepCopy() *IstioTrafficRouting {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureFlagStatus.
+func (in *FeatureFlagStatus) DeepCopy() *FeatureFlagStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(IstioTrafficRouting)
+	out := new(FeatureFlagStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *IstioVirtualService) DeepCopyInto(out *IstioVirtualService) {
+func (in *FieldRef) DeepCopyInto(out *FieldRef) {
 	*out = *in
-	if in.Routes != nil {
-		in, out := &in.Routes, &out.Routes
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioVirtualService.
-func (in *IstioVirtualService) DeepCopy() *IstioVirtualService {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldRef.
+func (in *FieldRef) DeepCopy() *FieldRef {
 	if in == nil {
 		return nil
 	}
-	out := new(IstioVirtualService)
+	out := new(FieldRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobMetric) DeepCopyInto(out *JobMetric) {
+func (in *GatewayAPITrafficRouting) DeepCopyInto(out *GatewayAPITrafficRouting) {
 	*out = *in
-	in.Metadata.DeepCopyInto(&out.Metadata)
-	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobMetric.
-func (in *JobMetric) DeepCopy() *JobMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayAPITrafficRouting.
+func (in *GatewayAPITrafficRouting) DeepCopy() *GatewayAPITrafficRouting {
 	if in == nil {
 		return nil
 	}
-	out := new(JobMetric)
+	out := new(GatewayAPITrafficRouting)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KayentaMetric) DeepCopyInto(out *KayentaMetric) {
+func (in *GraphiteMetric) DeepCopyInto(out *GraphiteMetric) {
 	*out = *in
-	out.Threshold = in.Threshold
-	if in.Scopes != nil {
-		in, out := &in.Scopes, &out.Scopes
-		*out = make([]KayentaScope, len(*in))
-		copy(*out, *in)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaMetric.
-func (in *KayentaMetric) DeepCopy() *KayentaMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GraphiteMetric.
+func (in *GraphiteMetric) DeepCopy() *GraphiteMetric {
 	if in == nil {
 		return nil
 	}
-	out := new(KayentaMetric)
+	out := new(GraphiteMetric)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KayentaScope) DeepCopyInto(out *KayentaScope) {
+func (in *HeaderRoutingMatch) DeepCopyInto(out *HeaderRoutingMatch) {
 	*out = *in
-	out.ControlScope = in.ControlScope
-	out.ExperimentScope = in.ExperimentScope
+	out.Value = in.Value
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaScope.
-func (in *KayentaScope) DeepCopy() *KayentaScope {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderRoutingMatch.
+func (in *HeaderRoutingMatch) DeepCopy() *HeaderRoutingMatch {
 	if in == nil {
 		return nil
 	}
-	out := new(KayentaScope)
+	out := new(HeaderRoutingMatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KayentaThreshold) DeepCopyInto(out *KayentaThreshold) {
+func (in *InfluxdbMetric) DeepCopyInto(out *InfluxdbMetric) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaThreshold.
-func (in *KayentaThreshold) DeepCopy() *KayentaThreshold {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InfluxdbMetric.
+func (in *InfluxdbMetric) DeepCopy() *InfluxdbMetric {
 	if in == nil {
 		return nil
 	}
-	out := new(KayentaThreshold)
+	out := new(InfluxdbMetric)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Measurement) DeepCopyInto(out *Measurement) {
+func (in *IstioDestinationRule) DeepCopyInto(out *IstioDestinationRule) {
 	*out = *in
-	if in.StartedAt != nil {
-		in, out := &in.StartedAt, &out.StartedAt
-		*out = (*in).DeepCopy()
-	}
-	if in.FinishedAt != nil {
-		in, out := &in.FinishedAt, &out.FinishedAt
-		*out = (*in).DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioDestinationRule.
+func (in *IstioDestinationRule) DeepCopy() *IstioDestinationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioDestinationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IstioTrafficRouting) DeepCopyInto(out *IstioTrafficRouting) {
+	*out = *in
+	in.VirtualService.DeepCopyInto(&out.VirtualService)
+	if in.DestinationRule != nil {
+		in, out := &in.DestinationRule, &out.DestinationRule
+		*out = new(IstioDestinationRule)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioTrafficRouting.
+func (in *IstioTrafficRouting) DeepCopy() *IstioTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IstioVirtualService) DeepCopyInto(out *IstioVirtualService) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioVirtualService.
+func (in *IstioVirtualService) DeepCopy() *IstioVirtualService {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioVirtualService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobMetric) DeepCopyInto(out *JobMetric) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobMetric.
+func (in *JobMetric) DeepCopy() *JobMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(JobMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KayentaMetric) DeepCopyInto(out *KayentaMetric) {
+	*out = *in
+	out.Threshold = in.Threshold
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]KayentaScope, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaMetric.
+func (in *KayentaMetric) DeepCopy() *KayentaMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(KayentaMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KayentaScope) DeepCopyInto(out *KayentaScope) {
+	*out = *in
+	out.ControlScope = in.ControlScope
+	out.ExperimentScope = in.ExperimentScope
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaScope.
+func (in *KayentaScope) DeepCopy() *KayentaScope {
+	if in == nil {
+		return nil
+	}
+	out := new(KayentaScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KayentaThreshold) DeepCopyInto(out *KayentaThreshold) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KayentaThreshold.
+func (in *KayentaThreshold) DeepCopy() *KayentaThreshold {
+	if in == nil {
+		return nil
+	}
+	out := new(KayentaThreshold)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Measurement) DeepCopyInto(out *Measurement) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
 	}
 	if in.Metadata != nil {
 		in, out := &in.Metadata, &out.Metadata
@@ -810,6 +1232,11 @@ func (in *Metric) DeepCopyInto(out *Metric) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(MetricRetryPolicy)
+		**out = **in
+	}
 	in.Provider.DeepCopyInto(&out.Provider)
 	return
 }
@@ -830,7 +1257,7 @@ func (in *MetricProvider) DeepCopyInto(out *MetricProvider) {
 	if in.Prometheus != nil {
 		in, out := &in.Prometheus, &out.Prometheus
 		*out = new(PrometheusMetric)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Kayenta != nil {
 		in, out := &in.Kayenta, &out.Kayenta
@@ -852,6 +1279,31 @@ func (in *MetricProvider) DeepCopyInto(out *MetricProvider) {
 		*out = new(JobMetric)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CloudEvent != nil {
+		in, out := &in.CloudEvent, &out.CloudEvent
+		*out = new(CloudEventMetric)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		*out = new(DatadogMetric)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Graphite != nil {
+		in, out := &in.Graphite, &out.Graphite
+		*out = new(GraphiteMetric)
+		**out = **in
+	}
+	if in.Influxdb != nil {
+		in, out := &in.Influxdb, &out.Influxdb
+		*out = new(InfluxdbMetric)
+		**out = **in
+	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginMetric)
+		**out = **in
+	}
 	return
 }
 
@@ -888,6 +1340,70 @@ func (in *MetricResult) DeepCopy() *MetricResult {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricRetryPolicy) DeepCopyInto(out *MetricRetryPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricRetryPolicy.
+func (in *MetricRetryPolicy) DeepCopy() *MetricRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NginxTrafficRouting) DeepCopyInto(out *NginxTrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NginxTrafficRouting.
+func (in *NginxTrafficRouting) DeepCopy() *NginxTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(NginxTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectRef) DeepCopyInto(out *ObjectRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectRef.
+func (in *ObjectRef) DeepCopy() *ObjectRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionStrategy) DeepCopyInto(out *PartitionStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionStrategy.
+func (in *PartitionStrategy) DeepCopy() *PartitionStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PauseCondition) DeepCopyInto(out *PauseCondition) {
 	*out = *in
@@ -895,58 +1411,237 @@ func (in *PauseCondition) DeepCopyInto(out *PauseCondition) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PauseCondition.
-func (in *PauseCondition) DeepCopy() *PauseCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PauseCondition.
+func (in *PauseCondition) DeepCopy() *PauseCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PauseCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginMetric) DeepCopyInto(out *PluginMetric) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginMetric.
+func (in *PluginMetric) DeepCopy() *PluginMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginTrafficRouting) DeepCopyInto(out *PluginTrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginTrafficRouting.
+func (in *PluginTrafficRouting) DeepCopy() *PluginTrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginTrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateMetadata) DeepCopyInto(out *PodTemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateMetadata.
+func (in *PodTemplateMetadata) DeepCopy() *PodTemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredDuringSchedulingIgnoredDuringExecution) DeepCopyInto(out *PreferredDuringSchedulingIgnoredDuringExecution) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredDuringSchedulingIgnoredDuringExecution.
+func (in *PreferredDuringSchedulingIgnoredDuringExecution) DeepCopy() *PreferredDuringSchedulingIgnoredDuringExecution {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredDuringSchedulingIgnoredDuringExecution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusAuthentication) DeepCopyInto(out *PrometheusAuthentication) {
+	*out = *in
+	if in.BearerTokenSecretKeyRef != nil {
+		in, out := &in.BearerTokenSecretKeyRef, &out.BearerTokenSecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(PrometheusBasicAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusAuthentication.
+func (in *PrometheusAuthentication) DeepCopy() *PrometheusAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusBasicAuth) DeepCopyInto(out *PrometheusBasicAuth) {
+	*out = *in
+	if in.PasswordSecretKeyRef != nil {
+		in, out := &in.PasswordSecretKeyRef, &out.PasswordSecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusBasicAuth.
+func (in *PrometheusBasicAuth) DeepCopy() *PrometheusBasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusBasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusMetric) DeepCopyInto(out *PrometheusMetric) {
+	*out = *in
+	if in.Range != nil {
+		in, out := &in.Range, &out.Range
+		*out = new(PrometheusRange)
+		**out = **in
+	}
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(PrometheusAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(PrometheusTLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusMetric.
+func (in *PrometheusMetric) DeepCopy() *PrometheusMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusRange) DeepCopyInto(out *PrometheusRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusRange.
+func (in *PrometheusRange) DeepCopy() *PrometheusRange {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusTLSConfig) DeepCopyInto(out *PrometheusTLSConfig) {
+	*out = *in
+	if in.CASecretKeyRef != nil {
+		in, out := &in.CASecretKeyRef, &out.CASecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusTLSConfig.
+func (in *PrometheusTLSConfig) DeepCopy() *PrometheusTLSConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(PauseCondition)
+	out := new(PrometheusTLSConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodTemplateMetadata) DeepCopyInto(out *PodTemplateMetadata) {
+func (in *RequiredDuringSchedulingIgnoredDuringExecution) DeepCopyInto(out *RequiredDuringSchedulingIgnoredDuringExecution) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateMetadata.
-func (in *PodTemplateMetadata) DeepCopy() *PodTemplateMetadata {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequiredDuringSchedulingIgnoredDuringExecution.
+func (in *RequiredDuringSchedulingIgnoredDuringExecution) DeepCopy() *RequiredDuringSchedulingIgnoredDuringExecution {
 	if in == nil {
 		return nil
 	}
-	out := new(PodTemplateMetadata)
+	out := new(RequiredDuringSchedulingIgnoredDuringExecution)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PrometheusMetric) DeepCopyInto(out *PrometheusMetric) {
+func (in *RollbackWindow) DeepCopyInto(out *RollbackWindow) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusMetric.
-func (in *PrometheusMetric) DeepCopy() *PrometheusMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackWindow.
+func (in *RollbackWindow) DeepCopy() *RollbackWindow {
 	if in == nil {
 		return nil
 	}
-	out := new(PrometheusMetric)
+	out := new(RollbackWindow)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1130,6 +1825,16 @@ func (in *RolloutExperimentTemplate) DeepCopyInto(out *RolloutExperimentTemplate
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(TemplateService)
+		**out = **in
+	}
 	return
 }
 
@@ -1211,17 +1916,46 @@ func (in *RolloutSpec) DeepCopyInto(out *RolloutSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Template.DeepCopyInto(&out.Template)
+	if in.WorkloadRef != nil {
+		in, out := &in.WorkloadRef, &out.WorkloadRef
+		*out = new(ObjectRef)
+		**out = **in
+	}
 	in.Strategy.DeepCopyInto(&out.Strategy)
 	if in.RevisionHistoryLimit != nil {
 		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
 		*out = new(int32)
 		**out = **in
 	}
+	if in.RestartAt != nil {
+		in, out := &in.RestartAt, &out.RestartAt
+		*out = (*in).DeepCopy()
+	}
 	if in.ProgressDeadlineSeconds != nil {
 		in, out := &in.ProgressDeadlineSeconds, &out.ProgressDeadlineSeconds
 		*out = new(int32)
 		**out = **in
 	}
+	if in.StuckDetection != nil {
+		in, out := &in.StuckDetection, &out.StuckDetection
+		*out = new(StuckDetection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RollbackWindow != nil {
+		in, out := &in.RollbackWindow, &out.RollbackWindow
+		*out = new(RollbackWindow)
+		**out = **in
+	}
+	if in.SLOAnalysis != nil {
+		in, out := &in.SLOAnalysis, &out.SLOAnalysis
+		*out = new(SLOAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(AnalysisRunStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1238,6 +1972,10 @@ func (in *RolloutSpec) DeepCopy() *RolloutSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
 	*out = *in
+	if in.RestartedAt != nil {
+		in, out := &in.RestartedAt, &out.RestartedAt
+		*out = (*in).DeepCopy()
+	}
 	if in.PauseConditions != nil {
 		in, out := &in.PauseConditions, &out.PauseConditions
 		*out = make([]PauseCondition, len(*in))
@@ -1262,8 +2000,9 @@ func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.Canary = in.Canary
+	in.Canary.DeepCopyInto(&out.Canary)
 	in.BlueGreen.DeepCopyInto(&out.BlueGreen)
+	in.SLO.DeepCopyInto(&out.SLO)
 	return
 }
 
@@ -1311,6 +2050,31 @@ func (in *RolloutTrafficRouting) DeepCopyInto(out *RolloutTrafficRouting) {
 		*out = new(IstioTrafficRouting)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SMI != nil {
+		in, out := &in.SMI, &out.SMI
+		*out = new(SMITrafficRouting)
+		**out = **in
+	}
+	if in.ALB != nil {
+		in, out := &in.ALB, &out.ALB
+		*out = new(ALBTrafficRouting)
+		**out = **in
+	}
+	if in.Nginx != nil {
+		in, out := &in.Nginx, &out.Nginx
+		*out = new(NginxTrafficRouting)
+		**out = **in
+	}
+	if in.GatewayAPI != nil {
+		in, out := &in.GatewayAPI, &out.GatewayAPI
+		*out = new(GatewayAPITrafficRouting)
+		**out = **in
+	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginTrafficRouting)
+		**out = **in
+	}
 	return
 }
 
@@ -1324,6 +2088,65 @@ func (in *RolloutTrafficRouting) DeepCopy() *RolloutTrafficRouting {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SLOAnalysis) DeepCopyInto(out *SLOAnalysis) {
+	*out = *in
+	if in.Objectives != nil {
+		in, out := &in.Objectives, &out.Objectives
+		*out = make([]ServiceLevelObjective, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SLOAnalysis.
+func (in *SLOAnalysis) DeepCopy() *SLOAnalysis {
+	if in == nil {
+		return nil
+	}
+	out := new(SLOAnalysis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SLOStatus) DeepCopyInto(out *SLOStatus) {
+	*out = *in
+	if in.PromotedAt != nil {
+		in, out := &in.PromotedAt, &out.PromotedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SLOStatus.
+func (in *SLOStatus) DeepCopy() *SLOStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SLOStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMITrafficRouting) DeepCopyInto(out *SMITrafficRouting) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMITrafficRouting.
+func (in *SMITrafficRouting) DeepCopy() *SMITrafficRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(SMITrafficRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScopeDetail) DeepCopyInto(out *ScopeDetail) {
 	*out = *in
@@ -1356,6 +2179,174 @@ func (in *SecretKeyRef) DeepCopy() *SecretKeyRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceLevelObjective) DeepCopyInto(out *ServiceLevelObjective) {
+	*out = *in
+	if in.BurnRates != nil {
+		in, out := &in.BurnRates, &out.BurnRates
+		*out = make([]BurnRate, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceLevelObjective.
+func (in *ServiceLevelObjective) DeepCopy() *ServiceLevelObjective {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceLevelObjective)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetCanaryScale) DeepCopyInto(out *SetCanaryScale) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetCanaryScale.
+func (in *SetCanaryScale) DeepCopy() *SetCanaryScale {
+	if in == nil {
+		return nil
+	}
+	out := new(SetCanaryScale)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetFeatureFlag) DeepCopyInto(out *SetFeatureFlag) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetFeatureFlag.
+func (in *SetFeatureFlag) DeepCopy() *SetFeatureFlag {
+	if in == nil {
+		return nil
+	}
+	out := new(SetFeatureFlag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetHeaderRoute) DeepCopyInto(out *SetHeaderRoute) {
+	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]HeaderRoutingMatch, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetHeaderRoute.
+func (in *SetHeaderRoute) DeepCopy() *SetHeaderRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(SetHeaderRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetMirrorRoute) DeepCopyInto(out *SetMirrorRoute) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetMirrorRoute.
+func (in *SetMirrorRoute) DeepCopy() *SetMirrorRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(SetMirrorRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringMatch) DeepCopyInto(out *StringMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringMatch.
+func (in *StringMatch) DeepCopy() *StringMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(StringMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StuckDetection) DeepCopyInto(out *StuckDetection) {
+	*out = *in
+	if in.PausedSeconds != nil {
+		in, out := &in.PausedSeconds, &out.PausedSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InconclusiveSeconds != nil {
+		in, out := &in.InconclusiveSeconds, &out.InconclusiveSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TrafficWeightSeconds != nil {
+		in, out := &in.TrafficWeightSeconds, &out.TrafficWeightSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StuckDetection.
+func (in *StuckDetection) DeepCopy() *StuckDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(StuckDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateService) DeepCopyInto(out *TemplateService) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateService.
+func (in *TemplateService) DeepCopy() *TemplateService {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateService)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TemplateSpec) DeepCopyInto(out *TemplateSpec) {
 	*out = *in
@@ -1370,6 +2361,16 @@ func (in *TemplateSpec) DeepCopyInto(out *TemplateSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Template.DeepCopyInto(&out.Template)
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(TemplateService)
+		**out = **in
+	}
 	return
 }
 
@@ -1451,7 +2452,14 @@ func (in *WebMetric) DeepCopyInto(out *WebMetric) {
 	if in.Headers != nil {
 		in, out := &in.Headers, &out.Headers
 		*out = make([]WebMetricHeader, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(WebMetricTLSConfig)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -1469,6 +2477,11 @@ func (in *WebMetric) DeepCopy() *WebMetric {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebMetricHeader) DeepCopyInto(out *WebMetricHeader) {
 	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1481,3 +2494,34 @@ func (in *WebMetricHeader) DeepCopy() *WebMetricHeader {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebMetricTLSConfig) DeepCopyInto(out *WebMetricTLSConfig) {
+	*out = *in
+	if in.CASecretKeyRef != nil {
+		in, out := &in.CASecretKeyRef, &out.CASecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.CertSecretKeyRef != nil {
+		in, out := &in.CertSecretKeyRef, &out.CertSecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.KeySecretKeyRef != nil {
+		in, out := &in.KeySecretKeyRef, &out.KeySecretKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebMetricTLSConfig.
+func (in *WebMetricTLSConfig) DeepCopy() *WebMetricTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebMetricTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,135 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+const (
+	example = `
+  # Show the revisions of a rollout
+  %[1]s history guestbook
+
+  # Show the details of a revision
+  %[1]s history guestbook --revision 3
+`
+	headerFmtString    = "REVISION\tHASH\tSTATUS\tCREATED\tIMAGES\tANALYSIS\tCHANGE-CAUSE\n"
+	noRevisionsMessage = "No revision history found. Revision history is recorded when the featureFlags.revisionHistory setting of the controller is enabled."
+)
+
+// NewCmdHistory returns a new instance of an `rollouts history` command
+func NewCmdHistory(o *options.ArgoRolloutsOptions) *cobra.Command {
+	var revision int64
+	var cmd = &cobra.Command{
+		Use:          "history ROLLOUT",
+		Short:        "Show the revision history of a rollout",
+		Example:      o.Example(example),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return o.UsageErr(c)
+			}
+			crs, err := historyutil.List(o.KubeClientset(), o.Namespace(), args[0])
+			if err != nil {
+				return err
+			}
+			if len(crs) == 0 {
+				fmt.Fprintln(o.ErrOut, noRevisionsMessage)
+				return nil
+			}
+			var revisions []*historyutil.Revision
+			for i := range crs {
+				rev, err := historyutil.FromControllerRevision(&crs[i])
+				if err != nil {
+					return err
+				}
+				if revision == 0 || rev.Revision == revision {
+					revisions = append(revisions, rev)
+				}
+			}
+			if revision != 0 {
+				if len(revisions) == 0 {
+					return fmt.Errorf("revision %d of rollout '%s' not found", revision, args[0])
+				}
+				printRevision(o, revisions[0])
+				return nil
+			}
+			w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+			fmt.Fprint(w, headerFmtString)
+			for _, rev := range revisions {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", rev.Revision, rev.PodTemplateHash, status(rev),
+					rev.Created.UTC().Format("2006-01-02T15:04:05Z"), strings.Join(rev.Images, ","), analysisSummary(rev), rev.ChangeCause)
+			}
+			return w.Flush()
+		},
+	}
+	o.AddKubectlFlags(cmd)
+	cmd.Flags().Int64Var(&revision, "revision", 0, "Show the details of the revision")
+	return cmd
+}
+
+func printRevision(o *options.ArgoRolloutsOptions, rev *historyutil.Revision) {
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Revision:\t%d\n", rev.Revision)
+	fmt.Fprintf(w, "Pod Template Hash:\t%s\n", rev.PodTemplateHash)
+	fmt.Fprintf(w, "Status:\t%s\n", status(rev))
+	fmt.Fprintf(w, "Created:\t%s\n", rev.Created.UTC().Format("2006-01-02T15:04:05Z"))
+	if rev.Completed != nil {
+		fmt.Fprintf(w, "Completed:\t%s\n", rev.Completed.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	if rev.ChangeCause != "" {
+		fmt.Fprintf(w, "Change Cause:\t%s\n", rev.ChangeCause)
+	}
+	fmt.Fprintf(w, "Images:\n")
+	for _, image := range rev.Images {
+		fmt.Fprintf(w, "  %s\n", image)
+	}
+	if len(rev.Analysis) > 0 {
+		fmt.Fprintf(w, "Analysis:\n")
+		for _, outcome := range rev.Analysis {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", outcome.Name, outcome.Type, outcome.Phase)
+		}
+	}
+	_ = w.Flush()
+}
+
+func status(rev *historyutil.Revision) string {
+	switch {
+	case rev.Aborted:
+		return "Aborted"
+	case rev.Completed != nil:
+		return "Completed"
+	default:
+		return "-"
+	}
+}
+
+// analysisSummary returns the number of AnalysisRuns of the revision per phase, e.g. 2 Successful
+func analysisSummary(rev *historyutil.Revision) string {
+	if len(rev.Analysis) == 0 {
+		return "-"
+	}
+	counts := map[string]int{}
+	var phases []string
+	for _, outcome := range rev.Analysis {
+		phase := string(outcome.Phase)
+		if phase == "" {
+			phase = "Pending"
+		}
+		if counts[phase] == 0 {
+			phases = append(phases, phase)
+		}
+		counts[phase]++
+	}
+	var parts []string
+	for _, phase := range phases {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[phase], phase))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,87 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	options "github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options/fake"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+func newRevisions(t *testing.T) []runtime.Object {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "test"},
+	}
+	completed := metav1.Now()
+	var objs []runtime.Object
+	for _, rev := range []*historyutil.Revision{
+		{Revision: 1, PodTemplateHash: "aaa", Images: []string{"guestbook:v1"}, Completed: &completed},
+		{Revision: 2, PodTemplateHash: "bbb", Images: []string{"guestbook:v2"}, Aborted: true, ChangeCause: "bump to v2",
+			Analysis: []historyutil.AnalysisOutcome{{Name: "guestbook-bbb-2", Type: "Background", Phase: v1alpha1.AnalysisPhaseFailed}}},
+	} {
+		cr, err := historyutil.NewControllerRevision(ro, rev)
+		assert.NoError(t, err)
+		objs = append(objs, cr)
+	}
+	return objs
+}
+
+func TestHistoryCmdUsage(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions()
+	defer tf.Cleanup()
+	cmd := NewCmdHistory(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	assert.Error(t, err)
+	stderr := o.ErrOut.(*bytes.Buffer).String()
+	assert.Contains(t, stderr, "Usage:")
+	assert.Contains(t, stderr, "history ROLLOUT")
+}
+
+func TestHistoryCmd(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions(newRevisions(t)...)
+	defer tf.Cleanup()
+	cmd := NewCmdHistory(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test"})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	stdout := o.Out.(*bytes.Buffer).String()
+	assert.Contains(t, stdout, "REVISION")
+	assert.Regexp(t, `1\s+aaa\s+Completed\s+\S+\s+guestbook:v1\s+-`, stdout)
+	assert.Regexp(t, `2\s+bbb\s+Aborted\s+\S+\s+guestbook:v2\s+1 Failed\s+bump to v2`, stdout)
+}
+
+func TestHistoryCmdRevision(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions(newRevisions(t)...)
+	defer tf.Cleanup()
+	cmd := NewCmdHistory(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test", "--revision", "2"})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	stdout := o.Out.(*bytes.Buffer).String()
+	assert.Contains(t, stdout, "bump to v2")
+	assert.Contains(t, stdout, "guestbook-bbb-2")
+
+	cmd = NewCmdHistory(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test", "--revision", "5"})
+	assert.EqualError(t, cmd.Execute(), "revision 5 of rollout 'guestbook' not found")
+}
+
+func TestHistoryCmdNoRevisions(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions()
+	defer tf.Cleanup()
+	cmd := NewCmdHistory(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, o.ErrOut.(*bytes.Buffer).String(), "No revision history found")
+}
@@ -7,38 +7,44 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 
-	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options"
-	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
 
 const (
 	example = `
-	# Promote a rollout with the canary strategy
-	%[1]s promote guestbook [--skip-steps] [--skip-current-step]
+	# Promote a paused rollout past its current step
+	%[1]s promote guestbook
+
+	# Fully promote a rollout, skipping all the remaining steps, pauses and analysis
+	%[1]s promote guestbook --full
 `
-	setCurrentStepIndex = `{
+	promotePatch = `{
+	"spec": {
+		"paused": false
+	},
 	"status": {
-		"currentStepIndex": %d
+		"promote": true
 	}
 }`
 
-	unpausePatch = `{
+	promoteFullPatch = `{
 	"spec": {
 		"paused": false
 	},
 	"status": {
-		"pauseConditions": null
+		"promoteFull": true
 	}
 }`
 	useBothSkipFlagsError         = "Cannot use skip-current-step and skip-all-steps flags at the same time"
 	skipFlagsWithBlueGreenError   = "Cannot skip steps of a bluegreen rollout. Run without a flags"
 	skipFlagWithNoStepCanaryError = "Cannot skip steps of a rollout without steps"
+	promoteAbortedRolloutError    = "Cannot promote an aborted rollout. Retry it first"
 )
 
 // NewCmdPromote returns a new instance of an `rollouts promote` command
 func NewCmdPromote(o *options.ArgoRolloutsOptions) *cobra.Command {
 	var (
+		full            = false
 		skipCurrentStep = false
 		skipAllSteps    = false
 	)
@@ -68,7 +74,10 @@ func NewCmdPromote(o *options.ArgoRolloutsOptions) *cobra.Command {
 					return fmt.Errorf(skipFlagWithNoStepCanaryError)
 				}
 			}
-			patch := getPatch(ro, skipCurrentStep, skipAllSteps)
+			if ro.Status.Abort {
+				return fmt.Errorf(promoteAbortedRolloutError)
+			}
+			patch := getPatch(full || skipAllSteps)
 			ro, err = rolloutIf.Patch(name, types.MergePatchType, patch)
 			if err != nil {
 				return err
@@ -78,25 +87,20 @@ func NewCmdPromote(o *options.ArgoRolloutsOptions) *cobra.Command {
 		},
 	}
 	o.AddKubectlFlags(cmd)
+	cmd.Flags().BoolVar(&full, "full", false, "Perform a full promotion, skipping all the remaining steps, pauses and analysis")
 	cmd.Flags().BoolVarP(&skipCurrentStep, "skip-current-step", "c", false, "Skip current step")
 	cmd.Flags().BoolVarP(&skipAllSteps, "skip-all-steps", "a", false, "Skip remaining steps")
+	_ = cmd.Flags().MarkDeprecated("skip-current-step", "promote skips the current step by default")
+	_ = cmd.Flags().MarkDeprecated("skip-all-steps", "use --full instead")
 
 	return cmd
 }
 
-func getPatch(rollout *v1alpha1.Rollout, skipCurrentStep, skipAllStep bool) []byte {
-	switch {
-	case skipCurrentStep:
-		_, index := replicasetutil.GetCurrentCanaryStep(rollout)
-		// At this point, the controller knows that the rollout is a canary with steps and GetCurrentCanaryStep returns 0 if
-		// the index is not set in the rollout
-		if *index < int32(len(rollout.Spec.Strategy.Canary.Steps)) {
-			*index++
-		}
-		return []byte(fmt.Sprintf(setCurrentStepIndex, *index))
-	case skipAllStep:
-		return []byte(fmt.Sprintf(setCurrentStepIndex, len(rollout.Spec.Strategy.Canary.Steps)))
-	default:
-		return []byte(unpausePatch)
+// getPatch returns the patch requesting the controller to promote the rollout. The controller,
+// not the CLI, moves the rollout forward, so the promotion does not race with its reconciliation.
+func getPatch(full bool) []byte {
+	if full {
+		return []byte(promoteFullPatch)
 	}
+	return []byte(promotePatch)
 }
@@ -126,7 +126,9 @@ func TestPromoteCmdSuccesSkipAllSteps(t *testing.T) {
 			if err != nil {
 				panic(err)
 			}
-			ro.Status.CurrentStepIndex = patchRo.Status.CurrentStepIndex
+			ro.Spec.Paused = patchRo.Spec.Paused
+			ro.Status.Promote = patchRo.Status.Promote
+			ro.Status.PromoteFull = patchRo.Status.PromoteFull
 		}
 		return true, &ro, nil
 	})
@@ -137,7 +139,8 @@ func TestPromoteCmdSuccesSkipAllSteps(t *testing.T) {
 
 	err := cmd.Execute()
 	assert.Nil(t, err)
-	assert.Equal(t, int32(2), *ro.Status.CurrentStepIndex)
+	assert.True(t, ro.Status.PromoteFull)
+	assert.False(t, ro.Status.Promote)
 	stdout := o.Out.(*bytes.Buffer).String()
 	stderr := o.ErrOut.(*bytes.Buffer).String()
 	assert.Equal(t, stdout, "rollout 'guestbook' promoted\n")
@@ -176,7 +179,9 @@ func TestPromoteCmdSuccesFirstStep(t *testing.T) {
 			if err != nil {
 				panic(err)
 			}
-			ro.Status.CurrentStepIndex = patchRo.Status.CurrentStepIndex
+			ro.Spec.Paused = patchRo.Spec.Paused
+			ro.Status.Promote = patchRo.Status.Promote
+			ro.Status.PromoteFull = patchRo.Status.PromoteFull
 		}
 		return true, &ro, nil
 	})
@@ -187,14 +192,15 @@ func TestPromoteCmdSuccesFirstStep(t *testing.T) {
 
 	err := cmd.Execute()
 	assert.Nil(t, err)
-	assert.Equal(t, int32(1), *ro.Status.CurrentStepIndex)
+	assert.True(t, ro.Status.Promote)
+	assert.False(t, ro.Status.PromoteFull)
 	stdout := o.Out.(*bytes.Buffer).String()
 	stderr := o.ErrOut.(*bytes.Buffer).String()
 	assert.Equal(t, stdout, "rollout 'guestbook' promoted\n")
 	assert.Empty(t, stderr)
 }
 
-func TestPromoteCmdSuccessDoNotGoPastLastStep(t *testing.T) {
+func TestPromoteCmdSuccessFullBlueGreen(t *testing.T) {
 	ro := v1alpha1.Rollout{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "guestbook",
@@ -202,21 +208,9 @@ func TestPromoteCmdSuccessDoNotGoPastLastStep(t *testing.T) {
 		},
 		Spec: v1alpha1.RolloutSpec{
 			Strategy: v1alpha1.RolloutStrategy{
-				Canary: &v1alpha1.CanaryStrategy{
-					Steps: []v1alpha1.CanaryStep{
-						{
-							SetWeight: pointer.Int32Ptr(1),
-						},
-						{
-							SetWeight: pointer.Int32Ptr(2),
-						},
-					},
-				},
+				BlueGreen: &v1alpha1.BlueGreenStrategy{},
 			},
 		},
-		Status: v1alpha1.RolloutStatus{
-			CurrentStepIndex: pointer.Int32Ptr(2),
-		},
 	}
 
 	tf, o := options.NewFakeArgoRolloutsOptions(&ro)
@@ -224,29 +218,49 @@ func TestPromoteCmdSuccessDoNotGoPastLastStep(t *testing.T) {
 	fakeClient := o.RolloutsClient.(*fakeroclient.Clientset)
 	fakeClient.PrependReactor("patch", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
 		if patchAction, ok := action.(kubetesting.PatchAction); ok {
-			patchRo := v1alpha1.Rollout{}
-			err := json.Unmarshal(patchAction.GetPatch(), &patchRo)
-			if err != nil {
-				panic(err)
+			if string(patchAction.GetPatch()) == promoteFullPatch {
+				ro.Status.PromoteFull = true
 			}
-			ro.Status.CurrentStepIndex = patchRo.Status.CurrentStepIndex
 		}
 		return true, &ro, nil
 	})
 
 	cmd := NewCmdPromote(o)
 	cmd.PersistentPreRunE = o.PersistentPreRunE
-	cmd.SetArgs([]string{"guestbook", "-c"})
+	cmd.SetArgs([]string{"guestbook", "--full"})
 
 	err := cmd.Execute()
 	assert.Nil(t, err)
-	assert.Equal(t, int32(2), *ro.Status.CurrentStepIndex)
+	assert.True(t, ro.Status.PromoteFull)
 	stdout := o.Out.(*bytes.Buffer).String()
 	stderr := o.ErrOut.(*bytes.Buffer).String()
 	assert.Equal(t, stdout, "rollout 'guestbook' promoted\n")
 	assert.Empty(t, stderr)
 }
 
+func TestPromoteAbortedRolloutError(t *testing.T) {
+	ro := v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Status: v1alpha1.RolloutStatus{
+			Abort: true,
+		},
+	}
+	tf, o := options.NewFakeArgoRolloutsOptions(&ro)
+	defer tf.Cleanup()
+	cmd := NewCmdPromote(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook"})
+	err := cmd.Execute()
+	assert.Error(t, err)
+	stdout := o.Out.(*bytes.Buffer).String()
+	stderr := o.ErrOut.(*bytes.Buffer).String()
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, promoteAbortedRolloutError)
+}
+
 func TestPromoteCmdSuccessUnpause(t *testing.T) {
 	ro := v1alpha1.Rollout{
 		ObjectMeta: metav1.ObjectMeta{
@@ -269,8 +283,8 @@ func TestPromoteCmdSuccessUnpause(t *testing.T) {
 	fakeClient := o.RolloutsClient.(*fakeroclient.Clientset)
 	fakeClient.PrependReactor("patch", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
 		if patchAction, ok := action.(kubetesting.PatchAction); ok {
-			if string(patchAction.GetPatch()) == unpausePatch {
-				ro.Status.PauseConditions = nil
+			if string(patchAction.GetPatch()) == promotePatch {
+				ro.Status.Promote = true
 				ro.Spec.Paused = false
 			}
 		}
@@ -283,9 +297,8 @@ func TestPromoteCmdSuccessUnpause(t *testing.T) {
 	err := cmd.Execute()
 	assert.Nil(t, err)
 
-	assert.Nil(t, ro.Status.PauseConditions)
+	assert.True(t, ro.Status.Promote)
 	assert.False(t, ro.Spec.Paused)
-	assert.True(t, ro.Status.ControllerPause)
 	stdout := o.Out.(*bytes.Buffer).String()
 	stderr := o.ErrOut.(*bytes.Buffer).String()
 	assert.Equal(t, stdout, "rollout 'guestbook' promoted\n")
@@ -0,0 +1,51 @@
+package restart
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	types "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options"
+)
+
+const (
+	example = `
+  # Restart the pods of a rollout now
+  %[1]s restart guestbook
+
+  # Restart the pods of a rollout in 30 minutes
+  %[1]s restart guestbook --in 30m
+`
+	restartPatch = `{"spec":{"restartAt":"%s"}}`
+)
+
+var nowFn = time.Now
+
+// NewCmdRestart returns a new instance of an `rollouts restart` command
+func NewCmdRestart(o *options.ArgoRolloutsOptions) *cobra.Command {
+	var in time.Duration
+	var cmd = &cobra.Command{
+		Use:          "restart ROLLOUT",
+		Short:        "Restart the pods of a rollout",
+		Example:      o.Example(example),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return o.UsageErr(c)
+			}
+			rolloutIf := o.RolloutsClientset().ArgoprojV1alpha1().Rollouts(o.Namespace())
+			restartAt := nowFn().Add(in).UTC().Format(time.RFC3339)
+			ro, err := rolloutIf.Patch(args[0], types.MergePatchType, []byte(fmt.Sprintf(restartPatch, restartAt)))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(o.Out, "rollout '%s' restarts at %s\n", ro.Name, restartAt)
+			return nil
+		},
+	}
+	o.AddKubectlFlags(cmd)
+	cmd.Flags().DurationVar(&in, "in", 0, "Time to wait before restarting the pods (e.g. 30s, 5m, 1h)")
+	return cmd
+}
@@ -0,0 +1,178 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	rolloutsclient "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/typed/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+const (
+	// ExitCodeDegraded is the exit code of the status command for a degraded rollout
+	ExitCodeDegraded = 2
+	// ExitCodeAborted is the exit code of the status command for an aborted rollout
+	ExitCodeAborted = 3
+	// ExitCodeTimeout is the exit code of the status command when the rollout did not complete in time
+	ExitCodeTimeout = 4
+)
+
+// ExitError is returned by the status command when the rollout is degraded or aborted, or did not
+// complete before the timeout, so the plugin exits with the code of the outcome
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e *ExitError) Error() string {
+	return e.Message
+}
+
+// NewCmdStatus returns a new instance of an `argo rollouts status` command
+func NewCmdStatus(o *options.ArgoRolloutsOptions) *cobra.Command {
+	var (
+		watch   bool
+		timeout time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   "status ROLLOUT",
+		Short: "Show the status of a rollout",
+		Long: `Show the status of a rollout: Healthy, Progressing, Paused, Degraded or Aborted.
+
+With --watch, the command waits until the rollout is Healthy, Degraded or Aborted. The command exits
+with 0 if the rollout is Healthy or still in progress without --watch, 2 if it is Degraded, 3 if it is
+Aborted and 4 if it did not complete before the --timeout.`,
+		Example: o.Example(`
+  # Show the status of a rollout
+  %[1]s status guestbook
+
+  # Wait for a rollout to complete, for at most 10 minutes
+  %[1]s status guestbook --watch --timeout 10m
+`),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return o.UsageErr(c)
+			}
+			name := args[0]
+			rolloutIf := o.RolloutsClientset().ArgoprojV1alpha1().Rollouts(o.Namespace())
+			var phase v1alpha1.RolloutPhase
+			var message string
+			if !watch {
+				ro, err := rolloutIf.Get(name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				phase, message = conditions.GetRolloutPhase(ro)
+				printStatus(o, phase, message)
+			} else {
+				ctx := context.Background()
+				if timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, timeout)
+					defer cancel()
+				}
+				var err error
+				phase, message, err = WaitForRollout(ctx, rolloutIf, name, func(phase v1alpha1.RolloutPhase, message string) {
+					printStatus(o, phase, message)
+				})
+				if err == context.DeadlineExceeded {
+					return &ExitError{Code: ExitCodeTimeout, Message: fmt.Sprintf("rollout '%s' did not complete within %s", name, timeout)}
+				}
+				if err != nil {
+					return err
+				}
+			}
+			switch phase {
+			case v1alpha1.RolloutPhaseDegraded:
+				return &ExitError{Code: ExitCodeDegraded, Message: fmt.Sprintf("rollout '%s' is degraded: %s", name, message)}
+			case v1alpha1.RolloutPhaseAborted:
+				return &ExitError{Code: ExitCodeAborted, Message: fmt.Sprintf("rollout '%s' is aborted: %s", name, message)}
+			}
+			return nil
+		},
+	}
+	o.AddKubectlFlags(cmd)
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Wait until the rollout is Healthy, Degraded or Aborted")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "The length of time to watch before giving up, e.g. 10m. Zero means wait forever")
+	return cmd
+}
+
+func printStatus(o *options.ArgoRolloutsOptions, phase v1alpha1.RolloutPhase, message string) {
+	if message != "" {
+		fmt.Fprintf(o.Out, "%s - %s\n", phase, message)
+		return
+	}
+	fmt.Fprintln(o.Out, phase)
+}
+
+// IsCompleted returns whether the phase is final for the current revision of a rollout: Healthy,
+// Degraded or Aborted
+func IsCompleted(phase v1alpha1.RolloutPhase) bool {
+	return phase == v1alpha1.RolloutPhaseHealthy || phase == v1alpha1.RolloutPhaseDegraded || phase == v1alpha1.RolloutPhaseAborted
+}
+
+// WaitForRollout watches the rollout until it is Healthy, Degraded or Aborted, and returns its final
+// phase and message. The callback is called whenever the phase or message changes. It returns the
+// error of the context if the context is done first, e.g. context.DeadlineExceeded on a timeout.
+func WaitForRollout(ctx context.Context, rolloutIf rolloutsclient.RolloutInterface, name string, onChange func(v1alpha1.RolloutPhase, string)) (v1alpha1.RolloutPhase, string, error) {
+	var lastPhase v1alpha1.RolloutPhase
+	lastMessage := ""
+	update := func(ro *v1alpha1.Rollout) (v1alpha1.RolloutPhase, string, bool) {
+		phase, message := conditions.GetRolloutPhase(ro)
+		if onChange != nil && (phase != lastPhase || message != lastMessage) {
+			onChange(phase, message)
+		}
+		lastPhase, lastMessage = phase, message
+		return phase, message, IsCompleted(phase)
+	}
+	for {
+		ro, err := rolloutIf.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		if phase, message, done := update(ro); done {
+			return phase, message, nil
+		}
+		watcher, err := rolloutIf.Watch(metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: ro.ResourceVersion,
+		})
+		if err != nil {
+			return "", "", err
+		}
+		// the rollout is read again once the API server closes the watch
+		for closed := false; !closed; {
+			select {
+			case <-ctx.Done():
+				watcher.Stop()
+				return lastPhase, lastMessage, ctx.Err()
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					closed = true
+					break
+				}
+				ro, isRollout := event.Object.(*v1alpha1.Rollout)
+				if !isRollout || ro.Name != name {
+					continue
+				}
+				if phase, message, done := update(ro); done {
+					watcher.Stop()
+					return phase, message, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return lastPhase, lastMessage, ctx.Err()
+		default:
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	fakeroclient "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	options "github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options/fake"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+func newRollout(healthy bool) *v1alpha1.Rollout {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Replicas: pointer.Int32Ptr(1),
+			Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{}},
+		},
+		Status: v1alpha1.RolloutStatus{
+			Replicas:          1,
+			UpdatedReplicas:   1,
+			AvailableReplicas: 1,
+			CurrentPodHash:    "def",
+			Canary:            v1alpha1.CanaryStatus{StableRS: "abc"},
+		},
+	}
+	ro.Status.ObservedGeneration = conditions.ComputeGenerationHash(ro.Spec)
+	if healthy {
+		ro.Status.Canary.StableRS = "def"
+	}
+	return ro
+}
+
+func TestStatusCmdUsage(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions()
+	defer tf.Cleanup()
+	cmd := NewCmdStatus(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	assert.Error(t, err)
+	stderr := o.ErrOut.(*bytes.Buffer).String()
+	assert.Contains(t, stderr, "Usage:\n  status ROLLOUT")
+}
+
+func TestStatusCmd(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions(newRollout(true))
+	defer tf.Cleanup()
+	cmd := NewCmdStatus(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Healthy\n", o.Out.(*bytes.Buffer).String())
+}
+
+func TestStatusCmdAborted(t *testing.T) {
+	ro := newRollout(false)
+	ro.Status.Abort = true
+	tf, o := options.NewFakeArgoRolloutsOptions(ro)
+	defer tf.Cleanup()
+	cmd := NewCmdStatus(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test"})
+	err := cmd.Execute()
+	exitErr, ok := err.(*ExitError)
+	assert.True(t, ok)
+	assert.Equal(t, ExitCodeAborted, exitErr.Code)
+	assert.Equal(t, "Aborted - Rollout is aborted\n", o.Out.(*bytes.Buffer).String())
+}
+
+func TestStatusCmdWatch(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions(newRollout(false))
+	defer tf.Cleanup()
+	fakeClient := o.RolloutsClient.(*fakeroclient.Clientset)
+	watcher := watch.NewFake()
+	fakeClient.PrependWatchReactor("rollouts", kubetesting.DefaultWatchReactor(watcher, nil))
+	go func() {
+		watcher.Modify(newRollout(true))
+	}()
+
+	cmd := NewCmdStatus(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test", "--watch"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Progressing - Waiting for rollout to finish steps\nHealthy\n", o.Out.(*bytes.Buffer).String())
+}
+
+func TestStatusCmdWatchTimeout(t *testing.T) {
+	tf, o := options.NewFakeArgoRolloutsOptions(newRollout(false))
+	defer tf.Cleanup()
+	fakeClient := o.RolloutsClient.(*fakeroclient.Clientset)
+	fakeClient.PrependWatchReactor("rollouts", kubetesting.DefaultWatchReactor(watch.NewFake(), nil))
+
+	cmd := NewCmdStatus(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs([]string{"guestbook", "-n", "test", "--watch", "--timeout", "10ms"})
+	err := cmd.Execute()
+	exitErr, ok := err.(*ExitError)
+	assert.True(t, ok)
+	assert.Equal(t, ExitCodeTimeout, exitErr.Code)
+}
+
+func TestWaitForRolloutDegraded(t *testing.T) {
+	ro := newRollout(false)
+	client := fakeroclient.NewSimpleClientset(ro)
+	watcher := watch.NewFake()
+	client.PrependWatchReactor("rollouts", kubetesting.DefaultWatchReactor(watcher, nil))
+	go func() {
+		degraded := ro.DeepCopy()
+		degraded.Status.Conditions = []v1alpha1.RolloutCondition{{
+			Type:    v1alpha1.RolloutProgressing,
+			Reason:  conditions.TimedOutReason,
+			Message: "ReplicaSet has timed out progressing.",
+		}}
+		watcher.Modify(degraded)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var phases []v1alpha1.RolloutPhase
+	phase, message, err := WaitForRollout(ctx, client.ArgoprojV1alpha1().Rollouts("test"), "guestbook", func(phase v1alpha1.RolloutPhase, _ string) {
+		phases = append(phases, phase)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.RolloutPhaseDegraded, phase)
+	assert.Equal(t, "ProgressDeadlineExceeded: ReplicaSet has timed out progressing.", message)
+	assert.Equal(t, []v1alpha1.RolloutPhase{v1alpha1.RolloutPhaseProgressing, v1alpha1.RolloutPhaseDegraded}, phases)
+}
@@ -0,0 +1,87 @@
+package undo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	types "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+const (
+	example = `
+  # Roll back to the previous revision
+  %[1]s undo guestbook
+
+  # Roll back to a specific revision
+  %[1]s undo guestbook --to-revision 3
+`
+)
+
+// NewCmdUndo returns a new instance of an `rollouts undo` command
+func NewCmdUndo(o *options.ArgoRolloutsOptions) *cobra.Command {
+	var toRevision int64
+	var cmd = &cobra.Command{
+		Use:          "undo ROLLOUT",
+		Short:        "Roll back a rollout to a previous revision",
+		Example:      o.Example(example),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return o.UsageErr(c)
+			}
+			name := args[0]
+			crs, err := historyutil.List(o.KubeClientset(), o.Namespace(), name)
+			if err != nil {
+				return err
+			}
+			var target *historyutil.Revision
+			switch {
+			case toRevision != 0:
+				for i := range crs {
+					if crs[i].Revision == toRevision {
+						if target, err = historyutil.FromControllerRevision(&crs[i]); err != nil {
+							return err
+						}
+					}
+				}
+			case len(crs) > 1:
+				if target, err = historyutil.FromControllerRevision(&crs[len(crs)-2]); err != nil {
+					return err
+				}
+			}
+			if target == nil {
+				if toRevision != 0 {
+					return fmt.Errorf("revision %d of rollout '%s' not found", toRevision, name)
+				}
+				return fmt.Errorf("no previous revision of rollout '%s' found", name)
+			}
+			patch, err := getPatch(target)
+			if err != nil {
+				return err
+			}
+			ro, err := o.RolloutsClientset().ArgoprojV1alpha1().Rollouts(o.Namespace()).Patch(name, types.JSONPatchType, patch)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(o.Out, "rollout '%s' rolled back to revision %d\n", ro.Name, target.Revision)
+			return nil
+		},
+	}
+	o.AddKubectlFlags(cmd)
+	cmd.Flags().Int64Var(&toRevision, "to-revision", 0, "The revision to roll back to. Defaults to the previous revision")
+	return cmd
+}
+
+// getPatch returns a JSON patch replacing the pod template, so labels and annotations added by
+// later revisions are removed rather than merged
+func getPatch(rev *historyutil.Revision) ([]byte, error) {
+	return json.Marshal([]map[string]interface{}{{
+		"op":    "replace",
+		"path":  "/spec/template",
+		"value": rev.Template,
+	}})
+}
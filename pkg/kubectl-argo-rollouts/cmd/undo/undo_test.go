@@ -0,0 +1,70 @@
+package undo
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	fakeroclient "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	options "github.com/argoproj/argo-rollouts/pkg/kubectl-argo-rollouts/options/fake"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+func newRevision(t *testing.T, ro *v1alpha1.Rollout, revision int64, image string) runtime.Object {
+	cr, err := historyutil.NewControllerRevision(ro, &historyutil.Revision{
+		Revision:        revision,
+		PodTemplateHash: fmt.Sprintf("rev%d", revision),
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "guestbook", Image: image}}},
+		},
+	})
+	assert.NoError(t, err)
+	return cr
+}
+
+func runUndo(t *testing.T, args ...string) (string, []byte, error) {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "test"},
+	}
+	tf, o := options.NewFakeArgoRolloutsOptions(ro, newRevision(t, ro, 1, "guestbook:v1"), newRevision(t, ro, 2, "guestbook:v2"), newRevision(t, ro, 3, "guestbook:v3"))
+	defer tf.Cleanup()
+	var patch []byte
+	fakeClient := o.RolloutsClient.(*fakeroclient.Clientset)
+	fakeClient.PrependReactor("patch", "rollouts", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		patch = action.(kubetesting.PatchAction).GetPatch()
+		return true, ro, nil
+	})
+	cmd := NewCmdUndo(o)
+	cmd.PersistentPreRunE = o.PersistentPreRunE
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return o.Out.(*bytes.Buffer).String(), patch, err
+}
+
+func TestUndoCmdPreviousRevision(t *testing.T) {
+	stdout, patch, err := runUndo(t, "guestbook", "-n", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "rollout 'guestbook' rolled back to revision 2\n", stdout)
+	assert.Contains(t, string(patch), `"op":"replace","path":"/spec/template"`)
+	assert.Contains(t, string(patch), "guestbook:v2")
+}
+
+func TestUndoCmdToRevision(t *testing.T) {
+	stdout, patch, err := runUndo(t, "guestbook", "-n", "test", "--to-revision", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "rollout 'guestbook' rolled back to revision 1\n", stdout)
+	assert.Contains(t, string(patch), "guestbook:v1")
+}
+
+func TestUndoCmdRevisionNotFound(t *testing.T) {
+	_, patch, err := runUndo(t, "guestbook", "-n", "test", "--to-revision", "7")
+	assert.EqualError(t, err, "revision 7 of rollout 'guestbook' not found")
+	assert.Nil(t, patch)
+}
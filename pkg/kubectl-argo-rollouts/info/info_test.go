@@ -194,6 +194,17 @@ func TestRolloutStatusPaused(t *testing.T) {
 	assert.Equal(t, "Paused", RolloutStatusString(ro))
 }
 
+func TestRolloutStatusPromoted(t *testing.T) {
+	ro := newCanaryRollout()
+	ro.Status.PauseConditions = []v1alpha1.PauseCondition{{Reason: v1alpha1.PauseReasonCanaryPauseStep}}
+	assert.Equal(t, "Paused", RolloutStatusString(ro))
+	ro.Status.Promote = true
+	assert.NotEqual(t, "Paused", RolloutStatusString(ro))
+	ro.Status.Promote = false
+	ro.Status.PromoteFull = true
+	assert.NotEqual(t, "Paused", RolloutStatusString(ro))
+}
+
 func TestRolloutStatusProgressing(t *testing.T) {
 	{
 		ro := newCanaryRollout()
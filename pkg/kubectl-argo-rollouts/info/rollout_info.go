@@ -99,7 +99,11 @@ func RolloutStatusString(ro *v1alpha1.Rollout) string {
 			return "Degraded"
 		}
 	}
-	if ro.Spec.Paused || len(ro.Status.PauseConditions) > 0 {
+	if ro.Spec.Paused {
+		return "Paused"
+	}
+	// the controller resumes a promoted rollout on its next reconciliation
+	if len(ro.Status.PauseConditions) > 0 && !ro.Status.Promote && !ro.Status.PromoteFull {
 		return "Paused"
 	}
 	if ro.Status.UpdatedReplicas < ro.Status.Replicas {
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +16,7 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
@@ -46,6 +49,10 @@ func (c *RolloutController) getAnalysisRunsForRollout(rollout *v1alpha1.Rollout)
 func (c *RolloutController) reconcileAnalysisRuns(roCtx rolloutContext) error {
 	otherArs := roCtx.OtherAnalysisRuns()
 	if roCtx.PauseContext().IsAborted() {
+		// the SLO analysis starts over when the update is retried
+		sloStatus := roCtx.NewStatus().SLO
+		sloStatus.CurrentAnalysisRun = ""
+		roCtx.SetSLOStatus(sloStatus)
 		allArs := append(roCtx.CurrentAnalysisRuns(), otherArs...)
 		return c.cancelAnalysisRuns(roCtx, allArs)
 	}
@@ -77,6 +84,21 @@ func (c *RolloutController) reconcileAnalysisRuns(roCtx rolloutContext) error {
 		if prePromotionAr != nil {
 			newCurrentAnalysisRuns = append(newCurrentAnalysisRuns, prePromotionAr)
 		}
+
+		postPromotionAr, err := c.reconcilePostPromotionAnalysisRun(roCtx)
+		if err != nil {
+			return err
+		}
+		if postPromotionAr != nil {
+			newCurrentAnalysisRuns = append(newCurrentAnalysisRuns, postPromotionAr)
+		}
+	}
+	sloAnalysisRun, err := c.reconcileSLOAnalysisRun(roCtx)
+	if err != nil {
+		return err
+	}
+	if sloAnalysisRun != nil {
+		newCurrentAnalysisRuns = append(newCurrentAnalysisRuns, sloAnalysisRun)
 	}
 	roCtx.SetCurrentAnalysisRuns(newCurrentAnalysisRuns)
 
@@ -102,6 +124,7 @@ func (c *RolloutController) reconcileAnalysisRuns(roCtx rolloutContext) error {
 
 	allRSs := roCtx.AllRSs()
 	arsToDelete := analysisutil.FilterAnalysisRunsToDelete(otherArs, allRSs)
+	arsToDelete = appendAnalysisRunsExceedingHistoryLimits(roCtx.Rollout(), otherArs, arsToDelete)
 	err = c.deleteAnalysisRuns(roCtx, arsToDelete)
 	if err != nil {
 		return err
@@ -123,8 +146,9 @@ func (c *RolloutController) reconcilePrePromotionAnalysisRun(roCtx rolloutContex
 
 	activeSelector := rollout.Status.BlueGreen.ActiveSelector
 	currentPodHash := rollout.Status.CurrentPodHash
-	// Do not create an analysis run if the rollout is active promotion happened, the rollout was just created, the newRS is not saturated
-	if activeSelector == "" || activeSelector == rollout.Status.CurrentPodHash || currentPodHash == "" || !annotations.IsSaturated(rollout, newRS) {
+	// Do not create an analysis run if the rollout is active promotion happened, the rollout was just created, the newRS is not saturated,
+	// or the rollout is fully promoted
+	if activeSelector == "" || activeSelector == rollout.Status.CurrentPodHash || currentPodHash == "" || !annotations.IsSaturated(rollout, newRS) || rollout.Status.PromoteFull {
 		err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
 		return nil, err
 	}
@@ -143,11 +167,74 @@ func (c *RolloutController) reconcilePrePromotionAnalysisRun(roCtx rolloutContex
 		}
 		return currentAr, err
 	}
+	c.recordAnalysisVerdict(roCtx, currentAr)
 	switch currentAr.Status.Phase {
 	case v1alpha1.AnalysisPhaseInconclusive:
 		roCtx.PauseContext().AddPauseCondition(v1alpha1.PauseReasonInconclusiveAnalysis)
 	case v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseFailed:
-		roCtx.PauseContext().AddAbort()
+		roCtx.PauseContext().AddAbort(fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", currentAr.Name, currentAr.Status.Phase))
+	}
+	return currentAr, nil
+}
+
+func (c *RolloutController) reconcilePostPromotionAnalysisRun(roCtx rolloutContext) (*v1alpha1.AnalysisRun, error) {
+	rollout := roCtx.Rollout()
+	newRS := roCtx.NewRS()
+	currentArs := roCtx.CurrentAnalysisRuns()
+	currentAr := analysisutil.FilterAnalysisRunsByName(currentArs, rollout.Status.BlueGreen.PostPromotionAnalysisRun)
+	if rollout.Spec.Strategy.BlueGreen.PostPromotionAnalysis == nil {
+		err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
+		return nil, err
+	}
+	roCtx.Log().Info("Reconciling Post Promotion Analysis")
+
+	activeSelector := rollout.Status.BlueGreen.ActiveSelector
+	currentPodHash := rollout.Status.CurrentPodHash
+	// Do not create an analysis run before the active service points at the newRS, or if there is no previous
+	// ReplicaSet running to switch the active service back to, or when the rollout is fully promoted
+	if currentPodHash == "" || activeSelector != currentPodHash || rollout.Status.PromoteFull {
+		err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
+		return nil, err
+	}
+
+	if getPauseCondition(rollout, v1alpha1.PauseReasonInconclusiveAnalysis) != nil {
+		return currentAr, nil
+	}
+
+	if currentAr == nil {
+		previousActiveRS := replicasetutil.GetPreviousActiveReplicaSet(rollout, roCtx.AllRSs(), activeSelector)
+		if previousActiveRS == nil || previousActiveRS.Spec.Replicas == nil || *previousActiveRS.Spec.Replicas == 0 {
+			return nil, nil
+		}
+		podHash := replicasetutil.GetPodTemplateHash(newRS)
+		instanceID := analysisutil.GetInstanceID(rollout)
+		postPromotionLabels := analysisutil.PostPromotionLabels(podHash, instanceID)
+		currentAr, err := c.createAnalysisRun(roCtx, rollout.Spec.Strategy.BlueGreen.PostPromotionAnalysis, nil, postPromotionLabels)
+		if err == nil {
+			roCtx.Log().WithField(logutil.AnalysisRunKey, currentAr.Name).Info("Created post promotion AnalysisRun")
+		}
+		return currentAr, err
+	}
+
+	// With a scaleDownDelaySeconds, the analysis is stopped once the previous ReplicaSet is due to be scaled down
+	if scaleDownDelaySeconds := rollout.Spec.Strategy.BlueGreen.ScaleDownDelaySeconds; scaleDownDelaySeconds != nil && !currentAr.Status.Phase.Completed() {
+		scaleDownAt := currentAr.CreationTimestamp.Add(time.Duration(*scaleDownDelaySeconds) * time.Second)
+		now := metav1.Now()
+		if now.After(scaleDownAt) {
+			err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
+			return currentAr, err
+		}
+		if remainingTime := scaleDownAt.Sub(now.Time); remainingTime < c.resyncPeriod {
+			c.enqueueRolloutAfter(rollout, remainingTime)
+		}
+	}
+
+	c.recordAnalysisVerdict(roCtx, currentAr)
+	switch currentAr.Status.Phase {
+	case v1alpha1.AnalysisPhaseInconclusive:
+		roCtx.PauseContext().AddPauseCondition(v1alpha1.PauseReasonInconclusiveAnalysis)
+	case v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseFailed:
+		roCtx.PauseContext().AddAbort(fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", currentAr.Name, currentAr.Status.Phase))
 	}
 	return currentAr, nil
 }
@@ -167,6 +254,11 @@ func (c *RolloutController) reconcileBackgroundAnalysisRun(roCtx rolloutContext)
 		return nil, nil
 	}
 
+	if rollout.Status.PromoteFull {
+		err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
+		return nil, err
+	}
+
 	if getPauseCondition(rollout, v1alpha1.PauseReasonInconclusiveAnalysis) != nil {
 		return currentAr, nil
 	}
@@ -181,19 +273,39 @@ func (c *RolloutController) reconcileBackgroundAnalysisRun(roCtx rolloutContext)
 		}
 		return currentAr, err
 	}
+	c.recordAnalysisVerdict(roCtx, currentAr)
 	switch currentAr.Status.Phase {
 	case v1alpha1.AnalysisPhaseInconclusive:
 		roCtx.PauseContext().AddPauseCondition(v1alpha1.PauseReasonInconclusiveAnalysis)
 	case v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseFailed:
-		roCtx.PauseContext().AddAbort()
+		roCtx.PauseContext().AddAbort(fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", currentAr.Name, currentAr.Status.Phase))
 	}
 	return currentAr, nil
 }
 
+// recordAnalysisVerdict emits an event with the outcome of a completed AnalysisRun
+func (c *RolloutController) recordAnalysisVerdict(roCtx rolloutContext, ar *v1alpha1.AnalysisRun) {
+	if !ar.Status.Phase.Completed() {
+		return
+	}
+	eventType := corev1.EventTypeWarning
+	if ar.Status.Phase == v1alpha1.AnalysisPhaseSuccessful {
+		eventType = corev1.EventTypeNormal
+	}
+	msg := fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", ar.Name, ar.Status.Phase)
+	if ar.Status.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, ar.Status.Message)
+	}
+	c.recorder.Event(roCtx.Rollout(), eventType, "AnalysisRun"+string(ar.Status.Phase), msg)
+}
+
 func (c *RolloutController) createAnalysisRun(roCtx rolloutContext, rolloutAnalysis *v1alpha1.RolloutAnalysis, stepIdx *int32, labels map[string]string) (*v1alpha1.AnalysisRun, error) {
 	newRS := roCtx.NewRS()
 	stableRS := roCtx.StableRS()
-	args := analysisutil.BuildArgumentsForRolloutAnalysisRun(rolloutAnalysis.Args, stableRS, newRS)
+	args, err := analysisutil.BuildArgumentsForRolloutAnalysisRun(rolloutAnalysis.Args, stableRS, newRS, roCtx.Rollout())
+	if err != nil {
+		return nil, err
+	}
 	podHash := replicasetutil.GetPodTemplateHash(newRS)
 	if podHash == "" {
 		return nil, fmt.Errorf("Latest ReplicaSet '%s' has no pod hash in the labels", newRS.Name)
@@ -232,11 +344,12 @@ func (c *RolloutController) reconcileStepBasedAnalysisRun(roCtx rolloutContext)
 		return currentAr, err
 	}
 
+	c.recordAnalysisVerdict(roCtx, currentAr)
 	switch currentAr.Status.Phase {
 	case v1alpha1.AnalysisPhaseInconclusive:
 		roCtx.PauseContext().AddPauseCondition(v1alpha1.PauseReasonInconclusiveAnalysis)
 	case v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseFailed:
-		roCtx.PauseContext().AddAbort()
+		roCtx.PauseContext().AddAbort(fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", currentAr.Name, currentAr.Status.Phase))
 	}
 
 	return currentAr, nil
@@ -271,6 +384,10 @@ func (c *RolloutController) newAnalysisRunFromRollout(roCtx rolloutContext, roll
 	if stepIdx != nil {
 		nameParts = append(nameParts, strconv.Itoa(int(*stepIdx)))
 	}
+	if labels[v1alpha1.RolloutTypeLabel] == v1alpha1.RolloutTypePostPromotionLabel {
+		// the pre and post promotion analyses of a revision would have the same name otherwise
+		nameParts = append(nameParts, "post")
+	}
 	if rolloutAnalysis.TemplateName != "" {
 		//TODO(dthomson) remove this code block in v0.9.0
 		nameParts = append(nameParts, rolloutAnalysis.TemplateName)
@@ -294,10 +411,10 @@ func (c *RolloutController) newAnalysisRunFromRollout(roCtx rolloutContext, roll
 	} else {
 		templates := make([]*v1alpha1.AnalysisTemplate, 0)
 		for _, templateRef := range rolloutAnalysis.Templates {
-			template, err := c.analysisTemplateLister.AnalysisTemplates(r.Namespace).Get(templateRef.TemplateName)
+			template, err := analysisutil.GetTemplate(c.analysisTemplateLister, c.clusterAnalysisTemplateLister, r.Namespace, templateRef.TemplateName, templateRef.ClusterScope)
 			if err != nil {
 				if k8serrors.IsNotFound(err) {
-					logctx.Warnf("AnalysisTemplate '%s' not found", rolloutAnalysis.TemplateName)
+					logctx.Warnf("%s '%s' not found", templateKind(templateRef.ClusterScope), templateRef.TemplateName)
 				}
 				return nil, err
 			}
@@ -316,6 +433,23 @@ func (c *RolloutController) newAnalysisRunFromRollout(roCtx rolloutContext, roll
 	return run, nil
 }
 
+// appendAnalysisRunsExceedingHistoryLimits appends the completed analysis runs beyond the history limits of
+// the rollout which are not already going to be deleted
+func appendAnalysisRunsExceedingHistoryLimits(rollout *v1alpha1.Rollout, otherArs, arsToDelete []*v1alpha1.AnalysisRun) []*v1alpha1.AnalysisRun {
+	deleting := map[string]bool{}
+	for _, ar := range arsToDelete {
+		deleting[ar.Name] = true
+	}
+	successfulLimit := defaults.GetSuccessfulRunHistoryLimitOrDefault(rollout)
+	unsuccessfulLimit := defaults.GetUnsuccessfulRunHistoryLimitOrDefault(rollout)
+	for _, ar := range analysisutil.FilterAnalysisRunsExceedingHistoryLimits(otherArs, successfulLimit, unsuccessfulLimit) {
+		if !deleting[ar.Name] {
+			arsToDelete = append(arsToDelete, ar)
+		}
+	}
+	return arsToDelete
+}
+
 func (c *RolloutController) deleteAnalysisRuns(roCtx rolloutContext, ars []*v1alpha1.AnalysisRun) error {
 	for i := range ars {
 		ar := ars[i]
@@ -44,6 +44,9 @@ func analysisRun(at *v1alpha1.AnalysisTemplate, analysisRunType string, r *v1alp
 	} else if analysisRunType == v1alpha1.RolloutTypePrePromotionLabel {
 		labels = analysisutil.PrePromotionLabels(podHash, "")
 		name = fmt.Sprintf("%s-%s-%s", r.Name, podHash, "2")
+	} else if analysisRunType == v1alpha1.RolloutTypePostPromotionLabel {
+		labels = analysisutil.PostPromotionLabels(podHash, "")
+		name = fmt.Sprintf("%s-%s-%s-%s", r.Name, podHash, "2", "post")
 	}
 	return &v1alpha1.AnalysisRun{
 		ObjectMeta: metav1.ObjectMeta{
@@ -169,6 +172,68 @@ func TestCreateBackgroundAnalysisRunWithTemplates(t *testing.T) {
 	assert.Equal(t, calculatePatch(r2, fmt.Sprintf(expectedPatch, expectedArName)), patch)
 }
 
+func TestCreateBackgroundAnalysisRunWithClusterTemplates(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{
+		SetWeight: int32Ptr(10),
+	}}
+	at := analysisTemplate("bar")
+	cat := &v1alpha1.ClusterAnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "golden-signals",
+		},
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{{
+				Name: "cluster-example",
+			}},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(0), intstr.FromInt(1))
+	r2 := bumpVersion(r1)
+	ar := analysisRun(at, v1alpha1.RolloutTypeBackgroundRunLabel, r2)
+	ar.Spec.Metrics = append(ar.Spec.Metrics, cat.Spec.Metrics...)
+	r2.Spec.Strategy.Canary.Analysis = &v1alpha1.RolloutAnalysisBackground{
+		RolloutAnalysis: v1alpha1.RolloutAnalysis{
+			Templates: []v1alpha1.RolloutAnalysisTemplates{{
+				TemplateName: at.Name,
+			}, {
+				TemplateName: cat.Name,
+				ClusterScope: true,
+			}},
+		},
+	}
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	progressingCondition, _ := newProgressingCondition(conditions.ReplicaSetUpdatedReason, rs2)
+	conditions.SetRolloutCondition(&r2.Status, progressingCondition)
+	availableCondition, _ := newAvailableCondition(true)
+	conditions.SetRolloutCondition(&r2.Status, availableCondition)
+
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.analysisTemplateLister = append(f.analysisTemplateLister, at)
+	f.clusterAnalysisTemplateLister = append(f.clusterAnalysisTemplateLister, cat)
+	f.objects = append(f.objects, r2, at, cat)
+
+	createdIndex := f.expectCreateAnalysisRunAction(ar)
+	f.expectUpdateReplicaSetAction(rs2)
+	f.expectPatchRolloutAction(r1)
+
+	f.run(getKey(r2, t))
+	createdAr := f.getCreatedAnalysisRun(createdIndex)
+	assert.Equal(t, fmt.Sprintf("%s-%s-%s", r2.Name, rs2PodHash, "2"), createdAr.Name)
+	assert.Equal(t, metav1.NamespaceDefault, createdAr.Namespace)
+	assert.Equal(t, ar.Spec.Metrics, createdAr.Spec.Metrics)
+}
+
 // TestCreateAnalysisRunWithCollision ensures we will create an new analysis run with a new name
 // when there is a conflict (e.g. such as when there is a retry)
 func TestCreateAnalysisRunWithCollision(t *testing.T) {
@@ -1583,3 +1648,118 @@ func TestAbortRolloutOnErrorPrePromotionAnalysis(t *testing.T) {
 	}`
 	assert.Equal(t, calculatePatch(r2, expectedPatch), patch)
 }
+
+// newPromotedBlueGreenRollout returns a Rollout whose active service was switched to the new ReplicaSet, and the
+// previous ReplicaSet waiting to be scaled down
+func newPromotedBlueGreenRollout(f *fixture, at *v1alpha1.AnalysisTemplate, previousAvailable int) *v1alpha1.Rollout {
+	r1 := newBlueGreenRollout("foo", 1, nil, "active", "")
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.BlueGreen.PostPromotionAnalysis = &v1alpha1.RolloutAnalysis{
+		Templates: []v1alpha1.RolloutAnalysisTemplates{{
+			TemplateName: at.Name,
+		}},
+	}
+
+	rs1 := newReplicaSetWithStatus(r1, 1, previousAvailable)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	inTheFuture := metav1.Now().Add(10 * time.Second).UTC().Format(time.RFC3339)
+	rs1.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey] = inTheFuture
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+
+	r2 = updateBlueGreenRolloutStatus(r2, "", rs2PodHash, 1, 1, 2, 1, false, true)
+	activeSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs2PodHash}
+	activeSvc := newService("active", 80, activeSelector)
+
+	f.kubeobjects = append(f.kubeobjects, activeSvc, rs1, rs2)
+	f.analysisTemplateLister = append(f.analysisTemplateLister, at)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+	f.serviceLister = append(f.serviceLister, activeSvc)
+	return r2
+}
+
+func TestCreatePostPromotionAnalysisRun(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	at := analysisTemplate("bar")
+	r2 := newPromotedBlueGreenRollout(f, at, 1)
+	ar := analysisRun(at, v1alpha1.RolloutTypePostPromotionLabel, r2)
+
+	f.objects = append(f.objects, r2, at)
+	f.rolloutLister = append(f.rolloutLister, r2)
+
+	f.expectCreateAnalysisRunAction(ar)
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+	patch := f.getPatchedRollout(patchIndex)
+	assert.Contains(t, patch, fmt.Sprintf(`"postPromotionAnalysisRun":"%s"`, ar.Name))
+}
+
+// TestDoNotCreatePostPromotionAnalysisRunWithoutPreviousReplicaSet ensures a post promotion analysis is not created
+// when there is no previous ReplicaSet to switch the active service back to
+func TestDoNotCreatePostPromotionAnalysisRunWithoutPreviousReplicaSet(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newBlueGreenRollout("foo", 1, nil, "active", "")
+	r.Spec.Strategy.BlueGreen.PostPromotionAnalysis = &v1alpha1.RolloutAnalysis{
+		Templates: []v1alpha1.RolloutAnalysisTemplates{{
+			TemplateName: "test",
+		}},
+	}
+	rs := newReplicaSetWithStatus(r, 1, 1)
+	rsPodHash := rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	r = updateBlueGreenRolloutStatus(r, "", rsPodHash, 1, 1, 1, 1, false, true)
+	r.Status.ObservedGeneration = conditions.ComputeGenerationHash(r.Spec)
+	activeSvc := newService("active", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rsPodHash})
+
+	f.objects = append(f.objects, r)
+	f.kubeobjects = append(f.kubeobjects, activeSvc, rs)
+	f.rolloutLister = append(f.rolloutLister, r)
+	f.replicaSetLister = append(f.replicaSetLister, rs)
+	f.serviceLister = append(f.serviceLister, activeSvc)
+
+	patchIndex := f.expectPatchRolloutAction(r)
+	f.run(getKey(r, t))
+	patch := f.getPatchedRollout(patchIndex)
+	assert.NotContains(t, patch, "postPromotionAnalysisRun")
+}
+
+func TestAbortRolloutOnFailedPostPromotionAnalysis(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	at := analysisTemplate("bar")
+	r2 := newPromotedBlueGreenRollout(f, at, 1)
+	ar := analysisRun(at, v1alpha1.RolloutTypePostPromotionLabel, r2)
+	ar.Status.Phase = v1alpha1.AnalysisPhaseFailed
+	r2.Status.BlueGreen.PostPromotionAnalysisRun = ar.Name
+
+	f.objects = append(f.objects, r2, at, ar)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.analysisRunLister = append(f.analysisRunLister, ar)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+	patch := f.getPatchedRollout(patchIndex)
+	assert.Contains(t, patch, `"abort":true`)
+	assert.Contains(t, patch, `"postPromotionAnalysisRun":null`)
+}
+
+// TestAbortedPostPromotionAnalysisWaitsForPreviousReplicaSet ensures the active service is only switched back once
+// the previous ReplicaSet is available
+func TestAbortedPostPromotionAnalysisWaitsForPreviousReplicaSet(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	at := analysisTemplate("bar")
+	r2 := newPromotedBlueGreenRollout(f, at, 0)
+	r2.Status.Abort = true
+
+	f.objects = append(f.objects, r2, at)
+	f.rolloutLister = append(f.rolloutLister, r2)
+
+	// the active service is not patched
+	f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+}
@@ -10,6 +10,7 @@ import (
 	"k8s.io/kubernetes/pkg/controller"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
@@ -37,7 +38,9 @@ func (c *RolloutController) rolloutBlueGreen(r *v1alpha1.Rollout, rsList []*apps
 	if reconcileBlueGreenTemplateChange(roCtx) {
 		roCtx.PauseContext().ClearPauseConditions()
 		roCtx.PauseContext().RemoveAbort()
+		roCtx.PauseContext().RemovePromoteFull()
 		logCtx.Infof("New pod template or template change detected")
+		c.reconcileRollbackWindow(roCtx)
 		return c.syncRolloutStatusBlueGreen(previewSvc, activeSvc, roCtx)
 	}
 
@@ -46,6 +49,11 @@ func (c *RolloutController) rolloutBlueGreen(r *v1alpha1.Rollout, rsList []*apps
 		return err
 	}
 
+	err = c.reconcileRestart(roCtx)
+	if err != nil {
+		return err
+	}
+
 	err = c.reconcilePreviewService(roCtx, previewSvc)
 	if err != nil {
 		return err
@@ -98,14 +106,23 @@ func (c *RolloutController) reconcileBlueGreenReplicaSets(roCtx *blueGreenContex
 	if err != nil {
 		return err
 	}
-	_, err = c.reconcileNewReplicaSet(roCtx)
-	if err != nil {
+	if err := c.reconcileBlueGreenNewReplicaSet(roCtx, activeSvc); err != nil {
 		return err
 	}
 	// Scale down old non-active replicasets, if we can.
 	_, filteredOldRS := replicasetutil.GetReplicaSetByTemplateHash(oldRSs, activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+	scaleDownRSs := filteredOldRS
+	if previousActiveRS := keepPreviousActiveReplicaSet(roCtx, activeSvc); previousActiveRS != nil {
+		// the previous ReplicaSet is scaled back up if it was scaled down in the meantime
+		logCtx.Infof("Keeping previous active ReplicaSet '%s' for the post promotion analysis", previousActiveRS.Name)
+		_, scaleDownRSs = replicasetutil.GetReplicaSetByTemplateHash(filteredOldRS, previousActiveRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey])
+		_, _, err = c.scaleReplicaSetAndRecordEvent(previousActiveRS, defaults.GetReplicasOrDefault(roCtx.Rollout().Spec.Replicas), roCtx.Rollout())
+		if err != nil {
+			return err
+		}
+	}
 	logCtx.Info("Reconciling old replica sets")
-	_, err = c.reconcileOldReplicaSets(controller.FilterActiveReplicaSets(filteredOldRS), roCtx)
+	_, err = c.reconcileOldReplicaSets(controller.FilterActiveReplicaSets(scaleDownRSs), roCtx)
 	if err != nil {
 		return err
 	}
@@ -116,6 +133,48 @@ func (c *RolloutController) reconcileBlueGreenReplicaSets(roCtx *blueGreenContex
 	return nil
 }
 
+// reconcileBlueGreenNewReplicaSet scales the new ReplicaSet, and down to zero once the
+// abortScaleDownDelaySeconds of an aborted update have passed
+func (c *RolloutController) reconcileBlueGreenNewReplicaSet(roCtx *blueGreenContext, activeSvc *corev1.Service) error {
+	rollout := roCtx.Rollout()
+	newRS := roCtx.NewRS()
+	scaleDownAt := rollout.Status.BlueGreen.AbortScaleDownAt
+	if !rollout.Status.Abort || scaleDownAt == nil || newRS == nil || activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] == replicasetutil.GetPodTemplateHash(newRS) {
+		_, err := c.reconcileNewReplicaSet(roCtx)
+		return err
+	}
+	if remaining := scaleDownAt.Sub(nowFn()); remaining > 0 {
+		roCtx.Log().Infof("Scaling down aborted ReplicaSet '%s' in %v", newRS.Name, remaining)
+		c.enqueueRolloutAfter(rollout, remaining)
+		_, err := c.reconcileNewReplicaSet(roCtx)
+		return err
+	}
+	_, _, err := c.scaleReplicaSetAndRecordEvent(newRS, 0, rollout)
+	return err
+}
+
+// keepPreviousActiveReplicaSet returns the ReplicaSet the active service pointed to before the new
+// ReplicaSet if it needs to keep running: while the post promotion analysis has not succeeded, and once the
+// rollout is aborted, until the active service is switched back to it.
+func keepPreviousActiveReplicaSet(roCtx *blueGreenContext, activeSvc *corev1.Service) *appsv1.ReplicaSet {
+	rollout := roCtx.Rollout()
+	newRS := roCtx.NewRS()
+	if rollout.Spec.Strategy.BlueGreen.PostPromotionAnalysis == nil || newRS == nil {
+		return nil
+	}
+	activeSelector := activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey]
+	if activeSelector != newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] {
+		return nil
+	}
+	if !rollout.Status.Abort && getPauseCondition(rollout, v1alpha1.PauseReasonInconclusiveAnalysis) == nil {
+		currentAr := analysisutil.FilterAnalysisRunsByName(roCtx.CurrentAnalysisRuns(), rollout.Status.BlueGreen.PostPromotionAnalysisRun)
+		if currentAr == nil || currentAr.Status.Phase == v1alpha1.AnalysisPhaseSuccessful {
+			return nil
+		}
+	}
+	return replicasetutil.GetPreviousActiveReplicaSet(rollout, roCtx.OlderRSs(), activeSelector)
+}
+
 // reconcileBlueGreenTemplateChange returns true if we detect there was a change in the pod template
 // from our current pod hash, or the newRS does not yet exist
 func reconcileBlueGreenTemplateChange(roCtx *blueGreenContext) bool {
@@ -130,6 +189,10 @@ func reconcileBlueGreenTemplateChange(roCtx *blueGreenContext) bool {
 func skipPause(roCtx *blueGreenContext, activeSvc *corev1.Service) bool {
 	rollout := roCtx.Rollout()
 	newRS := roCtx.NewRS()
+	if rollout.Status.PromoteFull {
+		roCtx.log.Info("Rollout is fully promoted and will skip pause")
+		return true
+	}
 	if _, ok := newRS.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey]; ok {
 		roCtx.log.Infof("Detected scale down annotation for ReplicaSet '%s' and will skip pause", newRS.Name)
 		return true
@@ -166,6 +229,15 @@ func (c *RolloutController) reconcileBlueGreenPause(activeSvc, previewSvc *corev
 		return
 	}
 
+	if rollout.Status.Promote {
+		roCtx.log.Info("Rollout is promoted and will resume")
+		if len(rollout.Status.PauseConditions) > 0 {
+			c.recorder.Event(rollout, corev1.EventTypeNormal, "RolloutPromoted", "Rollout promoted to the new ReplicaSet")
+		}
+		roCtx.PauseContext().ClearPauseConditions()
+		return
+	}
+
 	if skipPause(roCtx, activeSvc) {
 		roCtx.PauseContext().RemovePauseCondition(v1alpha1.PauseReasonBlueGreenPause)
 		return
@@ -205,7 +277,7 @@ func (c *RolloutController) scaleDownOldReplicaSetsForBlueGreen(oldRSs []*appsv1
 			scaleDownAtTime, err := time.Parse(time.RFC3339, scaleDownAtStr)
 			if err != nil {
 				logCtx.Warnf("Unable to read scaleDownAt label on rs '%s'", targetRS.Name)
-			} else if rollout.Spec.Strategy.BlueGreen.ScaleDownDelayRevisionLimit != nil && annotationedRSs == *rollout.Spec.Strategy.BlueGreen.ScaleDownDelayRevisionLimit {
+			} else if rollout.Spec.Strategy.BlueGreen.ScaleDownDelayRevisionLimit != nil && annotationedRSs >= *rollout.Spec.Strategy.BlueGreen.ScaleDownDelayRevisionLimit {
 				logCtx.Info("At ScaleDownDelayRevisionLimit and scaling down the rest")
 			} else {
 				now := metav1.Now()
@@ -242,9 +314,11 @@ func (c *RolloutController) syncRolloutStatusBlueGreen(previewSvc *corev1.Servic
 	allRSs := roCtx.AllRSs()
 	newStatus := c.calculateBaseStatus(roCtx)
 
-	if replicasetutil.CheckPodSpecChange(r, newRS) {
+	podSpecChanged := replicasetutil.CheckPodSpecChange(r, newRS)
+	if podSpecChanged {
 		roCtx.PauseContext().ClearPauseConditions()
 		roCtx.PauseContext().RemoveAbort()
+		roCtx.PauseContext().RemovePromoteFull()
 	}
 
 	newStatus.AvailableReplicas = replicasetutil.GetAvailableReplicaCountForReplicaSets([]*appsv1.ReplicaSet{newRS})
@@ -259,6 +333,8 @@ func (c *RolloutController) syncRolloutStatusBlueGreen(previewSvc *corev1.Servic
 	}
 
 	newStatus.BlueGreen.ActiveSelector = activeSelector
+	// the promotion lasts until the active service points at the new ReplicaSet
+	newStatus.Promote = r.Status.Promote && !podSpecChanged && !roCtx.PauseContext().IsAborted() && activeSelector != newStatus.CurrentPodHash
 	if newStatus.BlueGreen.ActiveSelector != r.Status.BlueGreen.ActiveSelector {
 		previousActiveRS, _ := replicasetutil.GetReplicaSetByTemplateHash(oldRSs, r.Status.BlueGreen.ActiveSelector)
 		if replicasetutil.GetReplicaCountForReplicaSets([]*appsv1.ReplicaSet{previousActiveRS}) > 0 {
@@ -279,11 +355,28 @@ func (c *RolloutController) syncRolloutStatusBlueGreen(previewSvc *corev1.Servic
 	}
 
 	newStatus.BlueGreen.ScaleUpPreviewCheckPoint = calculateScaleUpPreviewCheckPoint(roCtx, activeRS)
+	newStatus.BlueGreen.AbortScaleDownAt = calculateAbortScaleDownAt(roCtx, activeSelector)
 
 	newStatus = c.calculateRolloutConditions(roCtx, newStatus)
 	return c.persistRolloutStatus(roCtx, &newStatus)
 }
 
+// calculateAbortScaleDownAt returns when the new ReplicaSet of an aborted update is scaled down, or
+// nil if it is kept running
+func calculateAbortScaleDownAt(roCtx *blueGreenContext, activeSelector string) *metav1.Time {
+	r := roCtx.Rollout()
+	newRS := roCtx.NewRS()
+	delay := r.Spec.Strategy.BlueGreen.AbortScaleDownDelaySeconds
+	if !roCtx.PauseContext().IsAborted() || delay == nil || newRS == nil || activeSelector == replicasetutil.GetPodTemplateHash(newRS) {
+		return nil
+	}
+	if r.Status.BlueGreen.AbortScaleDownAt != nil {
+		return r.Status.BlueGreen.AbortScaleDownAt
+	}
+	scaleDownAt := metav1.NewTime(nowFn().Add(time.Duration(*delay) * time.Second))
+	return &scaleDownAt
+}
+
 func calculateScaleUpPreviewCheckPoint(roCtx *blueGreenContext, activeRS *appsv1.ReplicaSet) bool {
 	r := roCtx.Rollout()
 	newRS := roCtx.NewRS()
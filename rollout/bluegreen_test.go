@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	core "k8s.io/client-go/testing"
@@ -460,7 +461,8 @@ func TestBlueGreenHandlePause(t *testing.T) {
 		servicePatchIndex := f.expectPatchServiceAction(activeSvc, rs2PodHash)
 		patchedRSIndex := f.expectPatchReplicaSetAction(rs1)
 
-		generatedConditions := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true)
+		// the switch is verified against the cached service on the next reconciliation
+		generatedConditions := withServiceSelectorSwitchPending(generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true), activeSvc.Name, rs2PodHash)
 		newSelector := metav1.FormatLabelSelector(rs2.Spec.Selector)
 		expectedPatchWithoutSubs := `{
 			"status": {
@@ -557,7 +559,7 @@ func TestBlueGreenHandlePause(t *testing.T) {
 			}
 		}`
 
-		generateConditions := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs1, false)
+		generateConditions := withServiceSelectorSwitchPending(generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs1, false), activeSvc.Name, rs1PodHash)
 		newSelector := metav1.FormatLabelSelector(rs1.Spec.Selector)
 		expectedPatch := calculatePatch(r1, fmt.Sprintf(expectedPatchWithoutSubs, rs1PodHash, generateConditions, newSelector))
 		patchRolloutIndex := f.expectPatchRolloutActionWithPatch(r1, expectedPatch)
@@ -616,7 +618,7 @@ func TestBlueGreenHandlePause(t *testing.T) {
 		}`
 		assert.Equal(t, calculatePatch(r2, fmt.Sprintf(expectedUnpausePatch, unpauseConditions)), unpausePatch)
 
-		generatedConditions := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true)
+		generatedConditions := withServiceSelectorSwitchPending(generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true), activeSvc.Name, rs2PodHash)
 		expected2ndPatchWithoutSubs := `{
 			"status": {
 				"blueGreen": {
@@ -674,7 +676,7 @@ func TestBlueGreenAddScaleDownDelayToPreviousActiveReplicaSet(t *testing.T) {
 		}
 	}`
 	newSelector := metav1.FormatLabelSelector(rs2.Spec.Selector)
-	expectedCondition := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true)
+	expectedCondition := withServiceSelectorSwitchPending(generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, true), s.Name, rs2PodHash)
 	expectedPatch := calculatePatch(r2, fmt.Sprintf(expectedPatchWithoutSubs, rs2PodHash, expectedCondition, newSelector))
 	assert.Equal(t, expectedPatch, patch)
 }
@@ -1157,6 +1159,52 @@ func TestScaleDownLimit(t *testing.T) {
 	assert.Equal(t, rs1.Name, updatedRS.Name)
 }
 
+// TestScaleDownLimitBeyondLimit verifies every ReplicaSet beyond the ScaleDownDelayRevisionLimit is
+// scaled down, and not only the one at the limit
+func TestScaleDownLimitBeyondLimit(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1 := newBlueGreenRollout("foo", 1, nil, "bar", "")
+	r2 := bumpVersion(r1)
+	r3 := bumpVersion(r2)
+	r4 := bumpVersion(r3)
+	r4.Spec.Strategy.BlueGreen.ScaleDownDelayRevisionLimit = pointer.Int32Ptr(2)
+
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	rs3 := newReplicaSetWithStatus(r3, 1, 1)
+	rs4 := newReplicaSetWithStatus(r4, 1, 1)
+	rs4PodHash := rs4.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+
+	inTheFuture := metav1.Now().Add(10 * time.Second).UTC().Format(time.RFC3339)
+	rs1.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey] = inTheFuture
+	rs2.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey] = inTheFuture
+	rs3.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey] = inTheFuture
+
+	serviceSelector := map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs4PodHash}
+	s := newService("bar", 80, serviceSelector)
+	f.kubeobjects = append(f.kubeobjects, s, rs1, rs2, rs3, rs4)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2, rs3, rs4)
+
+	r4 = updateBlueGreenRolloutStatus(r4, "", rs4PodHash, 1, 1, 4, 1, false, true)
+	f.rolloutLister = append(f.rolloutLister, r4)
+	f.objects = append(f.objects, r4)
+	f.serviceLister = append(f.serviceLister, s)
+
+	updateRS2Index := f.expectUpdateReplicaSetAction(rs2)
+	updateRS1Index := f.expectUpdateReplicaSetAction(rs1)
+	f.expectPatchRolloutAction(r4)
+	f.run(getKey(r4, t))
+
+	updatedRS2 := f.getUpdatedReplicaSet(updateRS2Index)
+	assert.Equal(t, rs2.Name, updatedRS2.Name)
+	assert.Equal(t, int32(0), *updatedRS2.Spec.Replicas)
+	updatedRS1 := f.getUpdatedReplicaSet(updateRS1Index)
+	assert.Equal(t, rs1.Name, updatedRS1.Name)
+	assert.Equal(t, int32(0), *updatedRS1.Spec.Replicas)
+}
+
 // TestBlueGreenAbort Switches active service back to previous ReplicaSet when Rollout is aborted
 func TestBlueGreenAbort(t *testing.T) {
 	f := newFixture(t)
@@ -1188,7 +1236,7 @@ func TestBlueGreenAbort(t *testing.T) {
 	f.expectPatchServiceAction(s, rs1PodHash)
 	patchIndex := f.expectPatchRolloutAction(r2)
 	f.run(getKey(r2, t))
-	expectedConditions := generateConditionsPatch(true, conditions.RolloutAbortedReason, r2, true)
+	expectedConditions := withServiceSelectorSwitchPending(generateConditionsPatch(true, conditions.RolloutAbortedReason, r2, true), s.Name, rs1PodHash)
 	expectedPatch := fmt.Sprintf(`{
 		"status": {
 			"blueGreen": {
@@ -1201,3 +1249,96 @@ func TestBlueGreenAbort(t *testing.T) {
 	patch := f.getPatchedRollout(patchIndex)
 	assert.Equal(t, calculatePatch(r2, expectedPatch), patch)
 }
+
+func TestBlueGreenPromote(t *testing.T) {
+	r1 := newBlueGreenRollout("foo", 1, nil, "active", "preview")
+	r1.Spec.Strategy.BlueGreen.AutoPromotionEnabled = pointer.BoolPtr(false)
+	r2 := bumpVersion(r1)
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2PodHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	r2 = updateBlueGreenRolloutStatus(r2, rs2PodHash, rs1PodHash, 1, 1, 2, 1, true, true)
+	activeSvc := newService("active", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash})
+
+	roCtx := newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.False(t, roCtx.PauseContext().CompletedBlueGreenPause())
+	assert.False(t, skipPause(roCtx, activeSvc))
+
+	// a promotion completes the pause, but still waits for the pre-promotion analysis
+	r2.Status.Promote = true
+	roCtx = newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.True(t, roCtx.PauseContext().CompletedBlueGreenPause())
+	assert.False(t, skipPause(roCtx, activeSvc))
+
+	// a full promotion skips the pause and lasts until the next update of the rollout
+	r2.Status.Promote = false
+	r2.Status.PromoteFull = true
+	roCtx = newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.True(t, skipPause(roCtx, activeSvc))
+	newStatus := v1alpha1.RolloutStatus{}
+	roCtx.PauseContext().CalculatePauseStatus(&newStatus)
+	assert.True(t, newStatus.PromoteFull)
+	roCtx.PauseContext().RemovePromoteFull()
+	newStatus = v1alpha1.RolloutStatus{}
+	roCtx.PauseContext().CalculatePauseStatus(&newStatus)
+	assert.False(t, newStatus.PromoteFull)
+}
+
+func TestBlueGreenAbortScaleDownDelay(t *testing.T) {
+	r1 := newBlueGreenRollout("foo", 1, nil, "bar", "")
+	r2 := bumpVersion(r1)
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	r2.Status.Abort = true
+
+	// the new ReplicaSet is kept running without a delay
+	roCtx := newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.Nil(t, calculateAbortScaleDownAt(roCtx, rs1PodHash))
+
+	r2.Spec.Strategy.BlueGreen.AbortScaleDownDelaySeconds = pointer.Int32Ptr(30)
+	now := metav1.Now()
+	defer func() { nowFn = time.Now }()
+	nowFn = func() time.Time { return now.Time }
+	roCtx = newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	scaleDownAt := calculateAbortScaleDownAt(roCtx, rs1PodHash)
+	assert.Equal(t, metav1.NewTime(now.Add(30*time.Second)), *scaleDownAt)
+
+	// the scale down time is kept until the update is retried
+	r2.Status.BlueGreen.AbortScaleDownAt = &now
+	roCtx = newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.Equal(t, &now, calculateAbortScaleDownAt(roCtx, rs1PodHash))
+	r2.Status.Abort = false
+	roCtx = newBlueGreenCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil)
+	assert.Nil(t, calculateAbortScaleDownAt(roCtx, rs1PodHash))
+}
+
+func TestBlueGreenAbortScaleDown(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1 := newBlueGreenRollout("foo", 1, nil, "bar", "")
+	r1.Spec.Strategy.BlueGreen.AbortScaleDownDelaySeconds = pointer.Int32Ptr(30)
+	r2 := bumpVersion(r1)
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+
+	s := newService("bar", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: rs1PodHash})
+	f.kubeobjects = append(f.kubeobjects, s, rs1, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateBlueGreenRolloutStatus(r2, "", rs1PodHash, 1, 1, 2, 1, false, true)
+	r2.Status.Abort = true
+	inThePast := metav1.NewTime(metav1.Now().Add(-10 * time.Second))
+	r2.Status.BlueGreen.AbortScaleDownAt = &inThePast
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+	f.serviceLister = append(f.serviceLister, s)
+
+	updatedRSIndex := f.expectUpdateReplicaSetAction(rs2)
+	f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+	assert.Equal(t, int32(0), *f.getUpdatedReplicaSet(updatedRSIndex).Spec.Replicas)
+}
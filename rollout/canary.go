@@ -47,6 +47,10 @@ func (c *RolloutController) rolloutCanary(rollout *v1alpha1.Rollout, rsList []*a
 	roCtx := newCanaryCtx(rollout, newRS, previousRSs, exList, arList)
 	logCtx := roCtx.Log()
 
+	if err := c.reconcileRestart(roCtx); err != nil {
+		return err
+	}
+
 	logCtx.Info("Cleaning up old replicasets, experiments, and analysis runs")
 	if err := c.cleanupRollouts(roCtx.OlderRSs(), roCtx); err != nil {
 		return err
@@ -57,6 +61,13 @@ func (c *RolloutController) rolloutCanary(rollout *v1alpha1.Rollout, rsList []*a
 	}
 
 	if err := c.reconcileTrafficRouting(roCtx); err != nil {
+		if stuckErr := c.reconcileTrafficWeightStuck(rollout, err); stuckErr != nil {
+			logCtx.Warnf("Failed to update the Stuck condition: %v", stuckErr)
+		}
+		return err
+	}
+
+	if err := c.reconcileFeatureFlags(roCtx); err != nil {
 		return err
 	}
 
@@ -106,6 +117,11 @@ func (c *RolloutController) reconcileStableRS(roCtx *canaryContext) (bool, error
 		return false, nil
 	}
 	_, stableRSReplicaCount := replicasetutil.CalculateReplicaCountsForCanary(rollout, newRS, stableRS, olderRSs)
+	if rollout.Spec.Strategy.Canary.Partition != nil && stableRSReplicaCount < *stableRS.Spec.Replicas {
+		if err := c.orderStablePods(rollout, stableRS); err != nil {
+			return false, err
+		}
+	}
 	scaled, _, err := c.scaleReplicaSetAndRecordEvent(stableRS, stableRSReplicaCount, rollout)
 	return scaled, err
 }
@@ -230,10 +246,26 @@ func completedCurrentCanaryStep(roCtx *canaryContext) bool {
 	if currentStep.Pause != nil {
 		return roCtx.PauseContext().CompletedPauseStep(*currentStep.Pause)
 	}
-	if currentStep.SetWeight != nil && replicasetutil.AtDesiredReplicaCountsForCanary(r, roCtx.NewRS(), roCtx.StableRS(), roCtx.OlderRSs()) {
+	if currentStep.SetWeight != nil && replicasetutil.AtDesiredReplicaCountsForCanary(r, roCtx.NewRS(), roCtx.StableRS(), roCtx.OlderRSs()) && roCtx.WeightVerified() {
 		logCtx.Info("Rollout has reached the desired state for the correct weight")
 		return true
 	}
+	if currentStep.SetCanaryScale != nil && replicasetutil.AtDesiredReplicaCountsForCanary(r, roCtx.NewRS(), roCtx.StableRS(), roCtx.OlderRSs()) {
+		logCtx.Info("Rollout has reached the desired scale of the canary")
+		return true
+	}
+	if currentStep.SetHeaderRoute != nil {
+		// the traffic router set the header routes before the status is synced
+		logCtx.Infof("Rollout has set the header route '%s'", currentStep.SetHeaderRoute.Name)
+		return true
+	}
+	if currentStep.SetMirrorRoute != nil {
+		logCtx.Infof("Rollout has set the mirror route to %d%%", currentStep.SetMirrorRoute.Percentage)
+		return true
+	}
+	if currentStep.SetFeatureFlag != nil && completedFeatureFlagStep(roCtx, *currentStep.SetFeatureFlag) {
+		return true
+	}
 	experiment := roCtx.CurrentExperiment()
 	if currentStep.Experiment != nil && experiment != nil && experiment.Status.Phase.Completed() && experiment.Status.Phase == v1alpha1.AnalysisPhaseSuccessful {
 		return true
@@ -277,6 +309,10 @@ func (c *RolloutController) syncRolloutStatusCanary(roCtx *canaryContext) error
 		}
 		roCtx.PauseContext().ClearPauseConditions()
 		roCtx.PauseContext().RemoveAbort()
+		roCtx.PauseContext().RemovePromoteFull()
+		if c.reconcileRollbackWindow(roCtx) && newStatus.CurrentStepIndex != nil {
+			newStatus.CurrentStepIndex = pointer.Int32Ptr(stepCount)
+		}
 		newStatus = c.calculateRolloutConditions(roCtx, newStatus)
 		return c.persistRolloutStatus(roCtx, &newStatus)
 	}
@@ -294,6 +330,7 @@ func (c *RolloutController) syncRolloutStatusCanary(roCtx *canaryContext) error
 		}
 		roCtx.PauseContext().ClearPauseConditions()
 		roCtx.PauseContext().RemoveAbort()
+		roCtx.PauseContext().RemovePromoteFull()
 		newStatus = c.calculateRolloutConditions(roCtx, newStatus)
 		return c.persistRolloutStatus(roCtx, &newStatus)
 	}
@@ -332,6 +369,20 @@ func (c *RolloutController) syncRolloutStatusCanary(roCtx *canaryContext) error
 		return c.persistRolloutStatus(roCtx, &newStatus)
 	}
 
+	if r.Status.Promote || r.Status.PromoteFull {
+		stepIndex := *currentStepIndex + 1
+		if r.Status.PromoteFull {
+			stepIndex = stepCount
+		}
+		newStatus.CurrentStepIndex = &stepIndex
+		newStatus.Canary.CurrentStepAnalysisRun = ""
+		logCtx.Infof("Promoting the rollout to step %d", stepIndex)
+		c.recorder.Eventf(r, corev1.EventTypeNormal, "RolloutPromoted", "Rollout promoted to step %d", stepIndex)
+		roCtx.PauseContext().ClearPauseConditions()
+		newStatus = c.calculateRolloutConditions(roCtx, newStatus)
+		return c.persistRolloutStatus(roCtx, &newStatus)
+	}
+
 	if completedCurrentCanaryStep(roCtx) {
 		*currentStepIndex++
 		newStatus.CurrentStepIndex = currentStepIndex
@@ -21,6 +21,7 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 )
 
 func newCanaryRollout(name string, replicas int, revisionHistoryLimit *int32, steps []v1alpha1.CanaryStep, stepIndex *int32, maxSurge, maxUnavailable intstr.IntOrString) *v1alpha1.Rollout {
@@ -121,6 +122,42 @@ func TestCanaryRolloutEnterPauseState(t *testing.T) {
 	assert.Equal(t, expectedPatch, patch)
 }
 
+func TestCanaryRolloutEnterPauseStatePhase(t *testing.T) {
+	configutil.SetDefaults(map[string]string{configutil.RolloutPhaseKey: "true"})
+	defer configutil.SetDefaults(nil)
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+
+	var patch struct {
+		Status v1alpha1.RolloutStatus `json:"status"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(f.getPatchedRollout(patchIndex)), &patch))
+	assert.Equal(t, v1alpha1.RolloutPhasePaused, patch.Status.Phase)
+	assert.Equal(t, "Rollout is paused (CanaryPauseStep)", patch.Status.Message)
+}
+
 func TestCanaryRolloutNoProgressWhilePaused(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
@@ -274,6 +311,96 @@ func TestCanaryRolloutIncrementStepAfterUnPaused(t *testing.T) {
 	assert.Equal(t, expectedPatch, patch)
 }
 
+func TestCanaryRolloutPromote(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.kubeobjects = append(f.kubeobjects, rs1)
+	f.replicaSetLister = append(f.replicaSetLister, rs1)
+
+	r2 := bumpVersion(r1)
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	r2.Status.AvailableReplicas = 10
+	r2.Status.Promote = true
+
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+	f.kubeobjects = append(f.kubeobjects, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+	patch := f.getPatchedRollout(patchIndex)
+	expectedPatchTemplate := `{
+	"status":{
+		"promote": null,
+		"conditions" : %s,
+		"currentStepIndex": 1
+	}
+}`
+	generatedConditions := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, false)
+	expectedPatch := calculatePatch(r2, fmt.Sprintf(expectedPatchTemplate, generatedConditions))
+	assert.Equal(t, expectedPatch, patch)
+}
+
+func TestCanaryRolloutPromoteFull(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+		{
+			Pause: &v1alpha1.RolloutPause{},
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.kubeobjects = append(f.kubeobjects, rs1)
+	f.replicaSetLister = append(f.replicaSetLister, rs1)
+
+	r2 := bumpVersion(r1)
+	rs2 := newReplicaSetWithStatus(r2, 0, 0)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	r2.Status.AvailableReplicas = 10
+	r2.Status.PromoteFull = true
+
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+	f.kubeobjects = append(f.kubeobjects, rs2)
+	f.replicaSetLister = append(f.replicaSetLister, rs2)
+
+	// the full promotion is kept until the next update of the rollout
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+	patch := f.getPatchedRollout(patchIndex)
+	expectedPatchTemplate := `{
+	"status":{
+		"conditions" : %s,
+		"currentStepIndex": 2
+	}
+}`
+	generatedConditions := generateConditionsPatch(true, conditions.ReplicaSetUpdatedReason, rs2, false)
+	expectedPatch := calculatePatch(r2, fmt.Sprintf(expectedPatchTemplate, generatedConditions))
+	assert.Equal(t, expectedPatch, patch)
+}
+
 func TestCanaryRolloutUpdateStatusWhenAtEndOfSteps(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
@@ -638,16 +765,14 @@ func TestRollBackToStable(t *testing.T) {
 	f.rolloutLister = append(f.rolloutLister, r2)
 	f.objects = append(f.objects, r2)
 
-	updatedRSIndex := f.expectUpdateReplicaSetAction(rs1)
+	patchedRSIndex := f.expectPatchReplicaSetAction(rs1)
 	f.expectUpdateReplicaSetAction(rs1)
 	patchIndex := f.expectPatchRolloutAction(r2)
 	f.run(getKey(r2, t))
 
-	expectedRS1 := rs1.DeepCopy()
-	expectedRS1.Annotations[annotations.RevisionAnnotation] = "3"
-	expectedRS1.Annotations[annotations.RevisionHistoryAnnotation] = "1"
-	firstUpdatedRS1 := f.getUpdatedReplicaSet(updatedRSIndex)
-	assert.Equal(t, expectedRS1, firstUpdatedRS1)
+	// only the changed annotations are sent
+	expectedRSPatch := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"3","%s":"1"}}}`, annotations.RevisionAnnotation, annotations.RevisionHistoryAnnotation)
+	assert.Equal(t, expectedRSPatch, f.getPatchedReplicaSet(patchedRSIndex))
 
 	expectedPatchWithoutSub := `{
 		"status":{
@@ -728,14 +853,13 @@ func TestRollBackToStableAndStepChange(t *testing.T) {
 	f.rolloutLister = append(f.rolloutLister, r2)
 	f.objects = append(f.objects, r2)
 
-	updatedRSIndex := f.expectUpdateReplicaSetAction(rs1)
+	patchedRSIndex := f.expectPatchReplicaSetAction(rs1)
 	f.expectUpdateReplicaSetAction(rs1)
 	patchIndex := f.expectPatchRolloutAction(r2)
 	f.run(getKey(r2, t))
 
-	updatedReplicaSet := f.getUpdatedReplicaSet(updatedRSIndex)
-	assert.Equal(t, "3", updatedReplicaSet.Annotations[annotations.RevisionAnnotation])
-	assert.Equal(t, "1", updatedReplicaSet.Annotations[annotations.RevisionHistoryAnnotation])
+	expectedRSPatch := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"3","%s":"1"}}}`, annotations.RevisionAnnotation, annotations.RevisionHistoryAnnotation)
+	assert.Equal(t, expectedRSPatch, f.getPatchedReplicaSet(patchedRSIndex))
 
 	expectedPatchWithoutSub := `{
 		"status":{
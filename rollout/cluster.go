@@ -0,0 +1,175 @@
+package rollout
+
+import (
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// kubeClientForCluster returns the client of the registered cluster, or the client of the
+// controller's cluster if the name is empty
+func (c *RolloutController) kubeClientForCluster(name string) (kubernetes.Interface, error) {
+	if name == "" {
+		return c.kubeclientset, nil
+	}
+	cluster, err := c.clusters.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Client, nil
+}
+
+// kubeClientForReplicaSet returns the client of the cluster the ReplicaSet was created in
+func (c *RolloutController) kubeClientForReplicaSet(rs *appsv1.ReplicaSet) (kubernetes.Interface, error) {
+	return c.kubeClientForCluster(replicasetutil.GetReplicaSetCluster(rs))
+}
+
+// replicaSetClusters returns the sorted names of the registered clusters the ReplicaSets were
+// created in
+func replicaSetClusters(rsList []*appsv1.ReplicaSet) []string {
+	var clusters []string
+	seen := map[string]bool{}
+	for _, rs := range rsList {
+		if rs == nil {
+			continue
+		}
+		if cluster := replicasetutil.GetReplicaSetCluster(rs); cluster != "" && !seen[cluster] {
+			seen[cluster] = true
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// usesClusters returns whether the ReplicaSets of the rollout are or were created in registered
+// clusters
+func usesClusters(r *v1alpha1.Rollout) bool {
+	return replicasetutil.GetRolloutCluster(r) != "" || hasClusterReplicaSetsFinalizer(r)
+}
+
+func hasClusterReplicaSetsFinalizer(r *v1alpha1.Rollout) bool {
+	for _, finalizer := range r.Finalizers {
+		if finalizer == clusterutil.ReplicaSetsFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// getClusterReplicaSets returns the ReplicaSets of the rollout created in the registered clusters.
+// The ReplicaSets are not owned by the rollout, so they are found by the rollout label and the
+// selector of the rollout.
+func (c *RolloutController) getClusterReplicaSets(r *v1alpha1.Rollout) ([]*appsv1.ReplicaSet, error) {
+	if !usesClusters(r) {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(r.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("rollout %s/%s has invalid label selector: %v", r.Namespace, r.Name, err)
+	}
+	clusters, err := c.clusters.List()
+	if err != nil {
+		return nil, err
+	}
+	var rsList []*appsv1.ReplicaSet
+	for _, cluster := range clusters {
+		// a ReplicaSet missing from the list would be created or scaled again
+		if !cluster.HasSynced() {
+			return nil, fmt.Errorf("ReplicaSets of cluster '%s' are not synced", cluster.Name)
+		}
+		list, err := cluster.ReplicaSetLister.ReplicaSets(r.Namespace).List(labels.SelectorFromSet(labels.Set{clusterutil.RolloutLabelKey: r.Name}))
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range list {
+			if replicasetutil.GetReplicaSetCluster(rs) != cluster.Name || !selector.Matches(labels.Set(rs.Labels)) {
+				continue
+			}
+			rsList = append(rsList, rs)
+		}
+	}
+	return rsList, nil
+}
+
+// getReplicaSet returns the ReplicaSet of the cluster from the cache
+func (c *RolloutController) getReplicaSet(clusterName, namespace, name string) (*appsv1.ReplicaSet, error) {
+	if clusterName == "" {
+		return c.replicaSetLister.ReplicaSets(namespace).Get(name)
+	}
+	cluster, err := c.clusters.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.ReplicaSetLister.ReplicaSets(namespace).Get(name)
+}
+
+// isReplicaSetOfRollout returns whether the ReplicaSet belongs to the rollout: it is owned by the
+// rollout in the cluster of the controller, or labeled with its name in a registered cluster
+func isReplicaSetOfRollout(rs *appsv1.ReplicaSet, r *v1alpha1.Rollout) bool {
+	if replicasetutil.GetReplicaSetCluster(rs) != "" {
+		return rs.Labels[clusterutil.RolloutLabelKey] == r.Name
+	}
+	controllerRef := metav1.GetControllerOf(rs)
+	return controllerRef != nil && controllerRef.UID == r.UID
+}
+
+// addClusterReplicaSetsFinalizer adds the finalizer deleting the ReplicaSets of the registered
+// clusters with the rollout, before the first of them is created. The finalizer is also added to r.
+func (c *RolloutController) addClusterReplicaSetsFinalizer(r *v1alpha1.Rollout) (*v1alpha1.Rollout, error) {
+	if hasClusterReplicaSetsFinalizer(r) {
+		return r, nil
+	}
+	addFinalizer := func(ro *v1alpha1.Rollout) bool {
+		if hasClusterReplicaSetsFinalizer(ro) {
+			return false
+		}
+		ro.Finalizers = append(ro.Finalizers, clusterutil.ReplicaSetsFinalizer)
+		return true
+	}
+	addFinalizer(r)
+	return c.updateRolloutWithRetry(r, addFinalizer)
+}
+
+// finalizeClusterReplicaSets deletes the ReplicaSets of a deleted rollout in every registered
+// cluster and removes its finalizer. The ReplicaSets of clusters which are no longer registered are
+// left behind.
+func (c *RolloutController) finalizeClusterReplicaSets(r *v1alpha1.Rollout) error {
+	logCtx := logutil.WithRollout(r)
+	clusters, err := c.clusters.List()
+	if err != nil {
+		return err
+	}
+	listOptions := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{clusterutil.RolloutLabelKey: r.Name}).String()}
+	for _, cluster := range clusters {
+		err := cluster.Client.AppsV1().ReplicaSets(r.Namespace).DeleteCollection(&metav1.DeleteOptions{}, listOptions)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the ReplicaSets of cluster '%s': %v", cluster.Name, err)
+		}
+		logCtx.Infof("Deleted the ReplicaSets of cluster '%s'", cluster.Name)
+	}
+	removeFinalizer := func(ro *v1alpha1.Rollout) bool {
+		var finalizers []string
+		for _, finalizer := range ro.Finalizers {
+			if finalizer != clusterutil.ReplicaSetsFinalizer {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		modified := len(finalizers) != len(ro.Finalizers)
+		ro.Finalizers = finalizers
+		return modified
+	}
+	removeFinalizer(r)
+	_, err = c.updateRolloutWithRetry(r, removeFinalizer)
+	return err
+}
@@ -0,0 +1,230 @@
+package rollout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// fakeClusterRegistry registers clusters whose clients and ReplicaSet caches are fakes
+type fakeClusterRegistry struct {
+	clusters []*clusterutil.Cluster
+}
+
+func (r *fakeClusterRegistry) Get(name string) (*clusterutil.Cluster, error) {
+	for _, cluster := range r.clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster '%s' is not registered", name)
+}
+
+func (r *fakeClusterRegistry) List() ([]*clusterutil.Cluster, error) {
+	return r.clusters, nil
+}
+
+func (r *fakeClusterRegistry) AddReplicaSetEventHandler(handler cache.ResourceEventHandler) {}
+
+// addCluster registers a cluster holding the objects, whose ReplicaSets are also cached
+func (r *fakeClusterRegistry) addCluster(name string, objects ...runtime.Object) *k8sfake.Clientset {
+	client := k8sfake.NewSimpleClientset(objects...)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objects {
+		if rs, ok := obj.(*appsv1.ReplicaSet); ok {
+			indexer.Add(rs)
+		}
+	}
+	r.clusters = append(r.clusters, &clusterutil.Cluster{
+		Name:             name,
+		Client:           client,
+		ReplicaSetLister: appslisters.NewReplicaSetLister(indexer),
+		HasSynced:        func() bool { return true },
+	})
+	return client
+}
+
+// newClusterRollouts returns a rollout and its next revision, whose ReplicaSet is created in the
+// cluster
+func newClusterRollouts(cluster string) (*v1alpha1.Rollout, *v1alpha1.Rollout) {
+	steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r1.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	r1.Spec.Strategy.Canary.CanaryService = "canary"
+	r1.Spec.Strategy.Canary.StableService = "stable"
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.Cluster = cluster
+	return r1, r2
+}
+
+// newClusterReplicaSet returns the ReplicaSet of the rollout created in its cluster
+func newClusterReplicaSet(r *v1alpha1.Rollout, replicas int) *appsv1.ReplicaSet {
+	rs := newReplicaSetWithStatus(r, replicas, replicas)
+	podHash := replicasetutil.ComputePodTemplateHash(r)
+	rs.Name = fmt.Sprintf("%s-%s", r.Name, podHash)
+	rs.OwnerReferences = nil
+	rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] = podHash
+	rs.Labels[clusterutil.RolloutLabelKey] = r.Name
+	rs.Annotations[annotations.ClusterAnnotation] = replicasetutil.GetRolloutCluster(r)
+	return rs
+}
+
+func TestGetNewReplicaSetCreatesReplicaSetInCluster(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newClusterRollouts("east")
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	f.objects = append(f.objects, r2)
+	east := f.clusters.addCluster("east")
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	newRS, err := c.getNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1}, []*appsv1.ReplicaSet{rs1}, true)
+	assert.NoError(t, err)
+	// the cluster is part of the pod template hash
+	podHash := replicasetutil.ComputePodTemplateHash(r2)
+	assert.NotEqual(t, podHash, replicasetutil.ComputePodTemplateHash(r1))
+	assert.Equal(t, "foo-"+podHash, newRS.Name)
+	assert.Empty(t, newRS.OwnerReferences)
+	assert.Equal(t, "foo", newRS.Labels[clusterutil.RolloutLabelKey])
+	assert.Equal(t, "east", newRS.Annotations[annotations.ClusterAnnotation])
+
+	eastActions := filterInformerActions(east.Actions())
+	assert.Len(t, eastActions, 1)
+	assert.True(t, eastActions[0].Matches("create", "replicasets"))
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+	// the finalizer is added before the ReplicaSet is created
+	actions := filterInformerActions(f.client.Actions())
+	assert.True(t, len(actions) > 0)
+	updated := actions[0].(core.UpdateAction).GetObject().(*v1alpha1.Rollout)
+	assert.Contains(t, updated.Finalizers, clusterutil.ReplicaSetsFinalizer)
+}
+
+func TestGetNewReplicaSetFailsForUnregisteredCluster(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newClusterRollouts("west")
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	f.objects = append(f.objects, r2)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	_, err := c.getNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1}, []*appsv1.ReplicaSet{rs1}, true)
+	assert.EqualError(t, err, "cluster 'west' is not registered")
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+}
+
+func TestGetReplicaSetsForRolloutsListsClusterReplicaSets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newClusterRollouts("east")
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newClusterReplicaSet(r2, 1)
+	// a ReplicaSet annotated with another cluster is not one of the cluster
+	other := newClusterReplicaSet(r2, 1)
+	other.Name = "foo-other"
+	other.Annotations[annotations.ClusterAnnotation] = "west"
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+	f.replicaSetLister = append(f.replicaSetLister, rs1)
+	f.kubeobjects = append(f.kubeobjects, rs1)
+	f.clusters.addCluster("east", rs2, other)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	rsList, err := c.getReplicaSetsForRollouts(r2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*appsv1.ReplicaSet{rs1, rs2}, rsList)
+	assert.Equal(t, rs2, replicasetutil.FindNewReplicaSet(r2, rsList))
+
+	// a rollout which never used a registered cluster does not list them
+	rsList, err = c.getReplicaSetsForRollouts(r1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*appsv1.ReplicaSet{rs1}, rsList)
+}
+
+func TestScaleReplicaSetInCluster(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	_, r2 := newClusterRollouts("east")
+	rs2 := newClusterReplicaSet(r2, 1)
+	east := f.clusters.addCluster("east", rs2)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	scaled, _, err := c.scaleReplicaSet(rs2, 2, r2, "up")
+	assert.NoError(t, err)
+	assert.True(t, scaled)
+	eastActions := filterInformerActions(east.Actions())
+	assert.Len(t, eastActions, 1)
+	assert.True(t, eastActions[0].Matches("update", "replicasets"))
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+}
+
+func TestSwitchClusterServiceSelectors(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newClusterRollouts("east")
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newClusterReplicaSet(r2, 1)
+	canaryHash := rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	east := f.clusters.addCluster("east", rs2, newService("canary", 80, map[string]string{"foo": "bar"}))
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, nil)
+
+	err := c.switchClusterServiceSelectors(roCtx, "canary", canaryHash)
+	assert.NoError(t, err)
+	svc, err := east.CoreV1().Services(metav1.NamespaceDefault).Get("canary", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, canaryHash, svc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+
+	// the service is not read again until the value to select changes
+	requests := len(east.Actions())
+	assert.NoError(t, c.switchClusterServiceSelectors(roCtx, "canary", canaryHash))
+	assert.Len(t, east.Actions(), requests)
+
+	err = c.switchClusterServiceSelectors(roCtx, "stable", canaryHash)
+	assert.EqualError(t, err, fmt.Sprintf(conditions.ClusterServiceNotFoundMessage, "stable", "east"))
+}
+
+func TestFinalizeClusterReplicaSets(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	_, r2 := newClusterRollouts("east")
+	r2.Finalizers = []string{clusterutil.ReplicaSetsFinalizer}
+	now := metav1.Now()
+	r2.DeletionTimestamp = &now
+	rs2 := newClusterReplicaSet(r2, 1)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+	east := f.clusters.addCluster("east", rs2)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	assert.NoError(t, c.syncHandler(getKey(r2, t)))
+	eastActions := filterInformerActions(east.Actions())
+	assert.Len(t, eastActions, 1)
+	deleteAction := eastActions[0].(core.DeleteCollectionAction)
+	assert.Equal(t, "rollout.argoproj.io/rollout=foo", deleteAction.GetListRestrictions().Labels.String())
+	actions := filterInformerActions(f.client.Actions())
+	assert.Len(t, actions, 1)
+	updated := actions[0].(core.UpdateAction).GetObject().(*v1alpha1.Rollout)
+	assert.Empty(t, updated.Finalizers)
+}
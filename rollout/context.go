@@ -4,6 +4,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
@@ -27,6 +28,8 @@ type rolloutContext interface {
 	PauseContext() *pauseContext
 	NewStatus() v1alpha1.RolloutStatus
 	SetCurrentAnalysisRuns([]*v1alpha1.AnalysisRun)
+	SetSLOStatus(v1alpha1.SLOStatus)
+	SetRestartedAt(*metav1.Time)
 }
 
 type blueGreenContext struct {
@@ -62,6 +65,9 @@ type canaryContext struct {
 
 	newStatus    v1alpha1.RolloutStatus
 	pauseContext *pauseContext
+
+	// weightVerified is set once the traffic router verified whether the desired weight is applied
+	weightVerified *bool
 }
 
 func newBlueGreenCtx(r *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, olderRSs []*appsv1.ReplicaSet, arList []*v1alpha1.AnalysisRun) *blueGreenContext {
@@ -79,7 +85,9 @@ func newBlueGreenCtx(r *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, olderRSs []*
 		olderRSs: olderRSs,
 		allRSs:   allRSs,
 
-		newStatus: v1alpha1.RolloutStatus{},
+		// the SLO status is kept by the syncs which do not reconcile the AnalysisRuns, and the time of
+		// the last restart by the syncs which do not restart the pods
+		newStatus: v1alpha1.RolloutStatus{SLO: *r.Status.SLO.DeepCopy(), RestartedAt: r.Status.RestartedAt.DeepCopy()},
 		pauseContext: &pauseContext{
 			rollout: r,
 			log:     logCtx,
@@ -134,6 +142,13 @@ func (cCtx *blueGreenContext) SetCurrentAnalysisRuns(ars []*v1alpha1.AnalysisRun
 			cCtx.newStatus.BlueGreen.PrePromotionAnalysisRun = currPrePromoAr.Name
 		}
 	}
+	currPostPromoAr := analysisutil.GetCurrentAnalysisRunByType(ars, v1alpha1.RolloutTypePostPromotionLabel)
+	if currPostPromoAr != nil && !cCtx.PauseContext().IsAborted() {
+		switch currPostPromoAr.Status.Phase {
+		case v1alpha1.AnalysisPhasePending, v1alpha1.AnalysisPhaseRunning, v1alpha1.AnalysisPhaseSuccessful, "":
+			cCtx.newStatus.BlueGreen.PostPromotionAnalysisRun = currPostPromoAr.Name
+		}
+	}
 }
 
 func (bgCtx *blueGreenContext) OtherExperiments() []*v1alpha1.Experiment {
@@ -148,6 +163,14 @@ func (bgCtx *blueGreenContext) NewStatus() v1alpha1.RolloutStatus {
 	return bgCtx.newStatus
 }
 
+func (bgCtx *blueGreenContext) SetSLOStatus(status v1alpha1.SLOStatus) {
+	bgCtx.newStatus.SLO = status
+}
+
+func (bgCtx *blueGreenContext) SetRestartedAt(restartedAt *metav1.Time) {
+	bgCtx.newStatus.RestartedAt = restartedAt
+}
+
 func newCanaryCtx(r *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, otherRSs []*appsv1.ReplicaSet, exList []*v1alpha1.Experiment, arList []*v1alpha1.AnalysisRun) *canaryContext {
 	allRSs := append(otherRSs, newRS)
 	stableRS := replicasetutil.GetStableRS(r, newRS, otherRSs)
@@ -171,7 +194,9 @@ func newCanaryCtx(r *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, otherRSs []*app
 		currentEx: currentEx,
 		otherExs:  otherExs,
 
-		newStatus: v1alpha1.RolloutStatus{},
+		// the SLO status is kept by the syncs which do not reconcile the AnalysisRuns, and the time of
+		// the last restart by the syncs which do not restart the pods
+		newStatus: v1alpha1.RolloutStatus{SLO: *r.Status.SLO.DeepCopy(), RestartedAt: r.Status.RestartedAt.DeepCopy()},
 		pauseContext: &pauseContext{
 			rollout: r,
 			log:     logCtx,
@@ -179,6 +204,11 @@ func newCanaryCtx(r *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, otherRSs []*app
 	}
 }
 
+// WeightVerified returns false if the traffic router did not apply the desired weight yet
+func (cCtx *canaryContext) WeightVerified() bool {
+	return cCtx.weightVerified == nil || *cCtx.weightVerified
+}
+
 func (cCtx *canaryContext) Rollout() *v1alpha1.Rollout {
 	return cCtx.rollout
 }
@@ -255,3 +285,11 @@ func (cCtx *canaryContext) PauseContext() *pauseContext {
 func (cCtx *canaryContext) NewStatus() v1alpha1.RolloutStatus {
 	return cCtx.newStatus
 }
+
+func (cCtx *canaryContext) SetSLOStatus(status v1alpha1.SLOStatus) {
+	cCtx.newStatus.SLO = status
+}
+
+func (cCtx *canaryContext) SetRestartedAt(restartedAt *metav1.Time) {
+	cCtx.newStatus.RestartedAt = restartedAt
+}
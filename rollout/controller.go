@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -30,16 +32,26 @@ import (
 	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions/rollouts/v1alpha1"
 	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/featureflag"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	controllerutil "github.com/argoproj/argo-rollouts/utils/controller"
+	"github.com/argoproj/argo-rollouts/utils/cosign"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	recordutil "github.com/argoproj/argo-rollouts/utils/record"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 	serviceutil "github.com/argoproj/argo-rollouts/utils/service"
 )
 
 const (
 	virtualServiceIndexName = "byVirtualService"
+	workloadRefIndexName    = "byWorkloadRef"
+	// namespaceLimitRequeueDelay is how long a rollout waits when its namespace is at its limit of
+	// concurrent reconciles
+	namespaceLimitRequeueDelay = time.Second
 )
 
 // RolloutController is the controller implementation for Rollout resources
@@ -57,9 +69,14 @@ type RolloutController struct {
 	// It is used to interact with TrafficRouting resources
 	dynamicclientset    dynamic.Interface
 	defaultIstioVersion string
+	// serverSideApply indicates whether service selectors and rollout status are written with
+	// server-side apply instead of patches
+	serverSideApply bool
 
 	replicaSetLister       appslisters.ReplicaSetLister
 	replicaSetSynced       cache.InformerSynced
+	deploymentLister       appslisters.DeploymentLister
+	deploymentSynced       cache.InformerSynced
 	rolloutsLister         listers.RolloutLister
 	rolloutsSynced         cache.InformerSynced
 	rolloutsIndexer        cache.Indexer
@@ -67,12 +84,31 @@ type RolloutController struct {
 	experimentsLister      listers.ExperimentLister
 	analysisRunLister      listers.AnalysisRunLister
 	analysisTemplateLister listers.AnalysisTemplateLister
-	metricsServer          *metrics.MetricsServer
+	// clusterAnalysisTemplateLister lists the ClusterAnalysisTemplates referenced with clusterScope
+	clusterAnalysisTemplateLister listers.ClusterAnalysisTemplateLister
+	metricsServer                 *metrics.MetricsServer
+	// clusters are the registered clusters the ReplicaSets of new revisions can be created in
+	clusters clusterutil.Registry
+	// namespaceLimiter bounds the rollouts of a namespace reconciled at the same time
+	namespaceLimiter *controllerutil.NamespaceLimiter
+	// revisionHistory caches the last written ControllerRevision of each rollout
+	revisionHistory sync.Map
+	// verifiedImages holds the digests of the verified images of the pod template of each rollout
+	// until its ReplicaSet is created
+	verifiedImages sync.Map
+	// serviceSwitches holds the switch of the active or stable service of each rollout until the
+	// cached service selects the new value
+	serviceSwitches sync.Map
+	// clusterServiceSelectors holds the value the selector of each service of a registered cluster
+	// was switched to for a rollout
+	clusterServiceSelectors sync.Map
 
 	// used for unit testing
 	enqueueRollout              func(obj interface{})
 	enqueueRolloutAfter         func(obj interface{}, duration time.Duration)
 	newTrafficRoutingReconciler func(roCtx rolloutContext) TrafficRoutingReconciler
+	verifyImage                 func(image string, policy *cosign.Policy, credentials cosign.Credentials) (string, error)
+	newFeatureFlagClient        func(provider string) (featureflag.Client, error)
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -83,7 +119,10 @@ type RolloutController struct {
 	serviceWorkqueue workqueue.RateLimitingInterface
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
-	recorder     record.EventRecorder
+	recorder record.EventRecorder
+	// decisions holds the decisions to write to the decision history annotation with the next
+	// status patch of each rollout. The decision history is not recorded if it is nil.
+	decisions    recordutil.DecisionHistory
 	resyncPeriod time.Duration
 }
 
@@ -96,15 +135,20 @@ func NewRolloutController(
 	experimentInformer informers.ExperimentInformer,
 	analysisRunInformer informers.AnalysisRunInformer,
 	analysisTemplateInformer informers.AnalysisTemplateInformer,
+	clusterAnalysisTemplateInformer informers.ClusterAnalysisTemplateInformer,
 	replicaSetInformer appsinformers.ReplicaSetInformer,
+	deploymentInformer appsinformers.DeploymentInformer,
 	servicesInformer coreinformers.ServiceInformer,
 	rolloutsInformer informers.RolloutInformer,
+	clusters clusterutil.Registry,
 	resyncPeriod time.Duration,
 	rolloutWorkQueue workqueue.RateLimitingInterface,
 	serviceWorkQueue workqueue.RateLimitingInterface,
 	metricsServer *metrics.MetricsServer,
 	recorder record.EventRecorder,
-	defaultIstioVersion string) *RolloutController {
+	decisions recordutil.DecisionHistory,
+	defaultIstioVersion string,
+	serverSideApply bool) *RolloutController {
 
 	replicaSetControl := controller.RealRSControl{
 		KubeClient: kubeclientset,
@@ -112,26 +156,33 @@ func NewRolloutController(
 	}
 
 	controller := &RolloutController{
-		namespace:              namespace,
-		kubeclientset:          kubeclientset,
-		argoprojclientset:      argoprojclientset,
-		dynamicclientset:       dynamicclientset,
-		defaultIstioVersion:    defaultIstioVersion,
-		replicaSetControl:      replicaSetControl,
-		replicaSetLister:       replicaSetInformer.Lister(),
-		replicaSetSynced:       replicaSetInformer.Informer().HasSynced,
-		rolloutsIndexer:        rolloutsInformer.Informer().GetIndexer(),
-		rolloutsLister:         rolloutsInformer.Lister(),
-		rolloutsSynced:         rolloutsInformer.Informer().HasSynced,
-		rolloutWorkqueue:       rolloutWorkQueue,
-		serviceWorkqueue:       serviceWorkQueue,
-		servicesLister:         servicesInformer.Lister(),
-		experimentsLister:      experimentInformer.Lister(),
-		analysisRunLister:      analysisRunInformer.Lister(),
-		analysisTemplateLister: analysisTemplateInformer.Lister(),
-		recorder:               recorder,
-		resyncPeriod:           resyncPeriod,
-		metricsServer:          metricsServer,
+		namespace:                     namespace,
+		kubeclientset:                 kubeclientset,
+		argoprojclientset:             argoprojclientset,
+		dynamicclientset:              dynamicclientset,
+		defaultIstioVersion:           defaultIstioVersion,
+		serverSideApply:               serverSideApply,
+		replicaSetControl:             replicaSetControl,
+		replicaSetLister:              replicaSetInformer.Lister(),
+		replicaSetSynced:              replicaSetInformer.Informer().HasSynced,
+		deploymentLister:              deploymentInformer.Lister(),
+		deploymentSynced:              deploymentInformer.Informer().HasSynced,
+		rolloutsIndexer:               rolloutsInformer.Informer().GetIndexer(),
+		rolloutsLister:                rolloutsInformer.Lister(),
+		rolloutsSynced:                rolloutsInformer.Informer().HasSynced,
+		rolloutWorkqueue:              rolloutWorkQueue,
+		serviceWorkqueue:              serviceWorkQueue,
+		servicesLister:                servicesInformer.Lister(),
+		clusters:                      clusters,
+		experimentsLister:             experimentInformer.Lister(),
+		analysisRunLister:             analysisRunInformer.Lister(),
+		analysisTemplateLister:        analysisTemplateInformer.Lister(),
+		clusterAnalysisTemplateLister: clusterAnalysisTemplateInformer.Lister(),
+		recorder:                      recorder,
+		decisions:                     decisions,
+		resyncPeriod:                  resyncPeriod,
+		metricsServer:                 metricsServer,
+		namespaceLimiter:              controllerutil.NewNamespaceLimiter(),
 	}
 	controller.enqueueRollout = func(obj interface{}) {
 		controllerutil.EnqueueRateLimited(obj, rolloutWorkQueue)
@@ -140,6 +191,8 @@ func NewRolloutController(
 		controllerutil.EnqueueAfter(obj, duration, rolloutWorkQueue)
 	}
 	controller.newTrafficRoutingReconciler = controller.NewTrafficRoutingReconciler
+	controller.verifyImage = cosign.NewVerifier().Verify
+	controller.newFeatureFlagClient = controller.NewFeatureFlagClient
 
 	log.Info("Setting up event handlers")
 	// Set up an event handler for when rollout resources change
@@ -153,6 +206,7 @@ func NewRolloutController(
 				for _, s := range serviceutil.GetRolloutServiceKeys(r) {
 					controller.serviceWorkqueue.AddRateLimited(s)
 				}
+				controller.revisionHistory.Delete(fmt.Sprintf("%s/%s", r.Namespace, r.Name))
 			}
 		},
 	})
@@ -164,8 +218,28 @@ func NewRolloutController(
 			}
 			return
 		},
+		workloadRefIndexName: func(obj interface{}) (strings []string, e error) {
+			if rollout, ok := obj.(*v1alpha1.Rollout); ok {
+				return getRolloutWorkloadRefKeys(rollout), nil
+			}
+			return
+		},
 	}))
 
+	// Rollouts referencing a Deployment are reconciled when its pod template changes
+	deploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueWorkloadRefRollouts,
+		UpdateFunc: func(old, new interface{}) {
+			newDeployment := new.(*appsv1.Deployment)
+			oldDeployment := old.(*appsv1.Deployment)
+			if newDeployment.ResourceVersion == oldDeployment.ResourceVersion {
+				return
+			}
+			controller.enqueueWorkloadRefRollouts(new)
+		},
+		DeleteFunc: controller.enqueueWorkloadRefRollouts,
+	})
+
 	replicaSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			controllerutil.EnqueueParentObject(obj, register.RolloutKind, controller.enqueueRollout)
@@ -185,6 +259,27 @@ func NewRolloutController(
 		},
 	})
 
+	// the ReplicaSets of registered clusters are not owned by their rollout, which is found by the
+	// rollout label instead
+	enqueueClusterReplicaSetRollout := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if rs, ok := obj.(*appsv1.ReplicaSet); ok && rs.Labels[clusterutil.RolloutLabelKey] != "" {
+			controller.enqueueRollout(cache.ExplicitKey(rs.Namespace + "/" + rs.Labels[clusterutil.RolloutLabelKey]))
+		}
+	}
+	clusters.AddReplicaSetEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueueClusterReplicaSetRollout,
+		UpdateFunc: func(old, new interface{}) {
+			if new.(*appsv1.ReplicaSet).ResourceVersion == old.(*appsv1.ReplicaSet).ResourceVersion {
+				return
+			}
+			enqueueClusterReplicaSetRollout(new)
+		},
+		DeleteFunc: enqueueClusterReplicaSetRollout,
+	})
+
 	analysisRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			controllerutil.EnqueueParentObject(obj, register.RolloutKind, controller.enqueueRollout)
@@ -211,18 +306,15 @@ func NewRolloutController(
 // workers to finish processing their current work items.
 func (c *RolloutController) Run(threadiness int, stopCh <-chan struct{}) error {
 	log.Info("Starting Rollout workers")
-	for i := 0; i < threadiness; i++ {
-		go wait.Until(func() {
-			controllerutil.RunWorker(c.rolloutWorkqueue, logutil.RolloutKey, c.syncHandler, c.metricsServer)
-		}, time.Second, stopCh)
+	// the VirtualServices are not watched if Istio is disabled, so their RBAC rules can be removed
+	if !isTrafficRouterDisabled(istio.Type) {
+		gvk := schema.ParseGroupResource("virtualservices.networking.istio.io").WithVersion(c.defaultIstioVersion)
+		go controllerutil.WatchResourceWithExponentialBackoff(stopCh, c.dynamicclientset, c.namespace, gvk, c.rolloutWorkqueue, c.rolloutsIndexer, virtualServiceIndexName)
 	}
-	log.Info("Started Rollout workers")
-
-	gvk := schema.ParseGroupResource("virtualservices.networking.istio.io").WithVersion(c.defaultIstioVersion)
-	go controllerutil.WatchResourceWithExponentialBackoff(stopCh, c.dynamicclientset, c.namespace, gvk, c.rolloutWorkqueue, c.rolloutsIndexer, virtualServiceIndexName)
 
-	<-stopCh
-	log.Info("Shutting down workers")
+	log.Info("Started Rollout workers")
+	controllerutil.RunWorkers(threadiness, c.rolloutWorkqueue, logutil.RolloutKey, c.syncHandler, c.metricsServer, stopCh)
+	log.Info("Shut down Rollout workers")
 
 	return nil
 }
@@ -236,10 +328,26 @@ func (c *RolloutController) syncHandler(key string) error {
 	if err != nil {
 		return err
 	}
-	log.WithField(logutil.RolloutKey, name).WithField(logutil.NamespaceKey, namespace).Infof("Started syncing rollout at (%v)", startTime)
+	logutil.WithObject(logutil.RolloutKey, namespace, name).Infof("Started syncing rollout at (%v)", startTime)
 	rollout, err := c.rolloutsLister.Rollouts(namespace).Get(name)
 	if k8serrors.IsNotFound(err) {
-		log.WithField(logutil.RolloutKey, name).WithField(logutil.NamespaceKey, namespace).Info("Rollout has been deleted")
+		logutil.WithObject(logutil.RolloutKey, namespace, name).Info("Rollout has been deleted")
+		if c.decisions != nil {
+			c.decisions.ForgetDecisions(&v1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, -1)
+		}
+		c.serviceSwitches.Delete(key)
+		c.verifiedImages.Range(func(k, _ interface{}) bool {
+			if strings.HasPrefix(k.(string), key+"/") {
+				c.verifiedImages.Delete(k)
+			}
+			return true
+		})
+		c.clusterServiceSelectors.Range(func(k, _ interface{}) bool {
+			if strings.HasPrefix(k.(string), key+"/") {
+				c.clusterServiceSelectors.Delete(k)
+			}
+			return true
+		})
 		return nil
 	}
 	if err != nil {
@@ -252,6 +360,29 @@ func (c *RolloutController) syncHandler(key string) error {
 	r := remarshalRollout(rollout)
 	logCtx := logutil.WithRollout(r)
 
+	// the ReplicaSets of the registered clusters are deleted before the rollout, whether or not the
+	// Deployment of its workloadRef still exists
+	if r.ObjectMeta.DeletionTimestamp != nil && hasClusterReplicaSetsFinalizer(r) {
+		return c.finalizeClusterReplicaSets(r)
+	}
+
+	// The pod template and the selector of a rollout referencing a Deployment are the ones of the
+	// Deployment. They are only resolved on this copy and never written back to the rollout.
+	deployment, err := c.resolveWorkloadRef(r)
+	if err != nil {
+		return err
+	}
+
+	if limit := configutil.Get().GetInt(configutil.MaxConcurrentReconcilesPerNamespaceKey, 0); limit > 0 {
+		updating := !conditions.RolloutComplete(r, &r.Status)
+		if !c.namespaceLimiter.TryAcquire(namespace, limit, updating) {
+			logCtx.Infof("Namespace is at its limit of %d concurrent reconciles. Requeueing rollout", limit)
+			c.enqueueRolloutAfter(r, namespaceLimitRequeueDelay)
+			return nil
+		}
+		defer c.namespaceLimiter.Release(namespace)
+	}
+
 	if r.ObjectMeta.DeletionTimestamp != nil {
 		logCtx.Info("No reconciliation as rollout marked for deletion")
 		return nil
@@ -261,8 +392,15 @@ func (c *RolloutController) syncHandler(key string) error {
 	// the rollout to have the replicas field set to the default value. see https://github.com/argoproj/argo-rollouts/issues/119
 	if rollout.Spec.Replicas == nil {
 		logCtx.Info("Setting .Spec.Replica to 1 from nil")
-		r.Spec.Replicas = pointer.Int32Ptr(defaults.DefaultReplicas)
-		_, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(r.Namespace).Update(r)
+		setDefaultReplicas := func(ro *v1alpha1.Rollout) bool {
+			if ro.Spec.Replicas != nil {
+				return false
+			}
+			ro.Spec.Replicas = pointer.Int32Ptr(defaults.DefaultReplicas)
+			return true
+		}
+		setDefaultReplicas(r)
+		_, err := c.updateRolloutWithRetry(r, setDefaultReplicas)
 		return err
 	}
 	defer func() {
@@ -298,6 +436,26 @@ func (c *RolloutController) syncHandler(key string) error {
 		return err
 	}
 
+	err = c.scaleDownWorkload(r, deployment)
+	if err != nil {
+		return err
+	}
+
+	// the references of the rollout are verified before the ReplicaSet of a new revision is created
+	if configutil.Get().GetBool(configutil.VerifyReferencesKey, false) && replicasetutil.FindNewReplicaSet(r, rsList) == nil {
+		verified, err := c.reconcileReferencesVerified(r)
+		if err != nil || !verified {
+			return err
+		}
+	}
+	// so are the signatures of its images
+	if configutil.Get().GetBool(configutil.VerifyImageSignaturesKey, false) && replicasetutil.FindNewReplicaSet(r, rsList) == nil {
+		verified, err := c.reconcileImageSignatures(r)
+		if err != nil || !verified {
+			return err
+		}
+	}
+
 	err = c.checkPausedConditions(r)
 	if err != nil {
 		return err
@@ -308,7 +466,9 @@ func (c *RolloutController) syncHandler(key string) error {
 		return err
 	}
 
-	if getPauseCondition(r, v1alpha1.PauseReasonInconclusiveAnalysis) != nil || r.Spec.Paused || isScalingEvent {
+	// a promotion resumes the rollout paused on an inconclusive analysis
+	promoted := r.Status.Promote || r.Status.PromoteFull
+	if (getPauseCondition(r, v1alpha1.PauseReasonInconclusiveAnalysis) != nil && !promoted) || r.Spec.Paused || isScalingEvent {
 		return c.syncReplicasOnly(r, rsList, isScalingEvent)
 	}
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -60,12 +61,14 @@ type fixture struct {
 	client     *fake.Clientset
 	kubeclient *k8sfake.Clientset
 	// Objects to put in the store.
-	rolloutLister          []*v1alpha1.Rollout
-	experimentLister       []*v1alpha1.Experiment
-	analysisRunLister      []*v1alpha1.AnalysisRun
-	analysisTemplateLister []*v1alpha1.AnalysisTemplate
-	replicaSetLister       []*appsv1.ReplicaSet
-	serviceLister          []*corev1.Service
+	rolloutLister                 []*v1alpha1.Rollout
+	experimentLister              []*v1alpha1.Experiment
+	analysisRunLister             []*v1alpha1.AnalysisRun
+	analysisTemplateLister        []*v1alpha1.AnalysisTemplate
+	clusterAnalysisTemplateLister []*v1alpha1.ClusterAnalysisTemplate
+	replicaSetLister              []*appsv1.ReplicaSet
+	deploymentLister              []*appsv1.Deployment
+	serviceLister                 []*corev1.Service
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -76,6 +79,7 @@ type fixture struct {
 	unfreezeTime    func()
 
 	fakeTrafficRouting *FakeTrafficRoutingReconciler
+	clusters           *fakeClusterRegistry
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -88,6 +92,7 @@ func newFixture(t *testing.T) *fixture {
 	patch := monkey.Patch(time.Now, func() time.Time { return now })
 	f.unfreezeTime = patch.Unpatch
 	f.fakeTrafficRouting = &FakeTrafficRoutingReconciler{}
+	f.clusters = &fakeClusterRegistry{}
 	return f
 }
 
@@ -244,6 +249,21 @@ func generateConditionsPatch(available bool, progressingReason string, progressi
 	return fmt.Sprintf("[%s, %s]", progressingConditon, availableCondition)
 }
 
+// withServiceSelectorSwitchPending appends the condition of a pending switch of the selector of the
+// service to the conditions of a patch
+func withServiceSelectorSwitchPending(conditionsPatch, service, selector string) string {
+	condition := v1alpha1.RolloutCondition{
+		LastTransitionTime: metav1.Now(),
+		LastUpdateTime:     metav1.Now(),
+		Message:            fmt.Sprintf(conditions.ServiceSelectorSwitchPendingMessage, service, selector),
+		Reason:             conditions.ServiceSelectorSwitchPendingReason,
+		Status:             corev1.ConditionFalse,
+		Type:               v1alpha1.RolloutServiceSelectorSwitched,
+	}
+	conditionBytes, _ := json.Marshal(condition)
+	return fmt.Sprintf("%s, %s]", strings.TrimSuffix(conditionsPatch, "]"), string(conditionBytes))
+}
+
 // func updateBlueGreenRolloutStatus(r *v1alpha1.Rollout, preview, active string, availableReplicas, updatedReplicas, hpaReplicas int32, pause bool, available bool, progressingStatus string) *v1alpha1.Rollout {
 func updateBlueGreenRolloutStatus(r *v1alpha1.Rollout, preview, active string, availableReplicas, updatedReplicas, totalReplicas, hpaReplicas int32, pause bool, available bool) *v1alpha1.Rollout {
 	newRollout := updateBaseRolloutStatus(r, availableReplicas, updatedReplicas, totalReplicas, hpaReplicas)
@@ -380,15 +400,20 @@ func (f *fixture) newController(resync resyncFunc) (*RolloutController, informer
 		i.Argoproj().V1alpha1().Experiments(),
 		i.Argoproj().V1alpha1().AnalysisRuns(),
 		i.Argoproj().V1alpha1().AnalysisTemplates(),
+		i.Argoproj().V1alpha1().ClusterAnalysisTemplates(),
 		k8sI.Apps().V1().ReplicaSets(),
+		k8sI.Apps().V1().Deployments(),
 		k8sI.Core().V1().Services(),
 		i.Argoproj().V1alpha1().Rollouts(),
+		f.clusters,
 		resync(),
 		rolloutWorkqueue,
 		serviceWorkqueue,
-		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), &metrics.K8sRequestsCountProvider{}),
+		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), k8sI.Apps().V1().ReplicaSets().Lister(), &metrics.K8sRequestsCountProvider{}),
 		&record.FakeRecorder{},
+		nil,
 		"v1alpha3",
+		false,
 	)
 
 	var enqueuedObjectsLock sync.Mutex
@@ -427,12 +452,18 @@ func (f *fixture) newController(resync resyncFunc) (*RolloutController, informer
 	for _, r := range f.replicaSetLister {
 		k8sI.Apps().V1().ReplicaSets().Informer().GetIndexer().Add(r)
 	}
+	for _, d := range f.deploymentLister {
+		k8sI.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
+	}
 	for _, s := range f.serviceLister {
 		k8sI.Core().V1().Services().Informer().GetIndexer().Add(s)
 	}
 	for _, at := range f.analysisTemplateLister {
 		i.Argoproj().V1alpha1().AnalysisTemplates().Informer().GetIndexer().Add(at)
 	}
+	for _, cat := range f.clusterAnalysisTemplateLister {
+		i.Argoproj().V1alpha1().ClusterAnalysisTemplates().Informer().GetIndexer().Add(cat)
+	}
 	for _, ar := range f.analysisRunLister {
 		i.Argoproj().V1alpha1().AnalysisRuns().Informer().GetIndexer().Add(ar)
 	}
@@ -538,6 +569,8 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			action.Matches("watch", "rollouts") ||
 			action.Matches("list", "replicaSets") ||
 			action.Matches("watch", "replicaSets") ||
+			action.Matches("list", "deployments") ||
+			action.Matches("watch", "deployments") ||
 			action.Matches("list", "services") ||
 			action.Matches("watch", "services") {
 			continue
@@ -692,6 +725,15 @@ func (f *fixture) getUpdatedReplicaSet(index int) *appsv1.ReplicaSet {
 	return rs
 }
 
+func (f *fixture) getPatchedReplicaSet(index int) string {
+	action := filterInformerActions(f.kubeclient.Actions())[index]
+	patchAction, ok := action.(core.PatchAction)
+	if !ok {
+		f.t.Fatalf("Expected Patch action, not %s", action.GetVerb())
+	}
+	return string(patchAction.GetPatch())
+}
+
 func (f *fixture) verifyPatchedReplicaSet(index int, scaleDownDelaySeconds int32) bool {
 	action := filterInformerActions(f.kubeclient.Actions())[index]
 	patchAction, ok := action.(core.PatchAction)
@@ -0,0 +1,94 @@
+package rollout
+
+import (
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// syncEphemeralMetadata injects the ephemeral metadata of the role of the ReplicaSet in the strategy
+// into its pod template and its existing pods, in place of the metadata of its previous role
+func (c *RolloutController) syncEphemeralMetadata(rollout *v1alpha1.Rollout, rs *appsv1.ReplicaSet) (*appsv1.ReplicaSet, error) {
+	existing := replicasetutil.GetReplicaSetEphemeralMetadata(rs)
+	desired := replicasetutil.GetEphemeralMetadata(rollout, replicasetutil.GetPodTemplateHash(rs))
+	if replicasetutil.EphemeralMetadataEqual(existing, desired) {
+		return rs, nil
+	}
+	logutil.WithRollout(rollout).Infof("Syncing ephemeral metadata of ReplicaSet '%s'", rs.Name)
+
+	// the pods are patched before the ReplicaSet, so they are patched again if the patch of the
+	// ReplicaSet fails
+	selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	kubeclientset, err := c.kubeClientForReplicaSet(rs)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := kubeclientset.CoreV1().Pods(rs.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := pods.Items[i]
+		if !metav1.IsControlledBy(&pod, rs) {
+			continue
+		}
+		patch := ephemeralMetadataPatch(&pod.ObjectMeta, existing, desired)
+		if patch == nil {
+			continue
+		}
+		_, err := kubeclientset.CoreV1().Pods(pod.Namespace).Patch(pod.Name, patchtypes.MergePatchType, patch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rsCopy := rs.DeepCopy()
+	replicasetutil.SetReplicaSetEphemeralMetadata(rsCopy, desired)
+	return c.patchReplicaSet(rs, rsCopy)
+}
+
+// ephemeralMetadataPatch returns the merge patch replacing the existing ephemeral metadata of the pod
+// with the desired one, or nil if the pod already has the desired metadata
+func ephemeralMetadataPatch(metadata *metav1.ObjectMeta, existing, desired *v1alpha1.PodTemplateMetadata) []byte {
+	updated := replicasetutil.SyncEphemeralPodMetadata(metadata, existing, desired)
+	labels := mapPatch(metadata.Labels, updated.Labels)
+	podAnnotations := mapPatch(metadata.Annotations, updated.Annotations)
+	if len(labels) == 0 && len(podAnnotations) == 0 {
+		return nil
+	}
+	patchMetadata := map[string]interface{}{}
+	if len(labels) > 0 {
+		patchMetadata["labels"] = labels
+	}
+	if len(podAnnotations) > 0 {
+		patchMetadata["annotations"] = podAnnotations
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"metadata": patchMetadata})
+	return patch
+}
+
+// mapPatch returns the merge patch from the current map to the updated one, where removed keys
+// are null
+func mapPatch(current, updated map[string]string) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for k := range current {
+		if _, ok := updated[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, v := range updated {
+		if value, ok := current[k]; !ok || value != v {
+			patch[k] = v
+		}
+	}
+	return patch
+}
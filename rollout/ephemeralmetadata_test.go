@@ -0,0 +1,78 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+func newReplicaSetPod(rs *appsv1.ReplicaSet, name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rs.Namespace,
+			Labels:          rs.Spec.Template.Labels,
+			Annotations:     rs.Spec.Template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+		},
+	}
+}
+
+func TestSyncEphemeralMetadata(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 1, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Strategy.Canary.CanaryMetadata = &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "canary"}}
+	r.Spec.Strategy.Canary.StableMetadata = &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}}
+	rs := newReplicaSet(r, 1)
+	r.Status.Canary.StableRS = "other"
+	replicasetutil.SetReplicaSetEphemeralMetadata(rs, r.Spec.Strategy.Canary.CanaryMetadata)
+	pod := newReplicaSetPod(rs, "foo-abc123-1")
+	f.kubeobjects = append(f.kubeobjects, rs, pod)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	// the ReplicaSet is left as is while it is the canary
+	updated, err := c.syncEphemeralMetadata(r, rs)
+	assert.NoError(t, err)
+	assert.Equal(t, rs, updated)
+	assert.Len(t, filterInformerActions(f.kubeclient.Actions()), 0)
+
+	// the canary labels are replaced by the stable labels once it becomes stable
+	r.Status.Canary.StableRS = replicasetutil.GetPodTemplateHash(rs)
+	updated, err = c.syncEphemeralMetadata(r, rs)
+	assert.NoError(t, err)
+	assert.Equal(t, "stable", updated.Spec.Template.Labels["role"])
+	assert.Equal(t, r.Spec.Strategy.Canary.StableMetadata, replicasetutil.GetReplicaSetEphemeralMetadata(updated))
+
+	actions := filterInformerActions(f.kubeclient.Actions())
+	assert.Len(t, actions, 3)
+	assert.True(t, actions[0].Matches("list", "pods"))
+	patch, ok := actions[1].(core.PatchAction)
+	assert.True(t, ok)
+	assert.Equal(t, "pods", patch.GetResource().Resource)
+	assert.Equal(t, `{"metadata":{"labels":{"role":"stable"}}}`, string(patch.GetPatch()))
+	assert.True(t, actions[2].Matches("patch", "replicasets"))
+}
+
+func TestEphemeralMetadataPatch(t *testing.T) {
+	metadata := &metav1.ObjectMeta{
+		Labels:      map[string]string{"app": "guestbook", "role": "canary"},
+		Annotations: map[string]string{"canary": "true"},
+	}
+	existing := &v1alpha1.PodTemplateMetadata{
+		Labels:      map[string]string{"role": "canary"},
+		Annotations: map[string]string{"canary": "true"},
+	}
+	assert.Equal(t, `{"metadata":{"annotations":{"canary":null},"labels":{"role":null}}}`, string(ephemeralMetadataPatch(metadata, existing, nil)))
+	assert.Equal(t, `{"metadata":{"annotations":{"canary":null},"labels":{"role":"stable"}}}`, string(ephemeralMetadataPatch(metadata, existing, &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}})))
+	assert.Nil(t, ephemeralMetadataPatch(metadata, existing, existing))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
 	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 	appsv1 "k8s.io/api/apps/v1"
@@ -14,7 +15,6 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/kubernetes/pkg/controller"
 )
 
 // GetExperimentFromTemplate takes the canary experiment step and converts it to an experiment
@@ -23,7 +23,7 @@ func GetExperimentFromTemplate(r *v1alpha1.Rollout, stableRS, newRS *appsv1.Repl
 	if step == nil {
 		return nil, nil
 	}
-	podHash := controller.ComputeHash(&r.Spec.Template, r.Status.CollisionCount)
+	podHash := replicasetutil.ComputePodTemplateHash(r)
 	currentStep := int32(0)
 	if r.Status.CurrentStepIndex != nil {
 		currentStep = *r.Status.CurrentStepIndex
@@ -60,6 +60,8 @@ func GetExperimentFromTemplate(r *v1alpha1.Rollout, stableRS, newRS *appsv1.Repl
 		template := v1alpha1.TemplateSpec{
 			Name:     templateStep.Name,
 			Replicas: templateStep.Replicas,
+			Weight:   templateStep.Weight,
+			Service:  templateStep.Service,
 		}
 		templateRS := &appsv1.ReplicaSet{}
 		switch templateStep.SpecRef {
@@ -70,7 +72,7 @@ func GetExperimentFromTemplate(r *v1alpha1.Rollout, stableRS, newRS *appsv1.Repl
 		default:
 			return nil, fmt.Errorf("Invalid template step SpecRef: must be canary or stable")
 		}
-		template.Template = templateRS.Spec.Template
+		template.Template = *replicasetutil.GetPodTemplateWithoutEphemeralMetadata(templateRS)
 		template.MinReadySeconds = templateRS.Spec.MinReadySeconds
 
 		if templateStep.Selector != nil {
@@ -102,11 +104,16 @@ func GetExperimentFromTemplate(r *v1alpha1.Rollout, stableRS, newRS *appsv1.Repl
 
 	for i := range step.Analyses {
 		analysis := step.Analyses[i]
-		args := analysisutil.BuildArgumentsForRolloutAnalysisRun(analysis.Args, stableRS, newRS)
+		args, err := analysisutil.BuildArgumentsForRolloutAnalysisRun(analysis.Args, stableRS, newRS, r)
+		if err != nil {
+			return nil, err
+		}
 		analysisTemplate := v1alpha1.ExperimentAnalysisTemplateRef{
-			Name:         analysis.Name,
-			TemplateName: analysis.TemplateName,
-			Args:         args,
+			Name:                  analysis.Name,
+			TemplateName:          analysis.TemplateName,
+			ClusterScope:          analysis.ClusterScope,
+			Args:                  args,
+			RequiredForCompletion: analysis.RequiredForCompletion,
 		}
 		experiment.Spec.Analyses = append(experiment.Spec.Analyses, analysisTemplate)
 	}
@@ -177,7 +184,7 @@ func (c *RolloutController) reconcileExperiments(roCtx *canaryContext) error {
 		case v1alpha1.AnalysisPhaseInconclusive:
 			roCtx.PauseContext().AddPauseCondition(v1alpha1.PauseReasonInconclusiveExperiment)
 		case v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseFailed:
-			roCtx.PauseContext().AddAbort()
+			roCtx.PauseContext().AddAbort(fmt.Sprintf("Experiment '%s' completed with phase '%s'", currentEx.Name, currentEx.Status.Phase))
 		case v1alpha1.AnalysisPhaseSuccessful:
 			// Do not set current Experiment after successful experiment
 		default:
@@ -195,6 +202,7 @@ func (c *RolloutController) reconcileExperiments(roCtx *canaryContext) error {
 	}
 
 	exsToDelete := experimentutil.FilterExperimentsToDelete(otherExs, roCtx.AllRSs())
+	exsToDelete = appendExperimentsExceedingHistoryLimits(roCtx.Rollout(), otherExs, exsToDelete)
 	err = c.deleteExperiments(roCtx, exsToDelete)
 	if err != nil {
 		return err
@@ -251,6 +259,23 @@ func (c *RolloutController) cancelExperiments(roCtx *canaryContext, exs []*v1alp
 	return nil
 }
 
+// appendExperimentsExceedingHistoryLimits appends the completed experiments beyond the history limits of
+// the rollout which are not already going to be deleted
+func appendExperimentsExceedingHistoryLimits(rollout *v1alpha1.Rollout, otherExs, exsToDelete []*v1alpha1.Experiment) []*v1alpha1.Experiment {
+	deleting := map[string]bool{}
+	for _, ex := range exsToDelete {
+		deleting[ex.Name] = true
+	}
+	successfulLimit := defaults.GetSuccessfulRunHistoryLimitOrDefault(rollout)
+	unsuccessfulLimit := defaults.GetUnsuccessfulRunHistoryLimitOrDefault(rollout)
+	for _, ex := range experimentutil.FilterExperimentsExceedingHistoryLimits(otherExs, successfulLimit, unsuccessfulLimit) {
+		if !deleting[ex.Name] {
+			exsToDelete = append(exsToDelete, ex)
+		}
+	}
+	return exsToDelete
+}
+
 func (c *RolloutController) deleteExperiments(roCtx rolloutContext, exs []*v1alpha1.Experiment) error {
 	for i := range exs {
 		ex := exs[i]
@@ -28,8 +28,9 @@ func TestRolloutCreateExperiment(t *testing.T) {
 				Replicas: pointer.Int32Ptr(1),
 			}},
 			Analyses: []v1alpha1.RolloutExperimentStepAnalysisTemplateRef{{
-				Name:         "test",
-				TemplateName: at.Name,
+				Name:                  "test",
+				TemplateName:          at.Name,
+				RequiredForCompletion: true,
 			}},
 		},
 	}}
@@ -57,6 +58,7 @@ func TestRolloutCreateExperiment(t *testing.T) {
 	assert.Equal(t, createdEx.Name, ex.Name)
 	assert.Equal(t, createdEx.Spec.Analyses[0].TemplateName, at.Name)
 	assert.Equal(t, createdEx.Spec.Analyses[0].Name, "test")
+	assert.True(t, createdEx.Spec.Analyses[0].RequiredForCompletion)
 	patch := f.getPatchedRollout(patchIndex)
 	expectedPatch := `{
 		"status": {
@@ -0,0 +1,101 @@
+package featureflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	// LaunchDarkly is the provider of flags managed by LaunchDarkly
+	LaunchDarkly = "launchDarkly"
+	// Unleash is the provider of flags managed by Unleash
+	Unleash = "unleash"
+
+	// TokensSecretName is the name of the Secret, in the controller's namespace, holding the API
+	// tokens of the feature flag services keyed by provider
+	TokensSecretName = "argo-rollouts-feature-flags"
+
+	// DefaultProject is the project of flags which do not specify one
+	DefaultProject = "default"
+
+	httpTimeout = 15 * time.Second
+)
+
+// Client sets the percentage of users a feature flag is served to
+type Client interface {
+	SetPercentage(project, environment, flag string, percentage int32) error
+}
+
+// IsSupported returns true if the provider is a supported feature flag service
+func IsSupported(provider string) bool {
+	return provider == LaunchDarkly || provider == Unleash
+}
+
+// NewClient creates the client of the feature flag service of the provider
+func NewClient(provider, address, token string) (Client, error) {
+	api := &apiClient{
+		client:  &http.Client{Timeout: httpTimeout},
+		address: address,
+		token:   token,
+	}
+	switch provider {
+	case LaunchDarkly:
+		if api.address == "" {
+			api.address = DefaultLaunchDarklyAddress
+		}
+		return &launchDarklyClient{api}, nil
+	case Unleash:
+		if api.address == "" {
+			return nil, fmt.Errorf("the address of the unleash server is not configured")
+		}
+		return &unleashClient{api}, nil
+	}
+	return nil, fmt.Errorf("unsupported feature flag provider '%s'", provider)
+}
+
+// apiClient sends JSON requests to the API of a feature flag service
+type apiClient struct {
+	client  *http.Client
+	address string
+	token   string
+}
+
+// do sends the request and decodes the response into out if it is not nil
+func (c *apiClient) do(method, path, contentType string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status code %d: %s", method, path, resp.StatusCode, string(data))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,105 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+// newServer records the requests and serves the responses keyed by method and path
+func newServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "api-token", req.Header.Get("Authorization"))
+		r := request{method: req.Method, path: req.URL.RequestURI()}
+		if req.ContentLength > 0 {
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&r.body))
+		}
+		requests = append(requests, r)
+		response, ok := responses[req.Method+" "+req.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	return server, &requests
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("flagsmith", "", "")
+	assert.EqualError(t, err, "unsupported feature flag provider 'flagsmith'")
+	_, err = NewClient(Unleash, "", "")
+	assert.EqualError(t, err, "the address of the unleash server is not configured")
+	c, err := NewClient(LaunchDarkly, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLaunchDarklyAddress, c.(*launchDarklyClient).address)
+	assert.True(t, IsSupported(Unleash))
+	assert.False(t, IsSupported("flagsmith"))
+}
+
+func TestLaunchDarklySetPercentage(t *testing.T) {
+	server, requests := newServer(t, map[string]string{
+		"GET /api/v2/flags/default/new-checkout":   `{"variations": [{"_id": "v-on", "value": true}, {"_id": "v-off", "value": false}]}`,
+		"PATCH /api/v2/flags/default/new-checkout": `{}`,
+	})
+	defer server.Close()
+	c, err := NewClient(LaunchDarkly, server.URL, "api-token")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.SetPercentage(DefaultProject, "production", "new-checkout", 25))
+	assert.Len(t, *requests, 2)
+	assert.Equal(t, "/api/v2/flags/default/new-checkout?env=production", (*requests)[0].path)
+	patch := (*requests)[1].body
+	assert.Equal(t, "production", patch["environmentKey"])
+	instructions := patch["instructions"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"kind": "turnFlagOn"}, instructions[0])
+	assert.Equal(t, map[string]interface{}{"v-on": float64(25000), "v-off": float64(75000)}, instructions[1].(map[string]interface{})["rolloutWeights"])
+
+	err = c.SetPercentage(DefaultProject, "production", "missing", 25)
+	assert.Contains(t, err.Error(), "failed with status code 404")
+}
+
+func TestLaunchDarklySetPercentageRequiresBooleanFlag(t *testing.T) {
+	server, _ := newServer(t, map[string]string{
+		"GET /api/v2/flags/default/theme": `{"variations": [{"_id": "v-dark", "value": "dark"}, {"_id": "v-light", "value": "light"}]}`,
+	})
+	defer server.Close()
+	c, err := NewClient(LaunchDarkly, server.URL, "api-token")
+	assert.NoError(t, err)
+	assert.EqualError(t, c.SetPercentage(DefaultProject, "production", "theme", 25), "flag 'theme' is not a boolean flag")
+}
+
+func TestUnleashSetPercentage(t *testing.T) {
+	server, requests := newServer(t, map[string]string{
+		"GET /api/admin/projects/default/features/new-checkout":                                        `{"environments": [{"name": "development", "strategies": []}, {"name": "production", "strategies": [{"id": "s-1", "name": "flexibleRollout", "parameters": {"rollout": "10", "stickiness": "userId", "groupId": "new-checkout"}}]}]}`,
+		"PUT /api/admin/projects/default/features/new-checkout/environments/production/strategies/s-1": `{}`,
+		"POST /api/admin/projects/default/features/new-checkout/environments/production/on":            ``,
+		"POST /api/admin/projects/default/features/new-checkout/environments/development/strategies":   `{}`,
+		"POST /api/admin/projects/default/features/new-checkout/environments/development/on":           ``,
+	})
+	defer server.Close()
+	c, err := NewClient(Unleash, server.URL, "api-token")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.SetPercentage(DefaultProject, "production", "new-checkout", 50))
+	assert.Len(t, *requests, 3)
+	assert.Equal(t, map[string]interface{}{"rollout": "50", "stickiness": "userId", "groupId": "new-checkout"}, (*requests)[1].body["parameters"])
+
+	// the strategy is created if the environment has none
+	assert.NoError(t, c.SetPercentage(DefaultProject, "development", "new-checkout", 0))
+	assert.Len(t, *requests, 6)
+	assert.Equal(t, "flexibleRollout", (*requests)[4].body["name"])
+	assert.Equal(t, "0", (*requests)[4].body["parameters"].(map[string]interface{})["rollout"])
+
+	assert.EqualError(t, c.SetPercentage(DefaultProject, "staging", "new-checkout", 50), "flag 'new-checkout' has no environment 'staging'")
+}
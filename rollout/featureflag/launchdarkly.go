@@ -0,0 +1,61 @@
+package featureflag
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// DefaultLaunchDarklyAddress is the address of the LaunchDarkly API
+	DefaultLaunchDarklyAddress = "https://app.launchdarkly.com"
+
+	semanticPatchContentType = "application/json; domain-model=launchdarkly.semanticpatch"
+	// rolloutWeightTotal is the sum of the weights of a LaunchDarkly percentage rollout
+	rolloutWeightTotal = 100000
+)
+
+// launchDarklyClient sets the fallthrough rule of a boolean flag to a percentage rollout
+type launchDarklyClient struct {
+	*apiClient
+}
+
+type launchDarklyFlag struct {
+	Variations []struct {
+		ID    string      `json:"_id"`
+		Value interface{} `json:"value"`
+	} `json:"variations"`
+}
+
+func (c *launchDarklyClient) SetPercentage(project, environment, flag string, percentage int32) error {
+	path := fmt.Sprintf("/api/v2/flags/%s/%s", url.PathEscape(project), url.PathEscape(flag))
+	var f launchDarklyFlag
+	if err := c.do(http.MethodGet, path+"?env="+url.QueryEscape(environment), "", nil, &f); err != nil {
+		return err
+	}
+	var on, off string
+	for _, v := range f.Variations {
+		switch v.Value {
+		case true:
+			on = v.ID
+		case false:
+			off = v.ID
+		}
+	}
+	if on == "" || off == "" {
+		return fmt.Errorf("flag '%s' is not a boolean flag", flag)
+	}
+	weight := int(percentage) * rolloutWeightTotal / 100
+	patch := map[string]interface{}{
+		"environmentKey": environment,
+		"comment":        fmt.Sprintf("Argo Rollouts: serving %d%%", percentage),
+		"instructions": []map[string]interface{}{
+			{"kind": "turnFlagOn"},
+			{
+				"kind":           "updateFallthroughVariationOrRollout",
+				"rolloutWeights": map[string]int{on: weight, off: rolloutWeightTotal - weight},
+			},
+		},
+	}
+	return c.do(http.MethodPatch, path, semanticPatchContentType, patch, nil)
+}
@@ -0,0 +1,76 @@
+package featureflag
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// flexibleRollout is the Unleash strategy serving a flag to a percentage of users
+const flexibleRollout = "flexibleRollout"
+
+// unleashClient sets the rollout percentage of the flexible rollout strategy of a flag
+type unleashClient struct {
+	*apiClient
+}
+
+type unleashStrategy struct {
+	ID         string            `json:"id,omitempty"`
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+type unleashFeature struct {
+	Environments []struct {
+		Name       string            `json:"name"`
+		Strategies []unleashStrategy `json:"strategies"`
+	} `json:"environments"`
+}
+
+func (c *unleashClient) SetPercentage(project, environment, flag string, percentage int32) error {
+	path := fmt.Sprintf("/api/admin/projects/%s/features/%s", url.PathEscape(project), url.PathEscape(flag))
+	var feature unleashFeature
+	if err := c.do(http.MethodGet, path, "", nil, &feature); err != nil {
+		return err
+	}
+	var strategy *unleashStrategy
+	found := false
+	for _, env := range feature.Environments {
+		if env.Name != environment {
+			continue
+		}
+		found = true
+		for i := range env.Strategies {
+			if env.Strategies[i].Name == flexibleRollout {
+				strategy = &env.Strategies[i]
+				break
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("flag '%s' has no environment '%s'", flag, environment)
+	}
+
+	envPath := fmt.Sprintf("%s/environments/%s", path, url.PathEscape(environment))
+	if strategy == nil {
+		strategy = &unleashStrategy{
+			Name:       flexibleRollout,
+			Parameters: map[string]string{"stickiness": "default", "groupId": flag},
+		}
+	}
+	if strategy.Parameters == nil {
+		strategy.Parameters = map[string]string{}
+	}
+	strategy.Parameters["rollout"] = strconv.Itoa(int(percentage))
+	var err error
+	if strategy.ID == "" {
+		err = c.do(http.MethodPost, envPath+"/strategies", "application/json", strategy, nil)
+	} else {
+		err = c.do(http.MethodPut, envPath+"/strategies/"+url.PathEscape(strategy.ID), "application/json", strategy, nil)
+	}
+	if err != nil {
+		return err
+	}
+	return c.do(http.MethodPost, envPath+"/on", "", nil, nil)
+}
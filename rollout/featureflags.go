@@ -0,0 +1,175 @@
+package rollout
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/featureflag"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// featureFlagKey identifies a feature flag in the status of the rollout
+func featureFlagKey(f v1alpha1.SetFeatureFlag) string {
+	project := f.Project
+	if project == "" {
+		project = featureflag.DefaultProject
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", f.Provider, project, f.Environment, f.Flag)
+}
+
+// featureFlagPercentage returns the percentage of the flag of a step. Flags without a percentage
+// follow the weight of the canary.
+func featureFlagPercentage(r *v1alpha1.Rollout, f v1alpha1.SetFeatureFlag) int32 {
+	if f.Percentage != nil {
+		return *f.Percentage
+	}
+	return replicasetutil.GetCurrentSetWeight(r)
+}
+
+// desiredFeatureFlags returns the flags of the steps the rollout reached with their desired
+// percentages. The flags set by the rollout are reverted to 0 when the rollout is aborted.
+func desiredFeatureFlags(r *v1alpha1.Rollout) (map[string]v1alpha1.SetFeatureFlag, map[string]int32) {
+	flags := map[string]v1alpha1.SetFeatureFlag{}
+	desired := map[string]int32{}
+	_, index := replicasetutil.GetCurrentCanaryStep(r)
+	if index == nil {
+		return flags, desired
+	}
+	applied := map[string]bool{}
+	for _, f := range r.Status.Canary.FeatureFlags {
+		applied[f.Key] = true
+	}
+	for i, step := range r.Spec.Strategy.Canary.Steps {
+		if step.SetFeatureFlag == nil {
+			continue
+		}
+		key := featureFlagKey(*step.SetFeatureFlag)
+		flags[key] = *step.SetFeatureFlag
+		if r.Status.Abort {
+			if applied[key] {
+				desired[key] = 0
+			}
+			continue
+		}
+		// later steps override the percentage of a flag set by earlier steps
+		if int32(i) <= *index {
+			desired[key] = featureFlagPercentage(r, *step.SetFeatureFlag)
+		}
+	}
+	return flags, desired
+}
+
+// reconcileFeatureFlags sets the feature flags of the steps to their desired percentages and
+// records the percentages in the status so the services are only called when they change. When a
+// flag cannot be set, the flags already set are persisted before the error is returned, since the
+// callers return without syncing the status.
+func (c *RolloutController) reconcileFeatureFlags(roCtx *canaryContext) error {
+	r := roCtx.Rollout()
+	flags, desired := desiredFeatureFlags(r)
+	applied := map[string]int32{}
+	for _, f := range r.Status.Canary.FeatureFlags {
+		if _, ok := flags[f.Key]; ok {
+			applied[f.Key] = f.Percentage
+		}
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var err error
+	for _, key := range keys {
+		percentage := desired[key]
+		if current, ok := applied[key]; ok && current == percentage {
+			continue
+		}
+		flag := flags[key]
+		if err = c.setFeatureFlag(r, flag, percentage); err != nil {
+			msg := fmt.Sprintf("Failed to set feature flag '%s': %v", flag.Flag, err)
+			roCtx.Log().Warn(msg)
+			c.recorder.Event(r, corev1.EventTypeWarning, "FeatureFlagError", msg)
+			// the flags already set are recorded so they are not set again
+			break
+		}
+		roCtx.Log().Infof("Set feature flag '%s' to %d%%", key, percentage)
+		c.recorder.Eventf(r, corev1.EventTypeNormal, "FeatureFlagSet", "Set feature flag '%s' to %d%%", flag.Flag, percentage)
+		applied[key] = percentage
+	}
+
+	var statuses []v1alpha1.FeatureFlagStatus
+	for key, percentage := range applied {
+		statuses = append(statuses, v1alpha1.FeatureFlagStatus{Key: key, Percentage: percentage})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Key < statuses[j].Key
+	})
+	roCtx.newStatus.Canary.FeatureFlags = statuses
+	if err != nil {
+		if persistErr := c.persistFeatureFlags(r, statuses); persistErr != nil {
+			roCtx.Log().Warnf("Failed to persist the feature flags already set: %v", persistErr)
+		}
+	}
+	return err
+}
+
+// persistFeatureFlags patches the percentages of the feature flags into the status of the rollout
+func (c *RolloutController) persistFeatureFlags(r *v1alpha1.Rollout, statuses []v1alpha1.FeatureFlagStatus) error {
+	newStatus := r.Status.DeepCopy()
+	newStatus.Canary.FeatureFlags = statuses
+	_, err := c.writeRolloutStatus(r, *newStatus)
+	return err
+}
+
+// completedFeatureFlagStep returns true if the flag of the step is set to its percentage
+func completedFeatureFlagStep(roCtx *canaryContext, f v1alpha1.SetFeatureFlag) bool {
+	key := featureFlagKey(f)
+	for _, status := range roCtx.newStatus.Canary.FeatureFlags {
+		if status.Key == key {
+			return status.Percentage == featureFlagPercentage(roCtx.Rollout(), f)
+		}
+	}
+	return false
+}
+
+func (c *RolloutController) setFeatureFlag(r *v1alpha1.Rollout, f v1alpha1.SetFeatureFlag, percentage int32) error {
+	client, err := c.newFeatureFlagClient(f.Provider)
+	if err != nil {
+		return err
+	}
+	project := f.Project
+	if project == "" {
+		project = featureflag.DefaultProject
+	}
+	return client.SetPercentage(project, f.Environment, f.Flag, percentage)
+}
+
+// NewFeatureFlagClient creates the client of a feature flag service with the API token of the
+// provider in the feature flags Secret
+func (c *RolloutController) NewFeatureFlagClient(provider string) (featureflag.Client, error) {
+	if !featureflag.IsSupported(provider) {
+		return nil, fmt.Errorf("unsupported feature flag provider '%s'", provider)
+	}
+	secret, err := c.kubeclientset.CoreV1().Secrets(defaults.Namespace()).Get(featureflag.TokensSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	token, ok := secret.Data[provider]
+	if !ok {
+		return nil, fmt.Errorf("secret '%s' has no token for provider '%s'", featureflag.TokensSecretName, provider)
+	}
+	var address string
+	switch provider {
+	case featureflag.LaunchDarkly:
+		address = configutil.Get().GetString(configutil.FeatureFlagLaunchDarklyAddressKey, featureflag.DefaultLaunchDarklyAddress)
+	case featureflag.Unleash:
+		address = configutil.Get().GetString(configutil.FeatureFlagUnleashAddressKey, "")
+	}
+	return featureflag.NewClient(provider, address, string(token))
+}
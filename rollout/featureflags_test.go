@@ -0,0 +1,136 @@
+package rollout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/featureflag"
+	"github.com/argoproj/argo-rollouts/utils/defaults"
+)
+
+type fakeFeatureFlagClient struct {
+	calls []string
+	err   error
+}
+
+func (c *fakeFeatureFlagClient) SetPercentage(project, environment, flag string, percentage int32) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.calls = append(c.calls, fmt.Sprintf("%s/%s/%s=%d", project, environment, flag, percentage))
+	return nil
+}
+
+func newFeatureFlagSteps() []v1alpha1.CanaryStep {
+	return []v1alpha1.CanaryStep{
+		{SetWeight: pointer.Int32Ptr(10)},
+		{SetFeatureFlag: &v1alpha1.SetFeatureFlag{Provider: featureflag.LaunchDarkly, Flag: "new-checkout", Environment: "production"}},
+		{Pause: &v1alpha1.RolloutPause{}},
+		{SetWeight: pointer.Int32Ptr(50)},
+		{SetFeatureFlag: &v1alpha1.SetFeatureFlag{Provider: featureflag.Unleash, Project: "shop", Flag: "new-search", Environment: "production", Percentage: pointer.Int32Ptr(100)}},
+	}
+}
+
+func TestDesiredFeatureFlags(t *testing.T) {
+	r := newCanaryRollout("foo", 10, nil, newFeatureFlagSteps(), pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	flags, desired := desiredFeatureFlags(r)
+	assert.Len(t, flags, 2)
+	assert.Empty(t, desired)
+
+	// the flag follows the weight of the canary
+	r.Status.CurrentStepIndex = pointer.Int32Ptr(3)
+	_, desired = desiredFeatureFlags(r)
+	assert.Equal(t, map[string]int32{"launchDarkly/default/production/new-checkout": 50}, desired)
+
+	r.Status.CurrentStepIndex = pointer.Int32Ptr(4)
+	_, desired = desiredFeatureFlags(r)
+	assert.Equal(t, int32(100), desired["unleash/shop/production/new-search"])
+
+	// only the flags set by the rollout are reverted on abort
+	r.Status.Abort = true
+	r.Status.Canary.FeatureFlags = []v1alpha1.FeatureFlagStatus{{Key: "launchDarkly/default/production/new-checkout", Percentage: 50}}
+	_, desired = desiredFeatureFlags(r)
+	assert.Equal(t, map[string]int32{"launchDarkly/default/production/new-checkout": 0}, desired)
+}
+
+func TestReconcileFeatureFlags(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	client := &fakeFeatureFlagClient{}
+	c.newFeatureFlagClient = func(provider string) (featureflag.Client, error) {
+		return client, nil
+	}
+
+	r := newCanaryRollout("foo", 10, nil, newFeatureFlagSteps(), pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	roCtx := newCanaryCtx(r, nil, nil, nil, nil)
+	step := *r.Spec.Strategy.Canary.Steps[1].SetFeatureFlag
+	assert.False(t, completedFeatureFlagStep(roCtx, step))
+	assert.NoError(t, c.reconcileFeatureFlags(roCtx))
+	assert.Equal(t, []string{"default/production/new-checkout=10"}, client.calls)
+	assert.Equal(t, []v1alpha1.FeatureFlagStatus{{Key: "launchDarkly/default/production/new-checkout", Percentage: 10}}, roCtx.newStatus.Canary.FeatureFlags)
+	assert.True(t, completedFeatureFlagStep(roCtx, step))
+
+	// the service is not called again if the percentage did not change
+	r.Status.Canary.FeatureFlags = roCtx.newStatus.Canary.FeatureFlags
+	roCtx = newCanaryCtx(r, nil, nil, nil, nil)
+	assert.NoError(t, c.reconcileFeatureFlags(roCtx))
+	assert.Len(t, client.calls, 1)
+
+	// a failure keeps the last applied percentage
+	r.Status.CurrentStepIndex = pointer.Int32Ptr(3)
+	client.err = fmt.Errorf("unauthorized")
+	roCtx = newCanaryCtx(r, nil, nil, nil, nil)
+	assert.EqualError(t, c.reconcileFeatureFlags(roCtx), "unauthorized")
+	assert.Equal(t, int32(10), roCtx.newStatus.Canary.FeatureFlags[0].Percentage)
+}
+
+func TestReconcileFeatureFlagsPersistsFlagsSetBeforeFailure(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	r := newCanaryRollout("foo", 10, nil, newFeatureFlagSteps(), pointer.Int32Ptr(4), intstr.FromInt(1), intstr.FromInt(0))
+	f.objects = append(f.objects, r)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	launchDarkly := &fakeFeatureFlagClient{}
+	unleash := &fakeFeatureFlagClient{err: fmt.Errorf("unauthorized")}
+	c.newFeatureFlagClient = func(provider string) (featureflag.Client, error) {
+		if provider == featureflag.Unleash {
+			return unleash, nil
+		}
+		return launchDarkly, nil
+	}
+
+	roCtx := newCanaryCtx(r, nil, nil, nil, nil)
+	assert.EqualError(t, c.reconcileFeatureFlags(roCtx), "unauthorized")
+	assert.Equal(t, []string{"default/production/new-checkout=50"}, launchDarkly.calls)
+
+	// the flag set before the failure is persisted so it is not set again by the retry
+	patched, err := f.client.ArgoprojV1alpha1().Rollouts(r.Namespace).Get(r.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []v1alpha1.FeatureFlagStatus{{Key: "launchDarkly/default/production/new-checkout", Percentage: 50}}, patched.Status.Canary.FeatureFlags)
+}
+
+func TestNewFeatureFlagClient(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	f.kubeobjects = append(f.kubeobjects, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: featureflag.TokensSecretName, Namespace: defaults.Namespace()},
+		Data:       map[string][]byte{featureflag.LaunchDarkly: []byte("api-token")},
+	})
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	client, err := c.NewFeatureFlagClient(featureflag.LaunchDarkly)
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+	_, err = c.NewFeatureFlagClient(featureflag.Unleash)
+	assert.EqualError(t, err, "secret 'argo-rollouts-feature-flags' has no token for provider 'unleash'")
+	_, err = c.NewFeatureFlagClient("flagsmith")
+	assert.EqualError(t, err, "unsupported feature flag provider 'flagsmith'")
+}
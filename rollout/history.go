@@ -0,0 +1,151 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+// reconcileRevisionHistory records the revision of the new ReplicaSet, along with the outcome of
+// its analysis, in a ControllerRevision owned by the rollout. Errors are logged and not returned
+// since the history is informational and must not block the rollout.
+func (c *RolloutController) reconcileRevisionHistory(roCtx rolloutContext, newStatus *v1alpha1.RolloutStatus) {
+	if !configutil.Get().GetBool(configutil.RevisionHistoryKey, false) {
+		return
+	}
+	newRS := roCtx.NewRS()
+	if newRS == nil || newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] == "" {
+		return
+	}
+	if err := c.recordRevision(roCtx, newRS, newStatus); err != nil {
+		roCtx.Log().Warnf("Failed to record revision history: %v", err)
+	}
+}
+
+func (c *RolloutController) recordRevision(roCtx rolloutContext, newRS *appsv1.ReplicaSet, newStatus *v1alpha1.RolloutStatus) error {
+	r := roCtx.Rollout()
+	hash := newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	crIf := c.kubeclientset.AppsV1().ControllerRevisions(r.Namespace)
+	key := fmt.Sprintf("%s/%s", r.Namespace, r.Name)
+
+	// the last written ControllerRevision is cached so unchanged revisions do not cost an API call
+	var existing *appsv1.ControllerRevision
+	if cached, ok := c.revisionHistory.Load(key); ok && cached.(*appsv1.ControllerRevision).Name == historyutil.Name(r, hash) {
+		existing = cached.(*appsv1.ControllerRevision)
+	} else {
+		cr, err := crIf.Get(historyutil.Name(r, hash), metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			existing = cr
+		}
+	}
+
+	var prev *historyutil.Revision
+	desired := &historyutil.Revision{}
+	if existing != nil {
+		var err error
+		if prev, err = historyutil.FromControllerRevision(existing); err != nil {
+			return err
+		}
+		*desired = *prev
+		desired.Analysis = append([]historyutil.AnalysisOutcome(nil), prev.Analysis...)
+	}
+	revision, _ := strconv.ParseInt(newRS.Annotations[annotations.RevisionAnnotation], 10, 64)
+	desired.Revision = revision
+	desired.PodTemplateHash = hash
+	desired.Images = nil
+	for _, container := range newRS.Spec.Template.Spec.Containers {
+		desired.Images = append(desired.Images, container.Image)
+	}
+	desired.ChangeCause = r.Annotations[historyutil.ChangeCauseAnnotation]
+	desired.Created = newRS.CreationTimestamp
+	desired.Template = *newRS.Spec.Template.DeepCopy()
+	delete(desired.Template.Labels, v1alpha1.DefaultRolloutUniqueLabelKey)
+	desired.MergeAnalysis(analysisOutcomes(roCtx, hash))
+	desired.Aborted = desired.Aborted || newStatus.Abort
+	if desired.Completed == nil && !newStatus.Abort && conditions.RolloutComplete(r, newStatus) {
+		now := metav1.NewTime(nowFn())
+		desired.Completed = &now
+	}
+	if prev != nil && sameRevision(prev, desired) {
+		return nil
+	}
+
+	cr, err := historyutil.NewControllerRevision(r, desired)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		cr, err = crIf.Create(cr)
+		if err != nil {
+			return err
+		}
+		roCtx.Log().Infof("Recorded revision %d in ControllerRevision '%s'", desired.Revision, cr.Name)
+		c.revisionHistory.Store(key, cr)
+		return c.pruneRevisionHistory(r.Namespace, r.Name)
+	}
+	cr.ResourceVersion = existing.ResourceVersion
+	cr, err = crIf.Update(cr)
+	if err != nil {
+		c.revisionHistory.Delete(key)
+		return err
+	}
+	c.revisionHistory.Store(key, cr)
+	return nil
+}
+
+// sameRevision compares the revisions by their serialized form, since empty and unset fields of the
+// pod template are indistinguishable once the revision is stored
+func sameRevision(a, b *historyutil.Revision) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
+
+// pruneRevisionHistory deletes the oldest ControllerRevisions of the rollout beyond the limit
+func (c *RolloutController) pruneRevisionHistory(namespace, name string) error {
+	limit := configutil.Get().GetInt(configutil.RevisionHistoryLimitKey, historyutil.DefaultRevisionHistoryLimit)
+	if limit < 1 {
+		return nil
+	}
+	revisions, err := historyutil.List(c.kubeclientset, namespace, name)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(revisions)-limit; i++ {
+		err := c.kubeclientset.AppsV1().ControllerRevisions(namespace).Delete(revisions[i].Name, &metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// analysisOutcomes returns the outcome of the AnalysisRuns of the rollout run against the revision
+func analysisOutcomes(roCtx rolloutContext, podTemplateHash string) []historyutil.AnalysisOutcome {
+	var outcomes []historyutil.AnalysisOutcome
+	runs := append(append([]*v1alpha1.AnalysisRun{}, roCtx.CurrentAnalysisRuns()...), roCtx.OtherAnalysisRuns()...)
+	for _, run := range runs {
+		if run == nil || run.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] != podTemplateHash {
+			continue
+		}
+		outcomes = append(outcomes, historyutil.AnalysisOutcome{
+			Name:  run.Name,
+			Type:  run.Labels[v1alpha1.RolloutTypeLabel],
+			Phase: run.Status.Phase,
+		})
+	}
+	return outcomes
+}
@@ -0,0 +1,107 @@
+package rollout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	historyutil "github.com/argoproj/argo-rollouts/utils/history"
+)
+
+func newHistoryRollout(revision int) *v1alpha1.Rollout {
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	if r.Annotations == nil {
+		r.Annotations = map[string]string{}
+	}
+	r.Annotations[annotations.RevisionAnnotation] = fmt.Sprintf("%d", revision)
+	r.Annotations[historyutil.ChangeCauseAnnotation] = fmt.Sprintf("deploy %d", revision)
+	r.Spec.Template.Spec.Containers[0].Image = fmt.Sprintf("foo:v%d", revision)
+	return r
+}
+
+func TestReconcileRevisionHistoryDisabled(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	c := &RolloutController{kubeclientset: client}
+	r := newHistoryRollout(1)
+	roCtx := newCanaryCtx(r, newReplicaSet(r, 1), nil, nil, nil)
+	c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{})
+	assert.Empty(t, client.Actions())
+}
+
+func TestReconcileRevisionHistory(t *testing.T) {
+	configutil.SetDefaults(map[string]string{configutil.RevisionHistoryKey: "true"})
+	defer configutil.SetDefaults(nil)
+	client := k8sfake.NewSimpleClientset()
+	c := &RolloutController{kubeclientset: client}
+
+	r := newHistoryRollout(1)
+	rs := newReplicaSet(r, 1)
+	hash := rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	ar := &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo-" + hash + "-1",
+			Namespace:       r.Namespace,
+			Labels:          map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: hash, v1alpha1.RolloutTypeLabel: v1alpha1.RolloutTypeBackgroundRunLabel},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(r, controllerKind)},
+		},
+		Status: v1alpha1.AnalysisRunStatus{Phase: v1alpha1.AnalysisPhaseRunning},
+	}
+	roCtx := newCanaryCtx(r, rs, nil, nil, []*v1alpha1.AnalysisRun{ar})
+	c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{})
+
+	cr, err := client.AppsV1().ControllerRevisions(r.Namespace).Get(historyutil.Name(r, hash), metav1.GetOptions{})
+	assert.NoError(t, err)
+	revision, err := historyutil.FromControllerRevision(cr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), revision.Revision)
+	assert.Equal(t, []string{"foo:v1"}, revision.Images)
+	assert.Equal(t, "deploy 1", revision.ChangeCause)
+	assert.NotContains(t, revision.Template.Labels, v1alpha1.DefaultRolloutUniqueLabelKey)
+	assert.Equal(t, []historyutil.AnalysisOutcome{{Name: ar.Name, Type: v1alpha1.RolloutTypeBackgroundRunLabel, Phase: v1alpha1.AnalysisPhaseRunning}}, revision.Analysis)
+	assert.False(t, revision.Aborted)
+
+	// an unchanged revision is not written again
+	client.ClearActions()
+	c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{})
+	assert.Empty(t, client.Actions())
+
+	// the outcome of the analysis is kept once the AnalysisRun is deleted
+	ar.Status.Phase = v1alpha1.AnalysisPhaseFailed
+	c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{Abort: true})
+	roCtx = newCanaryCtx(r, rs, nil, nil, nil)
+	c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{})
+	cr, err = client.AppsV1().ControllerRevisions(r.Namespace).Get(historyutil.Name(r, hash), metav1.GetOptions{})
+	assert.NoError(t, err)
+	revision, err = historyutil.FromControllerRevision(cr)
+	assert.NoError(t, err)
+	assert.True(t, revision.Aborted)
+	assert.Equal(t, v1alpha1.AnalysisPhaseFailed, revision.Analysis[0].Phase)
+}
+
+func TestPruneRevisionHistory(t *testing.T) {
+	configutil.SetDefaults(map[string]string{
+		configutil.RevisionHistoryKey:      "true",
+		configutil.RevisionHistoryLimitKey: "2",
+	})
+	defer configutil.SetDefaults(nil)
+	client := k8sfake.NewSimpleClientset()
+	c := &RolloutController{kubeclientset: client}
+	for i := 1; i <= 3; i++ {
+		r := newHistoryRollout(i)
+		roCtx := newCanaryCtx(r, newReplicaSet(r, 1), nil, nil, nil)
+		c.reconcileRevisionHistory(roCtx, &v1alpha1.RolloutStatus{})
+	}
+	revisions, err := historyutil.List(client, metav1.NamespaceDefault, "foo")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, int64(2), revisions[0].Revision)
+	assert.Equal(t, int64(3), revisions[1].Revision)
+}
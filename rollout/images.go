@@ -0,0 +1,171 @@
+package rollout
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	"github.com/argoproj/argo-rollouts/utils/cosign"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// reconcileImageSignatures verifies the signatures of the images of the new revision before its
+// ReplicaSet is created. If an image is not signed according to the image verification policy,
+// the Degraded condition is set and false is returned so the update does not start. Otherwise the
+// digests the images were verified at are kept to be pinned into the new ReplicaSet.
+func (c *RolloutController) reconcileImageSignatures(r *v1alpha1.Rollout) (bool, error) {
+	logCtx := logutil.WithRollout(r)
+	digests, problems := c.verifyImageSignatures(r)
+	prevCond := conditions.GetRolloutCondition(r.Status, v1alpha1.RolloutDegraded)
+	if len(problems) == 0 {
+		c.verifiedImages.Store(verifiedImagesKey(r), digests)
+		if prevCond == nil || prevCond.Reason != conditions.ImageVerificationFailedReason {
+			return true, nil
+		}
+		cond := conditions.NewRolloutCondition(v1alpha1.RolloutDegraded, corev1.ConditionFalse, conditions.ImagesVerifiedReason, conditions.ImagesVerifiedMessage)
+		if err := c.patchCondition(r, r.Status.DeepCopy(), cond); err != nil {
+			return false, err
+		}
+		conditions.SetRolloutCondition(&r.Status, *cond)
+		return true, nil
+	}
+
+	msg := strings.Join(problems, "; ")
+	logCtx.Warnf("Image verification failed: %s", msg)
+	cond := conditions.NewRolloutCondition(v1alpha1.RolloutDegraded, corev1.ConditionTrue, conditions.ImageVerificationFailedReason, msg)
+	if prevCond == nil || prevCond.Status != cond.Status || prevCond.Message != cond.Message {
+		newStatus := r.Status.DeepCopy()
+		// SetRolloutCondition keeps the previous condition when only the message changes
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutDegraded)
+		if err := c.patchCondition(r, newStatus, cond); err != nil {
+			return false, err
+		}
+		c.recorder.Event(r, corev1.EventTypeWarning, cond.Reason, cond.Message)
+		conditions.RemoveRolloutCondition(&r.Status, v1alpha1.RolloutDegraded)
+		conditions.SetRolloutCondition(&r.Status, *cond)
+	}
+	// signatures may be pushed after the image, so the images are verified again later
+	c.enqueueRolloutAfter(r, referencesRecheckDelay)
+	return false, nil
+}
+
+// verifyImageSignatures returns the digests of the verified images of the rollout, and the
+// problems with the signatures of the others
+func (c *RolloutController) verifyImageSignatures(r *v1alpha1.Rollout) (map[string]string, []string) {
+	cfg := configutil.Get()
+	policy, err := cosign.ParsePolicy(
+		cfg.GetString(configutil.ImageVerificationPublicKeysKey, ""),
+		cfg.GetString(configutil.ImageVerificationRootsKey, ""),
+		cfg.GetStringSlice(configutil.ImageVerificationIdentitiesKey, nil),
+		cfg.GetString(configutil.ImageVerificationIssuerKey, ""),
+		cfg.GetString(configutil.ImageVerificationRekorPublicKeyKey, ""))
+	if err != nil {
+		return nil, []string{fmt.Sprintf("invalid image verification policy: %v", err)}
+	}
+	prefixes := cfg.GetStringSlice(configutil.ImageVerificationImagesKey, nil)
+	credentials := c.registryCredentials(r)
+	digests := map[string]string{}
+	var problems []string
+	for _, image := range imagesToVerify(r, prefixes) {
+		digest, err := c.verifyImage(image, policy, credentials)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("image '%s': %v", image, err))
+			continue
+		}
+		digests[image] = digest
+	}
+	return digests, problems
+}
+
+// verifiedImagesKey returns the key of the digests of the verified images of the pod template of
+// the rollout
+func verifiedImagesKey(r *v1alpha1.Rollout) string {
+	return fmt.Sprintf("%s/%s/%s", r.Namespace, r.Name, replicasetutil.ComputePodTemplateHash(r))
+}
+
+// imagesToVerify returns the images of the pod template matching one of the prefixes, or all of
+// them if no prefixes are set
+func imagesToVerify(r *v1alpha1.Rollout, prefixes []string) []string {
+	var images []string
+	containers := append(append([]corev1.Container{}, r.Spec.Template.Spec.InitContainers...), r.Spec.Template.Spec.Containers...)
+	for _, container := range containers {
+		matches := len(prefixes) == 0
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(container.Image, prefix) {
+				matches = true
+			}
+		}
+		if matches {
+			images = append(images, container.Image)
+		}
+	}
+	return uniqueNames(images)
+}
+
+// dockerConfig is the content of image pull secrets
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// registryCredentials returns the credentials of the image pull secrets of the pod template.
+// Secrets which cannot be read are ignored, and the registry is accessed anonymously.
+func (c *RolloutController) registryCredentials(r *v1alpha1.Rollout) cosign.Credentials {
+	auths := map[string]dockerAuth{}
+	for _, ref := range r.Spec.Template.Spec.ImagePullSecrets {
+		secret, err := c.kubeclientset.CoreV1().Secrets(r.Namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			logutil.WithRollout(r).Warnf("Failed to read image pull secret '%s': %v", ref.Name, err)
+			continue
+		}
+		var config dockerConfig
+		if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+			_ = json.Unmarshal(data, &config)
+		} else if data, ok := secret.Data[corev1.DockerConfigKey]; ok {
+			_ = json.Unmarshal(data, &config.Auths)
+		}
+		for server, auth := range config.Auths {
+			auths[normalizeRegistry(server)] = auth
+		}
+	}
+	return func(registry string) (string, string) {
+		auth, ok := auths[normalizeRegistry(registry)]
+		if !ok {
+			return "", ""
+		}
+		if auth.Username == "" && auth.Auth != "" {
+			if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+				if parts := strings.SplitN(string(decoded), ":", 2); len(parts) == 2 {
+					return parts[0], parts[1]
+				}
+			}
+		}
+		return auth.Username, auth.Password
+	}
+}
+
+// normalizeRegistry returns the host of a registry of a docker config, such as
+// https://index.docker.io/v1/
+func normalizeRegistry(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	if i := strings.Index(server, "/"); i >= 0 {
+		server = server[:i]
+	}
+	if server == "docker.io" {
+		return "index.docker.io"
+	}
+	return server
+}
@@ -0,0 +1,169 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	"github.com/argoproj/argo-rollouts/utils/cosign"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+const testPublicKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE1OOSyXRYbKaW/JMM0gLF3UUEA7BU
+3LaoSuLEX0n1teTswMBbyXuiylx1TT7bipP1p2NJcBx7yO4qWJB2Bfp4pw==
+-----END PUBLIC KEY-----
+`
+
+func setImageVerificationDefaults() {
+	configutil.SetDefaults(map[string]string{
+		configutil.VerifyImageSignaturesKey:       "true",
+		configutil.ImageVerificationPublicKeysKey: testPublicKey,
+		configutil.ImageVerificationImagesKey:     "registry.example.com/",
+	})
+}
+
+func TestImagesToVerify(t *testing.T) {
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "registry.example.com/init:v1"}}
+	r.Spec.Template.Spec.Containers = []corev1.Container{
+		{Name: "app", Image: "registry.example.com/app:v2"},
+		{Name: "sidecar", Image: "envoyproxy/envoy:v1.14"},
+		{Name: "debug", Image: "registry.example.com/app:v2"},
+	}
+	assert.Equal(t, []string{"registry.example.com/init:v1", "registry.example.com/app:v2"}, imagesToVerify(r, []string{"registry.example.com/"}))
+	assert.Len(t, imagesToVerify(r, nil), 3)
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	f.kubeobjects = append(f.kubeobjects, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "regcred", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths": {"https://index.docker.io/v1/": {"auth": "cm9sbG91dHM6c2VjcmV0"}, "registry.example.com": {"username": "ci", "password": "token"}}}`),
+		},
+	})
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "regcred"}, {Name: "missing"}}
+
+	credentials := c.registryCredentials(r)
+	username, password := credentials("index.docker.io")
+	assert.Equal(t, "rollouts", username)
+	assert.Equal(t, "secret", password)
+	username, password = credentials("registry.example.com")
+	assert.Equal(t, "ci", username)
+	assert.Equal(t, "token", password)
+	username, _ = credentials("quay.io")
+	assert.Equal(t, "", username)
+}
+
+func TestImageVerificationBlocksUpdate(t *testing.T) {
+	setImageVerificationDefaults()
+	defer configutil.SetDefaults(nil)
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Template.Spec.Containers[0].Image = "registry.example.com/app:v2"
+	f.rolloutLister = append(f.rolloutLister, r)
+	f.objects = append(f.objects, r)
+
+	patchIndex := f.expectPatchRolloutAction(r)
+	c, i, k8sI := f.newController(noResyncPeriodFunc)
+	var verified []string
+	c.verifyImage = func(image string, policy *cosign.Policy, credentials cosign.Credentials) (string, error) {
+		verified = append(verified, image)
+		assert.Len(t, policy.PublicKeys, 1)
+		return "", fmt.Errorf("no signatures found for sha256:abcd")
+	}
+	f.runController(getKey(r, t), true, false, c, i, k8sI)
+
+	assert.Equal(t, []string{"registry.example.com/app:v2"}, verified)
+	var patch struct {
+		Status v1alpha1.RolloutStatus `json:"status"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(f.getPatchedRollout(patchIndex)), &patch))
+	cond := conditions.GetRolloutCondition(patch.Status, v1alpha1.RolloutDegraded)
+	assert.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, conditions.ImageVerificationFailedReason, cond.Reason)
+	assert.Equal(t, "image 'registry.example.com/app:v2': no signatures found for sha256:abcd", cond.Message)
+	// the ReplicaSet of the update is not created
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+}
+
+func TestImageVerificationClearsDegradedCondition(t *testing.T) {
+	setImageVerificationDefaults()
+	defer configutil.SetDefaults(nil)
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Template.Spec.Containers[0].Image = "registry.example.com/app:v2"
+	conditions.SetRolloutCondition(&r.Status, *conditions.NewRolloutCondition(v1alpha1.RolloutDegraded, corev1.ConditionTrue, conditions.ImageVerificationFailedReason, "no signatures"))
+	f.objects = append(f.objects, r)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	c.verifyImage = func(image string, policy *cosign.Policy, credentials cosign.Credentials) (string, error) {
+		return "sha256:abcd", nil
+	}
+
+	verified, err := c.reconcileImageSignatures(r)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	cond := conditions.GetRolloutCondition(r.Status, v1alpha1.RolloutDegraded)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, conditions.ImagesVerifiedReason, cond.Reason)
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	// an invalid policy fails the verification
+	configutil.SetDefaults(map[string]string{configutil.VerifyImageSignaturesKey: "true"})
+	_, problems := c.verifyImageSignatures(r)
+	assert.Equal(t, []string{"invalid image verification policy: no public keys or keyless identities are configured"}, problems)
+}
+
+func TestImageVerificationPinsDigests(t *testing.T) {
+	setImageVerificationDefaults()
+	defer configutil.SetDefaults(nil)
+
+	r1 := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Template.Spec.Containers[0].Image = "registry.example.com/app:v2"
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+
+	c := &RolloutController{
+		kubeclientset:     k8sfake.NewSimpleClientset(rs1),
+		argoprojclientset: fake.NewSimpleClientset(r2),
+		recorder:          &record.FakeRecorder{},
+	}
+	c.verifyImage = func(image string, policy *cosign.Policy, credentials cosign.Credentials) (string, error) {
+		return "sha256:abcd", nil
+	}
+	verified, err := c.reconcileImageSignatures(r2)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	rs2, err := c.getNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1}, []*appsv1.ReplicaSet{rs1}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "registry.example.com/app:v2@sha256:abcd", rs2.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, `{"`+r2.Spec.Template.Spec.Containers[0].Name+`":"registry.example.com/app:v2"}`, rs2.Annotations[annotations.PinnedImagesAnnotation])
+	_, ok := c.verifiedImages.Load(verifiedImagesKey(r2))
+	assert.False(t, ok)
+	// the pinned ReplicaSet is still the new ReplicaSet of the rollout
+	assert.Equal(t, rs2.Name, replicasetutil.FindNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1, rs2}).Name)
+}
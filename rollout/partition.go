@@ -0,0 +1,78 @@
+package rollout
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// podDeletionCostAnnotation is the annotation the ReplicaSet controller uses to pick the pods to
+// delete when a ReplicaSet is scaled down. Pods with a lower cost are deleted first.
+const podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+const podDeletionCostPatch = `{"metadata":{"annotations":{"%s":"%d"}}}`
+
+// orderStablePods sets the deletion cost of the pods of the stable ReplicaSet of a partitioned
+// canary, so the ReplicaSet controller replaces them in the order of the partition strategy when
+// the stable ReplicaSet is scaled down
+func (c *RolloutController) orderStablePods(rollout *v1alpha1.Rollout, stableRS *appsv1.ReplicaSet) error {
+	selector, err := metav1.LabelSelectorAsSelector(stableRS.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	kubeclientset, err := c.kubeClientForReplicaSet(stableRS)
+	if err != nil {
+		return err
+	}
+	podList, err := kubeclientset.CoreV1().Pods(stableRS.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp == nil && metav1.IsControlledBy(&pod, stableRS) {
+			pods = append(pods, pod)
+		}
+	}
+	sortPodsForPartition(pods, rollout.Spec.Strategy.Canary.Partition.Order)
+	for i, pod := range pods {
+		cost := strconv.Itoa(i)
+		if pod.Annotations[podDeletionCostAnnotation] == cost {
+			continue
+		}
+		patch := fmt.Sprintf(podDeletionCostPatch, podDeletionCostAnnotation, i)
+		_, err := kubeclientset.CoreV1().Pods(pod.Namespace).Patch(pod.Name, patchtypes.MergePatchType, []byte(patch))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortPodsForPartition sorts the pods in the order they are replaced in
+func sortPodsForPartition(pods []corev1.Pod, order v1alpha1.PartitionOrder) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		switch order {
+		case v1alpha1.PartitionOrderNewest:
+			if !pods[i].CreationTimestamp.Equal(&pods[j].CreationTimestamp) {
+				return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+			}
+		case v1alpha1.PartitionOrderNodeName:
+			if pods[i].Spec.NodeName != pods[j].Spec.NodeName {
+				return pods[i].Spec.NodeName < pods[j].Spec.NodeName
+			}
+		default:
+			if !pods[i].CreationTimestamp.Equal(&pods[j].CreationTimestamp) {
+				return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+			}
+		}
+		return pods[i].Name < pods[j].Name
+	})
+}
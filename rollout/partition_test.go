@@ -0,0 +1,79 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newPodForReplicaSet(rs *appsv1.ReplicaSet, name, nodeName string, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         rs.Namespace,
+			Labels:            rs.Spec.Selector.MatchLabels,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			OwnerReferences:   []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestSortPodsForPartition(t *testing.T) {
+	r := newCanaryRollout("foo", 3, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(0), intstr.FromInt(1))
+	rs := newReplicaSet(r, 3)
+	pods := []corev1.Pod{
+		*newPodForReplicaSet(rs, "a", "node-2", time.Minute),
+		*newPodForReplicaSet(rs, "b", "node-3", time.Hour),
+		*newPodForReplicaSet(rs, "c", "node-1", time.Second),
+	}
+	names := func() []string {
+		var names []string
+		for _, pod := range pods {
+			names = append(names, pod.Name)
+		}
+		return names
+	}
+
+	sortPodsForPartition(pods, "")
+	assert.Equal(t, []string{"b", "a", "c"}, names())
+	sortPodsForPartition(pods, v1alpha1.PartitionOrderNewest)
+	assert.Equal(t, []string{"c", "a", "b"}, names())
+	sortPodsForPartition(pods, v1alpha1.PartitionOrderNodeName)
+	assert.Equal(t, []string{"c", "a", "b"}, names())
+}
+
+func TestOrderStablePods(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 3, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(0), intstr.FromInt(1))
+	r.Spec.Strategy.Canary.Partition = &v1alpha1.PartitionStrategy{Order: v1alpha1.PartitionOrderNodeName}
+	rs := newReplicaSet(r, 3)
+	first := newPodForReplicaSet(rs, "foo-1", "node-1", time.Hour)
+	first.Annotations = map[string]string{podDeletionCostAnnotation: "0"}
+	second := newPodForReplicaSet(rs, "foo-2", "node-2", time.Hour)
+	// pods of other ReplicaSets are left alone
+	other := newPodForReplicaSet(rs, "bar", "node-0", time.Hour)
+	other.OwnerReferences = nil
+	f.kubeobjects = append(f.kubeobjects, first, second, other)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	assert.NoError(t, c.orderStablePods(r, rs))
+	actions := filterInformerActions(f.kubeclient.Actions())
+	assert.Len(t, actions, 2)
+	assert.True(t, actions[0].Matches("list", "pods"))
+	assert.True(t, actions[1].Matches("patch", "pods"))
+
+	pod, err := f.kubeclient.CoreV1().Pods(rs.Namespace).Get("foo-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", pod.Annotations[podDeletionCostAnnotation])
+}
@@ -19,7 +19,10 @@ type pauseContext struct {
 	removePauseReasons   []v1alpha1.PauseReason
 	clearPauseConditions bool
 	addAbort             bool
+	abortMessage         string
 	removeAbort          bool
+	removePromoteFull    bool
+	addPromoteFull       bool
 }
 
 func (pCtx *pauseContext) HasAddPause() bool {
@@ -36,14 +39,32 @@ func (pCtx *pauseContext) IsAborted() bool {
 	return false
 }
 
-func (pCtx *pauseContext) AddAbort() {
+// AddAbort aborts the rollout. The message explains the decision and is recorded in the
+// RolloutAborted event
+func (pCtx *pauseContext) AddAbort(message string) {
 	pCtx.addAbort = true
+	pCtx.abortMessage = message
+}
+
+// AbortMessage returns the reason given when the rollout was aborted during this reconciliation
+func (pCtx *pauseContext) AbortMessage() string {
+	return pCtx.abortMessage
 }
 
 func (pCtx *pauseContext) RemoveAbort() {
 	pCtx.removeAbort = true
 }
 
+// RemovePromoteFull ends the full promotion of the rollout, on an update of the rollout
+func (pCtx *pauseContext) RemovePromoteFull() {
+	pCtx.removePromoteFull = true
+}
+
+// AddPromoteFull fully promotes the rollout, skipping its remaining steps, pauses and analysis
+func (pCtx *pauseContext) AddPromoteFull() {
+	pCtx.addPromoteFull = true
+}
+
 func (pCtx *pauseContext) AddPauseCondition(reason v1alpha1.PauseReason) {
 	pCtx.addPauseReasons = append(pCtx.addPauseReasons, reason)
 }
@@ -65,6 +86,7 @@ func (pCtx *pauseContext) CalculatePauseStatus(newStatus *v1alpha1.RolloutStatus
 		return
 	}
 	newStatus.Abort = false
+	newStatus.PromoteFull = pCtx.addPromoteFull || pCtx.rollout.Status.PromoteFull && !pCtx.removePromoteFull
 
 	if pCtx.clearPauseConditions {
 		return
@@ -148,6 +170,9 @@ func (pCtx *pauseContext) CompletedBlueGreenPause() bool {
 	if pCtx.HasAddPause() {
 		return false
 	}
+	if rollout.Status.Promote {
+		return true
+	}
 	cond := getPauseCondition(rollout, v1alpha1.PauseReasonBlueGreenPause)
 
 	autoPromoteActiveServiceDelaySeconds := rollout.Spec.Strategy.BlueGreen.AutoPromotionSeconds
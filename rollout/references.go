@@ -0,0 +1,231 @@
+package rollout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/nginx"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+// referencesRecheckDelay is how long a rollout with invalid references waits before they are
+// verified again, since not every referenced object notifies the controller when it is created
+const referencesRecheckDelay = 30 * time.Second
+
+// reconcileReferencesVerified verifies the objects referenced by the rollout before its update
+// starts and publishes the result in the ReferencesVerified condition. It returns false if the
+// update must not start because references are missing or incompatible.
+func (c *RolloutController) reconcileReferencesVerified(r *v1alpha1.Rollout) (bool, error) {
+	logCtx := logutil.WithRollout(r)
+	var cond *v1alpha1.RolloutCondition
+	problems := c.verifyReferences(r)
+	if len(problems) == 0 {
+		cond = conditions.NewRolloutCondition(v1alpha1.RolloutReferencesVerified, corev1.ConditionTrue, conditions.ReferencesVerifiedReason, conditions.ReferencesVerifiedMessage)
+	} else {
+		msg := strings.Join(problems, "; ")
+		logCtx.Warnf("Invalid references: %s", msg)
+		cond = conditions.NewRolloutCondition(v1alpha1.RolloutReferencesVerified, corev1.ConditionFalse, conditions.InvalidReferencesReason, msg)
+	}
+	prevCond := conditions.GetRolloutCondition(r.Status, v1alpha1.RolloutReferencesVerified)
+	if prevCond == nil || prevCond.Status != cond.Status || prevCond.Message != cond.Message {
+		newStatus := r.Status.DeepCopy()
+		// SetRolloutCondition keeps the previous condition when only the message changes
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutReferencesVerified)
+		if err := c.patchCondition(r, newStatus, cond); err != nil {
+			return false, err
+		}
+		if cond.Status == corev1.ConditionFalse {
+			c.recorder.Event(r, corev1.EventTypeWarning, cond.Reason, cond.Message)
+		}
+		// the rest of the reconciliation computes the new status from the rollout, which has to hold
+		// the condition for the next status patch to keep it
+		conditions.RemoveRolloutCondition(&r.Status, v1alpha1.RolloutReferencesVerified)
+		conditions.SetRolloutCondition(&r.Status, *cond)
+	}
+	if cond.Status == corev1.ConditionFalse {
+		c.enqueueRolloutAfter(r, referencesRecheckDelay)
+		return false, nil
+	}
+	return true, nil
+}
+
+// verifyReferences returns the problems with the objects referenced by the rollout
+func (c *RolloutController) verifyReferences(r *v1alpha1.Rollout) []string {
+	var problems []string
+	for _, name := range referencedServices(r) {
+		if _, err := c.servicesLister.Services(r.Namespace).Get(name); err != nil {
+			problems = append(problems, referenceError("Service", name, err))
+		}
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil && canary.TrafficRouting != nil && canary.TrafficRouting.Istio != nil {
+		name := canary.TrafficRouting.Istio.VirtualService.Name
+		gvr := schema.ParseGroupResource("virtualservices.networking.istio.io").WithVersion(c.defaultIstioVersion)
+		vsvc, err := c.dynamicclientset.Resource(gvr).Namespace(r.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			problems = append(problems, referenceError("VirtualService", name, err))
+		} else if err := istio.ValidateVirtualService(r, vsvc); err != nil {
+			problems = append(problems, fmt.Sprintf("VirtualService '%s' is incompatible: %v", name, err))
+		}
+		if dRule := canary.TrafficRouting.Istio.DestinationRule; dRule != nil {
+			gvr := schema.ParseGroupResource("destinationrules.networking.istio.io").WithVersion(c.defaultIstioVersion)
+			obj, err := c.dynamicclientset.Resource(gvr).Namespace(r.Namespace).Get(dRule.Name, metav1.GetOptions{})
+			if err != nil {
+				problems = append(problems, referenceError("DestinationRule", dRule.Name, err))
+			} else if err := istio.ValidateDestinationRule(r, obj); err != nil {
+				problems = append(problems, fmt.Sprintf("DestinationRule '%s' is incompatible: %v", dRule.Name, err))
+			}
+		}
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil && canary.TrafficRouting != nil && canary.TrafficRouting.ALB != nil {
+		name := canary.TrafficRouting.ALB.Ingress
+		ingress, err := c.kubeclientset.ExtensionsV1beta1().Ingresses(r.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			problems = append(problems, referenceError("Ingress", name, err))
+		} else if err := alb.ValidateIngress(r, ingress); err != nil {
+			problems = append(problems, fmt.Sprintf("Ingress '%s' is incompatible: %v", name, err))
+		}
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil && canary.TrafficRouting != nil && canary.TrafficRouting.Nginx != nil {
+		name := canary.TrafficRouting.Nginx.PrimaryIngress
+		ingress, err := c.kubeclientset.ExtensionsV1beta1().Ingresses(r.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			problems = append(problems, referenceError("Ingress", name, err))
+		} else if err := nginx.ValidateIngress(r, ingress); err != nil {
+			problems = append(problems, fmt.Sprintf("Ingress '%s' is incompatible: %v", name, err))
+		}
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil && canary.TrafficRouting != nil && canary.TrafficRouting.GatewayAPI != nil {
+		name := canary.TrafficRouting.GatewayAPI.HTTPRoute
+		route, err := c.dynamicclientset.Resource(gatewayapi.GVR()).Namespace(r.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			problems = append(problems, referenceError("HTTPRoute", name, err))
+		} else if err := gatewayapi.ValidateHTTPRoute(r, route); err != nil {
+			problems = append(problems, fmt.Sprintf("HTTPRoute '%s' is incompatible: %v", name, err))
+		}
+	}
+	// keys of the secrets referenced by the arguments of the templates, keyed by secret name
+	secrets := map[string][]string{}
+	var secretNames []string
+	for _, ref := range referencedAnalysisTemplates(r) {
+		template, err := analysisutil.GetTemplate(c.analysisTemplateLister, c.clusterAnalysisTemplateLister, r.Namespace, ref.TemplateName, ref.ClusterScope)
+		if err != nil {
+			problems = append(problems, referenceError(templateKind(ref.ClusterScope), ref.TemplateName, err))
+			continue
+		}
+		for _, arg := range template.Spec.Args {
+			if arg.ValueFrom == nil || arg.ValueFrom.SecretKeyRef == nil {
+				continue
+			}
+			ref := arg.ValueFrom.SecretKeyRef
+			if _, ok := secrets[ref.Name]; !ok {
+				secretNames = append(secretNames, ref.Name)
+			}
+			secrets[ref.Name] = append(secrets[ref.Name], ref.Key)
+		}
+	}
+	for _, name := range secretNames {
+		secret, err := c.kubeclientset.CoreV1().Secrets(r.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			problems = append(problems, referenceError("Secret", name, err))
+			continue
+		}
+		for _, key := range uniqueNames(secrets[name]) {
+			if _, ok := secret.Data[key]; !ok {
+				problems = append(problems, fmt.Sprintf("Secret '%s' has no key '%s'", name, key))
+			}
+		}
+	}
+	return problems
+}
+
+func referenceError(kind, name string, err error) string {
+	if k8serrors.IsNotFound(err) {
+		return fmt.Sprintf("%s '%s' not found", kind, name)
+	}
+	return fmt.Sprintf("failed to get %s '%s': %v", kind, name, err)
+}
+
+// referencedServices returns the services referenced by the strategy of the rollout
+func referencedServices(r *v1alpha1.Rollout) []string {
+	var names []string
+	if bg := r.Spec.Strategy.BlueGreen; bg != nil {
+		names = append(names, bg.ActiveService, bg.PreviewService)
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil {
+		names = append(names, canary.StableService, canary.CanaryService)
+	}
+	return uniqueNames(names)
+}
+
+// referencedAnalysisTemplates returns the AnalysisTemplates and ClusterAnalysisTemplates referenced
+// by the strategy of the rollout, without duplicates
+func referencedAnalysisTemplates(r *v1alpha1.Rollout) []v1alpha1.RolloutAnalysisTemplates {
+	var refs []v1alpha1.RolloutAnalysisTemplates
+	seen := map[v1alpha1.RolloutAnalysisTemplates]bool{}
+	addTemplate := func(ref v1alpha1.RolloutAnalysisTemplates) {
+		if ref.TemplateName != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	addAnalysis := func(analysis *v1alpha1.RolloutAnalysis) {
+		if analysis == nil {
+			return
+		}
+		addTemplate(v1alpha1.RolloutAnalysisTemplates{TemplateName: analysis.TemplateName})
+		for _, template := range analysis.Templates {
+			addTemplate(template)
+		}
+	}
+	if bg := r.Spec.Strategy.BlueGreen; bg != nil {
+		addAnalysis(bg.PrePromotionAnalysis)
+		addAnalysis(bg.PostPromotionAnalysis)
+	}
+	if canary := r.Spec.Strategy.Canary; canary != nil {
+		if canary.Analysis != nil {
+			addAnalysis(&canary.Analysis.RolloutAnalysis)
+		}
+		for _, step := range canary.Steps {
+			addAnalysis(step.Analysis)
+			if step.Experiment != nil {
+				for _, analysis := range step.Experiment.Analyses {
+					addTemplate(v1alpha1.RolloutAnalysisTemplates{TemplateName: analysis.TemplateName, ClusterScope: analysis.ClusterScope})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// templateKind returns the kind of the analysis templates referenced with the scope
+func templateKind(clusterScope bool) string {
+	if clusterScope {
+		return "ClusterAnalysisTemplate"
+	}
+	return "AnalysisTemplate"
+}
+
+// uniqueNames returns the non-empty names without duplicates, in their original order
+func uniqueNames(names []string) []string {
+	var unique []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,155 @@
+package rollout
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+func newReferencingRollout() *v1alpha1.Rollout {
+	steps := []v1alpha1.CanaryStep{{
+		Analysis: &v1alpha1.RolloutAnalysis{
+			Templates: []v1alpha1.RolloutAnalysisTemplates{{TemplateName: "success-rate"}},
+		},
+	}}
+	r := newCanaryRollout("foo", 1, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Strategy.Canary.StableService = "stable"
+	r.Spec.Strategy.Canary.CanaryService = "canary"
+	return r
+}
+
+func newReferencedTemplate() *v1alpha1.AnalysisTemplate {
+	return &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "success-rate", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.AnalysisTemplateSpec{
+			Args: []v1alpha1.Argument{{
+				Name:      "api-token",
+				ValueFrom: &v1alpha1.ValueFrom{SecretKeyRef: &v1alpha1.SecretKeyRef{Name: "metrics", Key: "token"}},
+			}},
+		},
+	}
+}
+
+func TestVerifyReferences(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newReferencingRollout()
+	analysis := r.Spec.Strategy.Canary.Steps[0].Analysis
+	analysis.Templates = append(analysis.Templates, v1alpha1.RolloutAnalysisTemplates{TemplateName: "golden-signals", ClusterScope: true})
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService: v1alpha1.IstioVirtualService{Name: "vsvc", Routes: []string{"primary"}},
+		},
+	}
+	f.serviceLister = append(f.serviceLister, newService("stable", 80, nil))
+	f.analysisTemplateLister = append(f.analysisTemplateLister, newReferencedTemplate())
+	f.kubeobjects = append(f.kubeobjects, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: metav1.NamespaceDefault},
+		Data:       map[string][]byte{"user": []byte("rollouts")},
+	})
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	vsvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "vsvc", "namespace": metav1.NamespaceDefault},
+		"spec": map[string]interface{}{
+			"http": []interface{}{map[string]interface{}{
+				"name":  "primary",
+				"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "stable"}}},
+			}},
+		},
+	}}
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vsvc)
+
+	assert.Equal(t, []string{
+		"Service 'canary' not found",
+		"VirtualService 'vsvc' is incompatible: Canary Service 'canary' not found in route",
+		"ClusterAnalysisTemplate 'golden-signals' not found",
+		"Secret 'metrics' has no key 'token'",
+	}, c.verifyReferences(r))
+}
+
+func TestVerifyDestinationRuleReferences(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+		Istio: &v1alpha1.IstioTrafficRouting{
+			VirtualService:  v1alpha1.IstioVirtualService{Name: "vsvc", Routes: []string{"primary"}},
+			DestinationRule: &v1alpha1.IstioDestinationRule{Name: "dr", CanarySubsetName: "canary", StableSubsetName: "stable"},
+		},
+	}
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	vsvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "vsvc", "namespace": metav1.NamespaceDefault},
+		"spec": map[string]interface{}{
+			"http": []interface{}{map[string]interface{}{
+				"name": "primary",
+				"route": []interface{}{
+					map[string]interface{}{"destination": map[string]interface{}{"host": "guestbook", "subset": "stable"}},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "guestbook", "subset": "canary"}},
+				},
+			}},
+		},
+	}}
+	dRule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "DestinationRule",
+		"metadata":   map[string]interface{}{"name": "dr", "namespace": metav1.NamespaceDefault},
+		"spec": map[string]interface{}{
+			"subsets": []interface{}{map[string]interface{}{"name": "stable"}},
+		},
+	}}
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vsvc, dRule)
+	assert.Equal(t, []string{
+		"DestinationRule 'dr' is incompatible: Subset 'canary' is not found",
+	}, c.verifyReferences(r))
+
+	c.dynamicclientset = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vsvc)
+	assert.Equal(t, []string{
+		"DestinationRule 'dr' not found",
+	}, c.verifyReferences(r))
+}
+
+func TestReferencesNotVerifiedBlocksUpdate(t *testing.T) {
+	configutil.SetDefaults(map[string]string{configutil.VerifyReferencesKey: "true"})
+	defer configutil.SetDefaults(nil)
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newReferencingRollout()
+	f.rolloutLister = append(f.rolloutLister, r)
+	f.objects = append(f.objects, r)
+	f.serviceLister = append(f.serviceLister, newService("stable", 80, nil), newService("canary", 80, nil))
+
+	patchIndex := f.expectPatchRolloutAction(r)
+	f.run(getKey(r, t))
+
+	var patch struct {
+		Status v1alpha1.RolloutStatus `json:"status"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(f.getPatchedRollout(patchIndex)), &patch))
+	cond := conditions.GetRolloutCondition(patch.Status, v1alpha1.RolloutReferencesVerified)
+	assert.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, conditions.InvalidReferencesReason, cond.Reason)
+	assert.Equal(t, "AnalysisTemplate 'success-rate' not found", cond.Message)
+	// the ReplicaSet of the update is not created
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+}
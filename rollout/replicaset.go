@@ -13,6 +13,7 @@ import (
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
+	"github.com/argoproj/argo-rollouts/utils/diff"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
 )
 
@@ -27,7 +28,11 @@ func (c *RolloutController) removeScaleDownDelay(roCtx rolloutContext, rs *appsv
 	logCtx := roCtx.Log()
 	logCtx.Infof("Removing '%s' annotation on RS '%s'", v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey, rs.Name)
 	patch := fmt.Sprintf(removeScaleDownAtAnnotationsPatch, v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey)
-	_, err := c.kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Patch(rs.Name, patchtypes.JSONPatchType, []byte(patch))
+	kubeclientset, err := c.kubeClientForReplicaSet(rs)
+	if err != nil {
+		return err
+	}
+	_, err = kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Patch(rs.Name, patchtypes.JSONPatchType, []byte(patch))
 	return err
 }
 
@@ -37,10 +42,40 @@ func (c *RolloutController) addScaleDownDelay(roCtx rolloutContext, rs *appsv1.R
 	scaleDownDelaySeconds := time.Duration(defaults.GetScaleDownDelaySecondsOrDefault(roCtx.Rollout()))
 	now := metav1.Now().Add(scaleDownDelaySeconds * time.Second).UTC().Format(time.RFC3339)
 	patch := fmt.Sprintf(addScaleDownAtAnnotationsPatch, v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey, now)
-	_, err := c.kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Patch(rs.Name, patchtypes.JSONPatchType, []byte(patch))
+	kubeclientset, err := c.kubeClientForReplicaSet(rs)
+	if err != nil {
+		return err
+	}
+	_, err = kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Patch(rs.Name, patchtypes.JSONPatchType, []byte(patch))
 	return err
 }
 
+// patchReplicaSet persists the changes to the labels, annotations and spec of the ReplicaSet with a
+// minimal strategic merge patch instead of a full update, so they do not conflict with the
+// ReplicaSet controller updating its status. No request is made if nothing changed.
+func (c *RolloutController) patchReplicaSet(orig, updated *appsv1.ReplicaSet) (*appsv1.ReplicaSet, error) {
+	patch, modified, err := diff.CreateTwoWayMergePatch(
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Labels: orig.Labels, Annotations: orig.Annotations},
+			Spec:       orig.Spec,
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Labels: updated.Labels, Annotations: updated.Annotations},
+			Spec:       updated.Spec,
+		}, appsv1.ReplicaSet{})
+	if err != nil {
+		return nil, err
+	}
+	if !modified {
+		return orig, nil
+	}
+	kubeclientset, err := c.kubeClientForReplicaSet(orig)
+	if err != nil {
+		return nil, err
+	}
+	return kubeclientset.AppsV1().ReplicaSets(orig.Namespace).Patch(orig.Name, patchtypes.StrategicMergePatchType, patch)
+}
+
 func (c *RolloutController) getReplicaSetsForRollouts(r *v1alpha1.Rollout) ([]*appsv1.ReplicaSet, error) {
 	// List all ReplicaSets to find those we own but that no longer match our
 	// selector. They will be orphaned by ClaimReplicaSets().
@@ -65,7 +100,16 @@ func (c *RolloutController) getReplicaSetsForRollouts(r *v1alpha1.Rollout) ([]*a
 		return fresh, nil
 	})
 	cm := controller.NewReplicaSetControllerRefManager(c.replicaSetControl, r, replicaSetSelector, controllerKind, canAdoptFunc)
-	return cm.ClaimReplicaSets(rsList)
+	claimedRSs, err := cm.ClaimReplicaSets(rsList)
+	if err != nil {
+		return nil, err
+	}
+	// the ReplicaSets of the registered clusters are never adopted or orphaned
+	clusterRSs, err := c.getClusterReplicaSets(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(claimedRSs, clusterRSs...), nil
 }
 
 func (c *RolloutController) reconcileNewReplicaSet(roCtx rolloutContext) (bool, error) {
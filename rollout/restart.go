@@ -0,0 +1,107 @@
+package rollout
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	"k8s.io/kubernetes/pkg/controller"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// restartPodsRequeueDelay is how long the controller waits before checking whether the recreated pods
+// are available to restart the next ones
+const restartPodsRequeueDelay = 10 * time.Second
+
+// reconcileRestart restarts the pods of the rollout created before spec.restartAt, like
+// `kubectl rollout restart` does for Deployments. The pods are deleted a few at a time so no more
+// than maxUnavailable pods are unavailable, and are recreated by their ReplicaSets. The restartAt
+// time is recorded in status.restartedAt once every pod was restarted.
+func (c *RolloutController) reconcileRestart(roCtx rolloutContext) error {
+	rollout := roCtx.Rollout()
+	restartAt := rollout.Spec.RestartAt
+	if restartAt == nil || (rollout.Status.RestartedAt != nil && !rollout.Status.RestartedAt.Before(restartAt)) {
+		return nil
+	}
+	logCtx := roCtx.Log()
+	now := metav1.NewTime(nowFn())
+	if now.Before(restartAt) {
+		c.enqueueRolloutAfter(rollout, restartAt.Sub(now.Time))
+		return nil
+	}
+
+	unavailable := int32(0)
+	var oldPods []*corev1.Pod
+	// the pods are deleted in the clusters of their ReplicaSets
+	podClients := map[*corev1.Pod]kubernetes.Interface{}
+	for _, rs := range controller.FilterActiveReplicaSets(roCtx.AllRSs()) {
+		selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+		if err != nil {
+			return err
+		}
+		kubeclientset, err := c.kubeClientForReplicaSet(rs)
+		if err != nil {
+			return err
+		}
+		podList, err := kubeclientset.CoreV1().Pods(rs.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return err
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if !metav1.IsControlledBy(pod, rs) {
+				continue
+			}
+			if pod.DeletionTimestamp != nil || !podutil.IsPodAvailable(pod, rollout.Spec.MinReadySeconds, now) {
+				unavailable++
+			}
+			if pod.DeletionTimestamp == nil && pod.CreationTimestamp.Before(restartAt) {
+				oldPods = append(oldPods, pod)
+				podClients[pod] = kubeclientset
+			}
+		}
+	}
+	if len(oldPods) == 0 {
+		logCtx.Info("Restarted all the pods")
+		roCtx.SetRestartedAt(restartAt.DeepCopy())
+		c.recorder.Eventf(rollout, corev1.EventTypeNormal, "RestartedPods", "Restarted all the pods created before %s", restartAt.UTC().Format(time.RFC3339))
+		return nil
+	}
+
+	// unavailable pods are restarted first since deleting them does not reduce the availability
+	// of the rollout
+	sort.SliceStable(oldPods, func(i, j int) bool {
+		return !podutil.IsPodAvailable(oldPods[i], rollout.Spec.MinReadySeconds, now) && podutil.IsPodAvailable(oldPods[j], rollout.Spec.MinReadySeconds, now)
+	})
+	budget := restartMaxUnavailable(rollout) - unavailable
+	for _, pod := range oldPods {
+		if podutil.IsPodAvailable(pod, rollout.Spec.MinReadySeconds, now) {
+			if budget <= 0 {
+				break
+			}
+			budget--
+		}
+		logCtx.Infof("Deleting pod '%s' to restart it", pod.Name)
+		err := podClients[pod].CoreV1().Pods(pod.Namespace).Delete(pod.Name, nil)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	c.enqueueRolloutAfter(rollout, restartPodsRequeueDelay)
+	return nil
+}
+
+// restartMaxUnavailable returns how many pods can be unavailable while the pods are restarted. A
+// blue-green rollout restarts one pod at a time, and a restart always makes progress.
+func restartMaxUnavailable(rollout *v1alpha1.Rollout) int32 {
+	if maxUnavailable := replicasetutil.MaxUnavailable(rollout); maxUnavailable > 1 {
+		return maxUnavailable
+	}
+	return 1
+}
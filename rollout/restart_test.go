@@ -0,0 +1,82 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newAvailablePod(rs *appsv1.ReplicaSet, name string, created time.Time) *corev1.Pod {
+	pod := newReplicaSetPod(rs, name)
+	pod.CreationTimestamp = metav1.NewTime(created)
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(created),
+	}}
+	return pod
+}
+
+func TestReconcileRestart(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 3, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(1))
+	r.Spec.RestartAt = &metav1.Time{Time: time.Now().Add(-time.Minute).Truncate(time.Second)}
+	rs := newReplicaSetWithStatus(r, 3, 3)
+	created := time.Now().Add(-time.Hour)
+	f.kubeobjects = append(f.kubeobjects, rs,
+		newAvailablePod(rs, "foo-abc123-1", created),
+		newAvailablePod(rs, "foo-abc123-2", created),
+		newAvailablePod(rs, "foo-abc123-3", created))
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	// a single pod is restarted at a time since only one can be unavailable
+	roCtx := newCanaryCtx(r, rs, nil, nil, nil)
+	assert.NoError(t, c.reconcileRestart(roCtx))
+	actions := filterInformerActions(f.kubeclient.Actions())
+	assert.Len(t, actions, 2)
+	assert.True(t, actions[0].Matches("list", "pods"))
+	assert.True(t, actions[1].Matches("delete", "pods"))
+	assert.Nil(t, roCtx.NewStatus().RestartedAt)
+}
+
+func TestReconcileRestartCompleted(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newCanaryRollout("foo", 1, nil, nil, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(1))
+	restartAt := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	r.Spec.RestartAt = &restartAt
+	rs := newReplicaSetWithStatus(r, 1, 1)
+	f.kubeobjects = append(f.kubeobjects, rs, newAvailablePod(rs, "foo-abc123-1", time.Now()))
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	// the restart completes once no pod was created before restartAt
+	roCtx := newCanaryCtx(r, rs, nil, nil, nil)
+	assert.NoError(t, c.reconcileRestart(roCtx))
+	assert.Equal(t, &restartAt, roCtx.NewStatus().RestartedAt)
+	assert.Len(t, filterInformerActions(f.kubeclient.Actions()), 1)
+
+	// a completed restart is not checked again
+	r.Status.RestartedAt = restartAt.DeepCopy()
+	assert.NoError(t, c.reconcileRestart(newCanaryCtx(r, rs, nil, nil, nil)))
+	assert.Len(t, filterInformerActions(f.kubeclient.Actions()), 1)
+}
+
+func TestRestartMaxUnavailable(t *testing.T) {
+	r := newCanaryRollout("foo", 10, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(3))
+	assert.Equal(t, int32(3), restartMaxUnavailable(r))
+	noUnavailable := intstr.FromInt(0)
+	r.Spec.Strategy.Canary.MaxUnavailable = &noUnavailable
+	assert.Equal(t, int32(1), restartMaxUnavailable(r))
+	assert.Equal(t, int32(1), restartMaxUnavailable(&v1alpha1.Rollout{Spec: v1alpha1.RolloutSpec{Strategy: v1alpha1.RolloutStrategy{BlueGreen: &v1alpha1.BlueGreenStrategy{}}}}))
+}
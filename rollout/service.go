@@ -3,16 +3,21 @@ package rollout
 import (
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/kubernetes/pkg/controller"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	applyutil "github.com/argoproj/argo-rollouts/utils/apply"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
 )
 
 const (
@@ -25,24 +30,56 @@ const (
 }`
 )
 
-// switchSelector switch the selector on an existing service to a new value
+// serviceSwitch is a switch of the selector of the active or stable service of a rollout, which is
+// verified once the service read from the cache selects the new value
+type serviceSwitch struct {
+	service  string
+	selector string
+}
+
+// switchSelector switch the selector on an existing service to a new value. The service is
+// replaced with the service returned by the API server, and the switch fails if the selector
+// returned does not select the new value, e.g. because another controller manages the selector.
+// The switch of the active or stable service is verified again against the cached service on the
+// next reconciliations, see reconcileServiceSelectorSwitched.
 func (c RolloutController) switchServiceSelector(service *corev1.Service, newRolloutUniqueLabelValue string, r *v1alpha1.Rollout) error {
-	if service.Spec.Selector == nil {
-		service.Spec.Selector = make(map[string]string)
-	}
+	return c.switchServiceSelectorOfCluster(c.kubeclientset, service, newRolloutUniqueLabelValue, r)
+}
+
+// switchServiceSelectorOfCluster switches the selector of a service of the cluster of the client
+func (c RolloutController) switchServiceSelectorOfCluster(kubeclientset kubernetes.Interface, service *corev1.Service, newRolloutUniqueLabelValue string, r *v1alpha1.Rollout) error {
 	if oldPodHash, ok := service.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey]; ok && oldPodHash == newRolloutUniqueLabelValue {
 		return nil
 	}
-	patch := fmt.Sprintf(switchSelectorPatch, v1alpha1.DefaultRolloutUniqueLabelKey, newRolloutUniqueLabelValue)
-	_, err := c.kubeclientset.CoreV1().Services(service.Namespace).Patch(service.Name, patchtypes.StrategicMergePatchType, []byte(patch))
+	span := tracing.StartSpan(logutil.RolloutKey, r.Namespace, r.Name, "switch service selector")
+	span.SetAttribute(logutil.ServiceKey, service.Name)
+	var updated *corev1.Service
+	var err error
+	if c.useServerSideApply() {
+		selector := make(map[string]string, len(service.Spec.Selector)+1)
+		for k, v := range service.Spec.Selector {
+			selector[k] = v
+		}
+		selector[v1alpha1.DefaultRolloutUniqueLabelKey] = newRolloutUniqueLabelValue
+		updated, err = applyutil.ServiceSelector(kubeclientset.CoreV1().RESTClient(), service, selector)
+	} else {
+		patch := fmt.Sprintf(switchSelectorPatch, v1alpha1.DefaultRolloutUniqueLabelKey, newRolloutUniqueLabelValue)
+		updated, err = kubeclientset.CoreV1().Services(service.Namespace).Patch(service.Name, patchtypes.StrategicMergePatchType, []byte(patch))
+	}
+	if err == nil && updated.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] != newRolloutUniqueLabelValue {
+		err = fmt.Errorf(conditions.ServiceSelectorNotSwitchedMessage, service.Name, newRolloutUniqueLabelValue)
+		c.recorder.Event(r, corev1.EventTypeWarning, conditions.ServiceSelectorNotSwitchedReason, err.Error())
+	}
+	span.End(err)
 	if err != nil {
 		return err
 	}
 	msg := fmt.Sprintf("Switched selector for service '%s' to value '%s'", service.Name, newRolloutUniqueLabelValue)
 	logutil.WithRollout(r).Info(msg)
 	c.recorder.Event(r, corev1.EventTypeNormal, "SwitchService", msg)
-	service.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] = newRolloutUniqueLabelValue
-	return err
+	c.metricsServer.IncServiceSwitch(r, service.Name)
+	*service = *updated
+	return nil
 }
 
 func (c *RolloutController) reconcilePreviewService(roCtx *blueGreenContext, previewSvc *corev1.Service) error {
@@ -84,23 +121,67 @@ func (c *RolloutController) reconcileActiveService(roCtx *blueGreenContext, prev
 
 	if r.Status.Abort {
 		currentRevision := int(0)
+		var rollbackRS *appsv1.ReplicaSet
 		for _, rs := range controller.FilterActiveReplicaSets(roCtx.OlderRSs()) {
 			revision := replicasetutil.GetReplicaSetRevision(r, rs)
 			if revision > currentRevision {
-				newPodHash = rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+				rollbackRS = rs
 				currentRevision = revision
 			}
 		}
+		if rollbackRS != nil {
+			newPodHash = rollbackRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+		}
+		// After a failed post promotion analysis, the active service is only switched back once the
+		// previous ReplicaSet is available again
+		if rollbackRS != nil && r.Spec.Strategy.BlueGreen.PostPromotionAnalysis != nil && rollbackRS.Status.AvailableReplicas < *rollbackRS.Spec.Replicas {
+			roCtx.log.Infof("Waiting for ReplicaSet '%s' to be available to switch the active service back", rollbackRS.Name)
+			newPodHash = activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey]
+		}
 	}
 
+	switched := activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] != newPodHash
 	err := c.switchServiceSelector(activeSvc, newPodHash, r)
 	if err != nil {
 		return err
 	}
+	if switched {
+		c.serviceSwitches.Store(serviceSwitchesKey(r), serviceSwitch{service: activeSvc.Name, selector: newPodHash})
+	}
 	return nil
 }
 
-// getReferencedService returns service references in rollout spec and sets warning condition if service does not exist
+// serviceSwitchesKey returns the key of the pending switch of the active or stable service of the rollout
+func serviceSwitchesKey(r *v1alpha1.Rollout) string {
+	return fmt.Sprintf("%s/%s", r.Namespace, r.Name)
+}
+
+// reconcileServiceSelectorSwitched returns the ServiceSelectorSwitched condition of the rollout,
+// or nil if it is unchanged. The condition is false from the switch of the selector of the active
+// or stable service until the service read from the cache on a later reconciliation selects the new
+// value, so the switch is not only verified against the response of the API server.
+func (c *RolloutController) reconcileServiceSelectorSwitched(r *v1alpha1.Rollout, newStatus *v1alpha1.RolloutStatus) *v1alpha1.RolloutCondition {
+	key := serviceSwitchesKey(r)
+	if value, ok := c.serviceSwitches.Load(key); ok {
+		pending := value.(serviceSwitch)
+		svc, err := c.servicesLister.Services(r.Namespace).Get(pending.service)
+		if err != nil || svc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] != pending.selector {
+			msg := fmt.Sprintf(conditions.ServiceSelectorSwitchPendingMessage, pending.service, pending.selector)
+			return conditions.NewRolloutCondition(v1alpha1.RolloutServiceSelectorSwitched, corev1.ConditionFalse, conditions.ServiceSelectorSwitchPendingReason, msg)
+		}
+		c.serviceSwitches.Delete(key)
+	}
+	// the pending switch is forgotten when the controller restarts, in which case the selectors are
+	// switched again by the reconciliation if they are not
+	prevCond := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutServiceSelectorSwitched)
+	if prevCond != nil && prevCond.Status == corev1.ConditionFalse {
+		return conditions.NewRolloutCondition(v1alpha1.RolloutServiceSelectorSwitched, corev1.ConditionTrue, conditions.ServiceSelectorSwitchedReason, conditions.ServiceSelectorSwitchedMessage)
+	}
+	return nil
+}
+
+// getReferencedService returns service references in rollout spec and sets warning condition if service does not exist.
+// The service is a copy of the cached service, so it can be replaced once its selector is switched.
 func (c *RolloutController) getReferencedService(r *v1alpha1.Rollout, serviceName string) (*corev1.Service, error) {
 	svc, err := c.servicesLister.Services(r.Namespace).Get(serviceName)
 	if err != nil {
@@ -113,7 +194,7 @@ func (c *RolloutController) getReferencedService(r *v1alpha1.Rollout, serviceNam
 		}
 		return nil, err
 	}
-	return svc, nil
+	return svc.DeepCopy(), nil
 }
 
 func (c *RolloutController) getPreviewAndActiveServices(r *v1alpha1.Rollout) (*corev1.Service, *corev1.Service, error) {
@@ -154,8 +235,12 @@ func (c *RolloutController) reconcileStableAndCanaryService(roCtx *canaryContext
 			if err != nil {
 				return err
 			}
+			c.serviceSwitches.Store(serviceSwitchesKey(r), serviceSwitch{service: svc.Name, selector: stableRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]})
+		}
+		err = c.switchClusterServiceSelectors(roCtx, svc.Name, stableRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey])
+		if err != nil {
+			return err
 		}
-
 	}
 	if r.Spec.Strategy.Canary.CanaryService != "" && newRS != nil {
 		svc, err := c.getReferencedService(r, r.Spec.Strategy.Canary.CanaryService)
@@ -168,6 +253,48 @@ func (c *RolloutController) reconcileStableAndCanaryService(roCtx *canaryContext
 				return err
 			}
 		}
+		err = c.switchClusterServiceSelectors(roCtx, svc.Name, newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey])
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// switchClusterServiceSelectors switches the selector of the service of the same name in each
+// registered cluster the ReplicaSets of the rollout run in. The services of all the clusters then
+// select the same pods, so a multi-cluster service merging them routes to those pods in whichever
+// cluster they run. A service is only read again once the value to select changes.
+func (c *RolloutController) switchClusterServiceSelectors(roCtx *canaryContext, serviceName, value string) error {
+	r := roCtx.Rollout()
+	for _, cluster := range replicaSetClusters(roCtx.AllRSs()) {
+		key := clusterServiceSelectorKey(r, cluster, serviceName)
+		if switched, ok := c.clusterServiceSelectors.Load(key); ok && switched.(string) == value {
+			continue
+		}
+		kubeclientset, err := c.kubeClientForCluster(cluster)
+		if err != nil {
+			return err
+		}
+		svc, err := kubeclientset.CoreV1().Services(r.Namespace).Get(serviceName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				err = fmt.Errorf(conditions.ClusterServiceNotFoundMessage, serviceName, cluster)
+				c.recorder.Event(r, corev1.EventTypeWarning, conditions.ServiceNotFoundReason, err.Error())
+			}
+			return err
+		}
+		err = c.switchServiceSelectorOfCluster(kubeclientset, svc, value, r)
+		if err != nil {
+			return err
+		}
+		c.clusterServiceSelectors.Store(key, value)
+	}
+	return nil
+}
+
+// clusterServiceSelectorKey returns the key of the value the selector of the service of the
+// registered cluster was switched to for the rollout
+func clusterServiceSelectorKey(r *v1alpha1.Rollout, cluster, serviceName string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", r.Namespace, r.Name, cluster, serviceName)
+}
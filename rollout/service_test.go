@@ -8,7 +8,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	testclient "k8s.io/client-go/testing"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
@@ -136,3 +138,64 @@ func TestPreviewServiceNotFound(t *testing.T) {
 	_, pausedCondition := newProgressingCondition(conditions.ServiceNotFoundReason, notUsedPreviewSvc)
 	assert.Equal(t, calculatePatch(r, fmt.Sprintf(expectedPatch, pausedCondition)), patch)
 }
+
+func TestSwitchServiceSelector(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newBlueGreenRollout("foo", 1, nil, "active", "")
+	activeSvc := newService("active", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: "abc"})
+	f.kubeobjects = append(f.kubeobjects, activeSvc)
+	f.serviceLister = append(f.serviceLister, activeSvc)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	// the service is replaced by the service written by the API server, and the cached service is left as is
+	svc, err := c.getReferencedService(r, "active")
+	assert.NoError(t, err)
+	assert.NoError(t, c.switchServiceSelector(svc, "def", r))
+	assert.Equal(t, "def", svc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+	assert.Equal(t, "abc", activeSvc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+
+	// the switch fails if another writer keeps the previous selector
+	f.kubeclient.PrependReactor("patch", "services", func(action testclient.Action) (bool, runtime.Object, error) {
+		return true, activeSvc.DeepCopy(), nil
+	})
+	svc, err = c.getReferencedService(r, "active")
+	assert.NoError(t, err)
+	err = c.switchServiceSelector(svc, "ghi", r)
+	assert.EqualError(t, err, fmt.Sprintf(conditions.ServiceSelectorNotSwitchedMessage, "active", "ghi"))
+	assert.Equal(t, "abc", svc.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+}
+
+func TestReconcileServiceSelectorSwitched(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r := newBlueGreenRollout("foo", 1, nil, "active", "")
+	activeSvc := newService("active", 80, map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: "abc"})
+	f.kubeobjects = append(f.kubeobjects, activeSvc)
+	f.serviceLister = append(f.serviceLister, activeSvc)
+	c, _, k8sI := f.newController(noResyncPeriodFunc)
+
+	// without a switch, the condition is not added
+	assert.Nil(t, c.reconcileServiceSelectorSwitched(r, &r.Status))
+
+	// the switch is pending until the cached service selects the new value
+	c.serviceSwitches.Store(serviceSwitchesKey(r), serviceSwitch{service: "active", selector: "def"})
+	cond := c.reconcileServiceSelectorSwitched(r, &r.Status)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, conditions.ServiceSelectorSwitchPendingReason, cond.Reason)
+	assert.Equal(t, fmt.Sprintf(conditions.ServiceSelectorSwitchPendingMessage, "active", "def"), cond.Message)
+	conditions.SetRolloutCondition(&r.Status, *cond)
+
+	switched := activeSvc.DeepCopy()
+	switched.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey] = "def"
+	assert.NoError(t, k8sI.Core().V1().Services().Informer().GetIndexer().Update(switched))
+	cond = c.reconcileServiceSelectorSwitched(r, &r.Status)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, conditions.ServiceSelectorSwitchedReason, cond.Reason)
+	_, ok := c.serviceSwitches.Load(serviceSwitchesKey(r))
+	assert.False(t, ok)
+	conditions.SetRolloutCondition(&r.Status, *cond)
+	assert.Nil(t, c.reconcileServiceSelectorSwitched(r, &r.Status))
+}
@@ -0,0 +1,183 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// defaultSLOPostPromotionWindow is how long the burn rates are evaluated after an update completed
+const defaultSLOPostPromotionWindow = 15 * time.Minute
+
+// reconcileSLOAnalysisRun evaluates the burn rates of the objectives of the rollout from the start
+// of an update until the post-promotion window after it completed. A fast burn aborts the update,
+// or rolls back to the previous revision once the update completed.
+func (c *RolloutController) reconcileSLOAnalysisRun(roCtx rolloutContext) (*v1alpha1.AnalysisRun, error) {
+	status := *roCtx.Rollout().Status.SLO.DeepCopy()
+	currentAr, err := c.reconcileSLOAnalysis(roCtx, &status)
+	roCtx.SetSLOStatus(status)
+	return currentAr, err
+}
+
+func (c *RolloutController) reconcileSLOAnalysis(roCtx rolloutContext, status *v1alpha1.SLOStatus) (*v1alpha1.AnalysisRun, error) {
+	rollout := roCtx.Rollout()
+	slo := rollout.Spec.SLOAnalysis
+	currentAr := analysisutil.FilterAnalysisRunsByName(roCtx.CurrentAnalysisRuns(), status.CurrentAnalysisRun)
+	podHash := replicasetutil.GetPodTemplateHash(roCtx.NewRS())
+	if slo == nil || (currentAr != nil && currentAr.Labels[v1alpha1.DefaultRolloutUniqueLabelKey] != podHash) {
+		// the run evaluated a previous revision
+		if err := c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr}); err != nil {
+			return nil, err
+		}
+		currentAr = nil
+		status.CurrentAnalysisRun = ""
+		status.PromotedAt = nil
+	}
+	if podHash != status.RolledBackFrom && podHash != status.RolledBackTo {
+		status.RolledBackFrom = ""
+		status.RolledBackTo = ""
+	}
+	// Do not evaluate the objectives if the rollout was just created, has no previous revision to
+	// return to, or was rolled back to the revision
+	if slo == nil || podHash == "" || rollout.Status.CurrentPodHash == "" || podHash == status.RolledBackTo || previousRevisionRS(roCtx) == nil {
+		return nil, nil
+	}
+
+	promoted := isPromoted(rollout, podHash)
+	if currentAr == nil {
+		if promoted {
+			// the objectives are only evaluated after the updates they evaluated during
+			return nil, nil
+		}
+		instanceID := analysisutil.GetInstanceID(rollout)
+		currentAr, err := c.createSLOAnalysisRun(roCtx, podHash, analysisutil.SLOLabels(podHash, instanceID))
+		if err != nil {
+			return nil, err
+		}
+		roCtx.Log().WithField(logutil.AnalysisRunKey, currentAr.Name).Info("Created SLO AnalysisRun")
+		status.CurrentAnalysisRun = currentAr.Name
+		status.PromotedAt = nil
+		return currentAr, nil
+	}
+
+	if promoted {
+		if status.PromotedAt == nil {
+			now := metav1.NewTime(nowFn())
+			status.PromotedAt = &now
+		}
+		window := defaultSLOPostPromotionWindow
+		if slo.PostPromotionWindow != "" {
+			window, _ = slo.PostPromotionWindow.Duration()
+		}
+		remaining := status.PromotedAt.Add(window).Sub(nowFn())
+		if remaining <= 0 {
+			return currentAr, c.cancelAnalysisRuns(roCtx, []*v1alpha1.AnalysisRun{currentAr})
+		}
+		c.enqueueRolloutAfter(rollout, remaining)
+	}
+
+	c.recordAnalysisVerdict(roCtx, currentAr)
+	msg := fmt.Sprintf("AnalysisRun '%s' completed with phase '%s'", currentAr.Name, currentAr.Status.Phase)
+	switch {
+	case !promoted && (currentAr.Status.Phase == v1alpha1.AnalysisPhaseError || currentAr.Status.Phase == v1alpha1.AnalysisPhaseFailed):
+		roCtx.PauseContext().AddAbort(msg)
+	case promoted && currentAr.Status.Phase == v1alpha1.AnalysisPhaseFailed && status.RolledBackFrom != podHash:
+		rolledBackTo, err := c.rollbackToPreviousRevision(roCtx, msg)
+		if err != nil {
+			return nil, err
+		}
+		status.RolledBackFrom = podHash
+		status.RolledBackTo = rolledBackTo
+	}
+	return currentAr, nil
+}
+
+func (c *RolloutController) createSLOAnalysisRun(roCtx rolloutContext, podHash string, labels map[string]string) (*v1alpha1.AnalysisRun, error) {
+	r := roCtx.Rollout()
+	revision := r.Annotations[annotations.RevisionAnnotation]
+	name := strings.Join([]string{r.Name, podHash, revision, "slo"}, "-")
+	ar, err := analysisutil.NewAnalysisRunFromSLOAnalysis(r.Spec.SLOAnalysis, name, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	ar.Labels = labels
+	ar.Annotations = map[string]string{
+		annotations.RevisionAnnotation: revision,
+	}
+	ar.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(r, controllerKind)}
+	analysisRunIf := c.argoprojclientset.ArgoprojV1alpha1().AnalysisRuns(r.Namespace)
+	return analysisutil.CreateWithCollisionCounter(roCtx.Log(), analysisRunIf, *ar)
+}
+
+// rollbackToPreviousRevision replaces the pod template of the rollout with the template of the
+// previous revision and returns the pod template hash of the revision. The template is the one the
+// rollout specified, without the metadata, image digests and anti-affinity injected by the
+// controller, so it hashes to the previous ReplicaSet.
+func (c *RolloutController) rollbackToPreviousRevision(roCtx rolloutContext, reason string) (string, error) {
+	r := roCtx.Rollout()
+	previousRS := previousRevisionRS(roCtx)
+	template := replicasetutil.GetRolloutPodTemplate(previousRS)
+	template.Spec.Affinity = replicasetutil.RemoveInjectedAntiAffinityRule(template.Spec.Affinity)
+	podHash := replicasetutil.GetPodTemplateHash(previousRS)
+	delete(template.Labels, v1alpha1.DefaultRolloutUniqueLabelKey)
+	patch, err := json.Marshal([]map[string]interface{}{{
+		"op":    "replace",
+		"path":  "/spec/template",
+		"value": template,
+	}})
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(r.Namespace).Patch(r.Name, patchtypes.JSONPatchType, patch); err != nil {
+		return "", err
+	}
+	revision := previousRS.Annotations[annotations.RevisionAnnotation]
+	roCtx.Log().Infof("Rolled back to revision %s: %s", revision, reason)
+	c.recorder.Eventf(r, corev1.EventTypeWarning, "SLORollback", "Rolled back to revision %s: %s", revision, reason)
+	return podHash, nil
+}
+
+// previousRevisionRS returns the ReplicaSet of the revision before the current one
+func previousRevisionRS(roCtx rolloutContext) *appsv1.ReplicaSet {
+	newRS := roCtx.NewRS()
+	if newRS == nil {
+		return nil
+	}
+	current, _ := replicasetutil.Revision(newRS)
+	var previous *appsv1.ReplicaSet
+	var previousRevision int64
+	for _, rs := range roCtx.AllRSs() {
+		if rs == nil || rs.Name == newRS.Name {
+			continue
+		}
+		revision, err := replicasetutil.Revision(rs)
+		if err != nil || revision >= current {
+			continue
+		}
+		if previous == nil || revision > previousRevision {
+			previous = rs
+			previousRevision = revision
+		}
+	}
+	return previous
+}
+
+// isPromoted returns true if the update to the revision with the pod template hash completed
+func isPromoted(r *v1alpha1.Rollout, podHash string) bool {
+	if r.Spec.Strategy.BlueGreen != nil {
+		return r.Status.BlueGreen.ActiveSelector == podHash
+	}
+	return r.Status.Canary.StableRS == podHash
+}
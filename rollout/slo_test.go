@@ -0,0 +1,201 @@
+package rollout
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+	"k8s.io/kubernetes/pkg/controller"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// newSLORollouts returns a rollout updated from r1 to r2 with an SLO analysis and their ReplicaSets
+func newSLORollouts() (*v1alpha1.Rollout, *v1alpha1.Rollout) {
+	steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}, {Pause: &v1alpha1.RolloutPause{}}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r1.Spec.SLOAnalysis = &v1alpha1.SLOAnalysis{
+		Address: "http://prometheus:9090",
+		Objectives: []v1alpha1.ServiceLevelObjective{{
+			Name:            "availability",
+			Target:          "99.9",
+			ErrorRatioQuery: "error_ratio:$(window)",
+		}},
+	}
+	r2 := bumpVersion(r1)
+	return r1, r2
+}
+
+func newSLOAnalysisRun(r *v1alpha1.Rollout, phase v1alpha1.AnalysisPhase) *v1alpha1.AnalysisRun {
+	ar, _ := analysisutil.NewAnalysisRunFromSLOAnalysis(r.Spec.SLOAnalysis, "foo-slo", r.Namespace)
+	ar.Labels = analysisutil.SLOLabels(r.Status.CurrentPodHash, "")
+	ar.Status.Phase = phase
+	return ar
+}
+
+func TestReconcileSLOAnalysisRunCreatesRun(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	r1, r2 := newSLORollouts()
+	rs1 := newReplicaSetWithStatus(r1, 9, 9)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	r2.Status.Canary.StableRS = rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, nil)
+
+	ar, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.NotNil(t, ar)
+	assert.Equal(t, "foo-"+r2.Status.CurrentPodHash+"-2-slo", ar.Name)
+	assert.Equal(t, v1alpha1.RolloutTypeSLOLabel, ar.Labels[v1alpha1.RolloutTypeLabel])
+	assert.Len(t, ar.Spec.Metrics, 2)
+	assert.Equal(t, ar.Name, roCtx.newStatus.SLO.CurrentAnalysisRun)
+
+	// the first revision has no previous revision to return to
+	r1.Status.CurrentPodHash = rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	roCtx = newCanaryCtx(r1, rs1, nil, nil, nil)
+	ar, err = c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.Nil(t, ar)
+}
+
+func TestReconcileSLOAnalysisRunAbortsUpdate(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	r1, r2 := newSLORollouts()
+	rs1 := newReplicaSetWithStatus(r1, 9, 9)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+	r2.Status.Canary.StableRS = rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	ar := newSLOAnalysisRun(r2, v1alpha1.AnalysisPhaseFailed)
+	r2.Status.SLO.CurrentAnalysisRun = ar.Name
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, []*v1alpha1.AnalysisRun{ar})
+
+	currentAr, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, ar, currentAr)
+	assert.True(t, roCtx.PauseContext().IsAborted())
+	assert.Empty(t, filterInformerActions(f.client.Actions()))
+}
+
+func TestReconcileSLOAnalysisRunRollsBackAfterPromotion(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newSLORollouts()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs2 := newReplicaSetWithStatus(r2, 10, 10)
+	r2.Status.Canary.StableRS = r2.Status.CurrentPodHash
+	ar := newSLOAnalysisRun(r2, v1alpha1.AnalysisPhaseFailed)
+	r2.Status.SLO.CurrentAnalysisRun = ar.Name
+	f.objects = append(f.objects, r2)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, []*v1alpha1.AnalysisRun{ar})
+
+	_, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.False(t, roCtx.PauseContext().IsAborted())
+	actions := filterInformerActions(f.client.Actions())
+	assert.Len(t, actions, 1)
+	patch := actions[0].(core.PatchAction)
+	var ops []struct {
+		Path  string                 `json:"path"`
+		Value map[string]interface{} `json:"value"`
+	}
+	assert.NoError(t, json.Unmarshal(patch.GetPatch(), &ops))
+	assert.Equal(t, "/spec/template", ops[0].Path)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	assert.Equal(t, rs1PodHash, roCtx.newStatus.SLO.RolledBackTo)
+	assert.Equal(t, r2.Status.CurrentPodHash, roCtx.newStatus.SLO.RolledBackFrom)
+	assert.NotNil(t, roCtx.newStatus.SLO.PromotedAt)
+
+	// the revision the rollout was rolled back to is not evaluated
+	r3 := r2.DeepCopy()
+	r3.Status.SLO = roCtx.newStatus.SLO
+	roCtx = newCanaryCtx(r3, rs1, []*appsv1.ReplicaSet{rs2}, nil, []*v1alpha1.AnalysisRun{ar})
+	currentAr, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.Nil(t, currentAr)
+	assert.Equal(t, "", roCtx.newStatus.SLO.CurrentAnalysisRun)
+	assert.Equal(t, rs1PodHash, roCtx.newStatus.SLO.RolledBackTo)
+}
+
+func TestReconcileSLOAnalysisRunRollsBackToTemplateOfRollout(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newSLORollouts()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs2 := newReplicaSetWithStatus(r2, 10, 10)
+	// the controller injected metadata, image digests and an anti-affinity into the previous revision
+	rs1.Spec.Template = *rs1.Spec.Template.DeepCopy()
+	image := rs1.Spec.Template.Spec.Containers[0].Image
+	replicasetutil.PinImages(rs1, map[string]string{image: "sha256:abc"})
+	replicasetutil.SetReplicaSetEphemeralMetadata(rs1, &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}})
+	rs1.Spec.Template.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      v1alpha1.DefaultRolloutUniqueLabelKey,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{r2.Status.CurrentPodHash},
+			}}},
+			TopologyKey: replicasetutil.AntiAffinityTopologyKey,
+		}},
+	}}
+	r2.Status.Canary.StableRS = r2.Status.CurrentPodHash
+	ar := newSLOAnalysisRun(r2, v1alpha1.AnalysisPhaseFailed)
+	r2.Status.SLO.CurrentAnalysisRun = ar.Name
+	f.objects = append(f.objects, r2)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, []*v1alpha1.AnalysisRun{ar})
+
+	_, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	actions := filterInformerActions(f.client.Actions())
+	assert.Len(t, actions, 1)
+	var ops []struct {
+		Value corev1.PodTemplateSpec `json:"value"`
+	}
+	assert.NoError(t, json.Unmarshal(actions[0].(core.PatchAction).GetPatch(), &ops))
+	template := ops[0].Value
+	assert.Equal(t, image, template.Spec.Containers[0].Image)
+	assert.NotContains(t, template.Labels, "role")
+	assert.Nil(t, template.Spec.Affinity)
+	// the template hashes to the previous ReplicaSet, so the rollout returns to it
+	assert.Equal(t, rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey], controller.ComputeHash(&template, r2.Status.CollisionCount))
+}
+
+func TestReconcileSLOAnalysisRunEndsAfterPostPromotionWindow(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	r1, r2 := newSLORollouts()
+	rs1 := newReplicaSetWithStatus(r1, 0, 0)
+	rs2 := newReplicaSetWithStatus(r2, 10, 10)
+	r2.Status.Canary.StableRS = r2.Status.CurrentPodHash
+	ar := newSLOAnalysisRun(r2, v1alpha1.AnalysisPhaseRunning)
+	f.objects = append(f.objects, ar)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	r2.Status.SLO.CurrentAnalysisRun = ar.Name
+	promotedAt := metav1.NewTime(time.Now().Add(-20 * time.Minute))
+	r2.Status.SLO.PromotedAt = &promotedAt
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, []*v1alpha1.AnalysisRun{ar})
+
+	currentAr, err := c.reconcileSLOAnalysisRun(roCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, ar, currentAr)
+	actions := filterInformerActions(f.client.Actions())
+	assert.Len(t, actions, 1)
+	assert.Equal(t, cancelAnalysisRun, string(actions[0].(core.PatchAction).GetPatch()))
+}
@@ -0,0 +1,107 @@
+package rollout
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+)
+
+// calculateStuckCondition sets the Stuck condition of the new status when the rollout waits for a
+// promotion, or on an inconclusive analysis, for longer than its stuck detection allows. The
+// condition is removed once the rollout makes progress again. The rollout is requeued for when it
+// would become stuck otherwise, since nothing else resyncs paused rollouts.
+func (c *RolloutController) calculateStuckCondition(r *v1alpha1.Rollout, newStatus *v1alpha1.RolloutStatus) {
+	if r.Spec.StuckDetection == nil {
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutStuck)
+		return
+	}
+	now := nowFn()
+	var stuckCond *v1alpha1.RolloutCondition
+	var requeueAfter *time.Duration
+	for _, pauseCond := range newStatus.PauseConditions {
+		var seconds *int32
+		var reason, message string
+		switch pauseCond.Reason {
+		case v1alpha1.PauseReasonInconclusiveAnalysis, v1alpha1.PauseReasonInconclusiveExperiment:
+			seconds = r.Spec.StuckDetection.InconclusiveSeconds
+			reason, message = conditions.InconclusiveTooLongReason, conditions.InconclusiveTooLongMessage
+		case v1alpha1.PauseReasonCanaryPauseStep, v1alpha1.PauseReasonBlueGreenPause:
+			if !awaitsPromotion(r) {
+				continue
+			}
+			seconds = r.Spec.StuckDetection.PausedSeconds
+			reason, message = conditions.PausedTooLongReason, conditions.PausedTooLongMessage
+		}
+		if seconds == nil {
+			continue
+		}
+		remaining := pauseCond.StartTime.Add(time.Duration(*seconds) * time.Second).Sub(now)
+		if remaining <= 0 {
+			stuckCond = conditions.NewRolloutCondition(v1alpha1.RolloutStuck, corev1.ConditionTrue, reason, fmt.Sprintf(message, *seconds))
+			break
+		}
+		if requeueAfter == nil || remaining < *requeueAfter {
+			requeueAfter = &remaining
+		}
+	}
+
+	prevCond := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck)
+	if stuckCond == nil {
+		// the status is only synced after the traffic router applied the weights, so a condition
+		// tracking the traffic router failing to apply them is removed as well
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutStuck)
+		if requeueAfter != nil {
+			c.enqueueRolloutAfter(r, *requeueAfter)
+		}
+		return
+	}
+	if prevCond != nil && prevCond.Reason != stuckCond.Reason {
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutStuck)
+	}
+	conditions.SetRolloutCondition(newStatus, *stuckCond)
+}
+
+// awaitsPromotion returns whether the rollout is paused until it is promoted, as opposed to a
+// pause which ends on its own
+func awaitsPromotion(r *v1alpha1.Rollout) bool {
+	if r.Spec.Strategy.BlueGreen != nil {
+		return r.Spec.Strategy.BlueGreen.AutoPromotionSeconds == nil
+	}
+	currentStep, _ := replicasetutil.GetCurrentCanaryStep(r)
+	return currentStep != nil && currentStep.Pause != nil && currentStep.Pause.Duration == nil
+}
+
+// reconcileTrafficWeightStuck tracks how long the traffic router fails to apply the weights of the
+// rollout. The first failure sets the Stuck condition to False, and the condition turns True once
+// the traffic router kept failing for longer than the stuck detection allows. The condition is
+// removed by the next successful sync of the rollout status.
+func (c *RolloutController) reconcileTrafficWeightStuck(r *v1alpha1.Rollout, routingErr error) error {
+	if r.Spec.StuckDetection == nil || r.Spec.StuckDetection.TrafficWeightSeconds == nil {
+		return nil
+	}
+	seconds := *r.Spec.StuckDetection.TrafficWeightSeconds
+	message := fmt.Sprintf(conditions.TrafficWeightNotConvergedMessage, routingErr)
+	prevCond := conditions.GetRolloutCondition(r.Status, v1alpha1.RolloutStuck)
+	newStatus := r.Status.DeepCopy()
+	if prevCond == nil || prevCond.Reason != conditions.TrafficWeightNotConvergedReason {
+		conditions.RemoveRolloutCondition(newStatus, v1alpha1.RolloutStuck)
+		cond := conditions.NewRolloutCondition(v1alpha1.RolloutStuck, corev1.ConditionFalse, conditions.TrafficWeightNotConvergedReason, message)
+		return c.patchCondition(r, newStatus, cond)
+	}
+	if prevCond.Status == corev1.ConditionTrue || nowFn().Before(prevCond.LastTransitionTime.Add(time.Duration(seconds)*time.Second)) {
+		return nil
+	}
+	logutil.WithRollout(r).Warnf("Traffic router failed to apply the weights for more than %d seconds", seconds)
+	cond := conditions.NewRolloutCondition(v1alpha1.RolloutStuck, corev1.ConditionTrue, conditions.TrafficWeightNotConvergedReason, message)
+	if err := c.patchCondition(r, newStatus, cond); err != nil {
+		return err
+	}
+	c.recorder.Event(r, corev1.EventTypeWarning, "RolloutStuck", cond.Message)
+	return nil
+}
@@ -0,0 +1,101 @@
+package rollout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+func TestCalculateStuckConditionPaused(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	steps := []v1alpha1.CanaryStep{{Pause: &v1alpha1.RolloutPause{}}}
+	r := newCanaryRollout("foo", 1, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.StuckDetection = &v1alpha1.StuckDetection{PausedSeconds: pointer.Int32Ptr(60)}
+	newStatus := r.Status.DeepCopy()
+	newStatus.PauseConditions = []v1alpha1.PauseCondition{{
+		Reason:    v1alpha1.PauseReasonCanaryPauseStep,
+		StartTime: metav1.NewTime(time.Now().Add(-30 * time.Second)),
+	}}
+	c.calculateStuckCondition(r, newStatus)
+	assert.Nil(t, conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck))
+
+	newStatus.PauseConditions[0].StartTime = metav1.NewTime(time.Now().Add(-61 * time.Second))
+	c.calculateStuckCondition(r, newStatus)
+	cond := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck)
+	assert.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, conditions.PausedTooLongReason, cond.Reason)
+	assert.Equal(t, "Rollout has been waiting for a promotion for more than 60 seconds", cond.Message)
+
+	// pauses with a duration end on their own
+	r.Spec.Strategy.Canary.Steps[0].Pause.Duration = v1alpha1.DurationFromInt(3600)
+	c.calculateStuckCondition(r, newStatus)
+	assert.Nil(t, conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck))
+}
+
+func TestCalculateStuckConditionInconclusive(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}}
+	r := newCanaryRollout("foo", 1, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.StuckDetection = &v1alpha1.StuckDetection{PausedSeconds: pointer.Int32Ptr(60)}
+	newStatus := r.Status.DeepCopy()
+	newStatus.PauseConditions = []v1alpha1.PauseCondition{{
+		Reason:    v1alpha1.PauseReasonInconclusiveAnalysis,
+		StartTime: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+	}}
+	// only the configured thresholds are checked
+	c.calculateStuckCondition(r, newStatus)
+	assert.Nil(t, conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck))
+
+	r.Spec.StuckDetection.InconclusiveSeconds = pointer.Int32Ptr(3600)
+	c.calculateStuckCondition(r, newStatus)
+	cond := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck)
+	assert.NotNil(t, cond)
+	assert.Equal(t, conditions.InconclusiveTooLongReason, cond.Reason)
+
+	// the condition is removed once the rollout is resumed
+	newStatus.PauseConditions = nil
+	c.calculateStuckCondition(r, newStatus)
+	assert.Nil(t, conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck))
+}
+
+func TestReconcileTrafficWeightStuck(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}}
+	r := newCanaryRollout("foo", 1, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.StuckDetection = &v1alpha1.StuckDetection{TrafficWeightSeconds: pointer.Int32Ptr(60)}
+	f.objects = append(f.objects, r)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+	routingErr := errors.New("virtualservice not found")
+
+	// the first failure is tracked with a False condition
+	assert.NoError(t, c.reconcileTrafficWeightStuck(r, routingErr))
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	cond := conditions.NewRolloutCondition(v1alpha1.RolloutStuck, corev1.ConditionFalse, conditions.TrafficWeightNotConvergedReason, "")
+	cond.LastTransitionTime = metav1.NewTime(time.Now().Add(-30 * time.Second))
+	r.Status.Conditions = []v1alpha1.RolloutCondition{*cond}
+	assert.NoError(t, c.reconcileTrafficWeightStuck(r, routingErr))
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	r.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-61 * time.Second))
+	assert.NoError(t, c.reconcileTrafficWeightStuck(r, routingErr))
+	assert.Len(t, filterInformerActions(f.client.Actions()), 2)
+}
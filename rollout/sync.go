@@ -4,25 +4,33 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/kubernetes/pkg/controller"
 	labelsutil "k8s.io/kubernetes/pkg/util/labels"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	applyutil "github.com/argoproj/argo-rollouts/utils/apply"
+	clusterutil "github.com/argoproj/argo-rollouts/utils/cluster"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	"github.com/argoproj/argo-rollouts/utils/diff"
 	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
 )
 
 // getAllReplicaSetsAndSyncRevision returns all the replica sets for the provided rollout (new and all old), with new RS's and rollout's revision updated.
@@ -45,6 +53,22 @@ func (c *RolloutController) getAllReplicaSetsAndSyncRevision(rollout *v1alpha1.R
 		return nil, nil, err
 	}
 
+	// the new and stable ReplicaSets carry the ephemeral metadata of their role in the strategy
+	if newRS != nil {
+		newRS, err = c.syncEphemeralMetadata(rollout, newRS)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, rs := range allOldRSs {
+		if replicasetutil.GetPodTemplateHash(rs) != replicasetutil.GetStablePodHash(rollout) {
+			continue
+		}
+		allOldRSs[i], err = c.syncEphemeralMetadata(rollout, rs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 	return newRS, allOldRSs, nil
 }
 
@@ -72,28 +96,36 @@ func (c *RolloutController) getNewReplicaSet(rollout *v1alpha1.Rollout, rsList,
 		// Set existing new replica set's annotation
 		annotationsUpdated := annotations.SetNewReplicaSetAnnotations(rollout, rsCopy, newRevision, true)
 		minReadySecondsNeedsUpdate := rsCopy.Spec.MinReadySeconds != rollout.Spec.MinReadySeconds
-		if annotationsUpdated || minReadySecondsNeedsUpdate {
+		// the injected anti-affinity is removed once the ReplicaSet becomes stable, which only
+		// affects pods created afterwards
+		affinity := replicasetutil.GenerateReplicaSetAffinity(rollout, replicasetutil.GetPodTemplateHash(rsCopy))
+		affinityNeedsUpdate := !apiequality.Semantic.DeepEqual(rsCopy.Spec.Template.Spec.Affinity, affinity)
+		if annotationsUpdated || minReadySecondsNeedsUpdate || affinityNeedsUpdate {
 			rsCopy.Spec.MinReadySeconds = rollout.Spec.MinReadySeconds
-			return c.kubeclientset.AppsV1().ReplicaSets(rsCopy.ObjectMeta.Namespace).Update(rsCopy)
-		}
-
-		// Should use the revision in existingNewRS's annotation, since it set by before
-		needsUpdate := annotations.SetRolloutRevision(rollout, rsCopy.Annotations[annotations.RevisionAnnotation])
-		// If no other Progressing condition has been recorded and we need to estimate the progress
-		// of this rollout then it is likely that old users started caring about progress. In that
-		// case we need to take into account the first time we noticed their new replica set.
-		cond := conditions.GetRolloutCondition(rollout.Status, v1alpha1.RolloutProgressing)
-		if cond == nil {
-			msg := fmt.Sprintf(conditions.FoundNewRSMessage, rsCopy.Name)
-			condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.FoundNewRSReason, msg)
-			conditions.SetRolloutCondition(&rollout.Status, *condition)
-			needsUpdate = true
+			rsCopy.Spec.Template.Spec.Affinity = affinity
+			return c.patchReplicaSet(existingNewRS, rsCopy)
+		}
+
+		setRevisionAndCondition := func(ro *v1alpha1.Rollout) bool {
+			// Should use the revision in existingNewRS's annotation, since it set by before
+			needsUpdate := annotations.SetRolloutRevision(ro, rsCopy.Annotations[annotations.RevisionAnnotation])
+			// If no other Progressing condition has been recorded and we need to estimate the progress
+			// of this rollout then it is likely that old users started caring about progress. In that
+			// case we need to take into account the first time we noticed their new replica set.
+			cond := conditions.GetRolloutCondition(ro.Status, v1alpha1.RolloutProgressing)
+			if cond == nil {
+				msg := fmt.Sprintf(conditions.FoundNewRSMessage, rsCopy.Name)
+				condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.FoundNewRSReason, msg)
+				conditions.SetRolloutCondition(&ro.Status, *condition)
+				needsUpdate = true
+			}
+			return needsUpdate
 		}
 
-		if needsUpdate {
+		if setRevisionAndCondition(rollout) {
 			var err error
 			logCtx.Info("Setting revision annotation after creating a new replicaset")
-			if rollout, err = c.argoprojclientset.ArgoprojV1alpha1().Rollouts(rollout.Namespace).Update(rollout); err != nil {
+			if rollout, err = c.updateRolloutWithRetry(rollout, setRevisionAndCondition); err != nil {
 				logCtx.WithError(err).Errorf("Error: Setting rollout revision annotation after creating a new replicaset")
 				return nil, err
 			}
@@ -107,8 +139,9 @@ func (c *RolloutController) getNewReplicaSet(rollout *v1alpha1.Rollout, rsList,
 
 	// new ReplicaSet does not exist, create one.
 	newRSTemplate := *rollout.Spec.Template.DeepCopy()
-	podTemplateSpecHash := controller.ComputeHash(&newRSTemplate, rollout.Status.CollisionCount)
+	podTemplateSpecHash := replicasetutil.ComputePodTemplateHash(rollout)
 	newRSTemplate.Labels = labelsutil.CloneAndAddLabel(rollout.Spec.Template.Labels, v1alpha1.DefaultRolloutUniqueLabelKey, podTemplateSpecHash)
+	newRSTemplate.Spec.Affinity = replicasetutil.GenerateReplicaSetAffinity(rollout, podTemplateSpecHash)
 	// Add podTemplateHash label to selector.
 	newRSSelector := labelsutil.CloneSelectorAndAddLabel(rollout.Spec.Selector, v1alpha1.DefaultRolloutUniqueLabelKey, podTemplateSpecHash)
 
@@ -136,28 +169,49 @@ func (c *RolloutController) getNewReplicaSet(rollout *v1alpha1.Rollout, rsList,
 	*(newRS.Spec.Replicas) = newReplicasCount
 	// Set new replica set's annotation
 	annotations.SetNewReplicaSetAnnotations(rollout, &newRS, newRevision, false)
+	replicasetutil.SetReplicaSetEphemeralMetadata(&newRS, replicasetutil.GetEphemeralMetadata(rollout, podTemplateSpecHash))
+	// A ReplicaSet of a registered cluster is labeled with the name of the rollout instead of being
+	// owned by it, and deleted by the finalizer of the rollout
+	cluster := replicasetutil.GetRolloutCluster(rollout)
+	if cluster != "" {
+		newRS.OwnerReferences = nil
+		newRS.Labels = labelsutil.CloneAndAddLabel(newRS.Labels, clusterutil.RolloutLabelKey, rollout.Name)
+		newRS.Annotations[annotations.ClusterAnnotation] = cluster
+		if _, err = c.addClusterReplicaSetsFinalizer(rollout); err != nil {
+			return nil, err
+		}
+	}
+	// Run the images at the digests their signatures were verified at
+	imagesKey := verifiedImagesKey(rollout)
+	if digests, ok := c.verifiedImages.Load(imagesKey); ok {
+		replicasetutil.PinImages(&newRS, digests.(map[string]string))
+	}
 	// Create the new ReplicaSet. If it already exists, then we need to check for possible
 	// hash collisions. If there is any other error, we need to report it in the status of
 	// the Rollout.
 	alreadyExists := false
-	createdRS, err := c.kubeclientset.AppsV1().ReplicaSets(rollout.Namespace).Create(&newRS)
+	var createdRS *appsv1.ReplicaSet
+	kubeclientset, err := c.kubeClientForCluster(cluster)
+	if err == nil {
+		createdRS, err = kubeclientset.AppsV1().ReplicaSets(rollout.Namespace).Create(&newRS)
+	}
 	switch {
 	// We may end up hitting this due to a slow cache or a fast resync of the Rollout.
 	case errors.IsAlreadyExists(err):
 		alreadyExists = true
 
 		// Fetch a copy of the ReplicaSet.
-		rs, rsErr := c.replicaSetLister.ReplicaSets(newRS.Namespace).Get(newRS.Name)
+		rs, rsErr := c.getReplicaSet(cluster, newRS.Namespace, newRS.Name)
 		if rsErr != nil {
 			return nil, rsErr
 		}
 
-		// If the Rollout owns the ReplicaSet and the ReplicaSet's PodTemplateSpec is semantically
-		// deep equal to the PodTemplateSpec of the Rollout, it's the Rollout's new ReplicaSet.
-		// Otherwise, this is a hash collision and we need to increment the collisionCount field in
-		// the status of the Rollout and requeue to try the creation in the next sync.
-		controllerRef := metav1.GetControllerOf(rs)
-		if controllerRef != nil && controllerRef.UID == rollout.UID && replicasetutil.PodTemplateEqualIgnoreHash(&rs.Spec.Template, &rollout.Spec.Template) {
+		// If the ReplicaSet belongs to the Rollout and the ReplicaSet's PodTemplateSpec is
+		// semantically deep equal to the PodTemplateSpec of the Rollout, it's the Rollout's new
+		// ReplicaSet. Otherwise, this is a hash collision and we need to increment the
+		// collisionCount field in the status of the Rollout and requeue to try the creation in the
+		// next sync.
+		if isReplicaSetOfRollout(rs, rollout) && replicasetutil.GetReplicaSetCluster(rs) == cluster && replicasetutil.PodTemplateEqualIgnoreHash(replicasetutil.GetRolloutPodTemplate(rs), &rollout.Spec.Template) {
 			createdRS = rs
 			err = nil
 			break
@@ -165,15 +219,21 @@ func (c *RolloutController) getNewReplicaSet(rollout *v1alpha1.Rollout, rsList,
 
 		// Matching ReplicaSet is not equal - increment the collisionCount in the RolloutStatus
 		// and requeue the Rollout.
-		if rollout.Status.CollisionCount == nil {
-			rollout.Status.CollisionCount = new(int32)
+		var preCollisionCount int32
+		bumpCollisionCount := func(ro *v1alpha1.Rollout) bool {
+			if ro.Status.CollisionCount == nil {
+				ro.Status.CollisionCount = new(int32)
+			}
+			preCollisionCount = *ro.Status.CollisionCount
+			*ro.Status.CollisionCount++
+			return true
 		}
-		preCollisionCount := *rollout.Status.CollisionCount
-		*rollout.Status.CollisionCount++
+		bumpCollisionCount(rollout)
 		// Update the collisionCount for the Rollout and let it requeue by returning the original
 		// error.
-		_, roErr := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(rollout.Namespace).Update(rollout)
+		updatedRollout, roErr := c.updateRolloutWithRetry(rollout, bumpCollisionCount)
 		if roErr == nil {
+			rollout = updatedRollout
 			logCtx.Warnf("Found a hash collision - bumped collisionCount (%d->%d) to resolve it", preCollisionCount, *rollout.Status.CollisionCount)
 		}
 		return nil, err
@@ -189,20 +249,28 @@ func (c *RolloutController) getNewReplicaSet(rollout *v1alpha1.Rollout, rsList,
 		return nil, err
 	}
 
+	c.verifiedImages.Delete(imagesKey)
+
 	if !alreadyExists && newReplicasCount > 0 {
 		c.recorder.Eventf(rollout, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set %s to %d", createdRS.Name, newReplicasCount)
 	}
 
-	needsUpdate := annotations.SetRolloutRevision(rollout, newRevision)
-	if !alreadyExists {
-		msg := fmt.Sprintf(conditions.NewReplicaSetMessage, createdRS.Name)
-		condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.NewReplicaSetReason, msg)
-		conditions.SetRolloutCondition(&rollout.Status, *condition)
-		needsUpdate = true
+	setRevisionAndCondition := func(ro *v1alpha1.Rollout) bool {
+		needsUpdate := annotations.SetRolloutRevision(ro, newRevision)
+		if !alreadyExists {
+			msg := fmt.Sprintf(conditions.NewReplicaSetMessage, createdRS.Name)
+			condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionTrue, conditions.NewReplicaSetReason, msg)
+			conditions.SetRolloutCondition(&ro.Status, *condition)
+			needsUpdate = true
+		}
+		return needsUpdate
 	}
 
-	if needsUpdate {
-		_, err = c.argoprojclientset.ArgoprojV1alpha1().Rollouts(rollout.Namespace).Update(rollout)
+	if setRevisionAndCondition(rollout) {
+		_, err = c.updateRolloutWithRetry(rollout, setRevisionAndCondition)
+	}
+	if err == nil && !alreadyExists {
+		c.recorder.Eventf(rollout, corev1.EventTypeNormal, "RolloutUpdated", "Rollout updated to revision %s", newRevision)
 	}
 	return createdRS, err
 }
@@ -271,6 +339,11 @@ func (c *RolloutController) syncReplicasOnly(r *v1alpha1.Rollout, rsList []*apps
 			return err
 		}
 
+		err = c.reconcileFeatureFlags(roCtx)
+		if err != nil {
+			return err
+		}
+
 		return c.syncRolloutStatusCanary(roCtx)
 	}
 	return fmt.Errorf("no rollout strategy provided")
@@ -331,7 +404,15 @@ func (c *RolloutController) scaleReplicaSet(rs *appsv1.ReplicaSet, newScale int3
 		if fullScaleDown {
 			delete(rsCopy.Annotations, v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey)
 		}
-		rs, err = c.kubeclientset.AppsV1().ReplicaSets(rsCopy.Namespace).Update(rsCopy)
+		span := tracing.StartSpan(logutil.RolloutKey, rollout.Namespace, rollout.Name, "scale replicaset")
+		span.SetAttribute("replicaset", rs.Name)
+		span.SetAttribute("replicas", strconv.Itoa(int(newScale)))
+		var kubeclientset kubernetes.Interface
+		kubeclientset, err = c.kubeClientForReplicaSet(rs)
+		if err == nil {
+			rs, err = kubeclientset.AppsV1().ReplicaSets(rsCopy.Namespace).Update(rsCopy)
+		}
+		span.End(err)
 		if err == nil && sizeNeedsUpdate {
 			scaled = true
 			c.recorder.Eventf(rollout, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled %s replica set %s to %d", scalingOperation, rs.Name, newScale)
@@ -358,7 +439,7 @@ func (c *RolloutController) calculateBaseStatus(roCtx rolloutContext) v1alpha1.R
 		// newRS potentially might be nil when called by Controller::syncReplicasOnly(). For this
 		// to happen, the user would have had to simultaneously change the number of replicas, and
 		// the pod template spec at the same time.
-		currentPodHash = controller.ComputeHash(&rollout.Spec.Template, rollout.Status.CollisionCount)
+		currentPodHash = replicasetutil.ComputePodTemplateHash(rollout)
 		logutil.WithRollout(rollout).Warnf("Assuming %s for new replicaset pod hash", currentPodHash)
 	} else {
 		currentPodHash = newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
@@ -404,7 +485,11 @@ func (c *RolloutController) cleanupRollouts(oldRSs []*appsv1.ReplicaSet, roCtx r
 			continue
 		}
 		logCtx.Infof("Trying to cleanup replica set %q", rs.Name)
-		if err := c.kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Delete(rs.Name, nil); err != nil && !errors.IsNotFound(err) {
+		kubeclientset, err := c.kubeClientForReplicaSet(rs)
+		if err != nil {
+			return err
+		}
+		if err := kubeclientset.AppsV1().ReplicaSets(rs.Namespace).Delete(rs.Name, nil); err != nil && !errors.IsNotFound(err) {
 			// Return error instead of aggregating and continuing DELETEs on the theory
 			// that we may be overloading the api server.
 			return err
@@ -430,6 +515,21 @@ func (c *RolloutController) cleanupRollouts(oldRSs []*appsv1.ReplicaSet, roCtx r
 	return nil
 }
 
+// reconcileRollbackWindow fully promotes a rollback to a revision within the rollback window, and
+// returns whether the rollout was promoted
+func (c *RolloutController) reconcileRollbackWindow(roCtx rolloutContext) bool {
+	r := roCtx.Rollout()
+	newRS := roCtx.NewRS()
+	if !replicasetutil.IsRollbackWithinWindow(r, newRS, roCtx.StableRS(), roCtx.AllRSs(), nowFn()) {
+		return false
+	}
+	msg := fmt.Sprintf("Skipping the steps, pauses and analysis of the rollback to ReplicaSet '%s' within the rollback window", newRS.Name)
+	roCtx.Log().Info(msg)
+	c.recorder.Event(r, corev1.EventTypeNormal, "RollbackWithinWindow", msg)
+	roCtx.PauseContext().AddPromoteFull()
+	return true
+}
+
 // checkPausedConditions checks if the given rollout is paused or not and adds an appropriate condition.
 // These conditions are needed so that we won't accidentally report lack of progress for resumed rollouts
 // that were paused for longer than progressDeadlineSeconds.
@@ -468,27 +568,15 @@ func (c *RolloutController) patchCondition(r *v1alpha1.Rollout, newStatus *v1alp
 	newStatus.ObservedGeneration = conditions.ComputeGenerationHash(r.Spec)
 
 	logCtx := logutil.WithRollout(r)
-	patch, modified, err := diff.CreateTwoWayMergePatch(
-		&v1alpha1.Rollout{
-			Status: r.Status,
-		},
-		&v1alpha1.Rollout{
-			Status: *newStatus,
-		}, v1alpha1.Rollout{})
+	modified, err := c.writeRolloutStatus(r, *newStatus)
 	if err != nil {
-		logCtx.Errorf("Error constructing app status patch: %v", err)
+		logCtx.Warningf("Error patching rollout: %v", err)
 		return err
 	}
 	if !modified {
 		logCtx.Info("No status changes. Skipping patch")
 		return nil
 	}
-	logCtx.Debugf("Rollout Condition Patch: %s", patch)
-	_, err = c.argoprojclientset.ArgoprojV1alpha1().Rollouts(r.Namespace).Patch(r.Name, patchtypes.MergePatchType, patch)
-	if err != nil {
-		logCtx.Warningf("Error patching rollout: %v", err)
-		return err
-	}
 	logCtx.Info("Condition Patch status successfully")
 	return nil
 }
@@ -507,6 +595,9 @@ func (c *RolloutController) calculateRolloutConditions(roCtx rolloutContext, new
 	if len(r.Status.PauseConditions) > 0 || r.Spec.Paused {
 		return newStatus
 	}
+	// the rollout is not completed until the switch of its active or stable service is verified
+	switchedCond := c.reconcileServiceSelectorSwitched(r, &newStatus)
+	switchVerified := switchedCond == nil || switchedCond.Status == corev1.ConditionTrue
 
 	// If there is only one replica set that is active then that means we are not running
 	// a new rollout and this is a resync where we don't need to estimate any progress.
@@ -519,7 +610,7 @@ func (c *RolloutController) calculateRolloutConditions(roCtx rolloutContext, new
 		case roCtx.PauseContext().IsAborted():
 			condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.RolloutAbortedReason, conditions.RolloutAbortedMessage)
 			conditions.SetRolloutCondition(&newStatus, *condition)
-		case conditions.RolloutComplete(r, &newStatus):
+		case conditions.RolloutComplete(r, &newStatus) && switchVerified:
 			// Update the rollout conditions with a message for the new replica set that
 			// was successfully deployed. If the condition already exists, we ignore this update.
 			msg := fmt.Sprintf(conditions.RolloutCompletedMessage, r.Name)
@@ -559,6 +650,9 @@ func (c *RolloutController) calculateRolloutConditions(roCtx rolloutContext, new
 			}
 			condition := conditions.NewRolloutCondition(v1alpha1.RolloutProgressing, corev1.ConditionFalse, conditions.TimedOutReason, msg)
 			conditions.SetRolloutCondition(&newStatus, *condition)
+			if r.Spec.ProgressDeadlineAbort {
+				roCtx.PauseContext().AddAbort(msg)
+			}
 		}
 	}
 
@@ -582,6 +676,13 @@ func (c *RolloutController) calculateRolloutConditions(roCtx rolloutContext, new
 	} else {
 		conditions.RemoveRolloutCondition(&newStatus, v1alpha1.RolloutReplicaFailure)
 	}
+	if switchedCond != nil {
+		// the message of a pending switch changes with the selector it waits for
+		if prevCond := conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutServiceSelectorSwitched); prevCond != nil && prevCond.Message != switchedCond.Message {
+			conditions.RemoveRolloutCondition(&newStatus, v1alpha1.RolloutServiceSelectorSwitched)
+		}
+		conditions.SetRolloutCondition(&newStatus, *switchedCond)
+	}
 	return newStatus
 }
 
@@ -589,17 +690,16 @@ func (c *RolloutController) calculateRolloutConditions(roCtx rolloutContext, new
 func (c *RolloutController) persistRolloutStatus(roCtx rolloutContext, newStatus *v1alpha1.RolloutStatus) error {
 	orig := roCtx.Rollout()
 	roCtx.PauseContext().CalculatePauseStatus(newStatus)
+	c.calculateStuckCondition(orig, newStatus)
 	newStatus.ObservedGeneration = conditions.ComputeGenerationHash(orig.Spec)
+	if configutil.Get().GetBool(configutil.RolloutPhaseKey, false) {
+		newStatus.Phase, newStatus.Message = conditions.ComputeRolloutPhase(orig, newStatus)
+	}
+	c.reconcileRevisionHistory(roCtx, newStatus)
 	logCtx := logutil.WithRollout(orig)
-	patch, modified, err := diff.CreateTwoWayMergePatch(
-		&v1alpha1.Rollout{
-			Status: orig.Status,
-		},
-		&v1alpha1.Rollout{
-			Status: *newStatus,
-		}, v1alpha1.Rollout{})
+	modified, err := c.writeRolloutStatus(orig, *newStatus)
 	if err != nil {
-		logCtx.Errorf("Error constructing app status patch: %v", err)
+		logCtx.Warningf("Error updating application: %v", err)
 		return err
 	}
 	if !modified {
@@ -607,16 +707,129 @@ func (c *RolloutController) persistRolloutStatus(roCtx rolloutContext, newStatus
 		c.requeueStuckRollout(orig, *newStatus)
 		return nil
 	}
-	logCtx.Debugf("Rollout Patch: %s", patch)
-	_, err = c.argoprojclientset.ArgoprojV1alpha1().Rollouts(orig.Namespace).Patch(orig.Name, patchtypes.MergePatchType, patch)
-	if err != nil {
-		logCtx.Warningf("Error updating application: %v", err)
-		return err
+	if newStatus.Abort && !orig.Status.Abort {
+		c.recorder.Event(orig, corev1.EventTypeWarning, "RolloutAborted", roCtx.PauseContext().AbortMessage())
+	}
+	if len(newStatus.PauseConditions) > 0 && len(orig.Status.PauseConditions) == 0 {
+		var reasons []string
+		for _, cond := range newStatus.PauseConditions {
+			reasons = append(reasons, string(cond.Reason))
+		}
+		c.recorder.Eventf(orig, corev1.EventTypeNormal, "RolloutPaused", "Rollout is paused (%s)", strings.Join(reasons, ", "))
+	}
+	if progressing := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutProgressing); progressing != nil && progressing.Reason == conditions.NewRSAvailableReason {
+		if prev := conditions.GetRolloutCondition(orig.Status, v1alpha1.RolloutProgressing); prev == nil || prev.Reason != conditions.NewRSAvailableReason {
+			c.recorder.Event(orig, corev1.EventTypeNormal, "RolloutCompleted", progressing.Message)
+		}
+	}
+	if stuck := conditions.GetRolloutCondition(*newStatus, v1alpha1.RolloutStuck); stuck != nil && stuck.Status == corev1.ConditionTrue {
+		if prev := conditions.GetRolloutCondition(orig.Status, v1alpha1.RolloutStuck); prev == nil || prev.Status != corev1.ConditionTrue || prev.Reason != stuck.Reason {
+			c.recorder.Event(orig, corev1.EventTypeWarning, "RolloutStuck", stuck.Message)
+		}
 	}
 	logCtx.Info("Patch status successfully")
 	return nil
 }
 
+// updateRolloutWithRetry updates the rollout. On a conflict, the latest version of the rollout is
+// re-read from the API server and the mutation is re-applied before retrying, so concurrent writers
+// (e.g. the HPA or a user) do not fail the reconciliation. The mutation returns false if the latest
+// rollout no longer needs the update.
+func (c *RolloutController) updateRolloutWithRetry(r *v1alpha1.Rollout, mutate func(*v1alpha1.Rollout) bool) (*v1alpha1.Rollout, error) {
+	rolloutIf := c.argoprojclientset.ArgoprojV1alpha1().Rollouts(r.Namespace)
+	toUpdate := r
+	updated := r
+	if r.Spec.WorkloadRef != nil {
+		// the resolved pod template and selector must not be written to the rollout, so the
+		// mutation is applied to the latest version instead
+		toUpdate = nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		if toUpdate == nil {
+			latest, getErr := rolloutIf.Get(r.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			if !mutate(latest) {
+				updated = latest
+				return nil
+			}
+			toUpdate = latest
+		}
+		updated, err = rolloutIf.Update(toUpdate)
+		if errors.IsConflict(err) {
+			logutil.WithRollout(r).Infof("Conflict updating rollout, retrying with the latest version")
+			toUpdate = nil
+		}
+		return err
+	})
+	if err == nil && r.Spec.WorkloadRef != nil {
+		updated.Spec.Template = r.Spec.Template
+		updated.Spec.Selector = r.Spec.Selector
+	}
+	return updated, err
+}
+
+// writeRolloutStatus persists the new rollout status along with the decisions recorded for the
+// rollout since its last write, either with a merge patch or by applying the full status when
+// server-side apply is enabled. It returns false if neither the status nor the decision history
+// changed.
+func (c *RolloutController) writeRolloutStatus(r *v1alpha1.Rollout, newStatus v1alpha1.RolloutStatus) (bool, error) {
+	history := r.Annotations[annotations.DecisionHistoryAnnotation]
+	pendingSeq := int64(0)
+	if c.decisions != nil {
+		history, pendingSeq = c.decisions.PendingHistory(r)
+	}
+	patch, modified, err := diff.CreateTwoWayMergePatch(
+		&v1alpha1.Rollout{
+			ObjectMeta: decisionHistoryMeta(r.Annotations[annotations.DecisionHistoryAnnotation]),
+			Status:     r.Status,
+		},
+		&v1alpha1.Rollout{
+			ObjectMeta: decisionHistoryMeta(history),
+			Status:     newStatus,
+		}, v1alpha1.Rollout{})
+	if err != nil {
+		return false, fmt.Errorf("error constructing rollout status patch: %v", err)
+	}
+	if !modified {
+		if pendingSeq > 0 {
+			// the pending decisions only repeated the last decision of the history
+			c.decisions.ForgetDecisions(r, pendingSeq)
+		}
+		return false, nil
+	}
+	logutil.WithRollout(r).Debugf("Rollout Patch: %s", patch)
+	if c.useServerSideApply() {
+		// the annotation is always applied since the fields the controller stops applying are removed
+		err = applyutil.RolloutStatus(c.argoprojclientset.ArgoprojV1alpha1().RESTClient(), r, newStatus, decisionHistoryMeta(history).Annotations)
+	} else {
+		_, err = c.argoprojclientset.ArgoprojV1alpha1().Rollouts(r.Namespace).Patch(r.Name, patchtypes.MergePatchType, patch)
+	}
+	if err != nil {
+		return true, err
+	}
+	if pendingSeq > 0 {
+		c.decisions.ForgetDecisions(r, pendingSeq)
+	}
+	return true, nil
+}
+
+// decisionHistoryMeta returns the metadata holding the decision history annotation, if it is set
+func decisionHistoryMeta(history string) metav1.ObjectMeta {
+	if history == "" {
+		return metav1.ObjectMeta{}
+	}
+	return metav1.ObjectMeta{Annotations: map[string]string{annotations.DecisionHistoryAnnotation: history}}
+}
+
+// useServerSideApply returns whether server-side apply is enabled, either with the controller flag
+// or the feature flag in the controller config
+func (c *RolloutController) useServerSideApply() bool {
+	return configutil.Get().GetBool(configutil.ServerSideApplyKey, c.serverSideApply)
+}
+
 // used for unit testing
 var nowFn = func() time.Time { return time.Now() }
 
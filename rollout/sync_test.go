@@ -1,19 +1,28 @@
 package rollout
 
 import (
+	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	testclient "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	"github.com/argoproj/argo-rollouts/utils/annotations"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	recordutil "github.com/argoproj/argo-rollouts/utils/record"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -206,3 +215,141 @@ func TestCleanupRollouts(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateRolloutWithRetryOnConflict(t *testing.T) {
+	r := newBlueGreenRollout("foo", 1, nil, "", "")
+	latest := r.DeepCopy()
+	latest.ResourceVersion = "2"
+	client := fake.NewSimpleClientset(latest)
+	conflicted := false
+	client.PrependReactor("update", "rollouts", func(action testclient.Action) (bool, runtime.Object, error) {
+		if !conflicted {
+			conflicted = true
+			return true, nil, k8serrors.NewConflict(schema.GroupResource{Resource: "rollouts"}, r.Name, fmt.Errorf("object has been modified"))
+		}
+		return false, nil, nil
+	})
+	c := &RolloutController{
+		argoprojclientset: client,
+	}
+	mutations := 0
+	setRevision := func(ro *v1alpha1.Rollout) bool {
+		mutations++
+		return annotations.SetRolloutRevision(ro, "2")
+	}
+	setRevision(r)
+	updated, err := c.updateRolloutWithRetry(r, setRevision)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mutations)
+	assert.Equal(t, "2", updated.Annotations[annotations.RevisionAnnotation])
+
+	// update (conflict), get, update
+	var verbs []string
+	for _, action := range client.Actions() {
+		verbs = append(verbs, action.GetVerb())
+	}
+	assert.Equal(t, []string{"update", "get", "update"}, verbs)
+}
+
+func TestWriteRolloutStatusDecisionHistory(t *testing.T) {
+	r := newBlueGreenRollout("foo", 1, nil, "", "")
+	client := fake.NewSimpleClientset(r)
+	decisions := recordutil.NewAuditRecorder(record.NewFakeRecorder(10), recordutil.DefaultDecisionHistoryLimit)
+	c := &RolloutController{
+		argoprojclientset: client,
+		decisions:         decisions,
+	}
+
+	// the decisions are written with the status, in a single patch
+	decisions.Event(r, corev1.EventTypeNormal, "RolloutUpdated", "Rollout updated to revision 2")
+	newStatus := r.Status.DeepCopy()
+	newStatus.CurrentPodHash = "abc"
+	modified, err := c.writeRolloutStatus(r, *newStatus)
+	assert.NoError(t, err)
+	assert.True(t, modified)
+	actions := client.Actions()
+	assert.Len(t, actions, 1)
+	patch := string(actions[0].(testclient.PatchAction).GetPatch())
+	assert.Contains(t, patch, `"currentPodHash":"abc"`)
+	assert.Contains(t, patch, annotations.DecisionHistoryAnnotation)
+	assert.Contains(t, patch, "RolloutUpdated")
+	_, pending := decisions.PendingHistory(r)
+	assert.Equal(t, int64(0), pending)
+
+	// the decisions are written even if the status is unchanged
+	latest, err := client.ArgoprojV1alpha1().Rollouts(r.Namespace).Get(r.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	decisions.Event(latest, corev1.EventTypeNormal, "RolloutCompleted", "Rollout completed update to revision 2")
+	modified, err = c.writeRolloutStatus(latest, latest.Status)
+	assert.NoError(t, err)
+	assert.True(t, modified)
+	latest, err = client.ArgoprojV1alpha1().Rollouts(r.Namespace).Get(r.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	history, err := recordutil.GetDecisionHistory(latest)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "RolloutCompleted", history[1].Reason)
+
+	modified, err = c.writeRolloutStatus(latest, latest.Status)
+	assert.NoError(t, err)
+	assert.False(t, modified)
+}
+
+func TestGetNewReplicaSetAntiAffinity(t *testing.T) {
+	r1 := newBlueGreenRollout("foo", 1, nil, "active", "")
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.BlueGreen.AntiAffinity = &v1alpha1.AntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+	}
+	rs1 := newReplicaSetWithStatus(r1, 1, 1)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	r2.Status.BlueGreen.ActiveSelector = rs1PodHash
+
+	c := &RolloutController{
+		kubeclientset:     k8sfake.NewSimpleClientset(rs1),
+		argoprojclientset: fake.NewSimpleClientset(r2),
+		recorder:          &record.FakeRecorder{},
+	}
+	rs2, err := c.getNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1}, []*appsv1.ReplicaSet{rs1}, true)
+	assert.NoError(t, err)
+	terms := rs2.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Len(t, terms, 1)
+	assert.Equal(t, "kubernetes.io/hostname", terms[0].TopologyKey)
+	assert.Equal(t, []string{rs1PodHash}, terms[0].LabelSelector.MatchExpressions[0].Values)
+
+	// the anti-affinity is removed once the new ReplicaSet is active
+	r2.Status.BlueGreen.ActiveSelector = rs2.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	updated, err := c.getNewReplicaSet(r2, []*appsv1.ReplicaSet{rs1, rs2}, []*appsv1.ReplicaSet{rs1}, true)
+	assert.NoError(t, err)
+	assert.Nil(t, updated.Spec.Template.Spec.Affinity)
+}
+
+func TestProgressDeadlineAbort(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	rs1 := newReplicaSetWithStatus(r1, 9, 9)
+	rs2 := newReplicaSetWithStatus(r2, 1, 0)
+	r2 = updateCanaryRolloutStatus(r2, rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey], 9, 1, 10, false)
+	progressingCondition, _ := newProgressingCondition(conditions.ReplicaSetUpdatedReason, rs2)
+	progressingCondition.LastUpdateTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions.RemoveRolloutCondition(&r2.Status, v1alpha1.RolloutProgressing)
+	conditions.SetRolloutCondition(&r2.Status, progressingCondition)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	// the rollout is only degraded by default
+	roCtx := newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, nil)
+	newStatus := c.calculateRolloutConditions(roCtx, *r2.Status.DeepCopy())
+	assert.Equal(t, conditions.TimedOutReason, conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing).Reason)
+	assert.False(t, roCtx.PauseContext().IsAborted())
+
+	r2.Spec.ProgressDeadlineAbort = true
+	roCtx = newCanaryCtx(r2, rs2, []*appsv1.ReplicaSet{rs1}, nil, nil)
+	newStatus = c.calculateRolloutConditions(roCtx, *r2.Status.DeepCopy())
+	assert.Equal(t, conditions.TimedOutReason, conditions.GetRolloutCondition(newStatus, v1alpha1.RolloutProgressing).Reason)
+	assert.True(t, roCtx.PauseContext().IsAborted())
+	assert.Equal(t, fmt.Sprintf(conditions.ReplicaSetTimeOutMessage, rs2.Name), roCtx.PauseContext().AbortMessage())
+}
@@ -1,30 +1,174 @@
 package rollout
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/nginx"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/plugin"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/smi"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
 	replicasetutil "github.com/argoproj/argo-rollouts/utils/replicaset"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
 )
 
+// weightVerificationDelay is how long the controller waits before verifying the weight of a traffic
+// router again
+const weightVerificationDelay = 10 * time.Second
+
 // TrafficRoutingReconciler common function across all TrafficRouting implementation
 type TrafficRoutingReconciler interface {
-	Reconcile(desiredWeight int32) error
+	// Reconcile sends the desired weight of the traffic to the canary service, the weights of the
+	// additional destinations to their services, and the rest to the stable service
+	Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error
+	// UpdateHash points the routes of the canary and stable pods at the ReplicaSets with the given pod
+	// template hashes, for the traffic routers which route to the pods without going through the services
+	UpdateHash(canaryHash, stableHash string) error
+	// SetHeaderRoutes sends the requests matching the header routes to the canary service, and removes
+	// the routes of the setHeaderRoute steps of the rollout which are not in the list
+	SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error
+	// SetMirrorRoute mirrors the percentage of the requests to the canary service, or stops mirroring
+	// requests if the percentage is 0
+	SetMirrorRoute(percentage int32) error
 	Type() string
 }
 
+// TrafficRoutingVerifier is implemented by the traffic routers which can verify that the data plane
+// applied the weights of a reconciliation
+type TrafficRoutingVerifier interface {
+	// VerifyWeight returns true once the desired weight of the traffic is sent to the canary
+	VerifyWeight(desiredWeight int32) (bool, error)
+}
+
+// trafficRouter creates the reconcilers of a traffic router for the rollouts configuring it
+type trafficRouter struct {
+	// routerType is the type of the reconcilers, which the operator uses to disable the traffic router
+	routerType string
+	// configured returns true if the traffic routing of a rollout uses the traffic router
+	configured func(trafficRouting *v1alpha1.RolloutTrafficRouting) bool
+	// newReconciler returns the reconciler of the traffic router for the rollout
+	newReconciler func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error)
+}
+
+// trafficRouters is the registry of the traffic routers supported by the controller, in the order
+// the traffic routing of a rollout is checked. Traffic routers which are not built into the
+// controller are reconciled by plugins.
+var trafficRouters = []trafficRouter{
+	{
+		routerType: istio.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.Istio != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return istio.NewReconciler(rollout, c.dynamicclientset, c.recorder, c.defaultIstioVersion), nil
+		},
+	},
+	{
+		routerType: smi.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.SMI != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return smi.NewReconciler(rollout, c.dynamicclientset, c.recorder), nil
+		},
+	},
+	{
+		routerType: alb.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.ALB != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return alb.NewReconciler(rollout, c.kubeclientset, c.recorder), nil
+		},
+	},
+	{
+		routerType: nginx.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.Nginx != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return nginx.NewReconciler(rollout, c.kubeclientset, c.recorder), nil
+		},
+	},
+	{
+		routerType: gatewayapi.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.GatewayAPI != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return gatewayapi.NewReconciler(rollout, c.dynamicclientset, c.recorder), nil
+		},
+	},
+	{
+		routerType: plugin.Type,
+		configured: func(tr *v1alpha1.RolloutTrafficRouting) bool { return tr.Plugin != nil },
+		newReconciler: func(c *RolloutController, rollout *v1alpha1.Rollout) (TrafficRoutingReconciler, error) {
+			return plugin.NewReconciler(rollout)
+		},
+	},
+}
+
 // NewTrafficRoutingReconciler identifies return the TrafficRouting Plugin that the rollout wants to modify
 func (c *RolloutController) NewTrafficRoutingReconciler(roCtx rolloutContext) TrafficRoutingReconciler {
 	rollout := roCtx.Rollout()
 	if rollout.Spec.Strategy.Canary.TrafficRouting == nil {
 		return nil
 	}
-	if rollout.Spec.Strategy.Canary.TrafficRouting.Istio != nil {
-		return istio.NewReconciler(rollout, c.dynamicclientset, c.recorder, c.defaultIstioVersion)
+	for _, router := range trafficRouters {
+		if !router.configured(rollout.Spec.Strategy.Canary.TrafficRouting) {
+			continue
+		}
+		if isTrafficRouterDisabled(router.routerType) {
+			return unavailableTrafficRouter{routerType: router.routerType, err: fmt.Errorf("traffic router '%s' is disabled", router.routerType)}
+		}
+		reconciler, err := router.newReconciler(c, rollout)
+		if err != nil {
+			return unavailableTrafficRouter{routerType: router.routerType, err: err}
+		}
+		return reconciler
 	}
 	return nil
 }
 
+// isTrafficRouterDisabled returns true if the operator disabled the traffic router
+func isTrafficRouterDisabled(routerType string) bool {
+	for _, disabled := range configutil.Get().GetStringSlice(configutil.DisabledTrafficRoutersKey, nil) {
+		if strings.EqualFold(disabled, routerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// unavailableTrafficRouter is used in place of a traffic router the operator disabled or which could
+// not be created. It fails every reconciliation so the rollout does not shift replicas without
+// shifting traffic.
+type unavailableTrafficRouter struct {
+	routerType string
+	err        error
+}
+
+func (r unavailableTrafficRouter) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	return r.err
+}
+
+func (r unavailableTrafficRouter) UpdateHash(canaryHash, stableHash string) error {
+	return r.err
+}
+
+func (r unavailableTrafficRouter) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	return r.err
+}
+
+func (r unavailableTrafficRouter) SetMirrorRoute(percentage int32) error {
+	return r.err
+}
+
+func (r unavailableTrafficRouter) Type() string {
+	return r.routerType
+}
+
 func (c *RolloutController) reconcileTrafficRouting(roCtx *canaryContext) error {
 	rollout := roCtx.Rollout()
 	reconciler := c.newTrafficRoutingReconciler(roCtx)
@@ -60,9 +204,76 @@ func (c *RolloutController) reconcileTrafficRouting(roCtx *canaryContext) error
 		}
 	}
 
-	err := reconciler.Reconcile(desiredWeight)
+	additionalDestinations := experimentWeightDestinations(roCtx)
+	totalWeight := desiredWeight
+	for _, d := range additionalDestinations {
+		totalWeight += d.Weight
+	}
+	if totalWeight > 100 {
+		err := fmt.Errorf("the canary weight %d and the weights of the experiment templates exceed 100 in total", desiredWeight)
+		c.recorder.Event(rollout, corev1.EventTypeWarning, "TrafficRoutingError", err.Error())
+		return err
+	}
+
+	span := tracing.StartSpan(logutil.RolloutKey, rollout.Namespace, rollout.Name, "traffic router "+reconciler.Type())
+	err := reconciler.UpdateHash(podHash(newRS), podHash(stableRS))
+	if err == nil {
+		err = reconciler.SetHeaderRoutes(replicasetutil.GetCurrentHeaderRoutes(rollout))
+	}
+	if err == nil {
+		err = reconciler.SetMirrorRoute(replicasetutil.GetCurrentMirrorPercentage(rollout))
+	}
+	if err == nil {
+		err = reconciler.Reconcile(desiredWeight, additionalDestinations...)
+	}
+	span.End(err)
 	if err != nil {
 		c.recorder.Event(rollout, corev1.EventTypeWarning, "TrafficRoutingError", err.Error())
+		return err
+	}
+	if verifier, ok := reconciler.(TrafficRoutingVerifier); ok {
+		verified, err := verifier.VerifyWeight(desiredWeight)
+		if err != nil {
+			roCtx.Log().Warnf("Failed to verify the weight of traffic router '%s': %v", reconciler.Type(), err)
+			c.recorder.Event(rollout, corev1.EventTypeWarning, "WeightVerificationError", err.Error())
+		}
+		roCtx.weightVerified = &verified
+		if !verified {
+			roCtx.Log().Infof("Desired weight '%d' not yet applied by traffic router '%s'", desiredWeight, reconciler.Type())
+			c.enqueueRolloutAfter(rollout, weightVerificationDelay)
+		}
+	}
+	return nil
+}
+
+// podHash returns the pod template hash of the ReplicaSet, or an empty string if there is none
+func podHash(rs *appsv1.ReplicaSet) string {
+	if rs == nil {
+		return ""
+	}
+	return rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+}
+
+// experimentWeightDestinations returns the services of the running templates of the current
+// experiment which receive a share of the traffic
+func experimentWeightDestinations(roCtx *canaryContext) []trafficrouting.WeightDestination {
+	ex := roCtx.CurrentExperiment()
+	if ex == nil || roCtx.PauseContext().IsAborted() || experimentutil.IsTerminating(ex) {
+		return nil
+	}
+	var destinations []trafficrouting.WeightDestination
+	for _, template := range ex.Spec.Templates {
+		if template.Weight == nil {
+			continue
+		}
+		templateStatus := experimentutil.GetTemplateStatus(ex.Status, template.Name)
+		if templateStatus == nil || templateStatus.Status != v1alpha1.TemplateStatusRunning || templateStatus.ServiceName == "" {
+			continue
+		}
+		destinations = append(destinations, trafficrouting.WeightDestination{
+			ServiceName: templateStatus.ServiceName,
+			Weight:      *template.Weight,
+		})
 	}
-	return err
+	return destinations
 }
@@ -0,0 +1,225 @@
+package alb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "ALB"
+	// DefaultAnnotationPrefix is the prefix of the annotations of the AWS Load Balancer Controller
+	DefaultAnnotationPrefix = "alb.ingress.kubernetes.io"
+
+	// useActionAnnotation is the service port of the backends of an Ingress whose action is defined
+	// in an annotation
+	useActionAnnotation = "use-annotation"
+)
+
+// NewReconciler returns a reconciler struct that brings the action annotation of the ALB Ingress
+// into the desired state
+func NewReconciler(r *v1alpha1.Rollout, client kubernetes.Interface, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		rollout:  r,
+		log:      logutil.WithRollout(r),
+		client:   client,
+		recorder: recorder,
+		newELBV2: newELBV2Client,
+	}
+}
+
+// Reconciler holds required fields to reconcile ALB Ingresses
+type Reconciler struct {
+	rollout  *v1alpha1.Rollout
+	log      *logrus.Entry
+	client   kubernetes.Interface
+	recorder record.EventRecorder
+	newELBV2 func() (*elbv2API, error)
+}
+
+// Type indicates this reconciler is an ALB reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// UpdateHash is a no-op for ALB since the target groups of the action are the stable and canary services
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	return nil
+}
+
+// SetHeaderRoutes fails if the rollout has header routes since the action annotation only splits the traffic by weight
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	if len(headerRoutes) > 0 {
+		return errors.New("the ALB traffic router does not support header routes")
+	}
+	return nil
+}
+
+// SetMirrorRoute fails if the rollout mirrors requests since the ALB traffic router can not mirror a
+// percentage of the requests
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	if percentage > 0 {
+		return errors.New("the ALB traffic router does not support mirror routes")
+	}
+	return nil
+}
+
+// Reconcile sets the forward action annotation of the Ingress to the desired weights
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	name := r.rollout.Spec.Strategy.Canary.TrafficRouting.ALB.Ingress
+	ingress, err := r.client.ExtensionsV1beta1().Ingresses(r.rollout.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Ingress `%s` not found", name)
+			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "IngressNotFound", msg)
+		}
+		return err
+	}
+	if err := ValidateIngress(r.rollout, ingress); err != nil {
+		return fmt.Errorf("Ingress `%s` is incompatible: %v", name, err)
+	}
+	desiredAction, err := forwardAction(r.rollout, desiredWeight, additionalDestinations...)
+	if err != nil {
+		return err
+	}
+	key := ActionAnnotationKey(r.rollout)
+	if ingress.Annotations[key] == desiredAction {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: desiredAction},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Updating Ingress `%s` to desiredWeight '%d'", name, desiredWeight)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingIngress", msg)
+	_, err = r.client.ExtensionsV1beta1().Ingresses(r.rollout.Namespace).Patch(name, patchtypes.MergePatchType, patch)
+	return err
+}
+
+// VerifyWeight returns true once the listener rules of the load balancer of the Ingress forward the
+// desired weight of the traffic to the target group of the canary service. Weights are only verified
+// when enabled in the controller configuration.
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	if !configutil.Get().GetBool(configutil.ALBVerifyWeightKey, false) {
+		return true, nil
+	}
+	alb := r.rollout.Spec.Strategy.Canary.TrafficRouting.ALB
+	ingress, err := r.client.ExtensionsV1beta1().Ingresses(r.rollout.Namespace).Get(alb.Ingress, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if len(ingress.Status.LoadBalancer.Ingress) == 0 || ingress.Status.LoadBalancer.Ingress[0].Hostname == "" {
+		r.log.Infof("Ingress `%s` has no load balancer yet", alb.Ingress)
+		return false, nil
+	}
+	api, err := r.newELBV2()
+	if err != nil {
+		return false, err
+	}
+	weights, err := targetGroupWeights(api, ingress.Status.LoadBalancer.Ingress[0].Hostname)
+	if err != nil {
+		return false, err
+	}
+	canaryResource := targetGroupResource(r.rollout.Namespace, alb.Ingress, r.rollout.Spec.Strategy.Canary.CanaryService, alb.ServicePort)
+	canaryWeights := weights[canaryResource]
+	if len(canaryWeights) == 0 {
+		// the load balancer controller does not create target groups without traffic
+		return desiredWeight == 0, nil
+	}
+	for _, weight := range canaryWeights {
+		if weight != int64(desiredWeight) {
+			r.log.Infof("Load balancer of Ingress `%s` forwards weight '%d' to the canary instead of '%d'", alb.Ingress, weight, desiredWeight)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ActionAnnotationKey returns the annotation holding the forward action of the rollout
+func ActionAnnotationKey(r *v1alpha1.Rollout) string {
+	alb := r.Spec.Strategy.Canary.TrafficRouting.ALB
+	prefix := alb.AnnotationPrefix
+	if prefix == "" {
+		prefix = DefaultAnnotationPrefix
+	}
+	return fmt.Sprintf("%s/actions.%s", prefix, actionName(r))
+}
+
+// actionName returns the name of the action used as the backend of the Ingress rules
+func actionName(r *v1alpha1.Rollout) string {
+	if rootService := r.Spec.Strategy.Canary.TrafficRouting.ALB.RootService; rootService != "" {
+		return rootService
+	}
+	return r.Spec.Strategy.Canary.StableService
+}
+
+type targetGroup struct {
+	ServiceName string `json:"ServiceName"`
+	ServicePort string `json:"ServicePort"`
+	Weight      int64  `json:"Weight"`
+}
+
+type action struct {
+	Type          string `json:"Type"`
+	ForwardConfig struct {
+		TargetGroups []targetGroup `json:"TargetGroups"`
+	} `json:"ForwardConfig"`
+}
+
+// forwardAction returns the forward action sending the desired weight of the traffic to the canary
+// service, the weights of the additional destinations to their services, and the rest to the stable service
+func forwardAction(r *v1alpha1.Rollout, desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) (string, error) {
+	canary := r.Spec.Strategy.Canary
+	port := strconv.Itoa(int(canary.TrafficRouting.ALB.ServicePort))
+	stableWeight := 100 - desiredWeight
+	a := action{Type: "forward"}
+	a.ForwardConfig.TargetGroups = append(a.ForwardConfig.TargetGroups, targetGroup{ServiceName: canary.CanaryService, ServicePort: port, Weight: int64(desiredWeight)})
+	for _, d := range additionalDestinations {
+		a.ForwardConfig.TargetGroups = append(a.ForwardConfig.TargetGroups, targetGroup{ServiceName: d.ServiceName, ServicePort: port, Weight: int64(d.Weight)})
+		stableWeight -= d.Weight
+	}
+	a.ForwardConfig.TargetGroups = append(a.ForwardConfig.TargetGroups, targetGroup{ServiceName: canary.StableService, ServicePort: port, Weight: int64(stableWeight)})
+	bytes, err := json.Marshal(a)
+	return string(bytes), err
+}
+
+// ValidateIngress ensures a rule of the Ingress uses the action of the rollout as its backend
+func ValidateIngress(r *v1alpha1.Rollout, ingress *extensionsv1beta1.Ingress) error {
+	name := actionName(r)
+	backends := []*extensionsv1beta1.IngressBackend{ingress.Spec.Backend}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			backends = append(backends, &rule.HTTP.Paths[i].Backend)
+		}
+	}
+	for _, backend := range backends {
+		if backend != nil && backend.ServiceName == name && backend.ServicePort.StrVal == useActionAnnotation {
+			return nil
+		}
+	}
+	return fmt.Errorf("no backend with serviceName '%s' and servicePort '%s' found", name, useActionAnnotation)
+}
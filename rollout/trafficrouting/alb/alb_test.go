@@ -0,0 +1,197 @@
+package alb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+func rollout(albConfig *v1alpha1.ALBTrafficRouting) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						ALB: albConfig,
+					},
+				},
+			},
+		},
+	}
+}
+
+func ingress(name, backendService string, annotations map[string]string) *extensionsv1beta1.Ingress {
+	return &extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: extensionsv1beta1.IngressSpec{
+			Rules: []extensionsv1beta1.IngressRule{{
+				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+						Paths: []extensionsv1beta1.HTTPIngressPath{{
+							Path: "/*",
+							Backend: extensionsv1beta1.IngressBackend{
+								ServiceName: backendService,
+								ServicePort: intstr.FromString("use-annotation"),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestType(t *testing.T) {
+	r := NewReconciler(rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress"}), fake.NewSimpleClientset(), &record.FakeRecorder{})
+	assert.Equal(t, Type, r.Type())
+	assert.Nil(t, r.UpdateHash("def456", "abc123"))
+	assert.Nil(t, r.SetMirrorRoute(0))
+	assert.EqualError(t, r.SetMirrorRoute(10), "the ALB traffic router does not support mirror routes")
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	assert.EqualError(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{{Name: "testers"}}), "the ALB traffic router does not support header routes")
+}
+
+func TestForwardAction(t *testing.T) {
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80})
+	action, err := forwardAction(ro, 10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 20})
+	assert.Nil(t, err)
+	expected := `{"Type":"forward","ForwardConfig":{"TargetGroups":[` +
+		`{"ServiceName":"canary","ServicePort":"80","Weight":10},` +
+		`{"ServiceName":"ex-baseline","ServicePort":"80","Weight":20},` +
+		`{"ServiceName":"stable","ServicePort":"80","Weight":70}]}}`
+	assert.Equal(t, expected, action)
+}
+
+func TestActionAnnotationKey(t *testing.T) {
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress"})
+	assert.Equal(t, "alb.ingress.kubernetes.io/actions.stable", ActionAnnotationKey(ro))
+	ro.Spec.Strategy.Canary.TrafficRouting.ALB.RootService = "root"
+	ro.Spec.Strategy.Canary.TrafficRouting.ALB.AnnotationPrefix = "custom.alb.example.com"
+	assert.Equal(t, "custom.alb.example.com/actions.root", ActionAnnotationKey(ro))
+}
+
+func TestReconcileUpdatesIngress(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable", nil))
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.Nil(t, err)
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "get", actions[0].GetVerb())
+	assert.Equal(t, "patch", actions[1].GetVerb())
+
+	updated, err := client.ExtensionsV1beta1().Ingresses("default").Get("ingress", metav1.GetOptions{})
+	assert.Nil(t, err)
+	expected, _ := forwardAction(ro, 10)
+	assert.Equal(t, expected, updated.Annotations["alb.ingress.kubernetes.io/actions.stable"])
+
+	// the Ingress is not patched when the action has the desired weights
+	client.ClearActions()
+	assert.Nil(t, r.Reconcile(10))
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestReconcileInvalidIngress(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "other", nil))
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.EqualError(t, err, "Ingress `ingress` is incompatible: no backend with serviceName 'stable' and servicePort 'use-annotation' found")
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestReconcileIngressNotFound(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestValidateIngress(t *testing.T) {
+	ro := rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80})
+	assert.NoError(t, ValidateIngress(ro, ingress("ingress", "stable", nil)))
+
+	ro.Spec.Strategy.Canary.TrafficRouting.ALB.RootService = "root"
+	assert.Error(t, ValidateIngress(ro, ingress("ingress", "stable", nil)))
+	defaultBackend := ingress("ingress", "stable", nil)
+	defaultBackend.Spec.Backend = &extensionsv1beta1.IngressBackend{ServiceName: "root", ServicePort: intstr.FromString("use-annotation")}
+	assert.NoError(t, ValidateIngress(ro, defaultBackend))
+}
+
+func TestVerifyWeightDisabled(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r := NewReconciler(rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress"}), client, &record.FakeRecorder{})
+	verified, err := r.VerifyWeight(10)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.Len(t, client.Actions(), 0)
+}
+
+func TestVerifyWeight(t *testing.T) {
+	configutil.SetDefaults(map[string]string{configutil.ALBVerifyWeightKey: "true"})
+	defer configutil.SetDefaults(nil)
+
+	canaryWeight := "10"
+	server := httptest.NewServer(newELBV2Handler(t, &canaryWeight))
+	defer server.Close()
+
+	ing := ingress("ingress", "stable", nil)
+	client := fake.NewSimpleClientset(ing)
+	r := NewReconciler(rollout(&v1alpha1.ALBTrafficRouting{Ingress: "ingress", ServicePort: 80}), client, &record.FakeRecorder{})
+	r.newELBV2 = func() (*elbv2API, error) {
+		return &elbv2API{endpoint: server.URL, client: server.Client(), signer: fakeSigner{}}, nil
+	}
+
+	// the weight cannot be verified before the load balancer is created
+	verified, err := r.VerifyWeight(10)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	ing.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "k8s-default-ingress-abc.us-west-2.elb.amazonaws.com"}}
+	client = fake.NewSimpleClientset(ing)
+	r.client = client
+	verified, err = r.VerifyWeight(10)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	verified, err = r.VerifyWeight(20)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	canaryWeight = "20"
+	verified, err = r.VerifyWeight(20)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(req *http.Request, body []byte, service string) error {
+	req.Header.Set("Authorization", "signed "+service)
+	return nil
+}
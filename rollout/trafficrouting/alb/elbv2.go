@@ -0,0 +1,259 @@
+package alb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	secretutil "github.com/argoproj/argo-rollouts/utils/secret"
+)
+
+const (
+	elbv2Service    = "elasticloadbalancing"
+	elbv2APIVersion = "2015-12-01"
+	// maxTagResources is the maximum number of resources of a DescribeTags request
+	maxTagResources = 20
+	// resourceTagKey is the tag the AWS Load Balancer Controller sets on target groups to the
+	// <namespace>/<ingress>-<service>:<port> they were created for
+	resourceTagKey = "ingress.k8s.aws/resource"
+
+	requestTimeout = 10 * time.Second
+)
+
+// signer signs requests to the AWS APIs
+type signer interface {
+	Sign(req *http.Request, body []byte, service string) error
+}
+
+var (
+	// signers are shared across reconciliations so credentials are only renewed before they expire
+	signersLock sync.Mutex
+	signers     = map[string]*secretutil.AWSSigner{}
+)
+
+// elbv2API reads the load balancers, listener rules and target groups of the Elastic Load
+// Balancing v2 API
+type elbv2API struct {
+	endpoint string
+	client   *http.Client
+	signer   signer
+}
+
+func newELBV2Client() (*elbv2API, error) {
+	region := configutil.Get().GetString(configutil.ALBRegionKey, os.Getenv("AWS_REGION"))
+	if region == "" {
+		return nil, errors.New("the AWS region of ALB load balancers is not configured")
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	signersLock.Lock()
+	s, ok := signers[region]
+	if !ok {
+		s = secretutil.NewAWSSigner(region, client)
+		signers[region] = s
+	}
+	signersLock.Unlock()
+	return &elbv2API{
+		endpoint: fmt.Sprintf("https://elasticloadbalancing.%s.amazonaws.com", region),
+		client:   client,
+		signer:   s,
+	}, nil
+}
+
+// call posts the action with the parameters and decodes the XML response into the result
+func (api *elbv2API) call(action string, params url.Values, result interface{}) error {
+	params.Set("Action", action)
+	params.Set("Version", elbv2APIVersion)
+	body := []byte(params.Encode())
+	req, err := http.NewRequest(http.MethodPost, api.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if err := api.signer.Sign(req, body, elbv2Service); err != nil {
+		return err
+	}
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		if err := xml.Unmarshal(data, &awsErr); err == nil && awsErr.Code != "" {
+			return fmt.Errorf("%s failed: %s: %s", action, awsErr.Code, awsErr.Message)
+		}
+		return fmt.Errorf("%s failed with status %s", action, resp.Status)
+	}
+	return xml.Unmarshal(data, result)
+}
+
+// loadBalancerARN returns the ARN of the load balancer with the DNS name
+func (api *elbv2API) loadBalancerARN(dnsName string) (string, error) {
+	marker := ""
+	for {
+		params := url.Values{}
+		if marker != "" {
+			params.Set("Marker", marker)
+		}
+		var result struct {
+			LoadBalancers []struct {
+				LoadBalancerArn string `xml:"LoadBalancerArn"`
+				DNSName         string `xml:"DNSName"`
+			} `xml:"DescribeLoadBalancersResult>LoadBalancers>member"`
+			NextMarker string `xml:"DescribeLoadBalancersResult>NextMarker"`
+		}
+		if err := api.call("DescribeLoadBalancers", params, &result); err != nil {
+			return "", err
+		}
+		for _, lb := range result.LoadBalancers {
+			if strings.EqualFold(lb.DNSName, dnsName) {
+				return lb.LoadBalancerArn, nil
+			}
+		}
+		if result.NextMarker == "" {
+			return "", fmt.Errorf("load balancer '%s' not found", dnsName)
+		}
+		marker = result.NextMarker
+	}
+}
+
+// listenerARNs returns the ARNs of the listeners of the load balancer
+func (api *elbv2API) listenerARNs(loadBalancerARN string) ([]string, error) {
+	var result struct {
+		Listeners []string `xml:"DescribeListenersResult>Listeners>member>ListenerArn"`
+	}
+	err := api.call("DescribeListeners", url.Values{"LoadBalancerArn": {loadBalancerARN}}, &result)
+	return result.Listeners, err
+}
+
+type ruleTargetGroup struct {
+	TargetGroupArn string `xml:"TargetGroupArn"`
+	Weight         int64  `xml:"Weight"`
+}
+
+type ruleAction struct {
+	Type           string            `xml:"Type"`
+	TargetGroupArn string            `xml:"TargetGroupArn"`
+	TargetGroups   []ruleTargetGroup `xml:"ForwardConfig>TargetGroups>member"`
+}
+
+// forwardActions returns the forward actions of the rules of the listener
+func (api *elbv2API) forwardActions(listenerARN string) ([]ruleAction, error) {
+	var result struct {
+		Actions []ruleAction `xml:"DescribeRulesResult>Rules>member>Actions>member"`
+	}
+	if err := api.call("DescribeRules", url.Values{"ListenerArn": {listenerARN}}, &result); err != nil {
+		return nil, err
+	}
+	var actions []ruleAction
+	for _, a := range result.Actions {
+		if a.Type == "forward" {
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
+
+// resourceTags returns the value of the resource tag of the target groups by ARN
+func (api *elbv2API) resourceTags(arns []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for start := 0; start < len(arns); start += maxTagResources {
+		end := start + maxTagResources
+		if end > len(arns) {
+			end = len(arns)
+		}
+		params := url.Values{}
+		for i, arn := range arns[start:end] {
+			params.Set("ResourceArns.member."+strconv.Itoa(i+1), arn)
+		}
+		var result struct {
+			TagDescriptions []struct {
+				ResourceArn string `xml:"ResourceArn"`
+				Tags        []struct {
+					Key   string `xml:"Key"`
+					Value string `xml:"Value"`
+				} `xml:"Tags>member"`
+			} `xml:"DescribeTagsResult>TagDescriptions>member"`
+		}
+		if err := api.call("DescribeTags", params, &result); err != nil {
+			return nil, err
+		}
+		for _, description := range result.TagDescriptions {
+			for _, tag := range description.Tags {
+				if tag.Key == resourceTagKey {
+					tags[description.ResourceArn] = tag.Value
+				}
+			}
+		}
+	}
+	return tags, nil
+}
+
+// targetGroupWeights returns the weights the listener rules of the load balancer with the DNS name
+// forward to each target group, keyed by the resource tag of the target group
+func targetGroupWeights(api *elbv2API, dnsName string) (map[string][]int64, error) {
+	lbARN, err := api.loadBalancerARN(dnsName)
+	if err != nil {
+		return nil, err
+	}
+	listeners, err := api.listenerARNs(lbARN)
+	if err != nil {
+		return nil, err
+	}
+	weightsByARN := map[string][]int64{}
+	var arns []string
+	addWeight := func(arn string, weight int64) {
+		if _, ok := weightsByARN[arn]; !ok {
+			arns = append(arns, arn)
+		}
+		weightsByARN[arn] = append(weightsByARN[arn], weight)
+	}
+	for _, listener := range listeners {
+		actions, err := api.forwardActions(listener)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range actions {
+			if len(a.TargetGroups) == 0 && a.TargetGroupArn != "" {
+				// a forward action to a single target group sends it all the traffic
+				addWeight(a.TargetGroupArn, 100)
+			}
+			for _, tg := range a.TargetGroups {
+				addWeight(tg.TargetGroupArn, tg.Weight)
+			}
+		}
+	}
+	tags, err := api.resourceTags(arns)
+	if err != nil {
+		return nil, err
+	}
+	weights := map[string][]int64{}
+	for _, arn := range arns {
+		if resource, ok := tags[arn]; ok {
+			weights[resource] = append(weights[resource], weightsByARN[arn]...)
+		}
+	}
+	return weights, nil
+}
+
+// targetGroupResource returns the resource tag of the target group of the service port of an Ingress
+func targetGroupResource(namespace, ingress, service string, port int32) string {
+	return fmt.Sprintf("%s/%s-%s:%d", namespace, ingress, service, port)
+}
@@ -0,0 +1,87 @@
+package alb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newELBV2Handler serves a load balancer with a listener rule forwarding the canary weight to the
+// target group of the canary service and the rest to the one of the stable service
+func newELBV2Handler(t *testing.T, canaryWeight *string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "signed elasticloadbalancing", r.Header.Get("Authorization"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "2015-12-01", r.Form.Get("Version"))
+		switch r.Form.Get("Action") {
+		case "DescribeLoadBalancers":
+			if r.Form.Get("Marker") == "" {
+				fmt.Fprint(w, `<DescribeLoadBalancersResponse><DescribeLoadBalancersResult>
+<LoadBalancers><member><LoadBalancerArn>arn:lb:other</LoadBalancerArn><DNSName>other.elb.amazonaws.com</DNSName></member></LoadBalancers>
+<NextMarker>page2</NextMarker></DescribeLoadBalancersResult></DescribeLoadBalancersResponse>`)
+				return
+			}
+			fmt.Fprint(w, `<DescribeLoadBalancersResponse><DescribeLoadBalancersResult>
+<LoadBalancers><member><LoadBalancerArn>arn:lb:ingress</LoadBalancerArn><DNSName>k8s-default-ingress-abc.us-west-2.elb.amazonaws.com</DNSName></member></LoadBalancers>
+</DescribeLoadBalancersResult></DescribeLoadBalancersResponse>`)
+		case "DescribeListeners":
+			assert.Equal(t, "arn:lb:ingress", r.Form.Get("LoadBalancerArn"))
+			fmt.Fprint(w, `<DescribeListenersResponse><DescribeListenersResult>
+<Listeners><member><ListenerArn>arn:listener</ListenerArn></member></Listeners>
+</DescribeListenersResult></DescribeListenersResponse>`)
+		case "DescribeRules":
+			assert.Equal(t, "arn:listener", r.Form.Get("ListenerArn"))
+			fmt.Fprintf(w, `<DescribeRulesResponse><DescribeRulesResult><Rules>
+<member><Actions><member><Type>forward</Type><ForwardConfig><TargetGroups>
+<member><TargetGroupArn>arn:tg:canary</TargetGroupArn><Weight>%s</Weight></member>
+<member><TargetGroupArn>arn:tg:stable</TargetGroupArn><Weight>0</Weight></member>
+</TargetGroups></ForwardConfig></member></Actions></member>
+<member><Actions><member><Type>fixed-response</Type></member></Actions></member>
+</Rules></DescribeRulesResult></DescribeRulesResponse>`, *canaryWeight)
+		case "DescribeTags":
+			assert.Equal(t, "arn:tg:canary", r.Form.Get("ResourceArns.member.1"))
+			assert.Equal(t, "arn:tg:stable", r.Form.Get("ResourceArns.member.2"))
+			fmt.Fprint(w, `<DescribeTagsResponse><DescribeTagsResult><TagDescriptions>
+<member><ResourceArn>arn:tg:canary</ResourceArn><Tags><member><Key>ingress.k8s.aws/resource</Key><Value>default/ingress-canary:80</Value></member></Tags></member>
+<member><ResourceArn>arn:tg:stable</ResourceArn><Tags><member><Key>ingress.k8s.aws/resource</Key><Value>default/ingress-stable:80</Value></member></Tags></member>
+</TagDescriptions></DescribeTagsResult></DescribeTagsResponse>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+}
+
+func TestTargetGroupWeights(t *testing.T) {
+	canaryWeight := "30"
+	server := httptest.NewServer(newELBV2Handler(t, &canaryWeight))
+	defer server.Close()
+	api := &elbv2API{endpoint: server.URL, client: server.Client(), signer: fakeSigner{}}
+
+	weights, err := targetGroupWeights(api, "k8s-default-ingress-abc.us-west-2.elb.amazonaws.com")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]int64{
+		"default/ingress-canary:80": {30},
+		"default/ingress-stable:80": {0},
+	}, weights)
+
+	_, err = targetGroupWeights(api, "missing.elb.amazonaws.com")
+	assert.EqualError(t, err, "load balancer 'missing.elb.amazonaws.com' not found")
+}
+
+func TestELBV2Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>not authorized</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+	api := &elbv2API{endpoint: server.URL, client: server.Client(), signer: fakeSigner{}}
+	_, err := api.listenerARNs("arn:lb:ingress")
+	assert.EqualError(t, err, "DescribeListeners failed: AccessDenied: not authorized")
+}
+
+func TestTargetGroupResource(t *testing.T) {
+	assert.Equal(t, "default/ingress-canary:80", targetGroupResource("default", "ingress", "canary", 80))
+}
@@ -0,0 +1,250 @@
+package gatewayapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "GatewayAPI"
+	// DefaultAPIVersion is the apiVersion of the HTTPRoutes when the controller configuration does
+	// not set one
+	DefaultAPIVersion = "v1beta1"
+
+	httpRouteGroup = "gateway.networking.k8s.io"
+
+	invalidCasting = "Invalid casting: field '%s' is not of type '%s'"
+)
+
+// NewReconciler returns a reconciler struct that brings the backendRefs of the HTTPRoute into the desired state
+func NewReconciler(r *v1alpha1.Rollout, client dynamic.Interface, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		rollout:  r,
+		log:      logutil.WithRollout(r),
+		client:   client,
+		recorder: recorder,
+	}
+}
+
+// Reconciler holds required fields to reconcile Gateway API resources
+type Reconciler struct {
+	rollout  *v1alpha1.Rollout
+	log      *logrus.Entry
+	client   dynamic.Interface
+	recorder record.EventRecorder
+}
+
+// Type indicates this reconciler is a Gateway API reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// UpdateHash is a no-op for the Gateway API since the backendRefs are the stable and canary services
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	return nil
+}
+
+// SetHeaderRoutes fails if the rollout has header routes since the controller only manages the
+// weights of the HTTPRoute
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	if len(headerRoutes) > 0 {
+		return errors.New("the GatewayAPI traffic router does not support header routes")
+	}
+	return nil
+}
+
+// SetMirrorRoute fails if the rollout mirrors requests since HTTPRoutes can not mirror a percentage
+// of the requests
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	if percentage > 0 {
+		return errors.New("the GatewayAPI traffic router does not support mirror routes")
+	}
+	return nil
+}
+
+// Reconcile sets the weights of the backendRefs of the rules of the HTTPRoute routing to the stable
+// and canary services
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	name := r.rollout.Spec.Strategy.Canary.TrafficRouting.GatewayAPI.HTTPRoute
+	client := r.client.Resource(GVR()).Namespace(r.rollout.Namespace)
+	httpRoute, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("HTTPRoute `%s` not found", name)
+			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "HTTPRouteNotFound", msg)
+		}
+		return err
+	}
+	modifiedRoute, modified, err := reconcileHTTPRoute(r.rollout, httpRoute, desiredWeight, additionalDestinations...)
+	if err != nil {
+		return err
+	}
+	if !modified {
+		return nil
+	}
+	msg := fmt.Sprintf("Updating HTTPRoute `%s` to desiredWeight '%d'", name, desiredWeight)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingHTTPRoute", msg)
+	_, err = client.Update(modifiedRoute, metav1.UpdateOptions{})
+	return err
+}
+
+// reconcileHTTPRoute sends the desired weight of the rules routing to the stable and canary services
+// to the canary service, the weights of the additional destinations to their services, and the rest
+// to the stable service. The backendRefs of these rules which are neither the stable or canary
+// service nor an additional destination are removed. Returns true if a rule was modified.
+func reconcileHTTPRoute(r *v1alpha1.Rollout, obj *unstructured.Unstructured, desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) (*unstructured.Unstructured, bool, error) {
+	canary := r.Spec.Strategy.Canary
+	newObj := obj.DeepCopy()
+	rules, found, err := unstructured.NestedSlice(newObj.Object, "spec", "rules")
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, fmt.Errorf(".spec.rules is not defined")
+	}
+	weights := map[string]int64{
+		canary.CanaryService: int64(desiredWeight),
+		canary.StableService: int64(100 - desiredWeight),
+	}
+	for _, d := range additionalDestinations {
+		weights[d.ServiceName] = int64(d.Weight)
+		weights[canary.StableService] -= int64(d.Weight)
+	}
+
+	modified := false
+	matched := false
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf(invalidCasting, "rules[]", "map[string]interface")
+		}
+		backendRefs, _, err := unstructured.NestedSlice(rule, "backendRefs")
+		if err != nil {
+			return nil, false, err
+		}
+		stableRef := findBackendRef(backendRefs, canary.StableService)
+		if stableRef == nil || findBackendRef(backendRefs, canary.CanaryService) == nil {
+			continue
+		}
+		matched = true
+
+		desired := []interface{}{}
+		present := map[string]bool{}
+		for _, ref := range backendRefs {
+			backendRef, ok := ref.(map[string]interface{})
+			if !ok {
+				return nil, false, fmt.Errorf(invalidCasting, "rules[].backendRefs[]", "map[string]interface")
+			}
+			name, _ := backendRef["name"].(string)
+			weight, ok := weights[name]
+			if !ok {
+				continue
+			}
+			present[name] = true
+			backendRef["weight"] = weight
+			desired = append(desired, backendRef)
+		}
+		for _, d := range additionalDestinations {
+			if present[d.ServiceName] {
+				continue
+			}
+			backendRef := map[string]interface{}{
+				"name":   d.ServiceName,
+				"weight": int64(d.Weight),
+			}
+			if port, ok := stableRef["port"]; ok {
+				backendRef["port"] = port
+			}
+			desired = append(desired, backendRef)
+		}
+
+		current, err := json.Marshal(backendRefs)
+		if err != nil {
+			return nil, false, err
+		}
+		updated, err := json.Marshal(desired)
+		if err != nil {
+			return nil, false, err
+		}
+		if string(current) == string(updated) {
+			continue
+		}
+		rule["backendRefs"] = desired
+		rules[i] = rule
+		modified = true
+	}
+	if !matched {
+		return nil, false, noRuleError(r)
+	}
+	err = unstructured.SetNestedSlice(newObj.Object, rules, "spec", "rules")
+	return newObj, modified, err
+}
+
+// findBackendRef returns a copy of the backendRef to the service, or nil if there is none
+func findBackendRef(backendRefs []interface{}, service string) map[string]interface{} {
+	for _, ref := range backendRefs {
+		backendRef, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := backendRef["name"].(string); name == service {
+			return backendRef
+		}
+	}
+	return nil
+}
+
+// ValidateHTTPRoute ensures a rule of the HTTPRoute routes to the stable and canary services
+func ValidateHTTPRoute(r *v1alpha1.Rollout, obj *unstructured.Unstructured) error {
+	canary := r.Spec.Strategy.Canary
+	rules, found, err := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf(".spec.rules is not defined")
+	}
+	for _, item := range rules {
+		rule, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		if findBackendRef(backendRefs, canary.StableService) != nil && findBackendRef(backendRefs, canary.CanaryService) != nil {
+			return nil
+		}
+	}
+	return noRuleError(r)
+}
+
+func noRuleError(r *v1alpha1.Rollout) error {
+	canary := r.Spec.Strategy.Canary
+	return fmt.Errorf("no rule with backendRefs to the Stable Service '%s' and the Canary Service '%s' found", canary.StableService, canary.CanaryService)
+}
+
+// APIVersion returns the apiVersion of the HTTPRoutes managed by the controller
+func APIVersion() string {
+	return configutil.Get().GetString(configutil.GatewayAPIVersionKey, DefaultAPIVersion)
+}
+
+// GVR returns the resource of the HTTPRoutes managed by the controller
+func GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: httpRouteGroup, Version: APIVersion(), Resource: "httproutes"}
+}
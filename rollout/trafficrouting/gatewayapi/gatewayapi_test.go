@@ -0,0 +1,185 @@
+package gatewayapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+const httpRoute = `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: route
+  namespace: default
+spec:
+  parentRefs:
+  - name: gateway
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /api
+    backendRefs:
+    - name: stable
+      port: 80
+      weight: 100
+    - name: canary
+      port: 80
+      weight: 0
+  - backendRefs:
+    - name: other
+      port: 8080`
+
+func strToUnstructured(yamlStr string) *unstructured.Unstructured {
+	obj := make(map[string]interface{})
+	yamlStr = strings.ReplaceAll(yamlStr, "\t", "    ")
+	err := yaml.Unmarshal([]byte(yamlStr), &obj)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func rollout(route string) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{
+							HTTPRoute: route,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// backendRefs returns the weights of the backendRefs of the rule of the HTTPRoute
+func backendRefs(t *testing.T, obj *unstructured.Unstructured, rule int) map[string]int64 {
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	refs, _, _ := unstructured.NestedSlice(rules[rule].(map[string]interface{}), "backendRefs")
+	weights := map[string]int64{}
+	for _, item := range refs {
+		ref := item.(map[string]interface{})
+		switch weight := ref["weight"].(type) {
+		case int64:
+			weights[ref["name"].(string)] = weight
+		case float64:
+			weights[ref["name"].(string)] = int64(weight)
+		default:
+			assert.Failf(t, "backendRef without weight", "backendRef '%s' has no weight", ref["name"])
+		}
+	}
+	return weights
+}
+
+func TestType(t *testing.T) {
+	r := NewReconciler(rollout("route"), fake.NewSimpleDynamicClient(runtime.NewScheme()), &record.FakeRecorder{})
+	assert.Equal(t, Type, r.Type())
+	assert.Nil(t, r.UpdateHash("def456", "abc123"))
+	assert.Nil(t, r.SetMirrorRoute(0))
+	assert.EqualError(t, r.SetMirrorRoute(10), "the GatewayAPI traffic router does not support mirror routes")
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	assert.EqualError(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{{Name: "testers"}}), "the GatewayAPI traffic router does not support header routes")
+}
+
+func TestReconcileUpdatesHTTPRoute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(httpRoute))
+	r := NewReconciler(rollout("route"), client, &record.FakeRecorder{})
+	assert.Nil(t, r.Reconcile(10))
+
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "get", actions[0].GetVerb())
+	assert.Equal(t, "update", actions[1].GetVerb())
+	obj := actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	assert.Equal(t, map[string]int64{"stable": 90, "canary": 10}, backendRefs(t, obj, 0))
+	// rules which do not route to the stable and canary services are left as is
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	refs, _, _ := unstructured.NestedSlice(rules[1].(map[string]interface{}), "backendRefs")
+	_, ok := refs[0].(map[string]interface{})["weight"]
+	assert.False(t, ok)
+}
+
+func TestReconcileHTTPRouteUnchanged(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(httpRoute))
+	r := NewReconciler(rollout("route"), client, &record.FakeRecorder{})
+	assert.Nil(t, r.Reconcile(0))
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestReconcileHTTPRouteAdditionalDestinations(t *testing.T) {
+	obj := strToUnstructured(httpRoute)
+	modified, changed, err := reconcileHTTPRoute(rollout("route"), obj, 10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 20})
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]int64{"stable": 70, "canary": 10, "ex-baseline": 20}, backendRefs(t, modified, 0))
+	rules, _, _ := unstructured.NestedSlice(modified.Object, "spec", "rules")
+	refs, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "backendRefs")
+	assert.Len(t, refs, 3)
+	assert.Equal(t, float64(80), refs[2].(map[string]interface{})["port"])
+
+	// the backendRefs of the experiment are removed once it finishes
+	modified, changed, err = reconcileHTTPRoute(rollout("route"), modified, 10)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]int64{"stable": 90, "canary": 10}, backendRefs(t, modified, 0))
+}
+
+func TestReconcileHTTPRouteNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := NewReconciler(rollout("route"), client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestReconcileHTTPRouteNoRule(t *testing.T) {
+	ro := rollout("route")
+	ro.Spec.Strategy.Canary.CanaryService = "preview"
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(httpRoute))
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.EqualError(t, err, "no rule with backendRefs to the Stable Service 'stable' and the Canary Service 'preview' found")
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestValidateHTTPRoute(t *testing.T) {
+	ro := rollout("route")
+	assert.Nil(t, ValidateHTTPRoute(ro, strToUnstructured(httpRoute)))
+
+	ro.Spec.Strategy.Canary.StableService = "other"
+	assert.EqualError(t, ValidateHTTPRoute(ro, strToUnstructured(httpRoute)), "no rule with backendRefs to the Stable Service 'other' and the Canary Service 'canary' found")
+
+	noRules := strToUnstructured(httpRoute)
+	unstructured.RemoveNestedField(noRules.Object, "spec", "rules")
+	assert.EqualError(t, ValidateHTTPRoute(ro, noRules), ".spec.rules is not defined")
+}
+
+func TestAPIVersion(t *testing.T) {
+	assert.Equal(t, DefaultAPIVersion, APIVersion())
+	configutil.SetDefaults(map[string]string{configutil.GatewayAPIVersionKey: "v1alpha2"})
+	defer configutil.SetDefaults(nil)
+	assert.Equal(t, "v1alpha2", APIVersion())
+	assert.Equal(t, "v1alpha2", GVR().Version)
+}
@@ -3,6 +3,8 @@ package istio
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -14,6 +16,7 @@ import (
 	"k8s.io/client-go/tools/record"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 )
 
@@ -91,9 +94,16 @@ func (patches virtualServicePatches) patchVirtualService(httpRoutes []interface{
 	return nil
 }
 
-func (r *Reconciler) generateVirtualServicePatches(httpRoutes []httpRoute, desiredWeight int64) virtualServicePatches {
-	canarySvc := r.rollout.Spec.Strategy.Canary.CanaryService
-	stableSvc := r.rollout.Spec.Strategy.Canary.StableService
+func (r *Reconciler) generateVirtualServicePatches(httpRoutes []httpRoute, desiredWeight int64, additionalDestinations ...trafficrouting.WeightDestination) virtualServicePatches {
+	stableSvc, canarySvc := stableAndCanaryDestinations(r.rollout)
+	weights := map[string]int64{
+		canarySvc: desiredWeight,
+		stableSvc: 100 - desiredWeight,
+	}
+	for _, d := range additionalDestinations {
+		weights[d.ServiceName] = int64(d.Weight)
+		weights[stableSvc] -= int64(d.Weight)
+	}
 	routes := map[string]bool{}
 	for _, r := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
 		routes[r] = true
@@ -107,21 +117,12 @@ func (r *Reconciler) generateVirtualServicePatches(httpRoutes []httpRoute, desir
 		}
 		for j := range route.Route {
 			destination := httpRoutes[i].Route[j]
-			host := destination.Destination.Host
-			weight := destination.Weight
-			if host == canarySvc && weight != desiredWeight {
-				patch := virtualServicePatch{
-					routeIndex:       i,
-					destinationIndex: j,
-					weight:           desiredWeight,
-				}
-				patches = append(patches, patch)
-			}
-			if host == stableSvc && weight != 100-desiredWeight {
+			weight, ok := weights[destination.Destination.key(r.rollout)]
+			if ok && destination.Weight != weight {
 				patch := virtualServicePatch{
 					routeIndex:       i,
 					destinationIndex: j,
-					weight:           100 - desiredWeight,
+					weight:           weight,
 				}
 				patches = append(patches, patch)
 			}
@@ -130,7 +131,70 @@ func (r *Reconciler) generateVirtualServicePatches(httpRoutes []httpRoute, desir
 	return patches
 }
 
-func (r *Reconciler) reconcileVirtualService(obj *unstructured.Unstructured, desiredWeight int32) (*unstructured.Unstructured, bool, error) {
+// reconcileDestinations adds a destination to the routes of the rollout for each additional
+// destination, and removes the destinations which are neither the stable or canary service nor an
+// additional destination. Returns true if a route was modified.
+func (r *Reconciler) reconcileDestinations(httpRoutes []interface{}, additionalDestinations []trafficrouting.WeightDestination) (bool, error) {
+	stableSvc, canarySvc := stableAndCanaryDestinations(r.rollout)
+	desired := map[string]bool{
+		canarySvc: true,
+		stableSvc: true,
+	}
+	for _, d := range additionalDestinations {
+		desired[d.ServiceName] = true
+	}
+	routes := map[string]bool{}
+	for _, r := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
+		routes[r] = true
+	}
+
+	modified := false
+	for i := range httpRoutes {
+		route, ok := httpRoutes[i].(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf(invalidCasting, "http[]", "map[string]interface")
+		}
+		if name, _ := route["name"].(string); !routes[name] {
+			continue
+		}
+		destinations, ok := route["route"].([]interface{})
+		if !ok {
+			return false, fmt.Errorf(invalidCasting, "http[].route", "[]interface")
+		}
+		newDestinations := []interface{}{}
+		found := map[string]bool{}
+		for _, d := range destinations {
+			dest, ok := d.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf(invalidCasting, "http[].route[].destination", "map[string]interface")
+			}
+			host, _, _ := unstructured.NestedString(dest, "destination", "host")
+			subset, _, _ := unstructured.NestedString(dest, "destination", "subset")
+			key := destination{Host: host, Subset: subset}.key(r.rollout)
+			if !desired[key] {
+				modified = true
+				continue
+			}
+			found[key] = true
+			newDestinations = append(newDestinations, dest)
+		}
+		for _, d := range additionalDestinations {
+			if found[d.ServiceName] {
+				continue
+			}
+			newDestinations = append(newDestinations, map[string]interface{}{
+				"destination": map[string]interface{}{"host": d.ServiceName},
+				"weight":      float64(0),
+			})
+			modified = true
+		}
+		route["route"] = newDestinations
+		httpRoutes[i] = route
+	}
+	return modified, nil
+}
+
+func (r *Reconciler) reconcileVirtualService(obj *unstructured.Unstructured, desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) (*unstructured.Unstructured, bool, error) {
 	newObj := obj.DeepCopy()
 	httpRoutesI, notFound, err := unstructured.NestedSlice(newObj.Object, "spec", "http")
 	if !notFound {
@@ -154,11 +218,28 @@ func (r *Reconciler) reconcileVirtualService(obj *unstructured.Unstructured, des
 		return nil, false, err
 	}
 
-	patches := r.generateVirtualServicePatches(httpRoutes, int64(desiredWeight))
-	patches.patchVirtualService(httpRoutesI)
+	destinationsModified, err := r.reconcileDestinations(httpRoutesI, additionalDestinations)
+	if err != nil {
+		return nil, false, err
+	}
+	if destinationsModified {
+		routeBytes, err := json.Marshal(httpRoutesI)
+		if err != nil {
+			return nil, false, err
+		}
+		httpRoutes = nil
+		if err := json.Unmarshal(routeBytes, &httpRoutes); err != nil {
+			return nil, false, err
+		}
+	}
+
+	patches := r.generateVirtualServicePatches(httpRoutes, int64(desiredWeight), additionalDestinations...)
+	if err := patches.patchVirtualService(httpRoutesI); err != nil {
+		return nil, false, err
+	}
 
 	err = unstructured.SetNestedSlice(newObj.Object, httpRoutesI, "spec", "http")
-	return newObj, len(patches) > 0, err
+	return newObj, destinationsModified || len(patches) > 0, err
 }
 
 // Type indicates this reconciler is an Istio reconciler
@@ -166,8 +247,8 @@ func (r *Reconciler) Type() string {
 	return Type
 }
 
-// Reconcile modifies Istio resources to reach desired state
-func (r *Reconciler) Reconcile(desiredWeight int32) error {
+// getVirtualService returns the VirtualService of the rollout and the client it is updated with
+func (r *Reconciler) getVirtualService() (dynamic.ResourceInterface, *unstructured.Unstructured, error) {
 	vsvcName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
 	gvk := schema.ParseGroupResource("virtualservices.networking.istio.io").WithVersion(r.defaultAPIVersion)
 	client := r.client.Resource(gvk).Namespace(r.rollout.Namespace)
@@ -177,9 +258,19 @@ func (r *Reconciler) Reconcile(desiredWeight int32) error {
 			msg := fmt.Sprintf("Virtual Service `%s` not found", vsvcName)
 			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "VirtualServiceNotFound", msg)
 		}
+		return nil, nil, err
+	}
+	return client, vsvc, nil
+}
+
+// Reconcile modifies Istio resources to reach desired state
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	vsvcName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
+	client, vsvc, err := r.getVirtualService()
+	if err != nil {
 		return err
 	}
-	modifiedVsvc, modifed, err := r.reconcileVirtualService(vsvc, desiredWeight)
+	modifiedVsvc, modifed, err := r.reconcileVirtualService(vsvc, desiredWeight, additionalDestinations...)
 	if err != nil {
 		return err
 	}
@@ -193,11 +284,379 @@ func (r *Reconciler) Reconcile(desiredWeight int32) error {
 	return err
 }
 
-// validateHTTPRoutes ensures that all the routes in the rollout exist and they only have two destinations
+// SetHeaderRoutes adds a route to the VirtualService for each header route, before the other routes so
+// it takes precedence, and removes the routes of the setHeaderRoute steps which are not in the list
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	managed := map[string]bool{}
+	for _, step := range r.rollout.Spec.Strategy.Canary.Steps {
+		if step.SetHeaderRoute != nil {
+			managed[step.SetHeaderRoute.Name] = true
+		}
+	}
+	if len(managed) == 0 {
+		return nil
+	}
+	for _, route := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
+		if managed[route] {
+			return fmt.Errorf("Header route '%s' has the name of a route of the rollout", route)
+		}
+	}
+	vsvcName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
+	client, vsvc, err := r.getVirtualService()
+	if err != nil {
+		return err
+	}
+	modifiedVsvc, modified, err := r.reconcileHeaderRoutes(vsvc, managed, headerRoutes)
+	if err != nil || !modified {
+		return err
+	}
+	msg := fmt.Sprintf("Updating VirtualService `%s` to %d header routes", vsvcName, len(headerRoutes))
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingVirtualService", msg)
+	_, err = client.Update(modifiedVsvc, metav1.UpdateOptions{})
+	return err
+}
+
+// reconcileHeaderRoutes replaces the managed routes of the VirtualService with the header routes.
+// Returns true if the routes were modified.
+func (r *Reconciler) reconcileHeaderRoutes(obj *unstructured.Unstructured, managed map[string]bool, headerRoutes []v1alpha1.SetHeaderRoute) (*unstructured.Unstructured, bool, error) {
+	newObj := obj.DeepCopy()
+	httpRoutesI, found, err := unstructured.NestedSlice(newObj.Object, "spec", "http")
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, fmt.Errorf(".spec.http is not defined")
+	}
+	routeBytes, err := json.Marshal(httpRoutesI)
+	if err != nil {
+		return nil, false, err
+	}
+	var httpRoutes []httpRoute
+	if err := json.Unmarshal(routeBytes, &httpRoutes); err != nil {
+		return nil, false, err
+	}
+	canaryDest, err := r.canaryDestination(httpRoutes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	desired := []interface{}{}
+	for _, headerRoute := range headerRoutes {
+		route, err := headerHTTPRoute(headerRoute, canaryDest)
+		if err != nil {
+			return nil, false, err
+		}
+		desired = append(desired, route)
+	}
+	for _, route := range httpRoutesI {
+		if routeMap, ok := route.(map[string]interface{}); ok {
+			if name, _ := routeMap["name"].(string); managed[name] {
+				continue
+			}
+		}
+		desired = append(desired, route)
+	}
+	desiredBytes, err := json.Marshal(desired)
+	if err != nil {
+		return nil, false, err
+	}
+	if string(desiredBytes) == string(routeBytes) {
+		return newObj, false, nil
+	}
+	err = unstructured.SetNestedSlice(newObj.Object, desired, "spec", "http")
+	return newObj, true, err
+}
+
+// canaryDestination returns the destination of the routes of the rollout sending traffic to the canary
+func (r *Reconciler) canaryDestination(httpRoutes []httpRoute) (destination, error) {
+	_, canaryKey := stableAndCanaryDestinations(r.rollout)
+	routes := map[string]bool{}
+	for _, route := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
+		routes[route] = true
+	}
+	for _, route := range httpRoutes {
+		if !routes[route.Name] {
+			continue
+		}
+		for _, dest := range route.Route {
+			if dest.Destination.key(r.rollout) == canaryKey {
+				return dest.Destination, nil
+			}
+		}
+	}
+	return destination{}, fmt.Errorf("Canary destination '%s' not found in the routes of the rollout", canaryKey)
+}
+
+// headerHTTPRoute returns the route sending the requests matching all the matches of the header route
+// to the canary destination. Cookies are matched with a regular expression on the cookie header.
+func headerHTTPRoute(headerRoute v1alpha1.SetHeaderRoute, canaryDest destination) (map[string]interface{}, error) {
+	headers := map[string]interface{}{}
+	for _, match := range headerRoute.Match {
+		if match.CookieName == "" {
+			headers[strings.ToLower(match.HeaderName)] = stringMatch(match.Value)
+			continue
+		}
+		if _, ok := headers["cookie"]; ok {
+			return nil, fmt.Errorf("Header route '%s' can only match a single cookie", headerRoute.Name)
+		}
+		headers["cookie"] = map[string]interface{}{"regex": cookieRegex(match.CookieName, match.Value)}
+	}
+	dest := map[string]interface{}{"host": canaryDest.Host}
+	if canaryDest.Subset != "" {
+		dest["subset"] = canaryDest.Subset
+	}
+	return map[string]interface{}{
+		"name":  headerRoute.Name,
+		"match": []interface{}{map[string]interface{}{"headers": headers}},
+		"route": []interface{}{map[string]interface{}{"destination": dest, "weight": int64(100)}},
+	}, nil
+}
+
+// stringMatch returns the Istio StringMatch of the value
+func stringMatch(value v1alpha1.StringMatch) map[string]interface{} {
+	switch {
+	case value.Exact != "":
+		return map[string]interface{}{"exact": value.Exact}
+	case value.Prefix != "":
+		return map[string]interface{}{"prefix": value.Prefix}
+	default:
+		return map[string]interface{}{"regex": value.Regex}
+	}
+}
+
+// cookieRegex returns the regular expression matching a cookie header with the cookie of the value
+func cookieRegex(name string, value v1alpha1.StringMatch) string {
+	var valueRegex string
+	switch {
+	case value.Exact != "":
+		valueRegex = regexp.QuoteMeta(value.Exact)
+	case value.Prefix != "":
+		valueRegex = regexp.QuoteMeta(value.Prefix) + "[^;]*"
+	default:
+		valueRegex = "(?:" + value.Regex + ")"
+	}
+	return fmt.Sprintf("^(.*?;\\s*)?(%s=%s)(;.*)?$", regexp.QuoteMeta(name), valueRegex)
+}
+
+// SetMirrorRoute mirrors the percentage of the requests of the routes of the rollout to their canary
+// destination, or removes the mirror of the routes if the percentage is 0
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	vsvcName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Name
+	client, vsvc, err := r.getVirtualService()
+	if err != nil {
+		return err
+	}
+	modifiedVsvc, modified, err := r.reconcileMirrorRoute(vsvc, percentage)
+	if err != nil || !modified {
+		return err
+	}
+	msg := fmt.Sprintf("Updating VirtualService `%s` to mirror '%d' percent of the requests", vsvcName, percentage)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingVirtualService", msg)
+	_, err = client.Update(modifiedVsvc, metav1.UpdateOptions{})
+	return err
+}
+
+// reconcileMirrorRoute sets the mirror of the routes of the rollout to their canary destination.
+// Returns true if a route was modified.
+func (r *Reconciler) reconcileMirrorRoute(obj *unstructured.Unstructured, percentage int32) (*unstructured.Unstructured, bool, error) {
+	newObj := obj.DeepCopy()
+	httpRoutesI, found, err := unstructured.NestedSlice(newObj.Object, "spec", "http")
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, fmt.Errorf(".spec.http is not defined")
+	}
+	routeBytes, err := json.Marshal(httpRoutesI)
+	if err != nil {
+		return nil, false, err
+	}
+	var httpRoutes []httpRoute
+	if err := json.Unmarshal(routeBytes, &httpRoutes); err != nil {
+		return nil, false, err
+	}
+	_, canaryKey := stableAndCanaryDestinations(r.rollout)
+	routes := map[string]bool{}
+	for _, route := range r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes {
+		routes[route] = true
+	}
+
+	modified := false
+	for i := range httpRoutesI {
+		route, ok := httpRoutesI[i].(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf(invalidCasting, "http[]", "map[string]interface")
+		}
+		if !routes[httpRoutes[i].Name] {
+			continue
+		}
+		if percentage == 0 {
+			if _, ok := route["mirror"]; ok {
+				delete(route, "mirror")
+				delete(route, "mirrorPercentage")
+				modified = true
+			}
+			continue
+		}
+		var mirror map[string]interface{}
+		for _, dest := range httpRoutes[i].Route {
+			if dest.Destination.key(r.rollout) == canaryKey {
+				mirror = map[string]interface{}{"host": dest.Destination.Host}
+				if dest.Destination.Subset != "" {
+					mirror["subset"] = dest.Destination.Subset
+				}
+			}
+		}
+		if mirror == nil {
+			return nil, false, fmt.Errorf("Canary destination '%s' not found in route '%s'", canaryKey, httpRoutes[i].Name)
+		}
+		mirrorPercentage := map[string]interface{}{"value": float64(percentage)}
+		current, err := json.Marshal([]interface{}{route["mirror"], route["mirrorPercentage"]})
+		if err != nil {
+			return nil, false, err
+		}
+		desired, err := json.Marshal([]interface{}{mirror, mirrorPercentage})
+		if err != nil {
+			return nil, false, err
+		}
+		if string(current) == string(desired) {
+			continue
+		}
+		route["mirror"] = mirror
+		route["mirrorPercentage"] = mirrorPercentage
+		httpRoutesI[i] = route
+		modified = true
+	}
+	err = unstructured.SetNestedSlice(newObj.Object, httpRoutesI, "spec", "http")
+	return newObj, modified, err
+}
+
+// UpdateHash points the canary and stable subsets of the DestinationRule of the rollout at the pods of
+// the ReplicaSets with the given pod template hashes. Subsets of an empty hash are left as they are.
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	dRule := r.rollout.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule
+	if dRule == nil {
+		return nil
+	}
+	gvk := schema.ParseGroupResource("destinationrules.networking.istio.io").WithVersion(r.defaultAPIVersion)
+	client := r.client.Resource(gvk).Namespace(r.rollout.Namespace)
+	obj, err := client.Get(dRule.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Destination Rule `%s` not found", dRule.Name)
+			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "DestinationRuleNotFound", msg)
+		}
+		return err
+	}
+	modifiedObj, modified, err := reconcileDestinationRule(r.rollout, obj, canaryHash, stableHash)
+	if err != nil {
+		return err
+	}
+	if !modified {
+		return nil
+	}
+	msg := fmt.Sprintf("Updating DestinationRule `%s` to canary hash '%s' and stable hash '%s'", dRule.Name, canaryHash, stableHash)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingDestinationRule", msg)
+	_, err = client.Update(modifiedObj, metav1.UpdateOptions{})
+	return err
+}
+
+// reconcileDestinationRule sets the pod template hash label of the canary and stable subsets of the
+// DestinationRule. Returns true if a subset was modified.
+func reconcileDestinationRule(r *v1alpha1.Rollout, obj *unstructured.Unstructured, canaryHash, stableHash string) (*unstructured.Unstructured, bool, error) {
+	dRule := r.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule
+	newObj := obj.DeepCopy()
+	subsets, found, err := unstructured.NestedSlice(newObj.Object, "spec", "subsets")
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, fmt.Errorf(".spec.subsets is not defined")
+	}
+	hashes := map[string]string{
+		dRule.CanarySubsetName: canaryHash,
+		dRule.StableSubsetName: stableHash,
+	}
+	modified := false
+	for i := range subsets {
+		subset, ok := subsets[i].(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf(invalidCasting, "subsets[]", "map[string]interface")
+		}
+		name, _, _ := unstructured.NestedString(subset, "name")
+		hash, ok := hashes[name]
+		if !ok || hash == "" {
+			continue
+		}
+		current, _, _ := unstructured.NestedString(subset, "labels", v1alpha1.DefaultRolloutUniqueLabelKey)
+		if current == hash {
+			continue
+		}
+		if err := unstructured.SetNestedField(subset, hash, "labels", v1alpha1.DefaultRolloutUniqueLabelKey); err != nil {
+			return nil, false, err
+		}
+		subsets[i] = subset
+		modified = true
+	}
+	err = unstructured.SetNestedSlice(newObj.Object, subsets, "spec", "subsets")
+	return newObj, modified, err
+}
+
+// ValidateDestinationRule ensures the canary and stable subsets of the rollout exist in the DestinationRule
+func ValidateDestinationRule(r *v1alpha1.Rollout, obj *unstructured.Unstructured) error {
+	dRule := r.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule
+	subsets, found, err := unstructured.NestedSlice(obj.Object, "spec", "subsets")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf(".spec.subsets is not defined")
+	}
+	names := map[string]bool{}
+	for _, s := range subsets {
+		if subset, ok := s.(map[string]interface{}); ok {
+			name, _, _ := unstructured.NestedString(subset, "name")
+			names[name] = true
+		}
+	}
+	for _, name := range []string{dRule.CanarySubsetName, dRule.StableSubsetName} {
+		if !names[name] {
+			return fmt.Errorf("Subset '%s' is not found", name)
+		}
+	}
+	return nil
+}
+
+// ValidateVirtualService ensures the routes of the rollout exist in the VirtualService and route
+// traffic to the stable and canary services
+func ValidateVirtualService(r *v1alpha1.Rollout, obj *unstructured.Unstructured) error {
+	httpRoutesI, found, err := unstructured.NestedSlice(obj.Object, "spec", "http")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf(".spec.http is not defined")
+	}
+	routeBytes, err := json.Marshal(httpRoutesI)
+	if err != nil {
+		return err
+	}
+	var httpRoutes []httpRoute
+	if err := json.Unmarshal(routeBytes, &httpRoutes); err != nil {
+		return err
+	}
+	return validateHTTPRoutes(r, httpRoutes)
+}
+
+// validateHTTPRoutes ensures that all the routes in the rollout exist and route to the stable and canary services
 func validateHTTPRoutes(r *v1alpha1.Rollout, httpRoutes []httpRoute) error {
 	routes := r.Spec.Strategy.Canary.TrafficRouting.Istio.VirtualService.Routes
 	stableSvc := r.Spec.Strategy.Canary.StableService
 	canarySvc := r.Spec.Strategy.Canary.CanaryService
+	dRule := r.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule
 
 	routesPatched := map[string]bool{}
 	for _, route := range routes {
@@ -208,7 +667,12 @@ func validateHTTPRoutes(r *v1alpha1.Rollout, httpRoutes []httpRoute) error {
 		// check if the httpRoute is in the list of routes from the rollout
 		if _, ok := routesPatched[route.Name]; ok {
 			routesPatched[route.Name] = true
-			err := validateHosts(route, stableSvc, canarySvc)
+			var err error
+			if dRule != nil {
+				err = validateSubsets(route, dRule.StableSubsetName, dRule.CanarySubsetName)
+			} else {
+				err = validateHosts(route, stableSvc, canarySvc)
+			}
 			if err != nil {
 				return err
 			}
@@ -224,11 +688,9 @@ func validateHTTPRoutes(r *v1alpha1.Rollout, httpRoutes []httpRoute) error {
 	return nil
 }
 
-// validateHosts ensures there are two destinations within a route and their hosts are the stable and canary service
+// validateHosts ensures the stable and canary service are destinations of a route. Other
+// destinations are the services of experiment templates managed by the controller.
 func validateHosts(hr httpRoute, stableSvc, canarySvc string) error {
-	if len(hr.Route) != 2 {
-		return fmt.Errorf("Route '%s' does not have exactly two routes", hr.Name)
-	}
 	hasStableSvc := false
 	hasCanarySvc := false
 	for _, r := range hr.Route {
@@ -249,11 +711,51 @@ func validateHosts(hr httpRoute, stableSvc, canarySvc string) error {
 
 }
 
+// validateSubsets ensures the stable and canary subsets of the DestinationRule are destinations of a route
+func validateSubsets(hr httpRoute, stableSubset, canarySubset string) error {
+	hasStableSubset := false
+	hasCanarySubset := false
+	for _, r := range hr.Route {
+		if r.Destination.Subset == stableSubset {
+			hasStableSubset = true
+		}
+		if r.Destination.Subset == canarySubset {
+			hasCanarySubset = true
+		}
+	}
+	if !hasCanarySubset {
+		return fmt.Errorf("Canary Subset '%s' not found in route", canarySubset)
+	}
+	if !hasStableSubset {
+		return fmt.Errorf("Stable Subset '%s' not found in route", stableSubset)
+	}
+	return nil
+}
+
+// stableAndCanaryDestinations returns the destinations of the routes sending traffic to the stable and
+// canary pods: the subsets of the DestinationRule of the rollout if it has one, or else the services
+func stableAndCanaryDestinations(r *v1alpha1.Rollout) (string, string) {
+	if dRule := r.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule; dRule != nil {
+		return dRule.StableSubsetName, dRule.CanarySubsetName
+	}
+	return r.Spec.Strategy.Canary.StableService, r.Spec.Strategy.Canary.CanaryService
+}
+
 // Structs below describe fields within Istio's VirtualService that the Rollout needs to modify
 
 // Destination fields within the destination struct of the Virtual Service that the controller modifies
 type destination struct {
-	Host string `json:"host,omitempty"`
+	Host   string `json:"host,omitempty"`
+	Subset string `json:"subset,omitempty"`
+}
+
+// key returns the name the weights of the destination are looked up by: the subset when the rollout
+// references a DestinationRule and the destination has one, or else the host
+func (d destination) key(r *v1alpha1.Rollout) string {
+	if d.Subset != "" && r.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule != nil {
+		return d.Subset
+	}
+	return d.Host
 }
 
 // route fields within the route struct of the Virtual Service that the controller modifies
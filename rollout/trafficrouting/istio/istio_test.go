@@ -12,9 +12,11 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
 )
 
 func strToUnstructured(yamlStr string) *unstructured.Unstructured {
@@ -115,6 +117,34 @@ func TestReconcileWeightsBaseCase(t *testing.T) {
 	checkDestination(t, unmodifiedRoute, "canary", 0)
 }
 
+func TestReconcileWeightsWithExperimentDestinations(t *testing.T) {
+	r := &Reconciler{
+		rollout: rollout("stable", "canary", "vsvc", []string{"primary"}),
+	}
+	obj := strToUnstructured(regularVsvc)
+	modifedObj, modified, err := r.reconcileVirtualService(obj, 10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 20})
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	routes, _, _ := unstructured.NestedSlice(modifedObj.Object, "spec", "http")
+	route := routes[0].(map[string]interface{})
+	assert.Len(t, route["route"], 3)
+	checkDestination(t, route, "stable", 70)
+	checkDestination(t, route, "canary", 10)
+	checkDestination(t, route, "ex-baseline", 20)
+	// the destinations of the experiment are only added to the routes of the rollout
+	assert.Len(t, routes[1].(map[string]interface{})["route"], 2)
+
+	// the destination is removed once the experiment ends
+	modifedObj, modified, err = r.reconcileVirtualService(modifedObj, 10)
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	routes, _, _ = unstructured.NestedSlice(modifedObj.Object, "spec", "http")
+	route = routes[0].(map[string]interface{})
+	assert.Len(t, route["route"], 2)
+	checkDestination(t, route, "stable", 90)
+	checkDestination(t, route, "canary", 10)
+}
+
 func TestReconcileUpdateVirtualService(t *testing.T) {
 	obj := strToUnstructured(regularVsvc)
 	schema := runtime.NewScheme()
@@ -169,6 +199,267 @@ func TestReconcileVirtualServiceNotFound(t *testing.T) {
 	assert.Equal(t, "get", actions[0].GetVerb())
 }
 
+const subsetVsvc = `apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: vsvc
+  namespace: default
+spec:
+  hosts:
+  - istio-rollout.dev.argoproj.io
+  http:
+  - name: primary
+    route:
+    - destination:
+        host: guestbook
+        subset: stable
+      weight: 100
+    - destination:
+        host: guestbook
+        subset: canary
+      weight: 0`
+
+const destinationRule = `apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: dr
+  namespace: default
+spec:
+  host: guestbook
+  subsets:
+  - name: stable
+    labels:
+      rollouts-pod-template-hash: abc123
+  - name: canary
+    labels:
+      rollouts-pod-template-hash: abc123`
+
+func rolloutWithDestinationRule() *v1alpha1.Rollout {
+	ro := rollout("", "", "vsvc", []string{"primary"})
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule = &v1alpha1.IstioDestinationRule{
+		Name:             "dr",
+		CanarySubsetName: "canary",
+		StableSubsetName: "stable",
+	}
+	return ro
+}
+
+func TestReconcileWeightsWithSubsets(t *testing.T) {
+	r := &Reconciler{
+		rollout: rolloutWithDestinationRule(),
+	}
+	obj := strToUnstructured(subsetVsvc)
+	modifedObj, modified, err := r.reconcileVirtualService(obj, 10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 20})
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	routes, _, _ := unstructured.NestedSlice(modifedObj.Object, "spec", "http")
+	destinations := routes[0].(map[string]interface{})["route"].([]interface{})
+	assert.Len(t, destinations, 3)
+	assert.Equal(t, float64(70), destinations[0].(map[string]interface{})["weight"])
+	assert.Equal(t, float64(10), destinations[1].(map[string]interface{})["weight"])
+	checkDestination(t, routes[0].(map[string]interface{}), "ex-baseline", 20)
+}
+
+func rolloutWithHeaderRoute(headerRoute v1alpha1.SetHeaderRoute) *v1alpha1.Rollout {
+	ro := rollout("stable", "canary", "vsvc", []string{"primary"})
+	ro.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{{SetHeaderRoute: &headerRoute}}
+	return ro
+}
+
+func TestSetHeaderRoutes(t *testing.T) {
+	headerRoute := v1alpha1.SetHeaderRoute{
+		Name: "testers",
+		Match: []v1alpha1.HeaderRoutingMatch{
+			{HeaderName: "X-Canary", Value: v1alpha1.StringMatch{Exact: "true"}},
+			{CookieName: "beta", Value: v1alpha1.StringMatch{Prefix: "tester-"}},
+		},
+	}
+	obj := strToUnstructured(regularVsvc)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	r := NewReconciler(rolloutWithHeaderRoute(headerRoute), client, &record.FakeRecorder{}, "v1alpha3")
+
+	assert.Nil(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{headerRoute}))
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "update", actions[1].GetVerb())
+	updated := actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	routes, _, _ := unstructured.NestedSlice(updated.Object, "spec", "http")
+	assert.Len(t, routes, 3)
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, "testers", route["name"])
+	headers, _, _ := unstructured.NestedMap(route["match"].([]interface{})[0].(map[string]interface{}), "headers")
+	assert.Equal(t, map[string]interface{}{
+		"x-canary": map[string]interface{}{"exact": "true"},
+		"cookie":   map[string]interface{}{"regex": `^(.*?;\s*)?(beta=tester-[^;]*)(;.*)?$`},
+	}, headers)
+	destinations := route["route"].([]interface{})
+	assert.Len(t, destinations, 1)
+	host, _, _ := unstructured.NestedString(destinations[0].(map[string]interface{}), "destination", "host")
+	assert.Equal(t, "canary", host)
+	assert.Equal(t, "primary", routes[1].(map[string]interface{})["name"])
+
+	// the VirtualService is not updated when it has the header routes
+	client.ClearActions()
+	assert.Nil(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{headerRoute}))
+	assert.Len(t, client.Actions(), 1)
+
+	// the route is removed once the rollout no longer sets it
+	client.ClearActions()
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	actions = client.Actions()
+	assert.Len(t, actions, 2)
+	updated = actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	routes, _, _ = unstructured.NestedSlice(updated.Object, "spec", "http")
+	assert.Len(t, routes, 2)
+	assert.Equal(t, "primary", routes[0].(map[string]interface{})["name"])
+}
+
+func TestSetHeaderRoutesWithoutSteps(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := NewReconciler(rollout("stable", "canary", "vsvc", []string{"primary"}), client, &record.FakeRecorder{}, "v1alpha3")
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	assert.Len(t, client.Actions(), 0)
+}
+
+func TestSetHeaderRoutesWithSubsets(t *testing.T) {
+	headerRoute := v1alpha1.SetHeaderRoute{
+		Name:  "testers",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Regex: "true|yes"}}},
+	}
+	ro := rolloutWithDestinationRule()
+	ro.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{{SetHeaderRoute: &headerRoute}}
+	r := &Reconciler{rollout: ro}
+	obj := strToUnstructured(subsetVsvc)
+	modifiedObj, modified, err := r.reconcileHeaderRoutes(obj, map[string]bool{"testers": true}, []v1alpha1.SetHeaderRoute{headerRoute})
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	routes, _, _ := unstructured.NestedSlice(modifiedObj.Object, "spec", "http")
+	dest, _, _ := unstructured.NestedMap(routes[0].(map[string]interface{})["route"].([]interface{})[0].(map[string]interface{}), "destination")
+	assert.Equal(t, map[string]interface{}{"host": "guestbook", "subset": "canary"}, dest)
+}
+
+func TestSetHeaderRoutesInvalid(t *testing.T) {
+	headerRoute := v1alpha1.SetHeaderRoute{
+		Name: "primary",
+		Match: []v1alpha1.HeaderRoutingMatch{
+			{CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "true"}},
+			{CookieName: "tester", Value: v1alpha1.StringMatch{Exact: "true"}},
+		},
+	}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), strToUnstructured(regularVsvc))
+	r := NewReconciler(rolloutWithHeaderRoute(headerRoute), client, &record.FakeRecorder{}, "v1alpha3")
+	assert.EqualError(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{headerRoute}), "Header route 'primary' has the name of a route of the rollout")
+
+	headerRoute.Name = "testers"
+	r = NewReconciler(rolloutWithHeaderRoute(headerRoute), client, &record.FakeRecorder{}, "v1alpha3")
+	assert.EqualError(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{headerRoute}), "Header route 'testers' can only match a single cookie")
+}
+
+func TestSetMirrorRoute(t *testing.T) {
+	obj := strToUnstructured(regularVsvc)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	r := NewReconciler(rollout("stable", "canary", "vsvc", []string{"primary"}), client, &record.FakeRecorder{}, "v1alpha3")
+
+	assert.Nil(t, r.SetMirrorRoute(20))
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "update", actions[1].GetVerb())
+	updated := actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	routes, _, _ := unstructured.NestedSlice(updated.Object, "spec", "http")
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"host": "canary"}, route["mirror"])
+	assert.Equal(t, map[string]interface{}{"value": float64(20)}, route["mirrorPercentage"])
+	// only the routes of the rollout are mirrored
+	assert.NotContains(t, routes[1].(map[string]interface{}), "mirror")
+
+	// the VirtualService is not updated when the routes have the mirror
+	client.ClearActions()
+	assert.Nil(t, r.SetMirrorRoute(20))
+	assert.Len(t, client.Actions(), 1)
+
+	client.ClearActions()
+	assert.Nil(t, r.SetMirrorRoute(0))
+	actions = client.Actions()
+	assert.Len(t, actions, 2)
+	updated = actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	routes, _, _ = unstructured.NestedSlice(updated.Object, "spec", "http")
+	assert.NotContains(t, routes[0].(map[string]interface{}), "mirror")
+	assert.NotContains(t, routes[0].(map[string]interface{}), "mirrorPercentage")
+}
+
+func TestSetMirrorRouteWithSubsets(t *testing.T) {
+	r := &Reconciler{rollout: rolloutWithDestinationRule()}
+	modifiedObj, modified, err := r.reconcileMirrorRoute(strToUnstructured(subsetVsvc), 50)
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	routes, _, _ := unstructured.NestedSlice(modifiedObj.Object, "spec", "http")
+	assert.Equal(t, map[string]interface{}{"host": "guestbook", "subset": "canary"}, routes[0].(map[string]interface{})["mirror"])
+}
+
+func TestCookieRegex(t *testing.T) {
+	assert.Equal(t, `^(.*?;\s*)?(beta=always)(;.*)?$`, cookieRegex("beta", v1alpha1.StringMatch{Exact: "always"}))
+	assert.Equal(t, `^(.*?;\s*)?(beta=(?:a|b))(;.*)?$`, cookieRegex("beta", v1alpha1.StringMatch{Regex: "a|b"}))
+}
+
+func TestUpdateHash(t *testing.T) {
+	obj := strToUnstructured(destinationRule)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	r := NewReconciler(rolloutWithDestinationRule(), client, &record.FakeRecorder{}, "v1alpha3")
+
+	err := r.UpdateHash("def456", "abc123")
+	assert.Nil(t, err)
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "get", actions[0].GetVerb())
+	assert.Equal(t, "update", actions[1].GetVerb())
+	updated := actions[1].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	subsets, _, _ := unstructured.NestedSlice(updated.Object, "spec", "subsets")
+	stableHash, _, _ := unstructured.NestedString(subsets[0].(map[string]interface{}), "labels", v1alpha1.DefaultRolloutUniqueLabelKey)
+	canaryHash, _, _ := unstructured.NestedString(subsets[1].(map[string]interface{}), "labels", v1alpha1.DefaultRolloutUniqueLabelKey)
+	assert.Equal(t, "abc123", stableHash)
+	assert.Equal(t, "def456", canaryHash)
+
+	// the DestinationRule is not updated when the subsets already select the ReplicaSets
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	r = NewReconciler(rolloutWithDestinationRule(), client, &record.FakeRecorder{}, "v1alpha3")
+	assert.Nil(t, r.UpdateHash("abc123", ""))
+	assert.Len(t, client.Actions(), 1)
+
+	// nothing is done without a DestinationRule
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r = NewReconciler(rollout("stable", "canary", "vsvc", []string{"primary"}), client, &record.FakeRecorder{}, "v1alpha3")
+	assert.Nil(t, r.UpdateHash("def456", "abc123"))
+	assert.Len(t, client.Actions(), 0)
+}
+
+func TestUpdateHashDestinationRuleNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	r := NewReconciler(rolloutWithDestinationRule(), client, &record.FakeRecorder{}, "v1alpha3")
+	err := r.UpdateHash("def456", "abc123")
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestValidateDestinationRule(t *testing.T) {
+	obj := strToUnstructured(destinationRule)
+	ro := rolloutWithDestinationRule()
+	assert.NoError(t, ValidateDestinationRule(ro, obj))
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule.CanarySubsetName = "preview"
+	assert.EqualError(t, ValidateDestinationRule(ro, obj), "Subset 'preview' is not found")
+
+	unstructured.RemoveNestedField(obj.Object, "spec", "subsets")
+	assert.EqualError(t, ValidateDestinationRule(ro, obj), ".spec.subsets is not defined")
+}
+
+func TestValidateSubsets(t *testing.T) {
+	obj := strToUnstructured(subsetVsvc)
+	ro := rolloutWithDestinationRule()
+	assert.NoError(t, ValidateVirtualService(ro, obj))
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule.StableSubsetName = "baseline"
+	assert.EqualError(t, ValidateVirtualService(ro, obj), "Stable Subset 'baseline' not found in route")
+	ro.Spec.Strategy.Canary.TrafficRouting.Istio.DestinationRule.CanarySubsetName = "preview"
+	assert.EqualError(t, ValidateVirtualService(ro, obj), "Canary Subset 'preview' not found in route")
+}
+
 func TestType(t *testing.T) {
 	schema := runtime.NewScheme()
 	client := fake.NewSimpleDynamicClient(schema)
@@ -241,7 +532,7 @@ func TestValidateHTTPRoutes(t *testing.T) {
 	}}
 	rollout := newRollout([]string{"test"})
 	err := validateHTTPRoutes(rollout, httpRoutes)
-	assert.Equal(t, fmt.Errorf("Route 'test' does not have exactly two routes"), err)
+	assert.Equal(t, fmt.Errorf("Canary Service 'canary' not found in route"), err)
 
 	httpRoutes[0].Route = []route{{
 		Destination: destination{
@@ -271,7 +562,7 @@ func TestValidateHosts(t *testing.T) {
 		}},
 	}
 	err := validateHosts(hr, "stable", "canary")
-	assert.Equal(t, fmt.Errorf("Route 'test' does not have exactly two routes"), err)
+	assert.Equal(t, fmt.Errorf("Canary Service 'canary' not found in route"), err)
 
 	hr.Route = []route{{
 		Destination: destination{
@@ -316,3 +607,13 @@ func TestGetRolloutVirtualServiceKeys(t *testing.T) {
 	assert.Len(t, keys, 1)
 	assert.Equal(t, keys[0], "default/test")
 }
+
+func TestValidateVirtualService(t *testing.T) {
+	obj := strToUnstructured(regularVsvc)
+	assert.NoError(t, ValidateVirtualService(rollout("stable", "canary", "vsvc", []string{"primary", "secondary"}), obj))
+	assert.EqualError(t, ValidateVirtualService(rollout("stable", "canary", "vsvc", []string{"tertiary"}), obj), "Route 'tertiary' is not found")
+	assert.EqualError(t, ValidateVirtualService(rollout("stable", "preview", "vsvc", []string{"primary"}), obj), "Canary Service 'preview' not found in route")
+
+	unstructured.RemoveNestedField(obj.Object, "spec", "http")
+	assert.EqualError(t, ValidateVirtualService(rollout("stable", "canary", "vsvc", []string{"primary"}), obj), ".spec.http is not defined")
+}
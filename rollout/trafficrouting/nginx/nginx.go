@@ -0,0 +1,251 @@
+package nginx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "Nginx"
+	// DefaultAnnotationPrefix is the prefix of the annotations of the Nginx Ingress Controller
+	DefaultAnnotationPrefix = "nginx.ingress.kubernetes.io"
+
+	// ingressClassAnnotation selects the ingress controller of an Ingress
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// cookieValue is the value of the canary cookie routing a request to the canary
+	cookieValue = "always"
+)
+
+// NewReconciler returns a reconciler struct that brings the canary Ingress of the rollout into the desired state
+func NewReconciler(r *v1alpha1.Rollout, client kubernetes.Interface, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		rollout:  r,
+		log:      logutil.WithRollout(r),
+		client:   client,
+		recorder: recorder,
+	}
+}
+
+// Reconciler holds required fields to reconcile Nginx Ingresses
+type Reconciler struct {
+	rollout     *v1alpha1.Rollout
+	log         *logrus.Entry
+	client      kubernetes.Interface
+	recorder    record.EventRecorder
+	headerRoute *v1alpha1.SetHeaderRoute
+}
+
+// Type indicates this reconciler is an Nginx reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// UpdateHash is a no-op for Nginx since the canary Ingress routes to the canary service
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	return nil
+}
+
+// SetHeaderRoutes records the header route the canary Ingress routes to the canary, which is applied
+// by Reconcile together with the weight. Nginx routes a single header or cookie to the canary Ingress.
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	r.headerRoute = nil
+	if len(headerRoutes) == 0 {
+		return nil
+	}
+	if len(headerRoutes) > 1 || len(headerRoutes[0].Match) > 1 {
+		return errors.New("the Nginx traffic router supports a single header route with a single match")
+	}
+	match := headerRoutes[0].Match[0]
+	if match.CookieName != "" && match.Value.Exact != cookieValue {
+		return fmt.Errorf("the Nginx traffic router only matches cookies with the exact value '%s'", cookieValue)
+	}
+	r.headerRoute = &headerRoutes[0]
+	return nil
+}
+
+// SetMirrorRoute fails if the rollout mirrors requests since the Nginx traffic router can not mirror a
+// percentage of the requests
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	if percentage > 0 {
+		return errors.New("the Nginx traffic router does not support mirror routes")
+	}
+	return nil
+}
+
+// Reconcile creates or updates the canary Ingress of the rollout to send the desired weight of the
+// traffic to the canary service. The canary Ingress is deleted when the canary receives no traffic and
+// has no header route, so it is cleaned up once the rollout completes or aborts.
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	if len(additionalDestinations) > 0 {
+		return errors.New("the Nginx traffic router cannot send traffic to the services of experiment templates")
+	}
+	ingresses := r.client.ExtensionsV1beta1().Ingresses(r.rollout.Namespace)
+	name := CanaryIngressName(r.rollout)
+	existing, err := ingresses.Get(name, metav1.GetOptions{})
+	found := err == nil
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if found && !metav1.IsControlledBy(existing, r.rollout) {
+		msg := fmt.Sprintf("Ingress `%s` is not controlled by Rollout `%s`", name, r.rollout.Name)
+		r.recorder.Event(r.rollout, corev1.EventTypeWarning, "CanaryIngressNotControlled", msg)
+		return errors.New(msg)
+	}
+
+	if desiredWeight == 0 && r.headerRoute == nil {
+		if !found {
+			return nil
+		}
+		msg := fmt.Sprintf("Deleting canary Ingress `%s`", name)
+		r.log.Info(msg)
+		r.recorder.Event(r.rollout, corev1.EventTypeNormal, "DeletingCanaryIngress", msg)
+		err = ingresses.Delete(name, &metav1.DeleteOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	primaryName := r.rollout.Spec.Strategy.Canary.TrafficRouting.Nginx.PrimaryIngress
+	primary, err := ingresses.Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Ingress `%s` not found", primaryName)
+			r.recorder.Event(r.rollout, corev1.EventTypeWarning, "IngressNotFound", msg)
+		}
+		return err
+	}
+	desired, err := canaryIngress(r.rollout, primary, desiredWeight, r.headerRoute)
+	if err != nil {
+		return fmt.Errorf("Ingress `%s` is incompatible: %v", primaryName, err)
+	}
+
+	if !found {
+		msg := fmt.Sprintf("Creating canary Ingress `%s` with desiredWeight '%d'", name, desiredWeight)
+		r.log.Info(msg)
+		r.recorder.Event(r.rollout, corev1.EventTypeNormal, "CreatingCanaryIngress", msg)
+		_, err = ingresses.Create(desired)
+		return err
+	}
+	if reflect.DeepEqual(existing.Spec, desired.Spec) && reflect.DeepEqual(existing.Annotations, desired.Annotations) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Annotations = desired.Annotations
+	updated.Spec = desired.Spec
+	msg := fmt.Sprintf("Updating canary Ingress `%s` to desiredWeight '%d'", name, desiredWeight)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingCanaryIngress", msg)
+	_, err = ingresses.Update(updated)
+	return err
+}
+
+// CanaryIngressName returns the name of the canary Ingress the controller creates for the rollout
+func CanaryIngressName(r *v1alpha1.Rollout) string {
+	return fmt.Sprintf("%s-%s-canary", r.Name, r.Spec.Strategy.Canary.TrafficRouting.Nginx.PrimaryIngress)
+}
+
+// annotationPrefix returns the prefix of the canary annotations
+func annotationPrefix(r *v1alpha1.Rollout) string {
+	if prefix := r.Spec.Strategy.Canary.TrafficRouting.Nginx.AnnotationPrefix; prefix != "" {
+		return prefix
+	}
+	return DefaultAnnotationPrefix
+}
+
+// canaryIngress returns the canary Ingress of the rollout, which copies the rules of the primary
+// Ingress routing to the stable service and routes them to the canary service instead
+func canaryIngress(r *v1alpha1.Rollout, primary *extensionsv1beta1.Ingress, desiredWeight int32, headerRoute *v1alpha1.SetHeaderRoute) (*extensionsv1beta1.Ingress, error) {
+	canary := r.Spec.Strategy.Canary
+	ingress := &extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CanaryIngressName(r),
+			Namespace:       r.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(r, v1alpha1.SchemeGroupVersion.WithKind("Rollout"))},
+			Annotations: map[string]string{
+				annotationPrefix(r) + "/canary":        "true",
+				annotationPrefix(r) + "/canary-weight": strconv.Itoa(int(desiredWeight)),
+			},
+		},
+		Spec: extensionsv1beta1.IngressSpec{
+			TLS: primary.Spec.TLS,
+		},
+	}
+	if headerRoute != nil {
+		for key, value := range headerRouteAnnotations(annotationPrefix(r), headerRoute.Match[0]) {
+			ingress.Annotations[key] = value
+		}
+	}
+	if class, ok := primary.Annotations[ingressClassAnnotation]; ok {
+		ingress.Annotations[ingressClassAnnotation] = class
+	}
+	if primary.Spec.Backend != nil && primary.Spec.Backend.ServiceName == canary.StableService {
+		backend := *primary.Spec.Backend
+		backend.ServiceName = canary.CanaryService
+		ingress.Spec.Backend = &backend
+	}
+	for _, rule := range primary.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		var paths []extensionsv1beta1.HTTPIngressPath
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName == canary.StableService {
+				path.Backend.ServiceName = canary.CanaryService
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) > 0 {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, extensionsv1beta1.IngressRule{
+				Host: rule.Host,
+				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{Paths: paths},
+				},
+			})
+		}
+	}
+	if ingress.Spec.Backend == nil && len(ingress.Spec.Rules) == 0 {
+		return nil, fmt.Errorf("no backend with serviceName '%s' found", canary.StableService)
+	}
+	return ingress, nil
+}
+
+// ValidateIngress ensures the primary Ingress routes traffic to the stable service
+func ValidateIngress(r *v1alpha1.Rollout, primary *extensionsv1beta1.Ingress) error {
+	_, err := canaryIngress(r, primary, 0, nil)
+	return err
+}
+
+// headerRouteAnnotations returns the canary annotations routing the requests with the header or cookie
+// of the match to the canary
+func headerRouteAnnotations(prefix string, match v1alpha1.HeaderRoutingMatch) map[string]string {
+	if match.CookieName != "" {
+		return map[string]string{prefix + "/canary-by-cookie": match.CookieName}
+	}
+	annotations := map[string]string{prefix + "/canary-by-header": match.HeaderName}
+	switch {
+	case match.Value.Exact != "":
+		annotations[prefix+"/canary-by-header-value"] = match.Value.Exact
+	case match.Value.Prefix != "":
+		annotations[prefix+"/canary-by-header-pattern"] = "^" + regexp.QuoteMeta(match.Value.Prefix)
+	default:
+		annotations[prefix+"/canary-by-header-pattern"] = match.Value.Regex
+	}
+	return annotations
+}
@@ -0,0 +1,231 @@
+package nginx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+)
+
+func rollout(nginxConfig *v1alpha1.NginxTrafficRouting) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Nginx: nginxConfig,
+					},
+				},
+			},
+		},
+	}
+}
+
+func ingress(name string, backendServices ...string) *extensionsv1beta1.Ingress {
+	var paths []extensionsv1beta1.HTTPIngressPath
+	for _, svc := range backendServices {
+		paths = append(paths, extensionsv1beta1.HTTPIngressPath{
+			Path: "/" + svc,
+			Backend: extensionsv1beta1.IngressBackend{
+				ServiceName: svc,
+				ServicePort: intstr.FromInt(80),
+			},
+		})
+	}
+	return &extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{"kubernetes.io/ingress.class": "nginx"},
+		},
+		Spec: extensionsv1beta1.IngressSpec{
+			Rules: []extensionsv1beta1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{Paths: paths},
+				},
+			}},
+		},
+	}
+}
+
+func TestType(t *testing.T) {
+	r := NewReconciler(rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"}), fake.NewSimpleClientset(), &record.FakeRecorder{})
+	assert.Equal(t, Type, r.Type())
+	assert.Nil(t, r.UpdateHash("def456", "abc123"))
+	assert.Nil(t, r.SetMirrorRoute(0))
+	assert.EqualError(t, r.SetMirrorRoute(10), "the Nginx traffic router does not support mirror routes")
+}
+
+func TestCanaryIngress(t *testing.T) {
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	ing, err := canaryIngress(ro, ingress("ingress", "stable", "other"), 10, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "rollout-ingress-canary", ing.Name)
+	assert.True(t, metav1.IsControlledBy(ing, ro))
+	assert.Equal(t, map[string]string{
+		"kubernetes.io/ingress.class":               "nginx",
+		"nginx.ingress.kubernetes.io/canary":        "true",
+		"nginx.ingress.kubernetes.io/canary-weight": "10",
+	}, ing.Annotations)
+	assert.Len(t, ing.Spec.Rules, 1)
+	assert.Equal(t, "example.com", ing.Spec.Rules[0].Host)
+	paths := ing.Spec.Rules[0].HTTP.Paths
+	assert.Len(t, paths, 1)
+	assert.Equal(t, "/stable", paths[0].Path)
+	assert.Equal(t, "canary", paths[0].Backend.ServiceName)
+}
+
+func TestCanaryIngressAnnotationPrefix(t *testing.T) {
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress", AnnotationPrefix: "example.nginx.com"})
+	ing, err := canaryIngress(ro, ingress("ingress", "stable"), 10, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "true", ing.Annotations["example.nginx.com/canary"])
+	assert.Equal(t, "10", ing.Annotations["example.nginx.com/canary-weight"])
+}
+
+func TestReconcileCreatesAndUpdatesCanaryIngress(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+
+	assert.Nil(t, r.Reconcile(10))
+	created, err := client.ExtensionsV1beta1().Ingresses("default").Get("rollout-ingress-canary", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "10", created.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+
+	// the canary Ingress is not updated when it has the desired weight
+	client.ClearActions()
+	assert.Nil(t, r.Reconcile(10))
+	for _, action := range client.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+
+	assert.Nil(t, r.Reconcile(20))
+	updated, err := client.ExtensionsV1beta1().Ingresses("default").Get("rollout-ingress-canary", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "20", updated.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+}
+
+func TestReconcileDeletesCanaryIngress(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	assert.Nil(t, r.Reconcile(10))
+
+	assert.Nil(t, r.Reconcile(0))
+	_, err := client.ExtensionsV1beta1().Ingresses("default").Get("rollout-ingress-canary", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// nothing to clean up once the canary Ingress is deleted
+	client.ClearActions()
+	assert.Nil(t, r.Reconcile(0))
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestReconcileHeaderRoute(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	headerRoute := v1alpha1.SetHeaderRoute{
+		Name:  "testers",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}},
+	}
+
+	// the canary Ingress serves the header route at a weight of 0
+	assert.Nil(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{headerRoute}))
+	assert.Nil(t, r.Reconcile(0))
+	created, err := client.ExtensionsV1beta1().Ingresses("default").Get("rollout-ingress-canary", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "0", created.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+	assert.Equal(t, "x-canary", created.Annotations["nginx.ingress.kubernetes.io/canary-by-header"])
+	assert.Equal(t, "true", created.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"])
+
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	assert.Nil(t, r.Reconcile(0))
+	_, err = client.ExtensionsV1beta1().Ingresses("default").Get("rollout-ingress-canary", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestSetHeaderRoutesInvalid(t *testing.T) {
+	r := NewReconciler(rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"}), fake.NewSimpleClientset(), &record.FakeRecorder{})
+	header := v1alpha1.HeaderRoutingMatch{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}
+	err := r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{{Name: "testers", Match: []v1alpha1.HeaderRoutingMatch{header, header}}})
+	assert.EqualError(t, err, "the Nginx traffic router supports a single header route with a single match")
+
+	cookie := v1alpha1.HeaderRoutingMatch{CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "true"}}
+	err = r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{{Name: "testers", Match: []v1alpha1.HeaderRoutingMatch{cookie}}})
+	assert.EqualError(t, err, "the Nginx traffic router only matches cookies with the exact value 'always'")
+}
+
+func TestHeaderRouteAnnotations(t *testing.T) {
+	prefix := DefaultAnnotationPrefix
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/canary-by-cookie": "beta",
+	}, headerRouteAnnotations(prefix, v1alpha1.HeaderRoutingMatch{CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "always"}}))
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/canary-by-header":         "x-canary",
+		"nginx.ingress.kubernetes.io/canary-by-header-pattern": "^tester\\.",
+	}, headerRouteAnnotations(prefix, v1alpha1.HeaderRoutingMatch{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Prefix: "tester."}}))
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/canary-by-header":         "x-canary",
+		"nginx.ingress.kubernetes.io/canary-by-header-pattern": "true|yes",
+	}, headerRouteAnnotations(prefix, v1alpha1.HeaderRoutingMatch{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Regex: "true|yes"}}))
+}
+
+func TestReconcileCanaryIngressNotControlled(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable"), ingress("rollout-ingress-canary", "canary"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	assert.EqualError(t, r.Reconcile(10), "Ingress `rollout-ingress-canary` is not controlled by Rollout `rollout`")
+	assert.EqualError(t, r.Reconcile(0), "Ingress `rollout-ingress-canary` is not controlled by Rollout `rollout`")
+}
+
+func TestReconcilePrimaryIngressNotFound(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestReconcileInvalidPrimaryIngress(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "other"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.EqualError(t, err, "Ingress `ingress` is incompatible: no backend with serviceName 'stable' found")
+}
+
+func TestReconcileAdditionalDestinations(t *testing.T) {
+	client := fake.NewSimpleClientset(ingress("ingress", "stable"))
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 10})
+	assert.EqualError(t, err, "the Nginx traffic router cannot send traffic to the services of experiment templates")
+	assert.Len(t, client.Actions(), 0)
+}
+
+func TestValidateIngress(t *testing.T) {
+	ro := rollout(&v1alpha1.NginxTrafficRouting{PrimaryIngress: "ingress"})
+	assert.NoError(t, ValidateIngress(ro, ingress("ingress", "stable")))
+	assert.Error(t, ValidateIngress(ro, ingress("ingress", "other")))
+
+	defaultBackend := ingress("ingress")
+	defaultBackend.Spec.Backend = &extensionsv1beta1.IngressBackend{ServiceName: "stable", ServicePort: intstr.FromInt(80)}
+	assert.NoError(t, ValidateIngress(ro, defaultBackend))
+}
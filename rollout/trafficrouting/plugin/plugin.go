@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	pluginutil "github.com/argoproj/argo-rollouts/utils/plugin"
+)
+
+const (
+	// Type holds this controller type
+	Type = "Plugin"
+
+	// MethodSetWeight, MethodUpdateHash, MethodSetHeaderRoutes, MethodSetMirrorRoute and
+	// MethodVerifyWeight are the methods of the traffic router called on a plugin, at
+	// <address>/v1/<method>
+	MethodSetWeight       = "setWeight"
+	MethodUpdateHash      = "updateHash"
+	MethodSetHeaderRoutes = "setHeaderRoutes"
+	MethodSetMirrorRoute  = "setMirrorRoute"
+	MethodVerifyWeight    = "verifyWeight"
+)
+
+// Request is the body of a call to a plugin. The configuration of the plugin is in the traffic
+// routing of the rollout.
+type Request struct {
+	Rollout *v1alpha1.Rollout `json:"rollout"`
+	// DesiredWeight is the weight of the traffic sent to the canary service
+	DesiredWeight int32 `json:"desiredWeight,omitempty"`
+	// AdditionalDestinations are the services, besides the stable and canary services, which receive
+	// a share of the traffic
+	AdditionalDestinations []trafficrouting.WeightDestination `json:"additionalDestinations,omitempty"`
+	// CanaryHash and StableHash are the pod template hashes of the canary and stable ReplicaSets
+	CanaryHash string `json:"canaryHash,omitempty"`
+	StableHash string `json:"stableHash,omitempty"`
+	// HeaderRoutes are the header routes sending requests to the canary service
+	HeaderRoutes []v1alpha1.SetHeaderRoute `json:"headerRoutes,omitempty"`
+	// MirrorPercentage is the percentage of the requests mirrored to the canary service
+	MirrorPercentage int32 `json:"mirrorPercentage,omitempty"`
+}
+
+// Response is the body of the response of a plugin
+type Response struct {
+	// Verified is the result of a weight verification. Plugins which do not verify the weights of
+	// the data plane leave it unset, and the weight is considered verified.
+	Verified *bool `json:"verified,omitempty"`
+	// Error fails the call
+	Error string `json:"error,omitempty"`
+}
+
+// Plugins returns the addresses of the plugins declared in the controller configuration by name
+func Plugins() (map[string]string, error) {
+	return pluginutil.Addresses(configutil.TrafficRouterPluginsKey)
+}
+
+// Reconciler dispatches the calls of the rollout controller to a traffic router plugin. Plugins run
+// out of process, typically as a sidecar of the controller, and are called over HTTP or a unix socket.
+type Reconciler struct {
+	rollout *v1alpha1.Rollout
+	name    string
+	address string
+	client  *http.Client
+	log     *logrus.Entry
+}
+
+// NewReconciler returns a reconciler calling the plugin of the traffic routing of the rollout
+func NewReconciler(r *v1alpha1.Rollout) (*Reconciler, error) {
+	name := r.Spec.Strategy.Canary.TrafficRouting.Plugin.Name
+	plugins, err := Plugins()
+	if err != nil {
+		return nil, err
+	}
+	address, ok := plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("traffic router plugin '%s' is not declared in %s", name, configutil.TrafficRouterPluginsKey)
+	}
+	client, address, err := pluginutil.NewClient(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Reconciler{
+		rollout: r,
+		name:    name,
+		address: address,
+		client:  client,
+		log:     logutil.WithRollout(r).WithField("plugin", name),
+	}, nil
+}
+
+// Type indicates this reconciler is a plugin reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// Reconcile asks the plugin to send the desired weight of the traffic to the canary service
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	_, err := r.call(MethodSetWeight, Request{DesiredWeight: desiredWeight, AdditionalDestinations: additionalDestinations})
+	return err
+}
+
+// UpdateHash passes the pod template hashes of the canary and stable ReplicaSets to the plugin
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	_, err := r.call(MethodUpdateHash, Request{CanaryHash: canaryHash, StableHash: stableHash})
+	return err
+}
+
+// SetHeaderRoutes asks the plugin to send the requests matching the header routes to the canary service
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	_, err := r.call(MethodSetHeaderRoutes, Request{HeaderRoutes: headerRoutes})
+	return err
+}
+
+// SetMirrorRoute asks the plugin to mirror the percentage of the requests to the canary service
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	_, err := r.call(MethodSetMirrorRoute, Request{MirrorPercentage: percentage})
+	return err
+}
+
+// VerifyWeight asks the plugin whether the data plane sends the desired weight to the canary
+func (r *Reconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	res, err := r.call(MethodVerifyWeight, Request{DesiredWeight: desiredWeight})
+	if err != nil {
+		return false, err
+	}
+	return res.Verified == nil || *res.Verified, nil
+}
+
+// call posts the request to the method of the plugin and returns its response
+func (r *Reconciler) call(method string, request Request) (*Response, error) {
+	request.Rollout = r.rollout
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.client.Post(r.address+"/v1/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' could not be reached: %v", r.name, err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var res Response
+	if err := json.Unmarshal(data, &res); err != nil {
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return nil, fmt.Errorf("plugin '%s' %s failed with status code %d", r.name, method, response.StatusCode)
+		}
+		return nil, fmt.Errorf("could not parse the response of plugin '%s': %v", r.name, err)
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("plugin '%s' %s failed: %s", r.name, method, res.Error)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("plugin '%s' %s failed with status code %d", r.name, method, response.StatusCode)
+	}
+	r.log.Debugf("Plugin %s succeeded", method)
+	return &res, nil
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+func rollout() *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						Plugin: &v1alpha1.PluginTrafficRouting{Name: "acme/mesh", Config: `{"route": "checkout"}`},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newPluginHandler returns a handler of the plugin protocol answering every method with the function
+func newPluginHandler(t *testing.T, handle func(method string, request Request) Response) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		var request Request
+		assert.NoError(t, json.Unmarshal(body, &request))
+		response := handle(filepath.Base(req.URL.Path), request)
+		if response.Error != "" {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		assert.NoError(t, json.NewEncoder(rw).Encode(response))
+	})
+}
+
+func newReconciler(t *testing.T, address string) *Reconciler {
+	configutil.SetDefaults(map[string]string{configutil.TrafficRouterPluginsKey: "acme/mesh=" + address})
+	defer configutil.SetDefaults(nil)
+	r, err := NewReconciler(rollout())
+	assert.NoError(t, err)
+	return r
+}
+
+func TestType(t *testing.T) {
+	r := newReconciler(t, "http://localhost:8090")
+	assert.Equal(t, Type, r.Type())
+}
+
+func TestDispatch(t *testing.T) {
+	requests := map[string]Request{}
+	server := httptest.NewServer(newPluginHandler(t, func(method string, request Request) Response {
+		requests[method] = request
+		return Response{}
+	}))
+	defer server.Close()
+	r := newReconciler(t, server.URL)
+
+	assert.NoError(t, r.UpdateHash("def456", "abc123"))
+	headerRoutes := []v1alpha1.SetHeaderRoute{{Name: "testers"}}
+	assert.NoError(t, r.SetHeaderRoutes(headerRoutes))
+	assert.NoError(t, r.SetMirrorRoute(20))
+	assert.NoError(t, r.Reconcile(10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 5}))
+	verified, err := r.VerifyWeight(10)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	assert.Len(t, requests, 5)
+	for _, request := range requests {
+		assert.Equal(t, "rollout", request.Rollout.Name)
+		assert.Equal(t, `{"route": "checkout"}`, request.Rollout.Spec.Strategy.Canary.TrafficRouting.Plugin.Config)
+	}
+	assert.Equal(t, "def456", requests[MethodUpdateHash].CanaryHash)
+	assert.Equal(t, "abc123", requests[MethodUpdateHash].StableHash)
+	assert.Equal(t, headerRoutes, requests[MethodSetHeaderRoutes].HeaderRoutes)
+	assert.Equal(t, int32(20), requests[MethodSetMirrorRoute].MirrorPercentage)
+	assert.Equal(t, int32(10), requests[MethodSetWeight].DesiredWeight)
+	assert.Equal(t, []trafficrouting.WeightDestination{{ServiceName: "ex-baseline", Weight: 5}}, requests[MethodSetWeight].AdditionalDestinations)
+	assert.Equal(t, int32(10), requests[MethodVerifyWeight].DesiredWeight)
+}
+
+func TestVerifyWeight(t *testing.T) {
+	verified := false
+	server := httptest.NewServer(newPluginHandler(t, func(method string, request Request) Response {
+		return Response{Verified: &verified}
+	}))
+	defer server.Close()
+	r := newReconciler(t, server.URL)
+
+	result, err := r.VerifyWeight(10)
+	assert.NoError(t, err)
+	assert.False(t, result)
+
+	verified = true
+	result, err = r.VerifyWeight(10)
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestPluginErrors(t *testing.T) {
+	server := httptest.NewServer(newPluginHandler(t, func(method string, request Request) Response {
+		return Response{Error: "route not found"}
+	}))
+	defer server.Close()
+	r := newReconciler(t, server.URL)
+	assert.EqualError(t, r.Reconcile(10), "plugin 'acme/mesh' setWeight failed: route not found")
+	verified, err := r.VerifyWeight(10)
+	assert.EqualError(t, err, "plugin 'acme/mesh' verifyWeight failed: route not found")
+	assert.False(t, verified)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	r = newReconciler(t, notFound.URL)
+	assert.EqualError(t, r.SetMirrorRoute(0), "plugin 'acme/mesh' setMirrorRoute failed with status code 404")
+
+	r = newReconciler(t, "http://127.0.0.1:1")
+	err = r.UpdateHash("def456", "abc123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin 'acme/mesh' could not be reached")
+}
+
+func TestNewReconciler(t *testing.T) {
+	_, err := NewReconciler(rollout())
+	assert.EqualError(t, err, "traffic router plugin 'acme/mesh' is not declared in trafficRouters.plugins")
+
+	configutil.SetDefaults(map[string]string{configutil.TrafficRouterPluginsKey: "acme/mesh=localhost:8090"})
+	_, err = NewReconciler(rollout())
+	assert.EqualError(t, err, "plugin address 'localhost:8090' must start with http://, https:// or unix://")
+	configutil.SetDefaults(nil)
+
+	configutil.SetDefaults(map[string]string{configutil.TrafficRouterPluginsKey: "acme/other=http://localhost:8091, acme/mesh = https://mesh.example.com/"})
+	defer configutil.SetDefaults(nil)
+	r, err := NewReconciler(rollout())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://mesh.example.com", r.address)
+}
@@ -0,0 +1,179 @@
+package smi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// Type holds this controller type
+	Type = "SMI"
+	// DefaultAPIVersion is the apiVersion of the TrafficSplits when the controller configuration does
+	// not set one
+	DefaultAPIVersion = "v1alpha2"
+
+	trafficSplitGroup = "split.smi-spec.io"
+	trafficSplitKind  = "TrafficSplit"
+)
+
+// NewReconciler returns a reconciler struct that brings the TrafficSplit of the rollout into the desired state
+func NewReconciler(r *v1alpha1.Rollout, client dynamic.Interface, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		rollout:  r,
+		log:      logutil.WithRollout(r),
+		client:   client,
+		recorder: recorder,
+	}
+}
+
+// Reconciler holds required fields to reconcile SMI resources
+type Reconciler struct {
+	rollout  *v1alpha1.Rollout
+	log      *logrus.Entry
+	client   dynamic.Interface
+	recorder record.EventRecorder
+}
+
+// Type indicates this reconciler is an SMI reconciler
+func (r *Reconciler) Type() string {
+	return Type
+}
+
+// UpdateHash is a no-op for SMI since the backends of the TrafficSplit are the stable and canary services
+func (r *Reconciler) UpdateHash(canaryHash, stableHash string) error {
+	return nil
+}
+
+// SetHeaderRoutes fails if the rollout has header routes since TrafficSplits only split the traffic by weight
+func (r *Reconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	if len(headerRoutes) > 0 {
+		return errors.New("the SMI traffic router does not support header routes")
+	}
+	return nil
+}
+
+// SetMirrorRoute fails if the rollout mirrors requests since the SMI traffic router can not mirror a
+// percentage of the requests
+func (r *Reconciler) SetMirrorRoute(percentage int32) error {
+	if percentage > 0 {
+		return errors.New("the SMI traffic router does not support mirror routes")
+	}
+	return nil
+}
+
+// Reconcile creates the TrafficSplit of the rollout, or updates its backends to the desired weights
+func (r *Reconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
+	name := TrafficSplitName(r.rollout)
+	client := r.client.Resource(gvr()).Namespace(r.rollout.Namespace)
+	desired := r.desiredTrafficSplit(desiredWeight, additionalDestinations...)
+
+	existing, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		msg := fmt.Sprintf("Creating TrafficSplit `%s` with desiredWeight '%d'", name, desiredWeight)
+		r.log.Info(msg)
+		r.recorder.Event(r.rollout, corev1.EventTypeNormal, "CreatingTrafficSplit", msg)
+		_, err = client.Create(desired, metav1.CreateOptions{})
+		return err
+	}
+	if !metav1.IsControlledBy(existing, r.rollout) {
+		msg := fmt.Sprintf("TrafficSplit `%s` is not controlled by Rollout `%s`", name, r.rollout.Name)
+		r.recorder.Event(r.rollout, corev1.EventTypeWarning, "TrafficSplitNotControlled", msg)
+		return errors.New(msg)
+	}
+	modified, err := specModified(existing, desired)
+	if err != nil || !modified {
+		return err
+	}
+	updated := existing.DeepCopy()
+	updated.Object["spec"] = desired.Object["spec"]
+	msg := fmt.Sprintf("Updating TrafficSplit `%s` to desiredWeight '%d'", name, desiredWeight)
+	r.log.Info(msg)
+	r.recorder.Event(r.rollout, corev1.EventTypeNormal, "UpdatingTrafficSplit", msg)
+	_, err = client.Update(updated, metav1.UpdateOptions{})
+	return err
+}
+
+// desiredTrafficSplit returns the TrafficSplit sending the desired weight of the traffic of the root
+// service to the canary service, the weights of the additional destinations to their services, and
+// the rest to the stable service
+func (r *Reconciler) desiredTrafficSplit(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) *unstructured.Unstructured {
+	canary := r.rollout.Spec.Strategy.Canary
+	stableWeight := 100 - desiredWeight
+	backends := []interface{}{
+		map[string]interface{}{"service": canary.CanaryService, "weight": int64(desiredWeight)},
+	}
+	for _, d := range additionalDestinations {
+		backends = append(backends, map[string]interface{}{"service": d.ServiceName, "weight": int64(d.Weight)})
+		stableWeight -= d.Weight
+	}
+	backends = append(backends, map[string]interface{}{"service": canary.StableService, "weight": int64(stableWeight)})
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"service":  RootService(r.rollout),
+			"backends": backends,
+		},
+	}}
+	obj.SetAPIVersion(fmt.Sprintf("%s/%s", trafficSplitGroup, APIVersion()))
+	obj.SetKind(trafficSplitKind)
+	obj.SetName(TrafficSplitName(r.rollout))
+	obj.SetNamespace(r.rollout.Namespace)
+	obj.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(r.rollout, v1alpha1.SchemeGroupVersion.WithKind("Rollout"))})
+	return obj
+}
+
+// specModified returns true if the spec of the existing TrafficSplit differs from the desired one
+func specModified(existing, desired *unstructured.Unstructured) (bool, error) {
+	existingSpec, err := json.Marshal(existing.Object["spec"])
+	if err != nil {
+		return false, err
+	}
+	desiredSpec, err := json.Marshal(desired.Object["spec"])
+	if err != nil {
+		return false, err
+	}
+	return string(existingSpec) != string(desiredSpec), nil
+}
+
+// TrafficSplitName returns the name of the TrafficSplit of the rollout
+func TrafficSplitName(r *v1alpha1.Rollout) string {
+	if name := r.Spec.Strategy.Canary.TrafficRouting.SMI.TrafficSplitName; name != "" {
+		return name
+	}
+	return r.Name
+}
+
+// RootService returns the service whose traffic the TrafficSplit of the rollout splits
+func RootService(r *v1alpha1.Rollout) string {
+	if rootService := r.Spec.Strategy.Canary.TrafficRouting.SMI.RootService; rootService != "" {
+		return rootService
+	}
+	return r.Spec.Strategy.Canary.StableService
+}
+
+// APIVersion returns the apiVersion of the TrafficSplits managed by the controller
+func APIVersion() string {
+	return configutil.Get().GetString(configutil.SMIAPIVersionKey, DefaultAPIVersion)
+}
+
+func gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: trafficSplitGroup, Version: APIVersion(), Resource: "trafficsplits"}
+}
@@ -0,0 +1,117 @@
+package smi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+func rollout(smi *v1alpha1.SMITrafficRouting) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+			UID:       "rollout-uid",
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					StableService: "stable",
+					CanaryService: "canary",
+					TrafficRouting: &v1alpha1.RolloutTrafficRouting{
+						SMI: smi,
+					},
+				},
+			},
+		},
+	}
+}
+
+func backends(obj *unstructured.Unstructured) map[string]int64 {
+	weights := map[string]int64{}
+	items, _, _ := unstructured.NestedSlice(obj.Object, "spec", "backends")
+	for _, item := range items {
+		backend := item.(map[string]interface{})
+		weights[backend["service"].(string)] = backend["weight"].(int64)
+	}
+	return weights
+}
+
+func TestType(t *testing.T) {
+	r := NewReconciler(rollout(&v1alpha1.SMITrafficRouting{}), fake.NewSimpleDynamicClient(runtime.NewScheme()), &record.FakeRecorder{})
+	assert.Equal(t, Type, r.Type())
+	assert.Nil(t, r.UpdateHash("def456", "abc123"))
+	assert.Nil(t, r.SetMirrorRoute(0))
+	assert.EqualError(t, r.SetMirrorRoute(10), "the SMI traffic router does not support mirror routes")
+	assert.Nil(t, r.SetHeaderRoutes(nil))
+	assert.EqualError(t, r.SetHeaderRoutes([]v1alpha1.SetHeaderRoute{{Name: "testers"}}), "the SMI traffic router does not support header routes")
+}
+
+func TestReconcileCreatesTrafficSplit(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	ro := rollout(&v1alpha1.SMITrafficRouting{})
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10, trafficrouting.WeightDestination{ServiceName: "ex-baseline", Weight: 20})
+	assert.Nil(t, err)
+
+	actions := client.Actions()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "get", actions[0].GetVerb())
+	assert.Equal(t, "create", actions[1].GetVerb())
+	ts := actions[1].(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+	assert.Equal(t, "rollout", ts.GetName())
+	assert.Equal(t, "split.smi-spec.io/v1alpha2", ts.GetAPIVersion())
+	assert.True(t, metav1.IsControlledBy(ts, ro))
+	service, _, _ := unstructured.NestedString(ts.Object, "spec", "service")
+	assert.Equal(t, "stable", service)
+	assert.Equal(t, map[string]int64{"canary": 10, "ex-baseline": 20, "stable": 70}, backends(ts))
+}
+
+func TestReconcileUpdatesTrafficSplit(t *testing.T) {
+	ro := rollout(&v1alpha1.SMITrafficRouting{RootService: "root", TrafficSplitName: "split"})
+	existing := NewReconciler(ro, nil, nil).desiredTrafficSplit(0)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+
+	// the TrafficSplit is not updated when it has the desired weights
+	assert.Nil(t, r.Reconcile(0))
+	assert.Len(t, client.Actions(), 1)
+
+	assert.Nil(t, r.Reconcile(30))
+	actions := client.Actions()
+	assert.Len(t, actions, 3)
+	assert.Equal(t, "update", actions[2].GetVerb())
+	ts := actions[2].(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	assert.Equal(t, "split", ts.GetName())
+	service, _, _ := unstructured.NestedString(ts.Object, "spec", "service")
+	assert.Equal(t, "root", service)
+	assert.Equal(t, map[string]int64{"canary": 30, "stable": 70}, backends(ts))
+}
+
+func TestReconcileTrafficSplitNotControlled(t *testing.T) {
+	ro := rollout(&v1alpha1.SMITrafficRouting{})
+	existing := NewReconciler(ro, nil, nil).desiredTrafficSplit(0)
+	existing.SetOwnerReferences(nil)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+	r := NewReconciler(ro, client, &record.FakeRecorder{})
+	err := r.Reconcile(10)
+	assert.EqualError(t, err, "TrafficSplit `rollout` is not controlled by Rollout `rollout`")
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestAPIVersion(t *testing.T) {
+	assert.Equal(t, DefaultAPIVersion, APIVersion())
+	configutil.SetDefaults(map[string]string{configutil.SMIAPIVersionKey: "v1alpha3"})
+	defer configutil.SetDefaults(nil)
+	assert.Equal(t, "v1alpha3", APIVersion())
+}
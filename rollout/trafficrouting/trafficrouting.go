@@ -0,0 +1,11 @@
+// Package trafficrouting holds the types shared by the traffic routers of canary rollouts
+package trafficrouting
+
+// WeightDestination is a service, besides the stable and canary services, which receives a share of
+// the traffic of a rollout, such as the service of an experiment template
+type WeightDestination struct {
+	// ServiceName is the name of the service receiving the traffic
+	ServiceName string `json:"serviceName"`
+	// Weight is the percentage of the traffic sent to the service
+	Weight int32 `json:"weight"`
+}
@@ -9,24 +9,58 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/alb"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/gatewayapi"
 	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/istio"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/nginx"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/plugin"
+	"github.com/argoproj/argo-rollouts/rollout/trafficrouting/smi"
 	"github.com/argoproj/argo-rollouts/utils/conditions"
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 )
 
 type FakeTrafficRoutingReconciler struct {
-	errMessage                 string
-	controllerSetDesiredWeight int32
+	errMessage                       string
+	controllerSetDesiredWeight       int32
+	controllerAdditionalDestinations []trafficrouting.WeightDestination
+	controllerCanaryHash             string
+	controllerStableHash             string
+	weightNotVerified                bool
+	controllerHeaderRoutes           []v1alpha1.SetHeaderRoute
+	controllerMirrorPercentage       int32
 }
 
-func (r *FakeTrafficRoutingReconciler) Reconcile(desiredWeight int32) error {
+func (r *FakeTrafficRoutingReconciler) Reconcile(desiredWeight int32, additionalDestinations ...trafficrouting.WeightDestination) error {
 	if r.errMessage != "" {
 		return fmt.Errorf(r.errMessage)
 	}
 	r.controllerSetDesiredWeight = desiredWeight
+	r.controllerAdditionalDestinations = additionalDestinations
 	return nil
 }
 
+func (r *FakeTrafficRoutingReconciler) UpdateHash(canaryHash, stableHash string) error {
+	r.controllerCanaryHash = canaryHash
+	r.controllerStableHash = stableHash
+	return nil
+}
+
+func (r *FakeTrafficRoutingReconciler) SetHeaderRoutes(headerRoutes []v1alpha1.SetHeaderRoute) error {
+	r.controllerHeaderRoutes = headerRoutes
+	return nil
+}
+
+func (r *FakeTrafficRoutingReconciler) SetMirrorRoute(percentage int32) error {
+	r.controllerMirrorPercentage = percentage
+	return nil
+}
+
+func (r *FakeTrafficRoutingReconciler) VerifyWeight(desiredWeight int32) (bool, error) {
+	return !r.weightNotVerified, nil
+}
+
 func (r *FakeTrafficRoutingReconciler) Type() string {
 	return "fake"
 }
@@ -96,6 +130,117 @@ func TestRolloutUseDesiredWeight(t *testing.T) {
 	assert.Equal(t, int32(10), f.fakeTrafficRouting.controllerSetDesiredWeight)
 }
 
+func TestRolloutSetHeaderRoute(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	headerRoute := v1alpha1.SetHeaderRoute{
+		Name:  "testers",
+		Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}},
+	}
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetHeaderRoute: &headerRoute,
+		},
+		{
+			SetWeight: pointer.Int32Ptr(10),
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	// a canary pod serves the requests of the header route at a weight of 0
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 11, 1, 11, false)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+
+	assert.Equal(t, int32(0), f.fakeTrafficRouting.controllerSetDesiredWeight)
+	assert.Equal(t, []v1alpha1.SetHeaderRoute{headerRoute}, f.fakeTrafficRouting.controllerHeaderRoutes)
+	assert.Contains(t, f.getPatchedRollout(patchIndex), `"currentStepIndex":1`)
+}
+
+func TestRolloutSetMirrorRoute(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetMirrorRoute: &v1alpha1.SetMirrorRoute{Percentage: 20},
+		},
+		{
+			SetWeight: pointer.Int32Ptr(10),
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 11, 1, 11, false)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+
+	assert.Equal(t, int32(0), f.fakeTrafficRouting.controllerSetDesiredWeight)
+	assert.Equal(t, int32(20), f.fakeTrafficRouting.controllerMirrorPercentage)
+	assert.Contains(t, f.getPatchedRollout(patchIndex), `"currentStepIndex":1`)
+}
+
+func TestRolloutWaitsForWeightVerification(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	steps := []v1alpha1.CanaryStep{
+		{
+			SetWeight: pointer.Int32Ptr(10),
+		},
+		{
+			SetWeight: pointer.Int32Ptr(20),
+		},
+	}
+	r1 := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(0), intstr.FromInt(1), intstr.FromInt(0))
+	r2 := bumpVersion(r1)
+	r2.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	f.fakeTrafficRouting.weightNotVerified = true
+
+	rs1 := newReplicaSetWithStatus(r1, 10, 10)
+	rs2 := newReplicaSetWithStatus(r2, 1, 1)
+
+	f.kubeobjects = append(f.kubeobjects, rs1, rs2)
+	rs1PodHash := rs1.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+	f.replicaSetLister = append(f.replicaSetLister, rs1, rs2)
+
+	r2 = updateCanaryRolloutStatus(r2, rs1PodHash, 10, 0, 10, false)
+	f.rolloutLister = append(f.rolloutLister, r2)
+	f.objects = append(f.objects, r2)
+
+	patchIndex := f.expectPatchRolloutAction(r2)
+	f.run(getKey(r2, t))
+
+	assert.Equal(t, int32(10), f.fakeTrafficRouting.controllerSetDesiredWeight)
+	// the step does not complete until the traffic router applied the weight
+	assert.NotContains(t, f.getPatchedRollout(patchIndex), "currentStepIndex")
+}
+
 func TestRolloutUsePreviousSetWeight(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
@@ -159,6 +304,46 @@ func TestRolloutSetWeightToZeroWhenFullyRolledOut(t *testing.T) {
 	assert.Equal(t, int32(0), f.fakeTrafficRouting.controllerSetDesiredWeight)
 }
 
+func TestExperimentWeightDestinations(t *testing.T) {
+	steps := []v1alpha1.CanaryStep{{
+		SetWeight: pointer.Int32Ptr(10),
+	}, {
+		Experiment: &v1alpha1.RolloutExperimentStep{
+			Templates: []v1alpha1.RolloutExperimentTemplate{
+				{Name: "baseline", SpecRef: v1alpha1.StableSpecRef, Weight: pointer.Int32Ptr(10)},
+				{Name: "canary", SpecRef: v1alpha1.CanarySpecRef, Weight: pointer.Int32Ptr(10)},
+				{Name: "no-traffic", SpecRef: v1alpha1.CanarySpecRef},
+			},
+		},
+	}}
+	r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	ex := &v1alpha1.Experiment{
+		Spec: v1alpha1.ExperimentSpec{
+			Templates: []v1alpha1.TemplateSpec{
+				{Name: "baseline", Weight: pointer.Int32Ptr(10)},
+				{Name: "canary", Weight: pointer.Int32Ptr(10)},
+				{Name: "no-traffic"},
+			},
+		},
+		Status: v1alpha1.ExperimentStatus{
+			Phase: v1alpha1.AnalysisPhaseRunning,
+			TemplateStatuses: []v1alpha1.TemplateStatus{
+				{Name: "baseline", Status: v1alpha1.TemplateStatusRunning, ServiceName: "foo-ex-baseline"},
+				{Name: "canary", Status: v1alpha1.TemplateStatusProgressing, ServiceName: "foo-ex-canary"},
+				{Name: "no-traffic", Status: v1alpha1.TemplateStatusRunning},
+			},
+		},
+	}
+	roCtx := newCanaryCtx(r, nil, nil, nil, nil)
+	roCtx.currentEx = ex
+	// templates only receive traffic once their pods are available
+	assert.Equal(t, []trafficrouting.WeightDestination{{ServiceName: "foo-ex-baseline", Weight: 10}}, experimentWeightDestinations(roCtx))
+
+	ex.Spec.Terminate = true
+	assert.Nil(t, experimentWeightDestinations(roCtx))
+}
+
 func TestNewTrafficRoutingReconciler(t *testing.T) {
 	rc := RolloutController{}
 	steps := []v1alpha1.CanaryStep{
@@ -199,4 +384,90 @@ func TestNewTrafficRoutingReconciler(t *testing.T) {
 		assert.NotNil(t, networkReconciler)
 		assert.Equal(t, istio.Type, networkReconciler.Type())
 	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			SMI: &v1alpha1.SMITrafficRouting{},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, smi.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			ALB: &v1alpha1.ALBTrafficRouting{},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, alb.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Nginx: &v1alpha1.NginxTrafficRouting{},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, nginx.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			GatewayAPI: &v1alpha1.GatewayAPITrafficRouting{},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.NotNil(t, networkReconciler)
+		assert.Equal(t, gatewayapi.Type, networkReconciler.Type())
+	}
+	{
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Plugin: &v1alpha1.PluginTrafficRouting{Name: "acme/mesh"},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Equal(t, plugin.Type, networkReconciler.Type())
+		assert.EqualError(t, networkReconciler.Reconcile(10), "traffic router plugin 'acme/mesh' is not declared in trafficRouters.plugins")
+
+		configutil.SetDefaults(map[string]string{configutil.TrafficRouterPluginsKey: "acme/mesh=http://localhost:8090"})
+		networkReconciler = rc.NewTrafficRoutingReconciler(roCtx)
+		configutil.SetDefaults(nil)
+		_, ok := networkReconciler.(TrafficRoutingVerifier)
+		assert.True(t, ok)
+	}
+	{
+		configutil.SetDefaults(map[string]string{configutil.DisabledTrafficRoutersKey: "istio"})
+		defer configutil.SetDefaults(nil)
+		r := newCanaryRollout("foo", 10, nil, steps, pointer.Int32Ptr(1), intstr.FromInt(1), intstr.FromInt(0))
+		r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{
+			Istio: &v1alpha1.IstioTrafficRouting{},
+		}
+		roCtx := &canaryContext{
+			rollout: r,
+			log:     logutil.WithRollout(r),
+		}
+		networkReconciler := rc.NewTrafficRoutingReconciler(roCtx)
+		assert.Equal(t, istio.Type, networkReconciler.Type())
+		assert.EqualError(t, networkReconciler.Reconcile(10), "traffic router 'Istio' is disabled")
+	}
 }
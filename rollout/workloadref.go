@@ -0,0 +1,87 @@
+package rollout
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// DeploymentKind is the only kind of workload a rollout can reference
+	DeploymentKind = "Deployment"
+
+	scaleDownWorkloadPatch = `{"spec":{"replicas":0}}`
+)
+
+// getRolloutWorkloadRefKeys returns the key of the Deployment referenced by the workloadRef of the
+// rollout, which indexes the rollouts by the Deployment they reference
+func getRolloutWorkloadRefKeys(r *v1alpha1.Rollout) []string {
+	if r.Spec.WorkloadRef == nil || r.Spec.WorkloadRef.Kind != DeploymentKind {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s/%s", r.Namespace, r.Spec.WorkloadRef.Name)}
+}
+
+// enqueueWorkloadRefRollouts enqueues the rollouts referencing the Deployment
+func (c *RolloutController) enqueueWorkloadRefRollouts(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	rollouts, err := c.rolloutsIndexer.ByIndex(workloadRefIndexName, key)
+	if err != nil {
+		return
+	}
+	for _, r := range rollouts {
+		c.enqueueRollout(r)
+	}
+}
+
+// resolveWorkloadRef sets the pod template and the selector of the rollout to the ones of the
+// Deployment referenced by its workloadRef, and returns the Deployment. Returns nil if the rollout
+// does not reference a Deployment.
+func (c *RolloutController) resolveWorkloadRef(r *v1alpha1.Rollout) (*appsv1.Deployment, error) {
+	ref := r.Spec.WorkloadRef
+	if ref == nil || conditions.InvalidWorkloadRef(ref) {
+		// an invalid workloadRef is reported by the verification of the spec
+		return nil, nil
+	}
+	deployment, err := c.deploymentLister.Deployments(r.Namespace).Get(ref.Name)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Deployment '%s' referenced by the workloadRef not found", ref.Name)
+			c.recorder.Event(r, corev1.EventTypeWarning, "WorkloadNotFound", msg)
+		}
+		return nil, err
+	}
+	r.Spec.Template = *deployment.Spec.Template.DeepCopy()
+	r.Spec.Selector = deployment.Spec.Selector.DeepCopy()
+	return deployment, nil
+}
+
+// scaleDownWorkload scales the Deployment referenced by the rollout down to zero once the rollout
+// completed with the pod template of the Deployment, so its pods replace the ones of the Deployment
+func (c *RolloutController) scaleDownWorkload(r *v1alpha1.Rollout, deployment *appsv1.Deployment) error {
+	if deployment == nil || (deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0) {
+		return nil
+	}
+	if !conditions.RolloutComplete(r, &r.Status) {
+		return nil
+	}
+	logutil.WithRollout(r).Infof("Scaling down Deployment '%s' adopted by the rollout", deployment.Name)
+	_, err := c.kubeclientset.AppsV1().Deployments(deployment.Namespace).Patch(deployment.Name, patchtypes.MergePatchType, []byte(scaleDownWorkloadPatch))
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Scaled down Deployment '%s' to 0", deployment.Name)
+	c.recorder.Event(r, corev1.EventTypeNormal, "ScalingDownWorkload", msg)
+	return nil
+}
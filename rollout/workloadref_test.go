@@ -0,0 +1,135 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/conditions"
+)
+
+func newWorkloadRefDeployment(name string, replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       metav1.NamespaceDefault,
+			ResourceVersion: "1",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32Ptr(replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: name, Image: "foo/bar:v2"}},
+				},
+			},
+		},
+	}
+}
+
+func newWorkloadRefRollout(deployment string) *v1alpha1.Rollout {
+	r := newCanaryRollout("foo", 3, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))
+	r.Spec.Template = corev1.PodTemplateSpec{}
+	r.Spec.Selector = nil
+	r.Spec.WorkloadRef = &v1alpha1.ObjectRef{APIVersion: "apps/v1", Kind: DeploymentKind, Name: deployment}
+	return r
+}
+
+func TestResolveWorkloadRef(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	deployment := newWorkloadRefDeployment("guestbook", 3)
+	f.deploymentLister = append(f.deploymentLister, deployment)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	r := newWorkloadRefRollout("guestbook")
+	resolved, err := c.resolveWorkloadRef(r)
+	assert.NoError(t, err)
+	assert.Equal(t, deployment, resolved)
+	assert.Equal(t, deployment.Spec.Template, r.Spec.Template)
+	assert.Equal(t, deployment.Spec.Selector, r.Spec.Selector)
+
+	// rollouts without a valid workloadRef keep their own pod template
+	r = newCanaryRollout("foo", 3, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))
+	resolved, err = c.resolveWorkloadRef(r)
+	assert.NoError(t, err)
+	assert.Nil(t, resolved)
+	assert.Equal(t, "foo/bar", r.Spec.Template.Spec.Containers[0].Image)
+	r = newWorkloadRefRollout("guestbook")
+	r.Spec.WorkloadRef.Kind = "StatefulSet"
+	resolved, err = c.resolveWorkloadRef(r)
+	assert.NoError(t, err)
+	assert.Nil(t, resolved)
+}
+
+func TestResolveWorkloadRefNotFound(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	_, err := c.resolveWorkloadRef(newWorkloadRefRollout("guestbook"))
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestScaleDownWorkload(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	deployment := newWorkloadRefDeployment("guestbook", 3)
+	f.kubeobjects = append(f.kubeobjects, deployment)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	r := newWorkloadRefRollout("guestbook")
+	r.Spec.Template = deployment.Spec.Template
+	r.Spec.Selector = deployment.Spec.Selector
+	r.Status.Replicas = 3
+	r.Status.UpdatedReplicas = 3
+	r.Status.AvailableReplicas = 3
+	r.Status.CurrentPodHash = "abc123"
+	r.Status.Canary.StableRS = "abc123"
+
+	// the Deployment is left as is until the rollout completes with its pod template
+	assert.NoError(t, c.scaleDownWorkload(r, deployment))
+	assert.Len(t, filterInformerActions(f.kubeclient.Actions()), 0)
+
+	r.Status.ObservedGeneration = conditions.ComputeGenerationHash(r.Spec)
+	assert.NoError(t, c.scaleDownWorkload(r, deployment))
+	actions := filterInformerActions(f.kubeclient.Actions())
+	assert.Len(t, actions, 1)
+	patch, ok := actions[0].(core.PatchAction)
+	assert.True(t, ok)
+	assert.Equal(t, "deployments", patch.GetResource().Resource)
+	assert.Equal(t, scaleDownWorkloadPatch, string(patch.GetPatch()))
+
+	// a Deployment scaled down to zero is not patched again
+	assert.NoError(t, c.scaleDownWorkload(r, newWorkloadRefDeployment("guestbook", 0)))
+	assert.Len(t, filterInformerActions(f.kubeclient.Actions()), 1)
+}
+
+func TestEnqueueWorkloadRefRollouts(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+	r := newWorkloadRefRollout("guestbook")
+	other := newCanaryRollout("bar", 3, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))
+	f.rolloutLister = append(f.rolloutLister, r, other)
+	c, _, _ := f.newController(noResyncPeriodFunc)
+
+	c.enqueueWorkloadRefRollouts(newWorkloadRefDeployment("guestbook", 3))
+	assert.Equal(t, map[string]int{"default/foo": 1}, f.enqueuedObjects)
+	c.enqueueWorkloadRefRollouts(newWorkloadRefDeployment("other", 3))
+	assert.Equal(t, map[string]int{"default/foo": 1}, f.enqueuedObjects)
+}
+
+func TestGetRolloutWorkloadRefKeys(t *testing.T) {
+	assert.Equal(t, []string{"default/guestbook"}, getRolloutWorkloadRefKeys(newWorkloadRefRollout("guestbook")))
+	assert.Nil(t, getRolloutWorkloadRefKeys(newCanaryRollout("foo", 3, nil, nil, nil, intstr.FromInt(1), intstr.FromInt(0))))
+}
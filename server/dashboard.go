@@ -0,0 +1,137 @@
+package server
+
+import (
+	"net/http"
+)
+
+// DashboardPath is the path of the dashboard
+const DashboardPath = "/"
+
+// dashboardHTML is a single page listing the rollouts of all namespaces with their step progress,
+// the measurements of their AnalysisRuns and buttons to promote, abort and retry them. The page
+// only talks to the API of the server with the bearer token entered by the user, so it grants no
+// more than the user's own RBAC permissions.
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Argo Rollouts</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 6px; text-align: left; vertical-align: top; }
+button { margin-right: 4px; }
+#error { color: #c00; }
+</style>
+</head>
+<body>
+<h1>Argo Rollouts</h1>
+<p>
+<input id="token" type="password" placeholder="Bearer token" size="60">
+<button onclick="saveToken()">Save</button>
+<span id="error"></span>
+</p>
+<table>
+<thead><tr><th>Namespace</th><th>Name</th><th>Strategy</th><th>Step</th><th>Status</th><th>Actions</th></tr></thead>
+<tbody id="rollouts"></tbody>
+</table>
+<div id="runs"></div>
+<script>
+var apiPath = "/api/v1/rollouts";
+
+function saveToken() {
+  sessionStorage.setItem("token", document.getElementById("token").value);
+  refresh();
+}
+
+function request(method, path) {
+  return fetch(path, {method: method, headers: {"Authorization": "Bearer " + sessionStorage.getItem("token")}})
+    .then(function(res) {
+      return res.json().then(function(body) {
+        if (!res.ok) { throw new Error(body.error || res.statusText); }
+        return body;
+      });
+    });
+}
+
+function text(value) {
+  var span = document.createElement("span");
+  span.textContent = value;
+  return span.innerHTML;
+}
+
+function status(ro) {
+  var s = ro.status || {};
+  if (s.phase) { return s.phase; }
+  if (s.abort) { return "Aborted"; }
+  if ((s.pauseConditions || []).length > 0 || ro.spec.paused) { return "Paused"; }
+  return "";
+}
+
+function step(ro) {
+  var canary = ro.spec.strategy.canary;
+  if (!canary || !canary.steps) { return ""; }
+  var index = (ro.status || {}).currentStepIndex || 0;
+  return index + "/" + canary.steps.length;
+}
+
+function refresh() {
+  request("GET", apiPath).then(function(list) {
+    document.getElementById("error").textContent = "";
+    var rows = (list.items || []).map(function(ro) {
+      var path = apiPath + "/" + encodeURIComponent(ro.metadata.namespace) + "/" + encodeURIComponent(ro.metadata.name);
+      var actions = ["promote", "abort", "retry"].map(function(action) {
+        return "<button onclick=\"operate('" + path + "/" + action + "')\">" + action + "</button>";
+      }).join("") + "<button onclick=\"showRuns('" + path + "')\">analysis</button>";
+      return "<tr><td>" + text(ro.metadata.namespace) + "</td><td>" + text(ro.metadata.name) + "</td><td>" +
+        (ro.spec.strategy.canary ? "canary" : "blueGreen") + "</td><td>" + step(ro) + "</td><td>" +
+        text(status(ro)) + "</td><td>" + actions + "</td></tr>";
+    });
+    document.getElementById("rollouts").innerHTML = rows.join("");
+  }).catch(function(err) {
+    document.getElementById("error").textContent = err.message;
+  });
+}
+
+function operate(path) {
+  request("POST", path).then(refresh).catch(function(err) {
+    document.getElementById("error").textContent = err.message;
+  });
+}
+
+function showRuns(path) {
+  request("GET", path + "/analysisruns").then(function(list) {
+    var html = (list.items || []).map(function(run) {
+      var metrics = ((run.status || {}).metricResults || []).map(function(result) {
+        var values = (result.measurements || []).map(function(m) { return text(m.value || m.phase); }).join(", ");
+        return "<li>" + text(result.name) + " (" + text(result.phase) + "): " + values + "</li>";
+      }).join("");
+      return "<h3>" + text(run.metadata.name) + " (" + text((run.status || {}).phase || "") + ")</h3><ul>" + metrics + "</ul>";
+    });
+    document.getElementById("runs").innerHTML = "<h2>AnalysisRuns</h2>" + html.join("");
+  }).catch(function(err) {
+    document.getElementById("error").textContent = err.message;
+  });
+}
+
+document.getElementById("token").value = sessionStorage.getItem("token") || "";
+if (sessionStorage.getItem("token")) { refresh(); }
+setInterval(function() { if (sessionStorage.getItem("token")) { refresh(); } }, 10000);
+</script>
+</body>
+</html>
+`
+
+// serveDashboard serves the dashboard page. Other paths which are not part of the API are not found.
+func serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != DashboardPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(dashboardHTML))
+}
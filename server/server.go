@@ -0,0 +1,367 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts"
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
+)
+
+const (
+	// DefaultPort is the default port the API server listens on
+	DefaultPort = 3100
+	// APIPath is the prefix of the rollout endpoints
+	APIPath = "/api/v1/rollouts/"
+
+	abortPatch       = `{"status":{"abort":true}}`
+	retryPatch       = `{"status":{"abort":false}}`
+	promotePatch     = `{"spec":{"paused":false},"status":{"promote":true}}`
+	promoteFullPatch = `{"spec":{"paused":false},"status":{"promoteFull":true}}`
+	restartPatch     = `{"spec":{"restartAt":"%s"}}`
+)
+
+// operations are the actions which can be performed on a rollout through the API
+var operations = map[string]func() []byte{
+	"abort":        func() []byte { return []byte(abortPatch) },
+	"retry":        func() []byte { return []byte(retryPatch) },
+	"promote":      func() []byte { return []byte(promotePatch) },
+	"promote-full": func() []byte { return []byte(promoteFullPatch) },
+	"restart": func() []byte {
+		return []byte(fmt.Sprintf(restartPatch, time.Now().UTC().Format(time.RFC3339)))
+	},
+}
+
+// access is a permission checked with a SubjectAccessReview. The resource defaults to rollouts.
+type access struct {
+	verb        string
+	resource    string
+	subresource string
+}
+
+func (a access) getResource() string {
+	if a.resource == "" {
+		return rollouts.RolloutPlural
+	}
+	return a.resource
+}
+
+func (a access) String() string {
+	if a.subresource != "" {
+		return fmt.Sprintf("%s %s/%s", a.verb, a.getResource(), a.subresource)
+	}
+	return fmt.Sprintf("%s %s", a.verb, a.getResource())
+}
+
+// Server exposes rollout operations over HTTP so the dashboard and external tooling do not need to
+// patch the CRDs directly. Callers authenticate with a Kubernetes bearer token, and each request is
+// authorized with a SubjectAccessReview against the rollout resource, then made with a client
+// impersonating the caller, so the API grants no more than the caller's own RBAC permissions.
+// Operations are also allowed to callers who may update the rollouts/<operation> subresource (e.g.
+// rollouts/promote), which lets RBAC grant operating on rollouts without granting write access to
+// their spec. The caller cannot patch the rollout in that case, so the fixed patch of the operation
+// is made with the credentials of the server.
+type Server struct {
+	kubeclientset     kubernetes.Interface
+	argoprojclientset clientset.Interface
+	// newClient returns a client impersonating the user
+	newClient func(user authenticationv1.UserInfo) (clientset.Interface, error)
+}
+
+// caller is an authenticated user of the API and the permission it was granted
+type caller struct {
+	user       authenticationv1.UserInfo
+	permission access
+}
+
+// NewServer returns an API server using the clientsets to authorize callers and to make the
+// operations granted by subresources, and the config to impersonate callers for other requests
+func NewServer(kubeclientset kubernetes.Interface, argoprojclientset clientset.Interface, config *rest.Config) *Server {
+	return &Server{
+		kubeclientset:     kubeclientset,
+		argoprojclientset: argoprojclientset,
+		newClient: func(user authenticationv1.UserInfo) (clientset.Interface, error) {
+			impersonating := rest.CopyConfig(config)
+			impersonating.Impersonate = rest.ImpersonationConfig{
+				UserName: user.Username,
+				Groups:   user.Groups,
+				Extra:    map[string][]string{},
+			}
+			for k, v := range user.Extra {
+				impersonating.Impersonate.Extra[k] = v
+			}
+			return clientset.NewForConfig(impersonating)
+		},
+	}
+}
+
+// NewHTTPServer returns an http server listening on addr
+func (s *Server) NewHTTPServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+	}
+}
+
+// Handler returns the handler serving the API and the dashboard. The endpoints are:
+//
+//	GET  /api/v1/rollouts                                   list the rollouts of all namespaces (?watch=true streams changes)
+//	GET  /api/v1/rollouts/{namespace}                       list rollouts (?watch=true streams changes)
+//	GET  /api/v1/rollouts/{namespace}/{name}                get a rollout (?watch=true streams changes)
+//	GET  /api/v1/rollouts/{namespace}/{name}/analysisruns   list the AnalysisRuns of a rollout and their measurements
+//	POST /api/v1/rollouts/{namespace}/{name}/{action}       promote, promote-full, abort, retry or restart a rollout
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(strings.TrimSuffix(APIPath, "/"), s.serveRollouts)
+	mux.HandleFunc(APIPath, s.serveRollouts)
+	mux.HandleFunc(DashboardPath, serveDashboard)
+	return mux
+}
+
+func (s *Server) serveRollouts(w http.ResponseWriter, r *http.Request) {
+	var parts []string
+	if path := strings.Trim(strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(APIPath, "/")), "/"); path != "" {
+		parts = strings.Split(path, "/")
+	}
+	if len(parts) > 3 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+		return
+	}
+	// the rollouts of all namespaces are listed without a namespace
+	namespace := ""
+	if len(parts) > 0 {
+		namespace = parts[0]
+	}
+	name := ""
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	watch := r.URL.Query().Get("watch") == "true"
+
+	var verb string
+	var alternatives []access
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		if _, ok := operations[parts[2]]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown action '%s'", parts[2]))
+			return
+		}
+		verb = "patch"
+		alternatives = []access{{verb: "update", subresource: parts[2]}}
+	case len(parts) == 3 && r.Method == http.MethodGet && parts[2] == rollouts.AnalysisRunPlural:
+		// the runs are listed in the namespace, so the caller needs to be able to list them
+		c, status, err := s.authorize(r, namespace, "", access{verb: "list", resource: rollouts.AnalysisRunPlural})
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		client, err := s.clientFor(c)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.listAnalysisRuns(w, client, namespace, name)
+		return
+	case len(parts) < 3 && r.Method == http.MethodGet:
+		switch {
+		case watch:
+			verb = "watch"
+		case name == "":
+			verb = "list"
+		default:
+			verb = "get"
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+
+	c, status, err := s.authorize(r, namespace, name, append(alternatives, access{verb: verb})...)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	client, err := s.clientFor(c)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rolloutIf := client.ArgoprojV1alpha1().Rollouts(namespace)
+	switch verb {
+	case "patch":
+		ro, err := rolloutIf.Patch(name, types.MergePatchType, operations[parts[2]]())
+		writeResult(w, ro, err)
+	case "get":
+		ro, err := rolloutIf.Get(name, metav1.GetOptions{})
+		writeResult(w, ro, err)
+	case "list":
+		list, err := rolloutIf.List(metav1.ListOptions{})
+		writeResult(w, list, err)
+	case "watch":
+		s.watchRollouts(w, r, client, namespace, name)
+	}
+}
+
+// clientFor returns the client the request of the caller is made with: a client impersonating the
+// caller, or the client of the server for an operation granted by its subresource
+func (s *Server) clientFor(c *caller) (clientset.Interface, error) {
+	if c.permission.subresource != "" {
+		return s.argoprojclientset, nil
+	}
+	return s.newClient(c.user)
+}
+
+// listAnalysisRuns writes the AnalysisRuns controlled by the rollout, which include the measurements
+// of their metrics
+func (s *Server) listAnalysisRuns(w http.ResponseWriter, client clientset.Interface, namespace, name string) {
+	ro, err := client.ArgoprojV1alpha1().Rollouts(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	list, err := client.ArgoprojV1alpha1().AnalysisRuns(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	runs := &v1alpha1.AnalysisRunList{ListMeta: list.ListMeta}
+	for _, run := range list.Items {
+		if ref := metav1.GetControllerOf(&run); ref != nil && ref.UID == ro.UID {
+			runs.Items = append(runs.Items, run)
+		}
+	}
+	writeResult(w, runs, nil)
+}
+
+// watchRollouts streams the rollout watch events as newline delimited JSON until the client
+// disconnects or the watch is closed by the API server
+func (s *Server) watchRollouts(w http.ResponseWriter, r *http.Request, client clientset.Interface, namespace, name string) {
+	opts := metav1.ListOptions{}
+	if name != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+	watcher, err := client.ArgoprojV1alpha1().Rollouts(namespace).Watch(opts)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	defer watcher.Stop()
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if _, isRollout := event.Object.(*v1alpha1.Rollout); !isRollout {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// authorize authenticates the bearer token of the request and checks the caller has one of the
+// permissions on the rollout. It returns the caller and the first permission it was granted. The
+// returned status code is meant to be sent back to the caller.
+func (s *Server) authorize(r *http.Request, namespace, name string, permissions ...access) (*caller, int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return nil, http.StatusUnauthorized, fmt.Errorf("a bearer token is required")
+	}
+	review, err := s.kubeclientset.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if !review.Status.Authenticated {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid bearer token")
+	}
+	user := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	var denied []string
+	for _, permission := range permissions {
+		access, err := s.kubeclientset.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user.Username,
+				UID:    user.UID,
+				Groups: user.Groups,
+				Extra:  extra,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   namespace,
+					Verb:        permission.verb,
+					Group:       rollouts.Group,
+					Resource:    permission.getResource(),
+					Subresource: permission.subresource,
+					Name:        name,
+				},
+			},
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if access.Status.Allowed {
+			log.WithField("user", user.Username).WithField("namespace", namespace).Infof("API %s '%s'", permission, name)
+			return &caller{user: user, permission: permission}, http.StatusOK, nil
+		}
+		denied = append(denied, permission.String())
+	}
+	scope := fmt.Sprintf("in namespace '%s'", namespace)
+	if namespace == "" {
+		scope = "in all namespaces"
+	}
+	return nil, http.StatusForbidden, fmt.Errorf("user '%s' cannot %s %s", user.Username, strings.Join(denied, " or "), scope)
+}
+
+func writeResult(w http.ResponseWriter, obj interface{}, err error) {
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		log.Warnf("Failed to write API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// errorStatus maps an error from the Kubernetes API to the status code returned to the caller
+func errorStatus(err error) int {
+	if status, ok := err.(k8serrors.APIStatus); ok && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	clientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
+	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+)
+
+// newTestServer returns a server which accepts the token "valid" of the user "jesse" and allows the
+// verbs. Verbs on subresources are formatted as <verb>/<subresource>. The client impersonating
+// callers is the returned client.
+func newTestServer(allowedVerbs ...string) (*Server, *fake.Clientset) {
+	kubeclient := k8sfake.NewSimpleClientset()
+	kubeclient.PrependReactor("create", "tokenreviews", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		review := action.(kubetesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status.Authenticated = review.Spec.Token == "valid"
+		review.Status.User.Username = "jesse"
+		review.Status.User.Groups = []string{"developers"}
+		return true, review, nil
+	})
+	kubeclient.PrependReactor("create", "subjectaccessreviews", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		review := action.(kubetesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		requested := attrs.Verb
+		if attrs.Subresource != "" {
+			requested += "/" + attrs.Subresource
+		}
+		for _, verb := range allowedVerbs {
+			if requested == verb {
+				review.Status.Allowed = true
+			}
+		}
+		return true, review, nil
+	})
+	rollout := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "default"},
+	}
+	client := fake.NewSimpleClientset(rollout)
+	s := NewServer(kubeclient, client, nil)
+	s.newClient = func(user authenticationv1.UserInfo) (clientset.Interface, error) {
+		return client, nil
+	}
+	return s, client
+}
+
+func doRequest(s *Server, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetRollout(t *testing.T) {
+	s, _ := newTestServer("get", "list")
+	rr := doRequest(s, http.MethodGet, APIPath+"default/guestbook", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"guestbook"`)
+
+	rr = doRequest(s, http.MethodGet, APIPath+"default", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"guestbook"`)
+
+	rr = doRequest(s, http.MethodGet, APIPath+"default/missing", "valid")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAuthentication(t *testing.T) {
+	s, _ := newTestServer("get")
+	rr := doRequest(s, http.MethodGet, APIPath+"default/guestbook", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = doRequest(s, http.MethodGet, APIPath+"default/guestbook", "invalid")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuthorization(t *testing.T) {
+	s, client := newTestServer("get")
+	rr := doRequest(s, http.MethodPost, APIPath+"default/guestbook/abort", "valid")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, client.Actions())
+}
+
+func TestOperations(t *testing.T) {
+	for _, action := range []string{"promote", "promote-full", "abort", "retry", "restart"} {
+		s, client := newTestServer("patch")
+		rr := doRequest(s, http.MethodPost, APIPath+"default/guestbook/"+action, "valid")
+		assert.Equal(t, http.StatusOK, rr.Code, action)
+		assert.Len(t, client.Actions(), 1, action)
+		patch := client.Actions()[0].(kubetesting.PatchAction)
+		assert.Equal(t, "guestbook", patch.GetName())
+		if action == "restart" {
+			assert.Contains(t, string(patch.GetPatch()), "restartAt")
+		} else {
+			assert.Equal(t, operations[action](), patch.GetPatch(), action)
+		}
+	}
+}
+
+func TestOperationSubresources(t *testing.T) {
+	s, client := newTestServer("update/promote")
+	rr := doRequest(s, http.MethodPost, APIPath+"default/guestbook/promote", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, client.Actions(), 1)
+
+	rr = doRequest(s, http.MethodPost, APIPath+"default/guestbook/abort", "valid")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cannot update rollouts/abort or patch rollouts")
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestImpersonation(t *testing.T) {
+	s, client := newTestServer("get", "patch", "update/abort")
+	impersonated := fake.NewSimpleClientset(&v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "default"},
+	})
+	var users []authenticationv1.UserInfo
+	s.newClient = func(user authenticationv1.UserInfo) (clientset.Interface, error) {
+		users = append(users, user)
+		return impersonated, nil
+	}
+
+	rr := doRequest(s, http.MethodGet, APIPath+"default/guestbook", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "jesse", users[0].Username)
+	assert.Equal(t, []string{"developers"}, users[0].Groups)
+	assert.Len(t, impersonated.Actions(), 1)
+
+	// the operation granted by its subresource is made by the server
+	rr = doRequest(s, http.MethodPost, APIPath+"default/guestbook/abort", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, users, 1)
+	assert.Len(t, client.Actions(), 1)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	s, _ := newTestServer("patch", "get")
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodPost, APIPath+"default/guestbook/scale", "valid").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(s, http.MethodGet, APIPath+"default/guestbook/abort", "valid").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodGet, APIPath+"default/guestbook/abort/now", "valid").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodGet, "/favicon.ico", "valid").Code)
+}
+
+func TestListAllNamespaces(t *testing.T) {
+	s, _ := newTestServer("get")
+	rr := doRequest(s, http.MethodGet, "/api/v1/rollouts", "valid")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cannot list rollouts in all namespaces")
+
+	s, _ = newTestServer("list")
+	for _, path := range []string{"/api/v1/rollouts", APIPath} {
+		rr = doRequest(s, http.MethodGet, path, "valid")
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Contains(t, rr.Body.String(), `"name":"guestbook"`, path)
+	}
+}
+
+func TestListAnalysisRuns(t *testing.T) {
+	s, _ := newTestServer("get")
+	rr := doRequest(s, http.MethodGet, APIPath+"default/guestbook/analysisruns", "valid")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cannot list analysisruns")
+
+	s, client := newTestServer("list")
+	ro, err := client.ArgoprojV1alpha1().Rollouts("default").Get("guestbook", metav1.GetOptions{})
+	assert.NoError(t, err)
+	ro.UID = "abc123"
+	_, err = client.ArgoprojV1alpha1().Rollouts("default").Update(ro)
+	assert.NoError(t, err)
+	owned := &v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "guestbook-abc123-1",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ro, v1alpha1.SchemeGroupVersion.WithKind("Rollout"))},
+		},
+		Status: v1alpha1.AnalysisRunStatus{
+			MetricResults: []v1alpha1.MetricResult{{Name: "success-rate", Measurements: []v1alpha1.Measurement{{Value: "0.99"}}}},
+		},
+	}
+	other := &v1alpha1.AnalysisRun{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+	for _, run := range []*v1alpha1.AnalysisRun{owned, other} {
+		_, err = client.ArgoprojV1alpha1().AnalysisRuns("default").Create(run)
+		assert.NoError(t, err)
+	}
+	rr = doRequest(s, http.MethodGet, APIPath+"default/guestbook/analysisruns", "valid")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"guestbook-abc123-1"`)
+	assert.Contains(t, rr.Body.String(), `"value":"0.99"`)
+	assert.NotContains(t, rr.Body.String(), `"name":"other"`)
+
+	rr = doRequest(s, http.MethodGet, APIPath+"default/missing/analysisruns", "valid")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDashboard(t *testing.T) {
+	s, _ := newTestServer()
+	rr := doRequest(s, http.MethodGet, DashboardPath, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "/api/v1/rollouts")
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(s, http.MethodPost, DashboardPath, "").Code)
+}
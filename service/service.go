@@ -7,7 +7,6 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/errors"
 	patchtypes "k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	v1 "k8s.io/client-go/listers/core/v1"
@@ -94,15 +93,8 @@ func NewServiceController(
 
 func (c *ServiceController) Run(threadiness int, stopCh <-chan struct{}) error {
 	log.Info("Starting Service workers")
-	for i := 0; i < threadiness; i++ {
-		go wait.Until(func() {
-			controllerutil.RunWorker(c.serviceWorkqueue, logutil.ServiceKey, c.syncService, c.metricServer)
-		}, time.Second, stopCh)
-	}
-
-	log.Info("Started Service workers")
-	<-stopCh
-	log.Info("Shutting down workers")
+	controllerutil.RunWorkers(threadiness, c.serviceWorkqueue, logutil.ServiceKey, c.syncService, c.metricServer, stopCh)
+	log.Info("Shut down Service workers")
 
 	return nil
 }
@@ -51,7 +51,7 @@ func newFakeServiceController(svc *corev1.Service, rollout *v1alpha1.Rollout) (*
 		0,
 		rolloutWorkqueue,
 		serviceWorkqueue,
-		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), &metrics.K8sRequestsCountProvider{}))
+		metrics.NewMetricsServer("localhost:8080", i.Argoproj().V1alpha1().Rollouts().Lister(), nil, &metrics.K8sRequestsCountProvider{}))
 	enqueuedObjects := map[string]int{}
 	c.enqueueRollout = func(obj interface{}) {
 		var key string
@@ -2,25 +2,50 @@ package analysis
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 )
 
+// mapKeyFieldPath matches the field paths selecting a key of a map, e.g. metadata.labels['app']
+var mapKeyFieldPath = regexp.MustCompile(`^(.+)\['([^']*)'\]$`)
+
 // BuildArgumentsForRolloutAnalysisRun builds the arguments for a analysis base created by a rollout
-func BuildArgumentsForRolloutAnalysisRun(args []v1alpha1.AnalysisRunArgument, stableRS, newRS *appsv1.ReplicaSet) []v1alpha1.Argument {
+func BuildArgumentsForRolloutAnalysisRun(args []v1alpha1.AnalysisRunArgument, stableRS, newRS *appsv1.ReplicaSet, r *v1alpha1.Rollout) ([]v1alpha1.Argument, error) {
 	arguments := []v1alpha1.Argument{}
 	for i := range args {
 		arg := args[i]
+		if arg.ValueFrom != nil && arg.ValueFrom.SecretKeyRef != nil {
+			// secrets are read by the analysis controller so their values are not copied into the run
+			arguments = append(arguments, v1alpha1.Argument{
+				Name:      arg.Name,
+				ValueFrom: &v1alpha1.ValueFrom{SecretKeyRef: arg.ValueFrom.SecretKeyRef.DeepCopy()},
+			})
+			continue
+		}
 		value := arg.Value
 		if arg.ValueFrom != nil {
-			switch *arg.ValueFrom.PodTemplateHashValue {
-			case v1alpha1.Latest:
-				value = newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
-			case v1alpha1.Stable:
-				value = stableRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+			switch {
+			case arg.ValueFrom.PodTemplateHashValue != nil:
+				switch *arg.ValueFrom.PodTemplateHashValue {
+				case v1alpha1.Latest:
+					value = newRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+				case v1alpha1.Stable:
+					value = stableRS.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+				}
+			case arg.ValueFrom.FieldRef != nil:
+				fieldValue, err := ResolveFieldPath(r, arg.ValueFrom.FieldRef.FieldPath)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve args.%s: %v", arg.Name, err)
+				}
+				value = fieldValue
 			}
 		}
 		analysisArg := v1alpha1.Argument{
@@ -30,7 +55,37 @@ func BuildArgumentsForRolloutAnalysisRun(args []v1alpha1.AnalysisRunArgument, st
 		arguments = append(arguments, analysisArg)
 
 	}
-	return arguments
+	return arguments, nil
+}
+
+// ResolveFieldPath returns the value of the field of the object at the path, e.g. metadata.name or
+// metadata.labels['app']. Only strings, numbers and booleans can be selected.
+func ResolveFieldPath(obj runtime.Object, fieldPath string) (string, error) {
+	objMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	var fields []string
+	if match := mapKeyFieldPath.FindStringSubmatch(fieldPath); match != nil {
+		fields = append(strings.Split(match[1], "."), match[2])
+	} else {
+		fields = strings.Split(fieldPath, ".")
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(objMap, fields...)
+	if err != nil {
+		return "", fmt.Errorf("invalid field path '%s': %v", fieldPath, err)
+	}
+	if !found {
+		return "", fmt.Errorf("field path '%s' not found", fieldPath)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64, float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("field path '%s' is not a string, number or boolean", fieldPath)
+	}
 }
 
 // PrePromotionLabels returns a map[string]string of common labels for the pre promotion analysis
@@ -46,6 +101,18 @@ func PrePromotionLabels(podHash, instanceID string) map[string]string {
 
 }
 
+// PostPromotionLabels returns a map[string]string of common labels for the post promotion analysis
+func PostPromotionLabels(podHash, instanceID string) map[string]string {
+	labels := map[string]string{
+		v1alpha1.DefaultRolloutUniqueLabelKey: podHash,
+		v1alpha1.RolloutTypeLabel:             v1alpha1.RolloutTypePostPromotionLabel,
+	}
+	if instanceID != "" {
+		labels[v1alpha1.LabelKeyControllerInstanceID] = instanceID
+	}
+	return labels
+}
+
 // BackgroundLabels returns a map[string]string of common labels for the background analysis
 func BackgroundLabels(podHash, instanceID string) map[string]string {
 	labels := map[string]string{
@@ -124,18 +191,89 @@ func ValidateMetric(metric v1alpha1.Metric) error {
 	if metric.ConsecutiveErrorLimit != nil && *metric.ConsecutiveErrorLimit < 0 {
 		return fmt.Errorf("consecutiveErrorLimit must be >= 0")
 	}
+	if policy := metric.RetryPolicy; policy != nil {
+		if policy.InitialInterval != "" {
+			if interval, err := policy.InitialInterval.Duration(); err != nil {
+				return fmt.Errorf("invalid retryPolicy.initialInterval string: %v", err)
+			} else if interval <= 0 {
+				return fmt.Errorf("retryPolicy.initialInterval must be positive")
+			}
+		}
+		if policy.Multiplier < 0 {
+			return fmt.Errorf("retryPolicy.multiplier must be >= 1")
+		}
+		if policy.MaxAttempts < 0 {
+			return fmt.Errorf("retryPolicy.maxAttempts must be >= 1")
+		}
+	}
 	numProviders := 0
 	if metric.Provider.Prometheus != nil {
 		numProviders++
+		if err := validatePrometheusMetric(metric.Provider.Prometheus); err != nil {
+			return err
+		}
 	}
 	if metric.Provider.Job != nil {
 		numProviders++
 	}
 	if metric.Provider.Web != nil {
 		numProviders++
+		if err := validateWebMetric(metric.Provider.Web); err != nil {
+			return err
+		}
 	}
 	if metric.Provider.Wavefront != nil {
 		numProviders++
+		if metric.Provider.Wavefront.Window != "" {
+			if window, err := metric.Provider.Wavefront.Window.Duration(); err != nil {
+				return fmt.Errorf("invalid wavefront window string: %v", err)
+			} else if window <= 0 {
+				return fmt.Errorf("wavefront window must be greater than 0")
+			}
+		}
+		if metric.Provider.Wavefront.NoDataRetries < 0 {
+			return fmt.Errorf("wavefront noDataRetries must be >= 0")
+		}
+		if metric.Provider.Wavefront.NoDataRetryDelay != "" {
+			if _, err := metric.Provider.Wavefront.NoDataRetryDelay.Duration(); err != nil {
+				return fmt.Errorf("invalid wavefront noDataRetryDelay string: %v", err)
+			}
+		}
+	}
+	if metric.Provider.CloudEvent != nil {
+		numProviders++
+	}
+	if metric.Provider.Datadog != nil {
+		numProviders++
+		if err := validateDatadogMetric(metric.Provider.Datadog); err != nil {
+			return err
+		}
+		if metric.Provider.Datadog.Interval != "" {
+			if interval, err := metric.Provider.Datadog.Interval.Duration(); err != nil {
+				return fmt.Errorf("invalid datadog interval string: %v", err)
+			} else if interval <= 0 {
+				return fmt.Errorf("datadog interval must be greater than 0")
+			}
+		}
+	}
+	if metric.Provider.Graphite != nil {
+		numProviders++
+		if metric.Provider.Graphite.Interval != "" {
+			if interval, err := metric.Provider.Graphite.Interval.Duration(); err != nil {
+				return fmt.Errorf("invalid graphite interval string: %v", err)
+			} else if interval < time.Second {
+				return fmt.Errorf("graphite interval must be at least 1s")
+			}
+		}
+	}
+	if metric.Provider.Influxdb != nil {
+		numProviders++
+	}
+	if metric.Provider.Plugin != nil {
+		numProviders++
+		if metric.Provider.Plugin.Name == "" {
+			return fmt.Errorf("plugin name must be specified")
+		}
 	}
 	if numProviders == 0 {
 		return fmt.Errorf("no provider specified")
@@ -145,3 +283,104 @@ func ValidateMetric(metric v1alpha1.Metric) error {
 	}
 	return nil
 }
+
+// validatePrometheusMetric validates the range and the authentication of a prometheus metric
+func validatePrometheusMetric(prometheus *v1alpha1.PrometheusMetric) error {
+	if prometheus.Range != nil {
+		if prometheus.Range.Duration == "" {
+			return fmt.Errorf("prometheus range duration must be specified")
+		}
+		if duration, err := prometheus.Range.Duration.Duration(); err != nil {
+			return fmt.Errorf("invalid prometheus range duration string: %v", err)
+		} else if duration <= 0 {
+			return fmt.Errorf("prometheus range duration must be greater than 0")
+		}
+		if prometheus.Range.Step != "" {
+			if step, err := prometheus.Range.Step.Duration(); err != nil {
+				return fmt.Errorf("invalid prometheus range step string: %v", err)
+			} else if step <= 0 {
+				return fmt.Errorf("prometheus range step must be greater than 0")
+			}
+		}
+		switch prometheus.Range.Aggregator {
+		case "", "avg", "min", "max", "last", "sum":
+		default:
+			return fmt.Errorf("prometheus range aggregator must be one of avg, min, max, last or sum")
+		}
+	}
+	if auth := prometheus.Authentication; auth != nil {
+		if auth.BearerTokenSecretKeyRef != nil && auth.BasicAuth != nil {
+			return fmt.Errorf("prometheus bearerTokenSecretKeyRef and basicAuth can not both be specified")
+		}
+		if auth.BasicAuth != nil && auth.BasicAuth.PasswordSecretKeyRef == nil {
+			return fmt.Errorf("prometheus basicAuth passwordSecretKeyRef must be specified")
+		}
+	}
+	return nil
+}
+
+// validateWebMetric validates the headers read from secrets and the TLS options of a web metric
+func validateWebMetric(web *v1alpha1.WebMetric) error {
+	for _, header := range web.Headers {
+		if header.ValueFrom == nil {
+			continue
+		}
+		if header.Value != "" {
+			return fmt.Errorf("web header '%s' has both value and valueFrom fields", header.Key)
+		}
+		if header.ValueFrom.SecretKeyRef == nil {
+			return fmt.Errorf("web header '%s' does not contain a secret reference", header.Key)
+		}
+	}
+	if web.TLS != nil && (web.TLS.CertSecretKeyRef == nil) != (web.TLS.KeySecretKeyRef == nil) {
+		return fmt.Errorf("web tls certSecretKeyRef and keySecretKeyRef must be specified together")
+	}
+	return nil
+}
+
+func validateDatadogMetric(datadog *v1alpha1.DatadogMetric) error {
+	if datadog.MonitorID != "" && datadog.SLOID != "" {
+		return fmt.Errorf("datadog monitorId and sloId can not both be specified")
+	}
+	if datadog.MonitorID != "" || datadog.SLOID != "" {
+		if datadog.Query != "" || len(datadog.Queries) > 0 || datadog.Formula != "" {
+			return fmt.Errorf("datadog monitorId and sloId can not be combined with query, queries or formula")
+		}
+		return nil
+	}
+	if datadog.SLOTimeframe != "" {
+		return fmt.Errorf("datadog sloTimeframe can only be specified with sloId")
+	}
+	switch datadog.APIVersion {
+	case "", "v1":
+		if datadog.Query == "" {
+			return fmt.Errorf("datadog query must be specified")
+		}
+		if len(datadog.Queries) > 0 || datadog.Formula != "" {
+			return fmt.Errorf("datadog queries and formula are only supported by apiVersion v2")
+		}
+	case "v2":
+		if datadog.Query != "" && len(datadog.Queries) > 0 {
+			return fmt.Errorf("datadog query and queries can not both be specified")
+		}
+		if datadog.Query == "" && len(datadog.Queries) == 0 {
+			return fmt.Errorf("datadog query or queries must be specified")
+		}
+		if datadog.Formula == "" && len(datadog.Queries) > 1 {
+			return fmt.Errorf("datadog formula must be specified with multiple queries")
+		}
+	default:
+		return fmt.Errorf("datadog apiVersion must be v1 or v2")
+	}
+	switch datadog.Aggregator {
+	case "", "avg", "min", "max", "last", "sum", "p95":
+	default:
+		return fmt.Errorf("datadog aggregator must be one of avg, min, max, last, sum or p95")
+	}
+	switch datadog.MultipleSeries {
+	case "", "first", "each", "merge":
+	default:
+		return fmt.Errorf("datadog multipleSeries must be one of first, each or merge")
+	}
+	return nil
+}
@@ -46,13 +46,63 @@ func TestBuildArgumentsForRolloutAnalysisRun(t *testing.T) {
 			Labels: map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: "123456"},
 		},
 	}
-	args := BuildArgumentsForRolloutAnalysisRun(rolloutAnalysis.Args, stableRS, newRS)
+	args, err := BuildArgumentsForRolloutAnalysisRun(rolloutAnalysis.Args, stableRS, newRS, &v1alpha1.Rollout{})
+	assert.NoError(t, err)
 	assert.Contains(t, args, v1alpha1.Argument{Name: "hard-coded-value-key", Value: pointer.StringPtr("hard-coded-value")})
 	assert.Contains(t, args, v1alpha1.Argument{Name: "stable-key", Value: pointer.StringPtr("abcdef")})
 	assert.Contains(t, args, v1alpha1.Argument{Name: "new-key", Value: pointer.StringPtr("123456")})
 
 }
 
+func TestBuildArgumentsFromSecretsAndFields(t *testing.T) {
+	r := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "guestbook",
+			Namespace:   "apps",
+			Labels:      map[string]string{"app.kubernetes.io/name": "guestbook"},
+			Annotations: map[string]string{"team": "checkout"},
+		},
+		Spec: v1alpha1.RolloutSpec{
+			Replicas: pointer.Int32Ptr(5),
+		},
+	}
+	fieldRef := func(path string) *v1alpha1.ArgumentValueFrom {
+		return &v1alpha1.ArgumentValueFrom{FieldRef: &v1alpha1.FieldRef{FieldPath: path}}
+	}
+	secretRef := &v1alpha1.SecretKeyRef{Name: "datadog", Key: "api-key"}
+	args, err := BuildArgumentsForRolloutAnalysisRun([]v1alpha1.AnalysisRunArgument{
+		{Name: "api-key", ValueFrom: &v1alpha1.ArgumentValueFrom{SecretKeyRef: secretRef}},
+		{Name: "name", ValueFrom: fieldRef("metadata.name")},
+		{Name: "namespace", ValueFrom: fieldRef("metadata.namespace")},
+		{Name: "app", ValueFrom: fieldRef("metadata.labels['app.kubernetes.io/name']")},
+		{Name: "team", ValueFrom: fieldRef("metadata.annotations['team']")},
+		{Name: "replicas", ValueFrom: fieldRef("spec.replicas")},
+	}, nil, nil, r)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1alpha1.Argument{
+		{Name: "api-key", ValueFrom: &v1alpha1.ValueFrom{SecretKeyRef: secretRef}},
+		{Name: "name", Value: pointer.StringPtr("guestbook")},
+		{Name: "namespace", Value: pointer.StringPtr("apps")},
+		{Name: "app", Value: pointer.StringPtr("guestbook")},
+		{Name: "team", Value: pointer.StringPtr("checkout")},
+		{Name: "replicas", Value: pointer.StringPtr("5")},
+	}, args)
+
+	tests := []struct {
+		fieldPath string
+		err       string
+	}{
+		{"metadata.labels['missing']", "failed to resolve args.arg: field path 'metadata.labels['missing']' not found"},
+		{"metadata.labels", "failed to resolve args.arg: field path 'metadata.labels' is not a string, number or boolean"},
+	}
+	for _, test := range tests {
+		_, err := BuildArgumentsForRolloutAnalysisRun([]v1alpha1.AnalysisRunArgument{{Name: "arg", ValueFrom: fieldRef(test.fieldPath)}}, nil, nil, r)
+		assert.EqualError(t, err, test.err)
+	}
+	_, err = BuildArgumentsForRolloutAnalysisRun([]v1alpha1.AnalysisRunArgument{{Name: "arg", ValueFrom: fieldRef("metadata.name.first")}}, nil, nil, r)
+	assert.Contains(t, err.Error(), "failed to resolve args.arg: invalid field path 'metadata.name.first'")
+}
+
 func TestPrePromotionLabels(t *testing.T) {
 	podHash := "abcd123"
 	expected := map[string]string{
@@ -64,6 +114,17 @@ func TestPrePromotionLabels(t *testing.T) {
 	assert.Equal(t, expected, generated)
 }
 
+func TestPostPromotionLabels(t *testing.T) {
+	podHash := "abcd123"
+	expected := map[string]string{
+		v1alpha1.LabelKeyControllerInstanceID: "test",
+		v1alpha1.RolloutTypeLabel:             v1alpha1.RolloutTypePostPromotionLabel,
+		v1alpha1.DefaultRolloutUniqueLabelKey: podHash,
+	}
+	generated := PostPromotionLabels(podHash, "test")
+	assert.Equal(t, expected, generated)
+}
+
 func TestStepLabels(t *testing.T) {
 	podHash := "abcd123"
 	expected := map[string]string{
@@ -265,6 +326,24 @@ func TestValidateMetrics(t *testing.T) {
 		err := ValidateMetrics(spec.Metrics)
 		assert.EqualError(t, err, "metrics[0]: consecutiveErrorLimit must be >= 0")
 	}
+	{
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name:        "success-rate",
+					RetryPolicy: &v1alpha1.MetricRetryPolicy{InitialInterval: "0s"},
+					Provider: v1alpha1.MetricProvider{
+						Prometheus: &v1alpha1.PrometheusMetric{},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: retryPolicy.initialInterval must be positive")
+		spec.Metrics[0].RetryPolicy = &v1alpha1.MetricRetryPolicy{MaxAttempts: -1}
+		err = ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: retryPolicy.maxAttempts must be >= 1")
+	}
 	{
 		spec := v1alpha1.AnalysisTemplateSpec{
 			Metrics: []v1alpha1.Metric{
@@ -292,6 +371,128 @@ func TestValidateMetrics(t *testing.T) {
 		err := ValidateMetrics(spec.Metrics)
 		assert.EqualError(t, err, "metrics[0]: multiple providers specified")
 	}
+	{
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name: "success-rate",
+					Provider: v1alpha1.MetricProvider{
+						Datadog: &v1alpha1.DatadogMetric{Query: "avg:requests.error.rate{*}", Interval: "5m-typo"},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: invalid datadog interval string: time: unknown unit m-typo in duration 5m-typo")
+	}
+	{
+		spec := v1alpha1.AnalysisTemplateSpec{
+			Metrics: []v1alpha1.Metric{
+				{
+					Name: "success-rate",
+					Provider: v1alpha1.MetricProvider{
+						Datadog: &v1alpha1.DatadogMetric{Query: "avg:requests.error.rate{*}", Interval: "0s"},
+					},
+				},
+			},
+		}
+		err := ValidateMetrics(spec.Metrics)
+		assert.EqualError(t, err, "metrics[0]: datadog interval must be greater than 0")
+	}
+	{
+		tests := []struct {
+			datadog v1alpha1.DatadogMetric
+			err     string
+		}{
+			{v1alpha1.DatadogMetric{}, "metrics[0]: datadog query must be specified"},
+			{v1alpha1.DatadogMetric{Query: "a", Formula: "a * 100"}, "metrics[0]: datadog queries and formula are only supported by apiVersion v2"},
+			{v1alpha1.DatadogMetric{APIVersion: "v3", Query: "a"}, "metrics[0]: datadog apiVersion must be v1 or v2"},
+			{v1alpha1.DatadogMetric{APIVersion: "v2"}, "metrics[0]: datadog query or queries must be specified"},
+			{v1alpha1.DatadogMetric{APIVersion: "v2", Query: "a", Queries: map[string]string{"a": "a"}}, "metrics[0]: datadog query and queries can not both be specified"},
+			{v1alpha1.DatadogMetric{APIVersion: "v2", Queries: map[string]string{"a": "a", "b": "b"}}, "metrics[0]: datadog formula must be specified with multiple queries"},
+			{v1alpha1.DatadogMetric{Query: "a", Aggregator: "median"}, "metrics[0]: datadog aggregator must be one of avg, min, max, last, sum or p95"},
+			{v1alpha1.DatadogMetric{Query: "a", MultipleSeries: "all"}, "metrics[0]: datadog multipleSeries must be one of first, each or merge"},
+			{v1alpha1.DatadogMetric{Query: "a", MonitorID: "1234"}, "metrics[0]: datadog monitorId and sloId can not be combined with query, queries or formula"},
+			{v1alpha1.DatadogMetric{MonitorID: "1234", SLOID: "abcd"}, "metrics[0]: datadog monitorId and sloId can not both be specified"},
+			{v1alpha1.DatadogMetric{Query: "a", SLOTimeframe: "7d"}, "metrics[0]: datadog sloTimeframe can only be specified with sloId"},
+		}
+		for _, test := range tests {
+			datadog := test.datadog
+			metrics := []v1alpha1.Metric{{Name: "success-rate", Provider: v1alpha1.MetricProvider{Datadog: &datadog}}}
+			assert.EqualError(t, ValidateMetrics(metrics), test.err)
+		}
+		metrics := []v1alpha1.Metric{{Name: "success-rate", Provider: v1alpha1.MetricProvider{Datadog: &v1alpha1.DatadogMetric{
+			APIVersion: "v2",
+			Queries:    map[string]string{"errors": "sum:requests.error{*}.as_count()", "hits": "sum:requests.hit{*}.as_count()"},
+			Formula:    "errors / hits * 100",
+		}}}}
+		assert.NoError(t, ValidateMetrics(metrics))
+		metrics = []v1alpha1.Metric{{Name: "monitor", Provider: v1alpha1.MetricProvider{Datadog: &v1alpha1.DatadogMetric{MonitorID: "{{args.monitor-id}}"}}}}
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
+	{
+		tests := []struct {
+			prometheus v1alpha1.PrometheusMetric
+			err        string
+		}{
+			{v1alpha1.PrometheusMetric{Range: &v1alpha1.PrometheusRange{}}, "metrics[0]: prometheus range duration must be specified"},
+			{v1alpha1.PrometheusMetric{Range: &v1alpha1.PrometheusRange{Duration: "10m", Step: "0s"}}, "metrics[0]: prometheus range step must be greater than 0"},
+			{v1alpha1.PrometheusMetric{Range: &v1alpha1.PrometheusRange{Duration: "10m", Aggregator: "p95"}}, "metrics[0]: prometheus range aggregator must be one of avg, min, max, last or sum"},
+			{v1alpha1.PrometheusMetric{Authentication: &v1alpha1.PrometheusAuthentication{BasicAuth: &v1alpha1.PrometheusBasicAuth{Username: "a"}}}, "metrics[0]: prometheus basicAuth passwordSecretKeyRef must be specified"},
+		}
+		for _, test := range tests {
+			prometheus := test.prometheus
+			metrics := []v1alpha1.Metric{{Name: "error-rate", Provider: v1alpha1.MetricProvider{Prometheus: &prometheus}}}
+			assert.EqualError(t, ValidateMetrics(metrics), test.err)
+		}
+		metrics := []v1alpha1.Metric{{Name: "error-rate", Provider: v1alpha1.MetricProvider{Prometheus: &v1alpha1.PrometheusMetric{
+			Range: &v1alpha1.PrometheusRange{Duration: "10m", Step: "30s", Aggregator: "max"},
+		}}}}
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
+	{
+		secretRef := &v1alpha1.ValueFrom{SecretKeyRef: &v1alpha1.SecretKeyRef{Name: "scoring", Key: "token"}}
+		tests := []struct {
+			web v1alpha1.WebMetric
+			err string
+		}{
+			{v1alpha1.WebMetric{Headers: []v1alpha1.WebMetricHeader{{Key: "Authorization", Value: "a", ValueFrom: secretRef}}}, "metrics[0]: web header 'Authorization' has both value and valueFrom fields"},
+			{v1alpha1.WebMetric{Headers: []v1alpha1.WebMetricHeader{{Key: "Authorization", ValueFrom: &v1alpha1.ValueFrom{}}}}, "metrics[0]: web header 'Authorization' does not contain a secret reference"},
+			{v1alpha1.WebMetric{TLS: &v1alpha1.WebMetricTLSConfig{CertSecretKeyRef: secretRef.SecretKeyRef}}, "metrics[0]: web tls certSecretKeyRef and keySecretKeyRef must be specified together"},
+		}
+		for _, test := range tests {
+			web := test.web
+			metrics := []v1alpha1.Metric{{Name: "score", Provider: v1alpha1.MetricProvider{Web: &web}}}
+			assert.EqualError(t, ValidateMetrics(metrics), test.err)
+		}
+		metrics := []v1alpha1.Metric{{Name: "score", Provider: v1alpha1.MetricProvider{Web: &v1alpha1.WebMetric{
+			Headers: []v1alpha1.WebMetricHeader{{Key: "Authorization", ValueFrom: secretRef}},
+			TLS:     &v1alpha1.WebMetricTLSConfig{CertSecretKeyRef: secretRef.SecretKeyRef, KeySecretKeyRef: secretRef.SecretKeyRef},
+		}}}}
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
+	{
+		metrics := []v1alpha1.Metric{{Name: "score", Provider: v1alpha1.MetricProvider{Plugin: &v1alpha1.PluginMetric{}}}}
+		assert.EqualError(t, ValidateMetrics(metrics), "metrics[0]: plugin name must be specified")
+		metrics[0].Provider.Plugin.Name = "acme/score"
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
+	{
+		metrics := []v1alpha1.Metric{{Name: "wavefront", Provider: v1alpha1.MetricProvider{Wavefront: &v1alpha1.WavefrontMetric{Query: "a", Window: "0s"}}}}
+		assert.EqualError(t, ValidateMetrics(metrics), "metrics[0]: wavefront window must be greater than 0")
+		metrics[0].Provider.Wavefront.Window = "5m"
+		metrics[0].Provider.Wavefront.NoDataRetries = -1
+		assert.EqualError(t, ValidateMetrics(metrics), "metrics[0]: wavefront noDataRetries must be >= 0")
+		metrics[0].Provider.Wavefront.NoDataRetries = 3
+		metrics[0].Provider.Wavefront.NoDataRetryDelay = "1m"
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
+	{
+		metrics := []v1alpha1.Metric{{Name: "graphite", Provider: v1alpha1.MetricProvider{Graphite: &v1alpha1.GraphiteMetric{Query: "a", Interval: "500ms"}}}}
+		assert.EqualError(t, ValidateMetrics(metrics), "metrics[0]: graphite interval must be at least 1s")
+		metrics[0].Provider.Graphite.Interval = "10m"
+		assert.NoError(t, ValidateMetrics(metrics))
+	}
 	{
 		spec := v1alpha1.AnalysisTemplateSpec{
 			Metrics: []v1alpha1.Metric{
@@ -1,6 +1,8 @@
 package analysis
 
 import (
+	"sort"
+
 	appsv1 "k8s.io/api/apps/v1"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -41,6 +43,12 @@ func FilterCurrentRolloutAnalysisRuns(analysisRuns []*v1alpha1.AnalysisRun, r *v
 		if ar.Name == r.Status.BlueGreen.PrePromotionAnalysisRun {
 			return true
 		}
+		if ar.Name == r.Status.BlueGreen.PostPromotionAnalysisRun {
+			return true
+		}
+		if ar.Name == r.Status.SLO.CurrentAnalysisRun {
+			return true
+		}
 		return false
 	})
 }
@@ -149,3 +157,35 @@ func FilterAnalysisRunsToDelete(ars []*v1alpha1.AnalysisRun, olderRSs []*appsv1.
 	}
 	return arsToDelete
 }
+
+// FilterAnalysisRunsExceedingHistoryLimits returns the completed analysis runs older than the most recent
+// successfulLimit successful runs and unsuccessfulLimit unsuccessful runs. Runs which are not completed are
+// always retained.
+func FilterAnalysisRunsExceedingHistoryLimits(ars []*v1alpha1.AnalysisRun, successfulLimit, unsuccessfulLimit int32) []*v1alpha1.AnalysisRun {
+	completedArs := []*v1alpha1.AnalysisRun{}
+	for i := range ars {
+		ar := ars[i]
+		if ar != nil && ar.Status.Phase.Completed() {
+			completedArs = append(completedArs, ar)
+		}
+	}
+	sort.SliceStable(completedArs, func(i, j int) bool {
+		return completedArs[j].CreationTimestamp.Before(&completedArs[i].CreationTimestamp)
+	})
+	arsToDelete := []*v1alpha1.AnalysisRun{}
+	successful, unsuccessful := int32(0), int32(0)
+	for _, ar := range completedArs {
+		if ar.Status.Phase == v1alpha1.AnalysisPhaseSuccessful {
+			successful++
+			if successful > successfulLimit {
+				arsToDelete = append(arsToDelete, ar)
+			}
+		} else {
+			unsuccessful++
+			if unsuccessful > unsuccessfulLimit {
+				arsToDelete = append(arsToDelete, ar)
+			}
+		}
+	}
+	return arsToDelete
+}
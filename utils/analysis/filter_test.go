@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
@@ -77,14 +78,16 @@ func TestFilterCurrentRolloutAnalysisRuns(t *testing.T) {
 		r := &v1alpha1.Rollout{
 			Status: v1alpha1.RolloutStatus{
 				BlueGreen: v1alpha1.BlueGreenStatus{
-					PrePromotionAnalysisRun: "foo",
+					PrePromotionAnalysisRun:  "foo",
+					PostPromotionAnalysisRun: "bar",
 				},
 			},
 		}
 		currentArs, nonCurrentArs := FilterCurrentRolloutAnalysisRuns(ars, r)
-		assert.Len(t, currentArs, 1)
-		assert.Len(t, nonCurrentArs, 2)
+		assert.Len(t, currentArs, 2)
+		assert.Len(t, nonCurrentArs, 1)
 		assert.Contains(t, currentArs, ars[0])
+		assert.Contains(t, currentArs, ars[1])
 	})
 }
 
@@ -192,6 +195,32 @@ func TestFilterAnalysisRunsToDelete(t *testing.T) {
 	assert.Contains(t, filteredArs, arWithNoMatchingRS)
 }
 
+func TestFilterAnalysisRunsExceedingHistoryLimits(t *testing.T) {
+	now := metav1.Now()
+	ar := func(name string, phase v1alpha1.AnalysisPhase, age int) *v1alpha1.AnalysisRun {
+		return &v1alpha1.AnalysisRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-time.Duration(age) * time.Minute)),
+			},
+			Status: v1alpha1.AnalysisRunStatus{Phase: phase},
+		}
+	}
+	successful1 := ar("successful1", v1alpha1.AnalysisPhaseSuccessful, 1)
+	successful2 := ar("successful2", v1alpha1.AnalysisPhaseSuccessful, 2)
+	successful3 := ar("successful3", v1alpha1.AnalysisPhaseSuccessful, 3)
+	failed := ar("failed", v1alpha1.AnalysisPhaseFailed, 4)
+	errored := ar("errored", v1alpha1.AnalysisPhaseError, 5)
+	running := ar("running", v1alpha1.AnalysisPhaseRunning, 6)
+	ars := []*v1alpha1.AnalysisRun{successful3, running, errored, successful1, nil, failed, successful2}
+
+	filteredArs := FilterAnalysisRunsExceedingHistoryLimits(ars, 2, 1)
+	assert.Equal(t, []*v1alpha1.AnalysisRun{successful3, errored}, filteredArs)
+
+	assert.Len(t, FilterAnalysisRunsExceedingHistoryLimits(ars, 5, 5), 0)
+	assert.Len(t, FilterAnalysisRunsExceedingHistoryLimits(ars, 0, 0), 5)
+}
+
 func TestSortAnalysisRunByPodHash(t *testing.T) {
 	emptyMap := SortAnalysisRunByPodHash(nil)
 	assert.NotNil(t, 0)
@@ -14,6 +14,7 @@ import (
 	patchtypes "k8s.io/apimachinery/pkg/types"
 
 	argoprojclient "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/typed/rollouts/v1alpha1"
+	listers "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
 )
 
 // analysisStatusOrder is a list of completed analysis sorted in best to worst condition
@@ -58,6 +59,10 @@ func IsTerminating(run *v1alpha1.AnalysisRun) bool {
 		return true
 	}
 	for _, res := range run.Status.MetricResults {
+		if res.DryRun {
+			// dry-run metrics never stop the other metrics of the run
+			continue
+		}
 		switch res.Phase {
 		case v1alpha1.AnalysisPhaseFailed, v1alpha1.AnalysisPhaseError, v1alpha1.AnalysisPhaseInconclusive:
 			return true
@@ -141,12 +146,19 @@ func MergeArgs(incomingArgs, templateArgs []v1alpha1.Argument) ([]v1alpha1.Argum
 	newArgs := append(templateArgs[:0:0], templateArgs...)
 	for _, arg := range incomingArgs {
 		i := findArg(arg.Name, newArgs)
-		if i >= 0 && arg.Value != nil {
+		if i < 0 {
+			continue
+		}
+		if arg.Value != nil {
 			newArgs[i].Value = arg.Value
+			newArgs[i].ValueFrom = nil
+		} else if arg.ValueFrom != nil {
+			newArgs[i].Value = nil
+			newArgs[i].ValueFrom = arg.ValueFrom
 		}
 	}
 	for _, arg := range newArgs {
-		if arg.Value == nil {
+		if arg.Value == nil && arg.ValueFrom == nil {
 			return nil, fmt.Errorf("args.%s was not resolved", arg.Name)
 		}
 	}
@@ -300,6 +312,27 @@ func NewAnalysisRunFromTemplate(template *v1alpha1.AnalysisTemplate, args []v1al
 	return &ar, nil
 }
 
+// GetTemplate returns the AnalysisTemplate of the namespace with the name, or the
+// ClusterAnalysisTemplate with the name if clusterScope is set. ClusterAnalysisTemplates are returned
+// as an AnalysisTemplate with the same name and spec, so runs are created from both the same way.
+func GetTemplate(templateLister listers.AnalysisTemplateLister, clusterTemplateLister listers.ClusterAnalysisTemplateLister, namespace, name string, clusterScope bool) (*v1alpha1.AnalysisTemplate, error) {
+	if !clusterScope {
+		return templateLister.AnalysisTemplates(namespace).Get(name)
+	}
+	clusterTemplate, err := clusterTemplateLister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        clusterTemplate.Name,
+			Labels:      clusterTemplate.Labels,
+			Annotations: clusterTemplate.Annotations,
+		},
+		Spec: *clusterTemplate.Spec.DeepCopy(),
+	}, nil
+}
+
 // GetInstanceID takes an object and returns the controller instance id if it has one
 func GetInstanceID(obj runtime.Object) string {
 	objMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
@@ -7,6 +7,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	informers "github.com/argoproj/argo-rollouts/pkg/client/informers/externalversions"
 )
 
 func TestIsWorst(t *testing.T) {
@@ -184,6 +186,9 @@ func TestIsTerminating(t *testing.T) {
 	successRate.Phase = v1alpha1.AnalysisPhaseError
 	run.Status.MetricResults[1] = successRate
 	assert.True(t, IsTerminating(run))
+	successRate.DryRun = true
+	run.Status.MetricResults[1] = successRate
+	assert.False(t, IsTerminating(run))
 }
 
 func TestTerminateRun(t *testing.T) {
@@ -575,6 +580,31 @@ func TestMergeArgs(t *testing.T) {
 		assert.Equal(t, "foo", args[0].Name)
 		assert.Equal(t, "my-value", *args[0].Value)
 	}
+	{
+		// secret references
+		secretRef := &v1alpha1.ValueFrom{SecretKeyRef: &v1alpha1.SecretKeyRef{Name: "datadog", Key: "api-key"}}
+		args, err := MergeArgs(
+			[]v1alpha1.Argument{
+				{
+					Name:      "api-key",
+					ValueFrom: secretRef,
+				},
+			}, []v1alpha1.Argument{
+				{
+					Name:  "api-key",
+					Value: pointer.StringPtr("default"),
+				},
+				{
+					Name:      "app-key",
+					ValueFrom: secretRef,
+				},
+			})
+		assert.NoError(t, err)
+		assert.Len(t, args, 2)
+		assert.Nil(t, args[0].Value)
+		assert.Equal(t, secretRef, args[0].ValueFrom)
+		assert.Equal(t, secretRef, args[1].ValueFrom)
+	}
 }
 
 //TODO(dthomson) remove this test in v0.9.0
@@ -612,6 +642,43 @@ func TestNewAnalysisRunFromTemplate(t *testing.T) {
 	assert.Equal(t, "my-val", *run.Spec.Args[0].Value)
 }
 
+func TestGetTemplate(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	templateInformer := factory.Argoproj().V1alpha1().AnalysisTemplates()
+	clusterTemplateInformer := factory.Argoproj().V1alpha1().ClusterAnalysisTemplates()
+	spec := v1alpha1.AnalysisTemplateSpec{
+		Metrics: []v1alpha1.Metric{{Name: "success-rate"}},
+	}
+	template := &v1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "success-rate", Namespace: metav1.NamespaceDefault},
+		Spec:       spec,
+	}
+	clusterTemplate := &v1alpha1.ClusterAnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "golden-signals", Labels: map[string]string{"team": "platform"}},
+		Spec:       spec,
+	}
+	assert.NoError(t, templateInformer.Informer().GetIndexer().Add(template))
+	assert.NoError(t, clusterTemplateInformer.Informer().GetIndexer().Add(clusterTemplate))
+	templateLister := templateInformer.Lister()
+	clusterTemplateLister := clusterTemplateInformer.Lister()
+
+	found, err := GetTemplate(templateLister, clusterTemplateLister, metav1.NamespaceDefault, "success-rate", false)
+	assert.NoError(t, err)
+	assert.Equal(t, template, found)
+
+	found, err = GetTemplate(templateLister, clusterTemplateLister, "other", "golden-signals", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "golden-signals", found.Name)
+	assert.Equal(t, "", found.Namespace)
+	assert.Equal(t, "platform", found.Labels["team"])
+	assert.Equal(t, spec, found.Spec)
+
+	_, err = GetTemplate(templateLister, clusterTemplateLister, metav1.NamespaceDefault, "golden-signals", false)
+	assert.True(t, k8serrors.IsNotFound(err))
+	_, err = GetTemplate(templateLister, clusterTemplateLister, metav1.NamespaceDefault, "success-rate", true)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
 func TestGetInstanceID(t *testing.T) {
 	run := &v1alpha1.AnalysisRun{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	// SLOWindowPlaceholder is replaced by the windows of the burn rates in the error ratio query of an objective
+	SLOWindowPlaceholder = "$(window)"
+	// DefaultSLOInterval is the interval between the evaluations of the burn rates
+	DefaultSLOInterval v1alpha1.DurationString = "1m"
+)
+
+// DefaultBurnRates are the fast burn rates of an objective which does not list any: 2% of a 30 day
+// budget burned in an hour, or 5% burned in six hours
+var DefaultBurnRates = []v1alpha1.BurnRate{
+	{LongWindow: "1h", ShortWindow: "5m", Threshold: "14.4"},
+	{LongWindow: "6h", ShortWindow: "30m", Threshold: "6"},
+}
+
+// SLOLabels returns a map[string]string of common labels for the SLO analysis
+func SLOLabels(podHash, instanceID string) map[string]string {
+	labels := map[string]string{
+		v1alpha1.DefaultRolloutUniqueLabelKey: podHash,
+		v1alpha1.RolloutTypeLabel:             v1alpha1.RolloutTypeSLOLabel,
+	}
+	if instanceID != "" {
+		labels[v1alpha1.LabelKeyControllerInstanceID] = instanceID
+	}
+	return labels
+}
+
+// NewAnalysisRunFromSLOAnalysis returns an AnalysisRun evaluating the burn rates of the objectives
+func NewAnalysisRunFromSLOAnalysis(slo *v1alpha1.SLOAnalysis, name, namespace string) (*v1alpha1.AnalysisRun, error) {
+	metrics, err := SLOMetrics(slo)
+	if err != nil {
+		return nil, err
+	}
+	ar := v1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.AnalysisRunSpec{
+			Metrics: metrics,
+		},
+	}
+	return &ar, nil
+}
+
+// SLOMetrics returns a prometheus metric per burn rate of the objectives. The metric fails when the
+// error budget burned over both windows of the burn rate faster than the threshold.
+func SLOMetrics(slo *v1alpha1.SLOAnalysis) ([]v1alpha1.Metric, error) {
+	if slo.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	if len(slo.Objectives) == 0 {
+		return nil, fmt.Errorf("no objectives specified")
+	}
+	interval := slo.Interval
+	if interval == "" {
+		interval = DefaultSLOInterval
+	}
+	if slo.PostPromotionWindow != "" {
+		if _, err := slo.PostPromotionWindow.Duration(); err != nil {
+			return nil, fmt.Errorf("invalid postPromotionWindow string: %v", err)
+		}
+	}
+	var metrics []v1alpha1.Metric
+	for i, objective := range slo.Objectives {
+		target, err := strconv.ParseFloat(objective.Target, 64)
+		if err != nil || target <= 0 || target >= 100 {
+			return nil, fmt.Errorf("objectives[%d]: target must be a percentage between 0 and 100", i)
+		}
+		if objective.ErrorRatioQuery == "" {
+			return nil, fmt.Errorf("objectives[%d]: errorRatioQuery is required", i)
+		}
+		budget := strconv.FormatFloat((100-target)/100, 'g', 10, 64)
+		burnRates := objective.BurnRates
+		if len(burnRates) == 0 {
+			burnRates = DefaultBurnRates
+		}
+		for j, burnRate := range burnRates {
+			threshold, err := strconv.ParseFloat(burnRate.Threshold, 64)
+			if err != nil || threshold <= 0 {
+				return nil, fmt.Errorf("objectives[%d].burnRates[%d]: threshold must be a number greater than 0", i, j)
+			}
+			for _, window := range []v1alpha1.DurationString{burnRate.LongWindow, burnRate.ShortWindow} {
+				if _, err := window.Duration(); err != nil {
+					return nil, fmt.Errorf("objectives[%d].burnRates[%d]: invalid window string: %v", i, j, err)
+				}
+			}
+			metrics = append(metrics, v1alpha1.Metric{
+				Name:     fmt.Sprintf("%s-%s-%s", objective.Name, burnRate.LongWindow, burnRate.ShortWindow),
+				Interval: interval,
+				// windows without events have no error ratio and do not fail the metric
+				FailureCondition: fmt.Sprintf("len(result) == 2 && all(result, {# >= %s})", strconv.FormatFloat(threshold, 'g', -1, 64)),
+				Provider: v1alpha1.MetricProvider{
+					Prometheus: &v1alpha1.PrometheusMetric{
+						Address: slo.Address,
+						Query:   burnRateQuery(objective.ErrorRatioQuery, budget, burnRate),
+					},
+				},
+			})
+		}
+	}
+	if err := ValidateMetrics(metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// burnRateQuery returns a query of the burn rates over the long and short window of the burn rate
+func burnRateQuery(errorRatioQuery, budget string, burnRate v1alpha1.BurnRate) string {
+	window := func(w v1alpha1.DurationString) string {
+		query := strings.Replace(errorRatioQuery, SLOWindowPlaceholder, string(w), -1)
+		return fmt.Sprintf(`label_replace(sum(%s) / %s >= 0, "window", "%s", "", "")`, query, budget, w)
+	}
+	return fmt.Sprintf("%s or %s", window(burnRate.LongWindow), window(burnRate.ShortWindow))
+}
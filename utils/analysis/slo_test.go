@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/evaluate"
+)
+
+func newSLOAnalysis() *v1alpha1.SLOAnalysis {
+	return &v1alpha1.SLOAnalysis{
+		Address: "http://prometheus:9090",
+		Objectives: []v1alpha1.ServiceLevelObjective{{
+			Name:            "availability",
+			Target:          "99.9",
+			ErrorRatioQuery: `sum(rate(http_requests_total{code=~"5.."}[$(window)])) / sum(rate(http_requests_total[$(window)]))`,
+		}},
+	}
+}
+
+func TestSLOLabels(t *testing.T) {
+	expected := map[string]string{
+		v1alpha1.LabelKeyControllerInstanceID: "test",
+		v1alpha1.RolloutTypeLabel:             v1alpha1.RolloutTypeSLOLabel,
+		v1alpha1.DefaultRolloutUniqueLabelKey: "abcd123",
+	}
+	assert.Equal(t, expected, SLOLabels("abcd123", "test"))
+	delete(expected, v1alpha1.LabelKeyControllerInstanceID)
+	assert.Equal(t, expected, SLOLabels("abcd123", ""))
+}
+
+func TestNewAnalysisRunFromSLOAnalysis(t *testing.T) {
+	run, err := NewAnalysisRunFromSLOAnalysis(newSLOAnalysis(), "foo-slo", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-slo", run.Name)
+	assert.Len(t, run.Spec.Metrics, 2)
+
+	metric := run.Spec.Metrics[0]
+	assert.Equal(t, "availability-1h-5m", metric.Name)
+	assert.Equal(t, DefaultSLOInterval, metric.Interval)
+	assert.Equal(t, "http://prometheus:9090", metric.Provider.Prometheus.Address)
+	assert.Equal(t, `label_replace(sum(sum(rate(http_requests_total{code=~"5.."}[1h])) / sum(rate(http_requests_total[1h]))) / 0.001 >= 0, "window", "1h", "", "")`+
+		` or label_replace(sum(sum(rate(http_requests_total{code=~"5.."}[5m])) / sum(rate(http_requests_total[5m]))) / 0.001 >= 0, "window", "5m", "", "")`,
+		metric.Provider.Prometheus.Query)
+	assert.Equal(t, "len(result) == 2 && all(result, {# >= 14.4})", metric.FailureCondition)
+	assert.Equal(t, "availability-6h-30m", run.Spec.Metrics[1].Name)
+
+	// both windows have to burn faster than the threshold
+	for _, test := range []struct {
+		result []float64
+		failed bool
+	}{
+		{[]float64{20, 15}, true},
+		{[]float64{20, 2}, false},
+		{[]float64{20}, false},
+		{[]float64{}, false},
+	} {
+		failed, err := evaluate.EvalCondition(test.result, metric.FailureCondition)
+		assert.NoError(t, err)
+		assert.Equal(t, test.failed, failed, test.result)
+	}
+}
+
+func TestSLOMetricsCustomBurnRates(t *testing.T) {
+	slo := newSLOAnalysis()
+	slo.Interval = "30s"
+	slo.Objectives[0].Target = "99"
+	slo.Objectives[0].BurnRates = []v1alpha1.BurnRate{{LongWindow: "30m", ShortWindow: "2m", Threshold: "10"}}
+	metrics, err := SLOMetrics(slo)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, v1alpha1.DurationString("30s"), metrics[0].Interval)
+	assert.Contains(t, metrics[0].Provider.Prometheus.Query, "/ 0.01 >= 0")
+	assert.Equal(t, "len(result) == 2 && all(result, {# >= 10})", metrics[0].FailureCondition)
+}
+
+func TestSLOMetricsInvalid(t *testing.T) {
+	for _, test := range []struct {
+		modify func(*v1alpha1.SLOAnalysis)
+		err    string
+	}{
+		{func(s *v1alpha1.SLOAnalysis) { s.Address = "" }, "address is required"},
+		{func(s *v1alpha1.SLOAnalysis) { s.Objectives = nil }, "no objectives specified"},
+		{func(s *v1alpha1.SLOAnalysis) { s.PostPromotionWindow = "soon" }, "invalid postPromotionWindow string"},
+		{func(s *v1alpha1.SLOAnalysis) { s.Objectives[0].Target = "100" }, "objectives[0]: target must be a percentage between 0 and 100"},
+		{func(s *v1alpha1.SLOAnalysis) { s.Objectives[0].ErrorRatioQuery = "" }, "objectives[0]: errorRatioQuery is required"},
+		{func(s *v1alpha1.SLOAnalysis) {
+			s.Objectives[0].BurnRates = []v1alpha1.BurnRate{{LongWindow: "1h", ShortWindow: "5m", Threshold: "fast"}}
+		}, "objectives[0].burnRates[0]: threshold must be a number greater than 0"},
+		{func(s *v1alpha1.SLOAnalysis) {
+			s.Objectives[0].BurnRates = []v1alpha1.BurnRate{{LongWindow: "1h", Threshold: "14.4"}}
+		}, "objectives[0].burnRates[0]: invalid window string"},
+		{func(s *v1alpha1.SLOAnalysis) { s.Objectives = append(s.Objectives, s.Objectives[0]) }, "metrics[2]: duplicate name 'availability-1h-5m"},
+	} {
+		slo := newSLOAnalysis()
+		test.modify(slo)
+		_, err := SLOMetrics(slo)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
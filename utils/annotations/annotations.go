@@ -25,6 +25,18 @@ const (
 	// in its replica sets. Helps in separating scaling events from the rollout process and for
 	// determining if the new replica set for a rollout is really saturated.
 	DesiredReplicasAnnotation = RolloutLabel + "/desired-replicas"
+	// DecisionHistoryAnnotation holds the most recent decisions made by the controller for a rollout
+	// as a JSON list, oldest first
+	DecisionHistoryAnnotation = RolloutLabel + "/decision-history"
+	// EphemeralMetadataAnnotation holds the ephemeral labels and annotations the controller injected
+	// into the pods of a replica set as JSON, so they can be removed once its role changes
+	EphemeralMetadataAnnotation = RolloutLabel + "/ephemeral-metadata"
+	// PinnedImagesAnnotation holds the images specified by the rollout for the containers of a
+	// replica set whose images were pinned to their verified digests, as JSON
+	PinnedImagesAnnotation = RolloutLabel + "/pinned-images"
+	// ClusterAnnotation is the name of the registered cluster a replica set was created in. Replica
+	// sets without it run in the cluster of the controller.
+	ClusterAnnotation = RolloutLabel + "/cluster"
 )
 
 // GetDesiredReplicasAnnotation returns the number of desired replicas
@@ -149,6 +161,10 @@ var annotationsToSkip = map[string]bool{
 	RevisionAnnotation:                 true,
 	RevisionHistoryAnnotation:          true,
 	DesiredReplicasAnnotation:          true,
+	DecisionHistoryAnnotation:          true,
+	EphemeralMetadataAnnotation:        true,
+	PinnedImagesAnnotation:             true,
+	ClusterAnnotation:                  true,
 }
 
 // skipCopyAnnotation returns true if we should skip copying the annotation with the given annotation key
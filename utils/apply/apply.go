@@ -0,0 +1,94 @@
+package apply
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	patchtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+const (
+	// FieldManager is the field manager the controller identifies itself as when applying changes
+	FieldManager = "argo-rollouts"
+)
+
+// serviceSelectorApply is the minimal Service configuration owned by the controller
+type serviceSelectorApply struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Selector map[string]string `json:"selector"`
+	} `json:"spec"`
+}
+
+// rolloutStatusApply is the minimal Rollout configuration owned by the controller
+type rolloutStatusApply struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Status            v1alpha1.RolloutStatus `json:"status"`
+}
+
+// ServiceSelector applies the selector to the service and returns the service as written by the
+// API server. The service selector is an atomic map, so the full selector is applied and the
+// controller takes ownership of it
+func ServiceSelector(restClient rest.Interface, svc *corev1.Service, selector map[string]string) (*corev1.Service, error) {
+	obj := serviceSelectorApply{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.Name,
+			Namespace: svc.Namespace,
+		},
+	}
+	obj.Spec.Selector = selector
+	applied := &corev1.Service{}
+	if err := apply(restClient, "services", svc.Namespace, svc.Name, obj, applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// RolloutStatus applies the status and the annotations owned by the controller to the rollout
+func RolloutStatus(restClient rest.Interface, r *v1alpha1.Rollout, status v1alpha1.RolloutStatus, annotations map[string]string) error {
+	obj := rolloutStatusApply{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "Rollout",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        r.Name,
+			Namespace:   r.Namespace,
+			Annotations: annotations,
+		},
+		Status: status,
+	}
+	return apply(restClient, "rollouts", r.Namespace, r.Name, obj, nil)
+}
+
+// apply sends the configuration as an apply patch, and decodes the written object into the result
+// if it is not nil
+func apply(restClient rest.Interface, resource, namespace, name string, obj interface{}, result runtime.Object) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	res := restClient.Patch(patchtypes.ApplyPatchType).
+		Namespace(namespace).
+		Resource(resource).
+		Name(name).
+		Param("fieldManager", FieldManager).
+		Param("force", "true").
+		Body(data).
+		Do()
+	if result == nil {
+		return res.Error()
+	}
+	return res.Into(result)
+}
@@ -0,0 +1,72 @@
+package apply
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	restfake "k8s.io/client-go/rest/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newFakeRESTClient(captured **http.Request, body *string) *restfake.RESTClient {
+	return &restfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs,
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			*captured = req
+			data, _ := ioutil.ReadAll(req.Body)
+			*body = string(data)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"active"},"spec":{"selector":{"rollouts-pod-template-hash":"abc"}}}`))),
+			}, nil
+		}),
+	}
+}
+
+func TestServiceSelector(t *testing.T) {
+	var req *http.Request
+	var body string
+	client := newFakeRESTClient(&req, &body)
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "active",
+			Namespace: "default",
+		},
+	}
+	applied, err := ServiceSelector(client, svc, map[string]string{"app": "guestbook", v1alpha1.DefaultRolloutUniqueLabelKey: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", applied.Spec.Selector[v1alpha1.DefaultRolloutUniqueLabelKey])
+	assert.Equal(t, http.MethodPatch, req.Method)
+	assert.Equal(t, "application/apply-patch+yaml", req.Header.Get("Content-Type"))
+	assert.Equal(t, FieldManager, req.URL.Query().Get("fieldManager"))
+	assert.Equal(t, "true", req.URL.Query().Get("force"))
+	assert.Contains(t, req.URL.Path, "/namespaces/default/services/active")
+	assert.Contains(t, body, `"kind":"Service"`)
+	assert.Contains(t, body, `"selector":{"app":"guestbook","rollouts-pod-template-hash":"abc"}`)
+}
+
+func TestRolloutStatus(t *testing.T) {
+	var req *http.Request
+	var body string
+	client := newFakeRESTClient(&req, &body)
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: "default",
+		},
+	}
+	err := RolloutStatus(client, ro, v1alpha1.RolloutStatus{CurrentPodHash: "abc"}, map[string]string{"rollout.argoproj.io/decision-history": "[]"})
+	assert.NoError(t, err)
+	assert.Contains(t, req.URL.Path, "/namespaces/default/rollouts/guestbook")
+	assert.Contains(t, body, `"apiVersion":"argoproj.io/v1alpha1"`)
+	assert.Contains(t, body, `"currentPodHash":"abc"`)
+	assert.Contains(t, body, `"rollout.argoproj.io/decision-history":"[]"`)
+}
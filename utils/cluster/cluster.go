@@ -0,0 +1,264 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+const (
+	// SecretTypeLabelKey is the label of the secrets of the controller's namespace registering the
+	// clusters the ReplicaSets of rollouts can be created in
+	SecretTypeLabelKey = "rollouts.argoproj.io/secret-type"
+	// SecretTypeCluster is the value of the secret type label of cluster secrets
+	SecretTypeCluster = "cluster"
+	// RolloutLabelKey is the label holding the name of the rollout of a ReplicaSet created in a
+	// registered cluster. Such ReplicaSets have no owner reference, since the garbage collector of
+	// their cluster would delete them.
+	RolloutLabelKey = annotations.RolloutLabel + "/rollout"
+	// ReplicaSetsFinalizer is the finalizer of the rollouts which created ReplicaSets in a registered
+	// cluster, which are deleted with the rollout
+	ReplicaSetsFinalizer = annotations.RolloutLabel + "/cluster-replicasets"
+)
+
+// Config is the connection config in the config key of a cluster secret, in the format of the
+// cluster secrets of Argo CD
+type Config struct {
+	// BearerToken authenticates the controller to the cluster
+	BearerToken string `json:"bearerToken,omitempty"`
+	// TLSClientConfig is the TLS config of the connection to the cluster
+	TLSClientConfig TLSClientConfig `json:"tlsClientConfig"`
+}
+
+// TLSClientConfig is the TLS config of the connection to a cluster. The certificates and keys are
+// base64 encoded PEM data.
+type TLSClientConfig struct {
+	Insecure   bool   `json:"insecure,omitempty"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     []byte `json:"caData,omitempty"`
+	CertData   []byte `json:"certData,omitempty"`
+	KeyData    []byte `json:"keyData,omitempty"`
+}
+
+// Cluster is a registered cluster the ReplicaSets of rollouts can be created in
+type Cluster struct {
+	// Name is the name of the cluster referenced by rollouts
+	Name string
+	// Client is the client of the cluster
+	Client kubernetes.Interface
+	// ReplicaSetLister lists the ReplicaSets with the rollout label in the cluster
+	ReplicaSetLister appslisters.ReplicaSetLister
+	// HasSynced returns true once the ReplicaSets of the cluster are listed
+	HasSynced cache.InformerSynced
+}
+
+// Registry returns the registered clusters
+type Registry interface {
+	// Get returns the registered cluster with the name
+	Get(name string) (*Cluster, error)
+	// List returns the registered clusters
+	List() ([]*Cluster, error)
+	// AddReplicaSetEventHandler adds a handler of the events of the ReplicaSets with the rollout
+	// label in every registered cluster
+	AddReplicaSetEventHandler(handler cache.ResourceEventHandler)
+}
+
+// registeredCluster is a cluster and the stop channel of the informer of its ReplicaSets, which is
+// closed once the secret of the cluster changes or is deleted
+type registeredCluster struct {
+	*Cluster
+	resourceVersion string
+	stopCh          chan struct{}
+}
+
+// secretRegistry registers the clusters of the cluster secrets of the controller's namespace. The
+// client and the ReplicaSet informer of a cluster are created on first use and recreated once its
+// secret changes.
+type secretRegistry struct {
+	secretsLister corelisters.SecretNamespaceLister
+	// namespace the ReplicaSets of the clusters are watched in
+	namespace    string
+	resyncPeriod time.Duration
+	newClient    func(config *rest.Config) (kubernetes.Interface, error)
+
+	lock     sync.Mutex
+	handlers []cache.ResourceEventHandler
+	clusters map[types.UID]*registeredCluster
+}
+
+// NewSecretRegistry returns a registry of the clusters of the cluster secrets of secretsNamespace,
+// watching the ReplicaSets of namespace in each cluster. The informer of the secrets is created by
+// NewSecretInformerFactory.
+func NewSecretRegistry(secretInformer coreinformers.SecretInformer, secretsNamespace, namespace string, resyncPeriod time.Duration) Registry {
+	return &secretRegistry{
+		secretsLister: secretInformer.Lister().Secrets(secretsNamespace),
+		namespace:     namespace,
+		resyncPeriod:  resyncPeriod,
+		newClient: func(config *rest.Config) (kubernetes.Interface, error) {
+			return kubernetes.NewForConfig(config)
+		},
+		clusters: map[types.UID]*registeredCluster{},
+	}
+}
+
+// NewSecretInformerFactory returns an informer factory of the cluster secrets of the namespace
+func NewSecretInformerFactory(kubeclientset kubernetes.Interface, namespace string, resyncPeriod time.Duration) kubeinformers.SharedInformerFactory {
+	return kubeinformers.NewSharedInformerFactoryWithOptions(
+		kubeclientset,
+		resyncPeriod,
+		kubeinformers.WithNamespace(namespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = labels.SelectorFromSet(labels.Set{SecretTypeLabelKey: SecretTypeCluster}).String()
+		}))
+}
+
+func (r *secretRegistry) AddReplicaSetEventHandler(handler cache.ResourceEventHandler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.handlers = append(r.handlers, handler)
+}
+
+func (r *secretRegistry) Get(name string) (*Cluster, error) {
+	clusters, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster '%s' is not registered", name)
+}
+
+// List returns the clusters of the cluster secrets, ordered by the names of their secrets. Secrets
+// which are invalid or register a cluster name twice are skipped.
+func (r *secretRegistry) List() ([]*Cluster, error) {
+	secrets, err := r.secretsLister.List(labels.SelectorFromSet(labels.Set{SecretTypeLabelKey: SecretTypeCluster}))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var clusters []*Cluster
+	names := map[string]bool{}
+	registered := map[types.UID]bool{}
+	for _, secret := range secrets {
+		cluster, err := r.getCluster(secret)
+		if err != nil {
+			log.Warnf("Skipping cluster secret '%s': %v", secret.Name, err)
+			continue
+		}
+		registered[secret.UID] = true
+		if names[cluster.Name] {
+			log.Warnf("Skipping cluster secret '%s': cluster '%s' is registered by another secret", secret.Name, cluster.Name)
+			continue
+		}
+		names[cluster.Name] = true
+		clusters = append(clusters, cluster.Cluster)
+	}
+	// the informers of the clusters whose secrets were deleted are stopped
+	for uid, cluster := range r.clusters {
+		if !registered[uid] {
+			close(cluster.stopCh)
+			delete(r.clusters, uid)
+		}
+	}
+	return clusters, nil
+}
+
+// getCluster returns the cluster of the secret, creating its client and starting the informer of
+// its ReplicaSets if the secret is new or changed
+func (r *secretRegistry) getCluster(secret *corev1.Secret) (*registeredCluster, error) {
+	if cluster, ok := r.clusters[secret.UID]; ok {
+		if cluster.resourceVersion == secret.ResourceVersion {
+			return cluster, nil
+		}
+		close(cluster.stopCh)
+		delete(r.clusters, secret.UID)
+	}
+	name, config, err := NewRestConfig(secret)
+	if err != nil {
+		return nil, err
+	}
+	client, err := r.newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	factory := kubeinformers.NewSharedInformerFactoryWithOptions(
+		client,
+		r.resyncPeriod,
+		kubeinformers.WithNamespace(r.namespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = RolloutLabelKey
+		}))
+	informer := factory.Apps().V1().ReplicaSets()
+	for _, handler := range r.handlers {
+		informer.Informer().AddEventHandler(handler)
+	}
+	cluster := &registeredCluster{
+		Cluster: &Cluster{
+			Name:             name,
+			Client:           client,
+			ReplicaSetLister: informer.Lister(),
+			HasSynced:        informer.Informer().HasSynced,
+		},
+		resourceVersion: secret.ResourceVersion,
+		stopCh:          make(chan struct{}),
+	}
+	factory.Start(cluster.stopCh)
+	r.clusters[secret.UID] = cluster
+	log.Infof("Registered cluster '%s' of secret '%s'", name, secret.Name)
+	return cluster, nil
+}
+
+// NewRestConfig returns the name of the cluster of a cluster secret, which has the name, server and
+// config keys of the cluster secrets of Argo CD, and the config of its client
+func NewRestConfig(secret *corev1.Secret) (string, *rest.Config, error) {
+	name := string(secret.Data["name"])
+	if name == "" {
+		return "", nil, fmt.Errorf("key 'name' is missing")
+	}
+	server := string(secret.Data["server"])
+	if server == "" {
+		return "", nil, fmt.Errorf("key 'server' is missing")
+	}
+	var config Config
+	if data, ok := secret.Data["config"]; ok {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return "", nil, fmt.Errorf("key 'config' is invalid: %v", err)
+		}
+	}
+	return name, &rest.Config{
+		Host:        server,
+		BearerToken: config.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure:   config.TLSClientConfig.Insecure,
+			ServerName: config.TLSClientConfig.ServerName,
+			CAData:     config.TLSClientConfig.CAData,
+			CertData:   config.TLSClientConfig.CertData,
+			KeyData:    config.TLSClientConfig.KeyData,
+		},
+	}, nil
+}
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newClusterSecret(name, clusterName string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "argo-rollouts",
+			UID:             types.UID(name),
+			ResourceVersion: "1",
+			Labels:          map[string]string{SecretTypeLabelKey: SecretTypeCluster},
+		},
+		Data: map[string][]byte{
+			"name":   []byte(clusterName),
+			"server": []byte("https://" + clusterName),
+			"config": []byte(`{"bearerToken":"token","tlsClientConfig":{"insecure":true}}`),
+		},
+	}
+}
+
+// newTestRegistry returns a registry of the secrets, whose clusters have fake clients
+func newTestRegistry(secrets ...*corev1.Secret) (*secretRegistry, cache.Indexer, *[]*rest.Config) {
+	factory := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0)
+	secretInformer := factory.Core().V1().Secrets()
+	indexer := secretInformer.Informer().GetIndexer()
+	for _, secret := range secrets {
+		indexer.Add(secret)
+	}
+	var configs []*rest.Config
+	registry := NewSecretRegistry(secretInformer, "argo-rollouts", "default", 0).(*secretRegistry)
+	registry.newClient = func(config *rest.Config) (kubernetes.Interface, error) {
+		configs = append(configs, config)
+		return k8sfake.NewSimpleClientset(), nil
+	}
+	return registry, indexer, &configs
+}
+
+func TestNewRestConfig(t *testing.T) {
+	name, config, err := NewRestConfig(newClusterSecret("east-secret", "east"))
+	assert.NoError(t, err)
+	assert.Equal(t, "east", name)
+	assert.Equal(t, "https://east", config.Host)
+	assert.Equal(t, "token", config.BearerToken)
+	assert.True(t, config.TLSClientConfig.Insecure)
+
+	secret := newClusterSecret("east-secret", "east")
+	delete(secret.Data, "name")
+	_, _, err = NewRestConfig(secret)
+	assert.EqualError(t, err, "key 'name' is missing")
+
+	secret = newClusterSecret("east-secret", "east")
+	delete(secret.Data, "server")
+	_, _, err = NewRestConfig(secret)
+	assert.EqualError(t, err, "key 'server' is missing")
+
+	secret = newClusterSecret("east-secret", "east")
+	secret.Data["config"] = []byte("{")
+	_, _, err = NewRestConfig(secret)
+	assert.Error(t, err)
+}
+
+func TestSecretRegistryList(t *testing.T) {
+	invalid := newClusterSecret("invalid-secret", "invalid")
+	delete(invalid.Data, "server")
+	// the cluster name of b-secret is registered by a-secret first
+	registry, _, configs := newTestRegistry(
+		newClusterSecret("west-secret", "west"),
+		newClusterSecret("b-secret", "east"),
+		newClusterSecret("a-secret", "east"),
+		invalid)
+
+	clusters, err := registry.List()
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, "east", clusters[0].Name)
+	assert.Equal(t, "west", clusters[1].Name)
+	assert.Len(t, *configs, 3)
+
+	// clients are created once per secret
+	_, err = registry.List()
+	assert.NoError(t, err)
+	assert.Len(t, *configs, 3)
+
+	cluster, err := registry.Get("west")
+	assert.NoError(t, err)
+	assert.Equal(t, clusters[1], cluster)
+	_, err = registry.Get("north")
+	assert.EqualError(t, err, "cluster 'north' is not registered")
+}
+
+func TestSecretRegistryUpdatesClusters(t *testing.T) {
+	secret := newClusterSecret("east-secret", "east")
+	registry, indexer, configs := newTestRegistry(secret)
+
+	cluster, err := registry.Get("east")
+	assert.NoError(t, err)
+	stopCh := registry.clusters[secret.UID].stopCh
+
+	updated := secret.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Data["server"] = []byte("https://east-2")
+	indexer.Update(updated)
+	updatedCluster, err := registry.Get("east")
+	assert.NoError(t, err)
+	assert.NotEqual(t, cluster, updatedCluster)
+	assert.Equal(t, "https://east-2", (*configs)[1].Host)
+	// the informer of the previous client is stopped
+	_, ok := <-stopCh
+	assert.False(t, ok)
+
+	stopCh = registry.clusters[secret.UID].stopCh
+	indexer.Delete(updated)
+	clusters, err := registry.List()
+	assert.NoError(t, err)
+	assert.Empty(t, clusters)
+	assert.Empty(t, registry.clusters)
+	_, ok = <-stopCh
+	assert.False(t, ok)
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -18,6 +19,7 @@ import (
 	hashutil "k8s.io/kubernetes/pkg/util/hash"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	analysisutil "github.com/argoproj/argo-rollouts/utils/analysis"
 	"github.com/argoproj/argo-rollouts/utils/defaults"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
 )
@@ -33,6 +35,43 @@ const (
 	RolloutSelectAllMessage = "This rollout is selecting all pods. A non-empty selector is required."
 	// InvalidSetWeightMessage indicates the setweight value needs to be between 0 and 100
 	InvalidSetWeightMessage = "SetWeight needs to be between 0 and 100"
+	// InvalidFeatureFlagMessage indicates the setFeatureFlag step is missing fields or its percentage is not between 0 and 100
+	InvalidFeatureFlagMessage = "SetFeatureFlag needs a provider, flag and environment, and a percentage between 0 and 100"
+	// InvalidExperimentWeightMessage indicates the weights of the templates of an experiment step are
+	// not between 0 and 100 in total, or are used without a traffic router supporting them
+	InvalidExperimentWeightMessage = "Experiment template weights require trafficRouting other than nginx and need to be between 0 and 100 in total"
+	// InvalidHeaderRouteMessage indicates the setHeaderRoute step has no name, its matches do not have
+	// one of a header or cookie and one value, or it is used without traffic routing
+	InvalidHeaderRouteMessage = "SetHeaderRoute requires trafficRouting and a name, and each match needs one of headerName or cookieName and one of exact, prefix or regex"
+	// InvalidMirrorRouteMessage indicates the percentage of the setMirrorRoute step is not between 0
+	// and 100, or the step is used without traffic routing
+	InvalidMirrorRouteMessage = "SetMirrorRoute requires trafficRouting and a percentage between 0 and 100"
+	// InvalidSetCanaryScaleMessage indicates the setCanaryScale step does not set exactly one of its
+	// fields, its weight is not between 0 and 100, or it is used without traffic routing
+	InvalidSetCanaryScaleMessage = "SetCanaryScale requires trafficRouting and exactly one of weight between 0 and 100, replicas or matchTrafficWeight"
+	// InvalidSLOAnalysisMessage indicates the SLO analysis of the rollout is invalid
+	InvalidSLOAnalysisMessage = "SLOAnalysis is invalid: %v"
+	// InvalidSLOAnalysisWorkloadRefMessage indicates the SLO analysis is used by a rollout whose pod
+	// template is read from the Deployment of its workloadRef, so it could not be rolled back
+	InvalidSLOAnalysisWorkloadRefMessage = "SLOAnalysis can not be used with workloadRef"
+	// InvalidRollbackWindowMessage indicates the rollback window of the rollout has an invalid duration
+	InvalidRollbackWindowMessage = "RollbackWindow has an invalid duration: %v"
+	// InvalidPartitionMessage indicates the partitioned canary has an unknown order or is used with traffic routing
+	InvalidPartitionMessage = "Partition needs an order of Oldest, Newest or NodeName and can not be used with trafficRouting"
+	// InvalidClusterMessage indicates the ReplicaSets of the rollout are created in a registered
+	// cluster without a traffic router and services to route to them, or the name of the rollout
+	// does not fit the label of those ReplicaSets
+	InvalidClusterMessage = "Cluster requires trafficRouting, a canaryService and a stableService, and a rollout name of at most 63 characters"
+	// InvalidAntiAffinityMessage indicates the antiAffinity of the strategy does not set exactly one
+	// rule, or the weight of the preferred rule is not between 1 and 100
+	InvalidAntiAffinityMessage = "AntiAffinity needs exactly one of preferredDuringSchedulingIgnoredDuringExecution or requiredDuringSchedulingIgnoredDuringExecution, and a weight between 1 and 100"
+	// InvalidEphemeralMetadataMessage indicates the ephemeral metadata of the strategy sets a label of
+	// the selector of the rollout or the pod template hash label
+	InvalidEphemeralMetadataMessage = "Ephemeral metadata can not set the labels of the selector or the %s label"
+	// InvalidWorkloadRefMessage indicates the workloadRef does not reference a Deployment by name
+	InvalidWorkloadRefMessage = "WorkloadRef needs the name of a Deployment of apiVersion apps/v1"
+	// InvalidStartingStepMessage indicates the startingStep of the background analysis is not the index of a step
+	InvalidStartingStepMessage = "StartingStep of the background analysis needs to be the index of one of the steps"
 	// InvalidDurationMessage indicates the Duration value needs to be greater than 0
 	InvalidDurationMessage = "Duration needs to be greater than 0"
 	// InvalidMaxSurgeMaxUnavailable indicates both maxSurge and MaxUnavailable can not be set to zero
@@ -133,6 +172,59 @@ const (
 	ServiceNotFoundReason = "ServiceNotFound"
 	// ServiceNotFoundMessage is added in a rollout when the service defined in the spec is not found
 	ServiceNotFoundMessage = "Service %q is not found"
+	// ClusterServiceNotFoundMessage is added in a rollout when the service defined in the spec is
+	// not found in a registered cluster its ReplicaSets run in
+	ClusterServiceNotFoundMessage = "Service %q is not found in cluster %q"
+	// ServiceSelectorNotSwitchedReason is emitted when the selector of a service does not select the
+	// new value after it was switched
+	ServiceSelectorNotSwitchedReason = "ServiceSelectorNotSwitched"
+	// ServiceSelectorNotSwitchedMessage is emitted when the selector of a service does not select the
+	// new value after it was switched
+	ServiceSelectorNotSwitchedMessage = "Selector of service %q does not select '%s' after it was switched"
+	// ServiceSelectorSwitchPendingReason is added in a rollout when the selector of its active or
+	// stable service was switched, but the service read from the cache does not select the new value yet
+	ServiceSelectorSwitchPendingReason = "ServiceSelectorSwitchPending"
+	// ServiceSelectorSwitchPendingMessage is added in a rollout when the selector of its active or
+	// stable service was switched, but the service read from the cache does not select the new value yet
+	ServiceSelectorSwitchPendingMessage = "Waiting for the selector of service %q to select '%s'"
+	// ServiceSelectorSwitchedReason is added in a rollout when the service read from the cache selects
+	// the value its selector was switched to
+	ServiceSelectorSwitchedReason = "ServiceSelectorSwitched"
+	// ServiceSelectorSwitchedMessage is added in a rollout when the service read from the cache selects
+	// the value its selector was switched to
+	ServiceSelectorSwitchedMessage = "Switched service selectors are verified"
+	// ReferencesVerifiedReason is added in a rollout when all the objects it references exist
+	ReferencesVerifiedReason = "ReferencesVerified"
+	// ReferencesVerifiedMessage is added in a rollout when all the objects it references exist
+	ReferencesVerifiedMessage = "All references of the rollout are verified"
+	// InvalidReferencesReason is added in a rollout when objects it references are missing or
+	// incompatible with the rollout. The update of the rollout does not start until they are fixed.
+	InvalidReferencesReason = "InvalidReferences"
+	// ImageVerificationFailedReason is added in a rollout when an image of the new revision has no
+	// valid signature. The ReplicaSet of the revision is not created until the images are verified.
+	ImageVerificationFailedReason = "ImageVerificationFailed"
+	// ImagesVerifiedReason is added in a rollout when the images of the new revision are verified
+	ImagesVerifiedReason = "ImagesVerified"
+	// ImagesVerifiedMessage is added in a rollout when the images of the new revision are verified
+	ImagesVerifiedMessage = "Images of the new revision are verified"
+	// PausedTooLongReason is added in a rollout when it waits for a promotion for longer than its
+	// stuck detection allows
+	PausedTooLongReason = "PausedTooLong"
+	// PausedTooLongMessage is added in a rollout when it waits for a promotion for longer than its
+	// stuck detection allows
+	PausedTooLongMessage = "Rollout has been waiting for a promotion for more than %d seconds"
+	// InconclusiveTooLongReason is added in a rollout when it is paused on an inconclusive analysis
+	// for longer than its stuck detection allows
+	InconclusiveTooLongReason = "InconclusiveTooLong"
+	// InconclusiveTooLongMessage is added in a rollout when it is paused on an inconclusive analysis
+	// for longer than its stuck detection allows
+	InconclusiveTooLongMessage = "Rollout has been paused on an inconclusive analysis for more than %d seconds"
+	// TrafficWeightNotConvergedReason is added in a rollout when the traffic router fails to apply
+	// its weights. The condition is True once it failed for longer than the stuck detection allows.
+	TrafficWeightNotConvergedReason = "TrafficWeightNotConverged"
+	// TrafficWeightNotConvergedMessage is added in a rollout when the traffic router fails to apply
+	// its weights
+	TrafficWeightNotConvergedMessage = "Traffic router failed to apply the weights: %v"
 )
 
 // NewRolloutCondition creates a new rollout condition.
@@ -256,6 +348,69 @@ func RolloutComplete(rollout *v1alpha1.Rollout, newStatus *v1alpha1.RolloutStatu
 		completedStrategy
 }
 
+// ComputeRolloutPhase returns the phase of the rollout with the new status, along with a message
+// explaining it, so external tools do not have to derive the health of the rollout themselves
+func ComputeRolloutPhase(rollout *v1alpha1.Rollout, newStatus *v1alpha1.RolloutStatus) (v1alpha1.RolloutPhase, string) {
+	if cond := GetRolloutCondition(*newStatus, v1alpha1.InvalidSpec); cond != nil {
+		return v1alpha1.RolloutPhaseDegraded, fmt.Sprintf("%s: %s", InvalidSpecReason, cond.Message)
+	}
+	if cond := GetRolloutCondition(*newStatus, v1alpha1.RolloutDegraded); cond != nil && cond.Status == corev1.ConditionTrue {
+		return v1alpha1.RolloutPhaseDegraded, fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+	}
+	progressing := GetRolloutCondition(*newStatus, v1alpha1.RolloutProgressing)
+	if newStatus.Abort {
+		message := "Rollout is aborted"
+		if progressing != nil && progressing.Reason == RolloutAbortedReason {
+			message = progressing.Message
+		}
+		return v1alpha1.RolloutPhaseAborted, message
+	}
+	if progressing != nil && progressing.Reason == TimedOutReason {
+		return v1alpha1.RolloutPhaseDegraded, fmt.Sprintf("%s: %s", TimedOutReason, progressing.Message)
+	}
+	if rollout.Spec.Paused {
+		return v1alpha1.RolloutPhasePaused, "Rollout is paused manually"
+	}
+	if len(newStatus.PauseConditions) > 0 {
+		var reasons []string
+		for _, cond := range newStatus.PauseConditions {
+			reasons = append(reasons, string(cond.Reason))
+		}
+		return v1alpha1.RolloutPhasePaused, fmt.Sprintf("Rollout is paused (%s)", strings.Join(reasons, ", "))
+	}
+	replicas := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
+	if newStatus.UpdatedReplicas < replicas {
+		return v1alpha1.RolloutPhaseProgressing, "More replicas need to be updated"
+	}
+	if newStatus.AvailableReplicas < newStatus.UpdatedReplicas {
+		return v1alpha1.RolloutPhaseProgressing, "Updated replicas are still becoming available"
+	}
+	if rollout.Spec.Strategy.BlueGreen != nil {
+		if newStatus.BlueGreen.ActiveSelector == "" || newStatus.BlueGreen.ActiveSelector != newStatus.CurrentPodHash {
+			return v1alpha1.RolloutPhaseProgressing, "Active service cutover pending"
+		}
+	}
+	if rollout.Spec.Strategy.Canary != nil {
+		if newStatus.Replicas > newStatus.UpdatedReplicas {
+			return v1alpha1.RolloutPhaseProgressing, "Old replicas are pending termination"
+		}
+		if newStatus.Canary.StableRS == "" || newStatus.Canary.StableRS != newStatus.CurrentPodHash {
+			return v1alpha1.RolloutPhaseProgressing, "Waiting for rollout to finish steps"
+		}
+	}
+	return v1alpha1.RolloutPhaseHealthy, ""
+}
+
+// GetRolloutPhase returns the phase of the rollout from its current status, along with a message
+// explaining it. The rollout is progressing until the controller observed its latest spec, so a
+// rollout which was just updated is not reported with the phase of its previous revision.
+func GetRolloutPhase(rollout *v1alpha1.Rollout) (v1alpha1.RolloutPhase, string) {
+	if rollout.Status.ObservedGeneration != ComputeGenerationHash(rollout.Spec) {
+		return v1alpha1.RolloutPhaseProgressing, "Waiting for the controller to observe the rollout spec"
+	}
+	return ComputeRolloutPhase(rollout, &rollout.Status)
+}
+
 // ComputeStepHash returns a hash value calculated from the Rollout's steps. The hash will
 // be safe encoded to avoid bad words.
 func ComputeStepHash(rollout *v1alpha1.Rollout) string {
@@ -292,6 +447,10 @@ func newInvalidSpecRolloutCondition(prevCond *v1alpha1.RolloutCondition, reason
 
 // VerifyRolloutSpec Checks for a valid spec otherwise returns a invalidSpec condition.
 func VerifyRolloutSpec(rollout *v1alpha1.Rollout, prevCond *v1alpha1.RolloutCondition) *v1alpha1.RolloutCondition {
+	if rollout.Spec.WorkloadRef != nil && InvalidWorkloadRef(rollout.Spec.WorkloadRef) {
+		return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidWorkloadRefMessage)
+	}
+
 	if rollout.Spec.Selector == nil {
 		message := fmt.Sprintf(MissingFieldMessage, ".Spec.Selector")
 		return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, message)
@@ -315,7 +474,35 @@ func VerifyRolloutSpec(rollout *v1alpha1.Rollout, prevCond *v1alpha1.RolloutCond
 		return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, RolloutMinReadyLongerThanDeadlineMessage)
 	}
 
+	if rollout.Spec.SLOAnalysis != nil {
+		if rollout.Spec.WorkloadRef != nil {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidSLOAnalysisWorkloadRefMessage)
+		}
+		if _, err := analysisutil.SLOMetrics(rollout.Spec.SLOAnalysis); err != nil {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, fmt.Sprintf(InvalidSLOAnalysisMessage, err))
+		}
+	}
+
+	if window := rollout.Spec.RollbackWindow; window != nil && window.Duration != "" {
+		if _, err := window.Duration.Duration(); err != nil {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, fmt.Sprintf(InvalidRollbackWindowMessage, err))
+		}
+	}
+
+	if invalidAntiAffinity(rollout) {
+		return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidAntiAffinityMessage)
+	}
+
+	if invalidEphemeralMetadata(rollout) {
+		message := fmt.Sprintf(InvalidEphemeralMetadataMessage, v1alpha1.DefaultRolloutUniqueLabelKey)
+		return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, message)
+	}
+
 	if rollout.Spec.Strategy.BlueGreen != nil {
+		if rollout.Spec.Strategy.BlueGreen.ActiveService == "" {
+			message := fmt.Sprintf(MissingFieldMessage, ".Spec.Strategy.BlueGreen.ActiveService")
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, message)
+		}
 		if rollout.Spec.Strategy.BlueGreen.ActiveService == rollout.Spec.Strategy.BlueGreen.PreviewService {
 			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, DuplicatedServicesMessage)
 		}
@@ -329,16 +516,40 @@ func VerifyRolloutSpec(rollout *v1alpha1.Rollout, prevCond *v1alpha1.RolloutCond
 		if invalidMaxSurgeMaxUnavailable(rollout) {
 			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidMaxSurgeMaxUnavailable)
 		}
+		if invalidPartition(rollout.Spec.Strategy.Canary) {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidPartitionMessage)
+		}
+		if invalidCluster(rollout) {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidClusterMessage)
+		}
+		if invalidStartingStep(rollout.Spec.Strategy.Canary) {
+			return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidStartingStepMessage)
+		}
 		for _, step := range rollout.Spec.Strategy.Canary.Steps {
 			if hasMultipleStepsType(step) {
 				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidStepMessage)
 			}
-			if step.Experiment == nil && step.Pause == nil && step.SetWeight == nil && step.Analysis == nil {
+			if step.Experiment == nil && step.Pause == nil && step.SetWeight == nil && step.Analysis == nil && step.SetFeatureFlag == nil && step.SetHeaderRoute == nil && step.SetMirrorRoute == nil && step.SetCanaryScale == nil {
 				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidStepMessage)
 			}
 			if step.SetWeight != nil && (*step.SetWeight < 0 || *step.SetWeight > 100) {
 				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidSetWeightMessage)
 			}
+			if f := step.SetFeatureFlag; f != nil && (f.Provider == "" || f.Flag == "" || f.Environment == "" || (f.Percentage != nil && (*f.Percentage < 0 || *f.Percentage > 100))) {
+				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidFeatureFlagMessage)
+			}
+			if step.SetHeaderRoute != nil && invalidHeaderRoute(rollout, *step.SetHeaderRoute) {
+				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidHeaderRouteMessage)
+			}
+			if m := step.SetMirrorRoute; m != nil && (rollout.Spec.Strategy.Canary.TrafficRouting == nil || m.Percentage < 0 || m.Percentage > 100) {
+				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidMirrorRouteMessage)
+			}
+			if step.SetCanaryScale != nil && invalidSetCanaryScale(rollout, *step.SetCanaryScale) {
+				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidSetCanaryScaleMessage)
+			}
+			if step.Experiment != nil && invalidExperimentWeights(rollout, *step.Experiment) {
+				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidExperimentWeightMessage)
+			}
 			if step.Pause != nil && step.Pause.DurationSeconds() < 0 {
 				return newInvalidSpecRolloutCondition(prevCond, InvalidSpecReason, InvalidDurationMessage)
 			}
@@ -348,12 +559,175 @@ func VerifyRolloutSpec(rollout *v1alpha1.Rollout, prevCond *v1alpha1.RolloutCond
 	return nil
 }
 
+// invalidEphemeralMetadata returns true if the ephemeral metadata of the strategy sets a label which
+// selects the pods of the ReplicaSets of the rollout
+func invalidEphemeralMetadata(rollout *v1alpha1.Rollout) bool {
+	var metadata []*v1alpha1.PodTemplateMetadata
+	if blueGreen := rollout.Spec.Strategy.BlueGreen; blueGreen != nil {
+		metadata = append(metadata, blueGreen.ActiveMetadata, blueGreen.PreviewMetadata)
+	} else {
+		metadata = append(metadata, rollout.Spec.Strategy.Canary.StableMetadata, rollout.Spec.Strategy.Canary.CanaryMetadata)
+	}
+	for _, m := range metadata {
+		if m == nil {
+			continue
+		}
+		if _, ok := m.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]; ok {
+			return true
+		}
+		for _, req := range rollout.Spec.Selector.MatchExpressions {
+			if _, ok := m.Labels[req.Key]; ok {
+				return true
+			}
+		}
+		for k := range rollout.Spec.Selector.MatchLabels {
+			if _, ok := m.Labels[k]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InvalidWorkloadRef returns true if the workloadRef does not reference a Deployment by name
+func InvalidWorkloadRef(ref *v1alpha1.ObjectRef) bool {
+	if ref.APIVersion != "" && ref.APIVersion != "apps/v1" {
+		return true
+	}
+	return ref.Kind != "Deployment" || ref.Name == ""
+}
+
+// invalidAntiAffinity returns true if the anti-affinity of the strategy does not set exactly one
+// rule, or the weight of its preferred rule is out of the range of the scheduler
+func invalidAntiAffinity(rollout *v1alpha1.Rollout) bool {
+	var antiAffinity *v1alpha1.AntiAffinity
+	if rollout.Spec.Strategy.BlueGreen != nil {
+		antiAffinity = rollout.Spec.Strategy.BlueGreen.AntiAffinity
+	} else {
+		antiAffinity = rollout.Spec.Strategy.Canary.AntiAffinity
+	}
+	if antiAffinity == nil {
+		return false
+	}
+	preferred := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if (preferred == nil) == (antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil) {
+		return true
+	}
+	return preferred != nil && (preferred.Weight < 1 || preferred.Weight > 100)
+}
+
+// invalidCluster returns true if the ReplicaSets of the rollout are created in a registered cluster
+// without traffic routing to the stable and canary services, whose selectors are switched in each
+// cluster, or the name of the rollout is too long for the value of the rollout label of the
+// ReplicaSets
+func invalidCluster(rollout *v1alpha1.Rollout) bool {
+	canary := rollout.Spec.Strategy.Canary
+	if canary.Cluster == "" {
+		return false
+	}
+	return canary.TrafficRouting == nil || canary.CanaryService == "" || canary.StableService == "" || len(rollout.Name) > validation.LabelValueMaxLength
+}
+
+// invalidPartition returns true if the partitioned canary has an unknown order, or the rollout
+// shifts the traffic with a traffic router which keeps the stable ReplicaSet fully scaled
+func invalidPartition(canary *v1alpha1.CanaryStrategy) bool {
+	if canary.Partition == nil {
+		return false
+	}
+	switch canary.Partition.Order {
+	case "", v1alpha1.PartitionOrderOldest, v1alpha1.PartitionOrderNewest, v1alpha1.PartitionOrderNodeName:
+	default:
+		return true
+	}
+	return canary.TrafficRouting != nil
+}
+
+// invalidStartingStep returns true if the background analysis starts on a step the rollout does
+// not have, in which case the analysis would never run
+func invalidStartingStep(canary *v1alpha1.CanaryStrategy) bool {
+	if canary.Analysis == nil || canary.Analysis.StartingStep == nil {
+		return false
+	}
+	startingStep := *canary.Analysis.StartingStep
+	return startingStep < 0 || (startingStep > 0 && int(startingStep) >= len(canary.Steps))
+}
+
+// invalidExperimentWeights returns true if the templates of the step have weights which are not
+// between 0 and 100 in total, or the rollout has no traffic router to send the traffic to the templates.
+// The Nginx traffic router only supports a single canary Ingress, so it cannot send traffic to them.
+func invalidExperimentWeights(r *v1alpha1.Rollout, step v1alpha1.RolloutExperimentStep) bool {
+	trafficRouting := r.Spec.Strategy.Canary.TrafficRouting
+	total := int32(0)
+	for _, template := range step.Templates {
+		if template.Weight == nil {
+			continue
+		}
+		if trafficRouting == nil || trafficRouting.Nginx != nil || *template.Weight < 0 {
+			return true
+		}
+		total += *template.Weight
+	}
+	return total > 100
+}
+
+// invalidHeaderRoute returns true if the route has no name, a match does not have exactly one of a
+// header or cookie name and one value, or the rollout has no traffic router to route the requests
+func invalidHeaderRoute(r *v1alpha1.Rollout, route v1alpha1.SetHeaderRoute) bool {
+	if r.Spec.Strategy.Canary.TrafficRouting == nil || route.Name == "" {
+		return true
+	}
+	for _, match := range route.Match {
+		if (match.HeaderName == "") == (match.CookieName == "") {
+			return true
+		}
+		values := 0
+		for _, value := range []string{match.Value.Exact, match.Value.Prefix, match.Value.Regex} {
+			if value != "" {
+				values++
+			}
+		}
+		if values != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidSetCanaryScale returns true if the setCanaryScale step does not set exactly one of its
+// fields, scales by an invalid weight or replicas, or is used without traffic routing
+func invalidSetCanaryScale(r *v1alpha1.Rollout, scale v1alpha1.SetCanaryScale) bool {
+	if r.Spec.Strategy.Canary.TrafficRouting == nil {
+		return true
+	}
+	set := 0
+	if scale.Weight != nil {
+		if *scale.Weight < 0 || *scale.Weight > 100 {
+			return true
+		}
+		set++
+	}
+	if scale.Replicas != nil {
+		if *scale.Replicas < 0 {
+			return true
+		}
+		set++
+	}
+	if scale.MatchTrafficWeight {
+		set++
+	}
+	return set != 1
+}
+
 func hasMultipleStepsType(s v1alpha1.CanaryStep) bool {
 	oneOf := make([]bool, 3)
 	oneOf = append(oneOf, s.SetWeight != nil)
 	oneOf = append(oneOf, s.Pause != nil)
 	oneOf = append(oneOf, s.Experiment != nil)
 	oneOf = append(oneOf, s.Analysis != nil)
+	oneOf = append(oneOf, s.SetFeatureFlag != nil)
+	oneOf = append(oneOf, s.SetHeaderRoute != nil)
+	oneOf = append(oneOf, s.SetMirrorRoute != nil)
+	oneOf = append(oneOf, s.SetCanaryScale != nil)
 	hasMultipleStepTypes := false
 	for i := range oneOf {
 		if oneOf[i] {
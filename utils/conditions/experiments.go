@@ -32,6 +32,8 @@ const (
 	ExperimentSelectAllMessage = "This experiment is selecting all pods at index %d. A non-empty selector is required."
 	// ExperimentMinReadyLongerThanDeadlineMessage indicates the MinReadySeconds is longer than ProgressDeadlineSeconds
 	ExperimentMinReadyLongerThanDeadlineMessage = "MinReadySeconds cannot be longer than ProgressDeadlineSeconds. Check template index %d"
+	// ExperimentTemplateWeightMessage indicates the weights of the templates are not between 0 and 100 in total
+	ExperimentTemplateWeightMessage = "The weights of the templates need to be between 0 and 100 in total. Check template index %d"
 )
 
 // NewExperimentConditions takes arguments to create new Condition
@@ -136,6 +138,7 @@ func newInvalidSpecExperimentCondition(prevCond *v1alpha1.ExperimentCondition, r
 // VerifyExperimentSpec Checks for a valid spec otherwise returns a invalidSpec condition.
 func VerifyExperimentSpec(experiment *v1alpha1.Experiment, prevCond *v1alpha1.ExperimentCondition) *v1alpha1.ExperimentCondition {
 	templateNameSet := make(map[string]bool)
+	totalWeight := int32(0)
 	for i := range experiment.Spec.Templates {
 		template := experiment.Spec.Templates[i]
 		if template.Selector == nil {
@@ -164,6 +167,14 @@ func VerifyExperimentSpec(experiment *v1alpha1.Experiment, prevCond *v1alpha1.Ex
 			return newInvalidSpecExperimentCondition(prevCond, InvalidSpecReason, message)
 		}
 		templateNameSet[template.Name] = true
+
+		if template.Weight != nil {
+			totalWeight += *template.Weight
+			if *template.Weight < 0 || totalWeight > 100 {
+				message := fmt.Sprintf(ExperimentTemplateWeightMessage, i)
+				return newInvalidSpecExperimentCondition(prevCond, InvalidSpecReason, message)
+			}
+		}
 	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 )
@@ -352,6 +353,13 @@ func TestVerifyExperimentSpecBaseCases(t *testing.T) {
 	assert.Equal(t, selectAllMessage, selectorEverythingConf.Message)
 	assert.Equal(t, InvalidSpecReason, selectorEverythingConf.Reason)
 
+	tooMuchWeight := ex.DeepCopy()
+	tooMuchWeight.Spec.Templates[0].Weight = pointer.Int32Ptr(101)
+	tooMuchWeightCond := VerifyExperimentSpec(tooMuchWeight, nil)
+	assert.NotNil(t, tooMuchWeightCond)
+	assert.Equal(t, fmt.Sprintf(ExperimentTemplateWeightMessage, 0), tooMuchWeightCond.Message)
+	assert.Equal(t, InvalidSpecReason, tooMuchWeightCond.Reason)
+
 	noSelector := ex.DeepCopy()
 	noSelector.Spec.Templates[0].Selector = nil
 	noSelectorCond := VerifyExperimentSpec(noSelector, nil)
@@ -3,6 +3,7 @@ package conditions
 import (
 	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -248,6 +249,12 @@ func TestVerifyRolloutSpecBlueGreen(t *testing.T) {
 	sameSvcsCond := VerifyRolloutSpec(sameSvcs, nil)
 	assert.NotNil(t, sameSvcsCond)
 	assert.Equal(t, DuplicatedServicesMessage, sameSvcsCond.Message)
+
+	noActiveSvc := validRollout.DeepCopy()
+	noActiveSvc.Spec.Strategy.BlueGreen.ActiveService = ""
+	noActiveSvcCond := VerifyRolloutSpec(noActiveSvc, nil)
+	assert.NotNil(t, noActiveSvcCond)
+	assert.Equal(t, fmt.Sprintf(MissingFieldMessage, ".Spec.Strategy.BlueGreen.ActiveService"), noActiveSvcCond.Message)
 	assert.Equal(t, InvalidSpecReason, sameSvcsCond.Reason)
 
 	scaleLimitLargerThanRevision := validRollout.DeepCopy()
@@ -256,6 +263,97 @@ func TestVerifyRolloutSpecBlueGreen(t *testing.T) {
 	assert.NotNil(t, scaleLimitLargerThanRevisionCond)
 	assert.Equal(t, ScaleDownLimitLargerThanRevisionLimit, scaleLimitLargerThanRevisionCond.Message)
 	assert.Equal(t, InvalidSpecReason, sameSvcsCond.Reason)
+
+	antiAffinity := validRollout.DeepCopy()
+	antiAffinity.Spec.Strategy.BlueGreen.AntiAffinity = &v1alpha1.AntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+	}
+	assert.Nil(t, VerifyRolloutSpec(antiAffinity, nil))
+	antiAffinity.Spec.Strategy.BlueGreen.AntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = &v1alpha1.PreferredDuringSchedulingIgnoredDuringExecution{Weight: 50}
+	antiAffinityCond := VerifyRolloutSpec(antiAffinity, nil)
+	assert.NotNil(t, antiAffinityCond)
+	assert.Equal(t, InvalidAntiAffinityMessage, antiAffinityCond.Message)
+	antiAffinity.Spec.Strategy.BlueGreen.AntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+	assert.Nil(t, VerifyRolloutSpec(antiAffinity, nil))
+	antiAffinity.Spec.Strategy.BlueGreen.AntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution.Weight = 0
+	antiAffinityCond = VerifyRolloutSpec(antiAffinity, nil)
+	assert.NotNil(t, antiAffinityCond)
+	assert.Equal(t, InvalidAntiAffinityMessage, antiAffinityCond.Message)
+
+	ephemeralMetadata := validRollout.DeepCopy()
+	ephemeralMetadata.Spec.Strategy.BlueGreen.PreviewMetadata = &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "preview"}}
+	assert.Nil(t, VerifyRolloutSpec(ephemeralMetadata, nil))
+	ephemeralMetadata.Spec.Strategy.BlueGreen.ActiveMetadata = &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"key": "active"}}
+	ephemeralMetadataCond := VerifyRolloutSpec(ephemeralMetadata, nil)
+	assert.NotNil(t, ephemeralMetadataCond)
+	assert.Equal(t, fmt.Sprintf(InvalidEphemeralMetadataMessage, v1alpha1.DefaultRolloutUniqueLabelKey), ephemeralMetadataCond.Message)
+
+	workloadRef := validRollout.DeepCopy()
+	workloadRef.Spec.WorkloadRef = &v1alpha1.ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "guestbook"}
+	assert.Nil(t, VerifyRolloutSpec(workloadRef, nil))
+	workloadRef.Spec.WorkloadRef.Kind = "StatefulSet"
+	workloadRefCond := VerifyRolloutSpec(workloadRef, nil)
+	assert.NotNil(t, workloadRefCond)
+	assert.Equal(t, InvalidWorkloadRefMessage, workloadRefCond.Message)
+	workloadRef.Spec.WorkloadRef = &v1alpha1.ObjectRef{APIVersion: "extensions/v1beta1", Kind: "Deployment", Name: "guestbook"}
+	workloadRefCond = VerifyRolloutSpec(workloadRef, nil)
+	assert.NotNil(t, workloadRefCond)
+	assert.Equal(t, InvalidWorkloadRefMessage, workloadRefCond.Message)
+}
+
+func TestVerifyRolloutSpecSLOAnalysis(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"key": "value"},
+			},
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{},
+			},
+			SLOAnalysis: &v1alpha1.SLOAnalysis{
+				Address: "http://prometheus:9090",
+				Objectives: []v1alpha1.ServiceLevelObjective{{
+					Name:            "availability",
+					Target:          "99.9",
+					ErrorRatioQuery: "error_ratio:$(window)",
+				}},
+			},
+		},
+	}
+	assert.Nil(t, VerifyRolloutSpec(ro, nil))
+
+	ro.Spec.SLOAnalysis.Objectives[0].Target = "150"
+	cond := VerifyRolloutSpec(ro, nil)
+	assert.NotNil(t, cond)
+	assert.Equal(t, InvalidSpecReason, cond.Reason)
+	assert.Equal(t, "SLOAnalysis is invalid: objectives[0]: target must be a percentage between 0 and 100", cond.Message)
+
+	// the pod template of a workloadRef rollout is read from the Deployment and can not be rolled back
+	ro.Spec.SLOAnalysis.Objectives[0].Target = "99.9"
+	ro.Spec.WorkloadRef = &v1alpha1.ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "guestbook"}
+	cond = VerifyRolloutSpec(ro, nil)
+	assert.NotNil(t, cond)
+	assert.Equal(t, InvalidSLOAnalysisWorkloadRefMessage, cond.Message)
+}
+
+func TestVerifyRolloutSpecRollbackWindow(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"key": "value"},
+			},
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{},
+			},
+			RollbackWindow: &v1alpha1.RollbackWindow{Revisions: 2, Duration: "1h"},
+		},
+	}
+	assert.Nil(t, VerifyRolloutSpec(ro, nil))
+
+	ro.Spec.RollbackWindow.Duration = "1x"
+	cond := VerifyRolloutSpec(ro, nil)
+	assert.NotNil(t, cond)
+	assert.Contains(t, cond.Message, "RollbackWindow has an invalid duration")
 }
 
 func TestVerifyRolloutSpecBaseCases(t *testing.T) {
@@ -311,6 +409,7 @@ func TestVerifyRolloutSpecCanary(t *testing.T) {
 		maxUnavailable *intstr.IntOrString
 		maxSurge       *intstr.IntOrString
 		steps          []v1alpha1.CanaryStep
+		analysis       *v1alpha1.RolloutAnalysisBackground
 
 		notValid bool
 		reason   string
@@ -364,6 +463,55 @@ func TestVerifyRolloutSpecCanary(t *testing.T) {
 			reason:   InvalidSpecReason,
 			message:  InvalidSetWeightMessage,
 		},
+		{
+			name: "experiment weights require traffic routing",
+			steps: []v1alpha1.CanaryStep{{
+				Experiment: &v1alpha1.RolloutExperimentStep{
+					Templates: []v1alpha1.RolloutExperimentTemplate{{
+						Name:    "baseline",
+						SpecRef: v1alpha1.StableSpecRef,
+						Weight:  pointer.Int32Ptr(10),
+					}},
+				},
+			}},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidExperimentWeightMessage,
+		},
+		{
+			name: "header routes require traffic routing",
+			steps: []v1alpha1.CanaryStep{{
+				SetHeaderRoute: &v1alpha1.SetHeaderRoute{
+					Name:  "testers",
+					Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}},
+				},
+			}},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidHeaderRouteMessage,
+		},
+		{
+			name: "mirror percentage over 100",
+			steps: []v1alpha1.CanaryStep{{
+				SetMirrorRoute: &v1alpha1.SetMirrorRoute{Percentage: 110},
+			}},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidMirrorRouteMessage,
+		},
+		{
+			name: "setCanaryScale without trafficRouting",
+			steps: []v1alpha1.CanaryStep{{
+				SetCanaryScale: &v1alpha1.SetCanaryScale{Replicas: pointer.Int32Ptr(1)},
+			}},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidSetCanaryScaleMessage,
+		},
 		{
 			name: "Pause duration is not less than 0",
 			steps: []v1alpha1.CanaryStep{{
@@ -376,6 +524,29 @@ func TestVerifyRolloutSpecCanary(t *testing.T) {
 			reason:   InvalidSpecReason,
 			message:  InvalidDurationMessage,
 		},
+		{
+			name:     "startingStep is not one of the steps",
+			steps:    []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}, {Pause: &v1alpha1.RolloutPause{}}},
+			analysis: &v1alpha1.RolloutAnalysisBackground{StartingStep: pointer.Int32Ptr(2)},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidStartingStepMessage,
+		},
+		{
+			name:     "negative startingStep",
+			steps:    []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}},
+			analysis: &v1alpha1.RolloutAnalysisBackground{StartingStep: pointer.Int32Ptr(-1)},
+
+			notValid: true,
+			reason:   InvalidSpecReason,
+			message:  InvalidStartingStepMessage,
+		},
+		{
+			name:     "startingStep on the last step",
+			steps:    []v1alpha1.CanaryStep{{SetWeight: pointer.Int32Ptr(10)}, {Pause: &v1alpha1.RolloutPause{}}},
+			analysis: &v1alpha1.RolloutAnalysisBackground{StartingStep: pointer.Int32Ptr(1)},
+		},
 		{
 			name: "Pause duration invalid unit",
 			steps: []v1alpha1.CanaryStep{{
@@ -402,6 +573,7 @@ func TestVerifyRolloutSpecCanary(t *testing.T) {
 							MaxUnavailable: test.maxUnavailable,
 							MaxSurge:       test.maxSurge,
 							Steps:          test.steps,
+							Analysis:       test.analysis,
 						},
 					},
 				},
@@ -441,6 +613,94 @@ func TestInvalidMaxSurgeMaxUnavailable(t *testing.T) {
 
 }
 
+func TestInvalidPartition(t *testing.T) {
+	canary := &v1alpha1.CanaryStrategy{}
+	assert.False(t, invalidPartition(canary))
+	canary.Partition = &v1alpha1.PartitionStrategy{}
+	assert.False(t, invalidPartition(canary))
+	canary.Partition.Order = v1alpha1.PartitionOrderNodeName
+	assert.False(t, invalidPartition(canary))
+	canary.Partition.Order = "Random"
+	assert.True(t, invalidPartition(canary))
+	canary.Partition.Order = v1alpha1.PartitionOrderOldest
+	canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	assert.True(t, invalidPartition(canary))
+}
+
+func TestInvalidCluster(t *testing.T) {
+	r := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook"},
+		Spec:       v1alpha1.RolloutSpec{Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{}}},
+	}
+	assert.False(t, invalidCluster(r))
+	r.Spec.Strategy.Canary.Cluster = "east"
+	assert.True(t, invalidCluster(r))
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	r.Spec.Strategy.Canary.CanaryService = "canary"
+	assert.True(t, invalidCluster(r))
+	r.Spec.Strategy.Canary.StableService = "stable"
+	assert.False(t, invalidCluster(r))
+	// the name of the rollout is the value of the rollout label of its ReplicaSets
+	r.Name = strings.Repeat("a", 64)
+	assert.True(t, invalidCluster(r))
+}
+
+func TestInvalidSetCanaryScale(t *testing.T) {
+	r := &v1alpha1.Rollout{Spec: v1alpha1.RolloutSpec{Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{
+		TrafficRouting: &v1alpha1.RolloutTrafficRouting{},
+	}}}}
+	assert.False(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Weight: pointer.Int32Ptr(100)}))
+	assert.False(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Replicas: pointer.Int32Ptr(0)}))
+	assert.False(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{MatchTrafficWeight: true}))
+	assert.True(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{}))
+	assert.True(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Weight: pointer.Int32Ptr(101)}))
+	assert.True(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Replicas: pointer.Int32Ptr(-1)}))
+	assert.True(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Weight: pointer.Int32Ptr(50), MatchTrafficWeight: true}))
+	r.Spec.Strategy.Canary.TrafficRouting = nil
+	assert.True(t, invalidSetCanaryScale(r, v1alpha1.SetCanaryScale{Weight: pointer.Int32Ptr(100)}))
+}
+
+func TestInvalidExperimentWeights(t *testing.T) {
+	r := &v1alpha1.Rollout{Spec: v1alpha1.RolloutSpec{Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{
+		TrafficRouting: &v1alpha1.RolloutTrafficRouting{Istio: &v1alpha1.IstioTrafficRouting{}},
+	}}}}
+	step := v1alpha1.RolloutExperimentStep{
+		Templates: []v1alpha1.RolloutExperimentTemplate{
+			{Name: "baseline", SpecRef: v1alpha1.StableSpecRef, Weight: pointer.Int32Ptr(5)},
+			{Name: "canary", SpecRef: v1alpha1.CanarySpecRef, Weight: pointer.Int32Ptr(5)},
+		},
+	}
+	assert.False(t, invalidExperimentWeights(r, step))
+	step.Templates[1].Weight = pointer.Int32Ptr(96)
+	assert.True(t, invalidExperimentWeights(r, step))
+	step.Templates[1].Weight = pointer.Int32Ptr(-1)
+	assert.True(t, invalidExperimentWeights(r, step))
+
+	// the nginx traffic router cannot send traffic to the templates
+	step.Templates[1].Weight = pointer.Int32Ptr(5)
+	r.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{Nginx: &v1alpha1.NginxTrafficRouting{}}
+	assert.True(t, invalidExperimentWeights(r, step))
+}
+
+func TestInvalidHeaderRoute(t *testing.T) {
+	r := &v1alpha1.Rollout{Spec: v1alpha1.RolloutSpec{Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{
+		TrafficRouting: &v1alpha1.RolloutTrafficRouting{},
+	}}}}
+	route := v1alpha1.SetHeaderRoute{Name: "testers"}
+	assert.False(t, invalidHeaderRoute(r, route))
+	route.Match = []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Prefix: "tester-"}}}
+	assert.False(t, invalidHeaderRoute(r, route))
+	route.Match[0].CookieName = "canary"
+	assert.True(t, invalidHeaderRoute(r, route))
+	route.Match[0].HeaderName = ""
+	route.Match[0].Value.Exact = "always"
+	assert.True(t, invalidHeaderRoute(r, route))
+	route.Match[0].Value.Prefix = ""
+	assert.False(t, invalidHeaderRoute(r, route))
+	route.Name = ""
+	assert.True(t, invalidHeaderRoute(r, route))
+}
+
 func TestHasRevisionHistoryLimit(t *testing.T) {
 	r := &v1alpha1.Rollout{}
 	assert.False(t, HasRevisionHistoryLimit(r))
@@ -707,6 +967,180 @@ func TestRolloutComplete(t *testing.T) {
 
 }
 
+func TestComputeRolloutPhase(t *testing.T) {
+	newRollout := func(status v1alpha1.RolloutStatus) *v1alpha1.Rollout {
+		return &v1alpha1.Rollout{
+			Spec: v1alpha1.RolloutSpec{
+				Replicas: pointer.Int32Ptr(3),
+				Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{}},
+			},
+			Status: status,
+		}
+	}
+	healthy := v1alpha1.RolloutStatus{
+		Replicas:          3,
+		UpdatedReplicas:   3,
+		AvailableReplicas: 3,
+		CurrentPodHash:    "abc",
+		Canary:            v1alpha1.CanaryStatus{StableRS: "abc"},
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(ro *v1alpha1.Rollout)
+		phase   v1alpha1.RolloutPhase
+		message string
+	}{
+		{
+			name:   "Healthy",
+			mutate: func(ro *v1alpha1.Rollout) {},
+			phase:  v1alpha1.RolloutPhaseHealthy,
+		},
+		{
+			name: "InvalidSpec",
+			mutate: func(ro *v1alpha1.Rollout) {
+				cond := NewRolloutCondition(v1alpha1.InvalidSpec, v1.ConditionTrue, InvalidSpecReason, "missing selector")
+				ro.Status.Conditions = append(ro.Status.Conditions, *cond)
+			},
+			phase:   v1alpha1.RolloutPhaseDegraded,
+			message: "InvalidSpec: missing selector",
+		},
+		{
+			name: "ImageVerificationFailed",
+			mutate: func(ro *v1alpha1.Rollout) {
+				cond := NewRolloutCondition(v1alpha1.RolloutDegraded, v1.ConditionTrue, ImageVerificationFailedReason, "image 'app:v2': no signatures found")
+				ro.Status.Conditions = append(ro.Status.Conditions, *cond)
+			},
+			phase:   v1alpha1.RolloutPhaseDegraded,
+			message: "ImageVerificationFailed: image 'app:v2': no signatures found",
+		},
+		{
+			name: "ImagesVerified",
+			mutate: func(ro *v1alpha1.Rollout) {
+				cond := NewRolloutCondition(v1alpha1.RolloutDegraded, v1.ConditionFalse, ImagesVerifiedReason, ImagesVerifiedMessage)
+				ro.Status.Conditions = append(ro.Status.Conditions, *cond)
+			},
+			phase: v1alpha1.RolloutPhaseHealthy,
+		},
+		{
+			name: "Aborted",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.Abort = true
+				cond := NewRolloutCondition(v1alpha1.RolloutProgressing, v1.ConditionFalse, RolloutAbortedReason, "Rollout is aborted by user")
+				ro.Status.Conditions = append(ro.Status.Conditions, *cond)
+			},
+			phase:   v1alpha1.RolloutPhaseAborted,
+			message: "Rollout is aborted by user",
+		},
+		{
+			name: "TimedOut",
+			mutate: func(ro *v1alpha1.Rollout) {
+				cond := NewRolloutCondition(v1alpha1.RolloutProgressing, v1.ConditionFalse, TimedOutReason, "ReplicaSet has timed out progressing")
+				ro.Status.Conditions = append(ro.Status.Conditions, *cond)
+			},
+			phase:   v1alpha1.RolloutPhaseDegraded,
+			message: "ProgressDeadlineExceeded: ReplicaSet has timed out progressing",
+		},
+		{
+			name: "PausedManually",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Spec.Paused = true
+			},
+			phase:   v1alpha1.RolloutPhasePaused,
+			message: "Rollout is paused manually",
+		},
+		{
+			name: "PausedByStep",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.PauseConditions = []v1alpha1.PauseCondition{{Reason: v1alpha1.PauseReasonCanaryPauseStep}}
+			},
+			phase:   v1alpha1.RolloutPhasePaused,
+			message: "Rollout is paused (CanaryPauseStep)",
+		},
+		{
+			name: "UpdatingReplicas",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.UpdatedReplicas = 1
+			},
+			phase:   v1alpha1.RolloutPhaseProgressing,
+			message: "More replicas need to be updated",
+		},
+		{
+			name: "UnavailableReplicas",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.AvailableReplicas = 2
+			},
+			phase:   v1alpha1.RolloutPhaseProgressing,
+			message: "Updated replicas are still becoming available",
+		},
+		{
+			name: "OldReplicasRunning",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.Replicas = 4
+			},
+			phase:   v1alpha1.RolloutPhaseProgressing,
+			message: "Old replicas are pending termination",
+		},
+		{
+			name: "CanaryStepsRemaining",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Status.Canary.StableRS = "def"
+			},
+			phase:   v1alpha1.RolloutPhaseProgressing,
+			message: "Waiting for rollout to finish steps",
+		},
+		{
+			name: "BlueGreenCutoverPending",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Spec.Strategy = v1alpha1.RolloutStrategy{BlueGreen: &v1alpha1.BlueGreenStrategy{ActiveService: "active"}}
+				ro.Status.BlueGreen.ActiveSelector = "def"
+			},
+			phase:   v1alpha1.RolloutPhaseProgressing,
+			message: "Active service cutover pending",
+		},
+		{
+			name: "BlueGreenHealthy",
+			mutate: func(ro *v1alpha1.Rollout) {
+				ro.Spec.Strategy = v1alpha1.RolloutStrategy{BlueGreen: &v1alpha1.BlueGreenStrategy{ActiveService: "active"}}
+				ro.Status.BlueGreen.ActiveSelector = "abc"
+			},
+			phase: v1alpha1.RolloutPhaseHealthy,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ro := newRollout(*healthy.DeepCopy())
+			test.mutate(ro)
+			phase, message := ComputeRolloutPhase(ro, &ro.Status)
+			assert.Equal(t, test.phase, phase)
+			assert.Equal(t, test.message, message)
+		})
+	}
+}
+
+func TestGetRolloutPhase(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Replicas: pointer.Int32Ptr(1),
+			Strategy: v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{}},
+		},
+		Status: v1alpha1.RolloutStatus{
+			Replicas:          1,
+			UpdatedReplicas:   1,
+			AvailableReplicas: 1,
+			CurrentPodHash:    "abc",
+			Canary:            v1alpha1.CanaryStatus{StableRS: "abc"},
+		},
+	}
+	phase, message := GetRolloutPhase(ro)
+	assert.Equal(t, v1alpha1.RolloutPhaseProgressing, phase)
+	assert.Equal(t, "Waiting for the controller to observe the rollout spec", message)
+
+	ro.Status.ObservedGeneration = ComputeGenerationHash(ro.Spec)
+	phase, _ = GetRolloutPhase(ro)
+	assert.Equal(t, v1alpha1.RolloutPhaseHealthy, phase)
+}
+
 func TestRolloutTimedOut(t *testing.T) {
 
 	before := metav1.Time{
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the controller's namespace, holding settings
+	// which are applied at runtime without restarting the controller
+	ConfigMapName = "argo-rollouts-config"
+
+	// LogLevelKey sets the logging level. One of: debug|info|warn|error
+	LogLevelKey = "logLevel"
+	// LogFormatKey sets the logging format. One of: text|json
+	LogFormatKey = "logFormat"
+	// ServerSideApplyKey enables server-side apply of service selectors and rollout status
+	ServerSideApplyKey = "featureFlags.serverSideApply"
+	// WebMetricTimeoutSecondsKey sets the default timeout of web metric requests
+	WebMetricTimeoutSecondsKey = "metricProviders.web.timeoutSeconds"
+	// MaxConcurrentMeasurementsKey sets how many metrics of an AnalysisRun are measured at the same time
+	MaxConcurrentMeasurementsKey = "metricProviders.maxConcurrentMeasurements"
+	// MaxConcurrentReconcilesPerNamespaceKey bounds the rollouts of a namespace reconciled at the
+	// same time. Unlimited if not set
+	MaxConcurrentReconcilesPerNamespaceKey = "rollouts.maxConcurrentReconcilesPerNamespace"
+	// DisabledMetricProvidersKey is a comma separated list of metric provider types (e.g.
+	// wavefront,kayenta) which AnalysisRuns are not allowed to use
+	DisabledMetricProvidersKey = "metricProviders.disabled"
+	// MetricProviderPluginsKey is a comma separated list of the metric provider plugins AnalysisRuns
+	// may use, as <name>=<address> pairs
+	MetricProviderPluginsKey = "metricProviders.plugins"
+	// DisabledTrafficRoutersKey is a comma separated list of traffic routers (e.g. istio) which
+	// rollouts are not allowed to use
+	DisabledTrafficRoutersKey = "trafficRouters.disabled"
+	// TrafficRouterPluginsKey is a comma separated list of the traffic router plugins rollouts may
+	// use, as <name>=<address> pairs
+	TrafficRouterPluginsKey = "trafficRouters.plugins"
+	// SMIAPIVersionKey sets the apiVersion of the SMI TrafficSplits managed by the controller
+	SMIAPIVersionKey = "trafficRouters.smi.apiVersion"
+	// GatewayAPIVersionKey sets the apiVersion of the Gateway API HTTPRoutes managed by the controller
+	GatewayAPIVersionKey = "trafficRouters.gatewayAPI.apiVersion"
+	// ALBVerifyWeightKey enables verifying with the AWS API that the listener rules of the load balancer
+	// of an ALB Ingress forward the desired weight to the canary before a setWeight step completes
+	ALBVerifyWeightKey = "trafficRouters.alb.verifyWeight"
+	// ALBRegionKey sets the AWS region of the load balancers of ALB Ingresses. Defaults to AWS_REGION
+	ALBRegionKey = "trafficRouters.alb.region"
+	// RevisionHistoryKey enables recording rollout revisions in ControllerRevisions
+	RevisionHistoryKey = "featureFlags.revisionHistory"
+	// RevisionHistoryLimitKey sets how many ControllerRevisions are kept per rollout
+	RevisionHistoryLimitKey = "rollouts.revisionHistory.limit"
+	// RolloutPhaseKey enables maintaining the phase and message of the rollout status
+	RolloutPhaseKey = "featureFlags.rolloutPhase"
+	// VerifyReferencesKey enables verifying the objects referenced by a rollout before its update starts
+	VerifyReferencesKey = "featureFlags.verifyReferences"
+	// VerifyImageSignaturesKey enables verifying the cosign signatures of the images of a new
+	// revision before its ReplicaSet is created
+	VerifyImageSignaturesKey = "featureFlags.verifyImageSignatures"
+	// ImageVerificationImagesKey is a comma separated list of image prefixes (e.g.
+	// registry.example.com/team/) which must be signed. All images must be signed if not set
+	ImageVerificationImagesKey = "imageVerification.images"
+	// ImageVerificationPublicKeysKey holds the PEM encoded public keys images may be signed with
+	ImageVerificationPublicKeysKey = "imageVerification.publicKeys"
+	// ImageVerificationIdentitiesKey is a comma separated list of the identities (emails or URIs)
+	// which may sign images with keyless signatures
+	ImageVerificationIdentitiesKey = "imageVerification.keyless.identities"
+	// ImageVerificationIssuerKey sets the OIDC issuer of the identities of keyless signatures
+	ImageVerificationIssuerKey = "imageVerification.keyless.issuer"
+	// ImageVerificationRootsKey holds the PEM encoded root certificates of keyless signatures
+	ImageVerificationRootsKey = "imageVerification.keyless.roots"
+	// ImageVerificationRekorPublicKeyKey holds the PEM encoded public key of the transparency log
+	ImageVerificationRekorPublicKeyKey = "imageVerification.keyless.rekorPublicKey"
+	// SecretBackendKey sets where the credentials of metric providers are read from. One of:
+	// kubernetes|vault|aws|gcp
+	SecretBackendKey = "secrets.backend"
+	// SecretCacheTTLSecondsKey sets how long secrets read from an external backend are cached
+	SecretCacheTTLSecondsKey = "secrets.cacheTTLSeconds"
+	// SecretVaultAddressKey sets the address of the Vault server
+	SecretVaultAddressKey = "secrets.vault.address"
+	// SecretVaultRoleKey sets the Vault role the controller logs in as with Kubernetes auth
+	SecretVaultRoleKey = "secrets.vault.role"
+	// SecretVaultAuthPathKey sets the mount path of the Kubernetes auth method in Vault
+	SecretVaultAuthPathKey = "secrets.vault.authPath"
+	// SecretVaultPathPrefixKey sets the path secrets are read from in Vault
+	SecretVaultPathPrefixKey = "secrets.vault.pathPrefix"
+	// SecretAWSRegionKey sets the region of AWS Secrets Manager
+	SecretAWSRegionKey = "secrets.aws.region"
+	// SecretAWSPrefixKey sets the prefix of the names of secrets in AWS Secrets Manager
+	SecretAWSPrefixKey = "secrets.aws.prefix"
+	// SecretAWSEndpointKey overrides the endpoint of AWS Secrets Manager
+	SecretAWSEndpointKey = "secrets.aws.endpoint"
+	// SecretGCPProjectKey sets the project of GCP Secret Manager
+	SecretGCPProjectKey = "secrets.gcp.project"
+	// SecretGCPPrefixKey sets the prefix of the names of secrets in GCP Secret Manager
+	SecretGCPPrefixKey = "secrets.gcp.prefix"
+	// SecretGCPEndpointKey overrides the endpoint of GCP Secret Manager
+	SecretGCPEndpointKey = "secrets.gcp.endpoint"
+	// FeatureFlagLaunchDarklyAddressKey overrides the address of the LaunchDarkly API used by
+	// setFeatureFlag steps
+	FeatureFlagLaunchDarklyAddressKey = "featureFlagProviders.launchDarkly.address"
+	// FeatureFlagUnleashAddressKey sets the address of the Unleash server used by setFeatureFlag steps
+	FeatureFlagUnleashAddressKey = "featureFlagProviders.unleash.address"
+	// AnalysisReportsKey enables publishing a report of each completed AnalysisRun
+	AnalysisReportsKey = "featureFlags.analysisReports"
+	// AnalysisReportsStoreKey sets where the reports of AnalysisRuns are published. One of: configMap|http
+	AnalysisReportsStoreKey = "analysisReports.store"
+	// AnalysisReportsHTTPURLKey sets the URL of the bucket the reports are uploaded to
+	AnalysisReportsHTTPURLKey = "analysisReports.http.url"
+	// AnalysisReportsHTTPTokenSecretKey sets the name of the secret holding the bearer token used to
+	// upload the reports
+	AnalysisReportsHTTPTokenSecretKey = "analysisReports.http.tokenSecret"
+)
+
+// Config is an immutable snapshot of the settings in the ConfigMap
+type Config struct {
+	data map[string]string
+}
+
+var (
+	current atomic.Value
+
+	// updateLock serializes rebuilding the snapshot from the flag defaults and the ConfigMap
+	updateLock    sync.Mutex
+	flagDefaults  = map[string]string{}
+	configMapData = map[string]string{}
+)
+
+func init() {
+	current.Store(&Config{})
+}
+
+// Get returns the current settings
+func Get() *Config {
+	return current.Load().(*Config)
+}
+
+// GetString returns the setting for the key or the default if it is not set
+func (c *Config) GetString(key, defaultValue string) string {
+	if value, ok := c.data[key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetBool returns the setting for the key or the default if it is not set or is invalid
+func (c *Config) GetBool(key string, defaultValue bool) bool {
+	value, ok := c.data[key]
+	if !ok {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warnf("Invalid value '%s' for config '%s': %v", value, key, err)
+		return defaultValue
+	}
+	return b
+}
+
+// GetInt returns the setting for the key or the default if it is not set or is invalid
+func (c *Config) GetInt(key string, defaultValue int) int {
+	value, ok := c.data[key]
+	if !ok {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warnf("Invalid value '%s' for config '%s': %v", value, key, err)
+		return defaultValue
+	}
+	return i
+}
+
+// GetStringSlice returns the comma separated values of the setting for the key or the default if
+// it is not set
+func (c *Config) GetStringSlice(key string, defaultValue []string) []string {
+	value, ok := c.data[key]
+	if !ok {
+		return defaultValue
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// SetDefaults sets the values used for keys missing from the ConfigMap, such as the values of
+// command line flags
+func SetDefaults(defaults map[string]string) {
+	updateLock.Lock()
+	defer updateLock.Unlock()
+	flagDefaults = map[string]string{}
+	for k, v := range defaults {
+		flagDefaults[k] = v
+	}
+	current.Store(buildConfig())
+}
+
+// Update replaces the current settings with the data of the ConfigMap and applies the logging
+// settings. A nil ConfigMap resets all settings to their defaults.
+func Update(cm *corev1.ConfigMap) {
+	updateLock.Lock()
+	defer updateLock.Unlock()
+	configMapData = map[string]string{}
+	if cm != nil {
+		for k, v := range cm.Data {
+			configMapData[k] = v
+		}
+	}
+	cfg := buildConfig()
+	applyLogSettings(cfg)
+	current.Store(cfg)
+	log.Infof("Loaded configuration from ConfigMap '%s'", ConfigMapName)
+}
+
+func buildConfig() *Config {
+	data := map[string]string{}
+	for k, v := range flagDefaults {
+		data[k] = v
+	}
+	for k, v := range configMapData {
+		data[k] = v
+	}
+	return &Config{data: data}
+}
+
+func applyLogSettings(cfg *Config) {
+	if levelStr, ok := cfg.data[LogLevelKey]; ok {
+		level, err := log.ParseLevel(levelStr)
+		if err != nil {
+			log.Warnf("Invalid value '%s' for config '%s': %v", levelStr, LogLevelKey, err)
+		} else if level != log.GetLevel() {
+			log.Infof("Setting log level to %s", level)
+			log.SetLevel(level)
+		}
+	}
+	if format, ok := cfg.data[LogFormatKey]; ok {
+		formatter, err := logutil.NewFormatter(format)
+		if err != nil {
+			log.Warnf("Invalid value '%s' for config '%s': %v", format, LogFormatKey, err)
+		} else {
+			log.SetFormatter(formatter)
+		}
+	}
+}
+
+// Watch starts an informer on the ConfigMap in the namespace and updates the settings whenever it
+// changes. It returns once the ConfigMap has been read, so the settings are applied before the
+// controllers start.
+func Watch(kubeclientset kubernetes.Interface, namespace string, resyncPeriod time.Duration, stopCh <-chan struct{}) {
+	factory := kubeinformers.NewSharedInformerFactoryWithOptions(
+		kubeclientset,
+		resyncPeriod,
+		kubeinformers.WithNamespace(namespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", ConfigMapName).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cm, ok := obj.(*corev1.ConfigMap); ok {
+				Update(cm)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if cm, ok := new.(*corev1.ConfigMap); ok {
+				Update(cm)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			Update(nil)
+		},
+	})
+	factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		log.Warn("Failed to read the controller settings before starting")
+	}
+}
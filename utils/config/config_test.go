@@ -0,0 +1,92 @@
+package config
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: "argo-rollouts",
+		},
+		Data: data,
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	Update(nil)
+	cfg := Get()
+	assert.Equal(t, "foo", cfg.GetString(LogLevelKey, "foo"))
+	assert.True(t, cfg.GetBool(ServerSideApplyKey, true))
+	assert.Equal(t, 10, cfg.GetInt(WebMetricTimeoutSecondsKey, 10))
+}
+
+func TestUpdate(t *testing.T) {
+	defer Update(nil)
+	Update(newConfigMap(map[string]string{
+		ServerSideApplyKey:         "true",
+		WebMetricTimeoutSecondsKey: "30",
+	}))
+	cfg := Get()
+	assert.True(t, cfg.GetBool(ServerSideApplyKey, false))
+	assert.Equal(t, 30, cfg.GetInt(WebMetricTimeoutSecondsKey, 10))
+}
+
+func TestInvalidValuesUseDefault(t *testing.T) {
+	defer Update(nil)
+	Update(newConfigMap(map[string]string{
+		ServerSideApplyKey:         "maybe",
+		WebMetricTimeoutSecondsKey: "ten",
+	}))
+	cfg := Get()
+	assert.False(t, cfg.GetBool(ServerSideApplyKey, false))
+	assert.Equal(t, 10, cfg.GetInt(WebMetricTimeoutSecondsKey, 10))
+}
+
+func TestUpdateLogSettings(t *testing.T) {
+	origLevel := log.GetLevel()
+	origFormatter := log.StandardLogger().Formatter
+	defer func() {
+		log.SetLevel(origLevel)
+		log.SetFormatter(origFormatter)
+		Update(nil)
+	}()
+
+	Update(newConfigMap(map[string]string{
+		LogLevelKey:  "debug",
+		LogFormatKey: "json",
+	}))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+	assert.IsType(t, &log.JSONFormatter{}, log.StandardLogger().Formatter)
+
+	// invalid values leave the current settings untouched
+	Update(newConfigMap(map[string]string{
+		LogLevelKey:  "loud",
+		LogFormatKey: "xml",
+	}))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+	assert.IsType(t, &log.JSONFormatter{}, log.StandardLogger().Formatter)
+}
+
+func TestSetDefaults(t *testing.T) {
+	defer SetDefaults(nil)
+	defer Update(nil)
+	SetDefaults(map[string]string{
+		DisabledMetricProvidersKey: "wavefront, kayenta",
+		DisabledTrafficRoutersKey:  "istio",
+	})
+	assert.Equal(t, []string{"wavefront", "kayenta"}, Get().GetStringSlice(DisabledMetricProvidersKey, nil))
+
+	// the ConfigMap takes precedence over the defaults
+	Update(newConfigMap(map[string]string{
+		DisabledMetricProvidersKey: "",
+	}))
+	assert.Empty(t, Get().GetStringSlice(DisabledMetricProvidersKey, []string{"job"}))
+	assert.Equal(t, []string{"istio"}, Get().GetStringSlice(DisabledTrafficRoutersKey, nil))
+}
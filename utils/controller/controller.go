@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -21,6 +22,7 @@ import (
 	"github.com/argoproj/argo-rollouts/controller/metrics"
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	logutil "github.com/argoproj/argo-rollouts/utils/log"
+	"github.com/argoproj/argo-rollouts/utils/tracing"
 )
 
 // processNextWatchObj will process a single object from the watch by seeing if
@@ -103,6 +105,25 @@ func RunWorker(workqueue workqueue.RateLimitingInterface, objType string, syncHa
 	}
 }
 
+// RunWorkers starts threadiness workers processing the workqueue and blocks until stopCh is closed.
+// The workqueue is then shut down so no new items are processed, and RunWorkers waits for the
+// workers to finish the items they are currently processing before returning.
+func RunWorkers(threadiness int, workqueue workqueue.RateLimitingInterface, objType string, syncHandler func(string) error, metricServer *metrics.MetricsServer, stopCh <-chan struct{}) {
+	var wg sync.WaitGroup
+	for i := 0; i < threadiness; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() {
+				RunWorker(workqueue, objType, syncHandler, metricServer)
+			}, time.Second, stopCh)
+		}()
+	}
+	<-stopCh
+	workqueue.ShutDown()
+	wg.Wait()
+}
+
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
 func processNextWorkItem(workqueue workqueue.RateLimitingInterface, objType string, syncHandler func(string) error, metricsServer *metrics.MetricsServer) bool {
@@ -111,6 +132,12 @@ func processNextWorkItem(workqueue workqueue.RateLimitingInterface, objType stri
 	if shutdown {
 		return false
 	}
+	// Items which are still queued when the queue is shut down are not processed, so that a
+	// shutdown only waits for the reconciliations already in progress
+	if workqueue.ShuttingDown() {
+		workqueue.Done(obj)
+		return false
+	}
 
 	// We wrap this block in a func so we can defer c.workqueue.Done.
 	err := func(obj interface{}) error {
@@ -137,10 +164,13 @@ func processNextWorkItem(workqueue workqueue.RateLimitingInterface, objType stri
 			return nil
 		}
 		namespace, name, err := cache.SplitMetaNamespaceKey(key)
-		logCtx := log.WithField(objType, name).WithField(logutil.NamespaceKey, namespace)
 		if err != nil {
 			return err
 		}
+		reconcileID := logutil.NewReconcileID()
+		logutil.SetReconcileID(objType, namespace, name, reconcileID)
+		defer logutil.ClearReconcileID(objType, namespace, name)
+		logCtx := log.WithField(objType, name).WithField(logutil.NamespaceKey, namespace).WithField(logutil.ReconcileIDKey, reconcileID)
 		runSyncHandler := func() (err error) {
 			defer func() {
 				if r := recover(); r != nil {
@@ -152,7 +182,10 @@ func processNextWorkItem(workqueue workqueue.RateLimitingInterface, objType stri
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Rollout resource to be synced.
-		if err := runSyncHandler(); err != nil {
+		tracing.StartReconcile(objType, namespace, name)
+		err = runSyncHandler()
+		tracing.EndReconcile(objType, namespace, name, err)
+		if err != nil {
 			metricsServer.IncError(namespace, name)
 			// Put the item back on the workqueue to handle any transient errors.
 			workqueue.AddRateLimited(key)
@@ -32,7 +32,7 @@ import (
 func TestProcessNextWorkItemHandlePanic(t *testing.T) {
 	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
 	q.Add("valid/key")
-	metricServer := metrics.NewMetricsServer("localhost:8080", nil, &metrics.K8sRequestsCountProvider{})
+	metricServer := metrics.NewMetricsServer("localhost:8080", nil, nil, &metrics.K8sRequestsCountProvider{})
 	syncHandler := func(key string) error {
 		panic("Bad big panic :(")
 	}
@@ -78,7 +78,7 @@ func TestProcessNextWorkItemNormalSync(t *testing.T) {
 func TestProcessNextWorkItemSyncHandlerReturnError(t *testing.T) {
 	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
 	q.Add("valid/key")
-	metricServer := metrics.NewMetricsServer("localhost:8080", nil, &metrics.K8sRequestsCountProvider{})
+	metricServer := metrics.NewMetricsServer("localhost:8080", nil, nil, &metrics.K8sRequestsCountProvider{})
 	syncHandler := func(key string) error {
 		return fmt.Errorf("error message")
 	}
@@ -449,3 +449,46 @@ func TestProcessNextWatchObj(t *testing.T) {
 		assert.Equal(t, 0, wq.Len())
 	}
 }
+
+func TestProcessNextWorkItemSkipsQueuedItemsOnShutDown(t *testing.T) {
+	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
+	q.Add("default/foo")
+	q.ShutDown()
+	called := false
+	syncHandler := func(key string) error {
+		called = true
+		return nil
+	}
+	assert.False(t, processNextWorkItem(q, log.RolloutKey, syncHandler, nil))
+	assert.False(t, called)
+}
+
+func TestRunWorkersWaitsForInFlightItems(t *testing.T) {
+	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Rollouts")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := false
+	syncHandler := func(key string) error {
+		close(started)
+		<-release
+		finished = true
+		return nil
+	}
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		RunWorkers(1, q, log.RolloutKey, syncHandler, nil, stopCh)
+		close(done)
+	}()
+	q.Add("default/foo")
+	<-started
+	close(stopCh)
+	select {
+	case <-done:
+		t.Fatal("RunWorkers returned before the in-flight item finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	assert.True(t, finished)
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"sync"
+)
+
+// NamespaceLimiter bounds the number of objects of a namespace which are reconciled at the same
+// time, so the objects of one namespace cannot occupy every worker of a shared controller. Half of
+// the slots of a namespace are reserved for priority objects, such as rollouts in the middle of an
+// update, so they are not delayed by objects which are only being resynced.
+type NamespaceLimiter struct {
+	lock     sync.Mutex
+	inFlight map[string]int
+}
+
+// NewNamespaceLimiter returns a limiter with no reconciles in flight
+func NewNamespaceLimiter() *NamespaceLimiter {
+	return &NamespaceLimiter{
+		inFlight: make(map[string]int),
+	}
+}
+
+// TryAcquire takes a slot for a reconcile in the namespace and returns false if the namespace is
+// at its limit. A limit less than one means unlimited. Each successful TryAcquire must be followed
+// by a Release.
+func (l *NamespaceLimiter) TryAcquire(namespace string, limit int, priority bool) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if limit > 0 {
+		effectiveLimit := limit
+		if !priority {
+			effectiveLimit = limit / 2
+			if effectiveLimit < 1 {
+				effectiveLimit = 1
+			}
+		}
+		if l.inFlight[namespace] >= effectiveLimit {
+			return false
+		}
+	}
+	l.inFlight[namespace]++
+	return true
+}
+
+// Release returns the slot taken by TryAcquire
+func (l *NamespaceLimiter) Release(namespace string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inFlight[namespace]--
+	if l.inFlight[namespace] <= 0 {
+		delete(l.inFlight, namespace)
+	}
+}
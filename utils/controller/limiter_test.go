@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceLimiter(t *testing.T) {
+	l := NewNamespaceLimiter()
+	// steady state objects may only use half of the slots
+	assert.True(t, l.TryAcquire("team-a", 4, false))
+	assert.True(t, l.TryAcquire("team-a", 4, false))
+	assert.False(t, l.TryAcquire("team-a", 4, false))
+	// the remaining slots are kept for priority objects
+	assert.True(t, l.TryAcquire("team-a", 4, true))
+	assert.True(t, l.TryAcquire("team-a", 4, true))
+	assert.False(t, l.TryAcquire("team-a", 4, true))
+	// other namespaces are not affected
+	assert.True(t, l.TryAcquire("team-b", 4, false))
+
+	l.Release("team-a")
+	assert.True(t, l.TryAcquire("team-a", 4, true))
+}
+
+func TestNamespaceLimiterUnlimited(t *testing.T) {
+	l := NewNamespaceLimiter()
+	for i := 0; i < 100; i++ {
+		assert.True(t, l.TryAcquire("team-a", 0, false))
+	}
+	for i := 0; i < 100; i++ {
+		l.Release("team-a")
+	}
+	assert.Empty(t, l.inFlight)
+}
+
+func TestNamespaceLimiterMinimumSteadyStateSlot(t *testing.T) {
+	l := NewNamespaceLimiter()
+	assert.True(t, l.TryAcquire("team-a", 1, false))
+	assert.False(t, l.TryAcquire("team-a", 1, true))
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TransformFunc mutates an object received from the API server before it is stored in an informer cache
+type TransformFunc func(obj runtime.Object)
+
+// NewTransformingListWatch returns a ListerWatcher which applies the transform to every object
+// listed or watched through lw
+func NewTransformingListWatch(lw cache.ListerWatcher, transform TransformFunc) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+			err = meta.EachListItem(list, func(obj runtime.Object) error {
+				transform(obj)
+				return nil
+			})
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				if in.Type != watch.Error {
+					transform(in.Object)
+				}
+				return in, true
+			}), nil
+		},
+	}
+}
+
+// StripManagedFields drops the managed fields, which are often larger than the object itself and
+// never read by the controller. Updates of an object without managed fields leave them untouched.
+func StripManagedFields(obj runtime.Object) {
+	if acc, err := meta.Accessor(obj); err == nil {
+		acc.SetManagedFields(nil)
+	}
+}
+
+// StripLastAppliedConfiguration drops the kubectl last-applied-configuration annotation. Only use
+// for objects the controller patches, since a full update would remove the annotation from the
+// live object.
+func StripLastAppliedConfiguration(obj runtime.Object) {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	annotations := acc.GetAnnotations()
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		return
+	}
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+	acc.SetAnnotations(annotations)
+}
+
+// stripJob drops the pod template of jobs created by the job metric provider, which only needs
+// their labels and status
+func stripJob(obj runtime.Object) {
+	StripManagedFields(obj)
+	StripLastAppliedConfiguration(obj)
+	if job, ok := obj.(*batchv1.Job); ok {
+		job.Spec.Template = corev1.PodTemplateSpec{}
+	}
+}
+
+// StripPod keeps the metadata, node and status conditions of a pod, which are the fields of the
+// pods of a DaemonSet read by the controller, and drops the rest of its spec and status
+func StripPod(obj runtime.Object) {
+	StripManagedFields(obj)
+	StripLastAppliedConfiguration(obj)
+	if pod, ok := obj.(*corev1.Pod); ok {
+		pod.Spec = corev1.PodSpec{NodeName: pod.Spec.NodeName}
+		pod.Status = corev1.PodStatus{Phase: pod.Status.Phase, Conditions: pod.Status.Conditions}
+	}
+}
+
+// StripControllerRevision drops the managed fields, the last-applied-configuration annotation and
+// the pod template stored in the data of a ControllerRevision, since only its labels, owner and
+// revision are read by the controller
+func StripControllerRevision(obj runtime.Object) {
+	StripManagedFields(obj)
+	StripLastAppliedConfiguration(obj)
+	if revision, ok := obj.(*appsv1.ControllerRevision); ok {
+		revision.Data = runtime.RawExtension{}
+	}
+}
+
+// stripPatchedObject is used for objects the controller reads or patches but never updates
+func stripPatchedObject(obj runtime.Object) {
+	StripManagedFields(obj)
+	StripLastAppliedConfiguration(obj)
+}
+
+func newTransformedInformer(objType runtime.Object, lw *cache.ListWatch, transform TransformFunc, resync time.Duration) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		NewTransformingListWatch(lw, transform),
+		objType,
+		resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+}
+
+// AddCacheTransforms registers the ReplicaSet, Deployment, DaemonSet, Service and Secret informers
+// of the factory with transforms which strip data the controller never reads. It must be called
+// before the informers are requested from the factory. ReplicaSets are updated by the controller so
+// only their managed fields are dropped. The pod templates of Deployments and DaemonSets are kept,
+// since they are read by workload references and canary DaemonSets.
+func AddCacheTransforms(factory kubeinformers.SharedInformerFactory, namespace string) {
+	factory.InformerFor(&appsv1.ReplicaSet{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&appsv1.ReplicaSet{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().ReplicaSets(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.AppsV1().ReplicaSets(namespace).Watch(options)
+			},
+		}, StripManagedFields, resync)
+	})
+	factory.InformerFor(&appsv1.Deployment{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&appsv1.Deployment{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().Deployments(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.AppsV1().Deployments(namespace).Watch(options)
+			},
+		}, stripPatchedObject, resync)
+	})
+	factory.InformerFor(&appsv1.DaemonSet{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&appsv1.DaemonSet{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().DaemonSets(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.AppsV1().DaemonSets(namespace).Watch(options)
+			},
+		}, stripPatchedObject, resync)
+	})
+	factory.InformerFor(&corev1.Service{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&corev1.Service{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Services(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Services(namespace).Watch(options)
+			},
+		}, stripPatchedObject, resync)
+	})
+	factory.InformerFor(&corev1.Secret{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&corev1.Secret{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Secrets(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Secrets(namespace).Watch(options)
+			},
+		}, stripPatchedObject, resync)
+	})
+}
+
+// AddJobCacheTransforms registers the Job informer of the factory with a transform which strips
+// the job pod templates. Only jobs matching the label selector are cached.
+func AddJobCacheTransforms(factory kubeinformers.SharedInformerFactory, namespace, labelSelector string) {
+	factory.InformerFor(&batchv1.Job{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return newTransformedInformer(&batchv1.Job{}, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = labelSelector
+				return client.BatchV1().Jobs(namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = labelSelector
+				return client.BatchV1().Jobs(namespace).Watch(options)
+			},
+		}, stripJob, resync)
+	})
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newStrippableService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "active",
+			Namespace: "default",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"kind":"Service"}`,
+				"keep":                             "me",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+	}
+}
+
+func TestTransformingListWatch(t *testing.T) {
+	fakeWatch := watch.NewFake()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.ServiceList{Items: []corev1.Service{*newStrippableService()}}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return fakeWatch, nil
+		},
+	}
+	transformed := NewTransformingListWatch(lw, stripPatchedObject)
+
+	list, err := transformed.List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	svc := list.(*corev1.ServiceList).Items[0]
+	assert.Nil(t, svc.ManagedFields)
+	assert.Equal(t, map[string]string{"keep": "me"}, svc.Annotations)
+
+	w, err := transformed.Watch(metav1.ListOptions{})
+	assert.NoError(t, err)
+	defer w.Stop()
+	go fakeWatch.Add(newStrippableService())
+	event := <-w.ResultChan()
+	watched := event.Object.(*corev1.Service)
+	assert.Nil(t, watched.ManagedFields)
+	assert.Equal(t, map[string]string{"keep": "me"}, watched.Annotations)
+}
+
+func TestStripJob(t *testing.T) {
+	job := &batchv1.Job{
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "test", Image: "busybox"}},
+				},
+			},
+		},
+		Status: batchv1.JobStatus{Succeeded: 1},
+	}
+	stripJob(job)
+	assert.Empty(t, job.Spec.Template.Spec.Containers)
+	assert.Equal(t, int32(1), job.Status.Succeeded)
+}
+
+func TestStripPod(t *testing.T) {
+	condition := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:          map[string]string{"controller-revision-hash": "abc"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent"}},
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubelet"}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "agent", Image: "agent:v1"}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{condition},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "agent"}},
+		},
+	}
+	StripPod(pod)
+	assert.Nil(t, pod.ManagedFields)
+	assert.Equal(t, "abc", pod.Labels["controller-revision-hash"])
+	assert.Len(t, pod.OwnerReferences, 1)
+	assert.Equal(t, corev1.PodSpec{NodeName: "node-1"}, pod.Spec)
+	assert.Equal(t, corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{condition}}, pod.Status)
+}
+
+func TestStripControllerRevision(t *testing.T) {
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:        map[string]string{"controller-revision-hash": "abc"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kube-controller-manager"}},
+		},
+		Data:     runtime.RawExtension{Raw: []byte(`{"spec":{"template":{}}}`)},
+		Revision: 2,
+	}
+	StripControllerRevision(revision)
+	assert.Nil(t, revision.ManagedFields)
+	assert.Nil(t, revision.Data.Raw)
+	assert.Equal(t, int64(2), revision.Revision)
+	assert.Equal(t, "abc", revision.Labels["controller-revision-hash"])
+}
@@ -0,0 +1,401 @@
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// signatureAnnotation holds the base64 encoded signature of a signature layer
+	signatureAnnotation = "dev.cosignproject.cosign/signature"
+	// certificateAnnotation holds the signing certificate of keyless signatures
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	// chainAnnotation holds the intermediate certificates of keyless signatures
+	chainAnnotation = "dev.sigstore.cosign/chain"
+	// bundleAnnotation holds the transparency log entry of keyless signatures
+	bundleAnnotation = "dev.sigstore.cosign/bundle"
+
+	requestTimeout = 30 * time.Second
+)
+
+var (
+	// oidcIssuerOID is the Fulcio certificate extension holding the OIDC issuer of the identity
+	oidcIssuerOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// oidcIssuerV2OID is the DER encoded version of the OIDC issuer extension
+	oidcIssuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Policy is what the signatures of images are verified against. An image is verified if one of its
+// signatures is made by one of the public keys, or by a certificate issued by one of the roots to
+// one of the identities.
+type Policy struct {
+	PublicKeys []crypto.PublicKey
+	// Roots are the certificate authorities issuing the certificates of keyless signatures
+	Roots *x509.CertPool
+	// Identities are the accepted subjects (emails or URIs) of keyless signatures
+	Identities []string
+	// Issuer is the accepted OIDC issuer of the identities of keyless signatures
+	Issuer string
+	// RekorPublicKey verifies the signed entry timestamps of the transparency log. It is required
+	// with keyless identities, since the time certificates are verified at is read from the entry.
+	RekorPublicKey crypto.PublicKey
+}
+
+// ParsePolicy returns the policy of the PEM encoded public keys, keyless roots and identities
+func ParsePolicy(publicKeys, roots string, identities []string, issuer, rekorPublicKey string) (*Policy, error) {
+	policy := &Policy{Identities: identities, Issuer: issuer}
+	keys, err := parsePublicKeys(publicKeys)
+	if err != nil {
+		return nil, err
+	}
+	policy.PublicKeys = keys
+	if len(identities) > 0 {
+		policy.Roots = x509.NewCertPool()
+		if !policy.Roots.AppendCertsFromPEM([]byte(roots)) {
+			return nil, fmt.Errorf("keyless verification requires the PEM encoded root certificates")
+		}
+	}
+	if rekorPublicKey != "" {
+		keys, err := parsePublicKeys(rekorPublicKey)
+		if err != nil || len(keys) != 1 {
+			return nil, fmt.Errorf("invalid Rekor public key")
+		}
+		policy.RekorPublicKey = keys[0]
+	}
+	if len(identities) > 0 && policy.RekorPublicKey == nil {
+		return nil, fmt.Errorf("keyless verification requires the Rekor public key")
+	}
+	if len(policy.PublicKeys) == 0 && len(policy.Identities) == 0 {
+		return nil, fmt.Errorf("no public keys or keyless identities are configured")
+	}
+	return policy, nil
+}
+
+func parsePublicKeys(data string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 && strings.TrimSpace(data) != "" {
+		return nil, fmt.Errorf("invalid public key: no PEM data found")
+	}
+	return keys, nil
+}
+
+// Verifier verifies the signatures stored by cosign next to images in their registry
+type Verifier struct {
+	client *http.Client
+}
+
+// NewVerifier returns a verifier reading signatures from registries
+func NewVerifier() *Verifier {
+	return &Verifier{client: &http.Client{Timeout: requestTimeout}}
+}
+
+// signatureManifest is the manifest of the signatures of an image
+type signatureManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// simpleSigning is the payload signed by cosign
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// Verify returns the digest of the image if one of its signatures satisfies the policy
+func (v *Verifier) Verify(image string, policy *Policy, credentials Credentials) (string, error) {
+	ref, err := parseReference(image)
+	if err != nil {
+		return "", err
+	}
+	registry := &registryClient{client: v.client, credentials: credentials, tokens: map[string]string{}}
+	digest, err := registry.resolveDigest(ref)
+	if err != nil {
+		return "", err
+	}
+	sigRef := *ref
+	sigRef.tag = strings.Replace(digest, ":", "-", 1) + ".sig"
+	data, _, err := registry.read(&sigRef, "manifests/"+sigRef.tag, "", []string{"application/vnd.oci.image.manifest.v1+json", "application/vnd.docker.distribution.manifest.v2+json"})
+	if err == errNotFound {
+		return "", fmt.Errorf("no signatures found for %s", digest)
+	}
+	if err != nil {
+		return "", err
+	}
+	var manifest signatureManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("invalid signature manifest: %v", err)
+	}
+	var problems []string
+	for _, layer := range manifest.Layers {
+		payload, _, err := registry.read(&sigRef, "blobs/"+layer.Digest, layer.Digest, nil)
+		if err == nil {
+			err = verifySignature(digest, payload, layer.Annotations, policy)
+		}
+		if err == nil {
+			return digest, nil
+		}
+		problems = append(problems, err.Error())
+	}
+	if len(problems) == 0 {
+		return "", fmt.Errorf("no signatures found for %s", digest)
+	}
+	return "", fmt.Errorf("no valid signature for %s: %s", digest, strings.Join(problems, "; "))
+}
+
+// verifySignature verifies a signature of the image digest against the policy
+func verifySignature(digest string, payload []byte, annotations map[string]string, policy *Policy) error {
+	signature, err := base64.StdEncoding.DecodeString(annotations[signatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("invalid signature")
+	}
+	var signed simpleSigning
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("invalid signature payload: %v", err)
+	}
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature is for %s", signed.Critical.Image.DockerManifestDigest)
+	}
+	if cert := annotations[certificateAnnotation]; cert != "" {
+		if len(policy.Identities) == 0 {
+			return fmt.Errorf("keyless signatures are not accepted")
+		}
+		key, err := verifyCertificate(cert, annotations[chainAnnotation], annotations[bundleAnnotation], payload, signature, policy)
+		if err != nil {
+			return err
+		}
+		return verifyWithKey(key, payload, signature)
+	}
+	for _, key := range policy.PublicKeys {
+		if verifyWithKey(key, payload, signature) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature does not match any public key")
+}
+
+// rekorBundle is the transparency log entry of a keyless signature
+type rekorBundle struct {
+	SignedEntryTimestamp string `json:"SignedEntryTimestamp"`
+	Payload              struct {
+		Body           interface{} `json:"body"`
+		IntegratedTime int64       `json:"integratedTime"`
+		LogIndex       int64       `json:"logIndex"`
+		LogID          string      `json:"logID"`
+	} `json:"Payload"`
+}
+
+// rekorEntry is the body of a hashedrekord or rekord transparency log entry
+type rekorEntry struct {
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   string `json:"content"`
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyCertificate verifies the signing certificate of a keyless signature and returns its key.
+// Signing certificates are short lived, so the certificate is verified at the time the signature was
+// recorded in the transparency log. The entry must be signed by the transparency log and record
+// this signature, so the time cannot be forged and entries of other signatures cannot be reused.
+func verifyCertificate(certPEM, chainPEM, bundleJSON string, payload, signature []byte, policy *Policy) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid signing certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %v", err)
+	}
+	if bundleJSON == "" {
+		return nil, fmt.Errorf("keyless signature has no transparency log entry")
+	}
+	var bundle rekorBundle
+	if err := json.Unmarshal([]byte(bundleJSON), &bundle); err != nil {
+		return nil, fmt.Errorf("invalid transparency log entry: %v", err)
+	}
+	if policy.RekorPublicKey == nil {
+		return nil, fmt.Errorf("keyless verification requires the Rekor public key")
+	}
+	if err := verifyBundle(&bundle, policy.RekorPublicKey); err != nil {
+		return nil, err
+	}
+	if err := verifyEntry(&bundle, cert, payload, signature); err != nil {
+		return nil, err
+	}
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM([]byte(chainPEM))
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         policy.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Unix(bundle.Payload.IntegratedTime, 0),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted signing certificate: %v", err)
+	}
+	var subjects []string
+	subjects = append(subjects, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	if !matchesAny(subjects, policy.Identities) {
+		return nil, fmt.Errorf("signing identity %v is not accepted", subjects)
+	}
+	if policy.Issuer != "" {
+		if issuer := certificateIssuer(cert); issuer != policy.Issuer {
+			return nil, fmt.Errorf("signing identity issuer '%s' is not accepted", issuer)
+		}
+	}
+	return cert.PublicKey, nil
+}
+
+// verifyBundle verifies the signed entry timestamp of the transparency log entry
+func verifyBundle(bundle *rekorBundle, key crypto.PublicKey) error {
+	signature, err := base64.StdEncoding.DecodeString(bundle.SignedEntryTimestamp)
+	if err != nil {
+		return fmt.Errorf("invalid signed entry timestamp")
+	}
+	// the timestamp signs the canonical JSON of the entry, which has sorted keys and no whitespace
+	canonical, err := json.Marshal(map[string]interface{}{
+		"body":           bundle.Payload.Body,
+		"integratedTime": bundle.Payload.IntegratedTime,
+		"logIndex":       bundle.Payload.LogIndex,
+		"logID":          bundle.Payload.LogID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := verifyWithKey(key, canonical, signature); err != nil {
+		return fmt.Errorf("invalid signed entry timestamp: %v", err)
+	}
+	return nil
+}
+
+// verifyEntry verifies that the body of the transparency log entry records the signature of the
+// payload made with the certificate
+func verifyEntry(bundle *rekorBundle, cert *x509.Certificate, payload, signature []byte) error {
+	encoded, ok := bundle.Payload.Body.(string)
+	if !ok {
+		return fmt.Errorf("invalid transparency log entry body")
+	}
+	body, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid transparency log entry body: %v", err)
+	}
+	var entry rekorEntry
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return fmt.Errorf("invalid transparency log entry body: %v", err)
+	}
+	sum := sha256.Sum256(payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(sum[:]) {
+		return fmt.Errorf("transparency log entry is not for the signed payload")
+	}
+	entrySignature, err := base64.StdEncoding.DecodeString(entry.Spec.Signature.Content)
+	if err != nil || !bytes.Equal(entrySignature, signature) {
+		return fmt.Errorf("transparency log entry is not for the signature")
+	}
+	entryCertPEM, err := base64.StdEncoding.DecodeString(entry.Spec.Signature.PublicKey.Content)
+	if err != nil {
+		return fmt.Errorf("transparency log entry is not for the signing certificate")
+	}
+	block, _ := pem.Decode(entryCertPEM)
+	if block == nil || !bytes.Equal(block.Bytes, cert.Raw) {
+		return fmt.Errorf("transparency log entry is not for the signing certificate")
+	}
+	return nil
+}
+
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidcIssuerV2OID):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(oidcIssuerOID):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+func matchesAny(values, accepted []string) bool {
+	for _, value := range values {
+		for _, a := range accepted {
+			if value == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyWithKey verifies the signature of the SHA-256 digest of the data
+func verifyWithKey(key crypto.PublicKey, data, signature []byte) error {
+	sum := sha256.Sum256(data)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+			return fmt.Errorf("invalid ECDSA signature: %v", err)
+		}
+		if !ecdsa.Verify(k, sum[:], sig.R, sig.S) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, data, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported public key type %T", key)
+}
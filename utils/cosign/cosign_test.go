@@ -0,0 +1,275 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) string {
+	sum := sha256.Sum256(data)
+	sig, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func publicKeyPEM(t *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// fakeRegistry serves the manifest of guestbook:v1 and its signatures, requiring a bearer token
+type fakeRegistry struct {
+	*httptest.Server
+	manifest []byte
+	blobs    map[string][]byte
+	// signatures are the annotations of the signature layers
+	signatures []map[string]string
+	payload    []byte
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{
+		manifest: []byte(`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json"}`),
+		blobs:    map[string][]byte{},
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			assert.Equal(t, "repository:argoproj/guestbook:pull", req.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "abc"}`))
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, r.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		digest := digestOf(r.manifest)
+		switch req.URL.Path {
+		case "/v2/argoproj/guestbook/manifests/v1":
+			_, _ = w.Write(r.manifest)
+		case "/v2/argoproj/guestbook/manifests/" + strings.Replace(digest, ":", "-", 1) + ".sig":
+			if len(r.signatures) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var layers []map[string]interface{}
+			for _, annotations := range r.signatures {
+				layers = append(layers, map[string]interface{}{"digest": digestOf(r.payload), "annotations": annotations})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"layers": layers})
+		case "/v2/argoproj/guestbook/blobs/" + digestOf(r.payload):
+			_, _ = w.Write(r.payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	r.payload = []byte(fmt.Sprintf(`{"critical": {"identity": {"docker-reference": "guestbook"}, "image": {"docker-manifest-digest": "%s"}, "type": "cosign container image signature"}}`, digestOf(r.manifest)))
+	return r
+}
+
+func (r *fakeRegistry) image() string {
+	return strings.TrimPrefix(r.URL, "http://") + "/argoproj/guestbook:v1"
+}
+
+func TestParseReference(t *testing.T) {
+	ref, err := parseReference("guestbook")
+	assert.NoError(t, err)
+	assert.Equal(t, reference{registry: "index.docker.io", repository: "library/guestbook", tag: "latest"}, *ref)
+
+	ref, err = parseReference("localhost:5000/argoproj/guestbook@sha256:abcd")
+	assert.NoError(t, err)
+	assert.Equal(t, reference{registry: "localhost:5000", repository: "argoproj/guestbook", digest: "sha256:abcd"}, *ref)
+
+	ref, err = parseReference("argoproj/guestbook:v1")
+	assert.NoError(t, err)
+	assert.Equal(t, reference{registry: "index.docker.io", repository: "argoproj/guestbook", tag: "v1"}, *ref)
+
+	_, err = parseReference("guestbook@md5:abcd")
+	assert.Error(t, err)
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	registry := newFakeRegistry(t)
+	defer registry.Close()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	policy, err := ParsePolicy(publicKeyPEM(t, key), "", nil, "", "")
+	assert.NoError(t, err)
+	verifier := NewVerifier()
+
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.EqualError(t, err, "no signatures found for "+digestOf(registry.manifest))
+
+	registry.signatures = []map[string]string{{signatureAnnotation: sign(t, other, registry.payload)}}
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "signature does not match any public key")
+
+	registry.signatures = append(registry.signatures, map[string]string{signatureAnnotation: sign(t, key, registry.payload)})
+	digest, err := verifier.Verify(registry.image(), policy, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, digestOf(registry.manifest), digest)
+
+	// the signature must be for the digest of the image
+	_, err = verifier.Verify(registry.image()+"@"+digestOf([]byte("other")), policy, nil)
+	assert.Error(t, err)
+}
+
+func TestParsePolicy(t *testing.T) {
+	_, err := ParsePolicy("", "", nil, "", "")
+	assert.EqualError(t, err, "no public keys or keyless identities are configured")
+	_, err = ParsePolicy("not a key", "", nil, "", "")
+	assert.Error(t, err)
+	_, err = ParsePolicy("", "", []string{"ci@example.com"}, "", "")
+	assert.EqualError(t, err, "keyless verification requires the PEM encoded root certificates")
+	root := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour), IsCA: true, BasicConstraintsValid: true}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rootDER, err := x509.CreateCertificate(rand.Reader, root, root, &key.PublicKey, key)
+	assert.NoError(t, err)
+	rootPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}))
+	_, err = ParsePolicy("", rootPEM, []string{"ci@example.com"}, "", "")
+	assert.EqualError(t, err, "keyless verification requires the Rekor public key")
+}
+
+func TestVerifyKeyless(t *testing.T) {
+	registry := newFakeRegistry(t)
+	defer registry.Close()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	issued := time.Now().Add(-time.Hour)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             issued.Add(-time.Hour),
+		NotAfter:              issued.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, root, root, &rootKey.PublicKey, rootKey)
+	assert.NoError(t, err)
+	root, err = x509.ParseCertificate(rootDER)
+	assert.NoError(t, err)
+
+	issuer, err := asn1.Marshal("https://token.actions.githubusercontent.com")
+	assert.NoError(t, err)
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leaf := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       issued,
+		NotAfter:        issued.Add(10 * time.Minute),
+		EmailAddresses:  []string{"ci@example.com"},
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{Id: oidcIssuerV2OID, Value: issuer}},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leaf, root, &signingKey.PublicKey, rootKey)
+	assert.NoError(t, err)
+
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	signature := sign(t, signingKey, registry.payload)
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}))
+	// newBundle returns a transparency log entry of the signature, signed by the transparency log
+	newBundle := func(signature string) string {
+		body, err := json.Marshal(map[string]interface{}{
+			"apiVersion": "0.0.1",
+			"kind":       "hashedrekord",
+			"spec": map[string]interface{}{
+				"data": map[string]interface{}{
+					"hash": map[string]string{"algorithm": "sha256", "value": strings.TrimPrefix(digestOf(registry.payload), "sha256:")},
+				},
+				"signature": map[string]interface{}{
+					"content":   signature,
+					"publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(certPEM))},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		entry := map[string]interface{}{
+			"body":           base64.StdEncoding.EncodeToString(body),
+			"integratedTime": issued.Add(time.Minute).Unix(),
+			"logIndex":       42,
+			"logID":          "c0d23d6a",
+		}
+		canonical, err := json.Marshal(entry)
+		assert.NoError(t, err)
+		bundle, err := json.Marshal(map[string]interface{}{"SignedEntryTimestamp": sign(t, rekorKey, canonical), "Payload": entry})
+		assert.NoError(t, err)
+		return string(bundle)
+	}
+	registry.signatures = []map[string]string{{
+		signatureAnnotation:   signature,
+		certificateAnnotation: certPEM,
+		bundleAnnotation:      newBundle(signature),
+	}}
+	rootPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}))
+	verifier := NewVerifier()
+
+	policy, err := ParsePolicy("", rootPEM, []string{"ci@example.com"}, "https://token.actions.githubusercontent.com", publicKeyPEM(t, rekorKey))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.NoError(t, err)
+
+	policy, err = ParsePolicy("", rootPEM, []string{"release@example.com"}, "", publicKeyPEM(t, rekorKey))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "signing identity [ci@example.com] is not accepted")
+
+	policy, err = ParsePolicy("", rootPEM, []string{"ci@example.com"}, "https://accounts.google.com", publicKeyPEM(t, rekorKey))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "issuer 'https://token.actions.githubusercontent.com' is not accepted")
+
+	otherRekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	policy, err = ParsePolicy("", rootPEM, []string{"ci@example.com"}, "", publicKeyPEM(t, otherRekorKey))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "invalid signed entry timestamp")
+
+	// the entry of another signature cannot be reused
+	registry.signatures[0][bundleAnnotation] = newBundle(sign(t, signingKey, registry.payload))
+	policy, err = ParsePolicy("", rootPEM, []string{"ci@example.com"}, "", publicKeyPEM(t, rekorKey))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "transparency log entry is not for the signature")
+	registry.signatures[0][bundleAnnotation] = newBundle(signature)
+
+	// keyless signatures are only accepted with keyless identities
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	policy, err = ParsePolicy(publicKeyPEM(t, key), "", nil, "", "")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(registry.image(), policy, nil)
+	assert.Contains(t, err.Error(), "keyless signatures are not accepted")
+}
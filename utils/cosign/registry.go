@@ -0,0 +1,244 @@
+package cosign
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	dockerHubRegistry = "index.docker.io"
+	dockerHubAPI      = "registry-1.docker.io"
+
+	// maxManifestSize bounds the manifests and signature payloads read from registries
+	maxManifestSize = 4 << 20
+)
+
+// manifestMediaTypes are the manifest types accepted when resolving the digest of an image
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// errNotFound is returned for manifests and blobs missing from the registry
+var errNotFound = fmt.Errorf("not found")
+
+// Credentials returns the username and password used to pull from the registry, or empty strings
+// for anonymous access
+type Credentials func(registry string) (username, password string)
+
+// reference is a parsed image reference
+type reference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseReference parses an image reference such as registry:5000/org/image:tag@sha256:abcd.
+// Images without a registry are pulled from Docker Hub.
+func parseReference(image string) (*reference, error) {
+	ref := &reference{}
+	if i := strings.Index(image, "@"); i >= 0 {
+		ref.digest = image[i+1:]
+		image = image[:i]
+		if !strings.HasPrefix(ref.digest, "sha256:") {
+			return nil, fmt.Errorf("unsupported digest '%s'", ref.digest)
+		}
+	}
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		ref.tag = image[i+1:]
+		image = image[:i]
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.registry = parts[0]
+		ref.repository = parts[1]
+	} else {
+		ref.registry = dockerHubRegistry
+		ref.repository = image
+	}
+	if ref.registry == "docker.io" {
+		ref.registry = dockerHubRegistry
+	}
+	if ref.registry == dockerHubRegistry && !strings.Contains(ref.repository, "/") {
+		ref.repository = "library/" + ref.repository
+	}
+	if ref.repository == "" {
+		return nil, fmt.Errorf("invalid image reference '%s'", image)
+	}
+	return ref, nil
+}
+
+// registryClient reads manifests and blobs with the registry API, authenticating with bearer
+// tokens or basic auth as requested by the registry
+type registryClient struct {
+	client      *http.Client
+	credentials Credentials
+	// tokens caches the bearer token of each repository
+	tokens map[string]string
+}
+
+func (c *registryClient) baseURL(registry string) string {
+	host := registry
+	if host == dockerHubRegistry {
+		host = dockerHubAPI
+	}
+	// like docker, registries on the loopback interface are accessed without TLS
+	if strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+		return "http://" + host
+	}
+	return "https://" + host
+}
+
+func (c *registryClient) get(ref *reference, path string, accept []string) (*http.Response, error) {
+	u := fmt.Sprintf("%s/v2/%s/%s", c.baseURL(ref.registry), ref.repository, path)
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		if token, ok := c.tokens[ref.registry+"/"+ref.repository]; ok {
+			req.Header.Set("Authorization", token)
+		}
+		return c.client.Do(req)
+	}
+	resp, err := do()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := c.authenticate(ref, challenge); err != nil {
+		return nil, err
+	}
+	return do()
+}
+
+// authenticate obtains the authorization for the repository requested by the challenge
+func (c *registryClient) authenticate(ref *reference, challenge string) error {
+	var username, password string
+	if c.credentials != nil {
+		username, password = c.credentials(ref.registry)
+	}
+	key := ref.registry + "/" + ref.repository
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return fmt.Errorf("registry '%s' requires credentials", ref.registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		c.tokens[key] = req.Header.Get("Authorization")
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported authentication challenge '%s' of registry '%s'", challenge, ref.registry)
+	}
+	query := url.Values{"scope": {fmt.Sprintf("repository:%s:pull", ref.repository)}}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("authentication with registry '%s' failed with status %s", ref.registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	c.tokens[key] = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	scheme := strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		for _, param := range strings.Split(parts[1], ",") {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 {
+				params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+		}
+	}
+	return scheme, params
+}
+
+// read reads a manifest or blob, verifying its content matches the digest if it is set
+func (c *registryClient) read(ref *reference, path, digest string, accept []string) ([]byte, string, error) {
+	resp, err := c.get(ref, path, accept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("reading %s/%s/%s failed with status %s", ref.registry, ref.repository, path, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxManifestSize {
+		return nil, "", fmt.Errorf("%s/%s/%s exceeds %d bytes", ref.registry, ref.repository, path, maxManifestSize)
+	}
+	sum := sha256.Sum256(data)
+	actual := "sha256:" + hex.EncodeToString(sum[:])
+	if digest != "" && digest != actual {
+		return nil, "", fmt.Errorf("content of %s/%s/%s does not match digest %s", ref.registry, ref.repository, path, digest)
+	}
+	return data, actual, nil
+}
+
+// resolveDigest returns the digest of the manifest of the image
+func (c *registryClient) resolveDigest(ref *reference) (string, error) {
+	if ref.digest != "" {
+		return ref.digest, nil
+	}
+	_, digest, err := c.read(ref, "manifests/"+ref.tag, "", manifestMediaTypes)
+	if err == errNotFound {
+		return "", fmt.Errorf("image %s/%s:%s not found", ref.registry, ref.repository, ref.tag)
+	}
+	return digest, err
+}
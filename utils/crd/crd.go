@@ -0,0 +1,110 @@
+package crd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	extensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// DefaultCRDDir is the directory of the controller image the CRD manifests are copied to
+	DefaultCRDDir = "/crds"
+	// ControllerVersionAnnotation records the version of the controller which last installed the CRD
+	ControllerVersionAnnotation = "rollouts.argoproj.io/controller-version"
+)
+
+// LoadCRDs reads the CRD manifests in the directory
+func LoadCRDs(dir string) ([]*extensionsv1beta1.CustomResourceDefinition, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var crds []*extensionsv1beta1.CustomResourceDefinition
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var crd extensionsv1beta1.CustomResourceDefinition
+		if err := yaml.Unmarshal(data, &crd); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		// skip other manifests (e.g. kustomization.yaml) living in the same directory
+		if crd.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		crds = append(crds, &crd)
+	}
+	return crds, nil
+}
+
+// isDowngrade returns true if the CRD was installed by a newer controller than the given version.
+// Versions which cannot be parsed (e.g. development builds) are never considered a downgrade.
+func isDowngrade(existing *extensionsv1beta1.CustomResourceDefinition, controllerVersion string) bool {
+	installedBy, ok := existing.Annotations[ControllerVersionAnnotation]
+	if !ok {
+		return false
+	}
+	installed, err := utilversion.ParseSemantic(installedBy)
+	if err != nil {
+		return false
+	}
+	current, err := utilversion.ParseSemantic(controllerVersion)
+	if err != nil {
+		return false
+	}
+	return current.LessThan(installed)
+}
+
+// Install creates the CRDs, or updates them if they already exist. A CRD installed by a newer
+// controller is left untouched so that rolling back the controller does not remove fields from the
+// schema that existing resources may use.
+func Install(client apiextensionsclient.CustomResourceDefinitionInterface, crds []*extensionsv1beta1.CustomResourceDefinition, controllerVersion string) error {
+	for _, crd := range crds {
+		logCtx := log.WithField("crd", crd.Name)
+		desired := crd.DeepCopy()
+		if desired.Annotations == nil {
+			desired.Annotations = map[string]string{}
+		}
+		desired.Annotations[ControllerVersionAnnotation] = controllerVersion
+
+		existing, err := client.Get(desired.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			if _, err := client.Create(desired); err != nil {
+				return fmt.Errorf("failed to create CRD %s: %v", desired.Name, err)
+			}
+			logCtx.Info("Created CRD")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if isDowngrade(existing, controllerVersion) {
+			logCtx.Warnf("CRD was installed by controller %s which is newer than %s. Skipping update", existing.Annotations[ControllerVersionAnnotation], controllerVersion)
+			continue
+		}
+		updated := existing.DeepCopy()
+		updated.Spec = desired.Spec
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		for k, v := range desired.Annotations {
+			updated.Annotations[k] = v
+		}
+		if _, err := client.Update(updated); err != nil {
+			return fmt.Errorf("failed to update CRD %s: %v", desired.Name, err)
+		}
+		logCtx.Info("Updated CRD")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package crd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const rolloutCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: rollouts.argoproj.io
+spec:
+  group: argoproj.io
+  names:
+    kind: Rollout
+    plural: rollouts
+  scope: Namespaced
+  version: v1alpha1
+`
+
+func newCRD(installedBy string) *extensionsv1beta1.CustomResourceDefinition {
+	crd := &extensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rollouts.argoproj.io",
+		},
+		Spec: extensionsv1beta1.CustomResourceDefinitionSpec{
+			Group: "argoproj.io",
+		},
+	}
+	if installedBy != "" {
+		crd.Annotations = map[string]string{ControllerVersionAnnotation: installedBy}
+	}
+	return crd
+}
+
+func TestLoadCRDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crds")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rollout-crd.yaml"), []byte(rolloutCRD), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\n"), 0644))
+
+	crds, err := LoadCRDs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, crds, 1)
+	assert.Equal(t, "rollouts.argoproj.io", crds[0].Name)
+	assert.Equal(t, "Rollout", crds[0].Spec.Names.Kind)
+}
+
+func TestInstallCreates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	crdIf := client.ApiextensionsV1beta1().CustomResourceDefinitions()
+	err := Install(crdIf, []*extensionsv1beta1.CustomResourceDefinition{newCRD("")}, "v0.8.0")
+	assert.NoError(t, err)
+	crd, err := crdIf.Get("rollouts.argoproj.io", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.8.0", crd.Annotations[ControllerVersionAnnotation])
+}
+
+func TestInstallUpgrades(t *testing.T) {
+	existing := newCRD("v0.7.0")
+	existing.Spec.Group = "old"
+	client := fake.NewSimpleClientset(existing)
+	crdIf := client.ApiextensionsV1beta1().CustomResourceDefinitions()
+	err := Install(crdIf, []*extensionsv1beta1.CustomResourceDefinition{newCRD("")}, "v0.8.0")
+	assert.NoError(t, err)
+	crd, err := crdIf.Get("rollouts.argoproj.io", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "argoproj.io", crd.Spec.Group)
+	assert.Equal(t, "v0.8.0", crd.Annotations[ControllerVersionAnnotation])
+}
+
+func TestInstallSkipsDowngrade(t *testing.T) {
+	existing := newCRD("v0.9.0")
+	existing.Spec.Group = "newer"
+	client := fake.NewSimpleClientset(existing)
+	crdIf := client.ApiextensionsV1beta1().CustomResourceDefinitions()
+	err := Install(crdIf, []*extensionsv1beta1.CustomResourceDefinition{newCRD("")}, "v0.8.0+abcdef1")
+	assert.NoError(t, err)
+	crd, err := crdIf.Get("rollouts.argoproj.io", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", crd.Spec.Group)
+	assert.Equal(t, "v0.9.0", crd.Annotations[ControllerVersionAnnotation])
+}
@@ -1,6 +1,10 @@
 package defaults
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -21,6 +25,10 @@ const (
 	DefaultScaleDownDelaySeconds = int32(30)
 	// DefaultAutoPromotionEnabled default value for auto promoting a blueGreen strategy
 	DefaultAutoPromotionEnabled = true
+	// DefaultSuccessfulRunHistoryLimit default number of old successful AnalysisRuns and Experiments to keep
+	DefaultSuccessfulRunHistoryLimit = int32(5)
+	// DefaultUnsuccessfulRunHistoryLimit default number of old unsuccessful AnalysisRuns and Experiments to keep
+	DefaultUnsuccessfulRunHistoryLimit = int32(5)
 )
 
 // GetReplicasOrDefault returns the deferenced number of replicas or the default number
@@ -39,6 +47,22 @@ func GetRevisionHistoryLimitOrDefault(rollout *v1alpha1.Rollout) int32 {
 	return *rollout.Spec.RevisionHistoryLimit
 }
 
+// GetSuccessfulRunHistoryLimitOrDefault returns the number of old successful AnalysisRuns and Experiments to keep
+func GetSuccessfulRunHistoryLimitOrDefault(rollout *v1alpha1.Rollout) int32 {
+	if rollout.Spec.Analysis == nil || rollout.Spec.Analysis.SuccessfulRunHistoryLimit == nil {
+		return DefaultSuccessfulRunHistoryLimit
+	}
+	return *rollout.Spec.Analysis.SuccessfulRunHistoryLimit
+}
+
+// GetUnsuccessfulRunHistoryLimitOrDefault returns the number of old unsuccessful AnalysisRuns and Experiments to keep
+func GetUnsuccessfulRunHistoryLimitOrDefault(rollout *v1alpha1.Rollout) int32 {
+	if rollout.Spec.Analysis == nil || rollout.Spec.Analysis.UnsuccessfulRunHistoryLimit == nil {
+		return DefaultUnsuccessfulRunHistoryLimit
+	}
+	return *rollout.Spec.Analysis.UnsuccessfulRunHistoryLimit
+}
+
 func GetMaxSurgeOrDefault(rollout *v1alpha1.Rollout) *intstr.IntOrString {
 	if rollout.Spec.Strategy.Canary != nil && rollout.Spec.Strategy.Canary.MaxSurge != nil {
 		return rollout.Spec.Strategy.Canary.MaxSurge
@@ -100,3 +124,19 @@ func GetAutoPromotionEnabledOrDefault(rollout *v1alpha1.Rollout) bool {
 	}
 	return *rollout.Spec.Strategy.BlueGreen.AutoPromotionEnabled
 }
+
+// Namespace returns the namespace the controller is running in
+func Namespace() string {
+	// This way assumes you've set the POD_NAMESPACE environment variable using the downward API.
+	// This check has to be done first for backwards compatibility with the way InClusterConfig was originally set up
+	if ns, ok := os.LookupEnv("POD_NAMESPACE"); ok {
+		return ns
+	}
+	// Fall back to the namespace associated with the service account token, if available
+	if data, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+		if ns := strings.TrimSpace(string(data)); len(ns) > 0 {
+			return ns
+		}
+	}
+	return "argo-rollouts"
+}
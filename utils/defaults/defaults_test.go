@@ -164,3 +164,22 @@ func TestGetExperimentProgressDeadlineSecondsOrDefault(t *testing.T) {
 	defaultValue := &v1alpha1.Experiment{}
 	assert.Equal(t, DefaultProgressDeadlineSeconds, GetExperimentProgressDeadlineSecondsOrDefault(defaultValue))
 }
+
+func TestGetRunHistoryLimitsOrDefault(t *testing.T) {
+	successfulLimit := int32(2)
+	unsuccessfulLimit := int32(3)
+	rolloutNonDefaultValue := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Analysis: &v1alpha1.AnalysisRunStrategy{
+				SuccessfulRunHistoryLimit:   &successfulLimit,
+				UnsuccessfulRunHistoryLimit: &unsuccessfulLimit,
+			},
+		},
+	}
+	assert.Equal(t, successfulLimit, GetSuccessfulRunHistoryLimitOrDefault(rolloutNonDefaultValue))
+	assert.Equal(t, unsuccessfulLimit, GetUnsuccessfulRunHistoryLimitOrDefault(rolloutNonDefaultValue))
+
+	rolloutDefaultValue := &v1alpha1.Rollout{}
+	assert.Equal(t, DefaultSuccessfulRunHistoryLimit, GetSuccessfulRunHistoryLimitOrDefault(rolloutDefaultValue))
+	assert.Equal(t, DefaultUnsuccessfulRunHistoryLimit, GetUnsuccessfulRunHistoryLimitOrDefault(rolloutDefaultValue))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	patchtypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/kubernetes/pkg/controller"
@@ -51,13 +52,31 @@ func IsTerminating(experiment *v1alpha1.Experiment) bool {
 
 func HasRequiredAnalysisRuns(ex *v1alpha1.Experiment) bool {
 	for _, analysis := range ex.Spec.Analyses {
-		if analysis.RequiredForCompletion {
+		if IsRequiredForCompletion(ex, analysis) {
 			return true
 		}
 	}
 	return false
 }
 
+// IsRequiredForCompletion returns whether the experiment completes once the analysis completes. An
+// experiment without a duration, which does not mark any analysis as required, completes once all
+// of its analyses complete.
+func IsRequiredForCompletion(ex *v1alpha1.Experiment, analysis v1alpha1.ExperimentAnalysisTemplateRef) bool {
+	if analysis.RequiredForCompletion {
+		return true
+	}
+	if ex.Spec.Duration != "" {
+		return false
+	}
+	for _, a := range ex.Spec.Analyses {
+		if a.RequiredForCompletion {
+			return false
+		}
+	}
+	return true
+}
+
 // RequiredAnalysisRunsSuccessful has at least one required for completition analysis run
 // and it completed successfully
 func RequiredAnalysisRunsSuccessful(ex *v1alpha1.Experiment, exStatus *v1alpha1.ExperimentStatus) bool {
@@ -67,7 +86,7 @@ func RequiredAnalysisRunsSuccessful(ex *v1alpha1.Experiment, exStatus *v1alpha1.
 	hasRequiredAnalysisRun := false
 	completedAllRequiredRuns := true
 	for _, analysis := range ex.Spec.Analyses {
-		if analysis.RequiredForCompletion {
+		if IsRequiredForCompletion(ex, analysis) {
 			hasRequiredAnalysisRun = true
 			analysisStatus := GetAnalysisRunStatus(*exStatus, analysis.Name)
 			if analysisStatus == nil || analysisStatus.Phase != v1alpha1.AnalysisPhaseSuccessful {
@@ -78,6 +97,28 @@ func RequiredAnalysisRunsSuccessful(ex *v1alpha1.Experiment, exStatus *v1alpha1.
 	return hasRequiredAnalysisRun && completedAllRequiredRuns
 }
 
+// ServiceNameForTemplate returns the name of the Service selecting the pods of the ReplicaSet of the
+// template, or an empty string if the template does not need a Service
+func ServiceNameForTemplate(template v1alpha1.TemplateSpec, rs *appsv1.ReplicaSet) string {
+	if rs == nil || (template.Service == nil && template.Weight == nil) {
+		return ""
+	}
+	if template.Service != nil && template.Service.Name != "" {
+		return template.Service.Name
+	}
+	return rs.Name
+}
+
+// PassedTTL indicates if the TTL of a finished experiment has expired, and otherwise when it expires
+func PassedTTL(experiment *v1alpha1.Experiment) (bool, time.Duration) {
+	if experiment.Spec.TTLSecondsAfterFinished == nil || experiment.Status.FinishedAt == nil {
+		return false, 0
+	}
+	expiredTime := experiment.Status.FinishedAt.Add(time.Duration(*experiment.Spec.TTLSecondsAfterFinished) * time.Second)
+	now := metav1.Now()
+	return !now.Before(expiredTime), expiredTime.Sub(now.Time)
+}
+
 // PassedDurations indicates if the experiment has run longer than the duration
 func PassedDurations(experiment *v1alpha1.Experiment) (bool, time.Duration) {
 	if experiment.Spec.Duration == "" {
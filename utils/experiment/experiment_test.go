@@ -8,6 +8,7 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubetesting "k8s.io/client-go/testing"
@@ -44,6 +45,37 @@ func TestCalculateTemplateReplicasCount(t *testing.T) {
 
 }
 
+func TestPassedTTL(t *testing.T) {
+	e := &v1alpha1.Experiment{}
+	passedTTL, _ := PassedTTL(e)
+	assert.False(t, passedTTL)
+
+	e.Spec.TTLSecondsAfterFinished = pointer.Int32Ptr(60)
+	passedTTL, _ = PassedTTL(e)
+	assert.False(t, passedTTL)
+
+	finishedAt := metav1.NewTime(metav1.Now().Add(-30 * time.Second))
+	e.Status.FinishedAt = &finishedAt
+	passedTTL, timeRemaining := PassedTTL(e)
+	assert.False(t, passedTTL)
+	assert.True(t, timeRemaining > 0 && timeRemaining <= 30*time.Second)
+
+	finishedAt = metav1.NewTime(metav1.Now().Add(-61 * time.Second))
+	passedTTL, _ = PassedTTL(e)
+	assert.True(t, passedTTL)
+}
+
+func TestServiceNameForTemplate(t *testing.T) {
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "foo-bar"}}
+	template := v1alpha1.TemplateSpec{Name: "bar"}
+	assert.Equal(t, "", ServiceNameForTemplate(template, rs))
+	template.Weight = pointer.Int32Ptr(10)
+	assert.Equal(t, "foo-bar", ServiceNameForTemplate(template, rs))
+	assert.Equal(t, "", ServiceNameForTemplate(template, nil))
+	template.Service = &v1alpha1.TemplateService{Name: "bar-svc"}
+	assert.Equal(t, "bar-svc", ServiceNameForTemplate(template, rs))
+}
+
 func TestPassedDurations(t *testing.T) {
 	e := &v1alpha1.Experiment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -373,6 +405,9 @@ func TestRequiredAnalysisRunsSuccessful(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "foo",
 		},
+		Spec: v1alpha1.ExperimentSpec{
+			Duration: "1m",
+		},
 	}
 	assert.False(t, RequiredAnalysisRunsSuccessful(e, nil))
 	assert.False(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
@@ -388,6 +423,19 @@ func TestRequiredAnalysisRunsSuccessful(t *testing.T) {
 	assert.False(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
 	e.Status.AnalysisRuns[0].Phase = v1alpha1.AnalysisPhaseSuccessful
 	assert.True(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
+
+	// without a duration, all analyses are required unless some are marked as required
+	e.Spec.Duration = ""
+	e.Spec.Analyses[0].RequiredForCompletion = false
+	assert.True(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
+	e.Spec.Analyses = append(e.Spec.Analyses, v1alpha1.ExperimentAnalysisTemplateRef{Name: "bar"})
+	e.Status.AnalysisRuns = append(e.Status.AnalysisRuns, v1alpha1.ExperimentAnalysisRunStatus{
+		Name:  "bar",
+		Phase: v1alpha1.AnalysisPhaseRunning,
+	})
+	assert.False(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
+	e.Spec.Analyses[0].RequiredForCompletion = true
+	assert.True(t, RequiredAnalysisRunsSuccessful(e, &e.Status))
 }
 
 func TestHasRequiredAnalysisRuns(t *testing.T) {
@@ -395,6 +443,9 @@ func TestHasRequiredAnalysisRuns(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "foo",
 		},
+		Spec: v1alpha1.ExperimentSpec{
+			Duration: "1m",
+		},
 	}
 	assert.False(t, HasRequiredAnalysisRuns(e))
 	e.Spec.Analyses = []v1alpha1.ExperimentAnalysisTemplateRef{{
@@ -403,4 +454,9 @@ func TestHasRequiredAnalysisRuns(t *testing.T) {
 	assert.False(t, HasRequiredAnalysisRuns(e))
 	e.Spec.Analyses[0].RequiredForCompletion = true
 	assert.True(t, HasRequiredAnalysisRuns(e))
+
+	// experiments without a duration complete after their analyses
+	e.Spec.Duration = ""
+	e.Spec.Analyses[0].RequiredForCompletion = false
+	assert.True(t, HasRequiredAnalysisRuns(e))
 }
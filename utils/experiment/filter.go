@@ -1,6 +1,8 @@
 package experiment
 
 import (
+	"sort"
+
 	appsv1 "k8s.io/api/apps/v1"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
@@ -103,3 +105,35 @@ func FilterExperimentsToDelete(exs []*v1alpha1.Experiment, olderRSs []*appsv1.Re
 	}
 	return exsToDelete
 }
+
+// FilterExperimentsExceedingHistoryLimits returns the completed experiments older than the most recent
+// successfulLimit successful experiments and unsuccessfulLimit unsuccessful experiments. Experiments which
+// are not completed are always retained.
+func FilterExperimentsExceedingHistoryLimits(exs []*v1alpha1.Experiment, successfulLimit, unsuccessfulLimit int32) []*v1alpha1.Experiment {
+	completedExs := []*v1alpha1.Experiment{}
+	for i := range exs {
+		ex := exs[i]
+		if ex != nil && ex.Status.Phase.Completed() {
+			completedExs = append(completedExs, ex)
+		}
+	}
+	sort.SliceStable(completedExs, func(i, j int) bool {
+		return completedExs[j].CreationTimestamp.Before(&completedExs[i].CreationTimestamp)
+	})
+	exsToDelete := []*v1alpha1.Experiment{}
+	successful, unsuccessful := int32(0), int32(0)
+	for _, ex := range completedExs {
+		if ex.Status.Phase == v1alpha1.AnalysisPhaseSuccessful {
+			successful++
+			if successful > successfulLimit {
+				exsToDelete = append(exsToDelete, ex)
+			}
+		} else {
+			unsuccessful++
+			if unsuccessful > unsuccessfulLimit {
+				exsToDelete = append(exsToDelete, ex)
+			}
+		}
+	}
+	return exsToDelete
+}
@@ -2,6 +2,7 @@ package experiment
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
@@ -117,3 +118,27 @@ func TestFilterExperimentsToDelete(t *testing.T) {
 	assert.Contains(t, filteredArs, exWithDeletedRS)
 	assert.Contains(t, filteredArs, exWithNoMatchingRS)
 }
+
+func TestFilterExperimentsExceedingHistoryLimits(t *testing.T) {
+	now := metav1.Now()
+	ex := func(name string, phase v1alpha1.AnalysisPhase, age int) *v1alpha1.Experiment {
+		return &v1alpha1.Experiment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-time.Duration(age) * time.Minute)),
+			},
+			Status: v1alpha1.ExperimentStatus{Phase: phase},
+		}
+	}
+	successful1 := ex("successful1", v1alpha1.AnalysisPhaseSuccessful, 1)
+	successful2 := ex("successful2", v1alpha1.AnalysisPhaseSuccessful, 2)
+	failed1 := ex("failed1", v1alpha1.AnalysisPhaseFailed, 3)
+	failed2 := ex("failed2", v1alpha1.AnalysisPhaseInconclusive, 4)
+	running := ex("running", v1alpha1.AnalysisPhaseRunning, 5)
+	exs := []*v1alpha1.Experiment{failed2, successful2, running, nil, successful1, failed1}
+
+	filteredExs := FilterExperimentsExceedingHistoryLimits(exs, 1, 1)
+	assert.Equal(t, []*v1alpha1.Experiment{successful2, failed2}, filteredExs)
+
+	assert.Len(t, FilterExperimentsExceedingHistoryLimits(exs, 5, 5), 0)
+}
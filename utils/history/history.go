@@ -0,0 +1,119 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+const (
+	// RolloutNameLabel is the label of revision history objects holding the name of their rollout
+	RolloutNameLabel = annotations.RolloutLabel + "/rollout-name"
+	// ChangeCauseAnnotation is the annotation recording why a rollout was changed, as set by
+	// `kubectl --record` or by users
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+	// DefaultRevisionHistoryLimit is the number of revision history objects kept per rollout
+	DefaultRevisionHistoryLimit = 25
+)
+
+// AnalysisOutcome is the result of an AnalysisRun run against a revision
+type AnalysisOutcome struct {
+	Name  string                 `json:"name"`
+	Type  string                 `json:"type,omitempty"`
+	Phase v1alpha1.AnalysisPhase `json:"phase"`
+}
+
+// Revision is a revision of a rollout. Revisions are stored in ControllerRevisions owned by the
+// rollout, so they outlive the ReplicaSet of the revision, which is deleted once the rollout's
+// revisionHistoryLimit is reached.
+type Revision struct {
+	Revision        int64                  `json:"revision"`
+	PodTemplateHash string                 `json:"podTemplateHash"`
+	Images          []string               `json:"images,omitempty"`
+	ChangeCause     string                 `json:"changeCause,omitempty"`
+	Created         metav1.Time            `json:"created"`
+	Completed       *metav1.Time           `json:"completed,omitempty"`
+	Aborted         bool                   `json:"aborted,omitempty"`
+	Analysis        []AnalysisOutcome      `json:"analysis,omitempty"`
+	Template        corev1.PodTemplateSpec `json:"template"`
+}
+
+// Name returns the name of the ControllerRevision storing the revision of the rollout
+func Name(rollout *v1alpha1.Rollout, podTemplateHash string) string {
+	return fmt.Sprintf("%s-%s", rollout.Name, podTemplateHash)
+}
+
+// NewControllerRevision returns the ControllerRevision storing the revision of the rollout
+func NewControllerRevision(rollout *v1alpha1.Rollout, revision *Revision) (*appsv1.ControllerRevision, error) {
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return nil, err
+	}
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name(rollout, revision.PodTemplateHash),
+			Namespace: rollout.Namespace,
+			Labels: map[string]string{
+				RolloutNameLabel:                      rollout.Name,
+				v1alpha1.DefaultRolloutUniqueLabelKey: revision.PodTemplateHash,
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rollout, v1alpha1.SchemeGroupVersion.WithKind("Rollout"))},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: revision.Revision,
+	}, nil
+}
+
+// FromControllerRevision returns the revision stored in the ControllerRevision
+func FromControllerRevision(cr *appsv1.ControllerRevision) (*Revision, error) {
+	var revision Revision
+	if err := json.Unmarshal(cr.Data.Raw, &revision); err != nil {
+		return nil, fmt.Errorf("invalid revision history '%s': %v", cr.Name, err)
+	}
+	return &revision, nil
+}
+
+// List returns the ControllerRevisions of the rollout ordered from the oldest to the newest revision
+func List(kubeclientset kubernetes.Interface, namespace, rolloutName string) ([]appsv1.ControllerRevision, error) {
+	selector := labels.SelectorFromSet(map[string]string{RolloutNameLabel: rolloutName})
+	list, err := kubeclientset.AppsV1().ControllerRevisions(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	revisions := list.Items
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// MergeAnalysis adds the outcomes to the revision, replacing earlier outcomes of the same
+// AnalysisRuns. Outcomes of AnalysisRuns which were since deleted are kept.
+func (r *Revision) MergeAnalysis(outcomes []AnalysisOutcome) {
+	for _, outcome := range outcomes {
+		found := false
+		for i := range r.Analysis {
+			if r.Analysis[i].Name == outcome.Name {
+				r.Analysis[i] = outcome
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Analysis = append(r.Analysis, outcome)
+		}
+	}
+	sort.SliceStable(r.Analysis, func(i, j int) bool {
+		return r.Analysis[i].Name < r.Analysis[j].Name
+	})
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func newRollout() *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "default", UID: "1234"},
+	}
+}
+
+func TestControllerRevisionRoundTrip(t *testing.T) {
+	ro := newRollout()
+	revision := &Revision{
+		Revision:        2,
+		PodTemplateHash: "abc123",
+		Images:          []string{"guestbook:v2"},
+		ChangeCause:     "update image",
+		Analysis:        []AnalysisOutcome{{Name: "guestbook-abc123-2", Phase: v1alpha1.AnalysisPhaseSuccessful}},
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "guestbook", Image: "guestbook:v2"}}},
+		},
+	}
+	cr, err := NewControllerRevision(ro, revision)
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook-abc123", cr.Name)
+	assert.Equal(t, int64(2), cr.Revision)
+	assert.Equal(t, "guestbook", cr.Labels[RolloutNameLabel])
+	assert.Equal(t, "abc123", cr.Labels[v1alpha1.DefaultRolloutUniqueLabelKey])
+	assert.True(t, *cr.OwnerReferences[0].Controller)
+
+	decoded, err := FromControllerRevision(cr)
+	assert.NoError(t, err)
+	assert.Equal(t, revision.Images, decoded.Images)
+	assert.Equal(t, revision.Analysis, decoded.Analysis)
+	assert.Equal(t, revision.Template, decoded.Template)
+
+	cr.Data.Raw = []byte("invalid")
+	_, err = FromControllerRevision(cr)
+	assert.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	ro := newRollout()
+	var objs []*Revision
+	for _, rev := range []int64{3, 1, 2} {
+		objs = append(objs, &Revision{Revision: rev, PodTemplateHash: string(rune('a' + rev))})
+	}
+	client := k8sfake.NewSimpleClientset()
+	for _, rev := range objs {
+		cr, err := NewControllerRevision(ro, rev)
+		assert.NoError(t, err)
+		_, err = client.AppsV1().ControllerRevisions("default").Create(cr)
+		assert.NoError(t, err)
+	}
+	other := newRollout()
+	other.Name = "other"
+	cr, _ := NewControllerRevision(other, &Revision{Revision: 1, PodTemplateHash: "x"})
+	_, err := client.AppsV1().ControllerRevisions("default").Create(cr)
+	assert.NoError(t, err)
+
+	revisions, err := List(client, "default", "guestbook")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 3)
+	for i, cr := range revisions {
+		assert.Equal(t, int64(i+1), cr.Revision)
+	}
+}
+
+func TestMergeAnalysis(t *testing.T) {
+	rev := &Revision{Analysis: []AnalysisOutcome{
+		{Name: "b", Phase: v1alpha1.AnalysisPhaseRunning},
+		{Name: "deleted", Phase: v1alpha1.AnalysisPhaseFailed},
+	}}
+	rev.MergeAnalysis([]AnalysisOutcome{
+		{Name: "b", Phase: v1alpha1.AnalysisPhaseSuccessful},
+		{Name: "a", Phase: v1alpha1.AnalysisPhaseRunning},
+	})
+	assert.Equal(t, []AnalysisOutcome{
+		{Name: "a", Phase: v1alpha1.AnalysisPhaseRunning},
+		{Name: "b", Phase: v1alpha1.AnalysisPhaseSuccessful},
+		{Name: "deleted", Phase: v1alpha1.AnalysisPhaseFailed},
+	}, rev.Analysis)
+}
@@ -1,7 +1,12 @@
 package log
 
 import (
+	"fmt"
+	"sync"
+
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 )
@@ -15,23 +20,99 @@ const (
 	AnalysisRunKey = "analysisrun"
 	// ServiceKey defines the key for the service field
 	ServiceKey = "service"
+	// DaemonSetKey defines the key for the daemonset field
+	DaemonSetKey = "daemonset"
 	// NamespaceKey defines the key for the namespace field
 	NamespaceKey = "namespace"
+	// RevisionKey defines the key for the revision field
+	RevisionKey = "revision"
+	// ReconcileIDKey defines the key for the reconcile id field
+	ReconcileIDKey = "reconcileID"
+
+	// revisionAnnotation mirrors annotations.RevisionAnnotation, which cannot be imported here
+	// without creating an import cycle
+	revisionAnnotation = "rollout.argoproj.io/revision"
+
+	// TextFormat is the default human readable log format
+	TextFormat = "text"
+	// JSONFormat emits one JSON object per log line
+	JSONFormat = "json"
 )
 
+// reconcileIDs holds the id of the reconciliation currently in progress for an object, keyed by
+// kind and namespace/name. The workqueue guarantees a key is never processed concurrently, so a
+// single entry per object is sufficient.
+var reconcileIDs sync.Map
+
+func reconcileIDMapKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// NewReconcileID generates a new random reconcile id
+func NewReconcileID() string {
+	return rand.String(10)
+}
+
+// SetReconcileID records the reconcile id for the object so that any logging context created
+// during the reconciliation carries the id
+func SetReconcileID(kind, namespace, name, id string) {
+	reconcileIDs.Store(reconcileIDMapKey(kind, namespace, name), id)
+}
+
+// ClearReconcileID removes the reconcile id of the object once the reconciliation finishes
+func ClearReconcileID(kind, namespace, name string) {
+	reconcileIDs.Delete(reconcileIDMapKey(kind, namespace, name))
+}
+
+// GetReconcileID returns the reconcile id in progress for the object or an empty string
+func GetReconcileID(kind, namespace, name string) string {
+	id, ok := reconcileIDs.Load(reconcileIDMapKey(kind, namespace, name))
+	if !ok {
+		return ""
+	}
+	return id.(string)
+}
+
+// withCorrelationFields adds the revision and reconcile id fields to the entry when they are known
+func withCorrelationFields(entry *log.Entry, kind, namespace, name string, annotations map[string]string) *log.Entry {
+	if revision, ok := annotations[revisionAnnotation]; ok {
+		entry = entry.WithField(RevisionKey, revision)
+	}
+	if id := GetReconcileID(kind, namespace, name); id != "" {
+		entry = entry.WithField(ReconcileIDKey, id)
+	}
+	return entry
+}
+
+// WithObject returns a logging context for an object known only by its workqueue key, such as
+// before it has been fetched from the lister
+func WithObject(kind, namespace, name string) *log.Entry {
+	entry := log.WithField(kind, name).WithField(NamespaceKey, namespace)
+	return withCorrelationFields(entry, kind, namespace, name, nil)
+}
+
 // WithRollout returns a logging context for Rollouts
 func WithRollout(rollout *v1alpha1.Rollout) *log.Entry {
-	return log.WithField(RolloutKey, rollout.Name).WithField(NamespaceKey, rollout.Namespace)
+	entry := log.WithField(RolloutKey, rollout.Name).WithField(NamespaceKey, rollout.Namespace)
+	return withCorrelationFields(entry, RolloutKey, rollout.Namespace, rollout.Name, rollout.Annotations)
 }
 
 // WithExperiment returns a logging context for Experiments
 func WithExperiment(experiment *v1alpha1.Experiment) *log.Entry {
-	return log.WithField(ExperimentKey, experiment.Name).WithField(NamespaceKey, experiment.Namespace)
+	entry := log.WithField(ExperimentKey, experiment.Name).WithField(NamespaceKey, experiment.Namespace)
+	return withCorrelationFields(entry, ExperimentKey, experiment.Namespace, experiment.Name, experiment.Annotations)
 }
 
 // WithAnalysisRun returns a logging context for AnalysisRun
 func WithAnalysisRun(ar *v1alpha1.AnalysisRun) *log.Entry {
-	return log.WithField(AnalysisRunKey, ar.Name).WithField(NamespaceKey, ar.Namespace)
+	entry := log.WithField(AnalysisRunKey, ar.Name).WithField(NamespaceKey, ar.Namespace)
+	return withCorrelationFields(entry, AnalysisRunKey, ar.Namespace, ar.Name, ar.Annotations)
+}
+
+// WithDaemonSet returns a logging context for DaemonSets
+func WithDaemonSet(ds *appsv1.DaemonSet) *log.Entry {
+	entry := log.WithField(DaemonSetKey, ds.Name).WithField(NamespaceKey, ds.Namespace)
+	return withCorrelationFields(entry, DaemonSetKey, ds.Namespace, ds.Name, nil)
 }
 
 // WithRedactor returns a log entry with the inputted secret values redacted
@@ -43,3 +124,17 @@ func WithRedactor(entry log.Entry, secrets []string) *log.Entry {
 	entry.Logger.SetFormatter(&newFormatter)
 	return &entry
 }
+
+// NewFormatter returns the logrus formatter for the given log format
+func NewFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case TextFormat:
+		return &log.TextFormatter{
+			FullTimestamp: true,
+		}, nil
+	case JSONFormat:
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format '%s'", format)
+	}
+}
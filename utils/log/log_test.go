@@ -107,3 +107,59 @@ func TestWithRedactorWithEmptySecret(t *testing.T) {
 	logMessage := buf.String()
 	assert.False(t, strings.Contains(logMessage, "*****"))
 }
+
+func TestWithRolloutCorrelationFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := log.New()
+	logger.SetOutput(buf)
+	ro := v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				"rollout.argoproj.io/revision": "3",
+			},
+		},
+	}
+	SetReconcileID(RolloutKey, "test-ns", "test-name", "abc123")
+	logCtx := WithRollout(&ro)
+	logCtx.Logger = logger
+	logCtx.Info("Test")
+	logMessage := buf.String()
+	assert.True(t, strings.Contains(logMessage, "revision=3"))
+	assert.True(t, strings.Contains(logMessage, "reconcileID=abc123"))
+
+	ClearReconcileID(RolloutKey, "test-ns", "test-name")
+	buf.Reset()
+	logCtx = WithRollout(&ro)
+	logCtx.Logger = logger
+	logCtx.Info("Test")
+	assert.False(t, strings.Contains(buf.String(), "reconcileID"))
+}
+
+func TestWithObject(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := log.New()
+	logger.SetOutput(buf)
+	SetReconcileID(AnalysisRunKey, "test-ns", "test-name", "abc123")
+	defer ClearReconcileID(AnalysisRunKey, "test-ns", "test-name")
+	logCtx := WithObject(AnalysisRunKey, "test-ns", "test-name")
+	logCtx.Logger = logger
+	logCtx.Info("Test")
+	logMessage := buf.String()
+	assert.True(t, strings.Contains(logMessage, "analysisrun=test-name"))
+	assert.True(t, strings.Contains(logMessage, "reconcileID=abc123"))
+}
+
+func TestNewFormatter(t *testing.T) {
+	formatter, err := NewFormatter(TextFormat)
+	assert.NoError(t, err)
+	assert.IsType(t, &log.TextFormatter{}, formatter)
+
+	formatter, err = NewFormatter(JSONFormat)
+	assert.NoError(t, err)
+	assert.IsType(t, &log.JSONFormatter{}, formatter)
+
+	_, err = NewFormatter("yaml")
+	assert.Error(t, err)
+}
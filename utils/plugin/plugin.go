@@ -0,0 +1,63 @@
+// Package plugin holds the helpers shared by the out-of-tree plugins of the controller, which run out
+// of process and are called over HTTP or a unix socket
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+const (
+	unixScheme = "unix://"
+	// RequestTimeout is how long the controller waits for the response of a plugin
+	RequestTimeout = 30 * time.Second
+)
+
+// Addresses returns the addresses of the plugins declared in the key of the controller configuration
+// by name. Plugins are declared as a comma separated list of <name>=<address> pairs.
+func Addresses(key string) (map[string]string, error) {
+	plugins := map[string]string{}
+	for _, plugin := range configutil.Get().GetStringSlice(key, nil) {
+		parts := strings.SplitN(plugin, "=", 2)
+		name, address := strings.TrimSpace(parts[0]), ""
+		if len(parts) == 2 {
+			address = strings.TrimSpace(parts[1])
+		}
+		if name == "" || address == "" {
+			return nil, fmt.Errorf("invalid plugin '%s' in %s: expected <name>=<address>", plugin, key)
+		}
+		plugins[name] = address
+	}
+	return plugins, nil
+}
+
+// NewClient returns a client for the address of a plugin, and the base URL of its methods. Addresses
+// starting with unix:// are the path of a unix socket, e.g. in a volume shared with a sidecar.
+func NewClient(address string) (*http.Client, string, error) {
+	if strings.HasPrefix(address, unixScheme) {
+		socket := strings.TrimPrefix(address, unixScheme)
+		if socket == "" {
+			return nil, "", errors.New("plugin unix socket path is empty")
+		}
+		dialer := &net.Dialer{}
+		return &http.Client{
+			Timeout: RequestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		}, "http://plugin", nil
+	}
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return nil, "", fmt.Errorf("plugin address '%s' must start with http://, https:// or unix://", address)
+	}
+	return &http.Client{Timeout: RequestTimeout}, strings.TrimSuffix(address, "/"), nil
+}
@@ -0,0 +1,181 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+	logutil "github.com/argoproj/argo-rollouts/utils/log"
+)
+
+// DefaultDecisionHistoryLimit is the number of decisions kept in the decision history annotation
+const DefaultDecisionHistoryLimit = 20
+
+// Decision is a single entry of a rollout's decision history
+type Decision struct {
+	Time    metav1.Time `json:"time"`
+	Type    string      `json:"type"`
+	Reason  string      `json:"reason"`
+	Message string      `json:"message"`
+}
+
+// GetDecisionHistory returns the decisions recorded on the rollout, oldest first
+func GetDecisionHistory(ro *v1alpha1.Rollout) ([]Decision, error) {
+	value, ok := ro.Annotations[annotations.DecisionHistoryAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	var history []Decision
+	if err := json.Unmarshal([]byte(value), &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// DecisionHistory holds the decisions recorded for rollouts until they are written to the decision
+// history annotation along with the rollout status
+type DecisionHistory interface {
+	// PendingHistory returns the decision history annotation of the rollout with the pending
+	// decisions appended, and the sequence number of the last pending decision it includes, or 0
+	// if there are none
+	PendingHistory(ro *v1alpha1.Rollout) (string, int64)
+	// ForgetDecisions drops the pending decisions of the rollout up to the sequence number once
+	// they are written, or all of them if the sequence number is negative
+	ForgetDecisions(ro *v1alpha1.Rollout, seq int64)
+}
+
+// pendingDecision is a decision which is not written yet, numbered in the order it was recorded so
+// it is forgotten once written even when older decisions were dropped in the meantime
+type pendingDecision struct {
+	Decision
+	seq int64
+}
+
+// AuditRecorder wraps an EventRecorder and additionally keeps every event emitted for a rollout in
+// the rollout's decision history annotation. Unlike events, which are garbage collected after an
+// hour, the history survives for the lifetime of the rollout and can be used to reconstruct what
+// the controller did during an incident. Decisions are only buffered when the event is recorded:
+// the rollout controller writes them with the next status patch of the rollout, so recording an
+// event never writes to the rollout in the middle of a reconciliation.
+type AuditRecorder struct {
+	record.EventRecorder
+
+	limit int
+	nowFn func() time.Time
+
+	lock    sync.Mutex
+	seq     int64
+	pending map[string][]pendingDecision
+}
+
+// NewAuditRecorder returns a recorder which keeps the last limit decisions on each rollout
+func NewAuditRecorder(recorder record.EventRecorder, limit int) *AuditRecorder {
+	return &AuditRecorder{
+		EventRecorder: recorder,
+		limit:         limit,
+		nowFn:         time.Now,
+		pending:       make(map[string][]pendingDecision),
+	}
+}
+
+// Event records the event and adds it to the pending decisions if the object is a rollout
+func (r *AuditRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, reason, message)
+	ro, ok := object.(*v1alpha1.Rollout)
+	if !ok {
+		return
+	}
+	decision := Decision{
+		Time:    metav1.NewTime(r.nowFn()),
+		Type:    eventtype,
+		Reason:  reason,
+		Message: message,
+	}
+	key := decisionKey(ro)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	pending := r.pending[key]
+	if len(pending) > 0 && sameDecision(pending[len(pending)-1].Decision, decision) {
+		return
+	}
+	r.seq++
+	pending = append(pending, pendingDecision{Decision: decision, seq: r.seq})
+	if len(pending) > r.limit {
+		pending = pending[len(pending)-r.limit:]
+	}
+	r.pending[key] = pending
+}
+
+// Eventf records the formatted event and adds it to the pending decisions if the object is a rollout
+func (r *AuditRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// PendingHistory returns the decision history annotation of the rollout with the pending decisions
+// appended, and the sequence number of the last pending decision it includes. Decisions repeating
+// the last decision of the history are not appended again, since the controller repeats a decision
+// every resync while it still holds.
+func (r *AuditRecorder) PendingHistory(ro *v1alpha1.Rollout) (string, int64) {
+	r.lock.Lock()
+	pending := append([]pendingDecision(nil), r.pending[decisionKey(ro)]...)
+	r.lock.Unlock()
+	current := ro.Annotations[annotations.DecisionHistoryAnnotation]
+	if len(pending) == 0 {
+		return current, 0
+	}
+	history, err := GetDecisionHistory(ro)
+	if err != nil {
+		log.Warnf("Discarding unparsable decision history of rollout '%s/%s': %v", ro.Namespace, ro.Name, err)
+		history = nil
+	}
+	for _, decision := range pending {
+		if len(history) > 0 && sameDecision(history[len(history)-1], decision.Decision) {
+			continue
+		}
+		history = append(history, decision.Decision)
+	}
+	if len(history) > r.limit {
+		history = history[len(history)-r.limit:]
+	}
+	value, err := json.Marshal(history)
+	if err != nil {
+		logutil.WithRollout(ro).Warnf("Failed to encode decision history: %v", err)
+		return current, 0
+	}
+	return string(value), pending[len(pending)-1].seq
+}
+
+// ForgetDecisions drops the pending decisions of the rollout up to the sequence number once they
+// are written. Decisions recorded after the history was computed stay pending. A negative sequence
+// number drops all the pending decisions, e.g. once the rollout is deleted.
+func (r *AuditRecorder) ForgetDecisions(ro *v1alpha1.Rollout, seq int64) {
+	key := decisionKey(ro)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	pending := r.pending[key]
+	i := 0
+	for i < len(pending) && pending[i].seq <= seq {
+		i++
+	}
+	if seq < 0 || i == len(pending) {
+		delete(r.pending, key)
+		return
+	}
+	r.pending[key] = pending[i:]
+}
+
+func decisionKey(ro *v1alpha1.Rollout) string {
+	return ro.Namespace + "/" + ro.Name
+}
+
+func sameDecision(a, b Decision) bool {
+	return a.Type == b.Type && a.Reason == b.Reason && a.Message == b.Message
+}
@@ -0,0 +1,93 @@
+package record
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+func TestAuditRecorder(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: "default",
+		},
+	}
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewAuditRecorder(fakeRecorder, 2)
+
+	history, pending := recorder.PendingHistory(ro)
+	assert.Equal(t, "", history)
+	assert.Equal(t, int64(0), pending)
+
+	recorder.Eventf(ro, corev1.EventTypeNormal, "SwitchService", "Switched selector for service '%s' to '%s'", "active", "abc")
+	recorder.Eventf(ro, corev1.EventTypeNormal, "SwitchService", "Switched selector for service '%s' to '%s'", "active", "abc")
+	recorder.Event(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled down replica set guestbook-def to 0")
+	// all events are still forwarded to the wrapped recorder
+	assert.Len(t, fakeRecorder.Events, 3)
+
+	history, pending = recorder.PendingHistory(ro)
+	assert.Equal(t, int64(2), pending)
+	ro.Annotations = map[string]string{annotations.DecisionHistoryAnnotation: history}
+	decisions, err := GetDecisionHistory(ro)
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "SwitchService", decisions[0].Reason)
+	assert.Equal(t, "ScalingReplicaSet", decisions[1].Reason)
+
+	// decisions recorded after the history was computed stay pending once it is written, even when
+	// the pending decisions were truncated to the limit in the meantime
+	recorder.Event(ro, corev1.EventTypeWarning, "RolloutAborted", "AnalysisRun 'guestbook-abc' completed with phase 'Failed'")
+	recorder.ForgetDecisions(ro, pending)
+	history, pending = recorder.PendingHistory(ro)
+	assert.Equal(t, int64(3), pending)
+	assert.Len(t, recorder.pending[decisionKey(ro)], 1)
+	ro.Annotations[annotations.DecisionHistoryAnnotation] = history
+	decisions, err = GetDecisionHistory(ro)
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "ScalingReplicaSet", decisions[0].Reason)
+	assert.Equal(t, "RolloutAborted", decisions[1].Reason)
+	assert.Equal(t, corev1.EventTypeWarning, decisions[1].Type)
+
+	// a decision repeating the last decision of the history is not appended again
+	recorder.ForgetDecisions(ro, pending)
+	recorder.Event(ro, corev1.EventTypeWarning, "RolloutAborted", "AnalysisRun 'guestbook-abc' completed with phase 'Failed'")
+	repeated, pending := recorder.PendingHistory(ro)
+	assert.Equal(t, int64(4), pending)
+	assert.Equal(t, history, repeated)
+
+	recorder.ForgetDecisions(ro, -1)
+	_, pending = recorder.PendingHistory(ro)
+	assert.Equal(t, int64(0), pending)
+}
+
+func TestAuditRecorderIgnoresOtherObjects(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewAuditRecorder(fakeRecorder, DefaultDecisionHistoryLimit)
+	ex := &v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: "default",
+		},
+	}
+	recorder.Event(ex, corev1.EventTypeNormal, "Running", "Experiment transitioned to Running")
+	assert.Len(t, fakeRecorder.Events, 1)
+	assert.Empty(t, recorder.pending)
+}
+
+func TestGetDecisionHistoryInvalid(t *testing.T) {
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{annotations.DecisionHistoryAnnotation: "not-json"},
+		},
+	}
+	_, err := GetDecisionHistory(ro)
+	assert.Error(t, err)
+}
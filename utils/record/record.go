@@ -0,0 +1,90 @@
+package record
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// DefaultDedupWindow is the period during which an identical event for the same object is
+	// only emitted once
+	DefaultDedupWindow = 5 * time.Minute
+	// DefaultAggregationMaxEvents is the number of similar events (same object and reason but a
+	// different message) tolerated before they are aggregated into a single event
+	DefaultAggregationMaxEvents = 5
+	// DefaultAggregationIntervalSeconds is the interval over which similar events are aggregated
+	DefaultAggregationIntervalSeconds = 600
+)
+
+// CorrelatorOptions returns the options given to the event broadcaster so that similar events
+// are aggregated more aggressively than the client-go defaults
+func CorrelatorOptions() record.CorrelatorOptions {
+	return record.CorrelatorOptions{
+		MaxEvents:            DefaultAggregationMaxEvents,
+		MaxIntervalInSeconds: DefaultAggregationIntervalSeconds,
+	}
+}
+
+// DedupRecorder wraps an EventRecorder and drops events which are identical to an event emitted
+// for the same object within the dedup window. This prevents the event stream from being flooded
+// by a rollout which re-emits the same event every resync.
+type DedupRecorder struct {
+	record.EventRecorder
+
+	window time.Duration
+	nowFn  func() time.Time
+
+	lock     sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// NewDedupRecorder returns a recorder which deduplicates identical events within the window
+func NewDedupRecorder(recorder record.EventRecorder, window time.Duration) *DedupRecorder {
+	return &DedupRecorder{
+		EventRecorder: recorder,
+		window:        window,
+		nowFn:         time.Now,
+		lastSeen:      make(map[string]time.Time),
+	}
+}
+
+// Event records an event unless an identical one was recently recorded
+func (r *DedupRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if !r.shouldRecord(object, eventtype, reason, message) {
+		return
+	}
+	r.EventRecorder.Event(object, eventtype, reason, message)
+}
+
+// Eventf records a formatted event unless an identical one was recently recorded
+func (r *DedupRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *DedupRecorder) shouldRecord(object runtime.Object, eventtype, reason, message string) bool {
+	acc, err := meta.Accessor(object)
+	if err != nil {
+		return true
+	}
+	key := fmt.Sprintf("%s/%s/%s/%s/%s/%s", acc.GetNamespace(), acc.GetName(), acc.GetUID(), eventtype, reason, message)
+	now := r.nowFn()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if last, ok := r.lastSeen[key]; ok && now.Sub(last) < r.window {
+		return false
+	}
+	r.lastSeen[key] = now
+	// prune expired entries so the map does not grow unbounded
+	for k, last := range r.lastSeen {
+		if now.Sub(last) >= r.window {
+			delete(r.lastSeen, k)
+		}
+	}
+	return true
+}
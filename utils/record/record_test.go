@@ -0,0 +1,43 @@
+package record
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func TestDedupRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewDedupRecorder(fakeRecorder, time.Minute)
+	now := time.Now()
+	recorder.nowFn = func() time.Time {
+		return now
+	}
+	ro := &v1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guestbook",
+			Namespace: "default",
+			UID:       "abc",
+		},
+	}
+
+	recorder.Eventf(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set %s to %d", "guestbook-abc", 1)
+	recorder.Eventf(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set %s to %d", "guestbook-abc", 1)
+	assert.Len(t, fakeRecorder.Events, 1)
+
+	// a different message is not a duplicate
+	recorder.Eventf(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set %s to %d", "guestbook-abc", 2)
+	assert.Len(t, fakeRecorder.Events, 2)
+
+	// the same event is emitted again after the window
+	now = now.Add(time.Minute)
+	recorder.Eventf(ro, corev1.EventTypeNormal, "ScalingReplicaSet", "Scaled up replica set %s to %d", "guestbook-abc", 1)
+	assert.Len(t, fakeRecorder.Events, 3)
+	assert.Len(t, recorder.lastSeen, 1)
+}
@@ -0,0 +1,119 @@
+package replicaset
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// AntiAffinityTopologyKey is the topology of the anti-affinity injected into the pods of a new
+// ReplicaSet, so they are not scheduled on the nodes of the pods of the stable ReplicaSet
+const AntiAffinityTopologyKey = "kubernetes.io/hostname"
+
+// GetRolloutAntiAffinity returns the anti-affinity of the strategy of the rollout
+func GetRolloutAntiAffinity(rollout *v1alpha1.Rollout) *v1alpha1.AntiAffinity {
+	if rollout.Spec.Strategy.BlueGreen != nil {
+		return rollout.Spec.Strategy.BlueGreen.AntiAffinity
+	}
+	if rollout.Spec.Strategy.Canary != nil {
+		return rollout.Spec.Strategy.Canary.AntiAffinity
+	}
+	return nil
+}
+
+// GetStablePodHash returns the pod template hash of the stable ReplicaSet of the rollout: the active
+// ReplicaSet of a blue-green rollout, or the stable ReplicaSet of a canary
+func GetStablePodHash(rollout *v1alpha1.Rollout) string {
+	if rollout.Spec.Strategy.BlueGreen != nil {
+		return rollout.Status.BlueGreen.ActiveSelector
+	}
+	return rollout.Status.Canary.StableRS
+}
+
+// GenerateReplicaSetAffinity returns the affinity of the pods of the ReplicaSet with the pod template
+// hash. When the strategy of the rollout sets an anti-affinity and the ReplicaSet is not the stable
+// ReplicaSet, a term against the pods of the stable ReplicaSet is added to the affinity of the pod
+// template of the rollout.
+func GenerateReplicaSetAffinity(rollout *v1alpha1.Rollout, podHash string) *corev1.Affinity {
+	affinity := rollout.Spec.Template.Spec.Affinity.DeepCopy()
+	antiAffinity := GetRolloutAntiAffinity(rollout)
+	stableHash := GetStablePodHash(rollout)
+	if antiAffinity == nil || stableHash == "" || stableHash == podHash {
+		return affinity
+	}
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      v1alpha1.DefaultRolloutUniqueLabelKey,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{stableHash},
+			}},
+		},
+		TopologyKey: AntiAffinityTopologyKey,
+	}
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	if preferred := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution; preferred != nil {
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          preferred.Weight,
+			PodAffinityTerm: term,
+		})
+	} else if antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	}
+	return affinity
+}
+
+// isInjectedAntiAffinityTerm returns true if the term is an anti-affinity against the pods of a
+// ReplicaSet of a rollout, injected by GenerateReplicaSetAffinity
+func isInjectedAntiAffinityTerm(term corev1.PodAffinityTerm) bool {
+	if term.TopologyKey != AntiAffinityTopologyKey || term.LabelSelector == nil || len(term.LabelSelector.MatchLabels) > 0 {
+		return false
+	}
+	expressions := term.LabelSelector.MatchExpressions
+	return len(expressions) == 1 && expressions[0].Key == v1alpha1.DefaultRolloutUniqueLabelKey && expressions[0].Operator == metav1.LabelSelectorOpIn
+}
+
+// RemoveInjectedAntiAffinityRule returns a copy of the affinity without the anti-affinity terms
+// injected by the controller, which is nil if the affinity only held injected terms
+func RemoveInjectedAntiAffinityRule(affinity *corev1.Affinity) *corev1.Affinity {
+	affinity = affinity.DeepCopy()
+	if affinity == nil || affinity.PodAntiAffinity == nil {
+		return affinity
+	}
+	antiAffinity := affinity.PodAntiAffinity
+	removed := false
+	var preferred []corev1.WeightedPodAffinityTerm
+	for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if isInjectedAntiAffinityTerm(term.PodAffinityTerm) {
+			removed = true
+			continue
+		}
+		preferred = append(preferred, term)
+	}
+	var required []corev1.PodAffinityTerm
+	for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		if isInjectedAntiAffinityTerm(term) {
+			removed = true
+			continue
+		}
+		required = append(required, term)
+	}
+	if !removed {
+		return affinity
+	}
+	antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = preferred
+	antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	if len(preferred) == 0 && len(required) == 0 {
+		affinity.PodAntiAffinity = nil
+	}
+	if affinity.NodeAffinity == nil && affinity.PodAffinity == nil && affinity.PodAntiAffinity == nil {
+		return nil
+	}
+	return affinity
+}
@@ -0,0 +1,125 @@
+package replicaset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1defaults "k8s.io/kubernetes/pkg/apis/core/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+func antiAffinityRollout(antiAffinity *v1alpha1.AntiAffinity) *v1alpha1.Rollout {
+	return &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{AntiAffinity: antiAffinity},
+			},
+		},
+		Status: v1alpha1.RolloutStatus{
+			Canary: v1alpha1.CanaryStatus{StableRS: "stable"},
+		},
+	}
+}
+
+func TestGenerateReplicaSetAffinity(t *testing.T) {
+	nodeAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+			}},
+		},
+	}
+	expectedTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      v1alpha1.DefaultRolloutUniqueLabelKey,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"stable"},
+			}},
+		},
+		TopologyKey: AntiAffinityTopologyKey,
+	}
+
+	// no anti-affinity keeps the affinity of the template
+	ro := antiAffinityRollout(nil)
+	assert.Nil(t, GenerateReplicaSetAffinity(ro, "canary"))
+	ro.Spec.Template.Spec.Affinity = &corev1.Affinity{NodeAffinity: nodeAffinity}
+	assert.Equal(t, ro.Spec.Template.Spec.Affinity, GenerateReplicaSetAffinity(ro, "canary"))
+
+	ro = antiAffinityRollout(&v1alpha1.AntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: &v1alpha1.PreferredDuringSchedulingIgnoredDuringExecution{Weight: 40},
+	})
+	ro.Spec.Template.Spec.Affinity = &corev1.Affinity{NodeAffinity: nodeAffinity}
+	affinity := GenerateReplicaSetAffinity(ro, "canary")
+	assert.Equal(t, nodeAffinity, affinity.NodeAffinity)
+	assert.Equal(t, []corev1.WeightedPodAffinityTerm{{Weight: 40, PodAffinityTerm: expectedTerm}}, affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	assert.Nil(t, ro.Spec.Template.Spec.Affinity.PodAntiAffinity)
+	// the stable ReplicaSet has no anti-affinity against itself
+	assert.Equal(t, ro.Spec.Template.Spec.Affinity, GenerateReplicaSetAffinity(ro, "stable"))
+
+	ro = antiAffinityRollout(&v1alpha1.AntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+	})
+	affinity = GenerateReplicaSetAffinity(ro, "canary")
+	assert.Equal(t, []corev1.PodAffinityTerm{expectedTerm}, affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+
+	// a rollout without a stable ReplicaSet has no anti-affinity
+	ro.Status.Canary.StableRS = ""
+	assert.Nil(t, GenerateReplicaSetAffinity(ro, "canary"))
+
+	ro = &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				BlueGreen: &v1alpha1.BlueGreenStrategy{AntiAffinity: &v1alpha1.AntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+				}},
+			},
+		},
+		Status: v1alpha1.RolloutStatus{
+			BlueGreen: v1alpha1.BlueGreenStatus{ActiveSelector: "stable"},
+		},
+	}
+	affinity = GenerateReplicaSetAffinity(ro, "preview")
+	assert.Equal(t, []corev1.PodAffinityTerm{expectedTerm}, affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+}
+
+func TestRemoveInjectedAntiAffinityRule(t *testing.T) {
+	assert.Nil(t, RemoveInjectedAntiAffinityRule(nil))
+
+	ro := antiAffinityRollout(&v1alpha1.AntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+	})
+	assert.Nil(t, RemoveInjectedAntiAffinityRule(GenerateReplicaSetAffinity(ro, "canary")))
+
+	userTerm := corev1.WeightedPodAffinityTerm{
+		Weight: 10,
+		PodAffinityTerm: corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+			TopologyKey:   AntiAffinityTopologyKey,
+		},
+	}
+	ro.Spec.Template.Spec.Affinity = &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{userTerm},
+		},
+	}
+	affinity := GenerateReplicaSetAffinity(ro, "canary")
+	assert.Len(t, affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Equal(t, ro.Spec.Template.Spec.Affinity, RemoveInjectedAntiAffinityRule(affinity))
+	assert.Equal(t, ro.Spec.Template.Spec.Affinity, RemoveInjectedAntiAffinityRule(ro.Spec.Template.Spec.Affinity))
+}
+
+func TestPodTemplateEqualIgnoreAntiAffinity(t *testing.T) {
+	ro := antiAffinityRollout(&v1alpha1.AntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1alpha1.RequiredDuringSchedulingIgnoredDuringExecution{},
+	})
+	podTemplate := corev1.PodTemplate{Template: *ro.Spec.Template.DeepCopy()}
+	corev1defaults.SetObjectDefaults_PodTemplate(&podTemplate)
+	live := podTemplate.Template
+	assert.True(t, PodTemplateEqualIgnoreHash(&live, &ro.Spec.Template))
+	live.Spec.Affinity = GenerateReplicaSetAffinity(ro, "canary")
+	assert.True(t, PodTemplateEqualIgnoreHash(&live, &ro.Spec.Template))
+}
@@ -30,6 +30,24 @@ func GetReplicaSetByTemplateHash(allRS []*appsv1.ReplicaSet, podTemplateHash str
 	return filterRS, otherRSs
 }
 
+// GetPreviousActiveReplicaSet returns the ReplicaSet the active service pointed to before the ReplicaSet of
+// the active selector, i.e. the ReplicaSet with the highest revision which was given a scale down deadline
+func GetPreviousActiveReplicaSet(rollout *v1alpha1.Rollout, allRSs []*appsv1.ReplicaSet, activeSelector string) *appsv1.ReplicaSet {
+	_, otherRSs := GetReplicaSetByTemplateHash(allRSs, activeSelector)
+	var previousActiveRS *appsv1.ReplicaSet
+	previousRevision := -1
+	for _, rs := range otherRSs {
+		if _, ok := rs.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey]; !ok {
+			continue
+		}
+		if revision := GetReplicaSetRevision(rollout, rs); revision > previousRevision {
+			previousActiveRS = rs
+			previousRevision = revision
+		}
+	}
+	return previousActiveRS
+}
+
 func ReadyForPause(rollout *v1alpha1.Rollout, newRS *appsv1.ReplicaSet, allRSs []*appsv1.ReplicaSet) bool {
 	newRSReplicaCount, err := NewRSNewReplicas(rollout, allRSs, newRS)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
 )
 
 func TestGetReplicaSetByTemplateHash(t *testing.T) {
@@ -35,6 +36,31 @@ func TestGetReplicaSetByTemplateHash(t *testing.T) {
 	assert.Equal(t, nonActiveRSs[0], rs1)
 }
 
+func TestGetPreviousActiveReplicaSet(t *testing.T) {
+	newRS := func(podHash, revision string, scaleDownDeadline bool) *appsv1.ReplicaSet {
+		rs := &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        podHash,
+				Labels:      map[string]string{v1alpha1.DefaultRolloutUniqueLabelKey: podHash},
+				Annotations: map[string]string{annotations.RevisionAnnotation: revision},
+			},
+		}
+		if scaleDownDeadline {
+			rs.Annotations[v1alpha1.DefaultReplicaSetScaleDownDeadlineAnnotationKey] = "2020-01-01T00:00:00Z"
+		}
+		return rs
+	}
+	rollout := &v1alpha1.Rollout{}
+	rs1 := newRS("rs1", "1", true)
+	rs2 := newRS("rs2", "2", true)
+	rs3 := newRS("rs3", "3", false)
+	rs4 := newRS("rs4", "4", false)
+
+	assert.Nil(t, GetPreviousActiveReplicaSet(rollout, []*appsv1.ReplicaSet{rs3, rs4}, "rs4"))
+	assert.Equal(t, rs2, GetPreviousActiveReplicaSet(rollout, []*appsv1.ReplicaSet{rs1, rs2, rs3, rs4}, "rs4"))
+	assert.Equal(t, rs1, GetPreviousActiveReplicaSet(rollout, []*appsv1.ReplicaSet{rs1, rs2, rs3}, "rs2"))
+}
+
 func TestReadyForPause(t *testing.T) {
 	rollout := &v1alpha1.Rollout{
 		Spec: v1alpha1.RolloutSpec{
@@ -41,6 +41,12 @@ func DesiredReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS, stableRS *a
 	// based canary leaves the stable as 100% scaled until the rollout completes.
 	if rollout.Spec.Strategy.Canary.TrafficRouting != nil {
 		desiredStableRSReplicaCount = rolloutSpecReplica
+		desiredNewRSReplicaCount = routedCanaryReplicaCount(rollout, desiredNewRSReplicaCount)
+	}
+	// A partitioned canary never runs more pods than replicas, so the pods of the new RS replace
+	// pods of the stable RS instead of both counts being rounded up.
+	if rollout.Spec.Strategy.Canary.Partition != nil {
+		desiredStableRSReplicaCount = rolloutSpecReplica - desiredNewRSReplicaCount
 	}
 
 	return desiredNewRSReplicaCount, desiredStableRSReplicaCount
@@ -89,7 +95,10 @@ func CalculateReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS *appsv1.Re
 	desiredNewRSReplicaCount := int32(math.Ceil(float64(rolloutSpecReplica) * (float64(setWeight) / 100)))
 
 	if rollout.Spec.Strategy.Canary.TrafficRouting != nil {
-		return desiredNewRSReplicaCount, rolloutSpecReplica
+		return routedCanaryReplicaCount(rollout, desiredNewRSReplicaCount), rolloutSpecReplica
+	}
+	if rollout.Spec.Strategy.Canary.Partition != nil {
+		desiredStableRSReplicaCount = rolloutSpecReplica - desiredNewRSReplicaCount
 	}
 
 	stableRSReplicaCount := int32(0)
@@ -110,7 +119,7 @@ func CalculateReplicaCountsForCanary(rollout *v1alpha1.Rollout, newRS *appsv1.Re
 
 	maxSurge := MaxSurge(rollout)
 
-	if extraReplicaAdded(rolloutSpecReplica, setWeight) {
+	if extraReplicaAdded(rolloutSpecReplica, setWeight) && rollout.Spec.Strategy.Canary.Partition == nil {
 		// In the case where the weight of the stable and canary replica counts cannot be divided evenly,
 		// the controller needs to surges by one to account for both replica counts being rounded up.
 		maxSurge = maxSurge + 1
@@ -258,6 +267,100 @@ func GetCurrentCanaryStep(rollout *v1alpha1.Rollout) (*v1alpha1.CanaryStep, *int
 	return &rollout.Spec.Strategy.Canary.Steps[currentStepIndex], &currentStepIndex
 }
 
+// GetCurrentHeaderRoutes returns the routes of the setHeaderRoute steps the rollout reached, in the
+// order of the steps. A later step replaces the route of an earlier step with the same name, and
+// removes it if it has no matches. There are no routes once the rollout completed the steps or is aborted.
+func GetCurrentHeaderRoutes(rollout *v1alpha1.Rollout) []v1alpha1.SetHeaderRoute {
+	if rollout.Status.Abort {
+		return nil
+	}
+	currentStep, currentStepIndex := GetCurrentCanaryStep(rollout)
+	if currentStep == nil {
+		return nil
+	}
+	var names []string
+	routes := map[string]v1alpha1.SetHeaderRoute{}
+	for _, step := range rollout.Spec.Strategy.Canary.Steps[:*currentStepIndex+1] {
+		if step.SetHeaderRoute == nil {
+			continue
+		}
+		if _, ok := routes[step.SetHeaderRoute.Name]; !ok {
+			names = append(names, step.SetHeaderRoute.Name)
+		}
+		routes[step.SetHeaderRoute.Name] = *step.SetHeaderRoute
+	}
+	var headerRoutes []v1alpha1.SetHeaderRoute
+	for _, name := range names {
+		if len(routes[name].Match) > 0 {
+			headerRoutes = append(headerRoutes, routes[name])
+		}
+	}
+	return headerRoutes
+}
+
+// GetCurrentMirrorPercentage returns the percentage of the latest setMirrorRoute step the rollout
+// reached. Requests are no longer mirrored once the rollout completed the steps or is aborted.
+func GetCurrentMirrorPercentage(rollout *v1alpha1.Rollout) int32 {
+	if rollout.Status.Abort {
+		return 0
+	}
+	currentStep, currentStepIndex := GetCurrentCanaryStep(rollout)
+	if currentStep == nil {
+		return 0
+	}
+	for i := *currentStepIndex; i >= 0; i-- {
+		if step := rollout.Spec.Strategy.Canary.Steps[i]; step.SetMirrorRoute != nil {
+			return step.SetMirrorRoute.Percentage
+		}
+	}
+	return 0
+}
+
+// GetCurrentSetCanaryScale returns the scale of the latest setCanaryScale step the rollout reached.
+// Returns nil once the rollout completed the steps or is aborted, or if the latest step matches the
+// canary weight.
+func GetCurrentSetCanaryScale(rollout *v1alpha1.Rollout) *v1alpha1.SetCanaryScale {
+	if rollout.Status.Abort {
+		return nil
+	}
+	currentStep, currentStepIndex := GetCurrentCanaryStep(rollout)
+	if currentStep == nil {
+		return nil
+	}
+	for i := *currentStepIndex; i >= 0; i-- {
+		if scale := rollout.Spec.Strategy.Canary.Steps[i].SetCanaryScale; scale != nil {
+			if scale.MatchTrafficWeight {
+				return nil
+			}
+			return scale
+		}
+	}
+	return nil
+}
+
+// routedCanaryReplicaCount returns the replicas of the canary of a rollout with traffic routing. The
+// scale of a setCanaryScale step takes precedence over the canary weight. Otherwise, a canary pod
+// keeps running while header routes or mirrored requests are sent to the canary, even when the
+// canary weight is 0.
+func routedCanaryReplicaCount(rollout *v1alpha1.Rollout, desiredNewRSReplicaCount int32) int32 {
+	if scale := GetCurrentSetCanaryScale(rollout); scale != nil {
+		if scale.Replicas != nil {
+			return *scale.Replicas
+		}
+		if scale.Weight != nil {
+			rolloutSpecReplica := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
+			return int32(math.Ceil(float64(rolloutSpecReplica) * (float64(*scale.Weight) / 100)))
+		}
+	}
+	if desiredNewRSReplicaCount > 0 || defaults.GetReplicasOrDefault(rollout.Spec.Replicas) == 0 {
+		return desiredNewRSReplicaCount
+	}
+	if len(GetCurrentHeaderRoutes(rollout)) > 0 || GetCurrentMirrorPercentage(rollout) > 0 {
+		return 1
+	}
+	return desiredNewRSReplicaCount
+}
+
 // GetCurrentSetWeight grabs the current setWeight used by the rollout by iterating backwards from the current step
 // until it finds a setWeight step. The controller defaults to 100 if it iterates through all the steps with no
 // setWeight or if there is no current step (i.e. the controller has already stepped through all the steps).
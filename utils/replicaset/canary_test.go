@@ -362,6 +362,22 @@ func TestCalculateReplicaCountsForCanary(t *testing.T) {
 			expectedStableReplicaCount: 9,
 			expectedCanaryReplicaCount: 0,
 		},
+		{
+			name:                "Scale up newRS and stable to the weight when the replicas are increased mid-update",
+			rolloutSpecReplicas: 20,
+			setWeight:           50,
+			maxSurge:            intstr.FromInt(1),
+			maxUnavailable:      intstr.FromInt(0),
+
+			stableSpecReplica:      5,
+			stableAvailableReplica: 5,
+
+			canarySpecReplica:      5,
+			canaryAvailableReplica: 5,
+
+			expectedStableReplicaCount: 10,
+			expectedCanaryReplicaCount: 10,
+		},
 	}
 	for i := range tests {
 		test := tests[i]
@@ -386,6 +402,95 @@ func TestCalculateReplicaCountsForCanaryTrafficRouting(t *testing.T) {
 	assert.Equal(t, int32(10), stableRSReplicaCount)
 }
 
+func TestCalculateReplicaCountsForCanaryHeaderRoute(t *testing.T) {
+	rollout := newRollout(10, 0, intstr.FromInt(0), intstr.FromInt(1), "canary", "stable")
+	rollout.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	rollout.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{{
+		SetHeaderRoute: &v1alpha1.SetHeaderRoute{
+			Name:  "testers",
+			Match: []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}},
+		},
+	}}
+	stableRS := newRS("stable", 10, 10)
+	newRS := newRS("canary", 0, 0)
+	newRSReplicaCount, stableRSReplicaCount := CalculateReplicaCountsForCanary(rollout, newRS, stableRS, nil)
+	assert.Equal(t, int32(1), newRSReplicaCount)
+	assert.Equal(t, int32(10), stableRSReplicaCount)
+	newRSReplicaCount, stableRSReplicaCount = DesiredReplicaCountsForCanary(rollout, newRS, stableRS)
+	assert.Equal(t, int32(1), newRSReplicaCount)
+	assert.Equal(t, int32(10), stableRSReplicaCount)
+}
+
+func TestCalculateReplicaCountsForCanaryMirrorRoute(t *testing.T) {
+	rollout := newRollout(10, 0, intstr.FromInt(0), intstr.FromInt(1), "canary", "stable")
+	rollout.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	rollout.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{{
+		SetMirrorRoute: &v1alpha1.SetMirrorRoute{Percentage: 20},
+	}}
+	newRSReplicaCount, stableRSReplicaCount := CalculateReplicaCountsForCanary(rollout, newRS("canary", 0, 0), newRS("stable", 10, 10), nil)
+	assert.Equal(t, int32(1), newRSReplicaCount)
+	assert.Equal(t, int32(10), stableRSReplicaCount)
+}
+
+func TestCalculateReplicaCountsForCanarySetCanaryScale(t *testing.T) {
+	rollout := newRollout(10, 5, intstr.FromInt(0), intstr.FromInt(1), "canary", "stable")
+	rollout.Spec.Strategy.Canary.TrafficRouting = &v1alpha1.RolloutTrafficRouting{}
+	rollout.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{
+		{SetCanaryScale: &v1alpha1.SetCanaryScale{Weight: pointer.Int32Ptr(100)}},
+		{SetWeight: pointer.Int32Ptr(5)},
+		{SetCanaryScale: &v1alpha1.SetCanaryScale{Replicas: pointer.Int32Ptr(2)}},
+		{SetCanaryScale: &v1alpha1.SetCanaryScale{MatchTrafficWeight: true}},
+	}
+	stepIndex := int32(1)
+	rollout.Status.CurrentStepIndex = &stepIndex
+	stableRS := newRS("stable", 10, 10)
+	canaryRS := newRS("canary", 0, 0)
+
+	// the canary is scaled to full size while it receives 5% of the traffic
+	newRSReplicaCount, stableRSReplicaCount := CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(10), newRSReplicaCount)
+	assert.Equal(t, int32(10), stableRSReplicaCount)
+	newRSReplicaCount, stableRSReplicaCount = DesiredReplicaCountsForCanary(rollout, canaryRS, stableRS)
+	assert.Equal(t, int32(10), newRSReplicaCount)
+	assert.Equal(t, int32(10), stableRSReplicaCount)
+
+	stepIndex = 2
+	newRSReplicaCount, _ = CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(2), newRSReplicaCount)
+
+	// matchTrafficWeight scales the canary with the weight again
+	stepIndex = 3
+	newRSReplicaCount, _ = CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(1), newRSReplicaCount)
+
+	// the scale no longer applies once the rollout is aborted
+	stepIndex = 2
+	rollout.Status.Abort = true
+	newRSReplicaCount, _ = CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(0), newRSReplicaCount)
+}
+
+func TestCalculateReplicaCountsForCanaryPartition(t *testing.T) {
+	rollout := newRollout(3, 50, intstr.FromInt(1), intstr.FromInt(0), "canary", "stable")
+	rollout.Spec.Strategy.Canary.Partition = &v1alpha1.PartitionStrategy{}
+	stableRS := newRS("stable", 3, 3)
+	canaryRS := newRS("canary", 0, 0)
+
+	// the stable RS is scaled down first since a partitioned canary does not surge
+	newRSReplicaCount, stableRSReplicaCount := CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(0), newRSReplicaCount)
+	assert.Equal(t, int32(2), stableRSReplicaCount)
+
+	stableRS = newRS("stable", 2, 2)
+	newRSReplicaCount, stableRSReplicaCount = CalculateReplicaCountsForCanary(rollout, canaryRS, stableRS, nil)
+	assert.Equal(t, int32(1), newRSReplicaCount)
+	assert.Equal(t, int32(2), stableRSReplicaCount)
+
+	desiredNewRSReplicaCount, desiredStableRSReplicaCount := DesiredReplicaCountsForCanary(rollout, canaryRS, stableRS)
+	assert.Equal(t, int32(2), desiredNewRSReplicaCount)
+	assert.Equal(t, int32(1), desiredStableRSReplicaCount)
+}
+
 func TestCalculateReplicaCountsForCanaryStableRSdEdgeCases(t *testing.T) {
 	rollout := newRollout(10, 10, intstr.FromInt(0), intstr.FromInt(1), "", "")
 	newRS := newRS("stable", 9, 9)
@@ -520,6 +625,59 @@ func TestGetCurrentSetWeight(t *testing.T) {
 
 }
 
+func TestGetCurrentHeaderRoutes(t *testing.T) {
+	testers := []v1alpha1.HeaderRoutingMatch{{HeaderName: "x-canary", Value: v1alpha1.StringMatch{Exact: "true"}}}
+	beta := []v1alpha1.HeaderRoutingMatch{{CookieName: "beta", Value: v1alpha1.StringMatch{Exact: "always"}}}
+	rollout := newRollout(10, 10, intstr.FromInt(0), intstr.FromInt(1), "", "")
+	rollout.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{
+		{SetHeaderRoute: &v1alpha1.SetHeaderRoute{Name: "testers", Match: testers}},
+		{SetHeaderRoute: &v1alpha1.SetHeaderRoute{Name: "beta", Match: beta}},
+		{SetWeight: pointer.Int32Ptr(50)},
+		{SetHeaderRoute: &v1alpha1.SetHeaderRoute{Name: "testers"}},
+	}
+
+	stepIndex := int32(0)
+	rollout.Status.CurrentStepIndex = &stepIndex
+	assert.Equal(t, []v1alpha1.SetHeaderRoute{{Name: "testers", Match: testers}}, GetCurrentHeaderRoutes(rollout))
+
+	stepIndex = 2
+	assert.Equal(t, []v1alpha1.SetHeaderRoute{{Name: "testers", Match: testers}, {Name: "beta", Match: beta}}, GetCurrentHeaderRoutes(rollout))
+
+	// a route without matches removes the route of an earlier step
+	stepIndex = 3
+	assert.Equal(t, []v1alpha1.SetHeaderRoute{{Name: "beta", Match: beta}}, GetCurrentHeaderRoutes(rollout))
+
+	stepIndex = 4
+	assert.Nil(t, GetCurrentHeaderRoutes(rollout))
+
+	stepIndex = 2
+	rollout.Status.Abort = true
+	assert.Nil(t, GetCurrentHeaderRoutes(rollout))
+}
+
+func TestGetCurrentMirrorPercentage(t *testing.T) {
+	rollout := newRollout(10, 10, intstr.FromInt(0), intstr.FromInt(1), "", "")
+	rollout.Spec.Strategy.Canary.Steps = []v1alpha1.CanaryStep{
+		{SetMirrorRoute: &v1alpha1.SetMirrorRoute{Percentage: 50}},
+		{SetWeight: pointer.Int32Ptr(10)},
+		{SetMirrorRoute: &v1alpha1.SetMirrorRoute{Percentage: 0}},
+	}
+	stepIndex := int32(1)
+	rollout.Status.CurrentStepIndex = &stepIndex
+	assert.Equal(t, int32(50), GetCurrentMirrorPercentage(rollout))
+
+	stepIndex = 2
+	assert.Equal(t, int32(0), GetCurrentMirrorPercentage(rollout))
+
+	stepIndex = 0
+	rollout.Status.Abort = true
+	assert.Equal(t, int32(0), GetCurrentMirrorPercentage(rollout))
+
+	rollout.Status.Abort = false
+	stepIndex = 3
+	assert.Equal(t, int32(0), GetCurrentMirrorPercentage(rollout))
+}
+
 func TestGetCurrentExperiment(t *testing.T) {
 	rollout := &v1alpha1.Rollout{
 		Spec: v1alpha1.RolloutSpec{
@@ -0,0 +1,122 @@
+package replicaset
+
+import (
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+// GetEphemeralMetadata returns the ephemeral metadata of the pods of the ReplicaSet with the pod
+// template hash. The stable ReplicaSet of a canary, or the active ReplicaSet of a blue-green
+// rollout, gets the stable or active metadata of the strategy, and the other ReplicaSets get the
+// canary or preview metadata. The first ReplicaSet of a rollout is about to become stable.
+func GetEphemeralMetadata(rollout *v1alpha1.Rollout, podHash string) *v1alpha1.PodTemplateMetadata {
+	stableHash := GetStablePodHash(rollout)
+	stable := stableHash == "" || stableHash == podHash
+	if blueGreen := rollout.Spec.Strategy.BlueGreen; blueGreen != nil {
+		if stable {
+			return blueGreen.ActiveMetadata
+		}
+		return blueGreen.PreviewMetadata
+	}
+	if canary := rollout.Spec.Strategy.Canary; canary != nil {
+		if stable {
+			return canary.StableMetadata
+		}
+		return canary.CanaryMetadata
+	}
+	return nil
+}
+
+// GetReplicaSetEphemeralMetadata returns the ephemeral metadata injected into the pods of the
+// ReplicaSet, as recorded in its ephemeral metadata annotation
+func GetReplicaSetEphemeralMetadata(rs *appsv1.ReplicaSet) *v1alpha1.PodTemplateMetadata {
+	value, ok := rs.Annotations[annotations.EphemeralMetadataAnnotation]
+	if !ok {
+		return nil
+	}
+	var metadata v1alpha1.PodTemplateMetadata
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return nil
+	}
+	return &metadata
+}
+
+// EphemeralMetadataEqual returns true if both ephemeral metadata hold the same labels and annotations
+func EphemeralMetadataEqual(a, b *v1alpha1.PodTemplateMetadata) bool {
+	if a == nil {
+		a = &v1alpha1.PodTemplateMetadata{}
+	}
+	if b == nil {
+		b = &v1alpha1.PodTemplateMetadata{}
+	}
+	return len(a.Labels) == len(b.Labels) && len(a.Annotations) == len(b.Annotations) &&
+		mapsEqual(a.Labels, b.Labels) && mapsEqual(a.Annotations, b.Annotations)
+}
+
+func mapsEqual(a, b map[string]string) bool {
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncEphemeralPodMetadata removes the ephemeral labels and annotations injected before from the
+// metadata of a pod, or of a pod template, and injects the desired ones. Returns the new metadata.
+func SyncEphemeralPodMetadata(metadata *metav1.ObjectMeta, existing, desired *v1alpha1.PodTemplateMetadata) *metav1.ObjectMeta {
+	metadata = metadata.DeepCopy()
+	if existing != nil {
+		for k := range existing.Labels {
+			delete(metadata.Labels, k)
+		}
+		for k := range existing.Annotations {
+			delete(metadata.Annotations, k)
+		}
+	}
+	if desired != nil {
+		for k, v := range desired.Labels {
+			if metadata.Labels == nil {
+				metadata.Labels = map[string]string{}
+			}
+			metadata.Labels[k] = v
+		}
+		for k, v := range desired.Annotations {
+			if metadata.Annotations == nil {
+				metadata.Annotations = map[string]string{}
+			}
+			metadata.Annotations[k] = v
+		}
+	}
+	return metadata
+}
+
+// SetReplicaSetEphemeralMetadata injects the desired ephemeral metadata into the pod template of the
+// ReplicaSet, in place of the one injected before, and records it in the annotations of the ReplicaSet
+func SetReplicaSetEphemeralMetadata(rs *appsv1.ReplicaSet, desired *v1alpha1.PodTemplateMetadata) {
+	existing := GetReplicaSetEphemeralMetadata(rs)
+	rs.Spec.Template.ObjectMeta = *SyncEphemeralPodMetadata(&rs.Spec.Template.ObjectMeta, existing, desired)
+	if desired == nil || (len(desired.Labels) == 0 && len(desired.Annotations) == 0) {
+		delete(rs.Annotations, annotations.EphemeralMetadataAnnotation)
+		return
+	}
+	value, _ := json.Marshal(desired)
+	if rs.Annotations == nil {
+		rs.Annotations = map[string]string{}
+	}
+	rs.Annotations[annotations.EphemeralMetadataAnnotation] = string(value)
+}
+
+// GetPodTemplateWithoutEphemeralMetadata returns a copy of the pod template of the ReplicaSet
+// without the ephemeral metadata injected by the controller
+func GetPodTemplateWithoutEphemeralMetadata(rs *appsv1.ReplicaSet) *corev1.PodTemplateSpec {
+	template := rs.Spec.Template.DeepCopy()
+	template.ObjectMeta = *SyncEphemeralPodMetadata(&template.ObjectMeta, GetReplicaSetEphemeralMetadata(rs), nil)
+	return template
+}
@@ -0,0 +1,83 @@
+package replicaset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+func TestGetEphemeralMetadata(t *testing.T) {
+	canaryMetadata := &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "canary"}}
+	stableMetadata := &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}}
+	ro := &v1alpha1.Rollout{
+		Spec: v1alpha1.RolloutSpec{
+			Strategy: v1alpha1.RolloutStrategy{
+				Canary: &v1alpha1.CanaryStrategy{
+					CanaryMetadata: canaryMetadata,
+					StableMetadata: stableMetadata,
+				},
+			},
+		},
+	}
+	// the first ReplicaSet becomes stable
+	assert.Equal(t, stableMetadata, GetEphemeralMetadata(ro, "abc123"))
+	ro.Status.Canary.StableRS = "abc123"
+	assert.Equal(t, stableMetadata, GetEphemeralMetadata(ro, "abc123"))
+	assert.Equal(t, canaryMetadata, GetEphemeralMetadata(ro, "def456"))
+
+	activeMetadata := &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "active"}}
+	ro.Spec.Strategy = v1alpha1.RolloutStrategy{
+		BlueGreen: &v1alpha1.BlueGreenStrategy{ActiveMetadata: activeMetadata},
+	}
+	ro.Status.BlueGreen.ActiveSelector = "abc123"
+	assert.Equal(t, activeMetadata, GetEphemeralMetadata(ro, "abc123"))
+	assert.Nil(t, GetEphemeralMetadata(ro, "def456"))
+}
+
+func TestSetReplicaSetEphemeralMetadata(t *testing.T) {
+	rs := &appsv1.ReplicaSet{
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "guestbook"},
+					Annotations: map[string]string{"team": "checkout"},
+				},
+			},
+		},
+	}
+	canaryMetadata := &v1alpha1.PodTemplateMetadata{
+		Labels:      map[string]string{"role": "canary"},
+		Annotations: map[string]string{"canary": "true"},
+	}
+	SetReplicaSetEphemeralMetadata(rs, canaryMetadata)
+	assert.Equal(t, map[string]string{"app": "guestbook", "role": "canary"}, rs.Spec.Template.Labels)
+	assert.Equal(t, map[string]string{"team": "checkout", "canary": "true"}, rs.Spec.Template.Annotations)
+	assert.Equal(t, canaryMetadata, GetReplicaSetEphemeralMetadata(rs))
+	assert.Equal(t, map[string]string{"app": "guestbook"}, GetPodTemplateWithoutEphemeralMetadata(rs).Labels)
+	assert.Equal(t, map[string]string{"team": "checkout"}, GetPodTemplateWithoutEphemeralMetadata(rs).Annotations)
+
+	stableMetadata := &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}}
+	SetReplicaSetEphemeralMetadata(rs, stableMetadata)
+	assert.Equal(t, map[string]string{"app": "guestbook", "role": "stable"}, rs.Spec.Template.Labels)
+	assert.Equal(t, map[string]string{"team": "checkout"}, rs.Spec.Template.Annotations)
+	assert.True(t, EphemeralMetadataEqual(stableMetadata, GetReplicaSetEphemeralMetadata(rs)))
+
+	SetReplicaSetEphemeralMetadata(rs, nil)
+	assert.Equal(t, map[string]string{"app": "guestbook"}, rs.Spec.Template.Labels)
+	_, ok := rs.Annotations[annotations.EphemeralMetadataAnnotation]
+	assert.False(t, ok)
+	assert.Nil(t, GetReplicaSetEphemeralMetadata(rs))
+}
+
+func TestEphemeralMetadataEqual(t *testing.T) {
+	assert.True(t, EphemeralMetadataEqual(nil, &v1alpha1.PodTemplateMetadata{}))
+	assert.True(t, EphemeralMetadataEqual(&v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "canary"}}, &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "canary"}}))
+	assert.False(t, EphemeralMetadataEqual(&v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "canary"}}, &v1alpha1.PodTemplateMetadata{Labels: map[string]string{"role": "stable"}}))
+	assert.False(t, EphemeralMetadataEqual(nil, &v1alpha1.PodTemplateMetadata{Annotations: map[string]string{"canary": "true"}}))
+}
@@ -0,0 +1,64 @@
+package replicaset
+
+import (
+	"encoding/json"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+// PinImages replaces the images of the pod template of the ReplicaSet with the digests they were
+// verified at, so the pods run the verified images even if their tags are pushed again before the
+// images are pulled. The images are mapped to their digests, and the images specified by the
+// rollout are recorded in the annotations of the ReplicaSet.
+func PinImages(rs *appsv1.ReplicaSet, digests map[string]string) {
+	original := map[string]string{}
+	pin := func(containers []corev1.Container) {
+		for i := range containers {
+			image := containers[i].Image
+			digest, ok := digests[image]
+			if !ok || strings.Contains(image, "@") {
+				continue
+			}
+			original[containers[i].Name] = image
+			containers[i].Image = image + "@" + digest
+		}
+	}
+	pin(rs.Spec.Template.Spec.InitContainers)
+	pin(rs.Spec.Template.Spec.Containers)
+	if len(original) == 0 {
+		return
+	}
+	value, _ := json.Marshal(original)
+	if rs.Annotations == nil {
+		rs.Annotations = map[string]string{}
+	}
+	rs.Annotations[annotations.PinnedImagesAnnotation] = string(value)
+}
+
+// GetRolloutPodTemplate returns a copy of the pod template of the ReplicaSet as the rollout
+// specified it, without the ephemeral metadata and the image digests injected by the controller
+func GetRolloutPodTemplate(rs *appsv1.ReplicaSet) *corev1.PodTemplateSpec {
+	template := GetPodTemplateWithoutEphemeralMetadata(rs)
+	value, ok := rs.Annotations[annotations.PinnedImagesAnnotation]
+	if !ok {
+		return template
+	}
+	var original map[string]string
+	if err := json.Unmarshal([]byte(value), &original); err != nil {
+		return template
+	}
+	unpin := func(containers []corev1.Container) {
+		for i := range containers {
+			if image, ok := original[containers[i].Name]; ok {
+				containers[i].Image = image
+			}
+		}
+	}
+	unpin(template.Spec.InitContainers)
+	unpin(template.Spec.Containers)
+	return template
+}
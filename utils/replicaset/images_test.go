@@ -0,0 +1,43 @@
+package replicaset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj/argo-rollouts/utils/annotations"
+)
+
+func TestPinImages(t *testing.T) {
+	rs := &appsv1.ReplicaSet{
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: "registry.example.com/init:v1"}},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "registry.example.com/app:v2"},
+						{Name: "sidecar", Image: "envoyproxy/envoy:v1.14"},
+					},
+				},
+			},
+		},
+	}
+	template := rs.Spec.Template.DeepCopy()
+	PinImages(rs, map[string]string{
+		"registry.example.com/init:v1": "sha256:1111",
+		"registry.example.com/app:v2":  "sha256:2222",
+	})
+	assert.Equal(t, "registry.example.com/init:v1@sha256:1111", rs.Spec.Template.Spec.InitContainers[0].Image)
+	assert.Equal(t, "registry.example.com/app:v2@sha256:2222", rs.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "envoyproxy/envoy:v1.14", rs.Spec.Template.Spec.Containers[1].Image)
+	assert.Equal(t, `{"app":"registry.example.com/app:v2","init":"registry.example.com/init:v1"}`, rs.Annotations[annotations.PinnedImagesAnnotation])
+	assert.Equal(t, template, GetRolloutPodTemplate(rs))
+
+	// images without verified digests are left as is
+	unpinned := &appsv1.ReplicaSet{Spec: appsv1.ReplicaSetSpec{Template: *template.DeepCopy()}}
+	PinImages(unpinned, nil)
+	assert.Nil(t, unpinned.Annotations)
+	assert.Equal(t, template, GetRolloutPodTemplate(unpinned))
+}
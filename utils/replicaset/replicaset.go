@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
@@ -35,7 +37,7 @@ func FindNewReplicaSet(rollout *v1alpha1.Rollout, rsList []*appsv1.ReplicaSet) *
 	rsList = newRSList
 	sort.Sort(controller.ReplicaSetsByCreationTimestamp(rsList))
 	// First, attempt to find the replicaset by the replicaset naming formula
-	replicaSetName := fmt.Sprintf("%s-%s", rollout.Name, controller.ComputeHash(&rollout.Spec.Template, rollout.Status.CollisionCount))
+	replicaSetName := fmt.Sprintf("%s-%s", rollout.Name, ComputePodTemplateHash(rollout))
 	for _, rs := range rsList {
 		if rs.Name == replicaSetName {
 			return rs
@@ -47,7 +49,7 @@ func FindNewReplicaSet(rollout *v1alpha1.Rollout, rsList []*appsv1.ReplicaSet) *
 	// When this (rare) situation arises, we do not want to return nil, since nil is considered a
 	// PodTemplate change, which in turn would triggers an unexpected redeploy of the replicaset.
 	for _, rs := range rsList {
-		if PodTemplateEqualIgnoreHash(&rs.Spec.Template, &rollout.Spec.Template) {
+		if GetReplicaSetCluster(rs) == GetRolloutCluster(rollout) && PodTemplateEqualIgnoreHash(GetRolloutPodTemplate(rs), &rollout.Spec.Template) {
 			logCtx := logutil.WithRollout(rollout)
 			logCtx.Infof("ComputeHash change detected (expected: %s, actual: %s)", replicaSetName, rs.Name)
 			return rs
@@ -130,6 +132,60 @@ func Revision(obj runtime.Object) (int64, error) {
 	return strconv.ParseInt(v, 10, 64)
 }
 
+// IsRollbackWithinWindow returns whether the new ReplicaSet is a previous revision of the rollout,
+// older than the stable ReplicaSet and within the rollback window of the rollout
+func IsRollbackWithinWindow(rollout *v1alpha1.Rollout, newRS, stableRS *appsv1.ReplicaSet, allRSs []*appsv1.ReplicaSet, now time.Time) bool {
+	window := rollout.Spec.RollbackWindow
+	if window == nil || newRS == nil || stableRS == nil || newRS.Name == stableRS.Name {
+		return false
+	}
+	stableRevision, err := Revision(stableRS)
+	if err != nil {
+		return false
+	}
+	// the revision of the new ReplicaSet is bumped once it is rolled back to, so its revision before
+	// the rollback is the last one of its revision history
+	previousRevision, err := Revision(newRS)
+	if err != nil {
+		return false
+	}
+	if previousRevision > stableRevision {
+		history := strings.Split(newRS.Annotations[annotations.RevisionHistoryAnnotation], ",")
+		previousRevision, err = strconv.ParseInt(history[len(history)-1], 10, 64)
+		if err != nil {
+			return false
+		}
+	}
+	if previousRevision <= 0 || previousRevision >= stableRevision {
+		return false
+	}
+	if window.Revisions > 0 && stableRevision-previousRevision > int64(window.Revisions) {
+		return false
+	}
+	if window.Duration != "" {
+		duration, err := window.Duration.Duration()
+		if err != nil {
+			return false
+		}
+		// the previous revision was replaced when the oldest of the ReplicaSets which followed it
+		// was created
+		replacedAt := stableRS.CreationTimestamp.Time
+		for _, rs := range allRSs {
+			revision, err := Revision(rs)
+			if err != nil || rs.Name == newRS.Name || revision <= previousRevision || revision > stableRevision {
+				continue
+			}
+			if rs.CreationTimestamp.Time.Before(replacedAt) {
+				replacedAt = rs.CreationTimestamp.Time
+			}
+		}
+		if now.Sub(replacedAt) > duration {
+			return false
+		}
+	}
+	return true
+}
+
 // FindActiveOrLatest returns the only active or the latest replica set in case there is at most one active
 // replica set. If there are more active replica sets, then we should proportionally scale them.
 func FindActiveOrLatest(newRS *appsv1.ReplicaSet, oldRSs []*appsv1.ReplicaSet) *appsv1.ReplicaSet {
@@ -235,8 +291,14 @@ func MaxUnavailable(rollout *v1alpha1.Rollout) int32 {
 		return int32(0)
 	}
 
+	maxSurge := defaults.GetMaxSurgeOrDefault(rollout)
+	if rollout.Spec.Strategy.Canary.Partition != nil {
+		// a partitioned canary does not surge, so at least one pod has to be unavailable
+		noSurge := intstrutil.FromInt(0)
+		maxSurge = &noSurge
+	}
 	// Error caught by validation
-	_, maxUnavailable, _ := resolveFenceposts(defaults.GetMaxSurgeOrDefault(rollout), defaults.GetMaxUnavailableOrDefault(rollout), rolloutReplicas)
+	_, maxUnavailable, _ := resolveFenceposts(maxSurge, defaults.GetMaxUnavailableOrDefault(rollout), rolloutReplicas)
 	if maxUnavailable > rolloutReplicas {
 		return rolloutReplicas
 	}
@@ -246,7 +308,7 @@ func MaxUnavailable(rollout *v1alpha1.Rollout) int32 {
 // MaxSurge returns the maximum surge pods a rolling deployment can take.
 func MaxSurge(rollout *v1alpha1.Rollout) int32 {
 	rolloutReplicas := defaults.GetReplicasOrDefault(rollout.Spec.Replicas)
-	if rollout.Spec.Strategy.Canary == nil {
+	if rollout.Spec.Strategy.Canary == nil || rollout.Spec.Strategy.Canary.Partition != nil {
 		return int32(0)
 	}
 	// Error caught by validation
@@ -276,7 +338,7 @@ func CheckPodSpecChange(rollout *v1alpha1.Rollout, newRS *appsv1.ReplicaSet) boo
 	if rollout.Status.CurrentPodHash == "" {
 		return false
 	}
-	podHash := controller.ComputeHash(&rollout.Spec.Template, rollout.Status.CollisionCount)
+	podHash := ComputePodTemplateHash(rollout)
 	if newRS != nil {
 		podHash = GetPodTemplateHash(newRS)
 	}
@@ -324,6 +386,8 @@ func PodTemplateEqualIgnoreHash(live, desired *corev1.PodTemplateSpec) bool {
 	// Remove hash labels from template.Labels before comparing
 	delete(live.Labels, v1alpha1.DefaultRolloutUniqueLabelKey)
 	delete(desired.Labels, v1alpha1.DefaultRolloutUniqueLabelKey)
+	// Remove the anti-affinity the controller injects into the pods of new ReplicaSets
+	live.Spec.Affinity = RemoveInjectedAntiAffinityRule(live.Spec.Affinity)
 
 	podTemplate := corev1.PodTemplate{
 		Template: *desired,
@@ -333,6 +397,37 @@ func PodTemplateEqualIgnoreHash(live, desired *corev1.PodTemplateSpec) bool {
 	return apiequality.Semantic.DeepEqual(live, desired)
 }
 
+// ComputePodTemplateHash returns the hash of the pod template of the rollout. The cluster the
+// ReplicaSets of new revisions are created in is part of the hash, so moving the rollout to another
+// cluster creates a new revision, while the hash of rollouts in the cluster of the controller is the
+// hash of their pod template.
+func ComputePodTemplateHash(rollout *v1alpha1.Rollout) string {
+	template := &rollout.Spec.Template
+	if cluster := GetRolloutCluster(rollout); cluster != "" {
+		template = template.DeepCopy()
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[annotations.ClusterAnnotation] = cluster
+	}
+	return controller.ComputeHash(template, rollout.Status.CollisionCount)
+}
+
+// GetRolloutCluster returns the registered cluster the ReplicaSets of new revisions of the rollout
+// are created in, or an empty string for the cluster of the controller
+func GetRolloutCluster(rollout *v1alpha1.Rollout) string {
+	if rollout.Spec.Strategy.Canary == nil {
+		return ""
+	}
+	return rollout.Spec.Strategy.Canary.Cluster
+}
+
+// GetReplicaSetCluster returns the registered cluster the ReplicaSet was created in, or an empty
+// string for the cluster of the controller
+func GetReplicaSetCluster(rs *appsv1.ReplicaSet) string {
+	return rs.Annotations[annotations.ClusterAnnotation]
+}
+
 // GetPodTemplateHash returns the rollouts-pod-template-hash value from a ReplicaSet's labels
 func GetPodTemplateHash(rs *appsv1.ReplicaSet) string {
 	if rs.Labels == nil {
@@ -327,6 +327,46 @@ func TestMaxRevision(t *testing.T) {
 	assert.Equal(t, int64(2), MaxRevision(allRs))
 }
 
+func TestIsRollbackWithinWindow(t *testing.T) {
+	now := time.Now()
+	newRevision := func(name, revision string, created time.Duration) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-created)),
+				Annotations:       map[string]string{annotations.RevisionAnnotation: revision},
+			},
+		}
+	}
+	rs1 := newRevision("rs1", "4", 3*time.Hour)
+	rs1.Annotations[annotations.RevisionHistoryAnnotation] = "1"
+	rs2 := newRevision("rs2", "2", 2*time.Hour)
+	rs3 := newRevision("rs3", "3", 30*time.Minute)
+	allRSs := []*appsv1.ReplicaSet{rs1, rs2, rs3}
+	ro := &v1alpha1.Rollout{}
+	assert.False(t, IsRollbackWithinWindow(ro, rs1, rs3, allRSs, now))
+
+	ro.Spec.RollbackWindow = &v1alpha1.RollbackWindow{Revisions: 2}
+	assert.True(t, IsRollbackWithinWindow(ro, rs1, rs3, allRSs, now))
+	ro.Spec.RollbackWindow.Revisions = 1
+	assert.False(t, IsRollbackWithinWindow(ro, rs1, rs3, allRSs, now))
+
+	// the first revision was replaced when the second one was created
+	ro.Spec.RollbackWindow = &v1alpha1.RollbackWindow{Duration: "1h"}
+	assert.False(t, IsRollbackWithinWindow(ro, rs1, rs3, allRSs, now))
+	ro.Spec.RollbackWindow.Duration = "3h"
+	assert.True(t, IsRollbackWithinWindow(ro, rs1, rs3, allRSs, now))
+
+	// the revision is not bumped yet
+	notBumped := newRevision("rs1", "1", 3*time.Hour)
+	assert.True(t, IsRollbackWithinWindow(ro, notBumped, rs3, []*appsv1.ReplicaSet{notBumped, rs2, rs3}, now))
+
+	// a new revision is not a rollback
+	rs4 := newRevision("rs4", "4", 0)
+	assert.False(t, IsRollbackWithinWindow(ro, rs4, rs3, allRSs, now))
+	assert.False(t, IsRollbackWithinWindow(ro, rs3, rs3, allRSs, now))
+}
+
 func rs(replicas int32, creationTimestamp metav1.Time) *appsv1.ReplicaSet {
 	return &appsv1.ReplicaSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -630,6 +670,22 @@ func TestCheckPodSpecChange(t *testing.T) {
 	assert.True(t, CheckPodSpecChange(&ro, &rs))
 }
 
+func TestComputePodTemplateHash(t *testing.T) {
+	ro := generateRollout("ngnix")
+	hash := controller.ComputeHash(&ro.Spec.Template, ro.Status.CollisionCount)
+	assert.Equal(t, hash, ComputePodTemplateHash(&ro))
+
+	ro.Spec.Strategy.Canary = &v1alpha1.CanaryStrategy{}
+	assert.Equal(t, hash, ComputePodTemplateHash(&ro))
+
+	ro.Spec.Strategy.Canary.Cluster = "east"
+	clusterHash := ComputePodTemplateHash(&ro)
+	assert.NotEqual(t, hash, clusterHash)
+	// the template of the rollout is not modified
+	assert.Empty(t, ro.Spec.Template.Annotations)
+	assert.Equal(t, clusterHash, ComputePodTemplateHash(&ro))
+}
+
 func TestCheckStepHashChange(t *testing.T) {
 	ro := generateRollout("ngnix")
 	assert.False(t, checkStepHashChange(&ro))
@@ -0,0 +1,254 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsSecretsManagerService = "secretsmanager"
+	awsTimeFormat            = "20060102T150405Z"
+	awsDateFormat            = "20060102"
+)
+
+// awsCredentials are the credentials requests to AWS are signed with
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	expires         time.Time
+}
+
+// awsBackend reads secrets named <prefix><namespace>/<name> from AWS Secrets Manager. Credentials
+// are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment
+// variables, or are assumed with the web identity token of IAM roles for service accounts
+// (AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE) and renewed before they expire.
+type awsBackend struct {
+	region      string
+	prefix      string
+	endpoint    string
+	stsEndpoint string
+	client      *http.Client
+	getenv      func(string) string
+	now         func() time.Time
+
+	credentials *awsCredentials
+}
+
+func newAWSBackend(region, prefix, endpoint string, client *http.Client) *awsBackend {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	return &awsBackend{
+		region:      region,
+		prefix:      prefix,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		stsEndpoint: fmt.Sprintf("https://sts.%s.amazonaws.com", region),
+		client:      client,
+		getenv:      os.Getenv,
+		now:         time.Now,
+	}
+}
+
+func (b *awsBackend) getCredentials() (*awsCredentials, error) {
+	if b.credentials != nil && (b.credentials.expires.IsZero() || b.now().Before(b.credentials.expires)) {
+		return b.credentials, nil
+	}
+	if accessKeyID := b.getenv("AWS_ACCESS_KEY_ID"); accessKeyID != "" {
+		b.credentials = &awsCredentials{
+			accessKeyID:     accessKeyID,
+			secretAccessKey: b.getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken:    b.getenv("AWS_SESSION_TOKEN"),
+		}
+		return b.credentials, nil
+	}
+	roleARN, tokenFile := b.getenv("AWS_ROLE_ARN"), b.getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleARN == "" || tokenFile == "" {
+		return nil, fmt.Errorf("no AWS credentials found: set AWS_ACCESS_KEY_ID or use IAM roles for service accounts")
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the web identity token: %v", err)
+	}
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {"argo-rollouts"},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	resp, err := b.client.Get(b.stsEndpoint + "/?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("assuming role '%s' failed with status %s", roleARN, resp.Status)
+	}
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	b.credentials = &awsCredentials{
+		accessKeyID:     result.Credentials.AccessKeyID,
+		secretAccessKey: result.Credentials.SecretAccessKey,
+		sessionToken:    result.Credentials.SessionToken,
+		// renewed a little before they expire
+		expires: result.Credentials.Expiration.Add(-5 * time.Minute),
+	}
+	return b.credentials, nil
+}
+
+func (b *awsBackend) fetch(namespace, name string) (map[string][]byte, error) {
+	creds, err := b.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf("%s%s/%s", b.prefix, namespace, name)
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, b.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, body, creds, b.region, awsSecretsManagerService, b.now())
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest {
+		// missing secrets are reported as a bad request with a ResourceNotFoundException
+		var awsErr struct {
+			Type string `json:"__type"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&awsErr); err == nil && strings.HasSuffix(awsErr.Type, "ResourceNotFoundException") {
+			return nil, notFound(name)
+		}
+		return nil, fmt.Errorf("reading secret '%s' failed with status %s (%s)", id, resp.Status, awsErr.Type)
+	}
+	if err := checkResponse(resp, name); err != nil {
+		return nil, err
+	}
+	var result struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	value := []byte(result.SecretString)
+	if result.SecretString == "" && result.SecretBinary != "" {
+		if value, err = base64.StdEncoding.DecodeString(result.SecretBinary); err != nil {
+			return nil, fmt.Errorf("secret '%s' has invalid binary data: %v", id, err)
+		}
+	}
+	return parseSecretData(id, value)
+}
+
+// AWSSigner signs requests to other AWS APIs with the credentials of the controller, which are read
+// like the ones of the aws secret backend
+type AWSSigner struct {
+	lock    sync.Mutex
+	backend *awsBackend
+}
+
+// NewAWSSigner returns a signer of requests to the AWS APIs of the region
+func NewAWSSigner(region string, client *http.Client) *AWSSigner {
+	return &AWSSigner{backend: newAWSBackend(region, "", "", client)}
+}
+
+// Sign signs the request to the AWS service with AWS Signature Version 4
+func (s *AWSSigner) Sign(req *http.Request, body []byte, service string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	creds, err := s.backend.getCredentials()
+	if err != nil {
+		return err
+	}
+	signAWSRequest(req, body, creds, s.backend.region, service, s.backend.now())
+	return nil
+}
+
+// signAWSRequest signs the request with AWS Signature Version 4
+func signAWSRequest(req *http.Request, body []byte, creds *awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(awsTimeFormat)
+	date := now.Format(awsDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
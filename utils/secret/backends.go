@@ -0,0 +1,249 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+const (
+	// KubernetesBackend reads secrets from the Kubernetes API
+	KubernetesBackend = "kubernetes"
+	// VaultBackend reads secrets from HashiCorp Vault, logging in with Kubernetes auth
+	VaultBackend = "vault"
+	// AWSBackend reads secrets from AWS Secrets Manager
+	AWSBackend = "aws"
+	// GCPBackend reads secrets from GCP Secret Manager
+	GCPBackend = "gcp"
+
+	// DefaultCacheTTL is how long secrets read from an external backend are cached
+	DefaultCacheTTL = 5 * time.Minute
+
+	// serviceAccountTokenPath is the token of the controller's service account, which external
+	// backends exchange for their own credentials
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	backendRequestTimeout = 10 * time.Second
+)
+
+// backend reads the keys of a secret from an external secret store. The keys are stored in the
+// external secret as a JSON object of strings.
+type backend interface {
+	fetch(namespace, name string) (map[string][]byte, error)
+}
+
+// backendSettings is the configuration of the external backend. A new backend is created whenever
+// the settings change.
+type backendSettings struct {
+	backend       string
+	vaultAddress  string
+	vaultRole     string
+	vaultAuthPath string
+	vaultPrefix   string
+	awsRegion     string
+	awsPrefix     string
+	awsEndpoint   string
+	gcpProject    string
+	gcpPrefix     string
+	gcpEndpoint   string
+}
+
+func getBackendSettings() backendSettings {
+	cfg := configutil.Get()
+	return backendSettings{
+		backend:       cfg.GetString(configutil.SecretBackendKey, KubernetesBackend),
+		vaultAddress:  cfg.GetString(configutil.SecretVaultAddressKey, ""),
+		vaultRole:     cfg.GetString(configutil.SecretVaultRoleKey, ""),
+		vaultAuthPath: cfg.GetString(configutil.SecretVaultAuthPathKey, "kubernetes"),
+		vaultPrefix:   cfg.GetString(configutil.SecretVaultPathPrefixKey, "secret/data/argo-rollouts"),
+		awsRegion:     cfg.GetString(configutil.SecretAWSRegionKey, ""),
+		awsPrefix:     cfg.GetString(configutil.SecretAWSPrefixKey, "argo-rollouts/"),
+		awsEndpoint:   cfg.GetString(configutil.SecretAWSEndpointKey, ""),
+		gcpProject:    cfg.GetString(configutil.SecretGCPProjectKey, ""),
+		gcpPrefix:     cfg.GetString(configutil.SecretGCPPrefixKey, "argo-rollouts-"),
+		gcpEndpoint:   cfg.GetString(configutil.SecretGCPEndpointKey, defaultGCPEndpoint),
+	}
+}
+
+func newBackend(settings backendSettings) (backend, error) {
+	client := &http.Client{Timeout: backendRequestTimeout}
+	switch settings.backend {
+	case VaultBackend:
+		if settings.vaultAddress == "" || settings.vaultRole == "" {
+			return nil, fmt.Errorf("the vault secret backend requires '%s' and '%s'", configutil.SecretVaultAddressKey, configutil.SecretVaultRoleKey)
+		}
+		return newVaultBackend(settings.vaultAddress, settings.vaultRole, settings.vaultAuthPath, settings.vaultPrefix, client), nil
+	case AWSBackend:
+		if settings.awsRegion == "" {
+			return nil, fmt.Errorf("the aws secret backend requires '%s'", configutil.SecretAWSRegionKey)
+		}
+		return newAWSBackend(settings.awsRegion, settings.awsPrefix, settings.awsEndpoint, client), nil
+	case GCPBackend:
+		if settings.gcpProject == "" {
+			return nil, fmt.Errorf("the gcp secret backend requires '%s'", configutil.SecretGCPProjectKey)
+		}
+		return newGCPBackend(settings.gcpProject, settings.gcpPrefix, settings.gcpEndpoint, client), nil
+	}
+	return nil, fmt.Errorf("unknown secret backend '%s'", settings.backend)
+}
+
+// cachedSecret is a secret read from an external backend
+type cachedSecret struct {
+	secret  *corev1.Secret
+	fetched time.Time
+}
+
+// fetchCall is a read of a secret from an external backend, which the concurrent reads of the
+// same secret wait for instead of reading it again
+type fetchCall struct {
+	done   chan struct{}
+	secret *corev1.Secret
+	err    error
+}
+
+// backendGetter reads secrets from the configured backend. Secrets of external backends are cached
+// for the cache TTL, so rotated credentials are picked up once their cache entry expires. If the
+// backend cannot be reached when an entry expires, the previous value is served until the backend
+// recovers, since a rotated secret usually keeps working for a while. The lock is not held while
+// a backend is read, so a slow backend only delays the reads of the same secret.
+type backendGetter struct {
+	kubernetes Getter
+	now        func() time.Time
+
+	lock     sync.Mutex
+	settings backendSettings
+	backend  backend
+	cache    map[string]cachedSecret
+	inflight map[string]*fetchCall
+}
+
+func newBackendGetter(kubernetes Getter) *backendGetter {
+	return &backendGetter{
+		kubernetes: kubernetes,
+		now:        time.Now,
+		cache:      map[string]cachedSecret{},
+		inflight:   map[string]*fetchCall{},
+	}
+}
+
+// getBackend returns the backend of the current settings, or nil for Kubernetes secrets
+func (g *backendGetter) getBackend() (backend, error) {
+	settings := getBackendSettings()
+	if settings.backend == KubernetesBackend || settings.backend == "" {
+		return nil, nil
+	}
+	if g.backend == nil || settings != g.settings {
+		b, err := newBackend(settings)
+		if err != nil {
+			return nil, err
+		}
+		g.settings = settings
+		g.backend = b
+		g.cache = map[string]cachedSecret{}
+		g.inflight = map[string]*fetchCall{}
+	}
+	return g.backend, nil
+}
+
+func (g *backendGetter) Get(namespace, name string) (*corev1.Secret, error) {
+	g.lock.Lock()
+	b, err := g.getBackend()
+	if err != nil {
+		g.lock.Unlock()
+		return nil, err
+	}
+	if b == nil {
+		g.lock.Unlock()
+		return g.kubernetes.Get(namespace, name)
+	}
+
+	key := namespace + "/" + name
+	ttl := time.Duration(configutil.Get().GetInt(configutil.SecretCacheTTLSecondsKey, int(DefaultCacheTTL/time.Second))) * time.Second
+	if cached, ok := g.cache[key]; ok && g.now().Sub(cached.fetched) < ttl {
+		g.lock.Unlock()
+		return cached.secret, nil
+	}
+	if call, ok := g.inflight[key]; ok {
+		g.lock.Unlock()
+		<-call.done
+		return call.secret, call.err
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	g.inflight[key] = call
+	backendName := g.settings.backend
+	g.lock.Unlock()
+
+	data, err := b.fetch(namespace, name)
+
+	g.lock.Lock()
+	defer close(call.done)
+	defer g.lock.Unlock()
+	if g.inflight[key] == call {
+		delete(g.inflight, key)
+	}
+	// the result is not cached if the backend was replaced during the read
+	current := g.backend == b
+	if err != nil {
+		if cached, ok := g.cache[key]; ok && current && !k8serrors.IsNotFound(err) {
+			log.Warnf("Failed to refresh secret '%s' from the %s backend, using the cached value: %v", key, backendName, err)
+			call.secret = cached.secret
+			return call.secret, nil
+		}
+		if current {
+			delete(g.cache, key)
+		}
+		call.err = err
+		return nil, err
+	}
+	call.secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       data,
+	}
+	if current {
+		g.cache[key] = cachedSecret{secret: call.secret, fetched: g.now()}
+	}
+	return call.secret, nil
+}
+
+// notFound returns the error returned for secrets missing from a backend, matching the errors of
+// the Kubernetes API
+func notFound(name string) error {
+	return k8serrors.NewNotFound(corev1.Resource("secrets"), name)
+}
+
+// parseSecretData parses the value of an external secret, which is a JSON object of strings
+func parseSecretData(name string, value []byte) (map[string][]byte, error) {
+	var fields map[string]string
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, fmt.Errorf("secret '%s' is not a JSON object of strings: %v", name, err)
+	}
+	return toData(fields), nil
+}
+
+func toData(fields map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(fields))
+	for k, v := range fields {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// checkResponse returns an error for requests which did not succeed
+func checkResponse(resp *http.Response, name string) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return notFound(name)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("reading secret '%s' failed with status %s", name, resp.Status)
+	}
+	return nil
+}
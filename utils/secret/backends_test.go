@@ -0,0 +1,319 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	configutil "github.com/argoproj/argo-rollouts/utils/config"
+)
+
+// fakeBackend returns the token of the secret and counts the reads
+type fakeBackend struct {
+	token string
+	err   error
+	reads int
+}
+
+func (b *fakeBackend) fetch(namespace, name string) (map[string][]byte, error) {
+	b.reads++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return map[string][]byte{"token": []byte(b.token)}, nil
+}
+
+func newFakeBackendGetter(b backend) *backendGetter {
+	g := newBackendGetter(nil)
+	g.settings = getBackendSettings()
+	g.backend = b
+	return g
+}
+
+func TestBackendGetterUsesKubernetesByDefault(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(newSecret("argo-rollouts", "wavefront-api-tokens"))
+	getter := NewGetter(nil, client)
+	secret, err := getter.Get("argo-rollouts", "wavefront-api-tokens")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789", string(secret.Data["token"]))
+}
+
+func TestBackendGetterCache(t *testing.T) {
+	configutil.SetDefaults(map[string]string{
+		configutil.SecretBackendKey:      VaultBackend,
+		configutil.SecretVaultAddressKey: "http://vault:8200",
+		configutil.SecretVaultRoleKey:    "argo-rollouts",
+	})
+	defer configutil.SetDefaults(nil)
+	now := time.Now()
+	b := &fakeBackend{token: "v1"}
+	g := newFakeBackendGetter(b)
+	g.now = func() time.Time { return now }
+
+	secret, err := g.Get("argo-rollouts", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(secret.Data["token"]))
+	assert.Equal(t, "datadog", secret.Name)
+	_, err = g.Get("argo-rollouts", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, b.reads)
+
+	// rotated credentials are read once the entry expires
+	b.token = "v2"
+	now = now.Add(DefaultCacheTTL)
+	secret, err = g.Get("argo-rollouts", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(secret.Data["token"]))
+
+	// the cached value is served while the backend is unavailable
+	b.err = fmt.Errorf("connection refused")
+	now = now.Add(DefaultCacheTTL)
+	secret, err = g.Get("argo-rollouts", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(secret.Data["token"]))
+
+	// but not once the secret is deleted
+	b.err = notFound("datadog")
+	_, err = g.Get("argo-rollouts", "datadog")
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+// slowBackend blocks the reads of the slow secret until it is released
+type slowBackend struct {
+	lock    sync.Mutex
+	reads   map[string]int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) fetch(namespace, name string) (map[string][]byte, error) {
+	b.lock.Lock()
+	b.reads[name]++
+	b.lock.Unlock()
+	if name == "slow" {
+		close(b.started)
+		<-b.release
+	}
+	return map[string][]byte{"token": []byte(name)}, nil
+}
+
+func TestBackendGetterDoesNotBlockOnSlowReads(t *testing.T) {
+	configutil.SetDefaults(map[string]string{
+		configutil.SecretBackendKey:      VaultBackend,
+		configutil.SecretVaultAddressKey: "http://vault:8200",
+		configutil.SecretVaultRoleKey:    "argo-rollouts",
+	})
+	defer configutil.SetDefaults(nil)
+	b := &slowBackend{reads: map[string]int{}, started: make(chan struct{}), release: make(chan struct{})}
+	g := newFakeBackendGetter(b)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 2)
+	get := func(i int) {
+		defer wg.Done()
+		secret, err := g.Get("argo-rollouts", "slow")
+		assert.NoError(t, err)
+		tokens[i] = string(secret.Data["token"])
+	}
+	wg.Add(1)
+	go get(0)
+	<-b.started
+	wg.Add(1)
+	go get(1)
+
+	// other secrets are read while the slow secret is being read
+	secret, err := g.Get("argo-rollouts", "fast")
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", string(secret.Data["token"]))
+
+	// the concurrent reads of the slow secret share a single read of the backend
+	close(b.release)
+	wg.Wait()
+	assert.Equal(t, []string{"slow", "slow"}, tokens)
+	assert.Equal(t, 1, b.reads["slow"])
+}
+
+func TestBackendGetterInvalidSettings(t *testing.T) {
+	configutil.SetDefaults(map[string]string{configutil.SecretBackendKey: VaultBackend})
+	defer configutil.SetDefaults(nil)
+	_, err := NewGetter(nil, k8sfake.NewSimpleClientset()).Get("argo-rollouts", "datadog")
+	assert.EqualError(t, err, "the vault secret backend requires 'secrets.vault.address' and 'secrets.vault.role'")
+}
+
+func TestVaultBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(tokenPath, []byte("sa-token"), 0600))
+
+	logins := 0
+	vaultToken := "s.1"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var login map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&login))
+			assert.Equal(t, map[string]string{"role": "argo-rollouts", "jwt": "sa-token"}, login)
+			logins++
+			_, _ = fmt.Fprintf(w, `{"auth": {"client_token": "%s", "lease_duration": 3600}}`, vaultToken)
+		case "/v1/secret/data/argo-rollouts/default/datadog":
+			if r.Header.Get("X-Vault-Token") != vaultToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"data": {"api-key": "abc", "port": 8126}, "metadata": {"version": 2}}}`))
+		case "/v1/secret/data/argo-rollouts/default/v1":
+			_, _ = w.Write([]byte(`{"data": {"api-key": "def"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	b := newVaultBackend(ts.URL+"/", "argo-rollouts", "kubernetes", "/secret/data/argo-rollouts/", ts.Client())
+	b.tokenPath = tokenPath
+
+	data, err := b.fetch("default", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"api-key": []byte("abc"), "port": []byte("8126")}, data)
+
+	data, err = b.fetch("default", "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "def", string(data["api-key"]))
+	assert.Equal(t, 1, logins)
+
+	// a revoked token is replaced
+	vaultToken = "s.2"
+	_, err = b.fetch("default", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, logins)
+
+	_, err = b.fetch("default", "missing")
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestAWSBackend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20200501/us-west-2/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="), auth)
+		var req map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req["SecretId"] != "argo-rollouts/default/datadog" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"SecretString": "{\"api-key\": \"abc\"}"}`))
+	}))
+	defer ts.Close()
+	b := newAWSBackend("us-west-2", "argo-rollouts/", ts.URL, ts.Client())
+	env := map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_SESSION_TOKEN": "session"}
+	b.getenv = func(key string) string { return env[key] }
+	b.now = func() time.Time { return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	data, err := b.fetch("default", "datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(data["api-key"]))
+
+	_, err = b.fetch("default", "missing")
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestAWSSigner(t *testing.T) {
+	s := NewAWSSigner("us-west-2", http.DefaultClient)
+	env := map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "secret"}
+	s.backend.getenv = func(key string) string { return env[key] }
+	s.backend.now = func() time.Time { return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	req, err := http.NewRequest(http.MethodPost, "https://elasticloadbalancing.us-west-2.amazonaws.com/", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Sign(req, nil, "elasticloadbalancing"))
+	auth := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20200501/us-west-2/elasticloadbalancing/aws4_request, SignedHeaders=host;x-amz-date, Signature="), auth)
+
+	env = map[string]string{}
+	s = NewAWSSigner("us-west-2", http.DefaultClient)
+	s.backend.getenv = func(key string) string { return env[key] }
+	assert.Error(t, s.Sign(req, nil, "elasticloadbalancing"))
+}
+
+func TestAWSBackendWebIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aws")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("web-identity\n"), 0600))
+
+	assumed := 0
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.URL.Query().Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123456789012:role/argo-rollouts", r.URL.Query().Get("RoleArn"))
+		assert.Equal(t, "web-identity", r.URL.Query().Get("WebIdentityToken"))
+		assumed++
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>2020-05-01T13:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+	b := newAWSBackend("us-west-2", "", "", sts.Client())
+	b.stsEndpoint = sts.URL
+	env := map[string]string{"AWS_ROLE_ARN": "arn:aws:iam::123456789012:role/argo-rollouts", "AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile}
+	b.getenv = func(key string) string { return env[key] }
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	creds, err := b.getCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.accessKeyID)
+	assert.Equal(t, "session", creds.sessionToken)
+	_, err = b.getCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, assumed)
+
+	// renewed before they expire
+	now = now.Add(56 * time.Minute)
+	_, err = b.getCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, assumed)
+}
+
+func TestGCPBackend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = w.Write([]byte(`{"access_token": "ya29", "expires_in": 3600}`))
+		case "/v1/projects/my-project/secrets/argo-rollouts-default_datadog_io/versions/latest:access":
+			assert.Equal(t, "Bearer ya29", r.Header.Get("Authorization"))
+			payload := base64.StdEncoding.EncodeToString([]byte(`{"api-key": "abc"}`))
+			_, _ = fmt.Fprintf(w, `{"payload": {"data": "%s"}}`, payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	b := newGCPBackend("my-project", "argo-rollouts-", ts.URL, ts.Client())
+	b.metadataURL = ts.URL
+
+	data, err := b.fetch("default", "datadog.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(data["api-key"]))
+
+	_, err = b.fetch("default", "missing")
+	assert.True(t, k8serrors.IsNotFound(err))
+}
@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGCPEndpoint = "https://secretmanager.googleapis.com"
+	defaultGCPMetadata = "http://metadata.google.internal"
+)
+
+// gcpBackend reads the latest version of the secrets named <prefix><namespace>_<name> from GCP
+// Secret Manager, with dots replaced by underscores since secret IDs may not contain dots. The
+// access token of the controller's service account, such as a Workload Identity service account,
+// is read from the metadata server and renewed before it expires.
+type gcpBackend struct {
+	project     string
+	prefix      string
+	endpoint    string
+	metadataURL string
+	client      *http.Client
+	now         func() time.Time
+
+	token   string
+	expires time.Time
+}
+
+func newGCPBackend(project, prefix, endpoint string, client *http.Client) *gcpBackend {
+	metadataURL := defaultGCPMetadata
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		metadataURL = "http://" + host
+	}
+	return &gcpBackend{
+		project:     project,
+		prefix:      prefix,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		metadataURL: metadataURL,
+		client:      client,
+		now:         time.Now,
+	}
+}
+
+func (b *gcpBackend) getToken() (string, error) {
+	if b.token != "" && b.now().Before(b.expires) {
+		return b.token, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, b.metadataURL+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reading the access token from the metadata server failed with status %s", resp.Status)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	b.token = result.AccessToken
+	b.expires = b.now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return b.token, nil
+}
+
+func (b *gcpBackend) fetch(namespace, name string) (map[string][]byte, error) {
+	token, err := b.getToken()
+	if err != nil {
+		return nil, err
+	}
+	id := strings.Replace(fmt.Sprintf("%s%s_%s", b.prefix, namespace, name), ".", "_", -1)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/latest:access", b.endpoint, b.project, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// the token is refreshed on the next read
+		b.token = ""
+	}
+	if err := checkResponse(resp, name); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("secret '%s' has invalid data: %v", id, err)
+	}
+	return parseSecretData(id, value)
+}
@@ -0,0 +1,46 @@
+package secret
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// Getter returns secrets holding credentials used by the controller, such as metric provider
+// API tokens. Returned secrets may be shared with the cache and must not be modified.
+type Getter interface {
+	Get(namespace, name string) (*corev1.Secret, error)
+}
+
+// kubernetesGetter serves secrets from the informer cache shared by the controllers. The cache is kept
+// up to date by the informer, so rotated credentials are picked up without a restart.
+type kubernetesGetter struct {
+	lister        corelisters.SecretLister
+	kubeclientset kubernetes.Interface
+}
+
+// NewGetter returns a Getter reading secrets from the backend configured in the controller
+// configuration. Kubernetes secrets are served from the secret lister, and secrets not found in the
+// cache, such as secrets outside the namespace watched by the informer, are read from the API
+// server.
+func NewGetter(lister corelisters.SecretLister, kubeclientset kubernetes.Interface) Getter {
+	return newBackendGetter(&kubernetesGetter{
+		lister:        lister,
+		kubeclientset: kubeclientset,
+	})
+}
+
+func (g *kubernetesGetter) Get(namespace, name string) (*corev1.Secret, error) {
+	if g.lister != nil {
+		secret, err := g.lister.Secrets(namespace).Get(name)
+		if err == nil {
+			return secret, nil
+		}
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return g.kubeclientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+}
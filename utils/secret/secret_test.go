@@ -0,0 +1,48 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newSecret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{"token": []byte("123456789")},
+	}
+}
+
+func TestGetterUsesCache(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(newSecret("argo-rollouts", "wavefront-api-tokens")))
+	client := k8sfake.NewSimpleClientset()
+	getter := NewGetter(corelisters.NewSecretLister(indexer), client)
+
+	secret, err := getter.Get("argo-rollouts", "wavefront-api-tokens")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789", string(secret.Data["token"]))
+	assert.Empty(t, client.Actions())
+}
+
+func TestGetterFallsBackToAPIServer(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	client := k8sfake.NewSimpleClientset(newSecret("other", "wavefront-api-tokens"))
+	getter := NewGetter(corelisters.NewSecretLister(indexer), client)
+
+	secret, err := getter.Get("other", "wavefront-api-tokens")
+	assert.NoError(t, err)
+	assert.Equal(t, "wavefront-api-tokens", secret.Name)
+	assert.Len(t, client.Actions(), 1)
+
+	_, err = getter.Get("other", "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultBackend reads secrets from <prefix>/<namespace>/<name> in Vault. The controller logs in with
+// the Kubernetes auth method using its service account token, and logs in again when the Vault
+// token expires or is revoked. Both versions of the KV secrets engine are supported.
+type vaultBackend struct {
+	address   string
+	role      string
+	authPath  string
+	prefix    string
+	tokenPath string
+	client    *http.Client
+	now       func() time.Time
+
+	token   string
+	expires time.Time
+}
+
+func newVaultBackend(address, role, authPath, prefix string, client *http.Client) *vaultBackend {
+	return &vaultBackend{
+		address:   strings.TrimSuffix(address, "/"),
+		role:      role,
+		authPath:  strings.Trim(authPath, "/"),
+		prefix:    strings.Trim(prefix, "/"),
+		tokenPath: serviceAccountTokenPath,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// errVaultForbidden is returned for reads denied by Vault, which happens when the token is revoked
+var errVaultForbidden = fmt.Errorf("vault denied access to the secret")
+
+// login exchanges the service account token for a Vault token
+func (b *vaultBackend) login() error {
+	jwt, err := ioutil.ReadFile(b.tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the service account token: %v", err)
+	}
+	body, err := json.Marshal(map[string]string{"role": b.role, "jwt": string(jwt)})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(fmt.Sprintf("%s/v1/auth/%s/login", b.address, b.authPath), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault login as role '%s' failed with status %s", b.role, resp.Status)
+	}
+	var result struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	b.token = result.Auth.ClientToken
+	b.expires = time.Time{}
+	if result.Auth.LeaseDuration > 0 {
+		// log in again a little before the token expires
+		b.expires = b.now().Add(time.Duration(result.Auth.LeaseDuration) * time.Second * 9 / 10)
+	}
+	return nil
+}
+
+func (b *vaultBackend) fetch(namespace, name string) (map[string][]byte, error) {
+	if b.token == "" || (!b.expires.IsZero() && b.now().After(b.expires)) {
+		if err := b.login(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := b.read(namespace, name)
+	if err == errVaultForbidden {
+		// the token was revoked before its lease expired
+		if err := b.login(); err != nil {
+			return nil, err
+		}
+		data, err = b.read(namespace, name)
+	}
+	return data, err
+}
+
+func (b *vaultBackend) read(namespace, name string) (map[string][]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s/%s/%s", b.address, b.prefix, namespace, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, errVaultForbidden
+	}
+	if err := checkResponse(resp, name); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	fields := result.Data
+	// version 2 of the KV engine nests the keys under data.data next to data.metadata
+	if nested, ok := fields["data"]; ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nil
+			if err := json.Unmarshal(nested, &fields); err != nil {
+				return nil, fmt.Errorf("secret '%s' has invalid data: %v", name, err)
+			}
+		}
+	}
+	values := map[string]string{}
+	for k, raw := range fields {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// keys which are not strings are passed in their JSON encoding
+			value = string(raw)
+		}
+		values[k] = value
+	}
+	return toData(values), nil
+}
@@ -11,12 +11,14 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	experimentutil "github.com/argoproj/argo-rollouts/utils/experiment"
 )
 
 const (
 	openBracket               = "{{"
 	closeBracket              = "}}"
 	experimentPodTemplateHash = "templates.%s.podTemplateHash"
+	experimentServiceName     = "templates.%s.serviceName"
 	experimentAvailableAt     = "experiment.availableAt"
 	experimentEndsAt          = "experiment.finishedAt"
 )
@@ -41,6 +43,9 @@ func ResolveExperimentArgsValue(argTemplate string, ex *v1alpha1.Experiment, tem
 	for _, template := range ex.Spec.Templates {
 		if rs, ok := templateRSs[template.Name]; ok {
 			argsMap[fmt.Sprintf(experimentPodTemplateHash, template.Name)] = rs.Labels[v1alpha1.DefaultRolloutUniqueLabelKey]
+			if serviceName := experimentutil.ServiceNameForTemplate(template, rs); serviceName != "" {
+				argsMap[fmt.Sprintf(experimentServiceName, template.Name)] = serviceName
+			}
 		}
 	}
 	return resolve(t, argsMap)
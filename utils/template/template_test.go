@@ -63,6 +63,13 @@ func TestResolveExperimentArgsValue(t *testing.T) {
 	argValue, err = ResolveExperimentArgsValue("{{experiment.finishedAt}}", ex, rsMap)
 	assert.Nil(t, err)
 	assert.Equal(t, now.Add(1*time.Minute).Format(time.RFC3339), argValue)
+
+	_, err = ResolveExperimentArgsValue("{{templates.test.serviceName}}", ex, rsMap)
+	assert.Error(t, err)
+	ex.Spec.Templates[0].Service = &v1alpha1.TemplateService{Name: "test-svc"}
+	argValue, err = ResolveExperimentArgsValue("{{templates.test.serviceName}}", ex, rsMap)
+	assert.Nil(t, err)
+	assert.Equal(t, "test-svc", argValue)
 }
 
 func TestResolveArgsWithNoSubstitution(t *testing.T) {
@@ -0,0 +1,180 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultServiceName is the service.name resource attribute reported with every span
+	DefaultServiceName = "argo-rollouts"
+	// DefaultFlushInterval is how often buffered spans are sent to the collector
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultMaxQueueSize is the number of spans buffered before new spans are dropped
+	DefaultMaxQueueSize = 2048
+
+	otlpTracesPath = "/v1/traces"
+	// span status codes as defined by the OTLP protocol
+	otlpStatusCodeOk    = 1
+	otlpStatusCodeError = 2
+	// SPAN_KIND_INTERNAL
+	otlpSpanKindInternal = 1
+)
+
+// OTLPExporter batches spans and sends them to an OpenTelemetry collector using the OTLP/HTTP
+// JSON encoding
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+
+	lock  sync.Mutex
+	queue []*Span
+}
+
+// NewOTLPExporter returns an exporter sending spans to the collector at address (e.g.
+// http://otel-collector:4318)
+func NewOTLPExporter(address string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    address + otlpTracesPath,
+		serviceName: DefaultServiceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export buffers the spans until the next flush. Spans are dropped if the buffer is full
+func (e *OTLPExporter) Export(spans []*Span) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.queue)+len(spans) > DefaultMaxQueueSize {
+		return fmt.Errorf("span queue full, dropping %d spans", len(spans))
+	}
+	e.queue = append(e.queue, spans...)
+	return nil
+}
+
+// Run periodically flushes buffered spans until the stop channel is closed
+func (e *OTLPExporter) Run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(DefaultFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			if err := e.Flush(); err != nil {
+				log.Warnf("Failed to flush spans: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := e.Flush(); err != nil {
+				log.Warnf("Failed to flush spans: %v", err)
+			}
+		}
+	}
+}
+
+// Flush sends all buffered spans to the collector
+func (e *OTLPExporter) Flush() error {
+	e.lock.Lock()
+	spans := e.queue
+	e.queue = nil
+	e.lock.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e.buildRequest(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpKeyValue struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope map[string]string `json:"scope"`
+	Spans []otlpSpan        `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   map[string][]otlpKeyValue `json:"resource"`
+	ScopeSpans []otlpScopeSpans          `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: map[string]string{"stringValue": value}}
+}
+
+func (e *OTLPExporter) buildRequest(spans []*Span) otlpRequest {
+	converted := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		status := otlpStatus{Code: otlpStatusCodeOk}
+		if s.Error != "" {
+			status = otlpStatus{Code: otlpStatusCodeError, Message: s.Error}
+		}
+		var attrs []otlpKeyValue
+		for k, v := range s.Attributes {
+			attrs = append(attrs, stringAttribute(k, v))
+		}
+		converted[i] = otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Attributes:        attrs,
+			Status:            status,
+		}
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: map[string][]otlpKeyValue{
+				"attributes": {stringAttribute("service.name", e.serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: map[string]string{"name": DefaultServiceName},
+				Spans: converted,
+			}},
+		}},
+	}
+}
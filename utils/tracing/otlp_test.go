@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExporterFlush(t *testing.T) {
+	var received otlpRequest
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+	}))
+	defer ts.Close()
+
+	e := NewOTLPExporter(ts.URL)
+	// no request is sent when there is nothing buffered
+	assert.NoError(t, e.Flush())
+	assert.Equal(t, "", path)
+
+	assert.NoError(t, e.Export([]*Span{{
+		TraceID:    "0123456789abcdef0123456789abcdef",
+		SpanID:     "0123456789abcdef",
+		Name:       "reconcile rollout",
+		StartTime:  time.Unix(0, 100),
+		EndTime:    time.Unix(0, 200),
+		Attributes: map[string]string{"rollout": "guestbook"},
+		Error:      "failed",
+	}}))
+	assert.NoError(t, e.Flush())
+	assert.Equal(t, "/v1/traces", path)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "reconcile rollout", spans[0].Name)
+	assert.Equal(t, "100", spans[0].StartTimeUnixNano)
+	assert.Equal(t, "200", spans[0].EndTimeUnixNano)
+	assert.Equal(t, otlpStatusCodeError, spans[0].Status.Code)
+	assert.Equal(t, "guestbook", spans[0].Attributes[0].Value["stringValue"])
+	assert.Empty(t, e.queue)
+}
+
+func TestOTLPExporterErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	e := NewOTLPExporter(ts.URL)
+	assert.NoError(t, e.Export([]*Span{{Name: "test"}}))
+	assert.Error(t, e.Flush())
+}
+
+func TestOTLPExporterQueueFull(t *testing.T) {
+	e := NewOTLPExporter("http://localhost")
+	e.queue = make([]*Span, DefaultMaxQueueSize)
+	assert.Error(t, e.Export([]*Span{{Name: "test"}}))
+}
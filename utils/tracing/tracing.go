@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Exporter ships finished spans to a tracing backend
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Span records the timing of a single operation within a reconciliation
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]string
+	Error        string
+}
+
+var (
+	exporterLock sync.RWMutex
+	exporter     Exporter
+
+	// reconcileSpans holds the root span of the reconciliation in progress for an object keyed by
+	// kind and namespace/name. Like reconcile ids, the workqueue guarantees one entry per object.
+	reconcileSpans sync.Map
+)
+
+// SetExporter configures the exporter finished spans are sent to. Tracing is disabled while the
+// exporter is nil
+func SetExporter(e Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporter = e
+}
+
+func getExporter() Exporter {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return exporter
+}
+
+// Enabled returns true if an exporter is configured
+func Enabled() bool {
+	return getExporter() != nil
+}
+
+func objectKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newSpan(traceID, parentSpanID, name string) *Span {
+	return &Span{
+		TraceID:      traceID,
+		SpanID:       newID(8),
+		ParentSpanID: parentSpanID,
+		Name:         name,
+		StartTime:    time.Now(),
+		Attributes:   map[string]string{},
+	}
+}
+
+// StartReconcile starts the root span for a reconciliation of the object. Returns nil if tracing
+// is disabled
+func StartReconcile(kind, namespace, name string) *Span {
+	if !Enabled() {
+		return nil
+	}
+	span := newSpan(newID(16), "", fmt.Sprintf("reconcile %s", kind))
+	span.SetAttribute(kind, name)
+	span.SetAttribute("namespace", namespace)
+	reconcileSpans.Store(objectKey(kind, namespace, name), span)
+	return span
+}
+
+// EndReconcile finishes the root span for a reconciliation of the object
+func EndReconcile(kind, namespace, name string, err error) {
+	key := objectKey(kind, namespace, name)
+	span, ok := reconcileSpans.Load(key)
+	if !ok {
+		return
+	}
+	reconcileSpans.Delete(key)
+	span.(*Span).End(err)
+}
+
+// StartSpan starts a span for an operation performed while reconciling the object. The span is a
+// child of the reconcile span if one is in progress. Returns nil if tracing is disabled
+func StartSpan(kind, namespace, name, operation string) *Span {
+	if !Enabled() {
+		return nil
+	}
+	var span *Span
+	if parent, ok := reconcileSpans.Load(objectKey(kind, namespace, name)); ok {
+		span = newSpan(parent.(*Span).TraceID, parent.(*Span).SpanID, operation)
+	} else {
+		span = newSpan(newID(16), "", operation)
+	}
+	span.SetAttribute(kind, name)
+	span.SetAttribute("namespace", namespace)
+	return span
+}
+
+// SetAttribute adds an attribute to the span. Safe to call on a nil span
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Attributes[key] = value
+}
+
+// End finishes the span and hands it to the exporter. Safe to call on a nil span
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.EndTime = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if e := getExporter(); e != nil {
+		_ = e.Export([]*Span{s})
+	}
+}
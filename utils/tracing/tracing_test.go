@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExporter struct {
+	lock  sync.Mutex
+	spans []*Span
+}
+
+func (f *fakeExporter) Export(spans []*Span) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.spans = append(f.spans, spans...)
+	return nil
+}
+
+func TestDisabled(t *testing.T) {
+	SetExporter(nil)
+	assert.False(t, Enabled())
+	assert.Nil(t, StartReconcile("rollout", "default", "guestbook"))
+	span := StartSpan("rollout", "default", "guestbook", "scale replicaset")
+	assert.Nil(t, span)
+	// nil spans are safe to use
+	span.SetAttribute("foo", "bar")
+	span.End(nil)
+	EndReconcile("rollout", "default", "guestbook", nil)
+}
+
+func TestChildSpans(t *testing.T) {
+	exporter := &fakeExporter{}
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	root := StartReconcile("rollout", "default", "guestbook")
+	child := StartSpan("rollout", "default", "guestbook", "scale replicaset")
+	child.End(errors.New("intentional error"))
+	EndReconcile("rollout", "default", "guestbook", nil)
+
+	assert.Len(t, exporter.spans, 2)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+	assert.Equal(t, "intentional error", child.Error)
+	assert.Equal(t, "guestbook", child.Attributes["rollout"])
+	assert.Equal(t, "", root.Error)
+
+	// span started outside of a reconcile gets its own trace
+	orphan := StartSpan("rollout", "default", "guestbook", "scale replicaset")
+	assert.NotEqual(t, root.TraceID, orphan.TraceID)
+	assert.Equal(t, "", orphan.ParentSpanID)
+}